@@ -0,0 +1,75 @@
+package binance
+
+import (
+	"context"
+	"sync"
+)
+
+// Accounts builds a Client per stored credential for requests that sign with an account other
+// than the active one. Clients are created on first use and share the default client's circuit
+// breaker and clock offset; caches of account-specific data (leverage, brackets) stay per client.
+type Accounts struct {
+	base *Client
+
+	mu      sync.Mutex
+	clients map[string]*accountClient
+}
+
+// accountClient is a cached account Client with the credentials it was built from
+type accountClient struct {
+	client    *Client
+	apiKey    string
+	secretKey string
+	testnet   bool
+}
+
+// NewAccounts returns an empty registry deriving its clients from base
+func NewAccounts(base *Client) *Accounts {
+	return &Accounts{base: base, clients: make(map[string]*accountClient)}
+}
+
+// Client returns the client of credential id, building it on first use or when the credential's
+// keys or network changed since it was built
+func (a *Accounts) Client(id, apiKey, secretKey string, testnet bool) *Client {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if cached, ok := a.clients[id]; ok && cached.apiKey == apiKey && cached.secretKey == secretKey && cached.testnet == testnet {
+		return cached.client
+	}
+	client := a.base.derive()
+	client.SetCredentials(apiKey, secretKey, testnet)
+	a.clients[id] = &accountClient{client: client, apiKey: apiKey, secretKey: secretKey, testnet: testnet}
+	return client
+}
+
+// Remove drops the client of credential id; requests still holding it finish with the old keys
+func (a *Accounts) Remove(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.clients, id)
+}
+
+// Close drops every account client; it matches the lifecycle stop signature
+func (a *Accounts) Close(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.clients = make(map[string]*accountClient)
+	return nil
+}
+
+// derive returns an unconfigured client sharing c's configuration, circuit breaker and clock
+// offset, with caches of its own
+func (c *Client) derive() *Client {
+	client := &Client{
+		Config:  c.Config,
+		retry:   c.retry,
+		breaker: c.breaker,
+		clock:   c.clock,
+	}
+	// A resync must replace this client's SDK clients, not c's
+	client.retry.resync = client.resyncClock
+	client.exchangeInfo = newExchangeInfoCache(client)
+	client.prices = newPriceCache(client)
+	return client
+}
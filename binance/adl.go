@@ -0,0 +1,82 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// GetADLQuantile returns symbol's auto-deleveraging queue position per position side (LONG,
+// SHORT, BOTH, and HEDGE for one-way positions), from 0 to 4 where 4 is the first to be
+// deleveraged. Symbols without a position are missing from Binance's answer and return nil.
+func (c *Client) GetADLQuantile(ctx context.Context, symbol string) (map[string]int, error) {
+	var quantiles map[string]int
+	err := c.retry.do(ctx, "get adl quantile", func() (err error) {
+		// Each attempt is signed with a fresh timestamp
+		quantiles, err = c.getADLQuantile(ctx, symbol)
+		return err
+	})
+	return quantiles, err
+}
+
+// getADLQuantile calls the signed ADL quantile endpoint directly, since go-binance does not
+// implement it
+func (c *Client) getADLQuantile(ctx context.Context, symbol string) (map[string]int, error) {
+	fc := c.Futures()
+	if fc.APIKey == "" || fc.SecretKey == "" {
+		return nil, fmt.Errorf("API keys not configured")
+	}
+
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli()-fc.TimeOffset, 10))
+	params.Set("recvWindow", strconv.FormatInt(c.RecvWindow(ctx), 10))
+	sig, err := signREST(c.EffectiveConfig(), fc.SecretKey, params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	params.Set("signature", sig)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, fc.BaseURL+"/fapi/v1/adlQuantile?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	httpReq.Header.Set("X-MBX-APIKEY", fc.APIKey)
+	resp, err := fc.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ADL quantile: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get ADL quantile: %w", newHTTPError(resp))
+	}
+
+	// A symbol filter returns one object; without it the endpoint returns a list
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	type entry struct {
+		Symbol      string         `json:"symbol"`
+		ADLQuantile map[string]int `json:"adlQuantile"`
+	}
+	var entries []entry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		var single entry
+		if err := json.Unmarshal(raw, &single); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		entries = []entry{single}
+	}
+	for _, e := range entries {
+		if e.Symbol == symbol {
+			return e.ADLQuantile, nil
+		}
+	}
+	return nil, nil
+}
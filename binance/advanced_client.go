@@ -3,21 +3,34 @@ package binance
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
 )
 
-// CreateAdvancedFuturesOrder creates an advanced futures order with all features
+// CreateAdvancedFuturesOrder creates an advanced futures order with all features.
+// Like CreateFuturesOrder, it retries once with a fresh clock offset when Binance rejects the timestamp.
 func (c *Client) CreateAdvancedFuturesOrder(ctx context.Context, req *AdvancedOrderRequest) (*futures.CreateOrderResponse, error) {
-	// Set leverage first if specified
+	var order *futures.CreateOrderResponse
+	err := c.retry.resyncing(ctx, "create order", func(ctx context.Context) (err error) {
+		order, err = c.createAdvancedFuturesOrder(ctx, req)
+		return err
+	})
+	return order, err
+}
+
+// createAdvancedFuturesOrder sets leverage and places the order with the current SDK client
+func (c *Client) createAdvancedFuturesOrder(ctx context.Context, req *AdvancedOrderRequest) (*futures.CreateOrderResponse, error) {
+	// Use one client for the whole operation even if keys rotate meanwhile
+	fc := c.Futures()
+
+	// Set leverage first if specified and not already set
 	if req.Leverage > 1 {
-		_, err := c.FuturesClient.NewChangeLeverageService().
-			Symbol(req.Symbol).
-			Leverage(req.Leverage).
-			Do(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to set leverage: %w", err)
+		if err := c.ensureLeverage(ctx, fc, req.Symbol, req.Leverage); err != nil {
+			return nil, err
 		}
 	}
 
@@ -28,15 +41,18 @@ func (c *Client) CreateAdvancedFuturesOrder(ctx context.Context, req *AdvancedOr
 	}
 
 	// Build order service
-	orderService := c.FuturesClient.NewCreateOrderService().
+	orderService := fc.NewCreateOrderService().
 		Symbol(req.Symbol).
 		Side(c.convertSide(req.Side)).
-		Type(orderType).
-		Quantity(fmt.Sprintf("%.8f", req.Quantity))
+		Type(orderType)
+	// Binance rejects a quantity next to closePosition
+	if !req.ClosePosition {
+		orderService = orderService.Quantity(req.Quantity.String())
+	}
 
 	// Set price for limit orders
-	if orderType == futures.OrderTypeLimit && req.Price > 0 {
-		orderService = orderService.Price(fmt.Sprintf("%.8f", req.Price))
+	if orderType == futures.OrderTypeLimit && req.Price.IsPositive() {
+		orderService = orderService.Price(req.Price.String())
 		
 		// Set TimeInForce
 		if req.TimeInForce != "" {
@@ -47,8 +63,8 @@ func (c *Client) CreateAdvancedFuturesOrder(ctx context.Context, req *AdvancedOr
 	}
 
 	// Set stop price for stop orders
-	if req.StopPrice > 0 {
-		orderService = orderService.StopPrice(fmt.Sprintf("%.8f", req.StopPrice))
+	if req.StopPrice.IsPositive() {
+		orderService = orderService.StopPrice(req.StopPrice.String())
 	}
 
 	// Set working type for stop orders
@@ -57,13 +73,13 @@ func (c *Client) CreateAdvancedFuturesOrder(ctx context.Context, req *AdvancedOr
 	}
 
 	// Set activation price for trailing stop
-	if req.ActivationPrice > 0 {
-		orderService = orderService.ActivationPrice(fmt.Sprintf("%.8f", req.ActivationPrice))
+	if req.ActivationPrice.IsPositive() {
+		orderService = orderService.ActivationPrice(req.ActivationPrice.String())
 	}
 
 	// Set callback rate for trailing stop
 	if req.CallbackRate > 0 {
-		orderService = orderService.CallbackRate(fmt.Sprintf("%.8f", req.CallbackRate))
+		orderService = orderService.CallbackRate(formatDecimal(req.CallbackRate))
 	}
 
 	// Set position side
@@ -81,10 +97,17 @@ func (c *Client) CreateAdvancedFuturesOrder(ctx context.Context, req *AdvancedOr
 		orderService = orderService.ClosePosition(req.ClosePosition)
 	}
 
+	// The client order ID lets a failed placement be looked up before it is retried
+	if req.ClientOrderID != "" {
+		orderService = orderService.NewClientOrderID(req.ClientOrderID)
+	}
+
 	// Note: STP, PriceMatch, NewOrderRespType, GoodTillDate may not be available in library
 	// These would need to be added via direct HTTP calls if library doesn't support them
 
-	order, err := orderService.Do(ctx)
+	order, err := c.retry.placeOrder(ctx, fc, req.Symbol, req.ClientOrderID, func() (*futures.CreateOrderResponse, error) {
+		return orderService.Do(ctx, c.futuresRecvWindow(ctx))
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create futures order: %w", err)
 	}
@@ -102,27 +125,70 @@ func (c *Client) ModifyFuturesOrder(ctx context.Context, req *ModifyOrderRequest
 	return nil, fmt.Errorf("order modification requires direct HTTP implementation with proper signing. Please use cancel and recreate for now.")
 }
 
-// CreateBatchOrders creates multiple orders at once using direct HTTP
-func (c *Client) CreateBatchOrders(ctx context.Context, orders []*AdvancedOrderRequest) ([]*futures.CreateOrderResponse, error) {
-	// Batch orders require direct HTTP implementation
-	// For now, create orders sequentially
-	var responses []*futures.CreateOrderResponse
-	var errors []string
+// BatchOrderError is returned by CreateBatchOrders when some of the orders failed
+type BatchOrderError struct {
+	Errors []error // by request index; nil for orders that were placed
+}
 
-	for _, req := range orders {
-		order, err := c.CreateAdvancedFuturesOrder(ctx, req)
+func (e *BatchOrderError) Error() string {
+	var msgs []string
+	for i, err := range e.Errors {
 		if err != nil {
-			errors = append(errors, fmt.Sprintf("Order failed: %v", err))
-			continue
+			msgs = append(msgs, fmt.Sprintf("order %d: %v", i, err))
+		}
+	}
+	return fmt.Sprintf("%d of %d orders failed: %s", len(msgs), len(e.Errors), strings.Join(msgs, "; "))
+}
+
+func (e *BatchOrderError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
 		}
-		responses = append(responses, order)
 	}
+	return errs
+}
 
-	if len(errors) > 0 && len(responses) == 0 {
-		return nil, fmt.Errorf("all orders failed: %v", errors)
+// CreateBatchOrders places the orders concurrently, at most BATCH_ORDER_CONCURRENCY at a time.
+// The native batch endpoint is not used because it cannot set each order's leverage. The
+// responses keep the request order, with nil for orders that failed; if any failed the error
+// is a *BatchOrderError, and only when all of them failed are no responses returned.
+func (c *Client) CreateBatchOrders(ctx context.Context, orders []*AdvancedOrderRequest) ([]*futures.CreateOrderResponse, error) {
+	workers := c.Config.BatchOrderConcurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	responses := make([]*futures.CreateOrderResponse, len(orders))
+	errs := make([]error, len(orders))
+
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, req := range orders {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, req *AdvancedOrderRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			responses[i], errs[i] = c.CreateAdvancedFuturesOrder(ctx, req)
+		}(i, req)
 	}
+	wg.Wait()
 
-	return responses, nil
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	switch {
+	case failed == 0:
+		return responses, nil
+	case failed == len(orders):
+		return nil, &BatchOrderError{Errors: errs}
+	default:
+		return responses, &BatchOrderError{Errors: errs}
+	}
 }
 
 // CancelBatchOrders cancels multiple orders
@@ -131,10 +197,14 @@ func (c *Client) CancelBatchOrders(ctx context.Context, symbol string, orderIDs
 	var responses []*futures.CancelOrderResponse
 
 	for _, orderID := range orderIDs {
-		resp, err := c.FuturesClient.NewCancelOrderService().
-			Symbol(symbol).
-			OrderID(orderID).
-			Do(ctx)
+		var resp *futures.CancelOrderResponse
+		err := c.retry.do(ctx, "cancel order", func() (err error) {
+			resp, err = c.Futures().NewCancelOrderService().
+				Symbol(symbol).
+				OrderID(orderID).
+				Do(ctx, c.futuresRecvWindow(ctx))
+			return err
+		})
 		if err != nil {
 			continue
 		}
@@ -142,10 +212,14 @@ func (c *Client) CancelBatchOrders(ctx context.Context, symbol string, orderIDs
 	}
 
 	for _, clientOrderID := range clientOrderIDs {
-		resp, err := c.FuturesClient.NewCancelOrderService().
-			Symbol(symbol).
-			OrigClientOrderID(clientOrderID).
-			Do(ctx)
+		var resp *futures.CancelOrderResponse
+		err := c.retry.do(ctx, "cancel order", func() (err error) {
+			resp, err = c.Futures().NewCancelOrderService().
+				Symbol(symbol).
+				OrigClientOrderID(clientOrderID).
+				Do(ctx, c.futuresRecvWindow(ctx))
+			return err
+		})
 		if err != nil {
 			continue
 		}
@@ -236,35 +310,35 @@ func (c *Client) convertPositionSide(ps string) futures.PositionSideType {
 
 // Request types
 type AdvancedOrderRequest struct {
-	Symbol                string
-	Side                  string
-	OrderType             string
-	Quantity              float64
-	Price                 float64
-	StopPrice             float64
-	ActivationPrice       float64
-	CallbackRate          float64
-	Leverage              int
-	PositionSide          string
-	TimeInForce           string
-	WorkingType           string
-	ReduceOnly            bool
-	ClosePosition         bool
+	Symbol                  string
+	Side                    string
+	OrderType               string
+	Quantity                decimal.Decimal
+	Price                   decimal.Decimal
+	StopPrice               decimal.Decimal
+	ActivationPrice         decimal.Decimal
+	CallbackRate            float64
+	Leverage                int
+	PositionSide            string
+	TimeInForce             string
+	WorkingType             string
+	ReduceOnly              bool
+	ClosePosition           bool
 	SelfTradePreventionMode string
-	PriceMatch            string
-	NewOrderRespType      string
-	ClientOrderID         string
-	GoodTillDate          *time.Time
+	PriceMatch              string
+	NewOrderRespType        string
+	ClientOrderID           string
+	GoodTillDate            *time.Time
 }
 
 type ModifyOrderRequest struct {
-	Symbol         string
-	OrderID        int64
-	ClientOrderID  string
-	Quantity       float64
-	Price          float64
-	StopPrice      float64
-	ActivationPrice float64
-	CallbackRate   float64
-	PriceMatch     string
+	Symbol          string
+	OrderID         int64
+	ClientOrderID   string
+	Quantity        decimal.Decimal
+	Price           decimal.Decimal
+	StopPrice       decimal.Decimal
+	ActivationPrice decimal.Decimal
+	CallbackRate    float64
+	PriceMatch      string
 }
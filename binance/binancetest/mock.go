@@ -0,0 +1,542 @@
+// Package binancetest provides a programmable in-memory stand-in for the Binance client.
+package binancetest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/config"
+
+	spot "github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/delivery"
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+)
+
+// Call records a single invocation of a mock method
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// MockClient implements the trading service's Binance interface without network access.
+// Set the *Func fields to program responses; unset methods succeed with zero values.
+// Every call is recorded and can be inspected with Calls / CallsTo.
+type MockClient struct {
+	Config        *config.Config
+	FuturesClient *futures.Client
+	OptionsClient *binance.OptionsClient
+	// CircuitBreaker is returned by Breaker; nil lets every request through
+	CircuitBreaker *binance.CircuitBreaker
+
+	CreateFuturesOrderFunc         func(ctx context.Context, symbol string, side futures.SideType, orderType futures.OrderType, quantity, price decimal.Decimal, leverage int, clientOrderID string) (*futures.CreateOrderResponse, error)
+	CreateAdvancedFuturesOrderFunc func(ctx context.Context, req *binance.AdvancedOrderRequest) (*futures.CreateOrderResponse, error)
+	ModifyFuturesOrderFunc         func(ctx context.Context, req *binance.ModifyOrderRequest) (*futures.CreateOrderResponse, error)
+	CreateBatchOrdersFunc          func(ctx context.Context, orders []*binance.AdvancedOrderRequest) ([]*futures.CreateOrderResponse, error)
+	CancelBatchOrdersFunc          func(ctx context.Context, symbol string, orderIDs []int64, clientOrderIDs []string) ([]*futures.CancelOrderResponse, error)
+	GetFuturesOrderFunc            func(ctx context.Context, symbol string, orderID int64) (*futures.Order, error)
+	ListOpenFuturesOrdersFunc      func(ctx context.Context, symbol string) ([]*futures.Order, error)
+	CreateDeliveryOrderFunc        func(ctx context.Context, req *binance.AdvancedOrderRequest) (*delivery.CreateOrderResponse, error)
+	CancelDeliveryOrdersFunc       func(ctx context.Context, symbol string, orderIDs []int64, clientOrderIDs []string) ([]*delivery.CancelOrderResponse, error)
+	GetDeliveryOrderFunc           func(ctx context.Context, symbol string, orderID int64) (*delivery.Order, error)
+	ListOpenDeliveryOrdersFunc     func(ctx context.Context, symbol string) ([]*delivery.Order, error)
+	GetDeliveryAccountFunc         func(ctx context.Context) (*delivery.Account, error)
+	GetDeliveryBalanceFunc         func(ctx context.Context) ([]*delivery.Balance, error)
+	GetDeliveryPositionsFunc       func(ctx context.Context) ([]*delivery.PositionRisk, error)
+	GetContractSizeFunc            func(ctx context.Context, symbol string) (float64, error)
+	CreateSpotOrderFunc            func(ctx context.Context, req *binance.SpotOrderRequest) (*spot.CreateOrderResponse, error)
+	GetSpotOrderFunc               func(ctx context.Context, symbol string, orderID int64) (*spot.Order, error)
+	ListOpenSpotOrdersFunc         func(ctx context.Context, symbol string) ([]*spot.Order, error)
+	GetSpotBalancesFunc            func(ctx context.Context) ([]spot.Balance, error)
+	GetSpotSymbolFiltersFunc       func(ctx context.Context, symbol string) (*binance.SymbolFilters, error)
+	RefreshExchangeInfoFunc        func(ctx context.Context, markets ...string) ([]binance.ExchangeInfoStatus, error)
+	ExchangeInfoStatusFunc         func() []binance.ExchangeInfoStatus
+	GetPriceFunc                   func(ctx context.Context, symbol string, source futures.WorkingType, maxAge time.Duration) (binance.Price, error)
+	SubscribePricesFunc            func(ctx context.Context, handle func(updates []binance.PriceUpdate)) error
+	SubscribeSymbolsFunc           func(ctx context.Context, symbols []string, handle func(update *binance.SymbolUpdate)) error
+	UniversalTransferFunc          func(ctx context.Context, transferType, asset string, amount float64) (int64, error)
+	ListUniversalTransfersFunc     func(ctx context.Context, transferType string, start, end time.Time) ([]*binance.TransferRecord, error)
+	GetFuturesAccountFunc          func(ctx context.Context) (*futures.Account, error)
+	GetFuturesPositionsFunc        func(ctx context.Context) ([]*futures.PositionRisk, error)
+	GetADLQuantileFunc             func(ctx context.Context, symbol string) (map[string]int, error)
+	GetFundingRateFunc             func(ctx context.Context, symbol string) (*binance.FundingRate, error)
+	GetIncomeHistoryFunc           func(ctx context.Context, start time.Time) ([]*futures.IncomeHistory, error)
+	GetKlinesFunc                  func(ctx context.Context, symbol, interval string, start, end time.Time) ([]*futures.Kline, error)
+	GetLeverageBracketsFunc        func(ctx context.Context, symbol string) ([]futures.Bracket, error)
+	ChangeLeverageFunc             func(ctx context.Context, symbol string, leverage int) error
+	ChangeMarginTypeFunc           func(ctx context.Context, symbol, marginType string) error
+	GetSymbolFiltersFunc           func(ctx context.Context, symbol string) (*binance.SymbolFilters, error)
+	SetPositionModeFunc            func(ctx context.Context, dualSide bool) error
+	GetPositionModeFunc            func(ctx context.Context) (bool, error)
+	ValidateAPIKeysFunc            func(ctx context.Context, apiKey, secretKey string, testnet bool) error
+	PingFunc                       func(ctx context.Context) error
+	CheckServerTimeFunc            func(ctx context.Context) (*binance.ServerTimeCheck, error)
+
+	mu        sync.Mutex
+	calls     []Call
+	apiKey    string
+	secretKey string
+	testnet   bool
+	offsetMs  int64
+}
+
+// NewMockClient returns a mock using cfg for EffectiveConfig; cfg may be nil
+func NewMockClient(cfg *config.Config) *MockClient {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	return &MockClient{
+		Config:    cfg,
+		apiKey:    cfg.BinanceAPIKey,
+		secretKey: cfg.BinanceSecretKey,
+		testnet:   cfg.BinanceTestnet,
+	}
+}
+
+func (m *MockClient) record(method string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+}
+
+// Calls returns a copy of every recorded call in order
+func (m *MockClient) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	calls := make([]Call, len(m.calls))
+	copy(calls, m.calls)
+	return calls
+}
+
+// CallsTo returns the recorded calls of a single method
+func (m *MockClient) CallsTo(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var calls []Call
+	for _, call := range m.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset clears the recorded calls
+func (m *MockClient) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+func (m *MockClient) CreateFuturesOrder(ctx context.Context, symbol string, side futures.SideType, orderType futures.OrderType, quantity, price decimal.Decimal, leverage int, clientOrderID string) (*futures.CreateOrderResponse, error) {
+	m.record("CreateFuturesOrder", symbol, side, orderType, quantity, price, leverage, clientOrderID)
+	if m.CreateFuturesOrderFunc != nil {
+		return m.CreateFuturesOrderFunc(ctx, symbol, side, orderType, quantity, price, leverage, clientOrderID)
+	}
+	return &futures.CreateOrderResponse{Symbol: symbol, Side: side, Type: orderType, ClientOrderID: clientOrderID, Status: futures.OrderStatusTypeNew}, nil
+}
+
+func (m *MockClient) CreateAdvancedFuturesOrder(ctx context.Context, req *binance.AdvancedOrderRequest) (*futures.CreateOrderResponse, error) {
+	m.record("CreateAdvancedFuturesOrder", req)
+	if m.CreateAdvancedFuturesOrderFunc != nil {
+		return m.CreateAdvancedFuturesOrderFunc(ctx, req)
+	}
+	return &futures.CreateOrderResponse{Symbol: req.Symbol, ClientOrderID: req.ClientOrderID, Status: futures.OrderStatusTypeNew}, nil
+}
+
+func (m *MockClient) ModifyFuturesOrder(ctx context.Context, req *binance.ModifyOrderRequest) (*futures.CreateOrderResponse, error) {
+	m.record("ModifyFuturesOrder", req)
+	if m.ModifyFuturesOrderFunc != nil {
+		return m.ModifyFuturesOrderFunc(ctx, req)
+	}
+	return &futures.CreateOrderResponse{Symbol: req.Symbol, OrderID: req.OrderID, ClientOrderID: req.ClientOrderID}, nil
+}
+
+func (m *MockClient) CreateBatchOrders(ctx context.Context, orders []*binance.AdvancedOrderRequest) ([]*futures.CreateOrderResponse, error) {
+	m.record("CreateBatchOrders", orders)
+	if m.CreateBatchOrdersFunc != nil {
+		return m.CreateBatchOrdersFunc(ctx, orders)
+	}
+	responses := make([]*futures.CreateOrderResponse, 0, len(orders))
+	for _, order := range orders {
+		responses = append(responses, &futures.CreateOrderResponse{Symbol: order.Symbol, ClientOrderID: order.ClientOrderID, Status: futures.OrderStatusTypeNew})
+	}
+	return responses, nil
+}
+
+func (m *MockClient) CancelBatchOrders(ctx context.Context, symbol string, orderIDs []int64, clientOrderIDs []string) ([]*futures.CancelOrderResponse, error) {
+	m.record("CancelBatchOrders", symbol, orderIDs, clientOrderIDs)
+	if m.CancelBatchOrdersFunc != nil {
+		return m.CancelBatchOrdersFunc(ctx, symbol, orderIDs, clientOrderIDs)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetFuturesOrder(ctx context.Context, symbol string, orderID int64) (*futures.Order, error) {
+	m.record("GetFuturesOrder", symbol, orderID)
+	if m.GetFuturesOrderFunc != nil {
+		return m.GetFuturesOrderFunc(ctx, symbol, orderID)
+	}
+	return &futures.Order{Symbol: symbol, OrderID: orderID}, nil
+}
+
+func (m *MockClient) ListOpenFuturesOrders(ctx context.Context, symbol string) ([]*futures.Order, error) {
+	m.record("ListOpenFuturesOrders", symbol)
+	if m.ListOpenFuturesOrdersFunc != nil {
+		return m.ListOpenFuturesOrdersFunc(ctx, symbol)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) CreateDeliveryOrder(ctx context.Context, req *binance.AdvancedOrderRequest) (*delivery.CreateOrderResponse, error) {
+	m.record("CreateDeliveryOrder", req)
+	if m.CreateDeliveryOrderFunc != nil {
+		return m.CreateDeliveryOrderFunc(ctx, req)
+	}
+	return &delivery.CreateOrderResponse{Symbol: req.Symbol, ClientOrderID: req.ClientOrderID, Status: delivery.OrderStatusTypeNew}, nil
+}
+
+func (m *MockClient) CancelDeliveryOrders(ctx context.Context, symbol string, orderIDs []int64, clientOrderIDs []string) ([]*delivery.CancelOrderResponse, error) {
+	m.record("CancelDeliveryOrders", symbol, orderIDs, clientOrderIDs)
+	if m.CancelDeliveryOrdersFunc != nil {
+		return m.CancelDeliveryOrdersFunc(ctx, symbol, orderIDs, clientOrderIDs)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetDeliveryOrder(ctx context.Context, symbol string, orderID int64) (*delivery.Order, error) {
+	m.record("GetDeliveryOrder", symbol, orderID)
+	if m.GetDeliveryOrderFunc != nil {
+		return m.GetDeliveryOrderFunc(ctx, symbol, orderID)
+	}
+	return &delivery.Order{Symbol: symbol, OrderID: orderID}, nil
+}
+
+func (m *MockClient) ListOpenDeliveryOrders(ctx context.Context, symbol string) ([]*delivery.Order, error) {
+	m.record("ListOpenDeliveryOrders", symbol)
+	if m.ListOpenDeliveryOrdersFunc != nil {
+		return m.ListOpenDeliveryOrdersFunc(ctx, symbol)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetDeliveryAccount(ctx context.Context) (*delivery.Account, error) {
+	m.record("GetDeliveryAccount")
+	if m.GetDeliveryAccountFunc != nil {
+		return m.GetDeliveryAccountFunc(ctx)
+	}
+	return &delivery.Account{}, nil
+}
+
+func (m *MockClient) GetDeliveryBalance(ctx context.Context) ([]*delivery.Balance, error) {
+	m.record("GetDeliveryBalance")
+	if m.GetDeliveryBalanceFunc != nil {
+		return m.GetDeliveryBalanceFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetDeliveryPositions(ctx context.Context) ([]*delivery.PositionRisk, error) {
+	m.record("GetDeliveryPositions")
+	if m.GetDeliveryPositionsFunc != nil {
+		return m.GetDeliveryPositionsFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetContractSize(ctx context.Context, symbol string) (float64, error) {
+	m.record("GetContractSize", symbol)
+	if m.GetContractSizeFunc != nil {
+		return m.GetContractSizeFunc(ctx, symbol)
+	}
+	return 100, nil
+}
+
+func (m *MockClient) CreateSpotOrder(ctx context.Context, req *binance.SpotOrderRequest) (*spot.CreateOrderResponse, error) {
+	m.record("CreateSpotOrder", req)
+	if m.CreateSpotOrderFunc != nil {
+		return m.CreateSpotOrderFunc(ctx, req)
+	}
+	return &spot.CreateOrderResponse{Symbol: req.Symbol, ClientOrderID: req.ClientOrderID, Status: spot.OrderStatusTypeNew}, nil
+}
+
+func (m *MockClient) GetSpotOrder(ctx context.Context, symbol string, orderID int64) (*spot.Order, error) {
+	m.record("GetSpotOrder", symbol, orderID)
+	if m.GetSpotOrderFunc != nil {
+		return m.GetSpotOrderFunc(ctx, symbol, orderID)
+	}
+	return &spot.Order{Symbol: symbol, OrderID: orderID}, nil
+}
+
+func (m *MockClient) ListOpenSpotOrders(ctx context.Context, symbol string) ([]*spot.Order, error) {
+	m.record("ListOpenSpotOrders", symbol)
+	if m.ListOpenSpotOrdersFunc != nil {
+		return m.ListOpenSpotOrdersFunc(ctx, symbol)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetSpotBalances(ctx context.Context) ([]spot.Balance, error) {
+	m.record("GetSpotBalances")
+	if m.GetSpotBalancesFunc != nil {
+		return m.GetSpotBalancesFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) UniversalTransfer(ctx context.Context, transferType, asset string, amount float64) (int64, error) {
+	m.record("UniversalTransfer", transferType, asset, amount)
+	if m.UniversalTransferFunc != nil {
+		return m.UniversalTransferFunc(ctx, transferType, asset, amount)
+	}
+	return 1, nil
+}
+
+func (m *MockClient) ListUniversalTransfers(ctx context.Context, transferType string, start, end time.Time) ([]*binance.TransferRecord, error) {
+	m.record("ListUniversalTransfers", transferType, start, end)
+	if m.ListUniversalTransfersFunc != nil {
+		return m.ListUniversalTransfersFunc(ctx, transferType, start, end)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetFuturesAccount(ctx context.Context) (*futures.Account, error) {
+	m.record("GetFuturesAccount")
+	if m.GetFuturesAccountFunc != nil {
+		return m.GetFuturesAccountFunc(ctx)
+	}
+	return &futures.Account{}, nil
+}
+
+func (m *MockClient) GetFuturesPositions(ctx context.Context) ([]*futures.PositionRisk, error) {
+	m.record("GetFuturesPositions")
+	if m.GetFuturesPositionsFunc != nil {
+		return m.GetFuturesPositionsFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetADLQuantile(ctx context.Context, symbol string) (map[string]int, error) {
+	m.record("GetADLQuantile", symbol)
+	if m.GetADLQuantileFunc != nil {
+		return m.GetADLQuantileFunc(ctx, symbol)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetFundingRate(ctx context.Context, symbol string) (*binance.FundingRate, error) {
+	m.record("GetFundingRate", symbol)
+	if m.GetFundingRateFunc != nil {
+		return m.GetFundingRateFunc(ctx, symbol)
+	}
+	return &binance.FundingRate{Symbol: symbol}, nil
+}
+
+func (m *MockClient) GetIncomeHistory(ctx context.Context, start time.Time) ([]*futures.IncomeHistory, error) {
+	m.record("GetIncomeHistory", start)
+	if m.GetIncomeHistoryFunc != nil {
+		return m.GetIncomeHistoryFunc(ctx, start)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetKlines(ctx context.Context, symbol, interval string, start, end time.Time) ([]*futures.Kline, error) {
+	m.record("GetKlines", symbol, interval, start, end)
+	if m.GetKlinesFunc != nil {
+		return m.GetKlinesFunc(ctx, symbol, interval, start, end)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) GetLeverageBrackets(ctx context.Context, symbol string) ([]futures.Bracket, error) {
+	m.record("GetLeverageBrackets", symbol)
+	if m.GetLeverageBracketsFunc != nil {
+		return m.GetLeverageBracketsFunc(ctx, symbol)
+	}
+	return []futures.Bracket{{Bracket: 1, InitialLeverage: 125, NotionalCap: 50000, MaintMarginRatio: 0.004}}, nil
+}
+
+func (m *MockClient) ChangeLeverage(ctx context.Context, symbol string, leverage int) error {
+	m.record("ChangeLeverage", symbol, leverage)
+	if m.ChangeLeverageFunc != nil {
+		return m.ChangeLeverageFunc(ctx, symbol, leverage)
+	}
+	return nil
+}
+
+func (m *MockClient) ChangeMarginType(ctx context.Context, symbol, marginType string) error {
+	m.record("ChangeMarginType", symbol, marginType)
+	if m.ChangeMarginTypeFunc != nil {
+		return m.ChangeMarginTypeFunc(ctx, symbol, marginType)
+	}
+	return nil
+}
+
+func (m *MockClient) LeverageCacheStats() binance.LeverageCacheStats {
+	return binance.LeverageCacheStats{}
+}
+
+func (m *MockClient) GetSymbolFilters(ctx context.Context, symbol string) (*binance.SymbolFilters, error) {
+	m.record("GetSymbolFilters", symbol)
+	if m.GetSymbolFiltersFunc != nil {
+		return m.GetSymbolFiltersFunc(ctx, symbol)
+	}
+	return &binance.SymbolFilters{Symbol: symbol, TickSize: 0.1, StepSize: 0.001, MinQty: 0.001, MaxQty: 1000, MarketStepSize: 0.001, MarketMinQty: 0.001, MarketMaxQty: 120, MinNotional: 5}, nil
+}
+
+func (m *MockClient) GetSpotSymbolFilters(ctx context.Context, symbol string) (*binance.SymbolFilters, error) {
+	m.record("GetSpotSymbolFilters", symbol)
+	if m.GetSpotSymbolFiltersFunc != nil {
+		return m.GetSpotSymbolFiltersFunc(ctx, symbol)
+	}
+	return &binance.SymbolFilters{Symbol: symbol, TickSize: 0.01, StepSize: 0.00001, MinQty: 0.00001, MaxQty: 9000, MarketStepSize: 0.00001, MarketMinQty: 0.00001, MarketMaxQty: 100, MinNotional: 5}, nil
+}
+
+func (m *MockClient) RefreshExchangeInfo(ctx context.Context, markets ...string) ([]binance.ExchangeInfoStatus, error) {
+	m.record("RefreshExchangeInfo", markets)
+	if m.RefreshExchangeInfoFunc != nil {
+		return m.RefreshExchangeInfoFunc(ctx, markets...)
+	}
+	return nil, nil
+}
+
+func (m *MockClient) ExchangeInfoStatus() []binance.ExchangeInfoStatus {
+	m.record("ExchangeInfoStatus")
+	if m.ExchangeInfoStatusFunc != nil {
+		return m.ExchangeInfoStatusFunc()
+	}
+	return nil
+}
+
+func (m *MockClient) GetPrice(ctx context.Context, symbol string, source futures.WorkingType, maxAge time.Duration) (binance.Price, error) {
+	m.record("GetPrice", symbol, source, maxAge)
+	if m.GetPriceFunc != nil {
+		return m.GetPriceFunc(ctx, symbol, source, maxAge)
+	}
+	return binance.Price{Symbol: symbol, Source: source, Value: 50000, Time: time.Now()}, nil
+}
+
+// SubscribePrices blocks until ctx is done unless SubscribePricesFunc is set
+func (m *MockClient) SubscribePrices(ctx context.Context, handle func(updates []binance.PriceUpdate)) error {
+	m.record("SubscribePrices")
+	if m.SubscribePricesFunc != nil {
+		return m.SubscribePricesFunc(ctx, handle)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// SubscribeSymbols blocks until ctx is done unless SubscribeSymbolsFunc is set
+func (m *MockClient) SubscribeSymbols(ctx context.Context, symbols []string, handle func(update *binance.SymbolUpdate)) error {
+	m.record("SubscribeSymbols", symbols)
+	if m.SubscribeSymbolsFunc != nil {
+		return m.SubscribeSymbolsFunc(ctx, symbols, handle)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func (m *MockClient) SetPositionMode(ctx context.Context, dualSide bool) error {
+	m.record("SetPositionMode", dualSide)
+	if m.SetPositionModeFunc != nil {
+		return m.SetPositionModeFunc(ctx, dualSide)
+	}
+	return nil
+}
+
+func (m *MockClient) GetPositionMode(ctx context.Context) (bool, error) {
+	m.record("GetPositionMode")
+	if m.GetPositionModeFunc != nil {
+		return m.GetPositionModeFunc(ctx)
+	}
+	return false, nil
+}
+
+func (m *MockClient) Futures() *futures.Client {
+	return m.FuturesClient
+}
+
+func (m *MockClient) Options() *binance.OptionsClient {
+	return m.OptionsClient
+}
+
+func (m *MockClient) SetCredentials(apiKey, secretKey string, testnet bool) {
+	m.record("SetCredentials", apiKey, testnet)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiKey, m.secretKey, m.testnet = apiKey, secretKey, testnet
+}
+
+func (m *MockClient) ValidateAPIKeys(ctx context.Context, apiKey, secretKey string, testnet bool) error {
+	m.record("ValidateAPIKeys", apiKey, testnet)
+	if m.ValidateAPIKeysFunc != nil {
+		return m.ValidateAPIKeysFunc(ctx, apiKey, secretKey, testnet)
+	}
+	return nil
+}
+
+func (m *MockClient) EffectiveConfig() *config.Config {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	effective := *m.Config
+	effective.BinanceAPIKey = m.apiKey
+	effective.BinanceSecretKey = m.secretKey
+	effective.BinanceTestnet = m.testnet
+	return &effective
+}
+
+func (m *MockClient) IsTestnet() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.testnet
+}
+
+func (m *MockClient) Breaker() *binance.CircuitBreaker {
+	return m.CircuitBreaker
+}
+
+func (m *MockClient) Ping(ctx context.Context) error {
+	m.record("Ping")
+	if m.PingFunc != nil {
+		return m.PingFunc(ctx)
+	}
+	return nil
+}
+
+// ProbeNetwork reports a direct connection without probing anything
+func (m *MockClient) ProbeNetwork(ctx context.Context) *binance.NetworkDiagnostics {
+	m.record("ProbeNetwork")
+	return &binance.NetworkDiagnostics{Network: binance.NetworkName(m.IsTestnet()), ProxySource: "environment", Probes: []binance.NetworkProbe{}}
+}
+
+// CheckServerTime reports a clock in sync with the server unless CheckServerTimeFunc is set
+func (m *MockClient) CheckServerTime(ctx context.Context) (*binance.ServerTimeCheck, error) {
+	m.record("CheckServerTime")
+	if m.CheckServerTimeFunc != nil {
+		return m.CheckServerTimeFunc(ctx)
+	}
+	now := time.Now().UTC()
+	return &binance.ServerTimeCheck{
+		Network:         binance.NetworkName(m.IsTestnet()),
+		ServerTime:      now,
+		LocalTime:       now,
+		AppliedOffsetMs: m.TimeOffset(),
+	}, nil
+}
+
+// SetTimeOffset records the offset, which TimeOffset returns
+func (m *MockClient) SetTimeOffset(offsetMs int64) {
+	m.record("SetTimeOffset", offsetMs)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.offsetMs = offsetMs
+}
+
+// TimeOffset returns the offset last passed to SetTimeOffset
+func (m *MockClient) TimeOffset() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.offsetMs
+}
@@ -0,0 +1,74 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// bracketCacheTTL is how long a symbol's leverage brackets are reused; Binance rarely changes them
+const bracketCacheTTL = time.Hour
+
+// bracketCache holds leverage brackets per symbol. Brackets are account-specific, so the cache is
+// cleared when the credentials change.
+type bracketCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedBrackets
+}
+
+type cachedBrackets struct {
+	brackets  []futures.Bracket
+	fetchedAt time.Time
+}
+
+func (b *bracketCache) get(symbol string) ([]futures.Bracket, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entry, ok := b.entries[symbol]
+	if !ok || time.Since(entry.fetchedAt) > bracketCacheTTL {
+		return nil, false
+	}
+	return entry.brackets, true
+}
+
+func (b *bracketCache) put(symbol string, brackets []futures.Bracket) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.entries == nil {
+		b.entries = make(map[string]cachedBrackets)
+	}
+	b.entries[symbol] = cachedBrackets{brackets: brackets, fetchedAt: time.Now()}
+}
+
+func (b *bracketCache) clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = nil
+}
+
+// GetLeverageBrackets returns symbol's notional brackets (maximum leverage, maintenance margin
+// ratio and amount) ordered from the smallest notional, cached for an hour
+func (c *Client) GetLeverageBrackets(ctx context.Context, symbol string) ([]futures.Bracket, error) {
+	if brackets, ok := c.brackets.get(symbol); ok {
+		return brackets, nil
+	}
+
+	var res []*futures.LeverageBracket
+	err := c.retry.do(ctx, "get leverage brackets", func() (err error) {
+		res, err = c.Futures().NewGetLeverageBracketService().Symbol(symbol).Do(ctx, c.futuresRecvWindow(ctx))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leverage brackets: %w", err)
+	}
+	for _, lb := range res {
+		if lb.Symbol == symbol && len(lb.Brackets) > 0 {
+			c.brackets.put(symbol, lb.Brackets)
+			return lb.Brackets, nil
+		}
+	}
+	return nil, fmt.Errorf("no leverage brackets for %s", symbol)
+}
@@ -0,0 +1,277 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"futures-options/latency"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// ErrCircuitOpen is matched (via errors.Is) by requests rejected while the breaker is open
+var ErrCircuitOpen = errors.New("exchange temporarily unavailable")
+
+// CircuitOpenError is returned without contacting Binance while the breaker is open
+type CircuitOpenError struct {
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("exchange temporarily unavailable, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitRetryAfter returns how long the breaker stays open if err was caused by an open breaker
+func CircuitRetryAfter(err error) (time.Duration, bool) {
+	var openErr *CircuitOpenError
+	if errors.As(err, &openErr) {
+		return openErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// BreakerSnapshot is the breaker state reported by /health and /metrics
+type BreakerSnapshot struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastFailureStatus   int        `json:"last_failure_status,omitempty"`
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+	Opens               int64      `json:"opens"`
+}
+
+// CircuitBreaker stops calls to Binance after consecutive 5xx/transport failures, and at once on
+// 429/418, until a cool-off passes; then a single probe decides whether to close it again.
+// A nil *CircuitBreaker lets every request through.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu                sync.Mutex
+	state             string
+	failures          int
+	lastFailureStatus int
+	openUntil         time.Time
+	probing           bool
+	opens             int64
+}
+
+// NewCircuitBreaker opens after threshold consecutive failures for cooldown (or Retry-After, if longer)
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, state: BreakerClosed}
+}
+
+// allow reports whether a request may be sent; probe is true for the single half-open trial request
+func (b *CircuitBreaker) allow() (probe bool, err error) {
+	if b == nil {
+		return false, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if wait := time.Until(b.openUntil); wait > 0 {
+			return false, &CircuitOpenError{RetryAfter: wait}
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true, nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return false, &CircuitOpenError{RetryAfter: time.Second}
+		}
+		b.probing = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// record updates the breaker with the outcome of a request. status is the HTTP status, or 0
+// when Binance could not be reached; retryAfter is the parsed Retry-After header, if any.
+func (b *CircuitBreaker) record(status int, retryAfter time.Duration, probe bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+
+	switch {
+	case status == http.StatusTooManyRequests || status == http.StatusTeapot:
+		// Rate limited or IP banned: keep hammering and the ban gets longer
+		b.failures++
+		b.lastFailureStatus = status
+		b.open(retryAfter)
+	case status == 0 || status >= 500:
+		b.failures++
+		b.lastFailureStatus = status
+		if probe || b.failures >= b.threshold {
+			b.open(retryAfter)
+		}
+	default:
+		if b.state != BreakerClosed {
+			slog.Info("Binance circuit breaker closed")
+		}
+		b.state = BreakerClosed
+		b.failures = 0
+	}
+}
+
+// release gives up the probe slot without judging the exchange, e.g. when the caller canceled
+func (b *CircuitBreaker) release(probe bool) {
+	if b == nil || !probe {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// open must be called with mu held
+func (b *CircuitBreaker) open(retryAfter time.Duration) {
+	cooldown := b.cooldown
+	if retryAfter > cooldown {
+		cooldown = retryAfter
+	}
+	if b.state != BreakerOpen {
+		b.opens++
+		slog.Warn("Binance circuit breaker opened", "failures", b.failures, "status", b.lastFailureStatus, "cooldown", cooldown)
+	}
+	b.state = BreakerOpen
+	b.openUntil = time.Now().Add(cooldown)
+}
+
+// Snapshot returns the current breaker state
+func (b *CircuitBreaker) Snapshot() BreakerSnapshot {
+	if b == nil {
+		return BreakerSnapshot{State: BreakerClosed}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snap := BreakerSnapshot{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastFailureStatus:   b.lastFailureStatus,
+		Opens:               b.opens,
+	}
+	if b.state == BreakerOpen {
+		until := b.openUntil
+		snap.OpenUntil = &until
+	}
+	return snap
+}
+
+// Do runs fn through the breaker; it is used for calls that don't go through the REST transport (WS-API)
+func (b *CircuitBreaker) Do(ctx context.Context, fn func() error) error {
+	probe, err := b.allow()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	switch {
+	case err == nil:
+		b.record(http.StatusOK, 0, probe)
+	case ctx.Err() != nil:
+		b.release(probe)
+	case IsTransportError(err):
+		b.record(0, 0, probe)
+	case HTTPStatusCode(err) != 0:
+		b.record(HTTPStatusCode(err), 0, probe)
+	default:
+		// Not a signal about the exchange's health (e.g. missing keys)
+		b.release(probe)
+	}
+	return err
+}
+
+// breakerTransport applies the breaker to REST requests. Cancels (DELETE) bypass an open
+// breaker so open orders can always be pulled, but their outcomes still count.
+type breakerTransport struct {
+	breaker *CircuitBreaker
+	base    http.RoundTripper
+}
+
+// Transport wraps base (http.DefaultTransport if nil) with the breaker
+func (b *CircuitBreaker) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &breakerTransport{breaker: b, base: base}
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var probe bool
+	if req.Method != http.MethodDelete {
+		var err error
+		if probe, err = t.breaker.allow(); err != nil {
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	// A canceled request says nothing about Binance, and a 4xx is an answer to the request itself
+	failed := err != nil && req.Context().Err() == nil || err == nil && resp.StatusCode >= http.StatusInternalServerError
+	latency.Record(restTarget(req.URL.Path), time.Since(start), failed)
+	if err != nil {
+		if req.Context().Err() != nil {
+			t.breaker.release(probe)
+		} else {
+			t.breaker.record(0, 0, probe)
+		}
+		return nil, err
+	}
+	t.breaker.record(resp.StatusCode, parseRetryAfter(resp.Header.Get("Retry-After")), probe)
+	return resp, nil
+}
+
+// restTarget names the latency target of a REST path by its API prefix
+func restTarget(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/fapi/"):
+		return latency.BinanceFuturesREST
+	case strings.HasPrefix(path, "/dapi/"):
+		return latency.BinanceCoinMREST
+	case strings.HasPrefix(path, "/eapi/"):
+		return latency.BinanceOptionsREST
+	default:
+		return latency.BinanceSpotREST
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
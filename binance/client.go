@@ -3,78 +3,245 @@ package binance
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sync"
 	"time"
 
 	"futures-options/config"
 
 	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/delivery"
 	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
 )
 
+// spotTestnetURL is the REST base URL of the Binance spot testnet
+const spotTestnetURL = "https://testnet.binance.vision"
+
+// Client wraps the Binance SDK clients. The underlying clients are swapped
+// atomically when keys change, so callers must go through Futures()/Delivery()/Spot()
+// instead of holding on to a client across calls.
 type Client struct {
-	FuturesClient *futures.Client
-	OptionsClient *binance.Client
-	Config        *config.Config
+	Config *config.Config
+
+	mu             sync.RWMutex
+	futuresClient  *futures.Client
+	deliveryClient *delivery.Client
+	spotClient     *binance.Client
+	optionsAPI     *OptionsClient
+	effective      config.Config // Config with the active keys and network applied
+
+	retry    retryPolicy
+	breaker  *CircuitBreaker
+	brackets bracketCache
+	leverage leverageCache
+
+	exchangeInfo *ExchangeInfoCache
+	prices       *PriceCache
+	clock        *clockOffset
 }
 
 func NewClient(cfg *config.Config) *Client {
 	client := &Client{
 		Config: cfg,
+		retry:   newRetryPolicy(cfg),
+		breaker: NewCircuitBreaker(cfg.BinanceBreakerThreshold, cfg.BinanceBreakerCooldown),
+		clock:   &clockOffset{},
 	}
+	client.retry.resync = client.resyncClock
+	client.exchangeInfo = newExchangeInfoCache(client)
+	client.prices = newPriceCache(client)
 
-	// Initialize Futures Client (Testnet)
-	if cfg.BinanceTestnet {
-		client.FuturesClient = futures.NewClient("", "")
-		client.FuturesClient.BaseURL = cfg.BinanceFuturesTestnetURL
-	} else {
-		client.FuturesClient = futures.NewClient(cfg.BinanceAPIKey, cfg.BinanceSecretKey)
-	}
-
-	// Note: Binance Options API might need different initialization
-	// For now, using standard client for options
-	if cfg.BinanceTestnet {
-		client.OptionsClient = binance.NewClient("", "")
-		// Options testnet URL might be different
-	} else {
-		client.OptionsClient = binance.NewClient(cfg.BinanceAPIKey, cfg.BinanceSecretKey)
+	// Testnet keys are applied later from the database or environment (see SetCredentials)
+	apiKey, secretKey := "", ""
+	if !cfg.BinanceTestnet {
+		apiKey, secretKey = cfg.BinanceAPIKey, cfg.BinanceSecretKey
 	}
+	client.SetCredentials(apiKey, secretKey, cfg.BinanceTestnet)
 
 	return client
 }
 
-// SetAPIKeys sets the API keys for authenticated requests
+// Futures returns the current futures SDK client
+func (c *Client) Futures() *futures.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.futuresClient
+}
+
+// Delivery returns the current COIN-M (delivery) futures SDK client
+func (c *Client) Delivery() *delivery.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.deliveryClient
+}
+
+// Spot returns the current spot SDK client
+func (c *Client) Spot() *binance.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.spotClient
+}
+
+// SetAPIKeys sets the API keys for authenticated requests on the configured network
 func (c *Client) SetAPIKeys(apiKey, secretKey string) {
-	c.FuturesClient = futures.NewClient(apiKey, secretKey)
-	if c.Config.BinanceTestnet {
-		c.FuturesClient.BaseURL = c.Config.BinanceFuturesTestnetURL
+	c.SetCredentials(apiKey, secretKey, c.Config.BinanceTestnet)
+}
+
+// SetCredentials sets the API keys and the network they belong to.
+// The testnet flag overrides the BINANCE_TESTNET default for the futures, options and WS-API clients.
+func (c *Client) SetCredentials(apiKey, secretKey string, testnet bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	networkChanged := c.effective.BinanceTestnet != testnet
+	c.effective = *c.Config
+	c.effective.BinanceAPIKey = apiKey
+	c.effective.BinanceSecretKey = secretKey
+	c.effective.BinanceTestnet = testnet
+
+	// Build all clients before publishing them so readers never see a mixed set
+	futuresClient := futures.NewClient(apiKey, secretKey)
+	deliveryClient := delivery.NewClient(apiKey, secretKey)
+	spotClient := binance.NewClient(apiKey, secretKey)
+	if testnet {
+		futuresClient.BaseURL = c.Config.BinanceFuturesTestnetURL
+		deliveryClient.BaseURL = c.Config.BinanceDeliveryTestnetURL
+		spotClient.BaseURL = spotTestnetURL
+	}
+	// All REST traffic shares one circuit breaker, which survives key changes, and reports its
+	// rate limit usage
+	futuresClient.HTTPClient = &http.Client{Transport: trackRateLimits(c.breaker.Transport(httpTransport()))}
+	deliveryClient.HTTPClient = &http.Client{Transport: trackRateLimits(c.breaker.Transport(httpTransport()))}
+	spotClient.HTTPClient = &http.Client{Transport: trackRateLimits(c.breaker.Transport(httpTransport()))}
+	// Signed requests keep the measured clock offset across key changes
+	futuresClient.TimeOffset = c.clock.ms.Load()
+	deliveryClient.TimeOffset = c.clock.ms.Load()
+	spotClient.TimeOffset = c.clock.ms.Load()
+	effective := c.effective
+	optionsAPI := NewOptionsClient(&effective)
+	optionsAPI.httpClient.Transport = trackRateLimits(c.breaker.Transport(httpTransport()))
+	optionsAPI.clock = c.clock
+	optionsAPI.retry = c.retry
+
+	c.futuresClient = futuresClient
+	c.deliveryClient = deliveryClient
+	c.spotClient = spotClient
+	c.optionsAPI = optionsAPI
+	c.brackets.clear()
+	c.leverage.clear()
+	// Testnet and mainnet list different symbols
+	if networkChanged {
+		c.exchangeInfo.invalidate()
+		c.prices.invalidate()
+	}
+}
+
+// Breaker returns the circuit breaker guarding requests to Binance
+func (c *Client) Breaker() *CircuitBreaker {
+	return c.breaker
+}
+
+// EffectiveConfig returns a copy of the configuration with the active keys and network applied
+func (c *Client) EffectiveConfig() *config.Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	effective := c.effective
+	return &effective
+}
+
+// IsTestnet reports whether the active credentials target the testnet
+func (c *Client) IsTestnet() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.effective.BinanceTestnet
+}
+
+// NetworkName returns "testnet" or "mainnet" for logging and diagnostics
+func NetworkName(testnet bool) string {
+	if testnet {
+		return "testnet"
+	}
+	return "mainnet"
+}
+
+// ValidateAPIKeys checks a key pair against Binance with a cheap signed request,
+// using the given testnet flag instead of the global configuration
+func ValidateAPIKeys(ctx context.Context, cfg *config.Config, apiKey, secretKey string, testnet bool) error {
+	client := futures.NewClient(apiKey, secretKey)
+	client.HTTPClient = &http.Client{Transport: httpTransport()}
+	if testnet {
+		client.BaseURL = cfg.BinanceFuturesTestnetURL
+	}
+	if _, err := client.NewGetBalanceService().Do(ctx, futures.WithRecvWindow(RecvWindowOf(ctx, cfg.BinanceRecvWindow))); err != nil {
+		return fmt.Errorf("binance rejected the API keys: %w", err)
 	}
+	return nil
 }
 
-// CreateFuturesOrder creates a futures order on Binance
-func (c *Client) CreateFuturesOrder(ctx context.Context, symbol string, side futures.SideType, orderType futures.OrderType, quantity, price float64, leverage int) (*futures.CreateOrderResponse, error) {
-	// Set leverage first
+// ValidateAPIKeys checks a key pair against the futures base URL of this client's configuration
+func (c *Client) ValidateAPIKeys(ctx context.Context, apiKey, secretKey string, testnet bool) error {
+	return ValidateAPIKeys(ctx, c.Config, apiKey, secretKey, testnet)
+}
+
+// Ping checks that the futures REST API is reachable (unauthenticated, on the active network)
+func (c *Client) Ping(ctx context.Context) error {
+	return c.retry.do(ctx, "ping", func() error {
+		return c.Futures().NewPingService().Do(ctx)
+	})
+}
+
+// Options returns the options API client built with the current API keys
+func (c *Client) Options() *OptionsClient {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.optionsAPI
+}
+
+// CreateFuturesOrder creates a futures order on Binance.
+// A request rejected for its timestamp is retried once after the clock offset is measured again.
+func (c *Client) CreateFuturesOrder(ctx context.Context, symbol string, side futures.SideType, orderType futures.OrderType, quantity, price decimal.Decimal, leverage int, clientOrderID string) (*futures.CreateOrderResponse, error) {
+	var order *futures.CreateOrderResponse
+	err := c.retry.resyncing(ctx, "create order", func(ctx context.Context) (err error) {
+		order, err = c.createFuturesOrder(ctx, symbol, side, orderType, quantity, price, leverage, clientOrderID)
+		return err
+	})
+	return order, err
+}
+
+// createFuturesOrder is one attempt at CreateFuturesOrder, signed with the clock offset current when it starts
+func (c *Client) createFuturesOrder(ctx context.Context, symbol string, side futures.SideType, orderType futures.OrderType, quantity, price decimal.Decimal, leverage int, clientOrderID string) (*futures.CreateOrderResponse, error) {
+	// Use one client for the whole operation even if keys rotate meanwhile
+	fc := c.Futures()
+
+	// Set leverage first unless it is already set
 	if leverage > 1 {
-		_, err := c.FuturesClient.NewChangeLeverageService().
-			Symbol(symbol).
-			Leverage(leverage).
-			Do(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to set leverage: %w", err)
+		if err := c.ensureLeverage(ctx, fc, symbol, leverage); err != nil {
+			return nil, err
 		}
 	}
 
 	// Create order
-	orderService := c.FuturesClient.NewCreateOrderService().
+	orderService := fc.NewCreateOrderService().
 		Symbol(symbol).
 		Side(side).
 		Type(orderType).
-		Quantity(fmt.Sprintf("%.8f", quantity))
+		Quantity(quantity.String())
 
 	if orderType == futures.OrderTypeLimit {
-		orderService = orderService.Price(fmt.Sprintf("%.8f", price)).TimeInForce(futures.TimeInForceTypeGTC)
+		orderService = orderService.Price(price.String()).TimeInForce(futures.TimeInForceTypeGTC)
 	}
 
-	order, err := orderService.Do(ctx)
+	// Without a client order ID a failed placement cannot be checked, so it is never retried
+	var order *futures.CreateOrderResponse
+	var err error
+	if clientOrderID != "" {
+		order, err = c.retry.placeOrder(ctx, fc, symbol, clientOrderID, func() (*futures.CreateOrderResponse, error) {
+			return orderService.NewClientOrderID(clientOrderID).Do(ctx, c.futuresRecvWindow(ctx))
+		})
+	} else {
+		order, err = orderService.Do(ctx, c.futuresRecvWindow(ctx))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create futures order: %w", err)
 	}
@@ -84,7 +251,11 @@ func (c *Client) CreateFuturesOrder(ctx context.Context, symbol string, side fut
 
 // GetFuturesAccount gets futures account information
 func (c *Client) GetFuturesAccount(ctx context.Context) (*futures.Account, error) {
-	account, err := c.FuturesClient.NewGetAccountService().Do(ctx)
+	var account *futures.Account
+	err := c.retry.do(ctx, "get account", func() (err error) {
+		account, err = c.Futures().NewGetAccountService().Do(ctx, c.futuresRecvWindow(ctx))
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get futures account: %w", err)
 	}
@@ -93,13 +264,76 @@ func (c *Client) GetFuturesAccount(ctx context.Context) (*futures.Account, error
 
 // GetFuturesPositions gets current futures positions
 func (c *Client) GetFuturesPositions(ctx context.Context) ([]*futures.PositionRisk, error) {
-	positions, err := c.FuturesClient.NewGetPositionRiskService().Do(ctx)
+	var positions []*futures.PositionRisk
+	err := c.retry.do(ctx, "get positions", func() (err error) {
+		positions, err = c.Futures().NewGetPositionRiskService().Do(ctx, c.futuresRecvWindow(ctx))
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get futures positions: %w", err)
 	}
+	c.leverage.load(positions)
 	return positions, nil
 }
 
+// incomePageLimit is the maximum page size of the income history endpoint
+const incomePageLimit = 1000
+
+// GetIncomeHistory returns every income record (realized PnL, commission, funding, ...) since start
+func (c *Client) GetIncomeHistory(ctx context.Context, start time.Time) ([]*futures.IncomeHistory, error) {
+	var all []*futures.IncomeHistory
+	from := start.UnixMilli()
+	for {
+		var page []*futures.IncomeHistory
+		err := c.retry.do(ctx, "get income history", func() (err error) {
+			page, err = c.Futures().NewGetIncomeHistoryService().
+				StartTime(from).
+				Limit(incomePageLimit).
+				Do(ctx, c.futuresRecvWindow(ctx))
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get income history: %w", err)
+		}
+		all = append(all, page...)
+		if len(page) < incomePageLimit {
+			return all, nil
+		}
+		from = page[len(page)-1].Time + 1
+	}
+}
+
+// GetFuturesOrder queries the live state of a futures order
+func (c *Client) GetFuturesOrder(ctx context.Context, symbol string, orderID int64) (*futures.Order, error) {
+	var order *futures.Order
+	err := c.retry.do(ctx, "get order", func() (err error) {
+		order, err = c.Futures().NewGetOrderService().
+			Symbol(symbol).
+			OrderID(orderID).
+			Do(ctx, c.futuresRecvWindow(ctx))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get futures order: %w", err)
+	}
+	return order, nil
+}
+
+// ListOpenFuturesOrders lists the open futures orders for a symbol
+func (c *Client) ListOpenFuturesOrders(ctx context.Context, symbol string) ([]*futures.Order, error) {
+	var orders []*futures.Order
+	err := c.retry.do(ctx, "list open orders", func() (err error) {
+		orders, err = c.Futures().NewListOpenOrdersService().
+			Symbol(symbol).
+			Do(ctx, c.futuresRecvWindow(ctx))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open futures orders: %w", err)
+	}
+	return orders, nil
+}
+
 // CloseFuturesPosition closes a futures position
 func (c *Client) CloseFuturesPosition(ctx context.Context, symbol string, side futures.SideType, quantity float64) (*futures.CreateOrderResponse, error) {
 	// Close position by placing opposite order
@@ -108,13 +342,17 @@ func (c *Client) CloseFuturesPosition(ctx context.Context, symbol string, side f
 		oppositeSide = futures.SideTypeSell
 	}
 
-	order, err := c.FuturesClient.NewCreateOrderService().
-		Symbol(symbol).
-		Side(oppositeSide).
-		Type(futures.OrderTypeMarket).
-		Quantity(fmt.Sprintf("%.8f", quantity)).
-		ReduceOnly(true).
-		Do(ctx)
+	var order *futures.CreateOrderResponse
+	err := c.retry.resyncing(ctx, "close position", func(ctx context.Context) (err error) {
+		order, err = c.Futures().NewCreateOrderService().
+			Symbol(symbol).
+			Side(oppositeSide).
+			Type(futures.OrderTypeMarket).
+			Quantity(formatDecimal(quantity)).
+			ReduceOnly(true).
+			Do(ctx, c.futuresRecvWindow(ctx))
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to close futures position: %w", err)
 	}
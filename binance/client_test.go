@@ -0,0 +1,114 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"futures-options/config"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+)
+
+func TestSetCredentialsFollowsCredentialNetwork(t *testing.T) {
+	const testnetURL = "https://futures-testnet.invalid"
+	for _, envTestnet := range []bool{false, true} {
+		for _, testnet := range []bool{false, true} {
+			t.Run(NetworkName(envTestnet)+" env, "+NetworkName(testnet)+" keys", func(t *testing.T) {
+				c := NewClient(&config.Config{BinanceTestnet: envTestnet, BinanceFuturesTestnetURL: testnetURL})
+				c.SetCredentials("key", "secret", testnet)
+
+				if got := c.Futures().BaseURL == testnetURL; got != testnet {
+					t.Errorf("futures base URL %s, want testnet %v", c.Futures().BaseURL, testnet)
+				}
+				if got := c.Options().config.BinanceTestnet; got != testnet {
+					t.Errorf("options client testnet %v, want %v", got, testnet)
+				}
+				// The WS-API client is built from the effective config
+				if got := c.EffectiveConfig().BinanceTestnet; got != testnet {
+					t.Errorf("effective config testnet %v, want %v", got, testnet)
+				}
+				if c.Config.BinanceTestnet != envTestnet {
+					t.Errorf("environment flag changed to %v", c.Config.BinanceTestnet)
+				}
+			})
+		}
+	}
+}
+
+// TestSetCredentialsWhileOrdering rotates keys while orders are placed; run it with -race. Every
+// request must reach the testnet server signed with one of the rotated keys.
+func TestSetCredentialsWhileOrdering(t *testing.T) {
+	keys := []string{"key-a", "key-b", "key-c"}
+	var orders, foreign atomic.Int32
+	c := newTestFuturesClient(t, func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-MBX-APIKEY")
+		if !strings.HasPrefix(key, "key-") {
+			foreign.Add(1)
+		}
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/positionRisk"):
+			w.Write([]byte(`[]`))
+		case strings.HasSuffix(r.URL.Path, "/leverage"):
+			w.Write([]byte(`{"symbol":"BTCUSDT","leverage":5}`))
+		case strings.HasSuffix(r.URL.Path, "/order"):
+			n := orders.Add(1)
+			fmt.Fprintf(w, `{"orderId":%d,"symbol":"BTCUSDT","status":"NEW"}`, n)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	testnetURL := c.Futures().BaseURL
+	c.SetCredentials(keys[0], "secret", true)
+
+	const workers, perWorker = 8, 25
+	ctx, cancel := context.WithCancel(context.Background())
+	var rotations sync.WaitGroup
+	rotations.Add(1)
+	go func() {
+		defer rotations.Done()
+		for i := 1; ctx.Err() == nil; i++ {
+			c.SetCredentials(keys[i%len(keys)], "secret", true)
+			c.SetTimeOffset(int64(i % 3))
+			time.Sleep(100 * time.Microsecond)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*workers*perWorker)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				_, err := c.CreateFuturesOrder(ctx, "BTCUSDT", futures.SideTypeBuy, futures.OrderTypeMarket, decimal.RequireFromString("0.001"), decimal.Zero, 5, "")
+				if err != nil {
+					errs <- err
+				}
+				if base := c.Futures().BaseURL; base != testnetURL {
+					errs <- fmt.Errorf("futures base URL %s, want %s", base, testnetURL)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	cancel()
+	rotations.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if got := orders.Load(); got != workers*perWorker {
+		t.Errorf("server received %d orders, want %d", got, workers*perWorker)
+	}
+	if got := foreign.Load(); got != 0 {
+		t.Errorf("%d requests were not signed with a rotated key", got)
+	}
+}
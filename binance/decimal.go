@@ -0,0 +1,9 @@
+package binance
+
+import "github.com/shopspring/decimal"
+
+// formatDecimal formats v for a Binance request parameter in plain notation with as many
+// decimals as it needs: 0.00000001 stays 0.00000001, and nothing past the eighth decimal is cut
+func formatDecimal(v float64) string {
+	return decimal.NewFromFloat(v).String()
+}
@@ -0,0 +1,254 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/adshao/go-binance/v2/delivery"
+)
+
+// CreateDeliveryOrder places a COIN-M futures order. Quantity is a number of contracts.
+// A -1021 timestamp rejection is retried once after the server time is measured again.
+func (c *Client) CreateDeliveryOrder(ctx context.Context, req *AdvancedOrderRequest) (*delivery.CreateOrderResponse, error) {
+	var order *delivery.CreateOrderResponse
+	err := c.retry.resyncing(ctx, "create delivery order", func(ctx context.Context) (err error) {
+		order, err = c.createDeliveryOrder(ctx, req)
+		return err
+	})
+	return order, err
+}
+
+// createDeliveryOrder is one attempt at CreateDeliveryOrder
+func (c *Client) createDeliveryOrder(ctx context.Context, req *AdvancedOrderRequest) (*delivery.CreateOrderResponse, error) {
+	// Use one client for the whole operation even if keys rotate meanwhile
+	dc := c.Delivery()
+
+	if req.Leverage > 1 {
+		err := c.retry.do(ctx, "change leverage", func() error {
+			_, err := dc.NewChangeLeverageService().
+				Symbol(req.Symbol).
+				Leverage(req.Leverage).
+				Do(ctx, c.deliveryRecvWindow(ctx))
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to set leverage: %w", err)
+		}
+	}
+
+	// The USDⓈ-M conversion doubles as validation; the enum values are shared by both APIs
+	futuresType, err := c.convertOrderType(req.OrderType)
+	if err != nil {
+		return nil, err
+	}
+	orderType := delivery.OrderType(futuresType)
+
+	orderService := dc.NewCreateOrderService().
+		Symbol(req.Symbol).
+		Side(delivery.SideType(c.convertSide(req.Side))).
+		Type(orderType)
+	// Binance rejects a quantity next to closePosition
+	if !req.ClosePosition {
+		orderService = orderService.Quantity(req.Quantity.String())
+	}
+
+	if orderType == delivery.OrderTypeLimit && req.Price.IsPositive() {
+		orderService = orderService.Price(req.Price.String()).
+			TimeInForce(delivery.TimeInForceType(c.convertTimeInForce(req.TimeInForce)))
+	}
+	if req.StopPrice.IsPositive() {
+		orderService = orderService.StopPrice(req.StopPrice.String())
+	}
+	if req.WorkingType != "" {
+		orderService = orderService.WorkingType(delivery.WorkingType(c.convertWorkingType(req.WorkingType)))
+	}
+	if req.ActivationPrice.IsPositive() {
+		orderService = orderService.ActivationPrice(req.ActivationPrice.String())
+	}
+	if req.CallbackRate > 0 {
+		orderService = orderService.CallbackRate(formatDecimal(req.CallbackRate))
+	}
+	if req.PositionSide != "" {
+		orderService = orderService.PositionSide(delivery.PositionSideType(c.convertPositionSide(req.PositionSide)))
+	}
+	if req.ReduceOnly {
+		orderService = orderService.ReduceOnly(req.ReduceOnly)
+	}
+	if req.ClosePosition {
+		orderService = orderService.ClosePosition(req.ClosePosition)
+	}
+	if req.ClientOrderID != "" {
+		orderService = orderService.NewClientOrderID(req.ClientOrderID)
+	}
+
+	order, err := c.placeDeliveryOrder(ctx, dc, req.Symbol, req.ClientOrderID, func() (*delivery.CreateOrderResponse, error) {
+		return orderService.Do(ctx, c.deliveryRecvWindow(ctx))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create delivery order: %w", err)
+	}
+	return order, nil
+}
+
+// placeDeliveryOrder is retryPolicy.placeOrder for the delivery API: a transient failure is only
+// retried when the client order ID lookup confirms the first attempt did not go through
+func (c *Client) placeDeliveryOrder(ctx context.Context, dc *delivery.Client, symbol, clientOrderID string, place func() (*delivery.CreateOrderResponse, error)) (*delivery.CreateOrderResponse, error) {
+	for attempt := 1; ; attempt++ {
+		order, err := place()
+		if err == nil || clientOrderID == "" || !IsRetryable(err) || attempt >= c.retry.maxAttempts {
+			return order, err
+		}
+		if !c.retry.wait(ctx, attempt) {
+			return nil, err
+		}
+
+		existing, lookupErr := dc.NewGetOrderService().Symbol(symbol).OrigClientOrderID(clientOrderID).Do(ctx, c.deliveryRecvWindow(ctx))
+		if lookupErr == nil {
+			return &delivery.CreateOrderResponse{
+				Symbol:           existing.Symbol,
+				Pair:             existing.Pair,
+				OrderID:          existing.OrderID,
+				ClientOrderID:    existing.ClientOrderID,
+				Price:            existing.Price,
+				OrigQuantity:     existing.OrigQuantity,
+				ExecutedQuantity: existing.ExecutedQuantity,
+				CumBase:          existing.CumBase,
+				AvgPrice:         existing.AvgPrice,
+				ReduceOnly:       existing.ReduceOnly,
+				Status:           existing.Status,
+				StopPrice:        existing.StopPrice,
+				TimeInForce:      existing.TimeInForce,
+				Type:             existing.Type,
+				Side:             existing.Side,
+				PositionSide:     existing.PositionSide,
+				UpdateTime:       existing.UpdateTime,
+			}, nil
+		}
+		if !IsOrderNotFound(lookupErr) {
+			return nil, err
+		}
+		slog.Warn("retrying delivery order placement", "symbol", symbol, "client_order_id", clientOrderID, "attempt", attempt+1, "error", err)
+	}
+}
+
+// CancelDeliveryOrders cancels COIN-M orders one by one; orders that fail to cancel are skipped
+func (c *Client) CancelDeliveryOrders(ctx context.Context, symbol string, orderIDs []int64, clientOrderIDs []string) ([]*delivery.CancelOrderResponse, error) {
+	var responses []*delivery.CancelOrderResponse
+	cancel := func(build func(s *delivery.CancelOrderService) *delivery.CancelOrderService) {
+		var resp *delivery.CancelOrderResponse
+		err := c.retry.do(ctx, "cancel delivery order", func() (err error) {
+			resp, err = build(c.Delivery().NewCancelOrderService().Symbol(symbol)).Do(ctx, c.deliveryRecvWindow(ctx))
+			return err
+		})
+		if err == nil {
+			responses = append(responses, resp)
+		}
+	}
+	for _, orderID := range orderIDs {
+		cancel(func(s *delivery.CancelOrderService) *delivery.CancelOrderService { return s.OrderID(orderID) })
+	}
+	for _, clientOrderID := range clientOrderIDs {
+		cancel(func(s *delivery.CancelOrderService) *delivery.CancelOrderService {
+			return s.OrigClientOrderID(clientOrderID)
+		})
+	}
+	return responses, nil
+}
+
+// GetDeliveryOrder queries the live state of a COIN-M order
+func (c *Client) GetDeliveryOrder(ctx context.Context, symbol string, orderID int64) (*delivery.Order, error) {
+	var order *delivery.Order
+	err := c.retry.do(ctx, "get delivery order", func() (err error) {
+		order, err = c.Delivery().NewGetOrderService().
+			Symbol(symbol).
+			OrderID(orderID).
+			Do(ctx, c.deliveryRecvWindow(ctx))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery order: %w", err)
+	}
+	return order, nil
+}
+
+// ListOpenDeliveryOrders lists the open COIN-M orders for a symbol
+func (c *Client) ListOpenDeliveryOrders(ctx context.Context, symbol string) ([]*delivery.Order, error) {
+	var orders []*delivery.Order
+	err := c.retry.do(ctx, "list open delivery orders", func() (err error) {
+		orders, err = c.Delivery().NewListOpenOrdersService().
+			Symbol(symbol).
+			Do(ctx, c.deliveryRecvWindow(ctx))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open delivery orders: %w", err)
+	}
+	return orders, nil
+}
+
+// GetDeliveryAccount gets the COIN-M futures account, with one asset per margin coin
+func (c *Client) GetDeliveryAccount(ctx context.Context) (*delivery.Account, error) {
+	var account *delivery.Account
+	err := c.retry.do(ctx, "get delivery account", func() (err error) {
+		account, err = c.Delivery().NewGetAccountService().Do(ctx, c.deliveryRecvWindow(ctx))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery account: %w", err)
+	}
+	return account, nil
+}
+
+// GetDeliveryBalance gets the COIN-M futures balance of every margin coin
+func (c *Client) GetDeliveryBalance(ctx context.Context) ([]*delivery.Balance, error) {
+	var balances []*delivery.Balance
+	err := c.retry.do(ctx, "get delivery balance", func() (err error) {
+		balances, err = c.Delivery().NewGetBalanceService().Do(ctx, c.deliveryRecvWindow(ctx))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery balance: %w", err)
+	}
+	return balances, nil
+}
+
+// GetDeliveryPositions gets current COIN-M futures positions; amounts are in contracts
+func (c *Client) GetDeliveryPositions(ctx context.Context) ([]*delivery.PositionRisk, error) {
+	var positions []*delivery.PositionRisk
+	err := c.retry.do(ctx, "get delivery positions", func() (err error) {
+		positions, err = c.Delivery().NewGetPositionRiskService().Do(ctx, c.deliveryRecvWindow(ctx))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery positions: %w", err)
+	}
+	return positions, nil
+}
+
+// GetContractSize returns the USD face value of one contract of a COIN-M symbol, from the
+// cached delivery exchange info
+func (c *Client) GetContractSize(ctx context.Context, symbol string) (float64, error) {
+	sizes, err := c.exchangeInfo.coinm.get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	size, ok := sizes[symbol]
+	if !ok || size <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownSymbol, symbol)
+	}
+	return size, nil
+}
+
+// getDeliveryExchangeInfo fetches the COIN-M exchange info
+func (c *Client) getDeliveryExchangeInfo(ctx context.Context) (*delivery.ExchangeInfo, error) {
+	var info *delivery.ExchangeInfo
+	err := c.retry.do(ctx, "get delivery exchange info", func() (err error) {
+		info, err = c.Delivery().NewExchangeInfoService().Do(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery exchange info: %w", err)
+	}
+	return info, nil
+}
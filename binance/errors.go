@@ -0,0 +1,127 @@
+package binance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/adshao/go-binance/v2/common"
+)
+
+// HTTPError is returned by the raw-HTTP and WS-API clients when Binance answers with a non-200 status.
+// Code and Message are filled from Binance's {"code": ..., "msg": ...} body when present.
+type HTTPError struct {
+	StatusCode int
+	Code       int64
+	Message    string
+}
+
+func (e *HTTPError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("<APIError> code=%d, msg=%s (status %d)", e.Code, e.Message, e.StatusCode)
+	}
+	return fmt.Sprintf("binance request failed with status %d", e.StatusCode)
+}
+
+// newHTTPError builds an HTTPError from a non-200 response, decoding the Binance error body if possible
+func newHTTPError(resp *http.Response) error {
+	httpErr := &HTTPError{StatusCode: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var apiErr struct {
+		Code int64  `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil {
+		httpErr.Code = apiErr.Code
+		httpErr.Message = apiErr.Msg
+	}
+	return httpErr
+}
+
+// IsOrderNotFound reports whether err is Binance's "Order does not exist" error
+func IsOrderNotFound(err error) bool {
+	return APIErrorCode(err) == -2013
+}
+
+// IsTimestampError reports whether err is Binance's -1021: the request's timestamp was outside
+// its recvWindow, or ahead of the server clock
+func IsTimestampError(err error) bool {
+	return APIErrorCode(err) == -1021
+}
+
+// TimestampError is a -1021 rejection that persisted after the request was retried with a freshly
+// measured clock offset, or after the offset could not be measured
+type TimestampError struct {
+	Err error `json:"-"`
+	// OffsetMs is local minus Binance server time as measured for the retry; positive when the
+	// local clock runs ahead
+	OffsetMs     int64 `json:"offset_ms"`
+	RecvWindowMs int64 `json:"recv_window_ms"`
+	// SyncErr is set when the server time could not be fetched, and no retry was made
+	SyncErr error `json:"-"`
+}
+
+func (e *TimestampError) Error() string {
+	drift := fmt.Sprintf("after a retry with the measured clock offset (local clock %dms %s Binance)", abs64(e.OffsetMs), aheadOrBehind(e.OffsetMs))
+	if e.SyncErr != nil {
+		drift = fmt.Sprintf("and the clock offset could not be measured (%v)", e.SyncErr)
+	}
+	return fmt.Sprintf("Binance rejected the request timestamp %s with recvWindow %dms; check GET /api/diagnostics/time, "+
+		"synchronize the host clock, or raise the recvWindow (BINANCE_RECV_WINDOW or X-Recv-Window) on a slow link: %v",
+		drift, e.RecvWindowMs, e.Err)
+}
+
+func (e *TimestampError) Unwrap() error {
+	return e.Err
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// aheadOrBehind describes the direction of a local minus server offset
+func aheadOrBehind(offsetMs int64) string {
+	if offsetMs > 0 {
+		return "ahead of"
+	}
+	return "behind"
+}
+
+// APIErrorCode returns the Binance error code carried by err, or 0 if err is not a Binance API error
+func APIErrorCode(err error) int64 {
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return 0
+}
+
+// HTTPStatusCode returns the HTTP status Binance answered with, or 0 if unknown
+func HTTPStatusCode(err error) int {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode
+	}
+	return 0
+}
+
+// IsTransportError reports whether err is a failure to reach Binance at all (DNS, TCP, TLS, timeout)
+func IsTransportError(err error) bool {
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		// Held back before it was sent; the HTTP client reports it as a failed request
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
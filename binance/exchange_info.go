@@ -0,0 +1,345 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"futures-options/latency"
+
+	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Exchange info markets
+const (
+	ExchangeInfoUSDM    = "usdm"
+	ExchangeInfoCoinM   = "coinm"
+	ExchangeInfoSpot    = "spot"
+	ExchangeInfoOptions = "options"
+)
+
+// errExchangeInfoInvalidated is returned to a fetch that finished after the network changed
+var errExchangeInfoInvalidated = errors.New("exchange info was invalidated while it was fetched")
+
+// ExchangeInfoMarkets lists every market the exchange info cache holds
+var ExchangeInfoMarkets = []string{ExchangeInfoUSDM, ExchangeInfoCoinM, ExchangeInfoSpot, ExchangeInfoOptions}
+
+const (
+	// exchangeInfoMaxAge is how old an entry may get before a read fetches it again, which only
+	// happens when the background refresh is off or failing
+	exchangeInfoMaxAge = time.Hour
+	// exchangeInfoRetryAfter spaces out fetches of an entry whose last fetch failed, so readers
+	// get the stale entry (or the error) instead of each hitting Binance
+	exchangeInfoRetryAfter = time.Minute
+)
+
+// ExchangeInfoStatus describes one market's exchange info cache entry
+type ExchangeInfoStatus struct {
+	Market        string     `json:"market"`
+	Loaded        bool       `json:"loaded"`
+	Symbols       int        `json:"symbols"`
+	FetchedAt     *time.Time `json:"fetched_at,omitempty"`
+	AgeSeconds    float64    `json:"age_seconds"`
+	Stale         bool       `json:"stale"` // older than the max age because refreshing failed
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// ExchangeInfoCache holds the parsed exchange info of each market, shared by everything that
+// needs symbol rules. Reads are served from memory and are safe for concurrent use. An entry
+// is fetched on first use and whenever it is refreshed; if a fetch fails the previous entry
+// keeps being served until one succeeds.
+type ExchangeInfoCache struct {
+	usdm    *infoEntry[map[string]*SymbolFilters]
+	coinm   *infoEntry[map[string]float64]
+	spot    *infoEntry[map[string]*SymbolFilters]
+	options *infoEntry[map[string]*OptionsSymbol]
+}
+
+func newExchangeInfoCache(c *Client) *ExchangeInfoCache {
+	return &ExchangeInfoCache{
+		usdm:    &infoEntry[map[string]*SymbolFilters]{market: ExchangeInfoUSDM, fetch: c.fetchUSDMInfo},
+		coinm:   &infoEntry[map[string]float64]{market: ExchangeInfoCoinM, fetch: c.fetchCoinMInfo},
+		spot:    &infoEntry[map[string]*SymbolFilters]{market: ExchangeInfoSpot, fetch: c.fetchSpotInfo},
+		options: &infoEntry[map[string]*OptionsSymbol]{market: ExchangeInfoOptions, fetch: c.fetchOptionsInfo},
+	}
+}
+
+// entry is the market-independent view of a cache entry
+type entry interface {
+	refresh(ctx context.Context) error
+	status() ExchangeInfoStatus
+	used() bool
+	invalidate()
+}
+
+func (ec *ExchangeInfoCache) entry(market string) (entry, bool) {
+	switch market {
+	case ExchangeInfoUSDM:
+		return ec.usdm, true
+	case ExchangeInfoCoinM:
+		return ec.coinm, true
+	case ExchangeInfoSpot:
+		return ec.spot, true
+	case ExchangeInfoOptions:
+		return ec.options, true
+	default:
+		return nil, false
+	}
+}
+
+// Refresh fetches the given markets again, or USDⓈ-M plus every market read so far when none
+// are given, and returns their status. A failed fetch is reported in the status, not as an
+// error; the previous entry stays in use.
+func (ec *ExchangeInfoCache) Refresh(ctx context.Context, markets ...string) ([]ExchangeInfoStatus, error) {
+	var entries []entry
+	if len(markets) == 0 {
+		for _, m := range ExchangeInfoMarkets {
+			e, _ := ec.entry(m)
+			if m == ExchangeInfoUSDM || e.used() {
+				entries = append(entries, e)
+			}
+		}
+	}
+	for _, m := range markets {
+		e, ok := ec.entry(m)
+		if !ok {
+			return nil, fmt.Errorf("unknown exchange info market %q", m)
+		}
+		entries = append(entries, e)
+	}
+
+	statuses := make([]ExchangeInfoStatus, 0, len(entries))
+	for _, e := range entries {
+		e.refresh(ctx)
+		statuses = append(statuses, e.status())
+	}
+	return statuses, nil
+}
+
+// Status returns the state of every market's entry
+func (ec *ExchangeInfoCache) Status() []ExchangeInfoStatus {
+	statuses := make([]ExchangeInfoStatus, 0, len(ExchangeInfoMarkets))
+	for _, m := range ExchangeInfoMarkets {
+		e, _ := ec.entry(m)
+		statuses = append(statuses, e.status())
+	}
+	return statuses
+}
+
+// invalidate drops every entry, e.g. when switching between testnet and mainnet
+func (ec *ExchangeInfoCache) invalidate() {
+	for _, m := range ExchangeInfoMarkets {
+		e, _ := ec.entry(m)
+		e.invalidate()
+	}
+}
+
+// infoEntry caches one market's parsed exchange info. fetchMu serializes fetches so concurrent
+// readers of an expired entry trigger a single request; mu guards the published value.
+type infoEntry[T any] struct {
+	market string
+	fetch  func(ctx context.Context) (T, int, error) // parsed info and its symbol count
+
+	fetchMu sync.Mutex
+
+	mu          sync.RWMutex
+	value       T
+	symbols     int
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	lastErr     error
+	generation  int // bumped by invalidate so in-flight fetches for the old network are dropped
+}
+
+// get returns the cached value, fetching it when missing or older than exchangeInfoMaxAge.
+// A stale value is returned if fetching it again fails.
+func (e *infoEntry[T]) get(ctx context.Context) (T, error) {
+	e.mu.RLock()
+	value, fetchedAt, lastAttempt, lastErr := e.value, e.fetchedAt, e.lastAttempt, e.lastErr
+	e.mu.RUnlock()
+
+	fresh := !fetchedAt.IsZero() && time.Since(fetchedAt) < exchangeInfoMaxAge
+	backingOff := lastErr != nil && time.Since(lastAttempt) < exchangeInfoRetryAfter
+	if fresh || backingOff && !fetchedAt.IsZero() {
+		return value, nil
+	}
+	if backingOff {
+		var zero T
+		return zero, lastErr
+	}
+
+	if err := e.refreshSince(ctx, fetchedAt); err != nil {
+		if !fetchedAt.IsZero() && !errors.Is(err, errExchangeInfoInvalidated) {
+			return value, nil
+		}
+		var zero T
+		return zero, err
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.value, nil
+}
+
+func (e *infoEntry[T]) refresh(ctx context.Context) error {
+	e.mu.RLock()
+	seen := e.fetchedAt
+	e.mu.RUnlock()
+	return e.refreshSince(ctx, seen)
+}
+
+// refreshSince fetches the entry unless another caller replaced the version fetched at seen
+// while this one waited for fetchMu
+func (e *infoEntry[T]) refreshSince(ctx context.Context, seen time.Time) error {
+	e.fetchMu.Lock()
+	defer e.fetchMu.Unlock()
+
+	e.mu.RLock()
+	current, generation := e.fetchedAt, e.generation
+	e.mu.RUnlock()
+	if current.After(seen) {
+		return nil
+	}
+
+	value, symbols, err := e.fetch(ctx)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.generation != generation {
+		return errExchangeInfoInvalidated
+	}
+	e.lastAttempt = time.Now()
+	if err != nil {
+		e.lastErr = err
+		slog.Warn("exchange info refresh failed", "market", e.market, "serving_stale", !e.fetchedAt.IsZero(), "error", err)
+		return err
+	}
+	e.value, e.symbols, e.fetchedAt, e.lastErr = value, symbols, e.lastAttempt, nil
+	return nil
+}
+
+func (e *infoEntry[T]) used() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return !e.lastAttempt.IsZero()
+}
+
+func (e *infoEntry[T]) invalidate() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var zero T
+	e.value, e.symbols, e.fetchedAt, e.lastAttempt, e.lastErr = zero, 0, time.Time{}, time.Time{}, nil
+	e.generation++
+}
+
+func (e *infoEntry[T]) status() ExchangeInfoStatus {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	s := ExchangeInfoStatus{Market: e.market, Loaded: !e.fetchedAt.IsZero(), Symbols: e.symbols}
+	if s.Loaded {
+		fetchedAt := e.fetchedAt
+		s.FetchedAt = &fetchedAt
+		s.AgeSeconds = time.Since(fetchedAt).Seconds()
+		s.Stale = time.Since(fetchedAt) >= exchangeInfoMaxAge
+	}
+	if !e.lastAttempt.IsZero() {
+		lastAttempt := e.lastAttempt
+		s.LastAttemptAt = &lastAttempt
+	}
+	if e.lastErr != nil {
+		s.LastError = e.lastErr.Error()
+	}
+	return s
+}
+
+// GetOptionsSymbol returns an options contract from the cached options exchange info
+func (c *Client) GetOptionsSymbol(ctx context.Context, symbol string) (*OptionsSymbol, error) {
+	symbols, err := c.exchangeInfo.options.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := symbols[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSymbol, symbol)
+	}
+	copied := *s
+	return &copied, nil
+}
+
+// RefreshExchangeInfo reloads the exchange info of the given markets (see ExchangeInfoCache.Refresh)
+func (c *Client) RefreshExchangeInfo(ctx context.Context, markets ...string) ([]ExchangeInfoStatus, error) {
+	return c.exchangeInfo.Refresh(ctx, markets...)
+}
+
+// ExchangeInfoStatus returns the state of each market's cached exchange info
+func (c *Client) ExchangeInfoStatus() []ExchangeInfoStatus {
+	return c.exchangeInfo.Status()
+}
+
+func (c *Client) fetchUSDMInfo(ctx context.Context) (map[string]*SymbolFilters, int, error) {
+	var info *futures.ExchangeInfo
+	err := c.retry.do(ctx, "get exchange info", func() (err error) {
+		info, err = c.Futures().NewExchangeInfoService().Do(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get exchange info: %w", err)
+	}
+	for _, l := range info.RateLimits {
+		rateLimits.setLimit(latency.BinanceFuturesREST, l.RateLimitType, int(l.IntervalNum), l.Interval, int(l.Limit))
+	}
+	filters := make(map[string]*SymbolFilters, len(info.Symbols))
+	for i := range info.Symbols {
+		f := parseSymbolFilters(&info.Symbols[i])
+		filters[f.Symbol] = f
+	}
+	return filters, len(filters), nil
+}
+
+func (c *Client) fetchSpotInfo(ctx context.Context) (map[string]*SymbolFilters, int, error) {
+	var info *binance.ExchangeInfo
+	err := c.retry.do(ctx, "get spot exchange info", func() (err error) {
+		info, err = c.Spot().NewExchangeInfoService().Do(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get spot exchange info: %w", err)
+	}
+	for _, l := range info.RateLimits {
+		rateLimits.setLimit(latency.BinanceSpotREST, l.RateLimitType, int(l.IntervalNum), l.Interval, int(l.Limit))
+	}
+	filters := make(map[string]*SymbolFilters, len(info.Symbols))
+	for i := range info.Symbols {
+		f := parseSpotSymbolFilters(&info.Symbols[i])
+		filters[f.Symbol] = f
+	}
+	return filters, len(filters), nil
+}
+
+func (c *Client) fetchCoinMInfo(ctx context.Context) (map[string]float64, int, error) {
+	info, err := c.getDeliveryExchangeInfo(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	sizes := make(map[string]float64, len(info.Symbols))
+	for _, s := range info.Symbols {
+		sizes[s.Symbol] = float64(s.ContractSize)
+	}
+	return sizes, len(sizes), nil
+}
+
+func (c *Client) fetchOptionsInfo(ctx context.Context) (map[string]*OptionsSymbol, int, error) {
+	info, err := c.Options().GetExchangeInfo(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	symbols := make(map[string]*OptionsSymbol, len(info.OptionSymbols))
+	for _, s := range info.OptionSymbols {
+		symbols[s.Symbol] = s
+	}
+	return symbols, len(symbols), nil
+}
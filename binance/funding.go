@@ -0,0 +1,49 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// FundingRate is a USDⓈ-M symbol's current funding rate, paid at NextFundingTime by longs to
+// shorts when positive and by shorts to longs when negative
+type FundingRate struct {
+	Symbol          string
+	Rate            float64
+	MarkPrice       float64
+	NextFundingTime time.Time
+}
+
+// GetFundingRate returns symbol's current funding rate from the premium index, retrying
+// transient failures
+func (c *Client) GetFundingRate(ctx context.Context, symbol string) (*FundingRate, error) {
+	var res []*futures.PremiumIndex
+	err := c.retry.do(ctx, "get funding rate", func() (err error) {
+		res, err = c.Futures().NewPremiumIndexService().Symbol(symbol).Do(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get funding rate: %w", err)
+	}
+	for _, p := range res {
+		if p.Symbol != symbol {
+			continue
+		}
+		rate, err := strconv.ParseFloat(p.LastFundingRate, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid funding rate %q for %s", p.LastFundingRate, symbol)
+		}
+		markPrice, _ := strconv.ParseFloat(p.MarkPrice, 64)
+		return &FundingRate{
+			Symbol:          symbol,
+			Rate:            rate,
+			MarkPrice:       markPrice,
+			NextFundingTime: time.UnixMilli(p.NextFundingTime),
+		}, nil
+	}
+	return nil, fmt.Errorf("no funding rate for %s", symbol)
+}
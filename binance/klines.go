@@ -0,0 +1,60 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// klinePageLimit is the maximum page size of the klines endpoint
+const klinePageLimit = 1500
+
+// KlineIntervals are the supported kline intervals and their lengths. Binance's monthly interval
+// is left out since its candles differ in length.
+var KlineIntervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"3m":  3 * time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"2h":  2 * time.Hour,
+	"4h":  4 * time.Hour,
+	"6h":  6 * time.Hour,
+	"8h":  8 * time.Hour,
+	"12h": 12 * time.Hour,
+	"1d":  24 * time.Hour,
+	"3d":  3 * 24 * time.Hour,
+	"1w":  7 * 24 * time.Hour,
+}
+
+// GetKlines returns symbol's USDⓈ-M klines opening in [start, end), oldest first, paging through
+// the klines endpoint
+func (c *Client) GetKlines(ctx context.Context, symbol, interval string, start, end time.Time) ([]*futures.Kline, error) {
+	var all []*futures.Kline
+	from := start.UnixMilli()
+	for from < end.UnixMilli() {
+		var page []*futures.Kline
+		err := c.retry.do(ctx, "get klines", func() (err error) {
+			page, err = c.Futures().NewKlinesService().
+				Symbol(symbol).
+				Interval(interval).
+				StartTime(from).
+				EndTime(end.UnixMilli() - 1).
+				Limit(klinePageLimit).
+				Do(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get klines: %w", err)
+		}
+		all = append(all, page...)
+		if len(page) < klinePageLimit {
+			break
+		}
+		from = page[len(page)-1].OpenTime + 1
+	}
+	return all, nil
+}
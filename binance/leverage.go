@@ -0,0 +1,133 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// leverageCache remembers the leverage last set per USDⓈ-M symbol so orders can skip the
+// change-leverage call when it already matches. It is filled lazily from the position risk
+// endpoint, which reports every symbol's leverage, and like bracketCache it is cleared when
+// the credentials change.
+type leverageCache struct {
+	mu      sync.Mutex
+	entries map[string]int
+	loaded  bool
+	hits    uint64
+	misses  uint64
+}
+
+// LeverageCacheStats reports how often orders could skip the change-leverage call
+type LeverageCacheStats struct {
+	Symbols int    `json:"symbols"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+}
+
+// lookup returns symbol's cached leverage and whether the cache has been filled from Binance
+func (l *leverageCache) lookup(symbol string) (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.entries[symbol], l.loaded
+}
+
+// load fills the cache from position risk data
+func (l *leverageCache) load(positions []*futures.PositionRisk) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.entries == nil {
+		l.entries = make(map[string]int)
+	}
+	for _, p := range positions {
+		if leverage, err := strconv.Atoi(p.Leverage); err == nil && leverage > 0 {
+			l.entries[p.Symbol] = leverage
+		}
+	}
+	l.loaded = true
+}
+
+func (l *leverageCache) set(symbol string, leverage int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.entries == nil {
+		l.entries = make(map[string]int)
+	}
+	l.entries[symbol] = leverage
+}
+
+// forget drops symbol after a failed change, when its leverage on Binance is unknown
+func (l *leverageCache) forget(symbol string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, symbol)
+}
+
+func (l *leverageCache) record(hit bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if hit {
+		l.hits++
+	} else {
+		l.misses++
+	}
+}
+
+func (l *leverageCache) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = nil
+	l.loaded = false
+}
+
+func (l *leverageCache) stats() LeverageCacheStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return LeverageCacheStats{Symbols: len(l.entries), Hits: l.hits, Misses: l.misses}
+}
+
+// LeverageCacheStats returns the hit and miss counts of the per-symbol leverage cache
+func (c *Client) LeverageCacheStats() LeverageCacheStats {
+	return c.leverage.stats()
+}
+
+// ensureLeverage sets symbol's leverage on fc unless the cache says it is already set
+func (c *Client) ensureLeverage(ctx context.Context, fc *futures.Client, symbol string, leverage int) error {
+	current, loaded := c.leverage.lookup(symbol)
+	if !loaded {
+		// One position risk call fills the cache for every symbol; if it fails the leverage is just set
+		if _, err := c.GetFuturesPositions(ctx); err == nil {
+			current, _ = c.leverage.lookup(symbol)
+		}
+	}
+	if current == leverage {
+		c.leverage.record(true)
+		return nil
+	}
+	c.leverage.record(false)
+	return c.changeLeverage(ctx, fc, symbol, leverage)
+}
+
+// ChangeLeverage sets symbol's leverage on Binance, whatever the cache holds
+func (c *Client) ChangeLeverage(ctx context.Context, symbol string, leverage int) error {
+	return c.changeLeverage(ctx, c.Futures(), symbol, leverage)
+}
+
+func (c *Client) changeLeverage(ctx context.Context, fc *futures.Client, symbol string, leverage int) error {
+	err := c.retry.do(ctx, "change leverage", func() error {
+		_, err := fc.NewChangeLeverageService().
+			Symbol(symbol).
+			Leverage(leverage).
+			Do(ctx, c.futuresRecvWindow(ctx))
+		return err
+	})
+	if err != nil {
+		c.leverage.forget(symbol)
+		return fmt.Errorf("failed to set leverage: %w", err)
+	}
+	c.leverage.set(symbol, leverage)
+	return nil
+}
@@ -0,0 +1,110 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"futures-options/config"
+)
+
+// newTestFuturesClient returns a client whose USDⓈ-M requests go to handler
+func newTestFuturesClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(&config.Config{BinanceTestnet: true, BinanceFuturesTestnetURL: server.URL})
+}
+
+func TestEnsureLeverageUsesCache(t *testing.T) {
+	var positionCalls, leverageCalls atomic.Int32
+	c := newTestFuturesClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/positionRisk"):
+			positionCalls.Add(1)
+			w.Write([]byte(`[{"symbol":"BTCUSDT","leverage":"20","positionAmt":"0"}]`))
+		case strings.HasSuffix(r.URL.Path, "/leverage"):
+			leverageCalls.Add(1)
+			w.Write([]byte(`{"symbol":"` + r.FormValue("symbol") + `","leverage":` + r.FormValue("leverage") + `}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	steps := []struct {
+		name         string
+		leverage     int
+		wantLeverage int32 // change-leverage calls so far
+	}{
+		{"matches the position risk leverage", 20, 0},
+		{"differs, so it is set", 10, 1},
+		{"now cached", 10, 1},
+		{"changed back", 20, 2},
+	}
+	for _, step := range steps {
+		if err := c.ensureLeverage(ctx, c.Futures(), "BTCUSDT", step.leverage); err != nil {
+			t.Fatalf("%s: ensureLeverage: %v", step.name, err)
+		}
+		if got := leverageCalls.Load(); got != step.wantLeverage {
+			t.Errorf("%s: %d change-leverage calls, want %d", step.name, got, step.wantLeverage)
+		}
+	}
+	if got := positionCalls.Load(); got != 1 {
+		t.Errorf("position risk fetched %d times, want once", got)
+	}
+	if stats := c.LeverageCacheStats(); stats.Hits != 2 || stats.Misses != 2 {
+		t.Errorf("stats = %+v, want 2 hits and 2 misses", stats)
+	}
+}
+
+func TestEnsureLeverageForgetsFailedChange(t *testing.T) {
+	var fail atomic.Bool
+	var leverageCalls atomic.Int32
+	c := newTestFuturesClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/positionRisk"):
+			w.Write([]byte(`[]`))
+		case strings.HasSuffix(r.URL.Path, "/leverage"):
+			leverageCalls.Add(1)
+			if fail.Load() {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":-4028,"msg":"Leverage 200 is not valid"}`))
+				return
+			}
+			w.Write([]byte(`{"symbol":"ETHUSDT","leverage":5}`))
+		}
+	})
+	ctx := context.Background()
+
+	if err := c.ensureLeverage(ctx, c.Futures(), "ETHUSDT", 5); err != nil {
+		t.Fatalf("ensureLeverage: %v", err)
+	}
+	fail.Store(true)
+	if err := c.ensureLeverage(ctx, c.Futures(), "ETHUSDT", 200); err == nil {
+		t.Fatal("ensureLeverage succeeded, want Binance's rejection")
+	}
+	// The rejected change leaves the leverage unknown, so 5 is set again rather than assumed
+	fail.Store(false)
+	if err := c.ensureLeverage(ctx, c.Futures(), "ETHUSDT", 5); err != nil {
+		t.Fatalf("ensureLeverage: %v", err)
+	}
+	if got := leverageCalls.Load(); got != 3 {
+		t.Errorf("%d change-leverage calls, want 3", got)
+	}
+}
+
+func TestSetCredentialsClearsLeverageCache(t *testing.T) {
+	c := NewClient(&config.Config{})
+	c.leverage.load(nil)
+	c.leverage.set("BTCUSDT", 10)
+
+	// Another account's leverage settings are unknown until its positions are fetched
+	c.SetCredentials("other-key", "other-secret", false)
+	if leverage, loaded := c.leverage.lookup("BTCUSDT"); leverage != 0 || loaded {
+		t.Errorf("after SetCredentials: leverage %d loaded %v, want 0 false", leverage, loaded)
+	}
+}
@@ -0,0 +1,28 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// errCodeNoMarginTypeChange is Binance's answer when a symbol already has the margin type asked for
+const errCodeNoMarginTypeChange = -4046
+
+// ChangeMarginType sets symbol's margin type, ISOLATED or CROSSED, on Binance. A symbol that
+// already has it is not an error. Binance refuses the change while the symbol has a position or
+// open orders.
+func (c *Client) ChangeMarginType(ctx context.Context, symbol, marginType string) error {
+	fc := c.Futures()
+	err := c.retry.do(ctx, "change margin type", func() error {
+		return fc.NewChangeMarginTypeService().
+			Symbol(symbol).
+			MarginType(futures.MarginType(marginType)).
+			Do(ctx, c.futuresRecvWindow(ctx))
+	})
+	if err != nil && APIErrorCode(err) != errCodeNoMarginTypeChange {
+		return fmt.Errorf("failed to set margin type: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package binance
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// networkProbeTimeout bounds each connectivity probe of ProbeNetwork
+const networkProbeTimeout = 5 * time.Second
+
+// network is the outbound proxy and TLS configuration every connection to Binance uses
+type network struct {
+	proxyURL   *url.URL // nil uses HTTPS_PROXY/HTTP_PROXY/NO_PROXY from the environment
+	caCertPath string
+	transport  *http.Transport
+	dialer     *websocket.Dialer
+}
+
+var currentNetwork atomic.Pointer[network]
+
+func init() {
+	n, _ := newNetwork("", "")
+	currentNetwork.Store(n)
+}
+
+// ConfigureNetwork routes every REST request and WebSocket connection to Binance through
+// proxyURL (http, https or socks5; empty falls back to the proxy environment variables) and
+// trusts the PEM certificates in caCertPath besides the system roots. Call it before NewClient:
+// clients built earlier keep the previous transport.
+func ConfigureNetwork(proxyURL, caCertPath string) error {
+	n, err := newNetwork(proxyURL, caCertPath)
+	if err != nil {
+		return err
+	}
+	currentNetwork.Store(n)
+	return nil
+}
+
+func newNetwork(proxyURL, caCertPath string) (*network, error) {
+	n := &network{caCertPath: caCertPath}
+	proxy := http.ProxyFromEnvironment
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q; use http, https or socks5", u.Scheme)
+		}
+		n.proxyURL = u
+		proxy = http.ProxyURL(u)
+	}
+
+	var tlsConfig *tls.Config
+	if caCertPath != "" {
+		pem, err := os.ReadFile(caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", caCertPath)
+		}
+		tlsConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+
+	n.transport = http.DefaultTransport.(*http.Transport).Clone()
+	n.transport.Proxy = proxy
+	n.transport.TLSClientConfig = tlsConfig
+	n.dialer = &websocket.Dialer{
+		Proxy:            proxy,
+		HandshakeTimeout: websocket.DefaultDialer.HandshakeTimeout,
+		TLSClientConfig:  tlsConfig,
+	}
+	return n, nil
+}
+
+// httpTransport returns the transport of REST requests to Binance
+func httpTransport() *http.Transport {
+	return currentNetwork.Load().transport
+}
+
+// wsDialer returns the dialer of WebSocket connections to Binance
+func wsDialer() *websocket.Dialer {
+	return currentNetwork.Load().dialer
+}
+
+// NetworkDiagnostics reports how connections to Binance leave this host and whether each
+// endpoint is reachable that way
+type NetworkDiagnostics struct {
+	Network string `json:"network"`
+	// ProxySource is "config" for BINANCE_PROXY_URL, else "environment" (HTTPS_PROXY, NO_PROXY)
+	ProxySource string         `json:"proxy_source"`
+	CACertPath  string         `json:"ca_cert_path,omitempty"`
+	Probes      []NetworkProbe `json:"probes"`
+}
+
+// NetworkProbe is one client's endpoint, the proxy its connections use and a connection attempt
+type NetworkProbe struct {
+	Client    string `json:"client"`
+	URL       string `json:"url"`
+	Proxy     string `json:"proxy"` // "direct" without one; credentials are redacted
+	OK        bool   `json:"ok"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ProbeNetwork connects to the endpoint of each REST and WebSocket client on the active network
+// through the configured proxy. Probes skip the circuit breaker, so they show the path itself.
+func (c *Client) ProbeNetwork(ctx context.Context) *NetworkDiagnostics {
+	n := currentNetwork.Load()
+	cfg := c.EffectiveConfig()
+	d := &NetworkDiagnostics{Network: NetworkName(cfg.BinanceTestnet), ProxySource: "environment", CACertPath: n.caCertPath}
+	if n.proxyURL != nil {
+		d.ProxySource = "config"
+	}
+
+	wsAPIURL, streamURL := cfg.BinanceFuturesWSAPIURL, "wss://fstream.binance.com/ws"
+	if cfg.BinanceTestnet {
+		wsAPIURL, streamURL = cfg.BinanceFuturesWSAPIURLTest, "wss://fstream.binancefuture.com/ws"
+	}
+	d.Probes = []NetworkProbe{
+		n.probeREST(ctx, "futures REST and server time", c.Futures().BaseURL+"/fapi/v1/time"),
+		n.probeREST(ctx, "COIN-M REST", c.Delivery().BaseURL+"/dapi/v1/ping"),
+		n.probeREST(ctx, "spot REST", c.Spot().BaseURL+"/api/v3/ping"),
+	}
+	if !cfg.BinanceTestnet {
+		// Options have no testnet
+		d.Probes = append(d.Probes, n.probeREST(ctx, "options REST", "https://eapi.binance.com/eapi/v1/ping"))
+	}
+	d.Probes = append(d.Probes,
+		n.probeWebSocket(ctx, "WebSocket API", wsAPIURL),
+		n.probeWebSocket(ctx, "user data and market streams", streamURL),
+	)
+	return d
+}
+
+// probeREST sends an unauthenticated GET to rawURL
+func (n *network) probeREST(ctx context.Context, client, rawURL string) NetworkProbe {
+	p := NetworkProbe{Client: client, URL: rawURL}
+	ctx, cancel := context.WithTimeout(ctx, networkProbeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		p.Error = err.Error()
+		return p
+	}
+	p.Proxy = n.proxyFor(req)
+
+	start := time.Now()
+	resp, err := (&http.Client{Transport: n.transport}).Do(req)
+	p.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		p.Error = err.Error()
+		return p
+	}
+	resp.Body.Close()
+	p.OK = resp.StatusCode == http.StatusOK
+	if !p.OK {
+		p.Error = "unexpected status " + resp.Status
+	}
+	return p
+}
+
+// probeWebSocket opens a connection to rawURL and closes it again
+func (n *network) probeWebSocket(ctx context.Context, client, rawURL string) NetworkProbe {
+	p := NetworkProbe{Client: client, URL: rawURL}
+	ctx, cancel := context.WithTimeout(ctx, networkProbeTimeout)
+	defer cancel()
+	if u, err := url.Parse(rawURL); err == nil {
+		// The dialer resolves proxies for the equivalent http(s) URL
+		switch u.Scheme {
+		case "wss":
+			u.Scheme = "https"
+		case "ws":
+			u.Scheme = "http"
+		}
+		p.Proxy = n.proxyFor(&http.Request{URL: u})
+	}
+
+	start := time.Now()
+	conn, _, err := n.dialer.DialContext(ctx, rawURL, nil)
+	p.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		p.Error = err.Error()
+		return p
+	}
+	conn.Close()
+	p.OK = true
+	return p
+}
+
+// proxyFor describes the proxy req would go through
+func (n *network) proxyFor(req *http.Request) string {
+	proxy, err := n.transport.Proxy(req)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	if proxy == nil {
+		return "direct"
+	}
+	return proxy.Redacted()
+}
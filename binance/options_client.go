@@ -2,9 +2,6 @@ package binance
 
 import (
 	"context"
-    "crypto/hmac"
-    "crypto/sha256"
-    "encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -13,6 +10,8 @@ import (
 	"time"
 
 	"futures-options/config"
+
+	"github.com/shopspring/decimal"
 )
 
 // OptionsClient handles Binance Options API calls
@@ -22,6 +21,8 @@ type OptionsClient struct {
 	httpClient *http.Client
     apiKey     string
     secretKey  string
+	retry      retryPolicy
+	clock      *clockOffset // shared with the Client that built this one; nil applies no offset
 }
 
 // NewOptionsClient creates a new Options client
@@ -34,9 +35,10 @@ func NewOptionsClient(cfg *config.Config) *OptionsClient {
 	}
 	return &OptionsClient{
 		config:     cfg,
-		httpClient: &http.Client{Timeout: 10 * time.Second},
+		httpClient: &http.Client{Timeout: 10 * time.Second, Transport: httpTransport()},
         apiKey:     cfg.BinanceAPIKey,
         secretKey:  cfg.BinanceSecretKey,
+		retry:      newRetryPolicy(cfg),
 	}
 }
 
@@ -44,13 +46,21 @@ func (oc *OptionsClient) signParams(params url.Values) (string, error) {
     if oc.secretKey == "" || oc.apiKey == "" {
         return "", fmt.Errorf("options API keys not configured")
     }
-    mac := hmac.New(sha256.New, []byte(oc.secretKey))
-    mac.Write([]byte(params.Encode()))
-    return hex.EncodeToString(mac.Sum(nil)), nil
+    return signREST(oc.config, oc.secretKey, params.Encode())
 }
 
-// CreateOptionsOrder creates an options order
+// CreateOptionsOrder creates an options order. Only a rejected timestamp is retried, as Binance
+// refuses such an order before placing it.
 func (oc *OptionsClient) CreateOptionsOrder(ctx context.Context, req *OptionsOrderRequest) (*OptionsOrderResponse, error) {
+	var order *OptionsOrderResponse
+	err := oc.retry.resyncing(ctx, "create options order", func(ctx context.Context) (err error) {
+		order, err = oc.createOptionsOrder(ctx, req)
+		return err
+	})
+	return order, err
+}
+
+func (oc *OptionsClient) createOptionsOrder(ctx context.Context, req *OptionsOrderRequest) (*OptionsOrderResponse, error) {
 	baseURL := "https://eapi.binance.com"
 	if oc.config.BinanceTestnet {
         return nil, fmt.Errorf("Binance Options testnet is not available. Use mainnet for Options endpoints")
@@ -62,10 +72,10 @@ func (oc *OptionsClient) CreateOptionsOrder(ctx context.Context, req *OptionsOrd
 	params.Set("symbol", req.Symbol)
 	params.Set("side", req.Side)
 	params.Set("type", req.OrderType)
-	params.Set("quantity", strconv.FormatFloat(req.Quantity, 'f', -1, 64))
+	params.Set("quantity", req.Quantity.String())
 
-	if req.Price > 0 {
-		params.Set("price", strconv.FormatFloat(req.Price, 'f', -1, 64))
+	if req.Price.IsPositive() {
+		params.Set("price", req.Price.String())
 	}
 
 	if req.TimeInForce != "" {
@@ -73,7 +83,8 @@ func (oc *OptionsClient) CreateOptionsOrder(ctx context.Context, req *OptionsOrd
 	}
 
     // Signed parameters
-    params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+    params.Set("timestamp", strconv.FormatInt(oc.clock.timestamp(), 10))
+    params.Set("recvWindow", strconv.FormatInt(RecvWindowOf(ctx, oc.config.BinanceRecvWindow), 10))
     sig, err := oc.signParams(params)
 	if err != nil {
         return nil, fmt.Errorf("signing failed: %w", err)
@@ -93,7 +104,7 @@ func (oc *OptionsClient) CreateOptionsOrder(ctx context.Context, req *OptionsOrd
     defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("options order failed with status: %d", resp.StatusCode)
+		return nil, fmt.Errorf("options order failed: %w", newHTTPError(resp))
 	}
 
 	var result OptionsOrderResponse
@@ -104,8 +115,18 @@ func (oc *OptionsClient) CreateOptionsOrder(ctx context.Context, req *OptionsOrd
 	return &result, nil
 }
 
-// GetOptionsPositions gets current options positions
+// GetOptionsPositions gets current options positions, retrying transient failures
 func (oc *OptionsClient) GetOptionsPositions(ctx context.Context) ([]*OptionsPosition, error) {
+	var positions []*OptionsPosition
+	err := oc.retry.do(ctx, "get options positions", func() (err error) {
+		// Each attempt is signed with a fresh timestamp
+		positions, err = oc.getOptionsPositions(ctx)
+		return err
+	})
+	return positions, err
+}
+
+func (oc *OptionsClient) getOptionsPositions(ctx context.Context) ([]*OptionsPosition, error) {
 	baseURL := "https://eapi.binance.com"
 	if oc.config.BinanceTestnet {
         return nil, fmt.Errorf("Binance Options testnet is not available. Use mainnet for Options endpoints")
@@ -114,7 +135,8 @@ func (oc *OptionsClient) GetOptionsPositions(ctx context.Context) ([]*OptionsPos
 	endpoint := baseURL + "/eapi/v1/account"
 
     params := url.Values{}
-    params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+    params.Set("timestamp", strconv.FormatInt(oc.clock.timestamp(), 10))
+    params.Set("recvWindow", strconv.FormatInt(RecvWindowOf(ctx, oc.config.BinanceRecvWindow), 10))
     sig, err := oc.signParams(params)
     if err != nil {
         return nil, fmt.Errorf("signing failed: %w", err)
@@ -134,7 +156,7 @@ func (oc *OptionsClient) GetOptionsPositions(ctx context.Context) ([]*OptionsPos
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get positions with status: %d", resp.StatusCode)
+		return nil, fmt.Errorf("failed to get positions: %w", newHTTPError(resp))
 	}
 
 	var account struct {
@@ -152,21 +174,25 @@ type OptionsOrderRequest struct {
 	Symbol      string
 	Side        string
 	OrderType   string
-	Quantity    float64
-	Price       float64
+	Quantity    decimal.Decimal
+	Price       decimal.Decimal
 	TimeInForce string
 }
 
 // OptionsOrderResponse represents an options order response
 type OptionsOrderResponse struct {
-	OrderID    int64  `json:"orderId"`
-	Symbol     string `json:"symbol"`
-	Status     string `json:"status"`
-	Side       string `json:"side"`
-	Type       string `json:"type"`
-	Quantity   string `json:"quantity"`
-	Price      string `json:"price"`
-	CreateTime int64  `json:"createTime"`
+	OrderID     int64  `json:"orderId"`
+	Symbol      string `json:"symbol"`
+	Status      string `json:"status"`
+	Side        string `json:"side"`
+	Type        string `json:"type"`
+	Quantity    string `json:"quantity"`
+	Price       string `json:"price"`
+	ExecutedQty string `json:"executedQty"`
+	AvgPrice    string `json:"avgPrice"`
+	Fee         string `json:"fee"` // commission of the executed quantity, in the quote asset
+	CreateTime  int64  `json:"createTime"`
+	UpdateTime  int64  `json:"updateTime"`
 }
 
 // OptionsPosition represents an options position
@@ -178,3 +204,136 @@ type OptionsPosition struct {
 	UnrealizedPnl float64 `json:"unrealizedPnl"`
 }
 
+
+// GetExchangeInfo gets the options contracts and their trading rules, retrying transient failures
+func (oc *OptionsClient) GetExchangeInfo(ctx context.Context) (*OptionsExchangeInfo, error) {
+	var info *OptionsExchangeInfo
+	err := oc.retry.do(ctx, "get options exchange info", func() (err error) {
+		info, err = oc.getExchangeInfo(ctx)
+		return err
+	})
+	return info, err
+}
+
+func (oc *OptionsClient) getExchangeInfo(ctx context.Context) (*OptionsExchangeInfo, error) {
+	baseURL := "https://eapi.binance.com"
+	if oc.config.BinanceTestnet {
+		return nil, fmt.Errorf("Binance Options testnet is not available. Use mainnet for Options endpoints")
+	}
+
+	// Public endpoint: no signature needed
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/eapi/v1/exchangeInfo", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	resp, err := oc.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get options exchange info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get options exchange info: %w", newHTTPError(resp))
+	}
+
+	var info OptionsExchangeInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &info, nil
+}
+
+// OptionsMark is an options contract's mark price, implied volatility and Greeks (per contract)
+type OptionsMark struct {
+	Symbol    string  `json:"symbol"`
+	MarkPrice float64 `json:"markPrice,string"`
+	MarkIV    float64 `json:"markIV,string"`
+	Delta     float64 `json:"delta,string"`
+	Gamma     float64 `json:"gamma,string"`
+	Theta     float64 `json:"theta,string"`
+	Vega      float64 `json:"vega,string"`
+}
+
+// GetMarks gets the mark price and Greeks of every options contract, retrying transient failures
+func (oc *OptionsClient) GetMarks(ctx context.Context) ([]*OptionsMark, error) {
+	var marks []*OptionsMark
+	err := oc.retry.do(ctx, "get options marks", func() (err error) {
+		marks, err = oc.getMarks(ctx)
+		return err
+	})
+	return marks, err
+}
+
+func (oc *OptionsClient) getMarks(ctx context.Context) ([]*OptionsMark, error) {
+	baseURL := "https://eapi.binance.com"
+	if oc.config.BinanceTestnet {
+		return nil, fmt.Errorf("Binance Options testnet is not available. Use mainnet for Options endpoints")
+	}
+
+	// Public endpoint: no signature needed
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/eapi/v1/mark", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	resp, err := oc.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get options marks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get options marks: %w", newHTTPError(resp))
+	}
+
+	var marks []*OptionsMark
+	if err := json.NewDecoder(resp.Body).Decode(&marks); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return marks, nil
+}
+
+// OptionsExchangeInfo lists the options contracts
+type OptionsExchangeInfo struct {
+	OptionSymbols []*OptionsSymbol `json:"optionSymbols"`
+}
+
+// OptionsSymbol is an options contract and its trading rules
+type OptionsSymbol struct {
+	Symbol        string          `json:"symbol"`
+	Underlying    string          `json:"underlying"`
+	Side          string          `json:"side"` // CALL or PUT
+	StrikePrice   string          `json:"strikePrice"`
+	ExpiryDate    int64           `json:"expiryDate"` // Unix ms
+	Unit          float64         `json:"unit"`       // underlying per contract
+	QuoteAsset    string          `json:"quoteAsset"`
+	PriceScale    int             `json:"priceScale"`
+	QuantityScale int             `json:"quantityScale"`
+	Filters       []OptionsFilter `json:"filters"`
+}
+
+// OptionsFilter is a PRICE_FILTER or LOT_SIZE rule of an options contract
+type OptionsFilter struct {
+	FilterType string `json:"filterType"`
+	MinPrice   string `json:"minPrice,omitempty"`
+	MaxPrice   string `json:"maxPrice,omitempty"`
+	TickSize   string `json:"tickSize,omitempty"`
+	MinQty     string `json:"minQty,omitempty"`
+	MaxQty     string `json:"maxQty,omitempty"`
+	StepSize   string `json:"stepSize,omitempty"`
+}
+
+// SymbolFilters returns the contract's tick and lot rules in the shape used for futures and spot
+func (s *OptionsSymbol) SymbolFilters() *SymbolFilters {
+	f := &SymbolFilters{Symbol: s.Symbol}
+	for _, filter := range s.Filters {
+		switch filter.FilterType {
+		case "PRICE_FILTER":
+			f.TickSize = parseFilterValue(filter.TickSize)
+		case "LOT_SIZE":
+			f.StepSize = parseFilterValue(filter.StepSize)
+			f.MinQty = parseFilterValue(filter.MinQty)
+			f.MaxQty = parseFilterValue(filter.MaxQty)
+		}
+	}
+	return f
+}
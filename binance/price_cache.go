@@ -0,0 +1,214 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+const (
+	// priceCacheTTL is how long a price is served before a read fetches it again. The mark price
+	// stream updates every second, so while it is subscribed mark reads never reach REST.
+	priceCacheTTL = 2 * time.Second
+	// priceFetchTimeout bounds a REST price fetch, which outlives the reader that started it
+	priceFetchTimeout = 10 * time.Second
+)
+
+// errPriceCacheInvalidated is returned to a fetch that finished after the network changed
+var errPriceCacheInvalidated = errors.New("price cache was invalidated while the price was fetched")
+
+// Price is a symbol's mark or last price and when it was observed
+type Price struct {
+	Symbol   string              `json:"symbol"`
+	Source   futures.WorkingType `json:"source"` // MARK_PRICE or CONTRACT_PRICE (last traded price)
+	Value    float64             `json:"price"`
+	Time     time.Time           `json:"time"`
+	Streamed bool                `json:"streamed"` // from the market stream rather than REST
+}
+
+// Age returns how long ago the price was observed
+func (p Price) Age() time.Duration {
+	return time.Since(p.Time)
+}
+
+type priceKey struct {
+	symbol string
+	source futures.WorkingType
+}
+
+// priceFetch is a REST fetch in progress; concurrent readers of the same price wait on done
+type priceFetch struct {
+	done  chan struct{}
+	price Price
+	err   error
+}
+
+// PriceCache serves the USDⓈ-M mark and last prices that validation, sizing and risk checks
+// need. Prices come from the market stream while one is subscribed (see SubscribePrices) and
+// from REST otherwise; concurrent reads of a missing price share a single request.
+type PriceCache struct {
+	client *Client
+
+	mu       sync.Mutex
+	prices   map[priceKey]Price
+	inflight map[priceKey]*priceFetch
+	gen      uint64 // bumped by invalidate so stale fetches and streams are dropped
+}
+
+func newPriceCache(c *Client) *PriceCache {
+	return &PriceCache{
+		client:   c,
+		prices:   make(map[priceKey]Price),
+		inflight: make(map[priceKey]*priceFetch),
+	}
+}
+
+// get returns the price of symbol from source, fetching it when the cached one is older than
+// maxAge (priceCacheTTL when zero)
+func (p *PriceCache) get(ctx context.Context, symbol string, source futures.WorkingType, maxAge time.Duration) (Price, error) {
+	if maxAge <= 0 || maxAge > priceCacheTTL {
+		maxAge = priceCacheTTL
+	}
+	key := priceKey{symbol: symbol, source: source}
+
+	p.mu.Lock()
+	if price, ok := p.prices[key]; ok && price.Age() <= maxAge {
+		p.mu.Unlock()
+		return price, nil
+	}
+	fetch, ok := p.inflight[key]
+	if !ok {
+		fetch = &priceFetch{done: make(chan struct{})}
+		p.inflight[key] = fetch
+		go p.fetch(key, fetch, p.gen)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-fetch.done:
+		return fetch.price, fetch.err
+	case <-ctx.Done():
+		return Price{}, ctx.Err()
+	}
+}
+
+// fetch loads one price over REST. It runs detached from the reader that started it, so a
+// cancelled reader does not fail the others waiting on the same fetch.
+func (p *PriceCache) fetch(key priceKey, fetch *priceFetch, gen uint64) {
+	ctx, cancel := context.WithTimeout(context.Background(), priceFetchTimeout)
+	defer cancel()
+
+	var value float64
+	var err error
+	if key.source == futures.WorkingTypeMarkPrice {
+		value, err = p.client.fetchMarkPrice(ctx, key.symbol)
+	} else {
+		value, err = p.client.fetchLastPrice(ctx, key.symbol)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.inflight, key)
+	switch {
+	case err != nil:
+		fetch.err = err
+	case gen != p.gen:
+		fetch.err = errPriceCacheInvalidated
+	default:
+		fetch.price = Price{Symbol: key.symbol, Source: key.source, Value: value, Time: time.Now()}
+		p.prices[key] = fetch.price
+	}
+	close(fetch.done)
+}
+
+// observe stores prices received from the market stream
+func (p *PriceCache) observe(gen uint64, updates []PriceUpdate) {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if gen != p.gen {
+		return
+	}
+	for _, u := range updates {
+		p.prices[priceKey{symbol: u.Symbol, source: u.Source}] = Price{
+			Symbol: u.Symbol, Source: u.Source, Value: u.Price, Time: now, Streamed: true,
+		}
+	}
+}
+
+func (p *PriceCache) generation() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.gen
+}
+
+// invalidate drops every price; fetches and streams started before it are ignored
+func (p *PriceCache) invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prices = make(map[priceKey]Price)
+	p.inflight = make(map[priceKey]*priceFetch)
+	p.gen++
+}
+
+// GetPrice returns symbol's mark (futures.WorkingTypeMarkPrice) or last
+// (futures.WorkingTypeContractPrice) price. maxAge bounds how old the returned price may be;
+// zero accepts anything within the cache TTL of two seconds.
+func (c *Client) GetPrice(ctx context.Context, symbol string, source futures.WorkingType, maxAge time.Duration) (Price, error) {
+	return c.prices.get(ctx, symbol, source, maxAge)
+}
+
+// SubscribePrices streams every symbol's mark and last price into the price cache, passing each
+// batch on to handle (which may be nil), until ctx is done (returning nil) or the stream fails
+func (c *Client) SubscribePrices(ctx context.Context, handle func(updates []PriceUpdate)) error {
+	gen := c.prices.generation()
+	return StreamPrices(ctx, c.IsTestnet(), func(updates []PriceUpdate) {
+		c.prices.observe(gen, updates)
+		if handle != nil {
+			handle(updates)
+		}
+	})
+}
+
+func (c *Client) fetchMarkPrice(ctx context.Context, symbol string) (float64, error) {
+	var res []*futures.PremiumIndex
+	err := c.retry.do(ctx, "get mark price", func() (err error) {
+		res, err = c.Futures().NewPremiumIndexService().Symbol(symbol).Do(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get mark price: %w", err)
+	}
+	for _, p := range res {
+		if p.Symbol == symbol {
+			if price, err := strconv.ParseFloat(p.MarkPrice, 64); err == nil && price > 0 {
+				return price, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("no mark price for %s", symbol)
+}
+
+func (c *Client) fetchLastPrice(ctx context.Context, symbol string) (float64, error) {
+	var res []*futures.SymbolPrice
+	err := c.retry.do(ctx, "get last price", func() (err error) {
+		res, err = c.Futures().NewListPricesService().Symbol(symbol).Do(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last price: %w", err)
+	}
+	for _, p := range res {
+		if p.Symbol == symbol {
+			if price, err := strconv.ParseFloat(p.Price, 64); err == nil && price > 0 {
+				return price, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("no last price for %s", symbol)
+}
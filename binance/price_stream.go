@@ -0,0 +1,85 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// priceStreams combines every symbol's mark price (each second) and last price
+const priceStreams = "!markPrice@arr@1s/!miniTicker@arr"
+
+// PriceUpdate is one symbol's price from the public market streams
+type PriceUpdate struct {
+	Symbol string
+	Source futures.WorkingType // MARK_PRICE or CONTRACT_PRICE (last traded price)
+	Price  float64
+}
+
+// StreamPrices subscribes to the mark and last prices of all symbols on the given network and
+// passes each batch to handle until ctx is done (returning nil) or the connection fails
+func StreamPrices(ctx context.Context, testnet bool, handle func(updates []PriceUpdate)) error {
+	url := "wss://fstream.binance.com/stream?streams=" + priceStreams
+	if testnet {
+		url = "wss://fstream.binancefuture.com/stream?streams=" + priceStreams
+	}
+
+	conn, _, err := wsDialer().DialContext(ctx, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to price stream: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock ReadMessage when ctx is done
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("price stream read failed: %w", err)
+		}
+
+		var envelope struct {
+			Stream string `json:"stream"`
+			Data   []struct {
+				Symbol     string `json:"s"`
+				MarkPrice  string `json:"p"`
+				ClosePrice string `json:"c"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(message, &envelope); err != nil {
+			continue
+		}
+
+		source := futures.WorkingTypeContractPrice
+		if strings.HasPrefix(envelope.Stream, "!markPrice") {
+			source = futures.WorkingTypeMarkPrice
+		}
+		updates := make([]PriceUpdate, 0, len(envelope.Data))
+		for _, d := range envelope.Data {
+			value := d.ClosePrice
+			if source == futures.WorkingTypeMarkPrice {
+				value = d.MarkPrice
+			}
+			if price, err := strconv.ParseFloat(value, 64); err == nil && price > 0 {
+				updates = append(updates, PriceUpdate{Symbol: d.Symbol, Source: source, Price: price})
+			}
+		}
+		handle(updates)
+	}
+}
@@ -0,0 +1,309 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"futures-options/latency"
+)
+
+// Rate limit types Binance reports
+const (
+	RateLimitRequestWeight = "REQUEST_WEIGHT"
+	RateLimitOrders        = "ORDERS"
+)
+
+// maxOrderLimitDelay is how long an order may be held back for its order-count window to reset
+// before it is rejected instead
+const maxOrderLimitDelay = 2 * time.Second
+
+// intervalUnits maps the first letter of Binance's rate limit intervals (SECOND, MINUTE, HOUR,
+// DAY, or the S/M/H/D of response headers) to its length
+var intervalUnits = map[string]time.Duration{"S": time.Second, "M": time.Minute, "H": time.Hour, "D": 24 * time.Hour}
+
+// WSRateLimit is an entry of the rateLimits array of a WS-API response
+type WSRateLimit struct {
+	RateLimitType string `json:"rateLimitType"`
+	Interval      string `json:"interval"`
+	IntervalNum   int    `json:"intervalNum"`
+	Limit         int    `json:"limit"`
+	Count         int    `json:"count"`
+}
+
+// RateLimit is the usage of one Binance rate limit as last reported over REST or the WS-API
+type RateLimit struct {
+	Source   string `json:"source"` // the latency target, e.g. binance_futures_rest or binance_ws_api
+	Type     string `json:"rate_limit_type"`
+	Interval string `json:"interval"` // e.g. 10s or 1m
+	// Limit is 0 until exchange info or a WS-API response reports it
+	Limit int `json:"limit,omitempty"`
+	// Count is 0 once the window it was reported in has passed
+	Count       int       `json:"count"`
+	UsedPercent float64   `json:"used_percent,omitempty"`
+	ObservedAt  time.Time `json:"observed_at"`
+	ResetsAt    time.Time `json:"resets_at"`
+
+	window time.Duration
+}
+
+// RateLimitError rejects an order before it is sent, because the order-count limit Binance last
+// reported is used up for longer than the order could be held back
+type RateLimitError struct {
+	Source     string        `json:"source"`
+	Interval   string        `json:"interval"`
+	Limit      int           `json:"limit"`
+	Count      int           `json:"count"`
+	RetryAfter time.Duration `json:"-"`
+	RetrySecs  int           `json:"retry_after_seconds"`
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("Binance order rate limit reached: %d of %d orders per %s used on %s, retry in %ds",
+		e.Count, e.Limit, e.Interval, e.Source, e.RetrySecs)
+}
+
+type rateLimitKey struct {
+	source string
+	typ    string
+	window time.Duration
+}
+
+// rateLimitTracker keeps the latest usage Binance reported for each rate limit, from REST
+// response headers and WS-API responses, and the limits exchange info lists
+type rateLimitTracker struct {
+	mu     sync.Mutex
+	usage  map[rateLimitKey]*RateLimit
+	limits map[rateLimitKey]int
+}
+
+var rateLimits = &rateLimitTracker{usage: map[rateLimitKey]*RateLimit{}, limits: map[rateLimitKey]int{}}
+
+// RateLimits returns the latest usage of each Binance rate limit, ordered by source, type and
+// window
+func RateLimits() []RateLimit {
+	return rateLimits.snapshot(time.Now())
+}
+
+// rateWindow returns the length and label (e.g. 10s) of num units of a rate limit interval
+func rateWindow(num int, unit string) (time.Duration, string, bool) {
+	if num <= 0 || unit == "" {
+		return 0, "", false
+	}
+	letter := strings.ToUpper(unit[:1])
+	d, ok := intervalUnits[letter]
+	if !ok {
+		return 0, "", false
+	}
+	return time.Duration(num) * d, strconv.Itoa(num) + strings.ToLower(letter), true
+}
+
+// setLimit records a limit listed in exchange info
+func (t *rateLimitTracker) setLimit(source, typ string, num int, unit string, limit int) {
+	window, _, ok := rateWindow(num, unit)
+	if !ok || limit <= 0 {
+		return
+	}
+	key := rateLimitKey{source: source, typ: typ, window: window}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limits[key] = limit
+	if r, ok := t.usage[key]; ok {
+		r.Limit = limit
+	}
+}
+
+// observe records a count Binance reported at, with its limit when the report includes it
+func (t *rateLimitTracker) observe(source, typ string, num int, unit string, limit, count int, at time.Time) {
+	window, label, ok := rateWindow(num, unit)
+	if !ok {
+		return
+	}
+	key := rateLimitKey{source: source, typ: typ, window: window}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if limit > 0 {
+		t.limits[key] = limit
+	}
+	t.usage[key] = &RateLimit{
+		Source:     source,
+		Type:       typ,
+		Interval:   label,
+		Limit:      t.limits[key],
+		Count:      count,
+		ObservedAt: at,
+		ResetsAt:   at.Truncate(window).Add(window),
+		window:     window,
+	}
+}
+
+// observeHeaders records the X-MBX-USED-WEIGHT-<interval> and X-MBX-ORDER-COUNT-<interval>
+// headers of a REST response
+func (t *rateLimitTracker) observeHeaders(source string, h http.Header, at time.Time) {
+	for name, values := range h {
+		upper := strings.ToUpper(name)
+		var typ, suffix string
+		switch {
+		case strings.HasPrefix(upper, "X-MBX-USED-WEIGHT-"):
+			typ, suffix = RateLimitRequestWeight, strings.TrimPrefix(upper, "X-MBX-USED-WEIGHT-")
+		case strings.HasPrefix(upper, "X-MBX-ORDER-COUNT-"):
+			typ, suffix = RateLimitOrders, strings.TrimPrefix(upper, "X-MBX-ORDER-COUNT-")
+		default:
+			continue
+		}
+		if len(values) == 0 || len(suffix) < 2 {
+			continue
+		}
+		count, err := strconv.Atoi(values[0])
+		if err != nil {
+			continue
+		}
+		num, err := strconv.Atoi(suffix[:len(suffix)-1])
+		if err != nil {
+			continue
+		}
+		t.observe(source, typ, num, suffix[len(suffix)-1:], 0, count, at)
+	}
+}
+
+// observeWS records the rateLimits of a WS-API response
+func (t *rateLimitTracker) observeWS(limits []WSRateLimit, at time.Time) {
+	for _, l := range limits {
+		t.observe(latency.BinanceWSAPI, l.RateLimitType, l.IntervalNum, l.Interval, l.Limit, l.Count, at)
+	}
+}
+
+func (t *rateLimitTracker) snapshot(now time.Time) []RateLimit {
+	t.mu.Lock()
+	out := make([]RateLimit, 0, len(t.usage))
+	for _, r := range t.usage {
+		out = append(out, *r)
+	}
+	t.mu.Unlock()
+
+	for i := range out {
+		r := &out[i]
+		if !now.Before(r.ResetsAt) {
+			r.Count = 0
+		}
+		if r.Limit > 0 {
+			r.UsedPercent = math.Round(float64(r.Count)/float64(r.Limit)*1000) / 10
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Source != out[j].Source {
+			return out[i].Source < out[j].Source
+		}
+		if out[i].Type != out[j].Type {
+			return out[i].Type < out[j].Type
+		}
+		return out[i].window < out[j].window
+	})
+	return out
+}
+
+// reserveOrder holds back an order sent to source until it fits the order-count limits Binance
+// last reported there, then counts it against them so a burst does not overshoot before the next
+// report. An order that would have to wait longer than maxOrderLimitDelay, or past ctx's
+// deadline, is rejected with a *RateLimitError.
+func (t *rateLimitTracker) reserveOrder(ctx context.Context, source string) error {
+	for {
+		full, wait := t.tryReserveOrder(source, time.Now())
+		if full == nil {
+			return nil
+		}
+		deadline, hasDeadline := ctx.Deadline()
+		if wait > maxOrderLimitDelay || hasDeadline && time.Until(deadline) < wait {
+			return &RateLimitError{
+				Source:     full.Source,
+				Interval:   full.Interval,
+				Limit:      full.Limit,
+				Count:      full.Count,
+				RetryAfter: wait,
+				RetrySecs:  int(math.Ceil(wait.Seconds())),
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// tryReserveOrder counts an order against source's order-count limits if none is used up, and
+// otherwise returns the one that resets last and how long until it does
+func (t *rateLimitTracker) tryReserveOrder(source string, now time.Time) (*RateLimit, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var current []*RateLimit
+	var full *RateLimit
+	var wait time.Duration
+	for key, r := range t.usage {
+		if key.source != source || key.typ != RateLimitOrders || r.Limit <= 0 || !now.Before(r.ResetsAt) {
+			continue
+		}
+		current = append(current, r)
+		if r.Count >= r.Limit && r.ResetsAt.Sub(now) > wait {
+			full, wait = r, r.ResetsAt.Sub(now)
+		}
+	}
+	if full != nil {
+		fullCopy := *full
+		return &fullCopy, wait
+	}
+	for _, r := range current {
+		r.Count++
+	}
+	return nil, 0
+}
+
+// isOrderRequest reports whether a REST request places or modifies an order, which counts
+// against the order-count limits
+func isOrderRequest(req *http.Request) bool {
+	if req.Method != http.MethodPost && req.Method != http.MethodPut {
+		return false
+	}
+	return strings.HasSuffix(req.URL.Path, "/order") || strings.HasSuffix(req.URL.Path, "/batchOrders")
+}
+
+// isOrderMethod reports whether a WS-API method places or modifies an order
+func isOrderMethod(method string) bool {
+	return method == "order.place" || method == "order.modify"
+}
+
+// rateLimitTransport records the usage Binance reports in REST response headers and holds back
+// orders that would exceed the order-count limit
+type rateLimitTransport struct {
+	base http.RoundTripper
+}
+
+// trackRateLimits wraps base with the rate limit tracker
+func trackRateLimits(base http.RoundTripper) http.RoundTripper {
+	return &rateLimitTransport{base: base}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	source := restTarget(req.URL.Path)
+	if isOrderRequest(req) {
+		if err := rateLimits.reserveOrder(req.Context(), source); err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, err
+		}
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		rateLimits.observeHeaders(source, resp.Header, time.Now())
+	}
+	return resp, err
+}
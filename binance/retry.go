@@ -0,0 +1,194 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"futures-options/config"
+
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// maxRetryDelay caps the backoff between two attempts
+const maxRetryDelay = 5 * time.Second
+
+// retryPolicy retries transient Binance failures with exponential backoff and jitter. With resync
+// set, a request rejected for its timestamp (-1021) is retried once after the clock offset is
+// measured again.
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	recvWindow  int64
+	resync      func(ctx context.Context) (offsetMs int64, err error)
+}
+
+// timestampRetryKey marks a context whose -1021 rejections are retried by an enclosing operation
+type timestampRetryKey struct{}
+
+func newRetryPolicy(cfg *config.Config) retryPolicy {
+	p := retryPolicy{maxAttempts: cfg.BinanceRetryMaxAttempts, baseDelay: cfg.BinanceRetryBaseDelay, recvWindow: cfg.BinanceRecvWindow}
+	if p.maxAttempts < 1 {
+		p.maxAttempts = 1
+	}
+	return p
+}
+
+// IsRetryable reports whether err is a transient failure after which the same request may succeed:
+// network errors, 5xx responses and Binance's "internal error / timeout / busy" codes
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		// Retrying would only hit the open breaker again
+		return false
+	}
+	if IsTransportError(err) {
+		return true
+	}
+	if status := HTTPStatusCode(err); status >= 500 {
+		return true
+	}
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case 0:
+			// The SDK could not decode the error body, which happens for gateway 5xx pages
+			return true
+		case -1001, -1007, -1008: // disconnected, backend timeout, server busy
+			return true
+		}
+	}
+	return false
+}
+
+// do runs fn until it succeeds, fails with a non-retryable error, attempts run out, or ctx
+// would expire before the next attempt. Only use it for idempotent requests.
+func (p retryPolicy) do(ctx context.Context, op string, fn func() error) error {
+	return p.resyncing(ctx, op, func(ctx context.Context) error {
+		var err error
+		for attempt := 1; ; attempt++ {
+			if err = fn(); err == nil || !IsRetryable(err) || attempt >= p.maxAttempts {
+				return err
+			}
+			if !p.wait(ctx, attempt) {
+				return err
+			}
+			slog.Warn("retrying Binance request", "op", op, "attempt", attempt+1, "error", err)
+		}
+	})
+}
+
+// resyncing runs fn and, when Binance rejects its timestamp, measures the clock offset again and
+// runs it once more; a rejection that persists is reported as a *TimestampError. fn must fetch the
+// SDK client it signs with after the resync, and leaves -1021 to this call for requests made with
+// the context it is passed. Binance rejects such a request before processing it, so orders are
+// safe to send again.
+func (p retryPolicy) resyncing(ctx context.Context, op string, fn func(ctx context.Context) error) error {
+	if p.resync == nil || ctx.Value(timestampRetryKey{}) != nil {
+		return fn(ctx)
+	}
+	ctx = context.WithValue(ctx, timestampRetryKey{}, true)
+	err := fn(ctx)
+	if !IsTimestampError(err) {
+		return err
+	}
+	recvWindow := RecvWindowOf(ctx, p.recvWindow)
+	offset, syncErr := p.resync(ctx)
+	if syncErr != nil {
+		return &TimestampError{Err: err, RecvWindowMs: recvWindow, SyncErr: syncErr}
+	}
+	slog.Warn("Binance rejected the request timestamp; retrying with the measured clock offset", "op", op, "offset_ms", offset, "recv_window_ms", recvWindow)
+	if err = fn(ctx); IsTimestampError(err) {
+		return &TimestampError{Err: err, OffsetMs: offset, RecvWindowMs: recvWindow}
+	}
+	return err
+}
+
+// wait sleeps before the next attempt; it returns false if ctx ends first
+func (p retryPolicy) wait(ctx context.Context, attempt int) bool {
+	delay := p.backoff(attempt)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return false
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// backoff returns base*2^(attempt-1), capped at maxRetryDelay, with jitter in [d/2, d]
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	delay := p.baseDelay
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// placeOrder submits an order, retrying transient failures only when the order carries a client
+// order ID and Binance confirms that no order with that ID exists; otherwise a retry could place
+// it twice. If the lookup finds the order, the first attempt went through and it is returned.
+func (p retryPolicy) placeOrder(ctx context.Context, fc *futures.Client, symbol, clientOrderID string, place func() (*futures.CreateOrderResponse, error)) (*futures.CreateOrderResponse, error) {
+	for attempt := 1; ; attempt++ {
+		order, err := place()
+		if err == nil || clientOrderID == "" || !IsRetryable(err) || attempt >= p.maxAttempts {
+			return order, err
+		}
+		if !p.wait(ctx, attempt) {
+			return nil, err
+		}
+
+		existing, lookupErr := fc.NewGetOrderService().Symbol(symbol).OrigClientOrderID(clientOrderID).Do(ctx, futures.WithRecvWindow(RecvWindowOf(ctx, p.recvWindow)))
+		if lookupErr == nil {
+			return orderToCreateResponse(existing), nil
+		}
+		if !IsOrderNotFound(lookupErr) {
+			// The order's fate is unknown; report the original failure rather than risk a duplicate
+			return nil, err
+		}
+		slog.Warn("retrying order placement", "symbol", symbol, "client_order_id", clientOrderID, "attempt", attempt+1, "error", err)
+	}
+}
+
+// orderToCreateResponse converts an order lookup into the shape returned by order placement
+func orderToCreateResponse(o *futures.Order) *futures.CreateOrderResponse {
+	return &futures.CreateOrderResponse{
+		Symbol:           o.Symbol,
+		OrderID:          o.OrderID,
+		ClientOrderID:    o.ClientOrderID,
+		Price:            o.Price,
+		OrigQuantity:     o.OrigQuantity,
+		ExecutedQuantity: o.ExecutedQuantity,
+		CumQuote:         o.CumQuote,
+		ReduceOnly:       o.ReduceOnly,
+		Status:           o.Status,
+		StopPrice:        o.StopPrice,
+		TimeInForce:      o.TimeInForce,
+		Type:             o.Type,
+		Side:             o.Side,
+		UpdateTime:       o.UpdateTime,
+		WorkingType:      o.WorkingType,
+		ActivatePrice:    o.ActivatePrice,
+		PriceRate:        o.PriceRate,
+		AvgPrice:         o.AvgPrice,
+		PositionSide:     o.PositionSide,
+		ClosePosition:    o.ClosePosition,
+		PriceProtect:     o.PriceProtect,
+	}
+}
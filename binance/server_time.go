@@ -0,0 +1,158 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/delivery"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// DefaultRecvWindow is the recvWindow, in milliseconds, of signed requests that do not set one;
+// Binance applies the same default
+const DefaultRecvWindow = 5000
+
+// MaxTimestampAheadMs is how far a signed request's timestamp may run ahead of Binance's clock
+const MaxTimestampAheadMs = 1000
+
+// recvWindowKey carries a request's recvWindow override in its context
+type recvWindowKey struct{}
+
+// WithRecvWindow returns ctx with the recvWindow, in milliseconds, of the signed requests made for
+// it, overriding BINANCE_RECV_WINDOW
+func WithRecvWindow(ctx context.Context, ms int64) context.Context {
+	return context.WithValue(ctx, recvWindowKey{}, ms)
+}
+
+// RecvWindowOf returns ctx's recvWindow override, else configured, else DefaultRecvWindow
+func RecvWindowOf(ctx context.Context, configured int64) int64 {
+	if ms, ok := ctx.Value(recvWindowKey{}).(int64); ok && ms > 0 {
+		return ms
+	}
+	if configured > 0 {
+		return configured
+	}
+	return DefaultRecvWindow
+}
+
+// RecvWindow returns the recvWindow, in milliseconds, of the signed requests made for ctx
+func (c *Client) RecvWindow(ctx context.Context) int64 {
+	return RecvWindowOf(ctx, c.Config.BinanceRecvWindow)
+}
+
+// futuresRecvWindow, deliveryRecvWindow and spotRecvWindow set RecvWindow on a signed SDK request
+func (c *Client) futuresRecvWindow(ctx context.Context) futures.RequestOption {
+	return futures.WithRecvWindow(c.RecvWindow(ctx))
+}
+
+func (c *Client) deliveryRecvWindow(ctx context.Context) delivery.RequestOption {
+	return delivery.WithRecvWindow(c.RecvWindow(ctx))
+}
+
+func (c *Client) spotRecvWindow(ctx context.Context) binance.RequestOption {
+	return binance.WithRecvWindow(c.RecvWindow(ctx))
+}
+
+// clockOffset is the signing layer's estimate of local time minus Binance server time, in
+// milliseconds, which signed requests subtract from the local clock. It is shared by every REST
+// client built from one Client, so it survives key changes.
+type clockOffset struct {
+	ms atomic.Int64
+}
+
+// timestamp returns the corrected request timestamp in Unix milliseconds; a nil offset applies
+// no correction
+func (o *clockOffset) timestamp() int64 {
+	if o == nil {
+		return time.Now().UnixMilli()
+	}
+	return time.Now().UnixMilli() - o.ms.Load()
+}
+
+// ServerTimeCheck compares the local clock with Binance's on the active network
+type ServerTimeCheck struct {
+	Network    string    `json:"network"`
+	ServerTime time.Time `json:"server_time"`
+	LocalTime  time.Time `json:"local_time"` // midpoint of the request
+	// OffsetMs is local minus server time; positive when the local clock runs ahead
+	OffsetMs    int64 `json:"offset_ms"`
+	RoundTripMs int64 `json:"round_trip_ms"`
+	// AppliedOffsetMs is the offset signed requests currently subtract from the local clock
+	AppliedOffsetMs int64 `json:"applied_offset_ms"`
+}
+
+// CheckServerTime fetches the futures server time and measures the local clock's offset from it,
+// taking the middle of the round trip as the moment the server answered
+func (c *Client) CheckServerTime(ctx context.Context) (*ServerTimeCheck, error) {
+	var serverMs int64
+	var sent, received time.Time
+	err := c.retry.do(ctx, "get server time", func() (err error) {
+		sent = time.Now()
+		serverMs, err = c.Futures().NewServerTimeService().Do(ctx)
+		received = time.Now()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server time: %w", err)
+	}
+
+	roundTrip := received.Sub(sent)
+	local := sent.Add(roundTrip / 2)
+	return &ServerTimeCheck{
+		Network:         NetworkName(c.IsTestnet()),
+		ServerTime:      time.UnixMilli(serverMs).UTC(),
+		LocalTime:       local.UTC(),
+		OffsetMs:        local.UnixMilli() - serverMs,
+		RoundTripMs:     roundTrip.Milliseconds(),
+		AppliedOffsetMs: c.TimeOffset(),
+	}, nil
+}
+
+// TimeOffset returns the offset, local minus server time in milliseconds, that signed requests
+// subtract from the local clock
+func (c *Client) TimeOffset() int64 {
+	return c.clock.ms.Load()
+}
+
+// SetTimeOffset makes signed requests subtract offsetMs from the local clock. The SDK clients read
+// their offset unguarded, so they are replaced by copies carrying the new one.
+func (c *Client) SetTimeOffset(offsetMs int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock.ms.Store(offsetMs)
+	c.applyTimeOffset()
+}
+
+// resyncClock measures the clock offset again and applies it to signed requests, after Binance
+// rejected a request's timestamp
+func (c *Client) resyncClock(ctx context.Context) (int64, error) {
+	check, err := c.CheckServerTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+	c.SetTimeOffset(check.OffsetMs)
+	return check.OffsetMs, nil
+}
+
+// applyTimeOffset copies the SDK clients with the current offset; c.mu must be held
+func (c *Client) applyTimeOffset() {
+	offset := c.clock.ms.Load()
+	if c.futuresClient != nil {
+		fc := *c.futuresClient
+		fc.TimeOffset = offset
+		c.futuresClient = &fc
+	}
+	if c.deliveryClient != nil {
+		dc := *c.deliveryClient
+		dc.TimeOffset = offset
+		c.deliveryClient = &dc
+	}
+	if c.spotClient != nil {
+		sc := *c.spotClient
+		sc.TimeOffset = offset
+		c.spotClient = &sc
+	}
+}
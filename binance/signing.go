@@ -0,0 +1,140 @@
+package binance
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"futures-options/config"
+)
+
+// Signature modes of WSAPI_SIGNATURE_MODE. RSA applies to REST requests signed here as well;
+// in the other modes REST requests are signed with HMAC.
+const (
+	SignatureModeEd25519 = "ed25519"
+	SignatureModeHMAC    = "hmac"
+	SignatureModeRSA     = "rsa"
+)
+
+// defaultRSAKeyFile is the key file tried after RSA_PRIVATE_KEY_PATH
+const defaultRSAKeyFile = "rsa.key"
+
+// signatureMode returns the configured signature mode, ed25519 when unset or unknown
+func signatureMode(cfg *config.Config) string {
+	switch mode := strings.ToLower(strings.TrimSpace(cfg.WSAPISignatureMode)); mode {
+	case SignatureModeHMAC, SignatureModeRSA:
+		return mode
+	default:
+		return SignatureModeEd25519
+	}
+}
+
+// signREST signs the query string of a REST request: RSA (base64) in rsa mode, otherwise
+// HMAC-SHA256 (hex) with secretKey
+func signREST(cfg *config.Config, secretKey, payload string) (string, error) {
+	if signatureMode(cfg) == SignatureModeRSA {
+		key, err := resolveRSAKey(cfg)
+		if err != nil {
+			return "", err
+		}
+		return signRSA(key, payload)
+	}
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// signRSA signs payload with RSASSA-PKCS1-v1_5 over SHA-256, as Binance specifies for RSA keys,
+// and returns the signature in base64
+func signRSA(key *rsa.PrivateKey, payload string) (string, error) {
+	digest := sha256.Sum256([]byte(payload))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign with RSA key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// resolveRSAKey reads the RSA private key from RSA_PRIVATE_KEY_PATH, then from the default
+// rsa.key, resolving paths like the Ed25519 key
+func resolveRSAKey(cfg *config.Config) (*rsa.PrivateKey, error) {
+	data, path, tried, err := readKeyFile(cfg, cfg.RSAPrivateKeyPath, defaultRSAKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("no RSA key found for WSAPI_SIGNATURE_MODE=rsa; tried %s. Set RSA_PRIVATE_KEY_PATH to the PEM of the key registered with Binance",
+			strings.Join(tried, ", "))
+	}
+	key, err := parseRSAKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RSA key in %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// parseRSAKey parses a PKCS#1 ("RSA PRIVATE KEY") or PKCS#8 ("PRIVATE KEY") PEM RSA key
+func parseRSAKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("expect a PEM encoded RSA private key")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("bad PKCS#1 PEM: %w", err)
+		}
+		return key, nil
+	case "PRIVATE KEY":
+		keyAny, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("bad PKCS#8 PEM: %w", err)
+		}
+		switch key := keyAny.(type) {
+		case *rsa.PrivateKey:
+			return key, nil
+		case ed25519.PrivateKey:
+			return nil, errors.New("PEM holds an Ed25519 key but WSAPI_SIGNATURE_MODE is rsa; set WSAPI_SIGNATURE_MODE=ed25519 to sign with it")
+		default:
+			return nil, fmt.Errorf("PEM holds a %T, not an RSA key", keyAny)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q; expect an unencrypted RSA PRIVATE KEY or PRIVATE KEY", block.Type)
+	}
+}
+
+// readKeyFile reads the first existing key file of the configured path and defaultFile, and
+// returns nil data with the locations tried when there is none
+func readKeyFile(cfg *config.Config, configured, defaultFile string) (data []byte, path string, tried []string, err error) {
+	var paths []string
+	if p := strings.TrimSpace(configured); p != "" {
+		paths = append(paths, p)
+	}
+	paths = append(paths, defaultFile)
+
+	for _, p := range paths {
+		path = cfg.KeyFilePath(p)
+		data, err = os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			tried = append(tried, path+" (not found)")
+			continue
+		}
+		if err != nil {
+			return nil, path, tried, fmt.Errorf("failed to read key %s: %w", path, err)
+		}
+		return data, path, tried, nil
+	}
+	return nil, "", tried, nil
+}
@@ -0,0 +1,161 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/adshao/go-binance/v2"
+	"github.com/shopspring/decimal"
+)
+
+// SpotOrderRequest is a spot market or limit order. Market orders set either Quantity in the
+// base asset or QuoteQuantity in the quote asset; limit orders need Quantity and Price.
+type SpotOrderRequest struct {
+	Symbol        string
+	Side          string // BUY or SELL
+	OrderType     string // MARKET or LIMIT
+	Quantity      decimal.Decimal
+	QuoteQuantity float64
+	Price         decimal.Decimal
+	TimeInForce   string // GTC, IOC or FOK; limit orders only
+	ClientOrderID string
+}
+
+// CreateSpotOrder places a spot order with the full response, so market orders include their fills.
+// Timestamp rejections are retried as for futures orders.
+func (c *Client) CreateSpotOrder(ctx context.Context, req *SpotOrderRequest) (*binance.CreateOrderResponse, error) {
+	var order *binance.CreateOrderResponse
+	err := c.retry.resyncing(ctx, "create spot order", func(ctx context.Context) (err error) {
+		order, err = c.createSpotOrder(ctx, req)
+		return err
+	})
+	return order, err
+}
+
+// createSpotOrder is one attempt at CreateSpotOrder
+func (c *Client) createSpotOrder(ctx context.Context, req *SpotOrderRequest) (*binance.CreateOrderResponse, error) {
+	sc := c.Spot()
+
+	orderType := binance.OrderTypeMarket
+	if req.OrderType == "LIMIT" {
+		orderType = binance.OrderTypeLimit
+	}
+	side := binance.SideTypeSell
+	if req.Side == "BUY" {
+		side = binance.SideTypeBuy
+	}
+
+	orderService := sc.NewCreateOrderService().
+		Symbol(req.Symbol).
+		Side(side).
+		Type(orderType).
+		NewOrderRespType(binance.NewOrderRespTypeFULL)
+
+	if req.Quantity.IsPositive() {
+		orderService = orderService.Quantity(req.Quantity.String())
+	} else if req.QuoteQuantity > 0 {
+		orderService = orderService.QuoteOrderQty(strconv.FormatFloat(req.QuoteQuantity, 'f', -1, 64))
+	}
+	if orderType == binance.OrderTypeLimit {
+		tif := binance.TimeInForceType(req.TimeInForce)
+		if tif == "" {
+			tif = binance.TimeInForceTypeGTC
+		}
+		orderService = orderService.
+			Price(req.Price.String()).
+			TimeInForce(tif)
+	}
+	if req.ClientOrderID != "" {
+		orderService = orderService.NewClientOrderID(req.ClientOrderID)
+	}
+
+	order, err := c.placeSpotOrder(ctx, sc, req.Symbol, req.ClientOrderID, func() (*binance.CreateOrderResponse, error) {
+		return orderService.Do(ctx, c.spotRecvWindow(ctx))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spot order: %w", err)
+	}
+	return order, nil
+}
+
+// placeSpotOrder is retryPolicy.placeOrder for the spot API: a transient failure is only retried
+// when the client order ID lookup confirms the first attempt did not go through
+func (c *Client) placeSpotOrder(ctx context.Context, sc *binance.Client, symbol, clientOrderID string, place func() (*binance.CreateOrderResponse, error)) (*binance.CreateOrderResponse, error) {
+	for attempt := 1; ; attempt++ {
+		order, err := place()
+		if err == nil || clientOrderID == "" || !IsRetryable(err) || attempt >= c.retry.maxAttempts {
+			return order, err
+		}
+		if !c.retry.wait(ctx, attempt) {
+			return nil, err
+		}
+
+		existing, lookupErr := sc.NewGetOrderService().Symbol(symbol).OrigClientOrderID(clientOrderID).Do(ctx, c.spotRecvWindow(ctx))
+		if lookupErr == nil {
+			return &binance.CreateOrderResponse{
+				Symbol:                   existing.Symbol,
+				OrderID:                  existing.OrderID,
+				ClientOrderID:            existing.ClientOrderID,
+				TransactTime:             existing.Time,
+				Price:                    existing.Price,
+				OrigQuantity:             existing.OrigQuantity,
+				ExecutedQuantity:         existing.ExecutedQuantity,
+				CummulativeQuoteQuantity: existing.CummulativeQuoteQuantity,
+				Status:                   existing.Status,
+				TimeInForce:              existing.TimeInForce,
+				Type:                     existing.Type,
+				Side:                     existing.Side,
+			}, nil
+		}
+		if !IsOrderNotFound(lookupErr) {
+			return nil, err
+		}
+		slog.Warn("retrying spot order placement", "symbol", symbol, "client_order_id", clientOrderID, "attempt", attempt+1, "error", err)
+	}
+}
+
+// GetSpotOrder queries the live state of a spot order
+func (c *Client) GetSpotOrder(ctx context.Context, symbol string, orderID int64) (*binance.Order, error) {
+	var order *binance.Order
+	err := c.retry.do(ctx, "get spot order", func() (err error) {
+		order, err = c.Spot().NewGetOrderService().
+			Symbol(symbol).
+			OrderID(orderID).
+			Do(ctx, c.spotRecvWindow(ctx))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spot order: %w", err)
+	}
+	return order, nil
+}
+
+// ListOpenSpotOrders lists the open spot orders for a symbol
+func (c *Client) ListOpenSpotOrders(ctx context.Context, symbol string) ([]*binance.Order, error) {
+	var orders []*binance.Order
+	err := c.retry.do(ctx, "list open spot orders", func() (err error) {
+		orders, err = c.Spot().NewListOpenOrdersService().
+			Symbol(symbol).
+			Do(ctx, c.spotRecvWindow(ctx))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open spot orders: %w", err)
+	}
+	return orders, nil
+}
+
+// GetSpotBalances gets the spot wallet balance of every asset, including empty ones
+func (c *Client) GetSpotBalances(ctx context.Context) ([]binance.Balance, error) {
+	var account *binance.Account
+	err := c.retry.do(ctx, "get spot account", func() (err error) {
+		account, err = c.Spot().NewGetAccountService().Do(ctx, c.spotRecvWindow(ctx))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spot account: %w", err)
+	}
+	return account.Balances, nil
+}
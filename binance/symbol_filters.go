@@ -0,0 +1,117 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// ErrUnknownSymbol is returned for symbols not listed in the exchange info
+var ErrUnknownSymbol = errors.New("unknown symbol")
+
+// SymbolFilters are the order size and price rules of a futures or spot symbol
+type SymbolFilters struct {
+	Symbol         string  `json:"symbol"`
+	Status         string  `json:"status"` // TRADING when open; e.g. SETTLING or BREAK otherwise
+	TickSize       float64 `json:"tick_size"`
+	StepSize       float64 `json:"step_size"`
+	MinQty         float64 `json:"min_qty"`
+	MaxQty         float64 `json:"max_qty"`
+	MarketStepSize float64 `json:"market_step_size"`
+	MarketMinQty   float64 `json:"market_min_qty"`
+	MarketMaxQty   float64 `json:"market_max_qty"`
+	MinNotional    float64 `json:"min_notional"`
+	// MaxNumOrders caps a symbol's open orders, MaxNumAlgoOrders its open conditional orders;
+	// both are 0 when exchange info lists no such filter (spot symbols)
+	MaxNumOrders     int `json:"max_num_orders,omitempty"`
+	MaxNumAlgoOrders int `json:"max_num_algo_orders,omitempty"`
+}
+
+// GetSymbolFilters returns symbol's lot size, price and notional filters from the cached
+// USDⓈ-M exchange info
+func (c *Client) GetSymbolFilters(ctx context.Context, symbol string) (*SymbolFilters, error) {
+	filters, err := c.exchangeInfo.usdm.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return lookupFilters(filters, symbol)
+}
+
+// GetSpotSymbolFilters is GetSymbolFilters for spot symbols, from the cached spot exchange info
+func (c *Client) GetSpotSymbolFilters(ctx context.Context, symbol string) (*SymbolFilters, error) {
+	filters, err := c.exchangeInfo.spot.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return lookupFilters(filters, symbol)
+}
+
+// lookupFilters returns a copy of symbol's filters, since the cached map is shared
+func lookupFilters(filters map[string]*SymbolFilters, symbol string) (*SymbolFilters, error) {
+	f, ok := filters[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSymbol, symbol)
+	}
+	copied := *f
+	return &copied, nil
+}
+
+func parseSymbolFilters(s *futures.Symbol) *SymbolFilters {
+	f := &SymbolFilters{Symbol: s.Symbol, Status: s.Status}
+	if pf := s.PriceFilter(); pf != nil {
+		f.TickSize = parseFilterValue(pf.TickSize)
+	}
+	if lf := s.LotSizeFilter(); lf != nil {
+		f.StepSize = parseFilterValue(lf.StepSize)
+		f.MinQty = parseFilterValue(lf.MinQuantity)
+		f.MaxQty = parseFilterValue(lf.MaxQuantity)
+	}
+	if mf := s.MarketLotSizeFilter(); mf != nil {
+		f.MarketStepSize = parseFilterValue(mf.StepSize)
+		f.MarketMinQty = parseFilterValue(mf.MinQuantity)
+		f.MarketMaxQty = parseFilterValue(mf.MaxQuantity)
+	}
+	if nf := s.MinNotionalFilter(); nf != nil {
+		f.MinNotional = parseFilterValue(nf.Notional)
+	}
+	if of := s.MaxNumOrdersFilter(); of != nil {
+		f.MaxNumOrders = int(of.Limit)
+	}
+	if af := s.MaxNumAlgoOrdersFilter(); af != nil {
+		f.MaxNumAlgoOrders = int(af.Limit)
+	}
+	return f
+}
+
+func parseSpotSymbolFilters(s *binance.Symbol) *SymbolFilters {
+	f := &SymbolFilters{Symbol: s.Symbol, Status: s.Status}
+	if pf := s.PriceFilter(); pf != nil {
+		f.TickSize = parseFilterValue(pf.TickSize)
+	}
+	if lf := s.LotSizeFilter(); lf != nil {
+		f.StepSize = parseFilterValue(lf.StepSize)
+		f.MinQty = parseFilterValue(lf.MinQuantity)
+		f.MaxQty = parseFilterValue(lf.MaxQuantity)
+	}
+	if mf := s.MarketLotSizeFilter(); mf != nil {
+		f.MarketStepSize = parseFilterValue(mf.StepSize)
+		f.MarketMinQty = parseFilterValue(mf.MinQuantity)
+		f.MarketMaxQty = parseFilterValue(mf.MaxQuantity)
+	}
+	// Spot symbols moved from MIN_NOTIONAL to NOTIONAL; either may be present
+	if nf := s.NotionalFilter(); nf != nil {
+		f.MinNotional = parseFilterValue(nf.MinNotional)
+	} else if nf := s.MinNotionalFilter(); nf != nil {
+		f.MinNotional = parseFilterValue(nf.MinNotional)
+	}
+	return f
+}
+
+func parseFilterValue(value string) float64 {
+	f, _ := strconv.ParseFloat(value, 64)
+	return f
+}
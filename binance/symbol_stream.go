@@ -0,0 +1,132 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// SymbolUpdate is one event from a symbol's mark price stream or 24h ticker stream; only the
+// fields of its stream are set
+type SymbolUpdate struct {
+	Symbol string
+	Time   time.Time
+
+	// Mark price stream, each second
+	MarkPrice       float64
+	FundingRate     float64
+	NextFundingTime time.Time
+
+	// 24h rolling ticker
+	LastPrice          float64
+	PriceChangePercent float64
+}
+
+// IsMark reports whether the update came from the mark price stream
+func (u *SymbolUpdate) IsMark() bool {
+	return u.MarkPrice > 0
+}
+
+// StreamSymbols subscribes to the mark price and 24h ticker streams of the given symbols and
+// passes each event to handle until ctx is done (returning nil) or the connection fails
+func StreamSymbols(ctx context.Context, testnet bool, symbols []string, handle func(update *SymbolUpdate)) error {
+	if len(symbols) == 0 {
+		return fmt.Errorf("no symbols to stream")
+	}
+	streams := make([]string, 0, 2*len(symbols))
+	for _, symbol := range symbols {
+		s := strings.ToLower(symbol)
+		streams = append(streams, s+"@markPrice@1s", s+"@ticker")
+	}
+	url := "wss://fstream.binance.com/stream?streams=" + strings.Join(streams, "/")
+	if testnet {
+		url = "wss://fstream.binancefuture.com/stream?streams=" + strings.Join(streams, "/")
+	}
+
+	conn, _, err := wsDialer().DialContext(ctx, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to symbol stream: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock ReadMessage when ctx is done
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("symbol stream read failed: %w", err)
+		}
+
+		var envelope struct {
+			Data struct {
+				Event           string `json:"e"`
+				Time            int64  `json:"E"`
+				Symbol          string `json:"s"`
+				MarkPrice       string `json:"p"`
+				FundingRate     string `json:"r"`
+				NextFundingTime int64  `json:"T"`
+				LastPrice       string `json:"c"`
+				ChangePercent   string `json:"P"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(message, &envelope); err != nil {
+			continue
+		}
+		d := envelope.Data
+		update := &SymbolUpdate{Symbol: d.Symbol, Time: time.UnixMilli(d.Time)}
+		switch d.Event {
+		case "markPriceUpdate":
+			update.MarkPrice, _ = strconv.ParseFloat(d.MarkPrice, 64)
+			update.FundingRate, _ = strconv.ParseFloat(d.FundingRate, 64)
+			if d.NextFundingTime > 0 {
+				update.NextFundingTime = time.UnixMilli(d.NextFundingTime)
+			}
+			if update.MarkPrice <= 0 {
+				continue
+			}
+		case "24hrTicker":
+			update.LastPrice, _ = strconv.ParseFloat(d.LastPrice, 64)
+			update.PriceChangePercent, _ = strconv.ParseFloat(d.ChangePercent, 64)
+			if update.LastPrice <= 0 {
+				continue
+			}
+		default:
+			continue
+		}
+		handle(update)
+	}
+}
+
+// SubscribeSymbols streams the mark price, funding rate and 24h ticker of the given symbols,
+// storing their prices in the price cache and passing each update on to handle, until ctx is
+// done (returning nil) or the stream fails
+func (c *Client) SubscribeSymbols(ctx context.Context, symbols []string, handle func(update *SymbolUpdate)) error {
+	gen := c.prices.generation()
+	return StreamSymbols(ctx, c.IsTestnet(), symbols, func(update *SymbolUpdate) {
+		if update.IsMark() {
+			c.prices.observe(gen, []PriceUpdate{{Symbol: update.Symbol, Source: futures.WorkingTypeMarkPrice, Price: update.MarkPrice}})
+		} else {
+			c.prices.observe(gen, []PriceUpdate{{Symbol: update.Symbol, Source: futures.WorkingTypeContractPrice, Price: update.LastPrice}})
+		}
+		if handle != nil {
+			handle(update)
+		}
+	})
+}
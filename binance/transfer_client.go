@@ -0,0 +1,115 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Universal transfer types between the spot and futures wallets
+const (
+	TransferSpotToUSDM  = "MAIN_UMFUTURE"
+	TransferUSDMToSpot  = "UMFUTURE_MAIN"
+	TransferSpotToCoinM = "MAIN_CMFUTURE"
+	TransferCoinMToSpot = "CMFUTURE_MAIN"
+)
+
+// ErrTestnetUnsupported is returned for wallet endpoints, which the testnets do not provide
+var ErrTestnetUnsupported = errors.New("not available on the Binance testnet")
+
+// TransferRecord is one entry of the universal transfer history
+type TransferRecord struct {
+	Asset     string `json:"asset"`
+	Amount    string `json:"amount"`
+	Type      string `json:"type"`
+	Status    string `json:"status"` // PENDING, CONFIRMED or FAILED
+	TranID    int64  `json:"tranId"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// UniversalTransfer moves amount of asset between wallets and returns Binance's transfer ID.
+// It is not retried: a transfer that timed out may still have gone through.
+func (c *Client) UniversalTransfer(ctx context.Context, transferType, asset string, amount float64) (int64, error) {
+	if c.IsTestnet() {
+		return 0, fmt.Errorf("wallet transfers are %w", ErrTestnetUnsupported)
+	}
+	resp, err := c.Spot().NewUserUniversalTransferService().
+		Type(transferType).
+		Asset(asset).
+		Amount(amount).
+		Do(ctx, c.spotRecvWindow(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("failed to transfer %s: %w", asset, err)
+	}
+	return resp.ID, nil
+}
+
+// ListUniversalTransfers returns up to 100 transfers of one type, newest first. Zero times are
+// left out, in which case Binance returns the last 7 days.
+func (c *Client) ListUniversalTransfers(ctx context.Context, transferType string, start, end time.Time) ([]*TransferRecord, error) {
+	var records []*TransferRecord
+	err := c.retry.do(ctx, "list transfers", func() (err error) {
+		// Each attempt is signed with a fresh timestamp
+		records, err = c.listUniversalTransfers(ctx, transferType, start, end)
+		return err
+	})
+	return records, err
+}
+
+// listUniversalTransfers calls the signed transfer history endpoint directly, since go-binance
+// only implements creating transfers
+func (c *Client) listUniversalTransfers(ctx context.Context, transferType string, start, end time.Time) ([]*TransferRecord, error) {
+	if c.IsTestnet() {
+		return nil, fmt.Errorf("wallet transfers are %w", ErrTestnetUnsupported)
+	}
+	sc := c.Spot()
+	if sc.APIKey == "" || sc.SecretKey == "" {
+		return nil, fmt.Errorf("API keys not configured")
+	}
+
+	params := url.Values{}
+	params.Set("type", transferType)
+	params.Set("size", "100")
+	if !start.IsZero() {
+		params.Set("startTime", strconv.FormatInt(start.UnixMilli(), 10))
+	}
+	if !end.IsZero() {
+		params.Set("endTime", strconv.FormatInt(end.UnixMilli(), 10))
+	}
+	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli()-sc.TimeOffset, 10))
+	params.Set("recvWindow", strconv.FormatInt(c.RecvWindow(ctx), 10))
+	sig, err := signREST(c.EffectiveConfig(), sc.SecretKey, params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	params.Set("signature", sig)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, sc.BaseURL+"/sapi/v1/asset/transfer?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	httpReq.Header.Set("X-MBX-APIKEY", sc.APIKey)
+	resp, err := sc.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transfers: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list transfers: %w", newHTTPError(resp))
+	}
+
+	var result struct {
+		Total int               `json:"total"`
+		Rows  []*TransferRecord `json:"rows"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return result.Rows, nil
+}
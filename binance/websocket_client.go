@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"futures-options/config"
@@ -21,6 +23,8 @@ type WebSocketClient struct {
 	listenKey   string
 	stopChan    chan struct{}
 	messageChan chan *futures.WsUserDataEvent
+	closeOnce   sync.Once
+	connected   atomic.Bool
 }
 
 // NewWebSocketClient creates a new WebSocket client
@@ -51,14 +55,12 @@ func (ws *WebSocketClient) Connect(ctx context.Context) error {
 	}
 	url += ws.listenKey
 
-	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	conn, _, err := wsDialer().Dial(url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to WebSocket: %w", err)
 	}
 	ws.conn = conn
-
-	// Start ping/pong
-	go ws.keepAlive(ctx)
+	ws.connected.Store(true)
 
 	// Start reading messages
 	go ws.readMessages()
@@ -66,32 +68,24 @@ func (ws *WebSocketClient) Connect(ctx context.Context) error {
 	return nil
 }
 
-// keepAlive sends ping to keep connection alive
-func (ws *WebSocketClient) keepAlive(ctx context.Context) {
-	ticker := time.NewTicker(3 * time.Minute)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ws.stopChan:
-			return
-		case <-ticker.C:
-			// Ping listen key
-			err := ws.client.NewKeepaliveUserStreamService().
-				ListenKey(ws.listenKey).
-				Do(ctx)
-			if err != nil {
-				log.Printf("Failed to keep alive: %v", err)
-			}
-		}
+// KeepAlive extends the listen key's validity; Binance closes the stream if it is not called
+// within 60 minutes
+func (ws *WebSocketClient) KeepAlive(ctx context.Context) error {
+	err := ws.client.NewKeepaliveUserStreamService().
+		ListenKey(ws.listenKey).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to keep user data stream alive: %w", err)
 	}
+	return nil
 }
 
 // readMessages reads messages from WebSocket
 func (ws *WebSocketClient) readMessages() {
 	defer ws.conn.Close()
+	// Closing the channel lets consumers notice the stream has ended
+	defer close(ws.messageChan)
+	defer ws.connected.Store(false)
 
 	for {
 		select {
@@ -100,36 +94,50 @@ func (ws *WebSocketClient) readMessages() {
 		default:
 			_, message, err := ws.conn.ReadMessage()
 			if err != nil {
-				log.Printf("WebSocket read error: %v", err)
+				slog.Warn("user data stream read error", "error", err)
 				return
 			}
 
 			var event futures.WsUserDataEvent
 			if err := json.Unmarshal(message, &event); err != nil {
-				log.Printf("Failed to unmarshal message: %v", err)
+				slog.Warn("failed to unmarshal user data event", "error", err)
 				continue
 			}
 
 			select {
 			case ws.messageChan <- &event:
 			default:
-				log.Println("Message channel full, dropping message")
+				slog.Warn("user data channel full, dropping event")
 			}
 		}
 	}
 }
 
+// IsConnected reports whether the stream is connected and still reading messages
+func (ws *WebSocketClient) IsConnected() bool {
+	return ws.connected.Load()
+}
+
 // GetMessageChannel returns the message channel
 func (ws *WebSocketClient) GetMessageChannel() <-chan *futures.WsUserDataEvent {
 	return ws.messageChan
 }
 
-// Close closes the WebSocket connection
+// Close closes the WebSocket connection and deletes the listen key; it is safe to call more than once
 func (ws *WebSocketClient) Close() error {
-	close(ws.stopChan)
-	if ws.conn != nil {
-		return ws.conn.Close()
-	}
-	return nil
+	var err error
+	ws.closeOnce.Do(func() {
+		close(ws.stopChan)
+		if ws.conn != nil {
+			err = ws.conn.Close()
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if closeErr := ws.client.NewCloseUserStreamService().ListenKey(ws.listenKey).Do(ctx); closeErr != nil {
+			slog.Warn("failed to delete listen key", "error", closeErr)
+		}
+	})
+	return err
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/ed25519"
     "crypto/hmac"
+    "crypto/rsa"
     "crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
@@ -11,23 +12,71 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"futures-options/config" // <-- change to your actual module path
-
-	"github.com/gorilla/websocket"
+	"futures-options/latency"
 )
 
 // WSAPIClient is a minimal client for Binance Futures WebSocket API
+// that keeps one connection open, pinging it and dialing a new one when it dies
 type WSAPIClient struct {
-    conn *websocket.Conn
     cfg  *config.Config
+    url  string
+    key    ed25519.PrivateKey // resolved once, then reused for every signed request
+    rsaKey *rsa.PrivateKey    // in rsa signature mode
+    keyMu  sync.Mutex         // guards key and rsaKey
+
+    reqMu sync.Mutex // one request at a time, each waiting for its response
+
+    mu         sync.Mutex // guards conn, reconnects and closed
+    conn       *wsAPIConn
+    reconnects int
+    closed     bool
+}
+
+// defaultEd25519KeyFile is the key file tried after ED25519_PRIVATE_KEY_PATH
+const defaultEd25519KeyFile = "ed25519.key"
+
+// SetPrivateKey signs requests with key (the active credential's stored key) instead of a key file
+func (w *WSAPIClient) SetPrivateKey(key ed25519.PrivateKey) {
+    w.keyMu.Lock()
+    defer w.keyMu.Unlock()
+    w.key = key
+}
+
+// rsaPrivateKey returns the RSA signing key, resolving it from the key files on first use
+func (w *WSAPIClient) rsaPrivateKey() (*rsa.PrivateKey, error) {
+    w.keyMu.Lock()
+    defer w.keyMu.Unlock()
+    if w.rsaKey == nil {
+        key, err := resolveRSAKey(w.cfg)
+        if err != nil {
+            return nil, err
+        }
+        w.rsaKey = key
+    }
+    return w.rsaKey, nil
+}
+
+// privateKey returns the signing key, resolving it from the key files on first use
+func (w *WSAPIClient) privateKey() (ed25519.PrivateKey, error) {
+    w.keyMu.Lock()
+    defer w.keyMu.Unlock()
+    if w.key == nil {
+        key, err := resolvePrivateKey(w.cfg)
+        if err != nil {
+            return nil, err
+        }
+        w.key = key
+    }
+    return w.key, nil
 }
 
 // NewWSAPIClient connects to the appropriate ws-fapi endpoint
@@ -40,12 +89,11 @@ func NewWSAPIClient(cfg *config.Config) (*WSAPIClient, error) {
     // Log the WS-API URL we will connect to
     fmt.Printf("[WS-API] Connecting to: %s -- (testnet=%v)\n", url, cfg.BinanceTestnet)
 
-    c, _, err := websocket.DefaultDialer.Dial(url, nil)
-    if err != nil {
-        return nil, fmt.Errorf("failed to connect to WebSocket API: %w", err)
+    w := &WSAPIClient{cfg: cfg, url: url}
+    if _, err := w.liveConn(); err != nil {
+        return nil, err
     }
-
-    return &WSAPIClient{conn: c, cfg: cfg}, nil
+    return w, nil
 }
 
 // getServerTimeMs fetches Binance serverTime in ms to avoid client clock skew.
@@ -61,7 +109,7 @@ func getServerTimeMs(cfg *config.Config) int64 {
     if err != nil {
         return time.Now().UnixMilli()
     }
-    client := &http.Client{Timeout: 2 * time.Second}
+    client := &http.Client{Timeout: 2 * time.Second, Transport: httpTransport()}
     resp, err := client.Do(req)
     if err != nil {
         return time.Now().UnixMilli()
@@ -74,10 +122,13 @@ func getServerTimeMs(cfg *config.Config) int64 {
     return body.ServerTime
 }
 
-// Close closes the WebSocket connection
+// Close closes the WebSocket connection; requests sent afterwards fail
 func (w *WSAPIClient) Close() error {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    w.closed = true
     if w.conn != nil {
-        return w.conn.Close()
+        w.conn.fail(errWSAPIClosed)
     }
     return nil
 }
@@ -98,31 +149,54 @@ type WSResponse struct {
         Code int    `json:"code"`
         Msg  string `json:"msg"`
     } `json:"error,omitempty"`
+    RateLimits []WSRateLimit `json:"rateLimits,omitempty"`
 }
 
 //
 // ---------- KEY RESOLUTION ----------
 //
 
-// resolvePrivateKey reads an Ed25519 private key from file (PEM or raw seed/key).
-// If no path is provided, defaults to ./ed25519.key. Returns error if not found/invalid.
+// resolvePrivateKey reads the Ed25519 private key (PEM or raw seed/key) from
+// ED25519_PRIVATE_KEY_PATH, then from the default ed25519.key. Relative paths are resolved
+// against KEY_BASE_DIR (the working directory when unset) and ~ is expanded. A key stored on
+// the active credential is set with SetPrivateKey and never reaches here. When no key is found
+// the error lists every location tried.
 func resolvePrivateKey(cfg *config.Config) (ed25519.PrivateKey, error) {
-    path := cfg.Ed25519PrivateKeyPath
-    if strings.TrimSpace(path) == "" {
-        path = "./ed25519.key"
+    data, path, tried, err := readKeyFile(cfg, cfg.Ed25519PrivateKeyPath, defaultEd25519KeyFile)
+    if err != nil {
+        return nil, err
+    }
+    if data == nil {
+        tried = append([]string{"the active credential in the database (no key stored)"}, tried...)
+        return nil, fmt.Errorf("no Ed25519 key found; tried %s. Generate one with POST /api/keys/ed25519/generate, import one with POST /api/keys/ed25519/import, or set ED25519_PRIVATE_KEY_PATH",
+            strings.Join(tried, ", "))
     }
-    data, err := os.ReadFile(path)
+    key, err := parsePrivateKeyFile(data)
     if err != nil {
-        return nil, fmt.Errorf("no Ed25519 key found at %s", path)
+        return nil, fmt.Errorf("invalid Ed25519 key in %s: %w", path, err)
     }
+    return key, nil
+}
+
+// parsePrivateKeyFile parses PKCS#8 PEM, a raw 32-byte seed or a raw 64-byte private key
+func parsePrivateKeyFile(data []byte) (ed25519.PrivateKey, error) {
     data = []byte(strings.TrimSpace(string(data)))
 
     if blk, _ := pem.Decode(data); blk != nil {
+        if blk.Type == "RSA PRIVATE KEY" {
+            return nil, errors.New("PEM holds an RSA key but WSAPI_SIGNATURE_MODE is ed25519; set WSAPI_SIGNATURE_MODE=rsa to sign with it")
+        }
         keyAny, err := x509.ParsePKCS8PrivateKey(blk.Bytes)
-        if err == nil {
-            if pk, ok := keyAny.(ed25519.PrivateKey); ok {
-                return pk, nil
-            }
+        if err != nil {
+            return nil, fmt.Errorf("bad PKCS#8 PEM: %w", err)
+        }
+        switch pk := keyAny.(type) {
+        case ed25519.PrivateKey:
+            return pk, nil
+        case *rsa.PrivateKey:
+            return nil, errors.New("PEM holds an RSA key but WSAPI_SIGNATURE_MODE is ed25519; set WSAPI_SIGNATURE_MODE=rsa to sign with it")
+        default:
+            return nil, fmt.Errorf("PEM holds a %T, not an Ed25519 key", keyAny)
         }
     }
     switch len(data) {
@@ -131,34 +205,65 @@ func resolvePrivateKey(cfg *config.Config) (ed25519.PrivateKey, error) {
     case ed25519.PrivateKeySize:
         return ed25519.PrivateKey(data), nil
     }
-    return nil, errors.New("invalid Ed25519 key content (expect raw 32-byte seed, 64-byte key, or PKCS#8 PEM)")
+    return nil, errors.New("expect raw 32-byte seed, 64-byte key, or PKCS#8 PEM")
 }
 
 //
 // ---------- CORE SEND / READ ----------
 //
 
-// SendRequest sends an arbitrary WS API request and decodes the response into out (if non-nil)
+// SendRequest sends an arbitrary WS API request and decodes the response into out (if non-nil).
+// Orders that would exceed the order-count limit of the last response's rateLimits are held back
+// briefly or rejected with a *RateLimitError.
+// A request that cannot be written because the connection died while idle is sent again on a
+// new connection; one whose response is lost is not, since Binance may have acted on it.
 func (w *WSAPIClient) SendRequest(ctx context.Context, id interface{}, method string, params map[string]interface{}, out interface{}) error {
     req := WSRequest{ID: id, Method: method, Params: params}
+    start := time.Now()
+    failed := true
+    defer func() { latency.Record(latency.BinanceWSAPI, time.Since(start), failed) }()
 
-    if deadline, ok := ctx.Deadline(); ok {
-        _ = w.conn.SetWriteDeadline(deadline)
-    }
-    if err := w.conn.WriteJSON(req); err != nil {
-        return fmt.Errorf("failed to send request: %w", err)
+    w.reqMu.Lock()
+    defer w.reqMu.Unlock()
+
+    if isOrderMethod(method) {
+        if err := rateLimits.reserveOrder(ctx, latency.BinanceWSAPI); err != nil {
+            return err
+        }
     }
 
-    if deadline, ok := ctx.Deadline(); ok {
-        _ = w.conn.SetReadDeadline(deadline)
+    conn, err := w.liveConn()
+    if err != nil {
+        return err
     }
-    var resp WSResponse
-    if err := w.conn.ReadJSON(&resp); err != nil {
+    conn.drain()
+    if err := conn.write(ctx, req); err != nil {
+        slog.Warn("WS-API connection is dead; reconnecting to send the request", "method", method, "error", err)
+        conn.fail(err)
+        if conn, err = w.liveConn(); err != nil {
+            return err
+        }
+        if err := conn.write(ctx, req); err != nil {
+            conn.fail(err)
+            return fmt.Errorf("failed to send request: %w", err)
+        }
+    }
+
+    resp, err := conn.await(ctx, id)
+    if err != nil {
         return fmt.Errorf("failed to read response: %w", err)
     }
+    // Error responses report the usage too
+    rateLimits.observeWS(resp.RateLimits, time.Now())
+    // Like REST, only a server error counts against Binance
+    failed = resp.Status >= 500
     if resp.Status != 200 {
-        b, _ := json.Marshal(resp)
-        return fmt.Errorf("request failed: %s", string(b))
+        httpErr := &HTTPError{StatusCode: resp.Status}
+        if resp.Error != nil {
+            httpErr.Code = int64(resp.Error.Code)
+            httpErr.Message = resp.Error.Msg
+        }
+        return fmt.Errorf("request failed: %w", httpErr)
     }
     if out != nil && resp.Result != nil {
         b, _ := json.Marshal(resp.Result)
@@ -217,17 +322,35 @@ func buildSignaturePayload(params map[string]interface{}) (string, error) {
     return b.String(), nil
 }
 
-// SendSignedRequest signs params with Ed25519 (base64) and sends the request.
-// It injects apiKey and timestamp if not provided.
+// SendSignedRequest signs params per WSAPI_SIGNATURE_MODE (Ed25519 by default) and sends the request.
+// It injects apiKey and timestamp if not provided. A request it stamped that Binance rejects for its
+// timestamp (-1021) is stamped and signed again once; a second rejection returns a *TimestampError.
 func (w *WSAPIClient) SendSignedRequest(ctx context.Context, id interface{}, method string, params map[string]interface{}, out interface{}) error {
-    priv, err := resolvePrivateKey(w.cfg)
-    if err != nil {
+    if params == nil {
+        params = map[string]interface{}{}
+    }
+    _, stamped := params["timestamp"]
+    err := w.sendSigned(ctx, id, method, params, out)
+    if stamped || !IsTimestampError(err) {
         return err
     }
 
-    if params == nil {
-        params = map[string]interface{}{}
+    // The timestamp already comes from Binance's clock, so the request was most likely slow to
+    // arrive; a fresh one usually succeeds
+    delete(params, "signature")
+    local := time.Now().UnixMilli()
+    ts := getServerTimeMs(w.cfg)
+    params["timestamp"] = ts
+    recvWindow := RecvWindowOf(ctx, w.cfg.BinanceRecvWindow)
+    slog.Warn("Binance rejected the WS-API request timestamp; retrying with a fresh one", "method", method, "recv_window_ms", recvWindow)
+    if err = w.sendSigned(ctx, id, method, params, out); IsTimestampError(err) {
+        return &TimestampError{Err: err, OffsetMs: local - ts, RecvWindowMs: recvWindow}
     }
+    return err
+}
+
+// sendSigned stamps, signs and sends one signed request
+func (w *WSAPIClient) sendSigned(ctx context.Context, id interface{}, method string, params map[string]interface{}, out interface{}) error {
     // inject apiKey + timestamp
     if _, ok := params["apiKey"]; !ok {
         params["apiKey"] = w.cfg.BinanceAPIKey
@@ -239,25 +362,41 @@ func (w *WSAPIClient) SendSignedRequest(ctx context.Context, id interface{}, met
     }
     // (optional but good) add recvWindow
     if _, ok := params["recvWindow"]; !ok {
-        params["recvWindow"] = 5000
+        params["recvWindow"] = RecvWindowOf(ctx, w.cfg.BinanceRecvWindow)
     }
 
     payload, err := buildSignaturePayload(params)
-    log.Printf("Payload: %s", payload)
+    slog.Debug("WS-API signature payload", "method", method, "payload", payload)
     if err != nil {
         return err
     }
 
-    // Signature mode: default ed25519 (WS-API spec). If WSAPI_SIGNATURE_MODE=hmac, sign with HMAC-SHA256 (testing only)
-    if strings.EqualFold(w.cfg.WSAPISignatureMode, "hmac") {
+    // Signature mode: default ed25519 (WS-API spec). WSAPI_SIGNATURE_MODE=rsa signs with an RSA
+    // key (RSASSA-PKCS1-v1_5), and hmac with HMAC-SHA256 (testing only)
+    switch signatureMode(w.cfg) {
+    case SignatureModeHMAC:
         mac := hmac.New(sha256.New, []byte(w.cfg.BinanceSecretKey))
         mac.Write([]byte(payload))
         params["signature"] = fmt.Sprintf("%x", mac.Sum(nil))
-    } else {
+    case SignatureModeRSA:
+        key, err := w.rsaPrivateKey()
+        if err != nil {
+            return err
+        }
+        sig, err := signRSA(key, payload)
+        if err != nil {
+            return err
+        }
+        params["signature"] = sig
+    default:
+        priv, err := w.privateKey()
+        if err != nil {
+            return err
+        }
         sig := ed25519.Sign(priv, []byte(payload))
         params["signature"] = base64.StdEncoding.EncodeToString(sig)
     }
-    log.Printf("Signature params: %v", params)
+    slog.Debug("WS-API signed request", "method", method, "id", id)
     return w.SendRequest(ctx, id, method, params, out)
 }
 
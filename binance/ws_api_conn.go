@@ -0,0 +1,236 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsAPIMaxConnAge is how long a WS-API connection is reused; Binance closes them after 24 hours
+	wsAPIMaxConnAge = 23 * time.Hour
+	// wsAPIMissedPongs is how many ping intervals may pass without a pong before a connection is
+	// considered dead
+	wsAPIMissedPongs = 2
+	// wsAPIControlTimeout bounds writing a ping or pong frame
+	wsAPIControlTimeout = 10 * time.Second
+	// defaultWSAPIPingInterval applies when WSAPI_PING_INTERVAL is unset
+	defaultWSAPIPingInterval = time.Minute
+)
+
+// errWSAPIClosed is returned by requests sent after the client was closed
+var errWSAPIClosed = errors.New("WS-API client is closed")
+
+// wsAPIConn is one WS-API connection with the goroutines keeping it alive: a reader, which
+// also answers Binance's pings, and a pinger, which notices a connection that died silently
+type wsAPIConn struct {
+	ws       *websocket.Conn
+	messages chan []byte   // frames read from ws
+	done     chan struct{} // closed once ws failed or was closed
+	err      error         // why ws ended; set before done is closed
+	openedAt time.Time
+	lastPong atomic.Int64 // unix ms of the last pong, or of openedAt before the first one
+	once     sync.Once
+}
+
+// dialWSAPI connects to url and starts keeping the connection alive, pinging every pingInterval
+func dialWSAPI(url string, pingInterval time.Duration) (*wsAPIConn, error) {
+	ws, _, err := wsDialer().Dial(url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to WebSocket API: %w", err)
+	}
+	if pingInterval <= 0 {
+		pingInterval = defaultWSAPIPingInterval
+	}
+	c := &wsAPIConn{ws: ws, messages: make(chan []byte, 16), done: make(chan struct{}), openedAt: time.Now()}
+	c.lastPong.Store(c.openedAt.UnixMilli())
+	ws.SetPongHandler(func(string) error {
+		c.lastPong.Store(time.Now().UnixMilli())
+		return nil
+	})
+	ws.SetPingHandler(func(data string) error {
+		// Binance pings every few minutes and drops connections that stop answering
+		err := ws.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(wsAPIControlTimeout))
+		if errors.Is(err, websocket.ErrCloseSent) {
+			return nil
+		}
+		return err
+	})
+	go c.read()
+	go c.ping(pingInterval)
+	return c, nil
+}
+
+// read hands every frame to the request waiting for it, so pings are answered while idle too
+func (c *wsAPIConn) read() {
+	for {
+		_, msg, err := c.ws.ReadMessage()
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		select {
+		case c.messages <- msg:
+		default:
+			// Nobody drained the late responses of requests that gave up waiting
+		}
+	}
+}
+
+// ping sends a ping every interval and fails the connection once pongs stop coming back
+func (c *wsAPIConn) ping(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+		if since := time.Since(time.UnixMilli(c.lastPong.Load())); since > wsAPIMissedPongs*interval {
+			c.fail(fmt.Errorf("no pong from the WS-API for %s", since.Round(time.Second)))
+			return
+		}
+		if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsAPIControlTimeout)); err != nil {
+			c.fail(fmt.Errorf("failed to ping the WS-API: %w", err))
+			return
+		}
+	}
+}
+
+// fail closes the connection for err; only the first call has an effect
+func (c *wsAPIConn) fail(err error) {
+	c.once.Do(func() {
+		c.err = err
+		close(c.done)
+		c.ws.Close()
+	})
+}
+
+// alive reports whether the connection has not failed yet
+func (c *wsAPIConn) alive() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// write sends v, bounded by ctx's deadline
+func (c *wsAPIConn) write(ctx context.Context, v interface{}) error {
+	if !c.alive() {
+		return c.err
+	}
+	deadline, _ := ctx.Deadline()
+	_ = c.ws.SetWriteDeadline(deadline)
+	return c.ws.WriteJSON(v)
+}
+
+// drain drops late responses to earlier requests
+func (c *wsAPIConn) drain() {
+	for {
+		select {
+		case <-c.messages:
+		default:
+			return
+		}
+	}
+}
+
+// await returns the response to the request with id, skipping late responses to earlier ones
+func (c *wsAPIConn) await(ctx context.Context, id interface{}) (*WSResponse, error) {
+	for {
+		var msg []byte
+		select {
+		case msg = <-c.messages:
+		case <-c.done:
+			// The response may have arrived just before the connection ended
+			select {
+			case msg = <-c.messages:
+			default:
+				return nil, c.err
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		var resp WSResponse
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			return nil, err
+		}
+		// Binance answers requests it cannot parse with a null id
+		if resp.ID != nil && fmt.Sprint(resp.ID) != fmt.Sprint(id) {
+			continue
+		}
+		return &resp, nil
+	}
+}
+
+// WSAPIStatus describes a WS-API client's connection
+type WSAPIStatus struct {
+	Connected      bool       `json:"connected"`
+	ConnectedSince *time.Time `json:"connected_since,omitempty"`
+	UptimeSeconds  float64    `json:"uptime_seconds"`
+	LastPongAt     *time.Time `json:"last_pong_at,omitempty"`
+	// Reconnects counts the connections dialed to replace one that died or aged out
+	Reconnects int    `json:"reconnects"`
+	LastError  string `json:"last_error,omitempty"`
+}
+
+// Status reports the client's current connection and how often it was replaced
+func (w *WSAPIClient) Status() WSAPIStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	status := WSAPIStatus{Reconnects: w.reconnects}
+	if w.conn == nil {
+		return status
+	}
+	if !w.conn.alive() {
+		status.LastError = w.conn.err.Error()
+		return status
+	}
+	since := w.conn.openedAt
+	lastPong := time.UnixMilli(w.conn.lastPong.Load())
+	status.Connected = true
+	status.ConnectedSince = &since
+	status.UptimeSeconds = time.Since(since).Seconds()
+	if lastPong.After(since) {
+		status.LastPongAt = &lastPong
+	}
+	return status
+}
+
+// liveConn returns the open connection, dialing a new one when it died or nears Binance's 24 hour
+// limit
+func (w *WSAPIClient) liveConn() (*wsAPIConn, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil, errWSAPIClosed
+	}
+	if w.conn != nil && w.conn.alive() && time.Since(w.conn.openedAt) < wsAPIMaxConnAge {
+		return w.conn, nil
+	}
+
+	if w.conn != nil {
+		w.conn.fail(fmt.Errorf("connection is %s old", time.Since(w.conn.openedAt).Round(time.Second)))
+	}
+	conn, err := dialWSAPI(w.url, w.cfg.WSAPIPingInterval)
+	if err != nil {
+		return nil, err
+	}
+	if w.conn != nil {
+		w.reconnects++
+		slog.Info("reconnected to the WS-API", "reason", w.conn.err, "reconnects", w.reconnects)
+	}
+	w.conn = conn
+	return conn, nil
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,35 +16,185 @@ type Config struct {
 	BinanceSecretKey       string
 	BinanceTestnet         bool
 	BinanceFuturesTestnetURL string
+	BinanceDeliveryTestnetURL string
 	BinanceOptionsTestnetURL string
     BinanceFuturesWSAPIURL      string
     BinanceFuturesWSAPIURLTest  string
     Ed25519PrivateKeyPath       string
+    RSAPrivateKeyPath           string
+    KeyBaseDir                  string
     WSAPISignatureMode          string
+    WSAPIPingInterval           time.Duration
 	MongoDBURI             string
 	MongoDBDatabase         string
+	MongoMaxPoolSize        int
+	MongoMinPoolSize        int
+	MongoConnectTimeout     time.Duration
+	MongoServerSelectionTimeout time.Duration
+	MongoSocketTimeout      time.Duration
+	MongoReadPreference     string
+	MongoOperationTimeout   time.Duration
 	Port                   string
+	CredentialsMasterKey   string
+	OrderReconcileInterval time.Duration
+	AuditBufferSize        int
+	RawResponseMaxBytes    int
+	APITokens              []string
+	AuthDisabled           bool
+	LogFormat              string
+	LogLevel               string
+	BinanceRetryMaxAttempts int
+	BinanceRetryBaseDelay   time.Duration
+	BinanceBreakerThreshold int
+	BinanceBreakerCooldown  time.Duration
+	ClockDriftThreshold     time.Duration
+	BinanceRecvWindow       int64 // milliseconds
+	QuoteQuantityTolerance  float64
+	RateLimitReadPerMinute  int
+	RateLimitReadBurst      int
+	RateLimitWritePerMinute int
+	RateLimitWriteBurst     int
+	RiskOverridePrincipals  []string
+	DailyLossLimit          float64
+	PaperTrading            bool
+	PaperStartingBalance    float64
+	EquitySnapshotInterval  time.Duration
+	EquityFillNotional      float64
+	MarginRatioWarning      float64
+	FundingAlertThreshold   float64
+	ClientOrderIDPrefix     string
+	WebhookMaxAttempts      int
+	WebhookRetryBaseDelay   time.Duration
+	WebhookTimeout          time.Duration
+	TelegramBotToken        string
+	TelegramChatID          string
+	TelegramEvents          []string
+	TelegramPerMinute       int
+	SlackWebhookURL         string
+	SlackEvents             []string
+	DiscordWebhookURL       string
+	DiscordEvents           []string
+	ScheduledOrderGrace     time.Duration
+	DCACheckInterval        time.Duration
+	GridSyncInterval        time.Duration
+	ExchangeInfoRefreshInterval time.Duration
+	PositionSyncInterval    time.Duration
+	IncomeSyncInterval      time.Duration
+	OrphanOrderSweepInterval time.Duration
+	OrphanOrderSweepDryRun  bool
+	ListenKeyKeepaliveInterval time.Duration
+	OrderRetentionDays      int
+	AuditRetentionDays      int
+	RiskEventRetentionDays  int
+	OrderArchiveInterval    time.Duration
+	BatchOrderConcurrency   int
+	ExportTimeout           time.Duration
+	MaxBodyBytes            int
+	MaxBatchBodyBytes       int
+	HTTPReadTimeout         time.Duration
+	HTTPReadHeaderTimeout   time.Duration
+	HTTPWriteTimeout        time.Duration
+	HTTPIdleTimeout         time.Duration
+	HTTPMaxHeaderBytes      int
+	BinanceProxyURL         string
+	BinanceCACertPath       string
 }
 
+// Load reads the configuration from the environment and .env; call Validate before using it
 func Load() *Config {
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
 	}
+	envProblems = nil
 
 	return &Config{
 		BinanceAPIKey:          getEnv("BINANCE_API_KEY", ""),
 		BinanceSecretKey:       getEnv("BINANCE_SECRET_KEY", ""),
 		BinanceTestnet:         getEnv("BINANCE_TESTNET", "true") == "true",
 		BinanceFuturesTestnetURL: getEnv("BINANCE_FUTURES_TESTNET_URL", "https://demo-fapi.binance.com"),
+		BinanceDeliveryTestnetURL: getEnv("BINANCE_DELIVERY_TESTNET_URL", "https://demo-dapi.binance.com"), // COIN-M futures
 		BinanceOptionsTestnetURL: getEnv("BINANCE_OPTIONS_TESTNET_URL", ""), // Note: Binance Options testnet may not exist
         BinanceFuturesWSAPIURL:      getEnv("BINANCE_FUTURES_WSAPI_URL", "wss://ws-fapi.binance.com/ws-fapi/v1"),
         BinanceFuturesWSAPIURLTest:  getEnv("BINANCE_FUTURES_WSAPI_URL_TEST", "wss://testnet.binancefuture.com/ws-fapi/v1"),
         Ed25519PrivateKeyPath:       getEnv("ED25519_PRIVATE_KEY_PATH", ""),
+        RSAPrivateKeyPath:           getEnv("RSA_PRIVATE_KEY_PATH", ""),
+        KeyBaseDir:                  getEnv("KEY_BASE_DIR", ""),
         WSAPISignatureMode:          getEnv("WSAPI_SIGNATURE_MODE", "ed25519"),
+        WSAPIPingInterval:           getEnvDuration("WSAPI_PING_INTERVAL", time.Minute),
 		MongoDBURI:             getEnv("MONGODB_URI", "mongodb://localhost:27017"),
 		MongoDBDatabase:         getEnv("MONGODB_DATABASE", "futures_options_db"),
+		MongoMaxPoolSize:        getEnvInt("MONGODB_MAX_POOL_SIZE", 100),
+		MongoMinPoolSize:        getEnvInt("MONGODB_MIN_POOL_SIZE", 0),
+		MongoConnectTimeout:     getEnvDuration("MONGODB_CONNECT_TIMEOUT", 10*time.Second),
+		MongoServerSelectionTimeout: getEnvDuration("MONGODB_SERVER_SELECTION_TIMEOUT", 5*time.Second),
+		MongoSocketTimeout:      getEnvDuration("MONGODB_SOCKET_TIMEOUT", 30*time.Second),
+		MongoReadPreference:     getEnv("MONGODB_READ_PREFERENCE", "primary"),
+		MongoOperationTimeout:   getEnvDuration("MONGODB_OPERATION_TIMEOUT", 10*time.Second),
 		Port:                   getEnv("PORT", "9090"),
+		CredentialsMasterKey:   getEnv("CREDENTIALS_MASTER_KEY", ""),
+		OrderReconcileInterval: getEnvDuration("ORDER_RECONCILE_INTERVAL", 5*time.Minute),
+		AuditBufferSize:        getEnvInt("AUDIT_BUFFER_SIZE", 1000),
+		RawResponseMaxBytes:    getEnvInt("RAW_RESPONSE_MAX_BYTES", 16*1024),
+		APITokens:              getEnvList("API_TOKENS"),
+		AuthDisabled:           getEnv("AUTH_DISABLED", "false") == "true",
+		LogFormat:              getEnv("LOG_FORMAT", "text"),
+		LogLevel:               getEnv("LOG_LEVEL", "info"),
+		BinanceRetryMaxAttempts: getEnvInt("BINANCE_RETRY_MAX_ATTEMPTS", 3),
+		BinanceRetryBaseDelay:   getEnvDuration("BINANCE_RETRY_BASE_DELAY", 200*time.Millisecond),
+		BinanceBreakerThreshold: getEnvInt("BINANCE_BREAKER_THRESHOLD", 5),
+		BinanceBreakerCooldown:  getEnvDuration("BINANCE_BREAKER_COOLDOWN", 30*time.Second),
+		ClockDriftThreshold:     getEnvDuration("BINANCE_CLOCK_DRIFT_THRESHOLD", 500*time.Millisecond),
+		BinanceRecvWindow:       int64(getEnvInt("BINANCE_RECV_WINDOW", 5000)),
+		QuoteQuantityTolerance:  getEnvFloat("QUOTE_QUANTITY_TOLERANCE", 0.01),
+		RateLimitReadPerMinute:  getEnvInt("RATE_LIMIT_READ_PER_MINUTE", 600),
+		RateLimitReadBurst:      getEnvInt("RATE_LIMIT_READ_BURST", 60),
+		RateLimitWritePerMinute: getEnvInt("RATE_LIMIT_WRITE_PER_MINUTE", 60),
+		RateLimitWriteBurst:     getEnvInt("RATE_LIMIT_WRITE_BURST", 10),
+		RiskOverridePrincipals:  getEnvList("RISK_OVERRIDE_PRINCIPALS"),
+		DailyLossLimit:          getEnvFloat("DAILY_LOSS_LIMIT", 0),
+		PaperTrading:            getEnv("PAPER_TRADING", "false") == "true",
+		PaperStartingBalance:    getEnvFloat("PAPER_STARTING_BALANCE", 10000),
+		EquitySnapshotInterval:  getEnvDuration("EQUITY_SNAPSHOT_INTERVAL", 15*time.Minute),
+		EquityFillNotional:      getEnvFloat("EQUITY_SNAPSHOT_FILL_NOTIONAL", 10000),
+		MarginRatioWarning:      getEnvFloat("MARGIN_RATIO_WARNING", 0.8),
+		FundingAlertThreshold:   getEnvFloat("FUNDING_ALERT_THRESHOLD", 0),
+		ClientOrderIDPrefix:     getEnv("CLIENT_ORDER_ID_PREFIX", ""),
+		WebhookMaxAttempts:      getEnvInt("WEBHOOK_MAX_ATTEMPTS", 5),
+		WebhookRetryBaseDelay:   getEnvDuration("WEBHOOK_RETRY_BASE_DELAY", time.Second),
+		WebhookTimeout:          getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		TelegramBotToken:        getEnv("TELEGRAM_BOT_TOKEN", ""),
+		TelegramChatID:          getEnv("TELEGRAM_CHAT_ID", ""),
+		TelegramEvents:          getEnvList("TELEGRAM_EVENTS"),
+		TelegramPerMinute:       getEnvInt("TELEGRAM_RATE_PER_MINUTE", 20),
+		SlackWebhookURL:         getEnv("SLACK_WEBHOOK_URL", ""),
+		SlackEvents:             getEnvList("SLACK_EVENTS"),
+		DiscordWebhookURL:       getEnv("DISCORD_WEBHOOK_URL", ""),
+		DiscordEvents:           getEnvList("DISCORD_EVENTS"),
+		ScheduledOrderGrace:     getEnvDuration("SCHEDULED_ORDER_GRACE_PERIOD", 30*time.Second),
+		DCACheckInterval:        getEnvDuration("DCA_CHECK_INTERVAL", 10*time.Second),
+		GridSyncInterval:        getEnvDuration("GRID_SYNC_INTERVAL", 30*time.Second),
+		ExchangeInfoRefreshInterval: getEnvDuration("EXCHANGE_INFO_REFRESH_INTERVAL", 30*time.Minute),
+		PositionSyncInterval:    getEnvDuration("POSITION_SYNC_INTERVAL", 5*time.Minute),
+		IncomeSyncInterval:      getEnvDuration("INCOME_SYNC_INTERVAL", time.Hour),
+		OrphanOrderSweepInterval: getEnvDuration("ORPHAN_ORDER_SWEEP_INTERVAL", 0),
+		OrphanOrderSweepDryRun:  getEnv("ORPHAN_ORDER_SWEEP_DRY_RUN", "false") == "true",
+		ListenKeyKeepaliveInterval: getEnvDuration("LISTEN_KEY_KEEPALIVE_INTERVAL", 30*time.Minute),
+		OrderRetentionDays:      getEnvInt("ORDER_RETENTION_DAYS", 0),
+		AuditRetentionDays:      getEnvInt("AUDIT_RETENTION_DAYS", 0),
+		RiskEventRetentionDays:  getEnvInt("RISK_EVENT_RETENTION_DAYS", 0),
+		OrderArchiveInterval:    getEnvDuration("ORDER_ARCHIVE_INTERVAL", 24*time.Hour),
+		BatchOrderConcurrency:   getEnvInt("BATCH_ORDER_CONCURRENCY", 5),
+		ExportTimeout:           getEnvDuration("EXPORT_TIMEOUT", 10*time.Minute),
+		MaxBodyBytes:            getEnvInt("MAX_BODY_BYTES", 1<<20),
+		MaxBatchBodyBytes:       getEnvInt("MAX_BATCH_BODY_BYTES", 8<<20),
+		HTTPReadTimeout:         getEnvDuration("HTTP_READ_TIMEOUT", 15*time.Second),
+		HTTPReadHeaderTimeout:   getEnvDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		HTTPWriteTimeout:        getEnvDuration("HTTP_WRITE_TIMEOUT", 15*time.Second),
+		HTTPIdleTimeout:         getEnvDuration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+		HTTPMaxHeaderBytes:      getEnvInt("HTTP_MAX_HEADER_BYTES", 1<<20),
+		BinanceProxyURL:         getEnv("BINANCE_PROXY_URL", ""),
+		BinanceCACertPath:       getEnv("BINANCE_CA_CERT_PATH", ""),
 	}
 }
 
@@ -51,3 +205,52 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		envProblems = append(envProblems, fmt.Sprintf("%s is not a valid duration (%q)", key, value))
+		return defaultValue
+	}
+	return d
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		envProblems = append(envProblems, fmt.Sprintf("%s is not a valid integer (%q)", key, value))
+		return defaultValue
+	}
+	return n
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		envProblems = append(envProblems, fmt.Sprintf("%s is not a valid number (%q)", key, value))
+		return defaultValue
+	}
+	return f
+}
+
+// getEnvList reads a comma-separated list, dropping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
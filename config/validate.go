@@ -0,0 +1,293 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minMasterKeyLength is the shortest accepted CREDENTIALS_MASTER_KEY
+const minMasterKeyLength = 16
+
+// MaxRecvWindow is the largest recvWindow, in milliseconds, Binance accepts
+const MaxRecvWindow = 60000
+
+// maxClientOrderIDPrefixLength keeps the longest generated client order ID, a grid order's
+// <prefix>grid-<17 character ID>-<counter>, within Binance's 36 characters
+const maxClientOrderIDPrefixLength = 7
+
+// clientOrderIDPrefixPattern is the character set Binance accepts in client order IDs
+var clientOrderIDPrefixPattern = regexp.MustCompile(`^[.A-Z:/a-z0-9_-]*$`)
+
+// envProblems collects values Load could not parse; Validate reports them with the rest
+var envProblems []string
+
+// ValidationError lists every problem found in the configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d configuration problem(s):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// configCheck collects problems, each naming the environment variable and an example value
+type configCheck struct {
+	problems []string
+}
+
+func (c *configCheck) add(env, message, example string) {
+	c.problems = append(c.problems, fmt.Sprintf("%s %s (e.g. %s=%s)", env, message, env, example))
+}
+
+// url checks that value, when required or set, is an absolute URL with one of schemes
+func (c *configCheck) url(env, value string, required bool, example string, schemes ...string) {
+	if value == "" {
+		if required {
+			c.add(env, "must be set", example)
+		}
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		c.add(env, fmt.Sprintf("is not a valid URL (%q)", value), example)
+		return
+	}
+	for _, scheme := range schemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return
+		}
+	}
+	c.add(env, fmt.Sprintf("must use %s, not %q", strings.Join(schemes, " or "), u.Scheme), example)
+}
+
+// oneOf checks that value is one of allowed, ignoring case
+func (c *configCheck) oneOf(env, value string, allowed ...string) {
+	for _, a := range allowed {
+		if strings.EqualFold(strings.TrimSpace(value), a) {
+			return
+		}
+	}
+	c.add(env, fmt.Sprintf("must be one of %s, not %q", strings.Join(allowed, ", "), value), allowed[0])
+}
+
+// atLeast checks an integer lower bound
+func (c *configCheck) atLeast(env string, value, min int) {
+	if value < min {
+		c.add(env, fmt.Sprintf("must be at least %d, not %d", min, value), strconv.Itoa(min))
+	}
+}
+
+// nonNegative checks that a duration is not negative
+func (c *configCheck) nonNegative(env string, value time.Duration, example string) {
+	if value < 0 {
+		c.add(env, fmt.Sprintf("must not be negative, not %s", value), example)
+	}
+}
+
+// keyFile checks that a configured key file exists
+func (c *configCheck) keyFile(cfg *Config, env, path, example string) {
+	if strings.TrimSpace(path) == "" {
+		return
+	}
+	resolved := cfg.KeyFilePath(strings.TrimSpace(path))
+	if info, err := os.Stat(resolved); err != nil {
+		c.add(env, fmt.Sprintf("points to %s, which cannot be read: %v", resolved, err), example)
+	} else if info.IsDir() {
+		c.add(env, fmt.Sprintf("points to %s, which is a directory", resolved), example)
+	}
+}
+
+// Validate checks the configuration before anything connects: URL syntax, settings that depend
+// on each other, numeric ranges and secret lengths. Every problem is reported at once.
+func (c *Config) Validate() error {
+	check := &configCheck{problems: append([]string(nil), envProblems...)}
+
+	check.url("MONGODB_URI", c.MongoDBURI, true, "mongodb://localhost:27017", "mongodb", "mongodb+srv")
+	if strings.TrimSpace(c.MongoDBDatabase) == "" {
+		check.add("MONGODB_DATABASE", "must be set", "futures_options_db")
+	}
+	check.oneOf("MONGODB_READ_PREFERENCE", c.MongoReadPreference, "primary", "primaryPreferred", "secondary", "secondaryPreferred", "nearest")
+	check.atLeast("MONGODB_MAX_POOL_SIZE", c.MongoMaxPoolSize, 0)
+	check.atLeast("MONGODB_MIN_POOL_SIZE", c.MongoMinPoolSize, 0)
+	if c.MongoMaxPoolSize > 0 && c.MongoMinPoolSize > c.MongoMaxPoolSize {
+		check.add("MONGODB_MIN_POOL_SIZE", fmt.Sprintf("must not exceed MONGODB_MAX_POOL_SIZE (%d)", c.MongoMaxPoolSize), strconv.Itoa(c.MongoMaxPoolSize))
+	}
+
+	check.url("BINANCE_FUTURES_TESTNET_URL", c.BinanceFuturesTestnetURL, true, "https://demo-fapi.binance.com", "https")
+	check.url("BINANCE_DELIVERY_TESTNET_URL", c.BinanceDeliveryTestnetURL, true, "https://demo-dapi.binance.com", "https")
+	if c.BinanceOptionsTestnetURL != "" {
+		check.add("BINANCE_OPTIONS_TESTNET_URL", "is not used: Binance has no options testnet, so options orders always go to mainnet; unset it", `""`)
+	}
+	check.url("BINANCE_FUTURES_WSAPI_URL", c.BinanceFuturesWSAPIURL, true, "wss://ws-fapi.binance.com/ws-fapi/v1", "wss", "ws")
+	check.url("BINANCE_FUTURES_WSAPI_URL_TEST", c.BinanceFuturesWSAPIURLTest, true, "wss://testnet.binancefuture.com/ws-fapi/v1", "wss", "ws")
+	check.url("BINANCE_PROXY_URL", c.BinanceProxyURL, false, "socks5://127.0.0.1:1080", "http", "https", "socks5", "socks5h")
+	check.keyFile(c, "BINANCE_CA_CERT_PATH", c.BinanceCACertPath, "/etc/ssl/certs/corporate-ca.pem")
+	if (c.BinanceAPIKey == "") != (c.BinanceSecretKey == "") {
+		check.add("BINANCE_API_KEY", "and BINANCE_SECRET_KEY must be set together", "<key from the Binance API management page>")
+	}
+
+	check.oneOf("WSAPI_SIGNATURE_MODE", c.WSAPISignatureMode, "ed25519", "rsa", "hmac")
+	if c.KeyBaseDir != "" {
+		if info, err := os.Stat(c.KeyBaseDir); err != nil || !info.IsDir() {
+			check.add("KEY_BASE_DIR", fmt.Sprintf("must be an existing directory, not %q", c.KeyBaseDir), "/etc/futures-options")
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(c.WSAPISignatureMode)) {
+	case "rsa":
+		check.keyFile(c, "RSA_PRIVATE_KEY_PATH", c.RSAPrivateKeyPath, "~/keys/binance-rsa.pem")
+	case "ed25519":
+		// Without a path the key may still be stored on the active credential, which is only
+		// known once MongoDB is connected
+		check.keyFile(c, "ED25519_PRIVATE_KEY_PATH", c.Ed25519PrivateKeyPath, "~/keys/binance-ed25519.pem")
+	}
+	if c.WSAPIPingInterval <= 0 {
+		check.add("WSAPI_PING_INTERVAL", fmt.Sprintf("must be positive, not %s", c.WSAPIPingInterval), "1m")
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		check.add("PORT", fmt.Sprintf("must be a port number between 1 and 65535, not %q", c.Port), "9090")
+	}
+	if key := c.CredentialsMasterKey; key != "" && len(strings.TrimSpace(key)) < minMasterKeyLength {
+		check.add("CREDENTIALS_MASTER_KEY", fmt.Sprintf("must be at least %d characters long", minMasterKeyLength), "$(openssl rand -hex 32)")
+	} else if key == "" && !c.BinanceTestnet {
+		check.add("CREDENTIALS_MASTER_KEY", "must be set when BINANCE_TESTNET=false", "$(openssl rand -hex 32)")
+	}
+	if c.AuthDisabled && !c.BinanceTestnet {
+		check.add("AUTH_DISABLED", "is only allowed with BINANCE_TESTNET=true", "false")
+	}
+	check.oneOf("LOG_FORMAT", c.LogFormat, "text", "json")
+	check.oneOf("LOG_LEVEL", c.LogLevel, "info", "debug", "warn", "warning", "error")
+
+	check.atLeast("BINANCE_RETRY_MAX_ATTEMPTS", c.BinanceRetryMaxAttempts, 1)
+	check.atLeast("BINANCE_BREAKER_THRESHOLD", c.BinanceBreakerThreshold, 1)
+	if c.BinanceRecvWindow < 1 || c.BinanceRecvWindow > MaxRecvWindow {
+		check.add("BINANCE_RECV_WINDOW", fmt.Sprintf("must be between 1 and %d milliseconds, not %d", MaxRecvWindow, c.BinanceRecvWindow), "5000")
+	}
+	check.atLeast("RATE_LIMIT_READ_PER_MINUTE", c.RateLimitReadPerMinute, 0)
+	check.atLeast("RATE_LIMIT_WRITE_PER_MINUTE", c.RateLimitWritePerMinute, 0)
+	check.atLeast("BATCH_ORDER_CONCURRENCY", c.BatchOrderConcurrency, 1)
+	check.atLeast("WEBHOOK_MAX_ATTEMPTS", c.WebhookMaxAttempts, 1)
+	check.atLeast("ORDER_RETENTION_DAYS", c.OrderRetentionDays, 0)
+	check.atLeast("AUDIT_RETENTION_DAYS", c.AuditRetentionDays, 0)
+	check.atLeast("RISK_EVENT_RETENTION_DAYS", c.RiskEventRetentionDays, 0)
+	check.atLeast("MAX_BODY_BYTES", c.MaxBodyBytes, 1)
+	check.atLeast("MAX_BATCH_BODY_BYTES", c.MaxBatchBodyBytes, 1)
+	check.atLeast("HTTP_MAX_HEADER_BYTES", c.HTTPMaxHeaderBytes, 1)
+	if c.QuoteQuantityTolerance < 0 || c.QuoteQuantityTolerance >= 1 {
+		check.add("QUOTE_QUANTITY_TOLERANCE", fmt.Sprintf("must be a fraction from 0 up to 1, not %g", c.QuoteQuantityTolerance), "0.01")
+	}
+	if c.MarginRatioWarning <= 0 || c.MarginRatioWarning > 1 {
+		check.add("MARGIN_RATIO_WARNING", fmt.Sprintf("must be above 0 and at most 1, not %g", c.MarginRatioWarning), "0.8")
+	}
+	if c.FundingAlertThreshold < 0 {
+		check.add("FUNDING_ALERT_THRESHOLD", "must not be negative; give the payment as a positive amount", "25")
+	}
+	if len(c.ClientOrderIDPrefix) > maxClientOrderIDPrefixLength {
+		check.add("CLIENT_ORDER_ID_PREFIX", fmt.Sprintf("must be at most %d characters so generated IDs fit Binance's 36", maxClientOrderIDPrefixLength), "fo-")
+	} else if !clientOrderIDPrefixPattern.MatchString(c.ClientOrderIDPrefix) {
+		check.add("CLIENT_ORDER_ID_PREFIX", "may only contain letters, digits and .:/_-", "fo-")
+	}
+	if c.DailyLossLimit < 0 {
+		check.add("DAILY_LOSS_LIMIT", "must not be negative; give the loss as a positive amount", "500")
+	}
+	if c.PaperTrading && c.PaperStartingBalance <= 0 {
+		check.add("PAPER_STARTING_BALANCE", "must be positive with PAPER_TRADING=true", "10000")
+	}
+	for env, d := range map[string]time.Duration{
+		"HTTP_READ_TIMEOUT":         c.HTTPReadTimeout,
+		"HTTP_READ_HEADER_TIMEOUT":  c.HTTPReadHeaderTimeout,
+		"HTTP_WRITE_TIMEOUT":        c.HTTPWriteTimeout,
+		"HTTP_IDLE_TIMEOUT":         c.HTTPIdleTimeout,
+		"MONGODB_OPERATION_TIMEOUT": c.MongoOperationTimeout,
+		"EXPORT_TIMEOUT":            c.ExportTimeout,
+	} {
+		check.nonNegative(env, d, "30s")
+	}
+
+	if (c.TelegramBotToken == "") != (c.TelegramChatID == "") {
+		check.add("TELEGRAM_CHAT_ID", "and TELEGRAM_BOT_TOKEN must be set together", "123456789")
+	}
+	check.url("SLACK_WEBHOOK_URL", c.SlackWebhookURL, false, "https://hooks.slack.com/services/...", "https")
+	check.url("DISCORD_WEBHOOK_URL", c.DiscordWebhookURL, false, "https://discord.com/api/webhooks/...", "https")
+
+	if len(check.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: check.problems}
+}
+
+// KeyFilePath expands a leading ~ and resolves a relative key path against KEY_BASE_DIR
+func (c *Config) KeyFilePath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	if !filepath.IsAbs(path) && c.KeyBaseDir != "" {
+		path = filepath.Join(c.KeyBaseDir, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return path
+}
+
+// LogValue logs the resolved configuration; secrets only show whether they are set
+func (c *Config) LogValue() slog.Value {
+	set := func(secret string) bool { return secret != "" }
+	return slog.GroupValue(
+		slog.String("port", c.Port),
+		slog.Bool("binance_testnet", c.BinanceTestnet),
+		slog.Bool("binance_api_key_set", set(c.BinanceAPIKey)),
+		slog.String("binance_futures_testnet_url", c.BinanceFuturesTestnetURL),
+		slog.String("binance_delivery_testnet_url", c.BinanceDeliveryTestnetURL),
+		slog.String("binance_futures_wsapi_url", c.BinanceFuturesWSAPIURL),
+		slog.String("binance_futures_wsapi_url_test", c.BinanceFuturesWSAPIURLTest),
+		slog.String("binance_proxy_url", redactURI(c.BinanceProxyURL)),
+		slog.String("binance_ca_cert_path", c.BinanceCACertPath),
+		slog.String("wsapi_signature_mode", c.WSAPISignatureMode),
+		slog.String("ed25519_private_key_path", c.Ed25519PrivateKeyPath),
+		slog.String("rsa_private_key_path", c.RSAPrivateKeyPath),
+		slog.String("key_base_dir", c.KeyBaseDir),
+		slog.String("mongodb_uri", redactURI(c.MongoDBURI)),
+		slog.String("mongodb_database", c.MongoDBDatabase),
+		slog.String("mongodb_read_preference", c.MongoReadPreference),
+		slog.Int("mongodb_max_pool_size", c.MongoMaxPoolSize),
+		slog.Duration("mongodb_operation_timeout", c.MongoOperationTimeout),
+		slog.Bool("credentials_master_key_set", set(c.CredentialsMasterKey)),
+		slog.Int("api_tokens", len(c.APITokens)),
+		slog.Bool("auth_disabled", c.AuthDisabled),
+		slog.Bool("paper_trading", c.PaperTrading),
+		slog.Int("rate_limit_read_per_minute", c.RateLimitReadPerMinute),
+		slog.Int("rate_limit_write_per_minute", c.RateLimitWritePerMinute),
+		slog.Float64("daily_loss_limit", c.DailyLossLimit),
+		slog.Int("binance_retry_max_attempts", c.BinanceRetryMaxAttempts),
+		slog.Int64("binance_recv_window_ms", c.BinanceRecvWindow),
+		slog.Int("batch_order_concurrency", c.BatchOrderConcurrency),
+		slog.Bool("telegram", set(c.TelegramBotToken)),
+		slog.Bool("slack", set(c.SlackWebhookURL)),
+		slog.Bool("discord", set(c.DiscordWebhookURL)),
+		slog.Duration("http_read_timeout", c.HTTPReadTimeout),
+		slog.Duration("http_write_timeout", c.HTTPWriteTimeout),
+		slog.Duration("http_idle_timeout", c.HTTPIdleTimeout),
+		slog.Int("max_body_bytes", c.MaxBodyBytes),
+		slog.Int("order_retention_days", c.OrderRetentionDays),
+		slog.Duration("export_timeout", c.ExportTimeout),
+	)
+}
+
+// redactURI drops the password from a connection URI
+func redactURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "[unparseable]"
+	}
+	return u.Redacted()
+}
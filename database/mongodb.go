@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"futures-options/config"
@@ -10,6 +11,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 var (
@@ -17,17 +19,67 @@ var (
 	DB         *mongo.Database
 	FuturesCollection *mongo.Collection
 	OptionsCollection *mongo.Collection
+	SpotOrdersCollection *mongo.Collection
+	TransfersCollection *mongo.Collection
 	PositionsCollection *mongo.Collection
 	APICredentialsCollection *mongo.Collection
+	RiskEventsCollection *mongo.Collection
+	AuditLogCollection *mongo.Collection
+	APITokensCollection *mongo.Collection
+	RiskLimitsCollection *mongo.Collection
+	SymbolSettingsCollection *mongo.Collection
+	TradingPolicyCollection *mongo.Collection
+	OrderTemplatesCollection *mongo.Collection
+	WatchlistCollection *mongo.Collection
+	ReconciliationReportsCollection *mongo.Collection
+	FuturesArchiveCollection *mongo.Collection
+	JobsCollection *mongo.Collection
+	IncomeCollection *mongo.Collection
+	FillsCollection *mongo.Collection
+	EquitySnapshotsCollection *mongo.Collection
+	WebhooksCollection *mongo.Collection
+	WebhookDeadLettersCollection *mongo.Collection
+	ConditionalOrdersCollection *mongo.Collection
+	ScheduledOrdersCollection *mongo.Collection
+	DCAPlansCollection *mongo.Collection
+	GridStrategiesCollection *mongo.Collection
+	CalendarSpreadsCollection *mongo.Collection
+	PositionModeCollection *mongo.Collection
+	PaperOrdersCollection *mongo.Collection
+	PaperPositionsCollection *mongo.Collection
+	PaperAccountCollection *mongo.Collection
+	KlinesCollection *mongo.Collection
+	JournalEntriesCollection *mongo.Collection
+
+	// TransactionsSupported is set by Connect when the server is a replica set member or mongos,
+	// the deployments that support multi-document transactions
+	TransactionsSupported bool
 )
 
+// paperPrefix is prepended to the trading data collections in paper trading mode, so simulated
+// orders, positions and audit entries never mix with real ones
+const paperPrefix = "paper_"
+
 func Connect(cfg *config.Config) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	readPref, err := readPreference(cfg.MongoReadPreference)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.MongoConnectTimeout+cfg.MongoServerSelectionTimeout)
 	defer cancel()
 
-	clientOptions := options.Client().ApplyURI(cfg.MongoDBURI)
+	clientOptions := options.Client().ApplyURI(cfg.MongoDBURI).
+		SetMaxPoolSize(uint64(cfg.MongoMaxPoolSize)).
+		SetMinPoolSize(uint64(cfg.MongoMinPoolSize)).
+		SetConnectTimeout(cfg.MongoConnectTimeout).
+		SetServerSelectionTimeout(cfg.MongoServerSelectionTimeout).
+		SetSocketTimeout(cfg.MongoSocketTimeout).
+		SetReadPreference(readPref).
+		SetPoolMonitor(poolMonitor()).
+		SetMonitor(commandMonitor())
+	pool.maxSize = uint64(cfg.MongoMaxPoolSize)
 
-	var err error
 	Client, err = mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		return fmt.Errorf("failed to connect to MongoDB: %w", err)
@@ -40,42 +92,161 @@ func Connect(cfg *config.Config) error {
 	}
 
 	DB = Client.Database(cfg.MongoDBDatabase)
-	FuturesCollection = DB.Collection("futures_orders")
-	OptionsCollection = DB.Collection("options_orders")
-	PositionsCollection = DB.Collection("positions")
+	TransactionsSupported = detectTransactions(ctx)
+	if !TransactionsSupported {
+		slog.Warn("MongoDB is a standalone server; related writes are not wrapped in transactions")
+	}
+	prefix := ""
+	if cfg.PaperTrading {
+		prefix = paperPrefix
+	}
+	FuturesCollection = DB.Collection(prefix + "futures_orders")
+	FuturesArchiveCollection = DB.Collection(prefix + "futures_orders_archive")
+	OptionsCollection = DB.Collection(prefix + "options_orders")
+	SpotOrdersCollection = DB.Collection(prefix + "spot_orders")
+	TransfersCollection = DB.Collection(prefix + "transfers")
+	PositionsCollection = DB.Collection(prefix + "positions")
+	PositionModeCollection = DB.Collection(prefix + "position_mode")
+	RiskEventsCollection = DB.Collection(prefix + "risk_events")
+	AuditLogCollection = DB.Collection(prefix + "audit_log")
+	IncomeCollection = DB.Collection(prefix + "income")
+	FillsCollection = DB.Collection(prefix + "futures_trades")
+	EquitySnapshotsCollection = DB.Collection(prefix + "equity_snapshots")
+	ConditionalOrdersCollection = DB.Collection(prefix + "conditional_orders")
+	ScheduledOrdersCollection = DB.Collection(prefix + "scheduled_orders")
+	DCAPlansCollection = DB.Collection(prefix + "dca_plans")
+	GridStrategiesCollection = DB.Collection(prefix + "grid_strategies")
+	CalendarSpreadsCollection = DB.Collection(prefix + "calendar_spreads")
+	ReconciliationReportsCollection = DB.Collection(prefix + "reconciliation_reports")
+	JobsCollection = DB.Collection(prefix + "jobs")
+	JournalEntriesCollection = DB.Collection(prefix + "journal_entries")
 	APICredentialsCollection = DB.Collection("api_credentials")
+	APITokensCollection = DB.Collection("api_tokens")
+	RiskLimitsCollection = DB.Collection("risk_limits")
+	SymbolSettingsCollection = DB.Collection("symbol_settings")
+	TradingPolicyCollection = DB.Collection("trading_policy")
+	OrderTemplatesCollection = DB.Collection("order_templates")
+	WatchlistCollection = DB.Collection("watchlist")
+	WebhooksCollection = DB.Collection("webhooks")
+	WebhookDeadLettersCollection = DB.Collection("webhook_dead_letters")
+	// Market data is the same whether or not trading is simulated
+	KlinesCollection = DB.Collection("klines")
+
+	// The paper trading engine's own book, standing in for the exchange
+	PaperOrdersCollection = DB.Collection(paperPrefix + "exchange_orders")
+	PaperPositionsCollection = DB.Collection(paperPrefix + "exchange_positions")
+	PaperAccountCollection = DB.Collection(paperPrefix + "account")
 
 	fmt.Println("Connected to MongoDB successfully!")
+	slog.Info("MongoDB settings",
+		"min_pool_size", cfg.MongoMinPoolSize, "max_pool_size", cfg.MongoMaxPoolSize,
+		"connect_timeout", cfg.MongoConnectTimeout, "server_selection_timeout", cfg.MongoServerSelectionTimeout,
+		"socket_timeout", cfg.MongoSocketTimeout, "read_preference", readPref.Mode().String(),
+		"operation_timeout", cfg.MongoOperationTimeout, "transactions", TransactionsSupported)
 	return nil
 }
 
-func Disconnect() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// readPreference parses MONGODB_READ_PREFERENCE: primary, primaryPreferred, secondary,
+// secondaryPreferred or nearest
+func readPreference(mode string) (*readpref.ReadPref, error) {
+	m, err := readpref.ModeFromString(mode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MONGODB_READ_PREFERENCE %q: %w", mode, err)
+	}
+	return readpref.New(m)
+}
+
+// detectTransactions reports whether the connected deployment supports transactions: a replica
+// set member (setName in hello) or a mongos router
+func detectTransactions(ctx context.Context) bool {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := Client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		slog.Warn("failed to detect MongoDB topology, transactions disabled", "error", err)
+		return false
+	}
+	return hello.SetName != "" || hello.Msg == "isdbgrid"
+}
+
+// Ping checks that MongoDB is reachable
+func Ping(ctx context.Context) error {
+	if Client == nil {
+		return fmt.Errorf("MongoDB client is not connected")
+	}
+	return Client.Ping(ctx, nil)
+}
+
+// Disconnect closes the MongoDB connection, waiting for in-use connections until ctx is done
+func Disconnect(ctx context.Context) error {
+	if Client == nil {
+		return nil
+	}
 	return Client.Disconnect(ctx)
 }
 
-// CreateIndexes creates indexes for better query performance
-func CreateIndexes() error {
+// CreateIndexes creates indexes for better query performance, and the TTL indexes of the
+// retention windows in cfg
+func CreateIndexes(cfg *config.Config) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	// Futures orders indexes
 	futuresIndexes := []mongo.IndexModel{
 		{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "created_at", Value: -1}}},
-		{Keys: bson.D{{Key: "binance_order_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		binanceOrderIDIndex(),
+		// Order search: exact and prefix client order ID lookups, and orders placed by a strategy
+		{Keys: bson.D{{Key: "client_order_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "strategy_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetSparse(true)},
+		// Archival of terminal orders by age
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "updated_at", Value: 1}}},
+		// Listings of one account (X-Account)
+		{Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetSparse(true)},
+	}
+	futuresArchiveIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "binance_order_id", Value: 1}}},
 	}
 
 	// Options orders indexes
 	optionsIndexes := []mongo.IndexModel{
 		{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "created_at", Value: -1}}},
-		{Keys: bson.D{{Key: "binance_order_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		binanceOrderIDIndex(),
+		// Listings of one account (X-Account)
+		{Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetSparse(true)},
+	}
+
+	// Older versions created a plain unique index that rejects a second order without a Binance ID
+	for _, coll := range []*mongo.Collection{FuturesCollection, OptionsCollection} {
+		if err := dropLegacyOrderIDIndex(ctx, coll); err != nil {
+			return err
+		}
 	}
 
-	// Positions indexes
+	// Spot orders indexes; the status index serves the reconciler
+	spotOrdersIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}}},
+		binanceOrderIDIndex(),
+		// Listings of one account (X-Account)
+		{Keys: bson.D{{Key: "account_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetSparse(true)},
+	}
+
+	// Transfer indexes
+	transfersIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tran_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	}
+
+	// Positions indexes; hedge-mode legs are stored per side
+	if err := migratePositionSides(ctx); err != nil {
+		return err
+	}
 	positionsIndexes := []mongo.IndexModel{
-		{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "type", Value: 1}}},
+		{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "type", Value: 1}, {Key: "side", Value: 1}}},
 		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "account_id", Value: 1}}},
 	}
 
 	// API Credentials indexes
@@ -84,16 +255,144 @@ func CreateIndexes() error {
 		{Keys: bson.D{{Key: "api_key", Value: 1}}, Options: options.Index().SetUnique(true)},
 	}
 
+	// Risk events indexes
+	riskEventsIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "created_at", Value: -1}}},
+	}
+
+	// Audit log indexes
+	auditLogIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "timestamp", Value: -1}}},
+	}
+
+	// API token indexes
+	apiTokensIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+	}
+
+	// Risk limit indexes
+	riskLimitsIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "symbol", Value: 1}}, Options: options.Index().SetUnique(true)},
+	}
+
+	// Symbol settings indexes
+	symbolSettingsIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "symbol", Value: 1}}, Options: options.Index().SetUnique(true)},
+	}
+
+	// Order template indexes
+	orderTemplatesIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+	}
+
+	// Watchlist indexes
+	watchlistIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "symbol", Value: 1}}, Options: options.Index().SetUnique(true)},
+	}
+
+	// Reconciliation report indexes
+	reconciliationReportsIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "started_at", Value: -1}}},
+	}
+
+	// Job run indexes
+	jobsIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+	}
+
+	// Income history indexes; a record is unique per transaction, income type and symbol
+	incomeIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tran_id", Value: 1}, {Key: "income_type", Value: 1}, {Key: "symbol", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "time", Value: 1}}},
+	}
+
+	// Fill indexes; a fill is unique per trade of an order, and the feed pages by fill time
+	fillsIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "product", Value: 1}, {Key: "symbol", Value: 1}, {Key: "binance_order_id", Value: 1}, {Key: "trade_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "fill_time", Value: -1}, {Key: "_id", Value: -1}}},
+	}
+
+	// Equity snapshot indexes
+	equitySnapshotsIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "time", Value: 1}}},
+	}
+
+	// Webhook dead letter indexes
+	webhookDeadLettersIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	}
+
+	// Conditional order indexes
+	conditionalOrdersIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "group", Value: 1}, {Key: "status", Value: 1}}},
+	}
+
+	// Scheduled order indexes
+	scheduledOrdersIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "execute_at", Value: 1}}},
+	}
+
+	// DCA plan indexes
+	dcaPlansIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+	}
+
+	// Grid strategy indexes
+	gridStrategiesIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+	}
+
+	// Kline indexes; a candle is unique per symbol, interval and open time
+	klinesIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "interval", Value: 1}, {Key: "open_time", Value: 1}}, Options: options.Index().SetUnique(true)},
+	}
+
+	// Journal indexes
+	journalEntriesIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "order_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "tags", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	}
+
+	// Paper trading engine indexes
+	paperOrdersIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "order_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "filled_at", Value: 1}}},
+	}
+	paperPositionsIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "symbol", Value: 1}, {Key: "position_side", Value: 1}}, Options: options.Index().SetUnique(true)},
+	}
+
 	_, err := FuturesCollection.Indexes().CreateMany(ctx, futuresIndexes)
 	if err != nil {
 		return fmt.Errorf("failed to create futures indexes: %w", err)
 	}
 
+	_, err = FuturesArchiveCollection.Indexes().CreateMany(ctx, futuresArchiveIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create futures archive indexes: %w", err)
+	}
+
 	_, err = OptionsCollection.Indexes().CreateMany(ctx, optionsIndexes)
 	if err != nil {
 		return fmt.Errorf("failed to create options indexes: %w", err)
 	}
 
+	_, err = SpotOrdersCollection.Indexes().CreateMany(ctx, spotOrdersIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create spot orders indexes: %w", err)
+	}
+
+	_, err = TransfersCollection.Indexes().CreateMany(ctx, transfersIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create transfers indexes: %w", err)
+	}
+
 	_, err = PositionsCollection.Indexes().CreateMany(ctx, positionsIndexes)
 	if err != nil {
 		return fmt.Errorf("failed to create positions indexes: %w", err)
@@ -104,7 +403,195 @@ func CreateIndexes() error {
 		return fmt.Errorf("failed to create credentials indexes: %w", err)
 	}
 
+	_, err = RiskEventsCollection.Indexes().CreateMany(ctx, riskEventsIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create risk events indexes: %w", err)
+	}
+
+	_, err = AuditLogCollection.Indexes().CreateMany(ctx, auditLogIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log indexes: %w", err)
+	}
+
+	_, err = APITokensCollection.Indexes().CreateMany(ctx, apiTokensIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create API token indexes: %w", err)
+	}
+
+	_, err = RiskLimitsCollection.Indexes().CreateMany(ctx, riskLimitsIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create risk limit indexes: %w", err)
+	}
+
+	_, err = SymbolSettingsCollection.Indexes().CreateMany(ctx, symbolSettingsIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create symbol settings indexes: %w", err)
+	}
+
+	_, err = OrderTemplatesCollection.Indexes().CreateMany(ctx, orderTemplatesIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create order template indexes: %w", err)
+	}
+
+	_, err = WatchlistCollection.Indexes().CreateMany(ctx, watchlistIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create watchlist indexes: %w", err)
+	}
+
+	_, err = ReconciliationReportsCollection.Indexes().CreateMany(ctx, reconciliationReportsIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create reconciliation report indexes: %w", err)
+	}
+
+	_, err = JobsCollection.Indexes().CreateMany(ctx, jobsIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create job indexes: %w", err)
+	}
+
+	_, err = IncomeCollection.Indexes().CreateMany(ctx, incomeIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create income indexes: %w", err)
+	}
+
+	_, err = FillsCollection.Indexes().CreateMany(ctx, fillsIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create fill indexes: %w", err)
+	}
+
+	_, err = EquitySnapshotsCollection.Indexes().CreateMany(ctx, equitySnapshotsIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create equity snapshot indexes: %w", err)
+	}
+
+	_, err = WebhookDeadLettersCollection.Indexes().CreateMany(ctx, webhookDeadLettersIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook dead letter indexes: %w", err)
+	}
+
+	_, err = ConditionalOrdersCollection.Indexes().CreateMany(ctx, conditionalOrdersIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create conditional order indexes: %w", err)
+	}
+
+	_, err = ScheduledOrdersCollection.Indexes().CreateMany(ctx, scheduledOrdersIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create scheduled order indexes: %w", err)
+	}
+
+	_, err = DCAPlansCollection.Indexes().CreateMany(ctx, dcaPlansIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create DCA plan indexes: %w", err)
+	}
+
+	_, err = GridStrategiesCollection.Indexes().CreateMany(ctx, gridStrategiesIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create grid strategy indexes: %w", err)
+	}
+
+	_, err = KlinesCollection.Indexes().CreateMany(ctx, klinesIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create kline indexes: %w", err)
+	}
+
+	_, err = JournalEntriesCollection.Indexes().CreateMany(ctx, journalEntriesIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create journal entry indexes: %w", err)
+	}
+
+	_, err = PaperOrdersCollection.Indexes().CreateMany(ctx, paperOrdersIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create paper order indexes: %w", err)
+	}
+
+	_, err = PaperPositionsCollection.Indexes().CreateMany(ctx, paperPositionsIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to create paper position indexes: %w", err)
+	}
+
+	// Audit entries and risk events expire on their own; orders are archived by the retention job
+	if err := ensureTTLIndex(ctx, AuditLogCollection, "timestamp", cfg.AuditRetentionDays); err != nil {
+		return err
+	}
+	if err := ensureTTLIndex(ctx, RiskEventsCollection, "event_time", cfg.RiskEventRetentionDays); err != nil {
+		return err
+	}
+
 	fmt.Println("Indexes created successfully!")
 	return nil
 }
 
+
+// binanceOrderIDIndex is unique only for real (positive) Binance order IDs, so orders
+// saved without one (rejected or pending) don't collide on the missing value
+func binanceOrderIDIndex() mongo.IndexModel {
+	return mongo.IndexModel{
+		Keys: bson.D{{Key: "binance_order_id", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"binance_order_id": bson.M{"$gt": 0}}),
+	}
+}
+
+// dropLegacyOrderIDIndex drops the old non-partial unique binance_order_id index if present
+func dropLegacyOrderIDIndex(ctx context.Context, coll *mongo.Collection) error {
+	cursor, err := coll.Indexes().List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list %s indexes: %w", coll.Name(), err)
+	}
+	defer cursor.Close(ctx)
+
+	var indexes []bson.M
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return fmt.Errorf("failed to decode %s indexes: %w", coll.Name(), err)
+	}
+
+	for _, idx := range indexes {
+		if idx["name"] != "binance_order_id_1" {
+			continue
+		}
+		if _, partial := idx["partialFilterExpression"]; partial {
+			return nil
+		}
+		if _, err := coll.Indexes().DropOne(ctx, "binance_order_id_1"); err != nil {
+			return fmt.Errorf("failed to drop legacy %s binance_order_id index: %w", coll.Name(), err)
+		}
+		slog.Info("dropped legacy binance_order_id index", "collection", coll.Name())
+	}
+	return nil
+}
+
+// migratePositionSides prepares positions stored when they were keyed by symbol and type only:
+// futures positions without a side get BOTH (one-way mode), and the old symbol+type index is
+// dropped in favour of symbol+type+side
+func migratePositionSides(ctx context.Context) error {
+	result, err := PositionsCollection.UpdateMany(ctx,
+		bson.M{"type": "FUTURES", "$or": bson.A{bson.M{"side": bson.M{"$exists": false}}, bson.M{"side": ""}}},
+		bson.M{"$set": bson.M{"side": "BOTH"}})
+	if err != nil {
+		return fmt.Errorf("failed to migrate position sides: %w", err)
+	}
+	if result.ModifiedCount > 0 {
+		slog.Info("set side BOTH on positions stored without one", "positions", result.ModifiedCount)
+	}
+
+	cursor, err := PositionsCollection.Indexes().List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list positions indexes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var indexes []bson.M
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return fmt.Errorf("failed to decode positions indexes: %w", err)
+	}
+	for _, idx := range indexes {
+		if idx["name"] != "symbol_1_type_1" {
+			continue
+		}
+		if _, err := PositionsCollection.Indexes().DropOne(ctx, "symbol_1_type_1"); err != nil {
+			return fmt.Errorf("failed to drop legacy positions index: %w", err)
+		}
+		slog.Info("dropped legacy positions index", "index", "symbol_1_type_1")
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+
+	"futures-options/latency"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// PoolStats counts the MongoDB driver's connection pool events since startup
+type PoolStats struct {
+	MaxPoolSize      uint64 `json:"max_pool_size"`
+	Open             int64  `json:"open"`   // connections created and not yet closed
+	InUse            int64  `json:"in_use"` // connections checked out
+	Created          int64  `json:"created"`
+	Closed           int64  `json:"closed"`
+	Checkouts        int64  `json:"checkouts"`
+	CheckoutFailures int64  `json:"checkout_failures"`
+	Cleared          int64  `json:"cleared"` // pool clears after a server error
+}
+
+var pool struct {
+	maxSize          uint64
+	created          atomic.Int64
+	closed           atomic.Int64
+	checkedOut       atomic.Int64
+	checkedIn        atomic.Int64
+	checkoutFailures atomic.Int64
+	cleared          atomic.Int64
+}
+
+// poolMonitor feeds the driver's pool events into the counters reported by GetPoolStats
+func poolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{Event: func(e *event.PoolEvent) {
+		switch e.Type {
+		case event.ConnectionCreated:
+			pool.created.Add(1)
+		case event.ConnectionClosed:
+			pool.closed.Add(1)
+		case event.GetSucceeded:
+			pool.checkedOut.Add(1)
+		case event.ConnectionReturned:
+			pool.checkedIn.Add(1)
+		case event.GetFailed:
+			pool.checkoutFailures.Add(1)
+		case event.PoolCleared:
+			pool.cleared.Add(1)
+		}
+	}}
+}
+
+// GetPoolStats returns the connection pool counters
+func GetPoolStats() PoolStats {
+	created, closed := pool.created.Load(), pool.closed.Load()
+	out, in := pool.checkedOut.Load(), pool.checkedIn.Load()
+	return PoolStats{
+		MaxPoolSize:      pool.maxSize,
+		Open:             created - closed,
+		InUse:            out - in,
+		Created:          created,
+		Closed:           closed,
+		Checkouts:        out,
+		CheckoutFailures: pool.checkoutFailures.Load(),
+		Cleared:          pool.cleared.Load(),
+	}
+}
+
+// commandMonitor records the latency of every MongoDB command for the latency diagnostics
+func commandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			latency.Record(latency.MongoDB, e.Duration, false)
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			latency.Record(latency.MongoDB, e.Duration, true)
+		},
+	}
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CollectionStats is the size of a collection as reported by MongoDB
+type CollectionStats struct {
+	Name        string `json:"name"`
+	Documents   int64  `json:"documents"`
+	SizeBytes   int64  `json:"size_bytes"`    // uncompressed data size
+	StorageSize int64  `json:"storage_bytes"` // on disk
+	Error       string `json:"error,omitempty"`
+}
+
+// ensureTTLIndex keeps a TTL index named <field>_ttl on coll expiring documents days after field.
+// The index is created, its window changed in place with collMod, or dropped when days is 0, so
+// calling it again with the configured value is safe.
+func ensureTTLIndex(ctx context.Context, coll *mongo.Collection, field string, days int) error {
+	name := field + "_ttl"
+	cursor, err := coll.Indexes().List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list %s indexes: %w", coll.Name(), err)
+	}
+	var indexes []bson.M
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return fmt.Errorf("failed to decode %s indexes: %w", coll.Name(), err)
+	}
+
+	var existing bson.M
+	for _, idx := range indexes {
+		if idx["name"] == name {
+			existing = idx
+			break
+		}
+	}
+
+	if days <= 0 {
+		if existing == nil {
+			return nil
+		}
+		if _, err := coll.Indexes().DropOne(ctx, name); err != nil {
+			return fmt.Errorf("failed to drop %s TTL index: %w", coll.Name(), err)
+		}
+		slog.Info("dropped TTL index", "collection", coll.Name(), "field", field)
+		return nil
+	}
+
+	seconds := int32(time.Duration(days) * 24 * time.Hour / time.Second)
+	if existing == nil {
+		model := mongo.IndexModel{
+			Keys:    bson.D{{Key: field, Value: 1}},
+			Options: options.Index().SetName(name).SetExpireAfterSeconds(seconds),
+		}
+		if _, err := coll.Indexes().CreateOne(ctx, model); err != nil {
+			return fmt.Errorf("failed to create %s TTL index: %w", coll.Name(), err)
+		}
+		return nil
+	}
+	if current, ok := existing["expireAfterSeconds"]; ok && fmt.Sprint(current) == fmt.Sprint(seconds) {
+		return nil
+	}
+	cmd := bson.D{
+		{Key: "collMod", Value: coll.Name()},
+		{Key: "index", Value: bson.D{{Key: "name", Value: name}, {Key: "expireAfterSeconds", Value: seconds}}},
+	}
+	if err := DB.RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("failed to change %s TTL index: %w", coll.Name(), err)
+	}
+	slog.Info("changed TTL index", "collection", coll.Name(), "field", field, "days", days)
+	return nil
+}
+
+// RetentionStats returns the document count and size of the collections covered by the
+// retention policy; a collection whose stats cannot be read reports the error
+func RetentionStats(ctx context.Context) ([]*CollectionStats, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("MongoDB is not connected")
+	}
+	colls := []*mongo.Collection{FuturesCollection, FuturesArchiveCollection, AuditLogCollection, RiskEventsCollection}
+	stats := make([]*CollectionStats, len(colls))
+	for i, coll := range colls {
+		var result struct {
+			Count       int64 `bson:"count"`
+			Size        int64 `bson:"size"`
+			StorageSize int64 `bson:"storageSize"`
+		}
+		stats[i] = &CollectionStats{Name: coll.Name()}
+		if err := DB.RunCommand(ctx, bson.D{{Key: "collStats", Value: coll.Name()}}).Decode(&result); err != nil {
+			stats[i].Error = err.Error()
+			continue
+		}
+		stats[i].Documents = result.Count
+		stats[i].SizeBytes = result.Size
+		stats[i].StorageSize = result.StorageSize
+	}
+	return stats, nil
+}
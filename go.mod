@@ -6,6 +6,7 @@ require (
 	github.com/adshao/go-binance/v2 v2.4.5
 	github.com/gorilla/mux v1.8.1
 	github.com/joho/godotenv v1.5.1
+	github.com/shopspring/decimal v1.4.0
 	github.com/swaggo/http-swagger v1.3.4
 	go.mongodb.org/mongo-driver v1.13.1
 )
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"futures-options/services"
+)
+
+// accountMiddleware signs the request with the stored credential named in X-Account or the account
+// query parameter, and limits the listings it returns to that account. Without either, the
+// active credential is used and listings cover every account.
+func (h *Handlers) accountMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSpace(r.Header.Get("X-Account"))
+		if id == "" {
+			id = strings.TrimSpace(r.URL.Query().Get("account"))
+		}
+		if id == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, err := h.tradingService.WithAccount(r.Context(), id)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, services.ErrInvalidID) {
+				status = http.StatusBadRequest
+			}
+			writeServiceError(w, status, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// accountErrorStatus maps account selection errors to HTTP status codes; ok is false for other errors
+func accountErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, services.ErrAccountNotSupported):
+		return http.StatusBadRequest, true
+	case errors.Is(err, services.ErrAccountNotFound):
+		return http.StatusNotFound, true
+	case errors.Is(err, services.ErrAccountUnavailable):
+		return http.StatusNotImplemented, true
+	default:
+		return 0, false
+	}
+}
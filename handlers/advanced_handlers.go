@@ -1,66 +1,73 @@
 package handlers
 
 import (
-	"crypto/ed25519"
-	"crypto/rand"
-	"encoding/base64"
-	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"os"
 
+	"futures-options/models"
 	"futures-options/services"
 )
 
 // CreateAdvancedFuturesOrder handles POST /api/futures/advanced/order
 // @Summary      Create advanced futures order
-// @Description  Create a futures order with advanced features (STOP, TAKE_PROFIT, TRAILING_STOP, STP, PriceMatch, etc.)
+// @Description  Create a futures order with advanced features (STOP, TAKE_PROFIT, TRAILING_STOP, STP, PriceMatch, etc.). quote_quantity sizes the order by notional instead of quantity.
 // @Tags         futures
 // @Accept       json
 // @Produce      json
 // @Param        order  body      services.AdvancedOrderRequest  true  "Advanced Futures Order Request"
-// @Success      200    {object}  models.FuturesOrder
-// @Failure      400    {string}  string  "Bad Request"
-// @Failure      500    {string}  string  "Internal Server Error"
+// @Success      201    {object}  models.FuturesOrder
+// @Success      200    {object}  models.FuturesOrder  "client_order_id already placed: the stored order, with replayed set"
+// @Header       201    {string}  Location  "GET URL of the order"
+// @Failure      400    {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      403    {object}  handlers.ErrorResponse  "Risk limit override not allowed for this token"
+// @Failure      409    {object}  handlers.ErrorResponse  "Symbol not open for trading"
+// @Failure      422    {object}  handlers.ErrorResponse  "Order would exceed a risk limit"
+// @Failure      423    {object}  handlers.ErrorResponse  "Trading is paused"
+// @Failure      429    {object}  handlers.ErrorResponse  "Too many new orders for the symbol"
+// @Failure      500    {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501    {object}  handlers.ErrorResponse  "COIN-M is not available in paper trading mode"
 // @Router       /api/futures/advanced/order [post]
 func (h *Handlers) CreateAdvancedFuturesOrder(w http.ResponseWriter, r *http.Request) {
 	var req services.AdvancedOrderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 
 	order, err := h.tradingService.CreateAdvancedFuturesOrder(r.Context(), &req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, riskErrorStatus(err), err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(order)
+	writeCreated(w, "/api/futures/orders/"+order.ID.Hex(), order.Replayed, order)
 }
 
 // ModifyFuturesOrder handles PUT /api/futures/order/modify
 // @Summary      Modify futures order
-// @Description  Modify an existing futures order (price, quantity, stop price, etc.)
+// @Description  Modify an existing futures order (price, quantity, stop price, etc.). The stored order is updated only after Binance accepts the change; force_local corrects the stored order without contacting Binance.
 // @Tags         futures
 // @Accept       json
 // @Produce      json
 // @Param        order  body      services.ModifyOrderRequest  true  "Modify Order Request"
 // @Success      200    {object}  models.FuturesOrder
-// @Failure      400    {string}  string  "Bad Request"
-// @Failure      500    {string}  string  "Internal Server Error"
+// @Failure      400    {object}  handlers.ErrorResponse  "Bad Request or rejected by Binance"
+// @Failure      404    {object}  handlers.ErrorResponse  "Order not stored"
+// @Failure      500    {object}  handlers.ErrorResponse  "Internal Server Error"
 // @Router       /api/futures/order/modify [put]
 func (h *Handlers) ModifyFuturesOrder(w http.ResponseWriter, r *http.Request) {
 	var req services.ModifyOrderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 
 	order, err := h.tradingService.ModifyFuturesOrder(r.Context(), &req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrOrderNotFound) {
+			status = http.StatusNotFound
+		}
+		writeServiceError(w, status, err)
 		return
 	}
 
@@ -75,24 +82,30 @@ func (h *Handlers) ModifyFuturesOrder(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param        orders  body      services.BatchOrderRequest  true  "Batch Orders Request"
-// @Success      200     {object}  services.BatchOrderResponse
-// @Failure      400     {string}  string  "Bad Request"
-// @Failure      500     {string}  string  "Internal Server Error"
+// @Success      201     {object}  services.BatchOrderResponse
+// @Failure      400     {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      403     {object}  handlers.ErrorResponse  "Risk limit override not allowed for this token"
+// @Failure      409     {object}  handlers.ErrorResponse  "Symbol not open for trading, or the orders would exceed its open order cap"
+// @Failure      422     {object}  handlers.ErrorResponse  "Order would exceed a risk limit"
+// @Failure      423     {object}  handlers.ErrorResponse  "Trading is paused"
+// @Failure      429     {object}  handlers.ErrorResponse  "Too many new orders for the symbol"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
 // @Router       /api/futures/batch/orders [post]
 func (h *Handlers) CreateBatchOrders(w http.ResponseWriter, r *http.Request) {
 	var req services.BatchOrderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 
 	response, err := h.tradingService.CreateBatchOrders(r.Context(), &req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, riskErrorStatus(err), err)
 		return
 	}
 
+	// Several orders are created, so there is no single Location; each order's id names its URL
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -103,23 +116,34 @@ func (h *Handlers) CreateBatchOrders(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param        symbol          query     string   true  "Trading symbol"
+// @Param        market          query     string   false "Futures market: usdm (default) or coinm"
 // @Param        order_ids       query     []int64  false "Order IDs to cancel"
 // @Param        client_order_ids query     []string false "Client Order IDs to cancel"
 // @Success      200  {object}  map[string]string
-// @Failure      400  {string}  string  "Bad Request"
-// @Failure      500  {string}  string  "Internal Server Error"
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501  {object}  handlers.ErrorResponse  "COIN-M is not available in paper trading mode"
 // @Router       /api/futures/batch/orders/cancel [delete]
 func (h *Handlers) CancelBatchOrders(w http.ResponseWriter, r *http.Request) {
 	symbol := r.URL.Query().Get("symbol")
 	if symbol == "" {
-		http.Error(w, "symbol parameter is required", http.StatusBadRequest)
+		writeValidationError(w, FieldError{Field: "symbol", Rule: services.RuleRequired, Message: "is required"})
+		return
+	}
+	market, err := parseMarketParam(r.URL.Query().Get("market"))
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
 		return
 	}
 
 	// Parse order IDs from query (simplified - would need proper parsing)
-	err := h.tradingService.CancelBatchOrders(r.Context(), symbol, nil, nil)
+	if market == models.MarketCoinM {
+		err = h.tradingService.CancelCoinMOrders(r.Context(), symbol, nil, nil)
+	} else {
+		err = h.tradingService.CancelBatchOrders(r.Context(), symbol, nil, nil)
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, paperErrorStatus(err), err)
 		return
 	}
 
@@ -127,6 +151,65 @@ func (h *Handlers) CancelBatchOrders(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "Orders cancelled successfully"})
 }
 
+// ChangeLeverage handles POST /api/futures/leverage
+// @Summary      Set leverage
+// @Description  Set the leverage of a USDⓈ-M symbol on Binance. Orders only change the leverage when it differs from the cached value, so use this after changing it elsewhere.
+// @Tags         futures
+// @Accept       json
+// @Produce      json
+// @Param        leverage  body      services.LeverageRequest  true  "Symbol and leverage (1-125)"
+// @Success      200       {object}  services.LeverageRequest
+// @Failure      400       {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500       {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/leverage [post]
+func (h *Handlers) ChangeLeverage(w http.ResponseWriter, r *http.Request) {
+	var req services.LeverageRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	if err := h.tradingService.ChangeLeverage(r.Context(), &req); err != nil {
+		var validationErr *services.ValidationError
+		status := http.StatusInternalServerError
+		if errors.As(err, &validationErr) {
+			status = http.StatusBadRequest
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(req)
+}
+
+// ChangeMarginType handles POST /api/futures/margin-type
+// @Summary      Set margin type
+// @Description  Set a USDⓈ-M symbol's margin type, ISOLATED or CROSSED, on Binance. A symbol that already has it is not an error. Binance refuses the change while the symbol has a position or open orders.
+// @Tags         futures
+// @Accept       json
+// @Produce      json
+// @Param        margin_type  body      services.MarginTypeRequest  true  "Symbol and margin type"
+// @Success      200          {object}  services.MarginTypeRequest
+// @Failure      400          {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      409          {object}  handlers.ErrorResponse  "Position or open orders on the symbol"
+// @Failure      500          {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501          {object}  handlers.ErrorResponse  "Isolated margin in paper trading mode"
+// @Router       /api/futures/margin-type [post]
+func (h *Handlers) ChangeMarginType(w http.ResponseWriter, r *http.Request) {
+	var req services.MarginTypeRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	if err := h.tradingService.ChangeMarginType(r.Context(), &req); err != nil {
+		writeServiceError(w, settingsErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(req)
+}
+
 // SetPositionMode handles POST /api/futures/position-mode
 // @Summary      Set position mode
 // @Description  Switch between One-way and Hedge position mode
@@ -135,25 +218,24 @@ func (h *Handlers) CancelBatchOrders(w http.ResponseWriter, r *http.Request) {
 // @Produce      json
 // @Param        mode  body      map[string]bool  true  "Position mode: {\"dual_side\": true} for Hedge, false for One-way"
 // @Success      200   {object}  map[string]string
-// @Failure      400   {string}  string  "Bad Request"
-// @Failure      500   {string}  string  "Internal Server Error"
+// @Failure      400   {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500   {object}  handlers.ErrorResponse  "Internal Server Error"
 // @Router       /api/futures/position-mode [post]
 func (h *Handlers) SetPositionMode(w http.ResponseWriter, r *http.Request) {
 	var req map[string]bool
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 
 	dualSide, ok := req["dual_side"]
 	if !ok {
-		http.Error(w, "dual_side parameter is required", http.StatusBadRequest)
+		writeValidationError(w, FieldError{Field: "dual_side", Rule: services.RuleRequired, Message: "is required"})
 		return
 	}
 
 	err := h.tradingService.SetPositionMode(r.Context(), dualSide)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -167,12 +249,12 @@ func (h *Handlers) SetPositionMode(w http.ResponseWriter, r *http.Request) {
 // @Tags         futures
 // @Produce      json
 // @Success      200  {object}  models.PositionModeConfig
-// @Failure      500  {string}  string  "Internal Server Error"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
 // @Router       /api/futures/position-mode [get]
 func (h *Handlers) GetPositionMode(w http.ResponseWriter, r *http.Request) {
 	mode, err := h.tradingService.GetPositionMode(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -186,7 +268,7 @@ func (h *Handlers) GetPositionMode(w http.ResponseWriter, r *http.Request) {
 // @Tags         websocket
 // @Produce      json
 // @Success      200  {object}  map[string]string
-// @Failure      500  {string}  string  "Internal Server Error"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
 // @Router       /api/websocket/connect [get]
 func (h *Handlers) ConnectWebSocket(w http.ResponseWriter, r *http.Request) {
 	// WebSocket upgrade would be handled here
@@ -204,7 +286,7 @@ func (h *Handlers) ConnectWebSocket(w http.ResponseWriter, r *http.Request) {
 // @Tags         websocket
 // @Produce      json
 // @Success      200  {array}  models.WebSocketMessage
-// @Failure      500  {string}  string  "Internal Server Error"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
 // @Router       /api/websocket/messages [get]
 func (h *Handlers) GetWebSocketMessages(w http.ResponseWriter, r *http.Request) {
 	// Placeholder - would need WebSocket message storage
@@ -212,17 +294,43 @@ func (h *Handlers) GetWebSocketMessages(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode([]interface{}{})
 }
 
+// GetWebSocketStatus handles GET /api/websocket/status
+// @Summary      WebSocket connection status
+// @Description  Whether the user data stream is connected, and the uptime, last pong and reconnect count of the pooled WS-API connection
+// @Tags         websocket
+// @Produce      json
+// @Success      200  {object}  services.WebSocketStatus
+// @Router       /api/websocket/status [get]
+func (h *Handlers) GetWebSocketStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.tradingService.WebSocketStatus())
+}
+
 // GetAccountStatusWS handles GET /api/futures/account/status (WS API)
 // @Summary      Get account status via WebSocket API
+// @Description  USDⓈ-M uses the WebSocket API; market=coinm returns the COIN-M account over REST
 // @Tags         futures
 // @Produce      json
+// @Param        market  query     string  false  "Futures market: usdm (default) or coinm"
 // @Success      200  {object}  interface{}
-// @Failure      500  {string}  string  "Internal Server Error"
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501  {object}  handlers.ErrorResponse  "COIN-M is not available in paper trading mode"
 // @Router       /api/futures/account/status [get]
 func (h *Handlers) GetAccountStatusWS(w http.ResponseWriter, r *http.Request) {
-    result, err := h.tradingService.GetAccountStatusWS(r.Context())
+    market, err := parseMarketParam(r.URL.Query().Get("market"))
     if err != nil {
-        http.Error(w, err.Error(), http.StatusInternalServerError)
+        writeServiceError(w, http.StatusBadRequest, err)
+        return
+    }
+    var result interface{}
+    if market == models.MarketCoinM {
+        result, err = h.tradingService.GetCoinMAccount(r.Context())
+    } else {
+        result, err = h.tradingService.GetAccountStatusWS(r.Context())
+    }
+    if err != nil {
+        writeServiceError(w, paperErrorStatus(err), err)
         return
     }
     w.Header().Set("Content-Type", "application/json")
@@ -231,15 +339,29 @@ func (h *Handlers) GetAccountStatusWS(w http.ResponseWriter, r *http.Request) {
 
 // GetAccountBalanceWS handles GET /api/futures/account/balance (WS API)
 // @Summary      Get account balance via WebSocket API
+// @Description  USDⓈ-M uses the WebSocket API; market=coinm returns the COIN-M balance of every margin coin over REST
 // @Tags         futures
 // @Produce      json
+// @Param        market  query     string  false  "Futures market: usdm (default) or coinm"
 // @Success      200  {object}  interface{}
-// @Failure      500  {string}  string  "Internal Server Error"
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501  {object}  handlers.ErrorResponse  "COIN-M is not available in paper trading mode"
 // @Router       /api/futures/account/balance [get]
 func (h *Handlers) GetAccountBalanceWS(w http.ResponseWriter, r *http.Request) {
-    result, err := h.tradingService.GetAccountBalanceWS(r.Context())
+    market, err := parseMarketParam(r.URL.Query().Get("market"))
+    if err != nil {
+        writeServiceError(w, http.StatusBadRequest, err)
+        return
+    }
+    var result interface{}
+    if market == models.MarketCoinM {
+        result, err = h.tradingService.GetCoinMBalance(r.Context())
+    } else {
+        result, err = h.tradingService.GetAccountBalanceWS(r.Context())
+    }
     if err != nil {
-        http.Error(w, err.Error(), http.StatusInternalServerError)
+        writeServiceError(w, paperErrorStatus(err), err)
         return
     }
     w.Header().Set("Content-Type", "application/json")
@@ -253,25 +375,25 @@ func (h *Handlers) GetAccountBalanceWS(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param        order  body      services.CreateOptionsOrderRequest  true  "Options Order Request"
-// @Success      200    {object}  models.OptionsOrder
-// @Failure      400    {string}  string  "Bad Request"
-// @Failure      500    {string}  string  "Internal Server Error"
+// @Success      201    {object}  models.OptionsOrder
+// @Header       201    {string}  Location  "GET URL of the order"
+// @Failure      400    {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500    {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501    {object}  handlers.ErrorResponse  "Paper trading mode"
 // @Router       /api/options/order [post]
 func (h *Handlers) CreateOptionsOrderAdvanced(w http.ResponseWriter, r *http.Request) {
 	var req services.CreateOptionsOrderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 
 	order, err := h.tradingService.CreateOptionsOrder(r.Context(), &req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, paperErrorStatus(err), err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(order)
+	writeCreated(w, "/api/options/orders/"+order.ID.Hex(), false, order)
 }
 
 // GetOptionsPositions handles GET /api/options/positions
@@ -280,12 +402,13 @@ func (h *Handlers) CreateOptionsOrderAdvanced(w http.ResponseWriter, r *http.Req
 // @Tags         options
 // @Produce      json
 // @Success      200  {array}  models.Position
-// @Failure      500  {string}  string  "Internal Server Error"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501  {object}  handlers.ErrorResponse  "Paper trading mode"
 // @Router       /api/options/positions [get]
 func (h *Handlers) GetOptionsPositions(w http.ResponseWriter, r *http.Request) {
 	positions, err := h.tradingService.GetOptionsPositions(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, paperErrorStatus(err), err)
 		return
 	}
 
@@ -295,39 +418,73 @@ func (h *Handlers) GetOptionsPositions(w http.ResponseWriter, r *http.Request) {
 
 // GenerateEd25519Key handles POST /api/keys/ed25519/generate
 // @Summary      Generate Ed25519 keypair (seed + public)
-// @Description  Generates a 32-byte Ed25519 private seed, writes it to ed25519.key, and returns seed/public in HEX and Base64
+// @Description  Generates an Ed25519 key for a stored credential (the active one by default) and stores its seed encrypted on the credential, replacing any key it had; WS-API requests are signed with the active credential's key. Returns the public key in HEX and Base64 to register with Binance; the private seed is only returned with include_private=true, and only this once.
 // @Tags         keys
 // @Produce      json
-// @Success      200  {object}  map[string]string
-// @Failure      500  {string}  string  "Internal Server Error"
+// @Param        credential_id    query     string  false  "Credential ID (default: the active credential)"
+// @Param        include_private  query     bool    false  "Also return the private seed"
+// @Success      200  {object}  services.Ed25519Key
+// @Failure      400  {object}  handlers.ErrorResponse  "Invalid credential ID"
+// @Failure      404  {object}  handlers.ErrorResponse  "Credential not found"
+// @Failure      409  {object}  handlers.ErrorResponse  "Mainnet credential without a master key"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
 // @Router       /api/keys/ed25519/generate [post]
 func (h *Handlers) GenerateEd25519Key(w http.ResponseWriter, r *http.Request) {
-    // Generate Ed25519 keypair
-    pub, priv, err := ed25519.GenerateKey(rand.Reader)
-    if err != nil {
-        http.Error(w, "failed to generate key", http.StatusInternalServerError)
-        return
-    }
+	query := r.URL.Query()
+	key, err := h.tradingService.GenerateEd25519Key(r.Context(), query.Get("credential_id"), query.Get("include_private") == "true")
+	if err != nil {
+		writeServiceError(w, credentialErrorStatus(err), err)
+		return
+	}
 
-    // Extract 32-byte seed from 64-byte private key
-    seed := priv.Seed()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(key)
+}
 
-    // Write seed to file in project root
-    filePath := "ed25519.key"
-    if err := os.WriteFile(filePath, seed, 0600); err != nil {
-        http.Error(w, "failed to write key file", http.StatusInternalServerError)
-        return
-    }
+// ImportEd25519Key handles POST /api/keys/ed25519/import
+// @Summary      Import an Ed25519 key
+// @Description  Store an Ed25519 key already registered with Binance on a stored credential (the active one by default), encrypted like the secret key and replacing any key it had. The key may be PKCS#8 PEM, or a 32-byte seed or 64-byte private key in hex or base64; with expected_public_key the import fails unless the derived public key matches. Returns the derived public key.
+// @Tags         keys
+// @Accept       json
+// @Produce      json
+// @Param        request  body      services.ImportEd25519KeyRequest  true  "Private key"
+// @Success      200      {object}  services.Ed25519Key
+// @Failure      400      {object}  handlers.ErrorResponse  "Malformed key or public key mismatch"
+// @Failure      404      {object}  handlers.ErrorResponse  "Credential not found"
+// @Failure      409      {object}  handlers.ErrorResponse  "Mainnet credential without a master key"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/keys/ed25519/import [post]
+func (h *Handlers) ImportEd25519Key(w http.ResponseWriter, r *http.Request) {
+	var req services.ImportEd25519KeyRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
 
-    resp := map[string]string{
-        "filePath":          filePath,
-        "privateSeedHEX":    hex.EncodeToString(seed),
-        "privateSeedB64":    base64.StdEncoding.EncodeToString(seed),
-        "publicKeyHEX":      hex.EncodeToString(pub),
-        "publicKeyB64":      base64.StdEncoding.EncodeToString(pub),
-        // "note":              "Register publicKeyHEX/B64 with Binance WS-API; keep private seed secret",
-    }
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(resp)
+	key, err := h.tradingService.ImportEd25519Key(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, credentialErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(key)
 }
 
+// ReconcileFuturesOrders handles POST /api/futures/orders/reconcile
+// @Summary      Reconcile futures orders
+// @Description  Compare locally stored NEW/PARTIALLY_FILLED orders with Binance and update drifted statuses
+// @Tags         futures
+// @Produce      json
+// @Success      200  {object}  services.ReconcileSummary
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/orders/reconcile [post]
+func (h *Handlers) ReconcileFuturesOrders(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.tradingService.ReconcileFuturesOrders(r.Context())
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
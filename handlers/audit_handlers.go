@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"futures-options/services"
+)
+
+// GetAuditLog handles GET /api/audit
+// @Summary      Get audit log
+// @Description  Retrieve a page of audited trading actions (orders, position mode, credentials), newest first
+// @Tags         audit
+// @Produce      json
+// @Param        since   query     string  false  "Entries at or after (RFC3339 or Unix ms)"
+// @Param        action  query     string  false  "Filter by action (e.g., ORDER_CREATE, ORDER_CANCEL, CREDENTIAL_SAVE)"
+// @Param        symbol  query     string  false  "Filter by symbol (e.g., BTCUSDT)"
+// @Param        limit   query     int     false  "Page size (default 100, max 1000)"
+// @Param        offset  query     int     false  "Number of entries to skip"
+// @Success      200     {object}  services.AuditLogPage
+// @Failure      400     {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/audit [get]
+func (h *Handlers) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := &services.AuditQuery{
+		Action: strings.ToUpper(q.Get("action")),
+		Symbol: q.Get("symbol"),
+	}
+
+	var err error
+	if query.Since, err = parseTimeParam(q.Get("since"), "since"); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	if query.Limit, err = parseNonNegativeInt(q.Get("limit"), "limit"); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	if query.Offset, err = parseNonNegativeInt(q.Get("offset"), "offset"); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := h.tradingService.GetAuditLog(r.Context(), query)
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
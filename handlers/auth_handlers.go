@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// authMiddleware requires a valid token in "Authorization: Bearer <token>" or X-API-Token
+func (h *Handlers) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := h.authService.Authenticate(r.Context(), requestToken(r))
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, services.ErrUnauthorized) {
+				status = http.StatusUnauthorized
+				w.Header().Set("WWW-Authenticate", `Bearer realm="futures-options"`)
+			}
+			WriteError(w, status, errorCodeForStatus(status), err.Error(), nil)
+			return
+		}
+
+		if rec, ok := w.(*statusRecorder); ok {
+			rec.principal = principal
+		}
+		next.ServeHTTP(w, r.WithContext(services.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// requestToken extracts the API token from the Authorization or X-API-Token header
+func requestToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if scheme, token, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Token"))
+}
+
+// CreateAPIToken handles POST /api/auth/tokens
+// @Summary      Create an API token
+// @Description  Issue a new REST API token; the plaintext token is only returned in this response
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        token  body      services.CreateAPITokenRequest  true  "Token label"
+// @Success      200    {object}  services.CreatedAPIToken
+// @Failure      400    {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      401    {object}  handlers.ErrorResponse  "Unauthorized"
+// @Failure      500    {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/auth/tokens [post]
+func (h *Handlers) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateAPITokenRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	token, err := h.authService.CreateToken(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(token)
+}
+
+// ListAPITokens handles GET /api/auth/tokens
+// @Summary      List API tokens
+// @Description  List stored REST API tokens (labels and prefixes only)
+// @Tags         auth
+// @Produce      json
+// @Success      200  {array}   models.APIToken
+// @Failure      401  {object}  handlers.ErrorResponse  "Unauthorized"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/auth/tokens [get]
+func (h *Handlers) ListAPITokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.authService.ListTokens(r.Context())
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// DeleteAPIToken handles DELETE /api/auth/tokens/{id}
+// @Summary      Revoke an API token
+// @Description  Delete a stored REST API token; tokens from API_TOKENS cannot be revoked here
+// @Tags         auth
+// @Produce      json
+// @Param        id   path      string  true  "Token ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      401  {object}  handlers.ErrorResponse  "Unauthorized"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/auth/tokens/{id} [delete]
+func (h *Handlers) DeleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	err := h.authService.DeleteToken(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrInvalidID):
+			status = http.StatusBadRequest
+		case errors.Is(err, services.ErrTokenNotFound):
+			status = http.StatusNotFound
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Token revoked successfully"})
+}
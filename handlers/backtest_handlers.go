@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"futures-options/services"
+)
+
+// BacktestStrategy handles POST /api/strategies/backtest
+// @Summary      Backtest a grid strategy or DCA plan
+// @Description  Replay a grid or DCA configuration, as accepted by POST /api/strategies/grid and POST /api/futures/dca, over the symbol's klines of interval in [start_time, end_time), loaded through the kline cache. Candles are walked open, low, high, close when they close up and open, high, low, close otherwise; a limit order fills at its price when the walk touches it, market orders fill at the open (price step DCA orders at the step price), and every fill pays commission_rate (default 0.0004) on its notional. Returns the trades, realized profit, max drawdown and final position.
+// @Tags         strategies
+// @Accept       json
+// @Produce      json
+// @Param        request  body      services.BacktestRequest  true  "Backtest"
+// @Success      200      {object}  strategy.BacktestResult
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/strategies/backtest [post]
+func (h *Handlers) BacktestStrategy(w http.ResponseWriter, r *http.Request) {
+	var req services.BacktestRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	result, err := h.tradingService.Backtest(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, validationErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetKlines handles GET /api/futures/klines
+// @Summary      Get klines
+// @Description  Get a USDⓈ-M symbol's closed klines opening in [start_time, end_time), oldest first. Ranges the kline cache holds in full are served from MongoDB; others are fetched from Binance and cached.
+// @Tags         futures
+// @Produce      json
+// @Param        symbol      query     string  true   "Symbol (e.g., BTCUSDT)"
+// @Param        interval    query     string  true   "Interval: 1m, 3m, 5m, 15m, 30m, 1h, 2h, 4h, 6h, 8h, 12h, 1d, 3d or 1w"
+// @Param        start_time  query     string  true   "Start (RFC3339 or Unix milliseconds)"
+// @Param        end_time    query     string  false  "End (RFC3339 or Unix milliseconds), default now"
+// @Success      200         {array}   models.Kline
+// @Failure      400         {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500         {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/klines [get]
+func (h *Handlers) GetKlines(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	start, err := parseTimeParam(q.Get("start_time"), "start_time")
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	end, err := parseTimeParam(q.Get("end_time"), "end_time")
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	var startTime, endTime time.Time
+	if start != nil {
+		startTime = *start
+	}
+	if end != nil {
+		endTime = *end
+	}
+
+	klines, err := h.tradingService.GetKlines(r.Context(), q.Get("symbol"), q.Get("interval"), startTime, endTime)
+	if err != nil {
+		writeServiceError(w, validationErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(klines)
+}
+
+// validationErrorStatus is 400 for validation errors and 500 otherwise
+func validationErrorStatus(err error) int {
+	var validationErr *services.ValidationError
+	if errors.As(err, &validationErr) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"futures-options/binance"
+)
+
+// binanceErrorStatus picks the HTTP status for a failed Binance call so callers can tell
+// their own mistakes (4xx) from exchange outages (502) and clock drift (504); ok is false for
+// non-Binance errors
+func binanceErrorStatus(err error) (status int, ok bool) {
+	if errors.Is(err, binance.ErrCircuitOpen) {
+		// Checked first: the breaker's error also looks like a transport failure
+		return http.StatusServiceUnavailable, true
+	}
+	var rateLimitErr *binance.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return http.StatusTooManyRequests, true
+	}
+	if code := binance.APIErrorCode(err); code != 0 {
+		return binanceCodeStatus(code), true
+	}
+
+	httpStatus := binance.HTTPStatusCode(err)
+	switch {
+	case httpStatus == http.StatusTooManyRequests || httpStatus == http.StatusTeapot:
+		// 418 means the IP is banned after ignoring 429s
+		return http.StatusTooManyRequests, true
+	case httpStatus != 0:
+		return http.StatusBadGateway, true
+	case binance.IsTransportError(err):
+		return http.StatusBadGateway, true
+	}
+	return 0, false
+}
+
+// binanceCodeStatus maps a Binance error code to an HTTP status.
+// See https://developers.binance.com/docs/derivatives/usds-margined-futures/error-code
+func binanceCodeStatus(code int64) int {
+	switch code {
+	case -1021: // timestamp outside recvWindow, even after the clock offset was measured again
+		return http.StatusGatewayTimeout
+	case -1003, -1015: // too many requests / too many new orders
+		return http.StatusTooManyRequests
+	case -2018, -2019: // balance / margin is insufficient
+		return http.StatusPaymentRequired
+	case -2011, -2013: // unknown order / order does not exist
+		return http.StatusNotFound
+	case -2021, // order would immediately trigger
+		-2022, // reduce-only order rejected
+		-4046, // no need to change margin type
+		-4047, // margin type cannot change with open orders
+		-4048, // margin type cannot change with an open position
+		-4059, // no need to change position side
+		-4061, // position side does not match the position mode
+		-4067, // position side cannot change with open orders
+		-4068, // position side cannot change with open positions
+		-5022: // post-only order would immediately match
+		return http.StatusConflict
+	case -1111, // precision over the maximum for this asset
+		-1013: // filter failure (price/lot size/notional)
+		return http.StatusUnprocessableEntity
+	case -1000, -1001, -1006, -1007, -1008, // unknown error, disconnected, unexpected response, timeout, overloaded
+		-1022,        // invalid signature
+		-2014, -2015: // server API key rejected
+		return http.StatusBadGateway
+	}
+
+	switch {
+	case code <= -1100 && code >= -1199: // request parameter errors, e.g. -1121 invalid symbol
+		return http.StatusBadRequest
+	case code <= -2000: // order, account and position rejections
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusBadGateway
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"futures-options/binance"
+
+	"github.com/adshao/go-binance/v2/common"
+)
+
+func TestBinanceCodeStatus(t *testing.T) {
+	tests := []struct {
+		code int64
+		want int
+	}{
+		{-1021, http.StatusGatewayTimeout},
+		{-1003, http.StatusTooManyRequests},
+		{-1015, http.StatusTooManyRequests},
+		{-2018, http.StatusPaymentRequired},
+		{-2019, http.StatusPaymentRequired},
+		{-2011, http.StatusNotFound},
+		{-2013, http.StatusNotFound},
+		{-2021, http.StatusConflict},
+		{-2022, http.StatusConflict},
+		{-4046, http.StatusConflict},
+		{-4047, http.StatusConflict},
+		{-4048, http.StatusConflict},
+		{-4059, http.StatusConflict},
+		{-4061, http.StatusConflict},
+		{-4067, http.StatusConflict},
+		{-4068, http.StatusConflict},
+		{-5022, http.StatusConflict},
+		{-1111, http.StatusUnprocessableEntity},
+		{-1013, http.StatusUnprocessableEntity},
+		{-1000, http.StatusBadGateway},
+		{-1001, http.StatusBadGateway},
+		{-1006, http.StatusBadGateway},
+		{-1007, http.StatusBadGateway},
+		{-1008, http.StatusBadGateway},
+		{-1022, http.StatusBadGateway},
+		{-2014, http.StatusBadGateway},
+		{-2015, http.StatusBadGateway},
+		// ranges
+		{-1100, http.StatusBadRequest},
+		{-1121, http.StatusBadRequest},
+		{-1199, http.StatusBadRequest},
+		{-2010, http.StatusUnprocessableEntity},
+		{-4003, http.StatusUnprocessableEntity},
+		// default
+		{-1002, http.StatusBadGateway},
+		{-1200, http.StatusBadGateway},
+		{-999, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.code), func(t *testing.T) {
+			if got := binanceCodeStatus(tt.code); got != tt.want {
+				t.Errorf("binanceCodeStatus(%d) = %d, want %d", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBinanceErrorStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		want   int
+		wantOK bool
+	}{
+		{"circuit open", fmt.Errorf("create order: %w", binance.ErrCircuitOpen), http.StatusServiceUnavailable, true},
+		{"rate limited", &binance.RateLimitError{}, http.StatusTooManyRequests, true},
+		{"API error code", fmt.Errorf("wrapped: %w", &common.APIError{Code: -2019}), http.StatusPaymentRequired, true},
+		{"HTTP error code", &binance.HTTPError{StatusCode: http.StatusBadRequest, Code: -1121}, http.StatusBadRequest, true},
+		{"IP banned", &binance.HTTPError{StatusCode: http.StatusTeapot}, http.StatusTooManyRequests, true},
+		{"HTTP 503 without code", &binance.HTTPError{StatusCode: http.StatusServiceUnavailable}, http.StatusBadGateway, true},
+		{"not a Binance error", errors.New("boom"), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := binanceErrorStatus(tt.err)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("binanceErrorStatus = %d, %v; want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Request body limits used until SetBodyLimits is called
+const (
+	defaultMaxBodyBytes      = 1 << 20
+	defaultMaxBatchBodyBytes = 8 << 20
+)
+
+// SetBodyLimits caps request bodies at body bytes, or batch bytes on the batch order routes.
+// Zero keeps the default.
+func (h *Handlers) SetBodyLimits(body, batch int64) {
+	h.maxBodyBytes = body
+	h.maxBatchBodyBytes = batch
+}
+
+// bodyLimit returns the body limit of the route r matched
+func (h *Handlers) bodyLimit(r *http.Request) int64 {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil && strings.Contains(template, "/batch/") {
+			if h.maxBatchBodyBytes > 0 {
+				return h.maxBatchBodyBytes
+			}
+			return defaultMaxBatchBodyBytes
+		}
+	}
+	if h.maxBodyBytes > 0 {
+		return h.maxBodyBytes
+	}
+	return defaultMaxBodyBytes
+}
+
+// bodyLimitMiddleware answers 413 for bodies declared larger than the route's limit and caps the
+// rest, so decodeJSONBody stops reading (and answers 413) once a body without a length goes over it
+func (h *Handlers) bodyLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := h.bodyLimit(r)
+		if r.ContentLength > limit {
+			writeBodyTooLarge(w, limit)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeBodyTooLarge writes the 413 for a body over limit bytes
+func writeBodyTooLarge(w http.ResponseWriter, limit int64) {
+	WriteError(w, http.StatusRequestEntityTooLarge, ErrCodePayloadTooLarge,
+		fmt.Sprintf("request body exceeds the %d byte limit", limit),
+		map[string]int64{"limit": limit})
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"futures-options/services"
+)
+
+// GetExchangeInfoCache handles GET /api/admin/cache/exchange-info
+// @Summary      Get the exchange info cache
+// @Description  Report each market's cached exchange info (symbol rules and contract sizes): whether it is loaded, how many symbols it holds, its age and the last fetch error.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   binance.ExchangeInfoStatus
+// @Router       /api/admin/cache/exchange-info [get]
+func (h *Handlers) GetExchangeInfoCache(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.tradingService.ExchangeInfoStatus())
+}
+
+// RefreshExchangeInfoCache handles POST /api/admin/cache/exchange-info/refresh
+// @Summary      Refresh the exchange info cache
+// @Description  Reload the cached exchange info now, e.g. after Binance lists a new contract. Without market, USDⓈ-M and every market in use are reloaded. A market whose fetch fails keeps serving its previous entry and reports the error in last_error.
+// @Tags         admin
+// @Produce      json
+// @Param        market  query     string  false  "Comma-separated markets: usdm, coinm, spot, options"
+// @Success      200     {array}   binance.ExchangeInfoStatus
+// @Failure      400     {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/admin/cache/exchange-info/refresh [post]
+func (h *Handlers) RefreshExchangeInfoCache(w http.ResponseWriter, r *http.Request) {
+	var markets []string
+	if m := r.URL.Query().Get("market"); m != "" {
+		markets = strings.Split(m, ",")
+	}
+
+	statuses, err := h.tradingService.RefreshExchangeInfo(r.Context(), markets)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			status = http.StatusBadRequest
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateConditionalOrder handles POST /api/futures/conditional
+// @Summary      Create a conditional order
+// @Description  Hold an advanced futures order locally until the mark price (or last price with price_source=CONTRACT_PRICE) of symbol crosses trigger_price, then submit it. The watched symbol may differ from the order's. Conditions sharing a group cancel each other when one triggers.
+// @Tags         futures
+// @Accept       json
+// @Produce      json
+// @Param        request  body      services.CreateConditionalOrderRequest  true  "Condition and order"
+// @Success      201      {object}  models.ConditionalOrder
+// @Header       201      {string}  Location  "GET URL of the conditional order"
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      403      {object}  handlers.ErrorResponse  "Risk limit override not allowed for this token"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/conditional [post]
+func (h *Handlers) CreateConditionalOrder(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateConditionalOrderRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	order, err := h.tradingService.CreateConditionalOrder(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, conditionalErrorStatus(err), err)
+		return
+	}
+
+	writeCreated(w, "/api/futures/conditional/"+order.ID.Hex(), false, order)
+}
+
+// ListConditionalOrders handles GET /api/futures/conditional
+// @Summary      List conditional orders
+// @Description  List conditional orders newest first, optionally only those with a status (PENDING, TRIGGERING, TRIGGERED, FAILED, CANCELED)
+// @Tags         futures
+// @Produce      json
+// @Param        status  query     string  false  "Status filter"
+// @Success      200     {array}   models.ConditionalOrder
+// @Failure      400     {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/conditional [get]
+func (h *Handlers) ListConditionalOrders(w http.ResponseWriter, r *http.Request) {
+	orders, err := h.tradingService.ListConditionalOrders(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		writeServiceError(w, conditionalErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orders)
+}
+
+// GetConditionalOrder handles GET /api/futures/conditional/{id}
+// @Summary      Get a conditional order
+// @Description  Get a conditional order with its trigger time and price and the resulting order ID
+// @Tags         futures
+// @Produce      json
+// @Param        id   path      string  true  "Conditional order ID"
+// @Success      200  {object}  models.ConditionalOrder
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/conditional/{id} [get]
+func (h *Handlers) GetConditionalOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := h.tradingService.GetConditionalOrder(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, conditionalErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// CancelConditionalOrder handles DELETE /api/futures/conditional/{id}
+// @Summary      Cancel a conditional order
+// @Description  Cancel a pending conditional order; other conditions in its group stay pending
+// @Tags         futures
+// @Produce      json
+// @Param        id   path      string  true  "Conditional order ID"
+// @Success      200  {object}  models.ConditionalOrder
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      409  {object}  handlers.ErrorResponse  "No longer pending"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/conditional/{id} [delete]
+func (h *Handlers) CancelConditionalOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := h.tradingService.CancelConditionalOrder(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, conditionalErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// conditionalErrorStatus maps conditional order errors to HTTP status codes
+func conditionalErrorStatus(err error) int {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr), errors.Is(err, services.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrRiskOverrideForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrConditionalNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrConditionalNotPending):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
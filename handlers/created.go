@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// writeCreated writes v, a resource the request created, as 201 Created with a Location header
+// naming its GET URL. A retried request that got an existing resource back (existing) is
+// answered 200, with the same Location.
+func writeCreated(w http.ResponseWriter, location string, existing bool, v interface{}) {
+	w.Header().Set("Location", location)
+	w.Header().Set("Content-Type", "application/json")
+	if !existing {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(v)
+}
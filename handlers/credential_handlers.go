@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// GetAPICredential handles GET /api/credentials/{id}
+// @Summary      Get API credentials
+// @Description  Get stored credentials by id, with the secret key redacted
+// @Tags         credentials
+// @Produce      json
+// @Param        id   path      string  true  "Credential ID"
+// @Success      200  {object}  services.CredentialResponse
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/credentials/{id} [get]
+func (h *Handlers) GetAPICredential(w http.ResponseWriter, r *http.Request) {
+	credentials, err := h.tradingService.GetAPICredential(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, credentialErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(services.NewCredentialResponse(credentials))
+}
+
+// UpdateAPICredentials handles PATCH /api/credentials/{id}
+// @Summary      Update API credentials
+// @Description  Toggle is_active or is_testnet on stored credentials; activating one deactivates all others
+// @Tags         credentials
+// @Accept       json
+// @Produce      json
+// @Param        id           path      string                                true  "Credential ID"
+// @Param        credentials  body      services.UpdateAPICredentialsRequest  true  "Fields to update"
+// @Success      200          {object}  services.CredentialResponse
+// @Failure      400          {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404          {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      409          {object}  handlers.ErrorResponse  "Mainnet credentials without a master key"
+// @Failure      500          {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/credentials/{id} [patch]
+func (h *Handlers) UpdateAPICredentials(w http.ResponseWriter, r *http.Request) {
+	var req services.UpdateAPICredentialsRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	credentials, err := h.tradingService.UpdateAPICredentials(r.Context(), mux.Vars(r)["id"], &req)
+	if err != nil {
+		writeServiceError(w, credentialErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(services.NewCredentialResponse(credentials))
+}
+
+// DeleteAPICredentials handles DELETE /api/credentials/{id}
+// @Summary      Delete API credentials
+// @Description  Delete stored credentials; the active credential cannot be deleted
+// @Tags         credentials
+// @Produce      json
+// @Param        id   path      string  true  "Credential ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      409  {object}  handlers.ErrorResponse  "Conflict"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/credentials/{id} [delete]
+func (h *Handlers) DeleteAPICredentials(w http.ResponseWriter, r *http.Request) {
+	if err := h.tradingService.DeleteAPICredentials(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeServiceError(w, credentialErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Credentials deleted successfully"})
+}
+
+// ReloadAPICredentials handles POST /api/credentials/reload
+// @Summary      Reload active API credentials
+// @Description  Re-read the active credential from the database and apply it to the live clients
+// @Tags         credentials
+// @Produce      json
+// @Success      200  {object}  services.CredentialResponse
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Router       /api/credentials/reload [post]
+func (h *Handlers) ReloadAPICredentials(w http.ResponseWriter, r *http.Request) {
+	credentials, err := h.tradingService.ReloadActiveCredentials(r.Context())
+	if err != nil {
+		writeServiceError(w, http.StatusNotFound, err)
+		return
+	}
+
+	response := services.NewCredentialResponse(credentials)
+	response.AppliedLive = true
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// credentialErrorStatus maps credential service errors to HTTP status codes
+func credentialErrorStatus(err error) int {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.Is(err, services.ErrInvalidID), errors.Is(err, services.ErrCredentialValidation), errors.As(err, &validationErr):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrCredentialNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrCredentialActive), errors.Is(err, services.ErrMasterKeyRequired):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/models"
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateDCAPlan handles POST /api/futures/dca
+// @Summary      Create a DCA plan
+// @Description  Build a position with market orders of order_notional (quote) or order_quantity (base), placed every interval (e.g. 4h) or each time the price moves price_step_percent against the last fill, until the filled notional reaches max_exposure. With take_profit_percent a reduce-only limit order closes the position at that distance from the blended entry. The first order is placed at once.
+// @Tags         futures
+// @Accept       json
+// @Produce      json
+// @Param        request  body      services.CreateDCAPlanRequest  true  "DCA plan"
+// @Success      201      {object}  models.DCAPlan
+// @Header       201      {string}  Location  "GET URL of the plan"
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/dca [post]
+func (h *Handlers) CreateDCAPlan(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateDCAPlanRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	plan, err := h.tradingService.CreateDCAPlan(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, dcaErrorStatus(err), err)
+		return
+	}
+
+	writeCreated(w, "/api/futures/dca/"+plan.ID.Hex(), false, plan)
+}
+
+// ListDCAPlans handles GET /api/futures/dca
+// @Summary      List DCA plans
+// @Description  List DCA plans newest first, optionally only those with a status (ACTIVE, PAUSED, CAPPED, COMPLETED, CANCELED)
+// @Tags         futures
+// @Produce      json
+// @Param        status  query     string  false  "Status filter"
+// @Success      200     {array}   models.DCAPlan
+// @Failure      400     {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/dca [get]
+func (h *Handlers) ListDCAPlans(w http.ResponseWriter, r *http.Request) {
+	plans, err := h.tradingService.ListDCAPlans(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		writeServiceError(w, dcaErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plans)
+}
+
+// GetDCAPlan handles GET /api/futures/dca/{id}
+// @Summary      Get a DCA plan
+// @Description  Get a DCA plan with its orders, blended entry, remaining budget, realized PnL from take profit fills and unrealized PnL at the current mark price
+// @Tags         futures
+// @Produce      json
+// @Param        id   path      string  true  "DCA plan ID"
+// @Success      200  {object}  models.DCAPlan
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/dca/{id} [get]
+func (h *Handlers) GetDCAPlan(w http.ResponseWriter, r *http.Request) {
+	plan, err := h.tradingService.GetDCAPlan(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, dcaErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+// PauseDCAPlan handles POST /api/futures/dca/{id}/pause
+// @Summary      Pause a DCA plan
+// @Description  Stop an active plan from placing orders; its take profit order is still maintained
+// @Tags         futures
+// @Produce      json
+// @Param        id   path      string  true  "DCA plan ID"
+// @Success      200  {object}  models.DCAPlan
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      409  {object}  handlers.ErrorResponse  "Plan is not active"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/dca/{id}/pause [post]
+func (h *Handlers) PauseDCAPlan(w http.ResponseWriter, r *http.Request) {
+	h.changeDCAPlan(w, r, h.tradingService.PauseDCAPlan)
+}
+
+// ResumeDCAPlan handles POST /api/futures/dca/{id}/resume
+// @Summary      Resume a DCA plan
+// @Description  Let a paused plan place orders again
+// @Tags         futures
+// @Produce      json
+// @Param        id   path      string  true  "DCA plan ID"
+// @Success      200  {object}  models.DCAPlan
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      409  {object}  handlers.ErrorResponse  "Plan is not paused"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/dca/{id}/resume [post]
+func (h *Handlers) ResumeDCAPlan(w http.ResponseWriter, r *http.Request) {
+	h.changeDCAPlan(w, r, h.tradingService.ResumeDCAPlan)
+}
+
+// CancelDCAPlan handles DELETE /api/futures/dca/{id}
+// @Summary      Cancel a DCA plan
+// @Description  End a plan and cancel its take profit order; the position it built is left open
+// @Tags         futures
+// @Produce      json
+// @Param        id   path      string  true  "DCA plan ID"
+// @Success      200  {object}  models.DCAPlan
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      409  {object}  handlers.ErrorResponse  "Plan already ended"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/dca/{id} [delete]
+func (h *Handlers) CancelDCAPlan(w http.ResponseWriter, r *http.Request) {
+	h.changeDCAPlan(w, r, h.tradingService.CancelDCAPlan)
+}
+
+func (h *Handlers) changeDCAPlan(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, id string) (*models.DCAPlan, error)) {
+	plan, err := change(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, dcaErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+// dcaErrorStatus maps DCA plan errors to HTTP status codes
+func dcaErrorStatus(err error) int {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr), errors.Is(err, services.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrDCAPlanNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrDCAPlanState):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
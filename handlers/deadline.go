@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"futures-options/logging"
+)
+
+// streamGrace is how long past its own deadline a streaming response may take to finish writing
+const streamGrace = 5 * time.Second
+
+// extendWriteDeadline lifts the server's WriteTimeout for a streaming response, which would
+// otherwise cut it off part way, to d plus streamGrace from now. Other routes keep the tight
+// server-wide timeout.
+func extendWriteDeadline(w http.ResponseWriter, r *http.Request, d time.Duration) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Now().Add(d + streamGrace)); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to extend write deadline", "error", err)
+	}
+}
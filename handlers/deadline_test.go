@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowExport streams rows for longer than the server's WriteTimeout, ending with a marker
+func slowExport(extend bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if extend {
+			extendWriteDeadline(w, r, time.Second)
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		rc := http.NewResponseController(w)
+		for i := 0; i < 10; i++ {
+			fmt.Fprintf(w, "row %d\n", i)
+			rc.Flush()
+			time.Sleep(30 * time.Millisecond)
+		}
+		io.WriteString(w, "end\n")
+	}
+}
+
+func TestExtendWriteDeadlineOutlivesWriteTimeout(t *testing.T) {
+	for _, extend := range []bool{true, false} {
+		t.Run(fmt.Sprintf("extended %v", extend), func(t *testing.T) {
+			server := httptest.NewUnstartedServer(gzipMiddleware(loggingMiddleware(slowExport(extend))))
+			server.Config.WriteTimeout = 100 * time.Millisecond
+			server.Start()
+			defer server.Close()
+
+			// The transport asks for gzip and decompresses, so the deadline is set through gzipResponseWriter
+			resp, err := http.Get(server.URL + "/api/export/orders.csv")
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			defer resp.Body.Close()
+			if !resp.Uncompressed {
+				t.Error("export was not compressed")
+			}
+			body, err := io.ReadAll(resp.Body)
+			complete := err == nil && strings.HasSuffix(string(body), "end\n")
+
+			if extend && !complete {
+				t.Errorf("export cut off after %d bytes: %v", len(body), err)
+			}
+			// Without the extension the server's WriteTimeout ends the response part way
+			if !extend && complete {
+				t.Error("export finished past the WriteTimeout without an extended deadline")
+			}
+		})
+	}
+}
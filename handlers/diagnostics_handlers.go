@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"futures-options/latency"
+	"futures-options/services"
+)
+
+// defaultLatencyWindowMinutes is the window of the recent latency statistics without ?minutes
+const defaultLatencyWindowMinutes = 5
+
+// GetClockDiagnostics handles GET /api/diagnostics/time
+// @Summary      Check clock drift against Binance
+// @Description  Fetch Binance server time on the active network (testnet or mainnet) and compare it with the local clock. offset_ms is local minus server time, taken at the middle of the round trip; applied_offset_ms is what signed requests currently subtract from the local clock. exceeds_recv_window tells whether uncorrected timestamps would be rejected with -1021 (over 1000ms ahead or over recv_window_ms behind). When the unaccounted drift exceeds BINANCE_CLOCK_DRIFT_THRESHOLD the measured offset is applied to signed REST requests and resynced is true; advice is set whenever the clock itself is off by more than the threshold.
+// @Tags         diagnostics
+// @Produce      json
+// @Success      200  {object}  services.ClockDiagnostics
+// @Failure      502  {object}  handlers.ErrorResponse  "Binance unreachable"
+// @Failure      503  {object}  handlers.ErrorResponse  "Binance circuit open"
+// @Router       /api/diagnostics/time [get]
+func (h *Handlers) GetClockDiagnostics(w http.ResponseWriter, r *http.Request) {
+	diagnostics, err := h.tradingService.CheckClock(r.Context())
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diagnostics)
+}
+
+// GetLatencyDiagnostics handles GET /api/diagnostics/latency
+// @Summary      Check latency to Binance and MongoDB
+// @Description  Runs a REST ping, a server time fetch, a signed account request, a WS-API account.status round trip and a MongoDB ping, and reports each one's latency and outcome. recent summarizes the Binance REST (by API), WS-API and MongoDB calls of the last minutes: count, errors (network failures and 5xx), error_rate and p50/p95/max latency in ms.
+// @Tags         diagnostics
+// @Produce      json
+// @Param        minutes  query     int  false  "Window of the recent statistics in minutes, 1 to 60 (default 5)"
+// @Success      200  {object}  services.LatencyDiagnostics
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Router       /api/diagnostics/latency [get]
+func (h *Handlers) GetLatencyDiagnostics(w http.ResponseWriter, r *http.Request) {
+	minutes, err := parseNonNegativeInt(r.URL.Query().Get("minutes"), "minutes")
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	if minutes == 0 {
+		minutes = defaultLatencyWindowMinutes
+	}
+	if minutes > int64(latency.Retention/time.Minute) {
+		writeServiceError(w, http.StatusBadRequest, &FieldError{Field: "minutes", Rule: services.RuleRange, Message: "must be between 1 and 60"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.tradingService.DiagnoseLatency(r.Context(), time.Duration(minutes)*time.Minute))
+}
+
+// GetNetworkDiagnostics handles GET /api/diagnostics/network
+// @Summary      Check connectivity to Binance
+// @Description  For each Binance client (futures, COIN-M, spot and options REST, the WebSocket API and the streams) report the endpoint on the active network, the proxy its connections go through (BINANCE_PROXY_URL, else the HTTPS_PROXY/NO_PROXY environment) and the result of a connection through it. Probes bypass the circuit breaker.
+// @Tags         diagnostics
+// @Produce      json
+// @Success      200  {object}  binance.NetworkDiagnostics
+// @Router       /api/diagnostics/network [get]
+func (h *Handlers) GetNetworkDiagnostics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.tradingService.ProbeNetwork(r.Context()))
+}
+
+// GetRateLimits handles GET /api/rate-limit
+// @Summary      Binance rate limit usage
+// @Description  The latest request weight and order counts Binance reported, per limit and window: source is binance_futures_rest, binance_coinm_rest, binance_spot_rest or binance_options_rest for REST response headers and binance_ws_api for WS-API rateLimits. limit comes from exchange info or the WS-API and is omitted until known; count drops to 0 once resets_at has passed. Orders that would exceed an order-count limit are held back up to 2s, then rejected with a 429.
+// @Tags         diagnostics
+// @Produce      json
+// @Success      200  {object}  services.RateLimitStatus
+// @Router       /api/rate-limit [get]
+func (h *Handlers) GetRateLimits(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.tradingService.BinanceRateLimits())
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"futures-options/binance"
+	"futures-options/services"
+)
+
+// Error codes returned in the error envelope
+const (
+	ErrCodeInvalidRequest  = "invalid_request"
+	ErrCodePayloadTooLarge = "payload_too_large"
+	ErrCodeValidation      = "validation_failed"
+	ErrCodeNotFound        = "not_found"
+	ErrCodeConflict        = "conflict"
+	ErrCodeLocked          = "locked"
+	ErrCodeForbidden       = "forbidden"
+	ErrCodeRiskLimit       = "risk_limit_exceeded"
+	ErrCodeBinance         = "binance_error"
+	ErrCodeRateLimited     = "rate_limited"
+	ErrCodeBadGateway      = "upstream_unavailable"
+	ErrCodeUnavailable     = "unavailable"
+	ErrCodeNotSupported    = "not_supported"
+	ErrCodeExchangeDown    = "exchange_unavailable"
+	ErrCodeInternal        = "internal_error"
+)
+
+// ErrorResponse is the JSON envelope for every error response
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody describes an error; BinanceCode is set when the exchange rejected the request
+type ErrorBody struct {
+	Code        string      `json:"code"`
+	Message     string      `json:"message"`
+	BinanceCode int64       `json:"binance_code,omitempty"`
+	Details     interface{} `json:"details,omitempty"`
+}
+
+// FieldError describes a problem with a single request field or query parameter
+type FieldError = services.FieldError
+
+// validatable is implemented by request structs that check their own fields
+type validatable interface {
+	Validate() error
+}
+
+// WriteError writes the error envelope with the given status
+func WriteError(w http.ResponseWriter, status int, code, message string, details interface{}) {
+	writeErrorBody(w, status, ErrorBody{Code: code, Message: message, Details: details})
+}
+
+func writeErrorBody(w http.ResponseWriter, status int, body ErrorBody) {
+	// The logging middleware reports the error, so handlers don't log it themselves
+	if rec, ok := w.(*statusRecorder); ok {
+		rec.errBody = &body
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: body})
+}
+
+// writeServiceError writes err with the given status, surfacing field errors as details
+// and the Binance error code when the exchange rejected the request. A 500 is replaced by
+// the status mapped from the Binance error, or from an account selection error, if err is one.
+// Orders rejected by the symbol policy are a 403 naming the policy, orders on a symbol not open for
+// trading a 409, orders placed while trading is paused a 423, and listings given an unknown or
+// malformed before_id cursor a 400. A timestamp rejection that survived a clock resync is a 504
+// whose details hold the measured offset and recvWindow, and an order held back by Binance's
+// order-count limit a 429 with Retry-After.
+func writeServiceError(w http.ResponseWriter, status int, err error) {
+	var policyErr *services.SymbolPolicyError
+	var symbolStatusErr *services.SymbolStatusError
+	var capErr *services.OpenOrderCapError
+	if status == http.StatusInternalServerError {
+		if mapped, ok := binanceErrorStatus(err); ok {
+			status = mapped
+		} else if mapped, ok := accountErrorStatus(err); ok {
+			status = mapped
+		} else if errors.As(err, &policyErr) {
+			status = http.StatusForbidden
+		} else if errors.As(err, &symbolStatusErr) || errors.As(err, &capErr) {
+			status = http.StatusConflict
+		} else if errors.Is(err, services.ErrTradingPaused) {
+			status = http.StatusLocked
+		} else if errors.Is(err, services.ErrInvalidCursor) {
+			status = http.StatusBadRequest
+		}
+	}
+
+	body := ErrorBody{Code: errorCodeForStatus(status), Message: err.Error()}
+
+	var validationErr *services.ValidationError
+	var fieldErr *FieldError
+	var riskErr *services.RiskLimitError
+	var throttleErr *services.OrderThrottleError
+	var timestampErr *binance.TimestampError
+	var rateLimitErr *binance.RateLimitError
+	switch {
+	case errors.As(err, &validationErr):
+		body.Code = ErrCodeValidation
+		body.Details = validationErr.Fields
+	case errors.As(err, &fieldErr):
+		body.Code = ErrCodeValidation
+		body.Details = []FieldError{*fieldErr}
+	case errors.As(err, &riskErr):
+		body.Code = ErrCodeRiskLimit
+		body.Details = riskErr
+	case errors.As(err, &throttleErr):
+		body.Details = throttleErr
+		w.Header().Set("Retry-After", strconv.Itoa(throttleErr.RetrySecs))
+	case errors.As(err, &policyErr):
+		body.Details = policyErr
+	case errors.As(err, &symbolStatusErr):
+		body.Details = symbolStatusErr
+	case errors.As(err, &capErr):
+		body.Details = capErr
+	case errors.As(err, &timestampErr):
+		body.Details = timestampErr
+	case errors.As(err, &rateLimitErr):
+		body.Details = rateLimitErr
+		w.Header().Set("Retry-After", strconv.Itoa(rateLimitErr.RetrySecs))
+	}
+	if code := binance.APIErrorCode(err); code != 0 {
+		body.Code = ErrCodeBinance
+		body.BinanceCode = code
+	}
+	if retryAfter, ok := binance.CircuitRetryAfter(err); ok {
+		body.Code = ErrCodeExchangeDown
+		body.Message = binance.ErrCircuitOpen.Error()
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+
+	writeErrorBody(w, status, body)
+}
+
+// writeValidationError writes a 400 with the offending fields as details
+func writeValidationError(w http.ResponseWriter, fields ...FieldError) {
+	message := "invalid request"
+	if len(fields) == 1 {
+		message = fields[0].Field + " " + fields[0].Message
+	}
+	WriteError(w, http.StatusBadRequest, ErrCodeValidation, message, fields)
+}
+
+// errorCodeForStatus picks the default error code for an HTTP status
+func errorCodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrCodeInvalidRequest
+	case http.StatusForbidden:
+		return ErrCodeForbidden
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusConflict:
+		return ErrCodeConflict
+	case http.StatusLocked:
+		return ErrCodeLocked
+	case http.StatusRequestEntityTooLarge:
+		return ErrCodePayloadTooLarge
+	case http.StatusTooManyRequests:
+		return ErrCodeRateLimited
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return ErrCodeBadGateway
+	case http.StatusServiceUnavailable:
+		return ErrCodeUnavailable
+	case http.StatusNotImplemented:
+		return ErrCodeNotSupported
+	default:
+		return ErrCodeInternal
+	}
+}
+
+// errTrailingData reports a request body holding more than one JSON value
+var errTrailingData = errors.New("request body must contain a single JSON value")
+
+// decodeJSONBody decodes the request body into v, rejecting unknown fields and anything after the
+// JSON value, and runs v's Validate method if it has one; on failure it writes a 400 naming the
+// offending fields (413 for a body over bodyLimitMiddleware's limit) and returns false
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err == nil {
+		var extra json.RawMessage
+		if err = decoder.Decode(&extra); errors.Is(err, io.EOF) {
+			err = nil
+		} else if err == nil {
+			err = errTrailingData
+		}
+	}
+	if err == nil {
+		if req, ok := v.(validatable); ok {
+			if err := req.Validate(); err != nil {
+				writeServiceError(w, http.StatusBadRequest, err)
+				return false
+			}
+		}
+		return true
+	}
+
+	var tooLargeErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &tooLargeErr):
+		writeBodyTooLarge(w, tooLargeErr.Limit)
+	case errors.Is(err, errTrailingData):
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error(), nil)
+	case errors.Is(err, io.EOF):
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "request body is empty", nil)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "request body contains truncated JSON", nil)
+	case errors.As(err, &syntaxErr):
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest,
+			fmt.Sprintf("request body contains malformed JSON at position %d", syntaxErr.Offset),
+			map[string]int64{"offset": syntaxErr.Offset})
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		writeValidationError(w, FieldError{
+			Field:   field,
+			Rule:    services.RuleType,
+			Message: fmt.Sprintf("must be %s, got %s (position %d)", typeErr.Type, typeErr.Value, typeErr.Offset),
+		})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for unknown fields
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		writeValidationError(w, FieldError{Field: field, Rule: services.RuleUnknown, Message: "is not a known field"})
+	default:
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "invalid request body: "+err.Error(), nil)
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"futures-options/logging"
+	"futures-options/services"
+)
+
+// ExportOrders handles GET /api/export/orders
+// @Summary      Export orders as CSV
+// @Description  Streams every stored order of the product created in the period as CSV, oldest first, with RFC3339 timestamps
+// @Tags         export
+// @Produce      text/csv
+// @Param        product  query     string  false  "futures (default) or options"
+// @Param        start    query     string  false  "Created at or after (RFC3339 or Unix ms)"
+// @Param        end      query     string  false  "Created before (RFC3339 or Unix ms)"
+// @Param        columns  query     string  false  "Comma-separated columns to include, in order (all by default)"
+// @Param        format   query     string  false  "csv (the only format)"
+// @Success      200      {string}  string  "CSV file"
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/export/orders [get]
+func (h *Handlers) ExportOrders(w http.ResponseWriter, r *http.Request) {
+	h.writeExport(w, r, h.tradingService.ExportOrders)
+}
+
+// ExportTrades handles GET /api/export/trades
+// @Summary      Export trades as CSV
+// @Description  Streams the orders of the product that traded in the period (FILLED, or with an executed quantity) as CSV, with the executed quantity and average price
+// @Tags         export
+// @Produce      text/csv
+// @Param        product  query     string  false  "futures (default) or options"
+// @Param        start    query     string  false  "Created at or after (RFC3339 or Unix ms)"
+// @Param        end      query     string  false  "Created before (RFC3339 or Unix ms)"
+// @Param        columns  query     string  false  "Comma-separated columns to include, in order (all by default)"
+// @Param        format   query     string  false  "csv (the only format)"
+// @Success      200      {string}  string  "CSV file"
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/export/trades [get]
+func (h *Handlers) ExportTrades(w http.ResponseWriter, r *http.Request) {
+	h.writeExport(w, r, h.tradingService.ExportTrades)
+}
+
+// writeExport validates the export query and streams the CSV. Once the header row is sent the
+// status can no longer change, so a failure part way is logged and ends the response early.
+func (h *Handlers) writeExport(w http.ResponseWriter, r *http.Request, prepare func(*services.ExportQuery) (*services.Export, error)) {
+	q := r.URL.Query()
+	query := &services.ExportQuery{Product: q.Get("product"), AccountID: services.AccountFromContext(r.Context())}
+
+	var err error
+	if query.Start, err = parseTimeParam(q.Get("start"), "start"); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	if query.End, err = parseTimeParam(q.Get("end"), "end"); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	if format := strings.ToLower(q.Get("format")); format != "" && format != "csv" {
+		writeServiceError(w, http.StatusBadRequest, &FieldError{Field: "format", Rule: services.RuleEnum, Message: "must be csv"})
+		return
+	}
+	if columns := q.Get("columns"); columns != "" {
+		query.Columns = strings.Split(columns, ",")
+	}
+
+	export, err := prepare(query)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			status = http.StatusBadRequest
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	timeout := h.tradingService.ExportTimeout()
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	extendWriteDeadline(w, r, timeout)
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+export.Filename+`"`)
+	if err := export.WriteCSV(ctx, w); err != nil {
+		logging.FromContext(r.Context()).Error("export failed", "file", export.Filename, "error", err)
+	}
+}
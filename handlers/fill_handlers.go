@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"futures-options/models"
+	"futures-options/services"
+)
+
+// GetFills handles GET /api/futures/fills
+// @Summary      Recent fills
+// @Description  List the most recent order fills, newest fill time first: futures trades reported by the user data stream and, with product=all or options, what options orders executed when placed. Each fill carries the parent order's Binance and client order IDs. Page with next_cursor.
+// @Tags         futures
+// @Produce      json
+// @Param        symbol     query     string  false  "Filter by symbol (e.g., BTCUSDT)"
+// @Param        product    query     string  false  "futures (default), options or all"
+// @Param        limit      query     int     false  "Page size (default 100, max 1000)"
+// @Param        before_id  query     string  false  "Cursor: next_cursor from the previous page"
+// @Success      200        {object}  services.FillPage
+// @Failure      400        {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500        {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/fills [get]
+func (h *Handlers) GetFills(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := &services.FillQuery{Symbol: q.Get("symbol"), BeforeID: q.Get("before_id")}
+
+	switch strings.ToLower(q.Get("product")) {
+	case "", models.FillProductFutures:
+		query.Products = []string{models.FillProductFutures}
+	case models.FillProductOptions:
+		query.Products = []string{models.FillProductOptions}
+	case "all":
+	default:
+		writeServiceError(w, http.StatusBadRequest, &FieldError{Field: "product", Rule: services.RuleEnum, Message: "must be futures, options or all"})
+		return
+	}
+
+	var err error
+	if query.Limit, err = parseNonNegativeInt(q.Get("limit"), "limit"); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := h.tradingService.GetFills(r.Context(), query)
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateGridStrategy handles POST /api/strategies/grid
+// @Summary      Start a grid strategy
+// @Description  Split lower_price..upper_price (which must contain the current price) into grid_count grids and keep a limit order in each: a buy at its lower price below the market, a sell at its upper price above it. When one fills the opposite order is placed one level away. LONG and SHORT strategies open the position their grids will unwind first; NEUTRAL starts flat.
+// @Tags         strategies
+// @Accept       json
+// @Produce      json
+// @Param        request  body      services.CreateGridStrategyRequest  true  "Grid strategy"
+// @Success      201      {object}  models.GridStrategy
+// @Header       201      {string}  Location  "GET URL of the strategy"
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      409      {object}  handlers.ErrorResponse  "The grid's orders would exceed the symbol's open order cap"
+// @Failure      422      {object}  handlers.ErrorResponse  "Risk limit exceeded"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/strategies/grid [post]
+func (h *Handlers) CreateGridStrategy(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateGridStrategyRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	strategy, err := h.tradingService.CreateGridStrategy(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, gridErrorStatus(err), err)
+		return
+	}
+
+	writeCreated(w, "/api/strategies/grid/"+strategy.ID.Hex(), false, strategy)
+}
+
+// ListGridStrategies handles GET /api/strategies/grid
+// @Summary      List grid strategies
+// @Description  List grid strategies newest first, optionally only those with a status (RUNNING, STOPPED, FAILED)
+// @Tags         strategies
+// @Produce      json
+// @Param        status  query     string  false  "Status filter"
+// @Success      200     {array}   models.GridStrategy
+// @Failure      400     {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/strategies/grid [get]
+func (h *Handlers) ListGridStrategies(w http.ResponseWriter, r *http.Request) {
+	strategies, err := h.tradingService.ListGridStrategies(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		writeServiceError(w, gridErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(strategies)
+}
+
+// GetGridStrategy handles GET /api/strategies/grid/{id}
+// @Summary      Get a grid strategy
+// @Description  Get a grid strategy's status, grids with their open orders and profit, fill count, position and cumulative profit
+// @Tags         strategies
+// @Produce      json
+// @Param        id   path      string  true  "Grid strategy ID"
+// @Success      200  {object}  models.GridStrategy
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/strategies/grid/{id} [get]
+func (h *Handlers) GetGridStrategy(w http.ResponseWriter, r *http.Request) {
+	strategy, err := h.tradingService.GetGridStrategy(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, gridErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(strategy)
+}
+
+// StopGridStrategy handles POST /api/strategies/grid/{id}/stop
+// @Summary      Stop a grid strategy
+// @Description  Cancel the strategy's remaining orders and, with flatten=true, close its accumulated position with a reduce-only market order
+// @Tags         strategies
+// @Produce      json
+// @Param        id       path      string  true   "Grid strategy ID"
+// @Param        flatten  query     bool    false  "Close the accumulated position"
+// @Success      200      {object}  models.GridStrategy
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404      {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      409      {object}  handlers.ErrorResponse  "Not running"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/strategies/grid/{id}/stop [post]
+func (h *Handlers) StopGridStrategy(w http.ResponseWriter, r *http.Request) {
+	flatten, err := parseBoolParam(r.URL.Query().Get("flatten"), "flatten")
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	strategy, err := h.tradingService.StopGridStrategy(r.Context(), mux.Vars(r)["id"], flatten)
+	if err != nil {
+		writeServiceError(w, gridErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(strategy)
+}
+
+// gridErrorStatus maps grid strategy errors, including rejected orders, to HTTP status codes
+func gridErrorStatus(err error) int {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr), errors.Is(err, services.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrGridNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrGridNotRunning):
+		return http.StatusConflict
+	default:
+		return riskErrorStatus(err)
+	}
+}
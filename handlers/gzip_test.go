@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"futures-options/models"
+)
+
+// serveGzip runs handler behind gzipMiddleware for a GET with the given Accept-Encoding
+func serveGzip(handler http.HandlerFunc, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rec := httptest.NewRecorder()
+	gzipMiddleware(handler).ServeHTTP(rec, req)
+	return rec
+}
+
+func gunzip(t *testing.T, body []byte) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read gzip body: %v", err)
+	}
+	return plain
+}
+
+func TestGzipMiddlewareCompressesKlines(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	klines := make([]models.Kline, 200)
+	for i := range klines {
+		open := start.Add(time.Duration(i) * time.Minute)
+		klines[i] = models.Kline{Symbol: "BTCUSDT", Interval: "1m", OpenTime: open, CloseTime: open.Add(time.Minute - time.Millisecond),
+			Open: 42000 + float64(i), High: 42010 + float64(i), Low: 41990 + float64(i), Close: 42005 + float64(i), Volume: 12.5, Trades: 340}
+	}
+	want, err := json.Marshal(klines)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	rec := serveGzip(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(klines)
+	}, "gzip, deflate, br")
+
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if rec.Body.Len() >= len(want) {
+		t.Errorf("compressed body is %d bytes, the JSON %d", rec.Body.Len(), len(want))
+	}
+	if got := bytes.TrimSuffix(gunzip(t, rec.Body.Bytes()), []byte("\n")); !bytes.Equal(got, want) {
+		t.Errorf("decompressed body differs from the encoded klines")
+	}
+}
+
+func TestGzipMiddlewarePassesThrough(t *testing.T) {
+	large := strings.Repeat("a,b,c\n", gzipMinSize)
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		// contentEncoding is set by the handler itself
+		contentEncoding string
+		status          int
+		body            string
+	}{
+		{name: "small body", acceptEncoding: "gzip", contentType: "application/json", status: http.StatusCreated, body: `{"id":1}`},
+		{name: "small body without a content type", acceptEncoding: "gzip", status: http.StatusOK, body: "<html><body>ok</body></html>"},
+		{name: "client without gzip", contentType: "text/csv", status: http.StatusOK, body: large},
+		{name: "gzip refused by quality", acceptEncoding: "gzip;q=0, identity", contentType: "text/csv", status: http.StatusOK, body: large},
+		{name: "event stream", acceptEncoding: "gzip", contentType: "text/event-stream", status: http.StatusOK, body: strings.Repeat("data: tick\n\n", 200)},
+		{name: "already encoded", acceptEncoding: "gzip", contentType: "application/octet-stream", contentEncoding: "br", status: http.StatusOK, body: large},
+		{name: "no content", acceptEncoding: "gzip", status: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveGzip(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				if tt.contentEncoding != "" {
+					w.Header().Set("Content-Encoding", tt.contentEncoding)
+				}
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}, tt.acceptEncoding)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got == "gzip" {
+				t.Errorf("response was compressed")
+			}
+			if got := rec.Body.String(); got != tt.body {
+				t.Errorf("body = %.40q, want %.40q", got, tt.body)
+			}
+			if tt.contentType == "" && tt.body != "" && rec.Header().Get("Content-Type") == "" {
+				t.Error("content type of a held back body was not sniffed")
+			}
+			if !strings.Contains(rec.Header().Get("Vary"), "Accept-Encoding") {
+				t.Error("Vary: Accept-Encoding missing")
+			}
+		})
+	}
+}
+
+func TestGzipMiddlewareFlushCommitsExport(t *testing.T) {
+	var rows bytes.Buffer
+	rec := httptest.NewRecorder()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		header := "time,symbol,side,quantity,price\n"
+		io.WriteString(w, header)
+		rows.WriteString(header)
+		// The first flush decides before gzipMinSize bytes were written
+		http.NewResponseController(w).Flush()
+		if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+			t.Errorf("Content-Encoding after the first flush = %q, want gzip", got)
+		}
+		if !rec.Flushed {
+			t.Error("flush did not reach the connection")
+		}
+		for i := 0; i < 100; i++ {
+			row := fmt.Sprintf("2026-01-01T00:%02d:00Z,BTCUSDT,BUY,0.01,%d\n", i%60, 42000+i)
+			io.WriteString(w, row)
+			rows.WriteString(row)
+			if i%25 == 0 {
+				http.NewResponseController(w).Flush()
+			}
+		}
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/export/orders.csv", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	gzipMiddleware(http.HandlerFunc(handler)).ServeHTTP(rec, req)
+
+	if got := gunzip(t, rec.Body.Bytes()); !bytes.Equal(got, rows.Bytes()) {
+		t.Errorf("decompressed export differs from the rows written")
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"GZIP", true},
+		{"deflate, gzip;q=0.5", true},
+		{"gzip; q=0", false},
+		{"gzip;q=0.0", false},
+		{"*", true},
+		{"*;q=0", false},
+		{"gzip;q=0, *", false},
+		{"*, gzip;q=0", false},
+		{"br, identity", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", tt.header)
+		if got := acceptsGzip(r); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
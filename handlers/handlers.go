@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
-	"log"
+	"errors"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
 
+	"futures-options/logging"
 	"futures-options/services"
 
 	"github.com/gorilla/mux"
@@ -13,41 +19,52 @@ import (
 )
 
 type Handlers struct {
-	tradingService *services.TradingService
+	tradingService    *services.TradingService
+	authService       *services.AuthService
+	readLimiter       *RateLimiter
+	writeLimiter      *RateLimiter
+	maxBodyBytes      int64
+	maxBatchBodyBytes int64
 }
 
-func NewHandlers(tradingService *services.TradingService) *Handlers {
+func NewHandlers(tradingService *services.TradingService, authService *services.AuthService) *Handlers {
 	return &Handlers{
 		tradingService: tradingService,
+		authService:    authService,
 	}
 }
 
 // CreateFuturesOrder handles POST /api/futures/order
 // @Summary      Create a futures order
-// @Description  Create a new futures trading order on Binance
+// @Description  Create a new futures trading order on Binance. Give quantity, or quote_quantity to size the order by notional at its price or the cached mark price.
 // @Tags         futures
 // @Accept       json
 // @Produce      json
 // @Param        order  body      services.CreateFuturesOrderRequest  true  "Futures Order Request"
-// @Success      200    {object}  models.FuturesOrder
-// @Failure      400    {string}  string  "Bad Request"
-// @Failure      500    {string}  string  "Internal Server Error"
+// @Success      201    {object}  models.FuturesOrder
+// @Header       201    {string}  Location  "GET URL of the order"
+// @Failure      400    {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      403    {object}  handlers.ErrorResponse  "Risk limit override not allowed for this token"
+// @Failure      409    {object}  handlers.ErrorResponse  "Symbol not open for trading"
+// @Failure      422    {object}  handlers.ErrorResponse  "Order would exceed a risk limit"
+// @Failure      423    {object}  handlers.ErrorResponse  "Trading is paused"
+// @Failure      429    {object}  handlers.ErrorResponse  "Too many new orders for the symbol"
+// @Failure      500    {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501    {object}  handlers.ErrorResponse  "COIN-M is not available in paper trading mode"
 // @Router       /api/futures/order [post]
 func (h *Handlers) CreateFuturesOrder(w http.ResponseWriter, r *http.Request) {
 	var req services.CreateFuturesOrderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 
 	order, err := h.tradingService.CreateFuturesOrder(r.Context(), &req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, riskErrorStatus(err), err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(order)
+	writeCreated(w, "/api/futures/orders/"+order.ID.Hex(), false, order)
 }
 
 // CreateOptionsOrder handles POST /api/options/order
@@ -57,42 +74,63 @@ func (h *Handlers) CreateFuturesOrder(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param        order  body      services.CreateOptionsOrderRequest  true  "Options Order Request"
-// @Success      200    {object}  models.OptionsOrder
-// @Failure      400    {string}  string  "Bad Request"
-// @Failure      500    {string}  string  "Internal Server Error"
+// @Success      201    {object}  models.OptionsOrder
+// @Header       201    {string}  Location  "GET URL of the order"
+// @Failure      400    {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500    {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501    {object}  handlers.ErrorResponse  "Paper trading mode"
 // @Router       /api/options/order [post]
 func (h *Handlers) CreateOptionsOrder(w http.ResponseWriter, r *http.Request) {
 	var req services.CreateOptionsOrderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 
 	order, err := h.tradingService.CreateOptionsOrder(r.Context(), &req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, paperErrorStatus(err), err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(order)
+	writeCreated(w, "/api/options/orders/"+order.ID.Hex(), false, order)
 }
 
 // GetFuturesOrders handles GET /api/futures/orders
 // @Summary      Get futures orders
-// @Description  Retrieve all futures orders, optionally filtered by symbol
+// @Description  Retrieve a page of futures orders with optional filters, sorted by creation time
 // @Tags         futures
 // @Produce      json
-// @Param        symbol  query     string  false  "Filter by symbol (e.g., BTCUSDT)"
-// @Success      200     {array}   models.FuturesOrder
-// @Failure      500     {string}  string  "Internal Server Error"
+// @Param        symbol      query     string  false  "Filter by symbol (e.g., BTCUSDT)"
+// @Param        status      query     string  false  "Filter by order status (e.g., NEW, FILLED)"
+// @Param        side        query     string  false  "Filter by side (BUY or SELL)"
+// @Param        start_time  query     string  false  "Created at or after (RFC3339 or Unix ms)"
+// @Param        end_time    query     string  false  "Created at or before (RFC3339 or Unix ms)"
+// @Param        sort        query     string  false  "Sort by created_at: asc or desc (default desc)"
+// @Param        limit       query     int     false  "Page size (default 100, max 1000)"
+// @Param        offset      query     int     false  "Number of orders to skip (ignored when before_id is set)"
+// @Param        before_id   query     string  false  "Cursor: next_cursor from the previous page"
+// @Param        include_raw query     bool    false  "Include the raw Binance response stored with each order"
+// @Param        include_journal query bool  false  "Include each order's latest journal entry"
+// @Param        partially_filled query bool false "Only orders that filled part of their quantity: PARTIALLY_FILLED, or canceled or expired after a partial fill"
+// @Param        source      query     string  false  "Filter by what placed the order: manual, template, grid, dca, conditional, scheduled, spread or external"
+// @Success      200         {object}  services.FuturesOrderPage
+// @Failure      400         {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500         {object}  handlers.ErrorResponse  "Internal Server Error"
 // @Router       /api/futures/orders [get]
 func (h *Handlers) GetFuturesOrders(w http.ResponseWriter, r *http.Request) {
-	symbol := r.URL.Query().Get("symbol")
+	query, err := parseOrderQuery(r)
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	if query.Source, err = parseOrderSource(r.URL.Query().Get("source")); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
 
-	orders, err := h.tradingService.GetFuturesOrders(r.Context(), symbol)
+	orders, err := h.tradingService.GetFuturesOrders(r.Context(), query)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -100,21 +138,93 @@ func (h *Handlers) GetFuturesOrders(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(orders)
 }
 
+// SearchFuturesOrders handles GET /api/futures/orders/search
+// @Summary      Search futures orders
+// @Description  Find stored futures orders by client order ID (exact or prefix), Binance order ID, strategy ID or free text, with the usual listing filters. Each hit reports which lookup it matched.
+// @Tags         futures
+// @Produce      json
+// @Param        client_order_id   query     string  false  "Client order ID or its prefix (e.g., grid-65f0)"
+// @Param        binance_order_id  query     int     false  "Binance order ID"
+// @Param        strategy_id       query     string  false  "ID of the grid, DCA, conditional or scheduled order, or calendar spread, that placed the order"
+// @Param        q                 query     string  false  "Free text: a Binance order ID, client order ID prefix or strategy ID"
+// @Param        source            query     string  false  "Filter by what placed the order: manual, template, grid, dca, conditional, scheduled, spread or external"
+// @Param        symbol            query     string  false  "Filter by symbol (e.g., BTCUSDT)"
+// @Param        status            query     string  false  "Filter by order status (e.g., NEW, FILLED)"
+// @Param        side              query     string  false  "Filter by side (BUY or SELL)"
+// @Param        start_time        query     string  false  "Created at or after (RFC3339 or Unix ms)"
+// @Param        end_time          query     string  false  "Created at or before (RFC3339 or Unix ms)"
+// @Param        sort              query     string  false  "Sort by created_at: asc or desc (default desc)"
+// @Param        limit             query     int     false  "Page size (default 100, max 1000)"
+// @Param        offset            query     int     false  "Number of orders to skip (ignored when before_id is set)"
+// @Param        before_id         query     string  false  "Cursor: next_cursor from the previous page"
+// @Success      200               {object}  services.FuturesOrderSearchPage
+// @Failure      400               {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500               {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/orders/search [get]
+func (h *Handlers) SearchFuturesOrders(w http.ResponseWriter, r *http.Request) {
+	query, err := parseOrderQuery(r)
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	q := r.URL.Query()
+	query.ClientOrderIDPrefix = q.Get("client_order_id")
+	query.StrategyID = q.Get("strategy_id")
+	query.Text = q.Get("q")
+	if query.Source, err = parseOrderSource(q.Get("source")); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	if query.BinanceOrderID, err = parseNonNegativeInt(q.Get("binance_order_id"), "binance_order_id"); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := h.tradingService.SearchFuturesOrders(r.Context(), query)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			status = http.StatusBadRequest
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
 // GetOptionsOrders handles GET /api/options/orders
 // @Summary      Get options orders
-// @Description  Retrieve all options orders, optionally filtered by symbol
+// @Description  Retrieve a page of options orders with optional filters, sorted by creation time
 // @Tags         options
 // @Produce      json
-// @Param        symbol  query     string  false  "Filter by symbol"
-// @Success      200     {array}   models.OptionsOrder
-// @Failure      500     {string}  string  "Internal Server Error"
+// @Param        symbol      query     string  false  "Filter by symbol"
+// @Param        status      query     string  false  "Filter by order status"
+// @Param        side        query     string  false  "Filter by side (BUY or SELL)"
+// @Param        start_time  query     string  false  "Created at or after (RFC3339 or Unix ms)"
+// @Param        end_time    query     string  false  "Created at or before (RFC3339 or Unix ms)"
+// @Param        sort        query     string  false  "Sort by created_at: asc or desc (default desc)"
+// @Param        limit       query     int     false  "Page size (default 100, max 1000)"
+// @Param        offset      query     int     false  "Number of orders to skip (ignored when before_id is set)"
+// @Param        before_id   query     string  false  "Cursor: next_cursor from the previous page"
+// @Param        include_raw query     bool    false  "Include the raw Binance response stored with each order"
+// @Param        include_journal query bool  false  "Include each order's latest journal entry"
+// @Success      200         {object}  services.OptionsOrderPage
+// @Failure      400         {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500         {object}  handlers.ErrorResponse  "Internal Server Error"
 // @Router       /api/options/orders [get]
 func (h *Handlers) GetOptionsOrders(w http.ResponseWriter, r *http.Request) {
-	symbol := r.URL.Query().Get("symbol")
+	query, err := parseOrderQuery(r)
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
 
-	orders, err := h.tradingService.GetOptionsOrders(r.Context(), symbol)
+	orders, err := h.tradingService.GetOptionsOrders(r.Context(), query)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -124,19 +234,19 @@ func (h *Handlers) GetOptionsOrders(w http.ResponseWriter, r *http.Request) {
 
 // GetPositions handles GET /api/positions
 // @Summary      Get positions
-// @Description  Retrieve all positions, optionally filtered by type (FUTURES or OPTIONS)
+// @Description  Retrieve all positions, optionally filtered by type (FUTURES or OPTIONS); futures positions carry their live margin ratio
 // @Tags         positions
 // @Produce      json
 // @Param        type  query     string  false  "Filter by position type (FUTURES or OPTIONS)"
 // @Success      200   {array}   models.Position
-// @Failure      500   {string}  string  "Internal Server Error"
+// @Failure      500   {object}  handlers.ErrorResponse  "Internal Server Error"
 // @Router       /api/positions [get]
 func (h *Handlers) GetPositions(w http.ResponseWriter, r *http.Request) {
 	positionType := r.URL.Query().Get("type")
 
 	positions, err := h.tradingService.GetPositions(r.Context(), positionType)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -146,49 +256,93 @@ func (h *Handlers) GetPositions(w http.ResponseWriter, r *http.Request) {
 
 // SyncPositions handles POST /api/positions/sync
 // @Summary      Sync positions from Binance
-// @Description  Sync current positions from Binance to local database
+// @Description  Replace the stored positions of a futures market with the open positions on Binance; closed positions are removed. COIN-M quantities are contracts; their notional and unrealized PnL are in the margin coin.
 // @Tags         positions
 // @Produce      json
-// @Success      200   {object}  map[string]string
-// @Failure      500   {string}  string  "Internal Server Error"
+// @Param        market  query     string  false  "Futures market: usdm (default) or coinm"
+// @Success      200   {object}  services.PositionSyncSummary
+// @Failure      400   {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500   {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501   {object}  handlers.ErrorResponse  "COIN-M is not available in paper trading mode"
 // @Router       /api/positions/sync [post]
 func (h *Handlers) SyncPositions(w http.ResponseWriter, r *http.Request) {
-	err := h.tradingService.SyncPositionsFromBinance(r.Context())
+	market, err := parseMarketParam(r.URL.Query().Get("market"))
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	summary, err := h.tradingService.SyncPositionsFromBinance(r.Context(), market)
+	if err != nil {
+		writeServiceError(w, paperErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// GetFuturesPosition handles GET /api/futures/positions/{symbol}
+// @Summary      Get a futures position
+// @Description  Merge a USDⓈ-M symbol's stored position (cached, with updated_at) with its live position risk, margin ratio,
+// @Description  open reduce-only and close-position orders and ADL quantile read from Binance at live_at.
+// @Description  Enrichments that fail are listed in errors; the live position itself is required.
+// @Tags         positions
+// @Produce      json
+// @Param        symbol  path      string  true  "Symbol"
+// @Success      200     {object}  services.PositionDetail
+// @Failure      404     {object}  handlers.ErrorResponse  "No open position"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/positions/{symbol} [get]
+func (h *Handlers) GetFuturesPosition(w http.ResponseWriter, r *http.Request) {
+	detail, err := h.tradingService.GetPositionDetail(r.Context(), mux.Vars(r)["symbol"])
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrPositionNotFound) {
+			status = http.StatusNotFound
+		}
+		writeServiceError(w, status, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "Positions synced successfully"})
+	json.NewEncoder(w).Encode(detail)
 }
 
 // SaveAPICredentials handles POST /api/credentials
 // @Summary      Save API credentials
-// @Description  Save Binance API credentials to the database
+// @Description  Validate Binance API credentials (unless skip_validation) and save them, updating the stored credentials when the API key is already saved; operation reports which happened, and the status is 201 for a new credential and 200 for an update. Active credentials are applied to the live clients.
 // @Tags         credentials
 // @Accept       json
 // @Produce      json
 // @Param        credentials  body      services.SaveAPICredentialsRequest  true  "API Credentials"
-// @Success      200          {object}  models.APICredentials
-// @Failure      400          {string}  string  "Bad Request"
-// @Failure      500          {string}  string  "Internal Server Error"
+// @Success      201          {object}  services.CredentialResponse
+// @Success      200          {object}  services.CredentialResponse
+// @Header       201          {string}  Location  "GET URL of the credential"
+// @Failure      400          {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      409          {object}  handlers.ErrorResponse  "Mainnet credentials without a master key"
+// @Failure      500          {object}  handlers.ErrorResponse  "Internal Server Error"
 // @Router       /api/credentials [post]
 func (h *Handlers) SaveAPICredentials(w http.ResponseWriter, r *http.Request) {
 	var req services.SaveAPICredentialsRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 
-	credentials, err := h.tradingService.SaveAPICredentials(r.Context(), &req)
+	saved, err := h.tradingService.SaveAPICredentials(r.Context(), &req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, credentialErrorStatus(err), err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(credentials)
+	response := services.NewCredentialResponse(saved.Credentials)
+	response.AppliedLive = saved.AppliedLive
+	response.Operation = services.CredentialUpdated
+	if saved.Created {
+		response.Operation = services.CredentialCreated
+	}
+
+	writeCreated(w, "/api/credentials/"+response.ID, !saved.Created, response)
 }
 
 // GetAPICredentials handles GET /api/credentials
@@ -197,60 +351,154 @@ func (h *Handlers) SaveAPICredentials(w http.ResponseWriter, r *http.Request) {
 // @Tags         credentials
 // @Produce      json
 // @Param        active_only  query     bool    false  "Filter to active credentials only"
-// @Success      200          {array}   models.APICredentials
-// @Failure      500          {string}  string  "Internal Server Error"
+// @Success      200          {array}   services.CredentialResponse
+// @Failure      500          {object}  handlers.ErrorResponse  "Internal Server Error"
 // @Router       /api/credentials [get]
 func (h *Handlers) GetAPICredentials(w http.ResponseWriter, r *http.Request) {
 	activeOnly := r.URL.Query().Get("active_only") == "true"
 
 	credentials, err := h.tradingService.GetAPICredentials(r.Context(), activeOnly)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	response := make([]*services.CredentialResponse, 0, len(credentials))
+	for _, c := range credentials {
+		response = append(response, services.NewCredentialResponse(c))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(credentials)
+	json.NewEncoder(w).Encode(response)
 }
 
 // HealthCheck handles GET /health
 // @Summary      Health check
-// @Description  Check if the API server is running
+// @Description  Ping MongoDB and the Binance futures API and report credential and user data stream state.
+// @Description  Returns 503 with the failing components when a critical dependency is down; quick=true skips external calls.
 // @Tags         health
 // @Produce      json
-// @Success      200  {object}  map[string]interface{}
+// @Param        quick  query     bool  false  "Liveness mode: skip the MongoDB and Binance checks"
+// @Success      200    {object}  services.HealthReport
+// @Failure      503    {object}  services.HealthReport
 // @Router       /health [get]
 func (h *Handlers) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	quick, err := parseBoolParam(r.URL.Query().Get("quick"), "quick")
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report := h.tradingService.CheckHealth(r.Context(), quick)
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":    "healthy",
-		"timestamp": time.Now(),
-	})
+	if report.Status != services.HealthStatusHealthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
 }
 
 func SetupRoutes(h *Handlers) *mux.Router {
 	router := mux.NewRouter()
 
-	// Request logging middleware
+	// Request ID and logging middleware (the ID must be in the context before logging)
+	router.Use(requestIDMiddleware)
+	router.Use(gzipMiddleware)
 	router.Use(loggingMiddleware)
+	router.Use(h.bodyLimitMiddleware)
+	if h.tradingService.Paper() {
+		router.Use(paperMiddleware)
+	}
 
 	// Swagger documentation
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
 	// Health check
 	router.HandleFunc("/health", h.HealthCheck).Methods("GET")
+	router.HandleFunc("/metrics", h.Metrics).Methods("GET")
 
-	// API routes
+	// API routes (token required)
 	api := router.PathPrefix("/api").Subrouter()
+	api.Use(h.authMiddleware)
+	api.Use(h.rateLimitMiddleware)
+	api.Use(h.accountMiddleware)
+	api.Use(h.recvWindowMiddleware)
+	api.Use(stringNumbersMiddleware)
 
 	// Futures routes
 	futures := api.PathPrefix("/futures").Subrouter()
 	futures.HandleFunc("/order", h.CreateFuturesOrder).Methods("POST")
 	futures.HandleFunc("/orders", h.GetFuturesOrders).Methods("GET")
+	futures.HandleFunc("/orders/search", h.SearchFuturesOrders).Methods("GET")
+	futures.HandleFunc("/orders/{id}", h.GetFuturesOrder).Methods("GET")
+	futures.HandleFunc("/orders/reconcile", h.ReconcileFuturesOrders).Methods("POST")
+	futures.HandleFunc("/orders/cleanup", h.CleanupOrphanOrders).Methods("POST")
+	futures.HandleFunc("/fills", h.GetFills).Methods("GET")
+	futures.HandleFunc("/open-order-count", h.GetOpenOrderCount).Methods("GET")
+	futures.HandleFunc("/spread", h.CreateCalendarSpread).Methods("POST")
+	futures.HandleFunc("/spread/{id}", h.GetCalendarSpread).Methods("GET")
+	futures.HandleFunc("/spread/{id}/unwind", h.UnwindCalendarSpread).Methods("POST")
+	futures.HandleFunc("/positions/{symbol}", h.GetFuturesPosition).Methods("GET")
+	futures.HandleFunc("/klines", h.GetKlines).Methods("GET")
+	futures.HandleFunc("/calculate/liquidation", h.CalculateLiquidation).Methods("POST")
+	futures.HandleFunc("/calculate/position-size", h.CalculatePositionSize).Methods("POST")
+	futures.HandleFunc("/conditional", h.CreateConditionalOrder).Methods("POST")
+	futures.HandleFunc("/conditional", h.ListConditionalOrders).Methods("GET")
+	futures.HandleFunc("/conditional/{id}", h.GetConditionalOrder).Methods("GET")
+	futures.HandleFunc("/conditional/{id}", h.CancelConditionalOrder).Methods("DELETE")
+	futures.HandleFunc("/scheduled", h.CreateScheduledOrder).Methods("POST")
+	futures.HandleFunc("/scheduled", h.ListScheduledOrders).Methods("GET")
+	futures.HandleFunc("/scheduled/{id}", h.GetScheduledOrder).Methods("GET")
+	futures.HandleFunc("/scheduled/{id}", h.CancelScheduledOrder).Methods("DELETE")
+	futures.HandleFunc("/dca", h.CreateDCAPlan).Methods("POST")
+	futures.HandleFunc("/dca", h.ListDCAPlans).Methods("GET")
+	futures.HandleFunc("/dca/{id}", h.GetDCAPlan).Methods("GET")
+	futures.HandleFunc("/dca/{id}", h.CancelDCAPlan).Methods("DELETE")
+	futures.HandleFunc("/dca/{id}/pause", h.PauseDCAPlan).Methods("POST")
+	futures.HandleFunc("/dca/{id}/resume", h.ResumeDCAPlan).Methods("POST")
+
+	// Strategy routes
+	strategies := api.PathPrefix("/strategies").Subrouter()
+	strategies.HandleFunc("/grid", h.CreateGridStrategy).Methods("POST")
+	strategies.HandleFunc("/grid", h.ListGridStrategies).Methods("GET")
+	strategies.HandleFunc("/grid/{id}", h.GetGridStrategy).Methods("GET")
+	strategies.HandleFunc("/grid/{id}/stop", h.StopGridStrategy).Methods("POST")
+	strategies.HandleFunc("/backtest", h.BacktestStrategy).Methods("POST")
 
 	// Options routes
 	options := api.PathPrefix("/options").Subrouter()
 	options.HandleFunc("/orders", h.GetOptionsOrders).Methods("GET")
+	options.HandleFunc("/orders/{id}", h.GetOptionsOrder).Methods("GET")
+
+	// Spot routes
+	spot := api.PathPrefix("/spot").Subrouter()
+	spot.HandleFunc("/order", h.CreateSpotOrder).Methods("POST")
+	spot.HandleFunc("/orders", h.GetSpotOrders).Methods("GET")
+	spot.HandleFunc("/orders/{id}", h.GetSpotOrder).Methods("GET")
+	spot.HandleFunc("/balances", h.GetSpotBalances).Methods("GET")
+
+	// Wallet transfer routes
+	api.HandleFunc("/account/transfer", h.TransferFunds).Methods("POST")
+	api.HandleFunc("/account/transfers", h.ListTransfers).Methods("GET")
+
+	// Admin routes
+	api.HandleFunc("/admin/cache/exchange-info", h.GetExchangeInfoCache).Methods("GET")
+	api.HandleFunc("/admin/cache/exchange-info/refresh", h.RefreshExchangeInfoCache).Methods("POST")
+	api.HandleFunc("/admin/reconcile", h.ReconcileWithBinance).Methods("POST")
+	api.HandleFunc("/admin/reconcile/reports", h.ListReconciliationReports).Methods("GET")
+	api.HandleFunc("/admin/user-stream/restart", h.RestartUserDataStream).Methods("POST")
+	api.HandleFunc("/admin/jobs", h.ListJobs).Methods("GET")
+	api.HandleFunc("/admin/jobs/{name}/run", h.RunJob).Methods("POST")
+	api.HandleFunc("/admin/retention", h.GetRetention).Methods("GET")
+	api.HandleFunc("/admin/trading-pause", h.GetTradingPause).Methods("GET")
+	api.HandleFunc("/admin/trading-pause", h.PauseTrading).Methods("POST")
+	api.HandleFunc("/admin/trading-pause", h.ResumeTrading).Methods("DELETE")
+
+	// Diagnostics routes
+	api.HandleFunc("/diagnostics/time", h.GetClockDiagnostics).Methods("GET")
+	api.HandleFunc("/diagnostics/network", h.GetNetworkDiagnostics).Methods("GET")
+	api.HandleFunc("/diagnostics/latency", h.GetLatencyDiagnostics).Methods("GET")
+	api.HandleFunc("/rate-limit", h.GetRateLimits).Methods("GET")
 
 	// Positions routes
 	api.HandleFunc("/positions", h.GetPositions).Methods("GET")
@@ -259,12 +507,18 @@ func SetupRoutes(h *Handlers) *mux.Router {
 	// API Credentials routes
 	api.HandleFunc("/credentials", h.SaveAPICredentials).Methods("POST")
 	api.HandleFunc("/credentials", h.GetAPICredentials).Methods("GET")
+	api.HandleFunc("/credentials/reload", h.ReloadAPICredentials).Methods("POST")
+	api.HandleFunc("/credentials/{id}", h.GetAPICredential).Methods("GET")
+	api.HandleFunc("/credentials/{id}", h.UpdateAPICredentials).Methods("PATCH")
+	api.HandleFunc("/credentials/{id}", h.DeleteAPICredentials).Methods("DELETE")
 
 	// Advanced Futures routes
 	api.HandleFunc("/futures/advanced/order", h.CreateAdvancedFuturesOrder).Methods("POST")
 	api.HandleFunc("/futures/order/modify", h.ModifyFuturesOrder).Methods("PUT")
 	api.HandleFunc("/futures/batch/orders", h.CreateBatchOrders).Methods("POST")
 	api.HandleFunc("/futures/batch/orders/cancel", h.CancelBatchOrders).Methods("DELETE")
+	api.HandleFunc("/futures/leverage", h.ChangeLeverage).Methods("POST")
+	api.HandleFunc("/futures/margin-type", h.ChangeMarginType).Methods("POST")
 	api.HandleFunc("/futures/position-mode", h.SetPositionMode).Methods("POST")
 	api.HandleFunc("/futures/position-mode", h.GetPositionMode).Methods("GET")
     api.HandleFunc("/futures/account/status", h.GetAccountStatusWS).Methods("GET")
@@ -272,10 +526,78 @@ func SetupRoutes(h *Handlers) *mux.Router {
 
     // Key utilities
     api.HandleFunc("/keys/ed25519/generate", h.GenerateEd25519Key).Methods("POST")
+    api.HandleFunc("/keys/ed25519/import", h.ImportEd25519Key).Methods("POST")
+
+	// Risk routes
+	api.HandleFunc("/risk/events", h.GetRiskEvents).Methods("GET")
+	api.HandleFunc("/risk/summary", h.GetRiskSummary).Methods("GET")
+	api.HandleFunc("/risk/limits", h.GetRiskLimits).Methods("GET")
+	api.HandleFunc("/risk/limits/{symbol}", h.GetRiskLimit).Methods("GET")
+	api.HandleFunc("/risk/limits/{symbol}", h.SetRiskLimit).Methods("PUT")
+	api.HandleFunc("/risk/limits/{symbol}", h.DeleteRiskLimit).Methods("DELETE")
+	api.HandleFunc("/risk/symbols", h.GetTradingPolicy).Methods("GET")
+	api.HandleFunc("/risk/symbols", h.SetTradingPolicy).Methods("PUT")
+	api.HandleFunc("/risk/symbols", h.AddPolicySymbols).Methods("POST")
+	api.HandleFunc("/risk/symbols/{symbol}", h.RemovePolicySymbol).Methods("DELETE")
+	api.HandleFunc("/risk/daily-pnl", h.GetDailyPnL).Methods("GET")
+	api.HandleFunc("/risk/reset", h.ResetDailyLossLock).Methods("POST")
+
+	// Symbol settings routes
+	api.HandleFunc("/settings/symbols", h.ListSymbolSettings).Methods("GET")
+	api.HandleFunc("/settings/symbols/{symbol}", h.GetSymbolSettings).Methods("GET")
+	api.HandleFunc("/settings/symbols/{symbol}", h.SetSymbolSettings).Methods("PUT")
+	api.HandleFunc("/settings/symbols/{symbol}", h.DeleteSymbolSettings).Methods("DELETE")
+
+	// Order template routes
+	api.HandleFunc("/templates", h.CreateOrderTemplate).Methods("POST")
+	api.HandleFunc("/templates", h.ListOrderTemplates).Methods("GET")
+	api.HandleFunc("/templates/{name}", h.GetOrderTemplate).Methods("GET")
+	api.HandleFunc("/templates/{name}", h.UpdateOrderTemplate).Methods("PUT")
+	api.HandleFunc("/templates/{name}", h.DeleteOrderTemplate).Methods("DELETE")
+	api.HandleFunc("/templates/{name}/execute", h.ExecuteOrderTemplate).Methods("POST")
+
+	// Trade journal routes
+	api.HandleFunc("/journal", h.ListJournalEntries).Methods("GET")
+	api.HandleFunc("/journal/tags", h.GetJournalTags).Methods("GET")
+	api.HandleFunc("/journal/entries/{id}", h.UpdateJournalEntry).Methods("PUT")
+	api.HandleFunc("/journal/entries/{id}", h.DeleteJournalEntry).Methods("DELETE")
+	api.HandleFunc("/journal/{order_id}", h.CreateJournalEntry).Methods("POST")
+
+	// Watchlist routes
+	api.HandleFunc("/watchlist", h.AddToWatchlist).Methods("POST")
+	api.HandleFunc("/watchlist", h.GetWatchlist).Methods("GET")
+	api.HandleFunc("/watchlist/{symbol}", h.RemoveFromWatchlist).Methods("DELETE")
+
+	// Audit routes
+	api.HandleFunc("/audit", h.GetAuditLog).Methods("GET")
+
+	// Report routes
+	api.HandleFunc("/reports/pnl", h.GetPnLReport).Methods("GET")
+	api.HandleFunc("/reports/equity-curve", h.GetEquityCurve).Methods("GET")
+	api.HandleFunc("/reports/latency", h.GetOrderLatencyReport).Methods("GET")
+
+	// Export routes
+	api.HandleFunc("/export/orders", h.ExportOrders).Methods("GET")
+	api.HandleFunc("/export/trades", h.ExportTrades).Methods("GET")
+
+	// Notification routes
+	api.HandleFunc("/notifications/test", h.SendTestNotification).Methods("POST")
+	api.HandleFunc("/notifications/status", h.GetNotificationStatus).Methods("GET")
+	api.HandleFunc("/notifications/webhooks", h.CreateWebhook).Methods("POST")
+	api.HandleFunc("/notifications/webhooks", h.ListWebhooks).Methods("GET")
+	api.HandleFunc("/notifications/webhooks/dead-letters", h.ListWebhookDeadLetters).Methods("GET")
+	api.HandleFunc("/notifications/webhooks/{id}", h.DeleteWebhook).Methods("DELETE")
+	api.HandleFunc("/notifications/webhooks/{id}/test", h.TestWebhook).Methods("POST")
+
+	// API token routes
+	api.HandleFunc("/auth/tokens", h.CreateAPIToken).Methods("POST")
+	api.HandleFunc("/auth/tokens", h.ListAPITokens).Methods("GET")
+	api.HandleFunc("/auth/tokens/{id}", h.DeleteAPIToken).Methods("DELETE")
 
 	// WebSocket routes
 	api.HandleFunc("/websocket/connect", h.ConnectWebSocket).Methods("GET")
 	api.HandleFunc("/websocket/messages", h.GetWebSocketMessages).Methods("GET")
+	api.HandleFunc("/websocket/status", h.GetWebSocketStatus).Methods("GET")
 
 	// Options routes (fully implemented)
 	options.HandleFunc("/order", h.CreateOptionsOrderAdvanced).Methods("POST")
@@ -287,8 +609,10 @@ func SetupRoutes(h *Handlers) *mux.Router {
 // statusRecorder wraps http.ResponseWriter to capture status code and size
 type statusRecorder struct {
 	http.ResponseWriter
-	status int
-	size   int
+	status    int
+	size      int
+	principal string     // set by authMiddleware
+	errBody   *ErrorBody // set when an error response is written
 }
 
 func (r *statusRecorder) WriteHeader(code int) {
@@ -306,14 +630,109 @@ func (r *statusRecorder) Write(b []byte) (int, error) {
 	return n, err
 }
 
-// loggingMiddleware logs each HTTP request with method, path, status and duration
+// Flush sends buffered data to the client if the underlying writer supports it (needed for SSE)
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		if r.status == 0 {
+			r.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over to the caller, as WebSocket upgrades require
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && r.status == 0 {
+		// The handler writes the 101 response directly to the connection
+		r.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Push initiates an HTTP/2 server push if the underlying writer supports it
+func (r *statusRecorder) Push(target string, opts *http.PushOptions) error {
+	if p, ok := r.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// loggingMiddleware logs each HTTP request once with its status, duration and, for failed
+// requests, the error written by the handler
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		rec := &statusRecorder{ResponseWriter: w}
 		next.ServeHTTP(rec, r)
-		dur := time.Since(start)
-		log.Printf("%s %s %d %dB %s", r.Method, r.URL.Path, rec.status, rec.size, dur)
+
+		principal := rec.principal
+		if principal == "" {
+			principal = "-"
+		}
+		attrs := []any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"bytes", rec.size,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"principal", principal,
+		}
+		if rec.errBody != nil {
+			attrs = append(attrs, "error", rec.errBody.Message, "error_code", rec.errBody.Code)
+			if rec.errBody.BinanceCode != 0 {
+				attrs = append(attrs, "binance_code", rec.errBody.BinanceCode)
+			}
+		}
+
+		logger := logging.FromContext(r.Context())
+		switch {
+		case rec.status >= http.StatusInternalServerError:
+			logger.Error("request failed", attrs...)
+		case rec.status >= http.StatusBadRequest:
+			logger.Warn("request rejected", attrs...)
+		default:
+			logger.Info("request completed", attrs...)
+		}
 	})
 }
 
+// requestIDMiddleware tags each request with an ID (reusing X-Request-ID when the client sends one)
+// and stores it with the route template in the request context for logging and auditing
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", requestID)
+
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if tmpl, err := current.GetPathTemplate(); err == nil {
+				route = tmpl
+			}
+		}
+
+		ctx := logging.WithRequest(r.Context(), requestID, r.Method+" "+route)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// newRequestID returns a random 16-character hex ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// ListJobs handles GET /api/admin/jobs
+// @Summary      List background jobs
+// @Description  List the registered background jobs (position sync, order reconciliation, equity snapshots, income sync, listen key keepalive, exchange info refresh) with their interval, whether a run is in progress, the next scheduled run and the latest recorded run with its duration, error and run counts
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   services.JobStatus
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/admin/jobs [get]
+func (h *Handlers) ListJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.tradingService.ListJobs(r.Context())
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// RunJob handles POST /api/admin/jobs/{name}/run
+// @Summary      Run a background job
+// @Description  Run a job now and wait for it to finish. A failed run is returned with 200 and its error; the job's schedule is unchanged.
+// @Tags         admin
+// @Produce      json
+// @Param        name  path      string  true  "Job name"
+// @Success      200   {object}  models.JobRun
+// @Failure      404   {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      409   {object}  handlers.ErrorResponse  "Job is already running"
+// @Router       /api/admin/jobs/{name}/run [post]
+func (h *Handlers) RunJob(w http.ResponseWriter, r *http.Request) {
+	run, err := h.tradingService.RunJob(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrJobNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrJobRunning):
+			status = http.StatusConflict
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateJournalEntry handles POST /api/journal/{order_id}
+// @Summary      Annotate an order or position
+// @Description  Add a note and tags to a stored futures, options or spot order or position, identified by its MongoDB ID (the id field of order listings, not the Binance order ID). Tags are trimmed, lower cased and deduplicated; a note or at least one tag is required.
+// @Tags         journal
+// @Accept       json
+// @Produce      json
+// @Param        order_id  path      string                        true  "MongoDB ID of the order or position"
+// @Param        entry     body      services.JournalEntryRequest  true  "Note and tags"
+// @Success      201       {object}  models.JournalEntry
+// @Failure      400       {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404       {object}  handlers.ErrorResponse  "Order or position not found"
+// @Failure      500       {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/journal/{order_id} [post]
+func (h *Handlers) CreateJournalEntry(w http.ResponseWriter, r *http.Request) {
+	var req services.JournalEntryRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	entry, err := h.tradingService.CreateJournalEntry(r.Context(), mux.Vars(r)["order_id"], &req)
+	if err != nil {
+		writeServiceError(w, journalErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// ListJournalEntries handles GET /api/journal
+// @Summary      List journal entries
+// @Description  List journal entries newest first. Repeated or comma separated tag parameters select entries carrying every tag.
+// @Tags         journal
+// @Produce      json
+// @Param        tag       query     string  false  "Tag filter; repeat or separate with commas to require several"
+// @Param        order_id  query     string  false  "MongoDB ID of the order or position"
+// @Param        symbol    query     string  false  "Symbol"
+// @Param        limit     query     int     false  "Maximum entries (default 100, max 1000)"
+// @Success      200       {array}   models.JournalEntry
+// @Failure      400       {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500       {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/journal [get]
+func (h *Handlers) ListJournalEntries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, err := parseNonNegativeInt(q.Get("limit"), "limit")
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	filter := &services.JournalFilter{OrderID: q.Get("order_id"), Symbol: q.Get("symbol"), Limit: limit}
+	for _, value := range q["tag"] {
+		filter.Tags = append(filter.Tags, strings.Split(value, ",")...)
+	}
+
+	entries, err := h.tradingService.ListJournalEntries(r.Context(), filter)
+	if err != nil {
+		writeServiceError(w, journalErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// GetJournalTags handles GET /api/journal/tags
+// @Summary      List journal tags
+// @Description  List the distinct tags in use, sorted, optionally only those starting with prefix; meant for autocomplete
+// @Tags         journal
+// @Produce      json
+// @Param        prefix  query     string  false  "Tag prefix"
+// @Success      200     {array}   string
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/journal/tags [get]
+func (h *Handlers) GetJournalTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.tradingService.JournalTags(r.Context(), r.URL.Query().Get("prefix"))
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// UpdateJournalEntry handles PUT /api/journal/entries/{id}
+// @Summary      Update a journal entry
+// @Description  Replace the note and tags of a journal entry
+// @Tags         journal
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string                        true  "Journal entry ID"
+// @Param        entry  body      services.JournalEntryRequest  true  "Note and tags"
+// @Success      200    {object}  models.JournalEntry
+// @Failure      400    {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404    {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500    {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/journal/entries/{id} [put]
+func (h *Handlers) UpdateJournalEntry(w http.ResponseWriter, r *http.Request) {
+	var req services.JournalEntryRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	entry, err := h.tradingService.UpdateJournalEntry(r.Context(), mux.Vars(r)["id"], &req)
+	if err != nil {
+		writeServiceError(w, journalErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+// DeleteJournalEntry handles DELETE /api/journal/entries/{id}
+// @Summary      Delete a journal entry
+// @Description  Remove a journal entry; the order or position it annotated is untouched
+// @Tags         journal
+// @Produce      json
+// @Param        id   path      string  true  "Journal entry ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/journal/entries/{id} [delete]
+func (h *Handlers) DeleteJournalEntry(w http.ResponseWriter, r *http.Request) {
+	if err := h.tradingService.DeleteJournalEntry(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeServiceError(w, journalErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Journal entry deleted successfully"})
+}
+
+// journalErrorStatus maps journal errors to HTTP status codes
+func journalErrorStatus(err error) int {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr), errors.Is(err, services.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrJournalTargetNotFound), errors.Is(err, services.ErrJournalEntryNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+)
+
+// CalculateLiquidation handles POST /api/futures/calculate/liquidation
+// @Summary      Estimate liquidation price
+// @Description  Estimate the initial and maintenance margin and liquidation price of a prospective one-way mode position from the symbol's leverage brackets. Cross margin uses the account's wallet balance unless wallet_balance is given.
+// @Tags         futures
+// @Accept       json
+// @Produce      json
+// @Param        request  body      services.LiquidationRequest  true  "Prospective position"
+// @Success      200      {object}  services.LiquidationEstimate
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/calculate/liquidation [post]
+func (h *Handlers) CalculateLiquidation(w http.ResponseWriter, r *http.Request) {
+	var req services.LiquidationRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	estimate, err := h.tradingService.CalculateLiquidation(r.Context(), &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			status = http.StatusBadRequest
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(estimate)
+}
+
+// CalculatePositionSize handles POST /api/futures/calculate/position-size
+// @Summary      Size a position by risk
+// @Description  Compute the quantity that loses risk_percent of the margin balance (or risk_amount) if the stop is hit, rounded down to the step size, with its notional and margin and whether the symbol filters, risk limits and available balance allow it. With execute=true an allowed position is placed as a batch: the entry order plus a close-position STOP_MARKET at the stop price.
+// @Tags         futures
+// @Accept       json
+// @Produce      json
+// @Param        request  body      services.PositionSizeRequest  true  "Risk budget, entry and stop"
+// @Param        execute  query     bool                          false "Place the entry and stop orders when allowed (same as the execute body field)"
+// @Success      200      {object}  services.PositionSize
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      422      {object}  handlers.ErrorResponse  "Risk limit exceeded"
+// @Failure      423      {object}  handlers.ErrorResponse  "Daily loss limit reached"
+// @Failure      429      {object}  handlers.ErrorResponse  "Order rate limit exceeded"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/calculate/position-size [post]
+func (h *Handlers) CalculatePositionSize(w http.ResponseWriter, r *http.Request) {
+	var req services.PositionSizeRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	execute, err := parseBoolParam(r.URL.Query().Get("execute"), "execute")
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	req.Execute = req.Execute || execute
+
+	size, err := h.tradingService.CalculatePositionSize(r.Context(), &req)
+	if err != nil {
+		status := riskErrorStatus(err)
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			status = http.StatusBadRequest
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(size)
+}
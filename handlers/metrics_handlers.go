@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"futures-options/binance"
+	"futures-options/latency"
+)
+
+// Metrics handles GET /metrics
+// @Summary      Prometheus metrics
+// @Description  Exposes the Binance circuit breaker state, leverage cache counters, MongoDB connection pool statistics and order placement latency histograms in the Prometheus text format.
+// @Tags         health
+// @Produce      plain
+// @Success      200  {string}  string  "Prometheus metrics"
+// @Router       /metrics [get]
+func (h *Handlers) Metrics(w http.ResponseWriter, r *http.Request) {
+	circuit := h.tradingService.BinanceCircuit()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	fmt.Fprintln(w, "# HELP binance_circuit_state Binance circuit breaker state (1 for the current state).")
+	fmt.Fprintln(w, "# TYPE binance_circuit_state gauge")
+	for _, state := range []string{binance.BreakerClosed, binance.BreakerOpen, binance.BreakerHalfOpen} {
+		value := 0
+		if circuit.State == state {
+			value = 1
+		}
+		fmt.Fprintf(w, "binance_circuit_state{state=%q} %d\n", state, value)
+	}
+
+	fmt.Fprintln(w, "# HELP binance_circuit_consecutive_failures Consecutive failed Binance requests.")
+	fmt.Fprintln(w, "# TYPE binance_circuit_consecutive_failures gauge")
+	fmt.Fprintf(w, "binance_circuit_consecutive_failures %d\n", circuit.ConsecutiveFailures)
+
+	fmt.Fprintln(w, "# HELP binance_circuit_opens_total Times the Binance circuit breaker has opened.")
+	fmt.Fprintln(w, "# TYPE binance_circuit_opens_total counter")
+	fmt.Fprintf(w, "binance_circuit_opens_total %d\n", circuit.Opens)
+
+	leverage := h.tradingService.LeverageCacheStats()
+	fmt.Fprintln(w, "# HELP binance_leverage_cache_requests_total Orders with leverage, by whether the cached leverage already matched.")
+	fmt.Fprintln(w, "# TYPE binance_leverage_cache_requests_total counter")
+	fmt.Fprintf(w, "binance_leverage_cache_requests_total{result=\"hit\"} %d\n", leverage.Hits)
+	fmt.Fprintf(w, "binance_leverage_cache_requests_total{result=\"miss\"} %d\n", leverage.Misses)
+
+	mongoPool := h.tradingService.DatabasePoolStats()
+	fmt.Fprintln(w, "# HELP mongodb_pool_max_connections Configured maximum MongoDB pool size.")
+	fmt.Fprintln(w, "# TYPE mongodb_pool_max_connections gauge")
+	fmt.Fprintf(w, "mongodb_pool_max_connections %d\n", mongoPool.MaxPoolSize)
+
+	fmt.Fprintln(w, "# HELP mongodb_pool_connections MongoDB connections, open and checked out.")
+	fmt.Fprintln(w, "# TYPE mongodb_pool_connections gauge")
+	fmt.Fprintf(w, "mongodb_pool_connections{state=\"open\"} %d\n", mongoPool.Open)
+	fmt.Fprintf(w, "mongodb_pool_connections{state=\"in_use\"} %d\n", mongoPool.InUse)
+
+	fmt.Fprintln(w, "# HELP mongodb_pool_checkouts_total MongoDB connection checkouts, by result.")
+	fmt.Fprintln(w, "# TYPE mongodb_pool_checkouts_total counter")
+	fmt.Fprintf(w, "mongodb_pool_checkouts_total{result=\"ok\"} %d\n", mongoPool.Checkouts)
+	fmt.Fprintf(w, "mongodb_pool_checkouts_total{result=\"failed\"} %d\n", mongoPool.CheckoutFailures)
+
+	fmt.Fprintln(w, "# HELP mongodb_pool_cleared_total Times the MongoDB pool was cleared after a server error.")
+	fmt.Fprintln(w, "# TYPE mongodb_pool_cleared_total counter")
+	fmt.Fprintf(w, "mongodb_pool_cleared_total %d\n", mongoPool.Cleared)
+
+	fmt.Fprintln(w, "# HELP order_placement_latency_seconds Order placement latency by stage: the Binance request, or the total from the HTTP request to the stored order.")
+	fmt.Fprintln(w, "# TYPE order_placement_latency_seconds histogram")
+	for _, hist := range latency.OrderHistograms() {
+		labels := fmt.Sprintf("stage=%q,symbol=%q,transport=%q", hist.Stage, hist.Symbol, hist.Transport)
+		for i, bound := range latency.OrderBuckets {
+			fmt.Fprintf(w, "order_placement_latency_seconds_bucket{%s,le=%q} %d\n", labels, strconv.FormatFloat(bound, 'g', -1, 64), hist.Buckets[i])
+		}
+		fmt.Fprintf(w, "order_placement_latency_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, hist.Count)
+		fmt.Fprintf(w, "order_placement_latency_seconds_sum{%s} %g\n", labels, hist.Sum)
+		fmt.Fprintf(w, "order_placement_latency_seconds_count{%s} %d\n", labels, hist.Count)
+	}
+}
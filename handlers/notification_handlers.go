@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+)
+
+// SendTestNotification handles POST /api/notifications/test
+// @Summary      Send a test notification
+// @Description  Send a TEST message through every configured chat channel (Telegram, Slack, Discord), ignoring the enabled event types, and report whether each channel accepted it
+// @Tags         notifications
+// @Produce      json
+// @Success      200  {array}   services.NotificationTestResult
+// @Failure      503  {object}  handlers.ErrorResponse  "No channel configured"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/notifications/test [post]
+func (h *Handlers) SendTestNotification(w http.ResponseWriter, r *http.Request) {
+	results, err := h.tradingService.SendTestNotification(r.Context())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrNoNotificationChannels) {
+			status = http.StatusServiceUnavailable
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// GetNotificationStatus handles GET /api/notifications/status
+// @Summary      Notification delivery status
+// @Description  List the configured chat channels with their enabled event types, queued messages, delivery counts and last success and failure
+// @Tags         notifications
+// @Produce      json
+// @Success      200  {array}   notifications.DestinationStatus
+// @Router       /api/notifications/status [get]
+func (h *Handlers) GetNotificationStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.tradingService.GetNotificationStatus())
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// GetFuturesOrder handles GET /api/futures/orders/{id}
+// @Summary      Get a futures order
+// @Description  Get a stored futures order by its id, or by its Binance order ID when the value is numeric. include_raw adds the raw Binance response and include_journal every journal entry on the order. Unknown and malformed IDs return 404.
+// @Tags         futures
+// @Produce      json
+// @Param        id               path      string  true   "Order ID or Binance order ID"
+// @Param        include_raw      query     bool    false  "Include the raw Binance response"
+// @Param        include_journal  query     bool    false  "Include the order's journal entries"
+// @Success      200  {object}  models.FuturesOrder
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/orders/{id} [get]
+func (h *Handlers) GetFuturesOrder(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseOrderDetailOptions(r)
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	order, err := h.tradingService.GetFuturesOrder(r.Context(), mux.Vars(r)["id"], opts)
+	if err != nil {
+		writeServiceError(w, orderLookupErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// GetOptionsOrder handles GET /api/options/orders/{id}
+// @Summary      Get an options order
+// @Description  Get a stored options order by its id, or by its Binance order ID when the value is numeric. include_raw adds the raw Binance response and include_journal every journal entry on the order. Unknown and malformed IDs return 404.
+// @Tags         options
+// @Produce      json
+// @Param        id               path      string  true   "Order ID or Binance order ID"
+// @Param        include_raw      query     bool    false  "Include the raw Binance response"
+// @Param        include_journal  query     bool    false  "Include the order's journal entries"
+// @Success      200  {object}  models.OptionsOrder
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/options/orders/{id} [get]
+func (h *Handlers) GetOptionsOrder(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseOrderDetailOptions(r)
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	order, err := h.tradingService.GetOptionsOrder(r.Context(), mux.Vars(r)["id"], opts)
+	if err != nil {
+		writeServiceError(w, orderLookupErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// orderLookupErrorStatus maps stored order lookup errors to HTTP status codes
+func orderLookupErrorStatus(err error) int {
+	if errors.Is(err, services.ErrOrderNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// GetOpenOrderCount handles GET /api/futures/open-order-count
+// @Summary      Count a symbol's open orders
+// @Description  Count the symbol's open USDⓈ-M orders, and the conditional (stop, take-profit and trailing) ones among them, with the caps from its MAX_NUM_ORDERS and MAX_NUM_ALGO_ORDERS filters. While the user data stream is connected the counts it keeps current are returned (live=true); otherwise the open orders are listed from Binance. Batch orders and grid strategies that would exceed a cap are rejected with 409 before any order is placed.
+// @Tags         futures
+// @Produce      json
+// @Param        symbol  query     string  true  "Futures symbol, e.g. BTCUSDT"
+// @Success      200     {object}  services.OpenOrderCount
+// @Failure      400     {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/open-order-count [get]
+func (h *Handlers) GetOpenOrderCount(w http.ResponseWriter, r *http.Request) {
+	count, err := h.tradingService.GetOpenOrderCount(r.Context(), r.URL.Query().Get("symbol"))
+	if err != nil {
+		writeServiceError(w, validationErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(count)
+}
+
+// CleanupOrphanOrders handles POST /api/futures/orders/cleanup
+// @Summary      Cancel orphan protective orders
+// @Description  List the open USDⓈ-M orders and positions and cancel every reduce-only or close-position order whose position side is flat, such as a stop-loss left behind when its take-profit closed the position. A side that also has an open entry order is skipped, since its stop may be waiting for the entry to fill. With dry_run=true the candidates are only listed. Orders Binance did not cancel, for example because they filled in the meantime, are returned with an error.
+// @Tags         futures
+// @Produce      json
+// @Param        dry_run  query     bool  false  "List the orphan orders without canceling them"
+// @Success      200      {object}  services.OrphanSweepResult
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/orders/cleanup [post]
+func (h *Handlers) CleanupOrphanOrders(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := parseBoolParam(r.URL.Query().Get("dry_run"), "dry_run")
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := h.tradingService.SweepOrphanOrders(r.Context(), dryRun)
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+)
+
+// paperMiddleware marks every response as simulated while paper trading mode is on
+func paperMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Paper-Trading", "true")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// paperErrorStatus maps errors of features not simulated in paper trading mode (options, COIN-M, spot)
+func paperErrorStatus(err error) int {
+	if errors.Is(err, services.ErrPaperUnsupported) {
+		return http.StatusNotImplemented
+	}
+	return http.StatusInternalServerError
+}
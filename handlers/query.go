@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"futures-options/models"
+	"futures-options/services"
+)
+
+// parseOrderQuery reads the order listing filters and paging options from the query string
+func parseOrderQuery(r *http.Request) (*services.OrderQuery, error) {
+	q := r.URL.Query()
+	query := &services.OrderQuery{
+		Symbol:   q.Get("symbol"),
+		Status:   strings.ToUpper(q.Get("status")),
+		Side:     strings.ToUpper(q.Get("side")),
+		BeforeID: q.Get("before_id"),
+	}
+
+	switch strings.ToLower(q.Get("sort")) {
+	case "", "desc":
+	case "asc":
+		query.SortAsc = true
+	default:
+		return nil, &FieldError{Field: "sort", Rule: services.RuleEnum, Message: "must be asc or desc"}
+	}
+
+	var err error
+	if query.Limit, err = parseNonNegativeInt(q.Get("limit"), "limit"); err != nil {
+		return nil, err
+	}
+	if query.Offset, err = parseNonNegativeInt(q.Get("offset"), "offset"); err != nil {
+		return nil, err
+	}
+	if query.StartTime, err = parseTimeParam(q.Get("start_time"), "start_time"); err != nil {
+		return nil, err
+	}
+	if query.EndTime, err = parseTimeParam(q.Get("end_time"), "end_time"); err != nil {
+		return nil, err
+	}
+	if query.IncludeRaw, err = parseBoolParam(q.Get("include_raw"), "include_raw"); err != nil {
+		return nil, err
+	}
+	if query.IncludeJournal, err = parseBoolParam(q.Get("include_journal"), "include_journal"); err != nil {
+		return nil, err
+	}
+	if query.PartiallyFilled, err = parseBoolParam(q.Get("partially_filled"), "partially_filled"); err != nil {
+		return nil, err
+	}
+
+	return query, nil
+}
+
+// parseOrderSource parses the optional source filter of the futures order endpoints
+func parseOrderSource(value string) (string, error) {
+	value = strings.ToLower(value)
+	if value == "" || slices.Contains(services.OrderSources, value) {
+		return value, nil
+	}
+	return "", &FieldError{Field: "source", Rule: services.RuleEnum, Message: "must be one of " + strings.Join(services.OrderSources, ", ")}
+}
+
+// parseOrderDetailOptions parses the include_raw and include_journal parameters of a single order lookup
+func parseOrderDetailOptions(r *http.Request) (services.OrderDetailOptions, error) {
+	q := r.URL.Query()
+	var opts services.OrderDetailOptions
+	var err error
+	if opts.IncludeRaw, err = parseBoolParam(q.Get("include_raw"), "include_raw"); err != nil {
+		return opts, err
+	}
+	if opts.IncludeJournal, err = parseBoolParam(q.Get("include_journal"), "include_journal"); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// parseNonNegativeInt parses an optional non-negative integer query parameter
+func parseNonNegativeInt(value, name string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, &FieldError{Field: name, Rule: services.RuleType, Message: "must be a non-negative integer"}
+	}
+	return n, nil
+}
+
+// parseBoolParam parses an optional boolean query parameter
+func parseBoolParam(value, name string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &FieldError{Field: name, Rule: services.RuleType, Message: "must be true or false"}
+	}
+	return b, nil
+}
+
+// parseMarketParam parses the optional futures market query parameter; empty means usdm
+func parseMarketParam(value string) (models.Market, error) {
+	switch models.Market(strings.ToLower(value)) {
+	case "", models.MarketUSDM:
+		return models.MarketUSDM, nil
+	case models.MarketCoinM:
+		return models.MarketCoinM, nil
+	default:
+		return "", &FieldError{Field: "market", Rule: services.RuleEnum, Message: "must be usdm or coinm"}
+	}
+}
+
+// parseTimeParam parses an optional time query parameter given as RFC3339 or Unix milliseconds
+func parseTimeParam(value, name string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		t := time.UnixMilli(ms)
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, &FieldError{Field: name, Rule: services.RuleType, Message: "must be RFC3339 or Unix milliseconds"}
+	}
+	return &t, nil
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"futures-options/services"
+)
+
+// rateLimitIdleTTL is how long an unused bucket is kept before it is pruned
+const rateLimitIdleTTL = 10 * time.Minute
+
+// RateLimiter is a token-bucket limiter with one bucket per client key
+type RateLimiter struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter allows perMinute requests per client with bursts of up to burst requests;
+// it returns nil (no limit) when perMinute is not positive
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:      float64(perMinute) / 60,
+		burst:     float64(burst),
+		buckets:   make(map[string]*tokenBucket),
+		lastPrune: time.Now(),
+	}
+}
+
+// Allow takes a token from key's bucket; when none is left it returns how long until one is
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// prune drops buckets idle for longer than rateLimitIdleTTL (they would be full again anyway);
+// it runs at most once per TTL and must be called with mu held
+func (l *RateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < rateLimitIdleTTL {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > rateLimitIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastPrune = now
+}
+
+// SetRateLimits enables per-client rate limiting of /api routes; read applies to GET requests
+// and write to everything else (order placement, cancels, settings). Either may be nil.
+func (h *Handlers) SetRateLimits(read, write *RateLimiter) {
+	h.readLimiter = read
+	h.writeLimiter = write
+}
+
+// rateLimitMiddleware answers 429 with Retry-After once the client's budget is used up.
+// It runs after authMiddleware so clients are told apart by their authenticated principal, or by
+// IP when authentication is disabled.
+func (h *Handlers) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter := h.writeLimiter
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			limiter = h.readLimiter
+		}
+
+		if ok, wait := limiter.Allow(rateLimitKey(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			WriteError(w, http.StatusTooManyRequests, ErrCodeRateLimited, "rate limit exceeded", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitKey identifies the client by the principal authMiddleware authenticated, falling back
+// to the remote IP for the anonymous principal. The presented token is not used: with
+// authentication disabled any token is accepted, so a client could get a fresh bucket per request.
+func rateLimitKey(r *http.Request) string {
+	if principal := services.PrincipalFromContext(r.Context()); principal != "" && principal != services.AnonymousPrincipal {
+		return "principal:" + principal
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"futures-options/services"
+)
+
+func TestRateLimitKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal string
+		token     string
+		want      string
+	}{
+		{"authenticated", "trading-bot", "fo_token", "principal:trading-bot"},
+		// Any token is accepted with authentication disabled, so it must not pick the bucket
+		{"auth disabled", services.AnonymousPrincipal, "made-up-token", "ip:192.0.2.1"},
+		{"no principal", "", "", "ip:192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/futures/orders", nil)
+			r.RemoteAddr = "192.0.2.1:4321"
+			r.Header.Set("X-API-Token", tt.token)
+			if tt.principal != "" {
+				r = r.WithContext(services.WithPrincipal(r.Context(), tt.principal))
+			}
+			if got := rateLimitKey(r); got != tt.want {
+				t.Errorf("rateLimitKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitIgnoresRotatedTokensWithAuthDisabled(t *testing.T) {
+	limiter := NewRateLimiter(60, 2)
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		r := httptest.NewRequest("POST", "/api/futures/orders", nil)
+		r.RemoteAddr = "192.0.2.1:4321"
+		r.Header.Set("X-API-Token", "token-"+string(rune('a'+i)))
+		r = r.WithContext(services.WithPrincipal(r.Context(), services.AnonymousPrincipal))
+		if ok, _ := limiter.Allow(rateLimitKey(r)); ok {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("%d requests allowed with a fresh token each, want the burst of 2", allowed)
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+)
+
+// ReconcileWithBinance handles POST /api/admin/reconcile
+// @Summary      Reconcile with Binance
+// @Description  Import open orders Binance has but the local book does not, close local open orders Binance no longer knows as CANCELED (EXPIRED past their good-till date) with a reconciliation note, correct drifted statuses and refresh the stored positions. The same runs on startup before the strategies resume. The report is stored for auditing.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  models.ReconciliationReport
+// @Failure      409  {object}  handlers.ErrorResponse  "A reconciliation is already running"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/admin/reconcile [post]
+func (h *Handlers) ReconcileWithBinance(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), services.ReconcileTimeout)
+	defer cancel()
+	extendWriteDeadline(w, r, services.ReconcileTimeout)
+	report, err := h.tradingService.ReconcileWithBinance(ctx, services.ReconcileTriggerManual)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrReconcileRunning) {
+			status = http.StatusConflict
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// ListReconciliationReports handles GET /api/admin/reconcile/reports
+// @Summary      List reconciliation reports
+// @Description  List the stored startup and manual reconciliation reports, newest first
+// @Tags         admin
+// @Produce      json
+// @Param        limit  query     int  false  "Maximum reports (default 20)"
+// @Success      200    {array}   models.ReconciliationReport
+// @Failure      400    {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500    {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/admin/reconcile/reports [get]
+func (h *Handlers) ListReconciliationReports(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseNonNegativeInt(r.URL.Query().Get("limit"), "limit")
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	reports, err := h.tradingService.ListReconciliationReports(r.Context(), limit)
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
+
+// RestartUserDataStream handles POST /api/admin/user-stream/restart
+// @Summary      Restart the user data stream
+// @Description  Close the user data stream and delete its listen key, acquire a new listen key and connect, then reconcile orders and positions with Binance to fill in events the old stream missed. Events the old stream had already received are still handled. A reconciliation that cannot run, for example because one is already running, is reported in reconcile_error without failing the restart.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  services.UserStreamRestart
+// @Failure      409  {object}  handlers.ErrorResponse  "A restart is already in progress"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501  {object}  handlers.ErrorResponse  "Paper trading mode has no user data stream"
+// @Failure      503  {object}  services.UserStreamRestart  "The new stream could not connect (connected false, connect_error set); it is retried in the background"
+// @Router       /api/admin/user-stream/restart [post]
+func (h *Handlers) RestartUserDataStream(w http.ResponseWriter, r *http.Request) {
+	// The restart waits for the reconciliation that follows it
+	extendWriteDeadline(w, r, services.ReconcileTimeout)
+	result, err := h.tradingService.RestartUserDataStream(r.Context())
+	if err != nil {
+		status := paperErrorStatus(err)
+		if errors.Is(err, services.ErrUserStreamRestarting) {
+			status = http.StatusConflict
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.ConnectError != "" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(result)
+}
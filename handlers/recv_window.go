@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"futures-options/binance"
+	"futures-options/config"
+	"futures-options/services"
+)
+
+// recvWindowMiddleware applies the recvWindow, in milliseconds, given in X-Recv-Window or the
+// recv_window query parameter to the signed Binance requests the request makes, in place of
+// BINANCE_RECV_WINDOW
+func (h *Handlers) recvWindowMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		field := "X-Recv-Window"
+		raw := strings.TrimSpace(r.Header.Get(field))
+		if raw == "" {
+			field = "recv_window"
+			raw = strings.TrimSpace(r.URL.Query().Get(field))
+		}
+		if raw == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ms, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || ms < 1 || ms > config.MaxRecvWindow {
+			writeValidationError(w, FieldError{
+				Field:   field,
+				Rule:    services.RuleRange,
+				Message: fmt.Sprintf("must be between 1 and %d milliseconds", config.MaxRecvWindow),
+			})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(binance.WithRecvWindow(r.Context(), ms)))
+	})
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"futures-options/models"
+	"futures-options/services"
+)
+
+// GetPnLReport handles GET /api/reports/pnl
+// @Summary      Get PnL report
+// @Description  Realized PnL, commissions and funding fees from the income history, grouped by symbol and/or UTC day, with the current unrealized PnL of open positions. group_by=source groups the recorded futures fills by what placed their order (manual, template, grid, dca, conditional, scheduled, spread or external); funding then only appears in the totals.
+// @Tags         reports
+// @Produce      json
+// @Produce      text/csv
+// @Param        start     query     string  false  "Period start (RFC3339 or Unix ms, default 7 days before end)"
+// @Param        end       query     string  false  "Period end (RFC3339 or Unix ms, default now)"
+// @Param        group_by  query     string  false  "symbol (default), day, symbol_day or source"
+// @Param        format    query     string  false  "json (default) or csv"
+// @Success      200       {object}  services.PnLReport
+// @Failure      400       {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500       {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/reports/pnl [get]
+func (h *Handlers) GetPnLReport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := &services.PnLReportQuery{GroupBy: strings.ToLower(q.Get("group_by"))}
+
+	var err error
+	if query.Start, err = parseTimeParam(q.Get("start"), "start"); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	if query.End, err = parseTimeParam(q.Get("end"), "end"); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	format := strings.ToLower(q.Get("format"))
+	if format != "" && format != "json" && format != "csv" {
+		writeServiceError(w, http.StatusBadRequest, &FieldError{Field: "format", Rule: services.RuleEnum, Message: "must be json or csv"})
+		return
+	}
+
+	report, err := h.tradingService.GetPnLReport(r.Context(), query)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			status = http.StatusBadRequest
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	if format == "csv" {
+		writePnLReportCSV(w, report)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// writePnLReportCSV writes one row per bucket followed by a TOTAL row
+func writePnLReportCSV(w http.ResponseWriter, report *services.PnLReport) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="pnl-report.csv"`)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"symbol", "day", "source", "realized_pnl", "commission", "funding_fees", "net_pnl", "unrealized_pnl", "trades", "wins", "win_rate"})
+	for _, b := range report.Buckets {
+		cw.Write(pnlBucketRow(b.Symbol, b.Day, b.Source, b))
+	}
+	cw.Write(pnlBucketRow("TOTAL", "", "", report.Totals))
+	cw.Flush()
+}
+
+func pnlBucketRow(symbol, day, source string, b *models.PnLBucket) []string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return []string{
+		symbol, day, source,
+		f(b.RealizedPnl), f(b.Commission), f(b.FundingFees), f(b.NetPnl), f(b.UnrealizedPnl),
+		strconv.FormatInt(b.Trades, 10), strconv.FormatInt(b.Wins, 10), f(b.WinRate),
+	}
+}
+
+// GetOrderLatencyReport handles GET /api/reports/latency
+// @Summary      Get order placement latency
+// @Description  p50 and p95 placement latency of the USDⓈ-M orders created in the period, per symbol and transport (rest). binance_* is the Binance request, from sending it to its response; total_* runs from the HTTP request reaching the service, or the Binance request for orders placed in the background, to the order being stored. Each order keeps its own timings in latency.
+// @Tags         reports
+// @Produce      json
+// @Param        start   query     string  false  "Period start (RFC3339 or Unix ms, default 24 hours before end)"
+// @Param        end     query     string  false  "Period end (RFC3339 or Unix ms, default now)"
+// @Param        symbol  query     string  false  "Futures symbol, e.g. BTCUSDT"
+// @Success      200     {object}  services.LatencyReport
+// @Failure      400     {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/reports/latency [get]
+func (h *Handlers) GetOrderLatencyReport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := &services.LatencyReportQuery{Symbol: q.Get("symbol")}
+
+	var err error
+	if query.Start, err = parseTimeParam(q.Get("start"), "start"); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	if query.End, err = parseTimeParam(q.Get("end"), "end"); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.tradingService.GetOrderLatencyReport(r.Context(), query)
+	if err != nil {
+		writeServiceError(w, validationErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetEquityCurve handles GET /api/reports/equity-curve
+// @Summary      Get equity curve
+// @Description  Recorded account equity snapshots downsampled to the resolution, with the period's return and maximum drawdown of the margin balance
+// @Tags         reports
+// @Produce      json
+// @Param        start       query     string  false  "Period start (RFC3339 or Unix ms, default 30 days before end)"
+// @Param        end         query     string  false  "Period end (RFC3339 or Unix ms, default now)"
+// @Param        resolution  query     string  false  "Point spacing as a duration (e.g., 15m, 1h, 24h); every snapshot when omitted"
+// @Success      200         {object}  services.EquityCurve
+// @Failure      400         {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500         {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/reports/equity-curve [get]
+func (h *Handlers) GetEquityCurve(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := &services.EquityCurveQuery{}
+
+	var err error
+	if query.Start, err = parseTimeParam(q.Get("start"), "start"); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	if query.End, err = parseTimeParam(q.Get("end"), "end"); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	if value := q.Get("resolution"); value != "" {
+		if query.Resolution, err = time.ParseDuration(value); err != nil || query.Resolution <= 0 {
+			writeServiceError(w, http.StatusBadRequest, &FieldError{Field: "resolution", Rule: services.RuleType, Message: "must be a positive duration such as 15m or 1h"})
+			return
+		}
+	}
+
+	curve, err := h.tradingService.GetEquityCurve(r.Context(), query)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			status = http.StatusBadRequest
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(curve)
+}
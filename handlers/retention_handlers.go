@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// GetRetention handles GET /api/admin/retention
+// @Summary      Get the retention policy
+// @Description  Show how long terminal orders, audit entries and risk events are kept, how often old orders are archived, and the document count and size of the orders, order archive, audit log and risk event collections. Open orders and positions are never expired or archived.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  services.RetentionReport
+// @Router       /api/admin/retention [get]
+func (h *Handlers) GetRetention(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.tradingService.GetRetention(r.Context()))
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// GetRiskEvents handles GET /api/risk/events
+// @Summary      Get risk events
+// @Description  Retrieve recorded risk events (e.g. MARGIN_CALL), newest first
+// @Tags         risk
+// @Produce      json
+// @Param        symbol  query     string  false  "Filter by symbol (e.g., BTCUSDT)"
+// @Param        limit   query     int     false  "Maximum number of events to return"
+// @Success      200     {array}   models.RiskEvent
+// @Failure      400     {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/risk/events [get]
+func (h *Handlers) GetRiskEvents(w http.ResponseWriter, r *http.Request) {
+	symbol := r.URL.Query().Get("symbol")
+
+	limit, err := parseNonNegativeInt(r.URL.Query().Get("limit"), "limit")
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	events, err := h.tradingService.GetRiskEvents(r.Context(), symbol, limit)
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+// GetRiskSummary handles GET /api/risk/summary
+// @Summary      Get a combined risk summary
+// @Description  Combine futures and options exposure: net delta per underlying (futures position amount plus options delta from the options mark endpoint), total notional, margin used vs available, the largest position's share of the notional, and each position's distance to liquidation and to the margin ratio warning. Every input is listed with when it was read; inputs that cannot be read are reported in errors and left out.
+// @Tags         risk
+// @Produce      json
+// @Success      200  {object}  services.RiskSummary
+// @Failure      502  {object}  handlers.ErrorResponse  "No input could be read"
+// @Router       /api/risk/summary [get]
+func (h *Handlers) GetRiskSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.tradingService.GetRiskSummary(r.Context())
+	if err != nil {
+		writeServiceError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// riskErrorStatus maps order placement errors from the risk limit check to HTTP statuses
+func riskErrorStatus(err error) int {
+	var riskErr *services.RiskLimitError
+	var throttleErr *services.OrderThrottleError
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		// e.g. a quote_quantity that rounds too far from the requested notional
+		return http.StatusBadRequest
+	case errors.As(err, &riskErr):
+		return http.StatusUnprocessableEntity
+	case errors.As(err, &throttleErr):
+		return http.StatusTooManyRequests
+	case errors.Is(err, services.ErrDailyLossLocked):
+		return http.StatusLocked
+	case errors.Is(err, services.ErrRiskOverrideForbidden), errors.Is(err, services.ErrRiskResetForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrMarkPriceUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, services.ErrPaperUnsupported):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetRiskLimits handles GET /api/risk/limits
+// @Summary      List risk limits
+// @Description  List the per-symbol position limits, including the DEFAULT limit applied to other symbols
+// @Tags         risk
+// @Produce      json
+// @Success      200  {array}   models.RiskLimit
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/risk/limits [get]
+func (h *Handlers) GetRiskLimits(w http.ResponseWriter, r *http.Request) {
+	limits, err := h.tradingService.ListRiskLimits(r.Context())
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(limits)
+}
+
+// GetRiskLimit handles GET /api/risk/limits/{symbol}
+// @Summary      Get a risk limit
+// @Description  Get the position limit stored for a symbol (DEFAULT for the global default)
+// @Tags         risk
+// @Produce      json
+// @Param        symbol  path      string  true  "Symbol, or DEFAULT"
+// @Success      200     {object}  models.RiskLimit
+// @Failure      404     {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/risk/limits/{symbol} [get]
+func (h *Handlers) GetRiskLimit(w http.ResponseWriter, r *http.Request) {
+	limit, err := h.tradingService.GetRiskLimit(r.Context(), mux.Vars(r)["symbol"])
+	if err != nil {
+		writeServiceError(w, riskLimitErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(limit)
+}
+
+// SetRiskLimit handles PUT /api/risk/limits/{symbol}
+// @Summary      Set a risk limit
+// @Description  Create or replace the max notional (at mark price) and/or max quantity for a symbol's net position,
+// @Description  and the max new orders per minute and per 10 seconds. Use DEFAULT as the symbol for the limit
+// @Description  applied to symbols without their own, and GLOBAL for order rate limits across all symbols.
+// @Tags         risk
+// @Accept       json
+// @Produce      json
+// @Param        symbol  path      string                     true  "Symbol, DEFAULT or GLOBAL"
+// @Param        limit   body      services.RiskLimitRequest  true  "Limits"
+// @Success      200     {object}  models.RiskLimit
+// @Failure      400     {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/risk/limits/{symbol} [put]
+func (h *Handlers) SetRiskLimit(w http.ResponseWriter, r *http.Request) {
+	var req services.RiskLimitRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	limit, err := h.tradingService.SetRiskLimit(r.Context(), mux.Vars(r)["symbol"], &req)
+	if err != nil {
+		writeServiceError(w, riskLimitErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(limit)
+}
+
+// DeleteRiskLimit handles DELETE /api/risk/limits/{symbol}
+// @Summary      Delete a risk limit
+// @Description  Remove a symbol's limit; the symbol falls back to the DEFAULT limit, if any
+// @Tags         risk
+// @Produce      json
+// @Param        symbol  path      string  true  "Symbol, or DEFAULT"
+// @Success      200     {object}  map[string]string
+// @Failure      404     {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/risk/limits/{symbol} [delete]
+func (h *Handlers) DeleteRiskLimit(w http.ResponseWriter, r *http.Request) {
+	if err := h.tradingService.DeleteRiskLimit(r.Context(), mux.Vars(r)["symbol"]); err != nil {
+		writeServiceError(w, riskLimitErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Risk limit deleted successfully"})
+}
+
+// GetDailyPnL handles GET /api/risk/daily-pnl
+// @Summary      Get today's PnL
+// @Description  Realized (income since 00:00 UTC) and unrealized PnL of the request's account (X-Account, else the active credential), and whether its daily loss lock is engaged
+// @Tags         risk
+// @Produce      json
+// @Success      200  {object}  services.DailyPnL
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/risk/daily-pnl [get]
+func (h *Handlers) GetDailyPnL(w http.ResponseWriter, r *http.Request) {
+	pnl, err := h.tradingService.GetDailyPnL(r.Context())
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pnl)
+}
+
+// ResetDailyLossLock handles POST /api/risk/reset
+// @Summary      Reset the daily loss lock
+// @Description  Unblock new positions of the request's account before 00:00 UTC; its lock engages again after another DAILY_LOSS_LIMIT of losses
+// @Tags         risk
+// @Produce      json
+// @Success      200  {object}  services.DailyPnL
+// @Failure      403  {object}  handlers.ErrorResponse  "Token not allowed to reset the lock"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/risk/reset [post]
+func (h *Handlers) ResetDailyLossLock(w http.ResponseWriter, r *http.Request) {
+	pnl, err := h.tradingService.ResetDailyLossLock(r.Context())
+	if err != nil {
+		writeServiceError(w, riskErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pnl)
+}
+
+func riskLimitErrorStatus(err error) int {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.Is(err, services.ErrRiskLimitNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &validationErr):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
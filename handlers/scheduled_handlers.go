@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateScheduledOrder handles POST /api/futures/scheduled
+// @Summary      Schedule an order
+// @Description  Submit an advanced futures order at execute_at (RFC 3339, sub-second precision). A schedule whose time passes while the server cannot run it, e.g. during a restart, is skipped with missed_policy=SKIP (default) or submitted at once with missed_policy=FIRE if no more than grace_seconds late.
+// @Tags         futures
+// @Accept       json
+// @Produce      json
+// @Param        request  body      services.CreateScheduledOrderRequest  true  "Execution time and order"
+// @Success      201      {object}  models.ScheduledOrder
+// @Header       201      {string}  Location  "GET URL of the scheduled order"
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      403      {object}  handlers.ErrorResponse  "Risk limit override not allowed for this token"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/scheduled [post]
+func (h *Handlers) CreateScheduledOrder(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateScheduledOrderRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	order, err := h.tradingService.CreateScheduledOrder(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, scheduledErrorStatus(err), err)
+		return
+	}
+
+	writeCreated(w, "/api/futures/scheduled/"+order.ID.Hex(), false, order)
+}
+
+// ListScheduledOrders handles GET /api/futures/scheduled
+// @Summary      List scheduled orders
+// @Description  List scheduled orders by execution time, optionally only those with a status (PENDING, EXECUTING, EXECUTED, FAILED, SKIPPED, CANCELED)
+// @Tags         futures
+// @Produce      json
+// @Param        status  query     string  false  "Status filter"
+// @Success      200     {array}   models.ScheduledOrder
+// @Failure      400     {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/scheduled [get]
+func (h *Handlers) ListScheduledOrders(w http.ResponseWriter, r *http.Request) {
+	orders, err := h.tradingService.ListScheduledOrders(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		writeServiceError(w, scheduledErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orders)
+}
+
+// GetScheduledOrder handles GET /api/futures/scheduled/{id}
+// @Summary      Get a scheduled order
+// @Description  Get a scheduled order with its placement latency and the resulting order ID
+// @Tags         futures
+// @Produce      json
+// @Param        id   path      string  true  "Scheduled order ID"
+// @Success      200  {object}  models.ScheduledOrder
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/scheduled/{id} [get]
+func (h *Handlers) GetScheduledOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := h.tradingService.GetScheduledOrder(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, scheduledErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// CancelScheduledOrder handles DELETE /api/futures/scheduled/{id}
+// @Summary      Cancel a scheduled order
+// @Description  Cancel a pending scheduled order
+// @Tags         futures
+// @Produce      json
+// @Param        id   path      string  true  "Scheduled order ID"
+// @Success      200  {object}  models.ScheduledOrder
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      409  {object}  handlers.ErrorResponse  "No longer pending"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/scheduled/{id} [delete]
+func (h *Handlers) CancelScheduledOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := h.tradingService.CancelScheduledOrder(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, scheduledErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// scheduledErrorStatus maps scheduled order errors to HTTP status codes
+func scheduledErrorStatus(err error) int {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr), errors.Is(err, services.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrRiskOverrideForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrScheduleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrScheduleNotPending):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
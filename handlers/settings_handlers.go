@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// settingsErrorStatus maps symbol settings and margin type errors to HTTP status codes; Binance
+// rejections are mapped by writeServiceError
+func settingsErrorStatus(err error) int {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrSymbolSettingsNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrPaperUnsupported):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListSymbolSettings handles GET /api/settings/symbols
+// @Summary      List symbol settings
+// @Description  List the order defaults and margin type stored per USDⓈ-M symbol
+// @Tags         settings
+// @Produce      json
+// @Success      200  {array}   models.SymbolSettings
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/settings/symbols [get]
+func (h *Handlers) ListSymbolSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.tradingService.ListSymbolSettings(r.Context())
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// GetSymbolSettings handles GET /api/settings/symbols/{symbol}
+// @Summary      Get symbol settings
+// @Description  Get a symbol's order defaults and margin type
+// @Tags         settings
+// @Produce      json
+// @Param        symbol  path      string  true  "Symbol, e.g. BTCUSDT"
+// @Success      200     {object}  models.SymbolSettings
+// @Failure      404     {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/settings/symbols/{symbol} [get]
+func (h *Handlers) GetSymbolSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.tradingService.GetSymbolSettings(r.Context(), mux.Vars(r)["symbol"])
+	if err != nil {
+		writeServiceError(w, settingsErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// SetSymbolSettings handles PUT /api/settings/symbols/{symbol}
+// @Summary      Set symbol settings
+// @Description  Create or replace a symbol's defaults. A leverage above the symbol's leverage brackets is rejected before anything is applied. The margin type and then the leverage are applied on Binance for the request's account; if Binance refuses either (e.g. a margin type change with a position open), the settings are not saved.
+// @Description  Orders on the symbol then take the leverage, position side, working type (trigger orders) and time in force (LIMIT orders) they leave empty from the settings, and list them in applied_defaults.
+// @Description  POST /api/futures/leverage and /api/futures/margin-type update stored settings to what they applied.
+// @Tags         settings
+// @Accept       json
+// @Produce      json
+// @Param        symbol    path      string                         true  "Symbol, e.g. BTCUSDT"
+// @Param        settings  body      services.SymbolSettingsRequest  true  "Defaults"
+// @Success      200       {object}  models.SymbolSettings
+// @Failure      400       {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      409       {object}  handlers.ErrorResponse  "Binance refused the margin type change"
+// @Failure      500       {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501       {object}  handlers.ErrorResponse  "Isolated margin in paper trading mode"
+// @Router       /api/settings/symbols/{symbol} [put]
+func (h *Handlers) SetSymbolSettings(w http.ResponseWriter, r *http.Request) {
+	var req services.SymbolSettingsRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	settings, err := h.tradingService.SetSymbolSettings(r.Context(), mux.Vars(r)["symbol"], &req)
+	if err != nil {
+		writeServiceError(w, settingsErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// DeleteSymbolSettings handles DELETE /api/settings/symbols/{symbol}
+// @Summary      Delete symbol settings
+// @Description  Remove a symbol's defaults; its leverage and margin type on Binance are left as they are
+// @Tags         settings
+// @Produce      json
+// @Param        symbol  path      string  true  "Symbol, e.g. BTCUSDT"
+// @Success      200     {object}  map[string]string
+// @Failure      404     {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/settings/symbols/{symbol} [delete]
+func (h *Handlers) DeleteSymbolSettings(w http.ResponseWriter, r *http.Request) {
+	if err := h.tradingService.DeleteSymbolSettings(r.Context(), mux.Vars(r)["symbol"]); err != nil {
+		writeServiceError(w, settingsErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Symbol settings deleted successfully"})
+}
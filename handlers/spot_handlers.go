@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateSpotOrder handles POST /api/spot/order
+// @Summary      Create a spot order
+// @Description  Place a spot MARKET or LIMIT order for hedging or treasury moves. Quantity is rounded down to the symbol's lot step and price to its tick; orders below the minimum quantity or notional are rejected. Market orders may be sized in the quote asset with quote_quantity instead of quantity.
+// @Tags         spot
+// @Accept       json
+// @Produce      json
+// @Param        order  body      services.CreateSpotOrderRequest  true  "Spot order"
+// @Success      201    {object}  models.SpotOrder
+// @Success      200    {object}  models.SpotOrder  "client_order_id already placed: the stored order, with replayed set"
+// @Header       201    {string}  Location  "GET URL of the order"
+// @Failure      400    {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500    {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501    {object}  handlers.ErrorResponse  "Spot is not available in paper trading mode"
+// @Router       /api/spot/order [post]
+func (h *Handlers) CreateSpotOrder(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateSpotOrderRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	order, err := h.tradingService.CreateSpotOrder(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, spotErrorStatus(err), err)
+		return
+	}
+
+	writeCreated(w, "/api/spot/orders/"+order.ID.Hex(), order.Replayed, order)
+}
+
+// GetSpotOrder handles GET /api/spot/orders/{id}
+// @Summary      Get a spot order
+// @Description  Get a stored spot order by its id, or by its Binance order ID when the value is numeric. include_raw adds the raw Binance response and include_journal every journal entry on the order. Unknown and malformed IDs return 404.
+// @Tags         spot
+// @Produce      json
+// @Param        id               path      string  true   "Order ID or Binance order ID"
+// @Param        include_raw      query     bool    false  "Include the raw Binance response"
+// @Param        include_journal  query     bool    false  "Include the order's journal entries"
+// @Success      200  {object}  models.SpotOrder
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/spot/orders/{id} [get]
+func (h *Handlers) GetSpotOrder(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseOrderDetailOptions(r)
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	order, err := h.tradingService.GetSpotOrder(r.Context(), mux.Vars(r)["id"], opts)
+	if err != nil {
+		writeServiceError(w, orderLookupErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// GetSpotOrders handles GET /api/spot/orders
+// @Summary      Get spot orders
+// @Description  Retrieve a page of spot orders with optional filters, sorted by creation time
+// @Tags         spot
+// @Produce      json
+// @Param        symbol      query     string  false  "Filter by symbol (e.g., BTCUSDT)"
+// @Param        status      query     string  false  "Filter by order status (e.g., NEW, FILLED)"
+// @Param        side        query     string  false  "Filter by side (BUY or SELL)"
+// @Param        start_time  query     string  false  "Created at or after (RFC3339 or Unix ms)"
+// @Param        end_time    query     string  false  "Created at or before (RFC3339 or Unix ms)"
+// @Param        sort        query     string  false  "Sort by created_at: asc or desc (default desc)"
+// @Param        limit       query     int     false  "Page size (default 100, max 1000)"
+// @Param        offset      query     int     false  "Number of orders to skip (ignored when before_id is set)"
+// @Param        before_id   query     string  false  "Cursor: next_cursor from the previous page"
+// @Param        include_raw query     bool    false  "Include the raw Binance response stored with each order"
+// @Param        include_journal query bool  false  "Include each order's latest journal entry"
+// @Success      200         {object}  services.SpotOrderPage
+// @Failure      400         {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500         {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/spot/orders [get]
+func (h *Handlers) GetSpotOrders(w http.ResponseWriter, r *http.Request) {
+	query, err := parseOrderQuery(r)
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	orders, err := h.tradingService.GetSpotOrders(r.Context(), query)
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orders)
+}
+
+// GetSpotBalances handles GET /api/spot/balances
+// @Summary      Get spot balances
+// @Description  Get the free and locked spot wallet balance of every asset that is not empty
+// @Tags         spot
+// @Produce      json
+// @Success      200  {array}   models.SpotBalance
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501  {object}  handlers.ErrorResponse  "Spot is not available in paper trading mode"
+// @Router       /api/spot/balances [get]
+func (h *Handlers) GetSpotBalances(w http.ResponseWriter, r *http.Request) {
+	balances, err := h.tradingService.GetSpotBalances(r.Context())
+	if err != nil {
+		writeServiceError(w, spotErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balances)
+}
+
+func spotErrorStatus(err error) int {
+	var validationErr *services.ValidationError
+	if errors.As(err, &validationErr) {
+		return http.StatusBadRequest
+	}
+	return paperErrorStatus(err)
+}
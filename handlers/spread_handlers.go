@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateCalendarSpread handles POST /api/futures/spread
+// @Summary      Open a calendar spread
+// @Description  Place both legs of a basis trade in one batch: LONG_BASIS buys far_symbol and sells near_symbol, SHORT_BASIS the reverse. The near leg trades quantity and the far leg the same notional at the current mark prices, each rounded to its lot size. price_mode MARKET sends market orders; MARK sends GTC limits at each leg's mark price. Both orders are tagged with the spread's ID (their strategy_id). If a leg fails, or both have not filled within fill_timeout_seconds (default 30), the open remainder is canceled and the spread is BROKEN; auto_unwind then closes the filled portion, otherwise POST /api/futures/spread/{id}/unwind does.
+// @Tags         futures
+// @Accept       json
+// @Produce      json
+// @Param        request  body      services.CreateSpreadRequest  true  "Calendar spread"
+// @Success      201      {object}  models.CalendarSpread
+// @Header       201      {string}  Location  "GET URL of the spread"
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      409      {object}  handlers.ErrorResponse  "A leg's symbol is not open for trading"
+// @Failure      422      {object}  handlers.ErrorResponse  "Risk limit exceeded"
+// @Failure      423      {object}  handlers.ErrorResponse  "Trading is paused"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/spread [post]
+func (h *Handlers) CreateCalendarSpread(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateSpreadRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	spread, err := h.tradingService.CreateCalendarSpread(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, spreadErrorStatus(err), err)
+		return
+	}
+
+	writeCreated(w, "/api/futures/spread/"+spread.ID.Hex(), false, spread)
+}
+
+// GetCalendarSpread handles GET /api/futures/spread/{id}
+// @Summary      Get a calendar spread
+// @Description  Get a spread's legs with their fills, its status and entry basis (far minus near average fill price), and at the current mark prices the basis and the PnL of the filled legs, before fees and funding
+// @Tags         futures
+// @Produce      json
+// @Param        id   path      string  true  "Calendar spread ID"
+// @Success      200  {object}  models.CalendarSpread
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/spread/{id} [get]
+func (h *Handlers) GetCalendarSpread(w http.ResponseWriter, r *http.Request) {
+	spread, err := h.tradingService.GetCalendarSpread(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, spreadErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(spread)
+}
+
+// UnwindCalendarSpread handles POST /api/futures/spread/{id}/unwind
+// @Summary      Unwind a calendar spread
+// @Description  Close the filled quantity of each leg of a BROKEN or OPEN spread with market orders placed in one batch
+// @Tags         futures
+// @Produce      json
+// @Param        id   path      string  true  "Calendar spread ID"
+// @Success      200  {object}  models.CalendarSpread
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      409  {object}  handlers.ErrorResponse  "Pending, failed or already unwound"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/futures/spread/{id}/unwind [post]
+func (h *Handlers) UnwindCalendarSpread(w http.ResponseWriter, r *http.Request) {
+	spread, err := h.tradingService.UnwindCalendarSpread(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, spreadErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(spread)
+}
+
+// spreadErrorStatus maps calendar spread errors, including rejected legs, to HTTP status codes
+func spreadErrorStatus(err error) int {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr), errors.Is(err, services.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrSpreadNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrSpreadState):
+		return http.StatusConflict
+	default:
+		return riskErrorStatus(err)
+	}
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// logCapture records the status of every request loggingMiddleware logs
+type logCapture struct {
+	mu       sync.Mutex
+	statuses []int64
+}
+
+func (c *logCapture) Enabled(context.Context, slog.Level) bool { return true }
+func (c *logCapture) WithAttrs([]slog.Attr) slog.Handler       { return c }
+func (c *logCapture) WithGroup(string) slog.Handler            { return c }
+
+func (c *logCapture) Handle(_ context.Context, r slog.Record) error {
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "status" {
+			c.mu.Lock()
+			c.statuses = append(c.statuses, a.Value.Int64())
+			c.mu.Unlock()
+		}
+		return true
+	})
+	return nil
+}
+
+// waitStatus returns the first logged status, waiting for the request to finish
+func (c *logCapture) waitStatus(t *testing.T) int64 {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		if len(c.statuses) > 0 {
+			status := c.statuses[0]
+			c.mu.Unlock()
+			return status
+		}
+		c.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("request was not logged")
+	return 0
+}
+
+// captureRequestLog routes the default logger to a logCapture for the test
+func captureRequestLog(t *testing.T) *logCapture {
+	capture := &logCapture{}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(capture))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return capture
+}
+
+// middlewareServer serves handler behind the gzip and logging middleware, in router order
+func middlewareServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	server := httptest.NewServer(gzipMiddleware(loggingMiddleware(handler)))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestStatusRecorderWebSocketUpgrade(t *testing.T) {
+	logs := captureRequestLog(t)
+	upgrader := websocket.Upgrader{}
+	server := middlewareServer(t, func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return // Upgrade already wrote the error response
+		}
+		defer conn.Close()
+		messageType, msg, err := conn.ReadMessage()
+		if err == nil {
+			conn.WriteMessage(messageType, msg)
+		}
+	})
+
+	header := http.Header{"Accept-Encoding": {"gzip"}}
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), header)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("handshake status %d", resp.StatusCode)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+	if _, msg, err := conn.ReadMessage(); err != nil || string(msg) != "ping" {
+		t.Fatalf("echo = %q, %v", msg, err)
+	}
+	conn.Close()
+
+	if status := logs.waitStatus(t); status != http.StatusSwitchingProtocols {
+		t.Errorf("logged status %d, want 101", status)
+	}
+}
+
+func TestStatusRecorderServerSentEvents(t *testing.T) {
+	logs := captureRequestLog(t)
+	firstRead := make(chan struct{})
+	server := middlewareServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		rc := http.NewResponseController(w)
+		w.Write([]byte("data: first\n\n"))
+		if err := rc.Flush(); err != nil {
+			t.Errorf("Flush: %v", err)
+			return
+		}
+		// The second event is only sent once the client saw the first, so it must have been flushed
+		select {
+		case <-firstRead:
+		case <-time.After(2 * time.Second):
+			t.Error("client did not receive the flushed event")
+		}
+		w.Write([]byte("data: second\n\n"))
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	if enc := resp.Header.Get("Content-Encoding"); enc != "" {
+		t.Errorf("event stream sent with Content-Encoding %q", enc)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil || line != "data: first\n" {
+		t.Fatalf("first line = %q, %v", line, err)
+	}
+	close(firstRead)
+	rest := new(bytes.Buffer)
+	rest.ReadFrom(reader)
+	if !strings.Contains(rest.String(), "data: second") {
+		t.Errorf("rest of stream = %q", rest.String())
+	}
+
+	if status := logs.waitStatus(t); status != http.StatusOK {
+		t.Errorf("logged status %d, want 200", status)
+	}
+}
+
+// pushRecorder is a ResponseWriter that supports server push
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (p *pushRecorder) Push(target string, opts *http.PushOptions) error {
+	p.pushed = append(p.pushed, target)
+	return nil
+}
+
+func TestStatusRecorderPush(t *testing.T) {
+	pusher := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	rec := &statusRecorder{ResponseWriter: pusher}
+	if err := rec.Push("/static/app.js", nil); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if len(pusher.pushed) != 1 || pusher.pushed[0] != "/static/app.js" {
+		t.Errorf("pushed %q", pusher.pushed)
+	}
+
+	// HTTP/1.1 writers cannot push
+	plain := &statusRecorder{ResponseWriter: httptest.NewRecorder()}
+	if err := plain.Push("/static/app.js", nil); err != http.ErrNotSupported {
+		t.Errorf("Push on HTTP/1.1 = %v, want ErrNotSupported", err)
+	}
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// decimalKeys are the JSON fields holding a price or quantity, the only numbers string_numbers
+// rewrites. A number in an array is matched by the key of the array.
+var decimalKeys = map[string]bool{
+	// Prices
+	"price": true, "stop_price": true, "activation_price": true, "trigger_price": true,
+	"triggered_price": true, "avg_price": true, "avg_fill_price": true, "avg_entry_price": true,
+	"entry_price": true, "mark_price": true, "mark": true, "last_price": true, "last_fill_price": true,
+	"current_price": true, "start_price": true, "strike_price": true, "take_profit_price": true,
+	"liquidation_price": true, "margin_call_price": true, "lower_price": true, "upper_price": true,
+	"open": true, "high": true, "low": true, "close": true, "tick_size": true,
+	// Quantities
+	"quantity": true, "quote_quantity": true, "executed_qty": true, "executed_quantity": true,
+	"filled_qty": true, "filled_quantity": true, "closed_quantity": true, "order_quantity": true,
+	"take_profit_quantity": true, "position_amount": true, "volume": true, "min_qty": true,
+	"max_qty": true, "max_quantity": true, "market_min_qty": true, "market_max_qty": true,
+	"step_size": true, "market_step_size": true,
+}
+
+// stringNumbersMiddleware rewrites the prices and quantities of a JSON response (decimalKeys) as
+// decimal strings in plain notation ("0.00000001", not 1e-08) when the request has
+// ?string_numbers=true, so clients can parse them without going through a float. Other numbers,
+// such as IDs, counts and timestamps, stay numbers. Other responses, and JSON that a handler
+// flushes while streaming, are passed through as they are.
+func stringNumbersMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enabled, err := parseBoolParam(r.URL.Query().Get("string_numbers"), "string_numbers")
+		if err != nil {
+			writeServiceError(w, http.StatusBadRequest, err)
+			return
+		}
+		if !enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		sw := &stringNumbersWriter{ResponseWriter: w}
+		defer sw.close()
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// stringNumbersWriter holds a JSON body until the handler returns; it decides on the first write
+// by the Content-Type the handler set
+type stringNumbersWriter struct {
+	http.ResponseWriter
+	status      int
+	decided     bool
+	passthrough bool
+	buf         bytes.Buffer
+}
+
+func (w *stringNumbersWriter) WriteHeader(code int) {
+	if w.decided && w.passthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *stringNumbersWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		w.passthrough = !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+		if w.passthrough && w.status != 0 {
+			w.ResponseWriter.WriteHeader(w.status)
+		}
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+// Flush gives up on rewriting: what was held is sent unchanged and the rest passes through
+func (w *stringNumbersWriter) Flush() {
+	w.send(w.buf.Bytes())
+	w.buf.Reset()
+	w.decided, w.passthrough = true, true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController
+func (w *stringNumbersWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close sends the held body with its numbers rewritten; a body that is not valid JSON is sent
+// unchanged
+func (w *stringNumbersWriter) close() {
+	if w.passthrough {
+		return
+	}
+	body := w.buf.Bytes()
+	if json.Valid(body) {
+		body = stringifyNumbers(body)
+		w.Header().Del("Content-Length")
+	}
+	w.send(body)
+}
+
+// send writes the held status, if any, and body to the underlying writer
+func (w *stringNumbersWriter) send(body []byte) {
+	if w.passthrough {
+		return
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if len(body) > 0 {
+		w.ResponseWriter.Write(body)
+	}
+}
+
+// jsonScope is an object or array that stringifyNumbers is inside of. key is the object's current
+// key, or for an array the key the array is the value of.
+type jsonScope struct {
+	object bool
+	key    string
+}
+
+// stringifyNumbers quotes the numbers of the valid JSON document data whose key is in decimalKeys,
+// keeping everything else, including key order and whitespace, as it is
+func stringifyNumbers(data []byte) []byte {
+	out := make([]byte, 0, len(data)+len(data)/8)
+	var scopes []jsonScope
+	expectKey := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for ; data[end] != '"'; end++ {
+				if data[end] == '\\' {
+					end++
+				}
+			}
+			if expectKey {
+				var key string
+				json.Unmarshal(data[i:end+1], &key)
+				scopes[len(scopes)-1].key = key
+				expectKey = false
+			}
+			out = append(out, data[i:end+1]...)
+			i = end
+		case c == '{' || c == '[':
+			scope := jsonScope{object: c == '{'}
+			if len(scopes) > 0 {
+				scope.key = scopes[len(scopes)-1].key
+			}
+			scopes = append(scopes, scope)
+			expectKey = scope.object
+			out = append(out, c)
+		case c == '}' || c == ']':
+			scopes = scopes[:len(scopes)-1]
+			expectKey = false
+			out = append(out, c)
+		case c == ',':
+			expectKey = len(scopes) > 0 && scopes[len(scopes)-1].object
+			out = append(out, c)
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(data) && strings.IndexByte("0123456789.eE+-", data[end]) >= 0 {
+				end++
+			}
+			literal := string(data[i:end])
+			if len(scopes) > 0 && decimalKeys[scopes[len(scopes)-1].key] {
+				if d, err := decimal.NewFromString(literal); err == nil {
+					literal = d.String()
+				}
+				literal = `"` + literal + `"`
+			}
+			out = append(out, literal...)
+			i = end - 1
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
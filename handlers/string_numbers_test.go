@@ -0,0 +1,26 @@
+package handlers
+
+import "testing"
+
+func TestStringifyNumbers(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"price and quantity", `{"price":1e-08,"quantity":0.5}`, `{"price":"0.00000001","quantity":"0.5"}`},
+		{"ids and counts stay numbers", `{"binance_order_id":123456789,"leverage":10,"price":100}`, `{"binance_order_id":123456789,"leverage":10,"price":"100"}`},
+		{"nested objects and arrays", `{"orders":[{"id":1,"stop_price":2.5}],"total":1}`, `{"orders":[{"id":1,"stop_price":"2.5"}],"total":1}`},
+		{"array of prices", `{"close":[1.5, 2],"count":2}`, `{"close":["1.5", "2"],"count":2}`},
+		{"key after nested value", `{"fill":{"price":3},"created":1700000000000}`, `{"fill":{"price":"3"},"created":1700000000000}`},
+		{"strings with escapes", `{"note":"a \"price\": 1","price":-0.1}`, `{"note":"a \"price\": 1","price":"-0.1"}`},
+		{"top level number", `42`, `42`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(stringifyNumbers([]byte(tt.in))); got != tt.want {
+				t.Errorf("stringifyNumbers(%s) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// GetTradingPolicy handles GET /api/risk/symbols
+// @Summary      Get the symbol policy
+// @Description  Get the symbol whitelist or blacklist applied to new orders; mode is off when none was set
+// @Tags         risk
+// @Produce      json
+// @Success      200  {object}  models.TradingPolicy
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/risk/symbols [get]
+func (h *Handlers) GetTradingPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.tradingService.GetTradingPolicy(r.Context())
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// SetTradingPolicy handles PUT /api/risk/symbols
+// @Summary      Set the symbol policy
+// @Description  Replace the symbol policy. In whitelist mode only the listed symbols can be traded; in blacklist
+// @Description  mode the listed symbols cannot. Reduce-only and close-position orders are always allowed.
+// @Tags         risk
+// @Accept       json
+// @Produce      json
+// @Param        policy  body      services.TradingPolicyRequest  true  "Mode and symbols"
+// @Success      200     {object}  models.TradingPolicy
+// @Failure      400     {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/risk/symbols [put]
+func (h *Handlers) SetTradingPolicy(w http.ResponseWriter, r *http.Request) {
+	var req services.TradingPolicyRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	policy, err := h.tradingService.SetTradingPolicy(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// AddPolicySymbols handles POST /api/risk/symbols
+// @Summary      Add symbols to the policy
+// @Description  Add symbols to the whitelist or blacklist, keeping the current mode
+// @Tags         risk
+// @Accept       json
+// @Produce      json
+// @Param        symbols  body      services.PolicySymbolsRequest  true  "Symbols to add"
+// @Success      200      {object}  models.TradingPolicy
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/risk/symbols [post]
+func (h *Handlers) AddPolicySymbols(w http.ResponseWriter, r *http.Request) {
+	var req services.PolicySymbolsRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	policy, err := h.tradingService.AddPolicySymbols(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// RemovePolicySymbol handles DELETE /api/risk/symbols/{symbol}
+// @Summary      Remove a symbol from the policy
+// @Description  Remove a symbol from the whitelist or blacklist, keeping the current mode
+// @Tags         risk
+// @Produce      json
+// @Param        symbol  path      string  true  "Symbol"
+// @Success      200     {object}  map[string]string
+// @Failure      404     {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/risk/symbols/{symbol} [delete]
+func (h *Handlers) RemovePolicySymbol(w http.ResponseWriter, r *http.Request) {
+	if err := h.tradingService.RemovePolicySymbol(r.Context(), mux.Vars(r)["symbol"]); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrPolicySymbolNotFound) {
+			status = http.StatusNotFound
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Symbol removed from trading policy"})
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateOrderTemplate handles POST /api/templates
+// @Summary      Create an order template
+// @Description  Save a named advanced futures order skeleton. Quantity, price, stop price and callback rate may be left 0 as placeholders to be supplied when the template is executed. USDⓈ-M only; client_order_id is set per execution.
+// @Tags         templates
+// @Accept       json
+// @Produce      json
+// @Param        template  body      services.OrderTemplateRequest  true  "Name and order"
+// @Success      201       {object}  models.NamedOrderTemplate
+// @Header       201       {string}  Location  "GET URL of the template"
+// @Failure      400       {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      403       {object}  handlers.ErrorResponse  "Risk limit override not allowed for this token"
+// @Failure      409       {object}  handlers.ErrorResponse  "Name already in use"
+// @Failure      500       {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/templates [post]
+func (h *Handlers) CreateOrderTemplate(w http.ResponseWriter, r *http.Request) {
+	var req services.OrderTemplateRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	template, err := h.tradingService.CreateOrderTemplate(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, templateErrorStatus(err), err)
+		return
+	}
+
+	writeCreated(w, "/api/templates/"+url.PathEscape(template.Name), false, template)
+}
+
+// ListOrderTemplates handles GET /api/templates
+// @Summary      List order templates
+// @Description  List the saved order templates by name
+// @Tags         templates
+// @Produce      json
+// @Success      200  {array}   models.NamedOrderTemplate
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/templates [get]
+func (h *Handlers) ListOrderTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.tradingService.ListOrderTemplates(r.Context())
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// GetOrderTemplate handles GET /api/templates/{name}
+// @Summary      Get an order template
+// @Description  Get a saved order template
+// @Tags         templates
+// @Produce      json
+// @Param        name  path      string  true  "Template name"
+// @Success      200   {object}  models.NamedOrderTemplate
+// @Failure      404   {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500   {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/templates/{name} [get]
+func (h *Handlers) GetOrderTemplate(w http.ResponseWriter, r *http.Request) {
+	template, err := h.tradingService.GetOrderTemplate(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		writeServiceError(w, templateErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+// UpdateOrderTemplate handles PUT /api/templates/{name}
+// @Summary      Update an order template
+// @Description  Replace the order of a saved template; the name cannot change
+// @Tags         templates
+// @Accept       json
+// @Produce      json
+// @Param        name      path      string                         true  "Template name"
+// @Param        template  body      services.OrderTemplateRequest  true  "Order"
+// @Success      200       {object}  models.NamedOrderTemplate
+// @Failure      400       {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      403       {object}  handlers.ErrorResponse  "Risk limit override not allowed for this token"
+// @Failure      404       {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500       {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/templates/{name} [put]
+func (h *Handlers) UpdateOrderTemplate(w http.ResponseWriter, r *http.Request) {
+	var req services.OrderTemplateRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	template, err := h.tradingService.UpdateOrderTemplate(r.Context(), mux.Vars(r)["name"], &req)
+	if err != nil {
+		writeServiceError(w, templateErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+// DeleteOrderTemplate handles DELETE /api/templates/{name}
+// @Summary      Delete an order template
+// @Description  Remove a saved template; orders placed from it keep its name
+// @Tags         templates
+// @Produce      json
+// @Param        name  path      string  true  "Template name"
+// @Success      200   {object}  map[string]string
+// @Failure      404   {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500   {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/templates/{name} [delete]
+func (h *Handlers) DeleteOrderTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := h.tradingService.DeleteOrderTemplate(r.Context(), mux.Vars(r)["name"]); err != nil {
+		writeServiceError(w, templateErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Order template deleted successfully"})
+}
+
+// ExecuteOrderTemplate handles POST /api/templates/{name}/execute
+// @Summary      Execute an order template
+// @Description  Merge the supplied values into the template, validate the result and place it as an advanced futures order. The stored order records the template name.
+// @Tags         templates
+// @Accept       json
+// @Produce      json
+// @Param        name       path      string                           true  "Template name"
+// @Param        overrides  body      services.ExecuteTemplateRequest  true  "Values replacing the template's"
+// @Success      201        {object}  models.FuturesOrder
+// @Success      200        {object}  models.FuturesOrder  "client_order_id already placed: the stored order, with replayed set"
+// @Header       201        {string}  Location  "GET URL of the order"
+// @Failure      400        {object}  handlers.ErrorResponse  "Bad Request or rejected by Binance"
+// @Failure      403        {object}  handlers.ErrorResponse  "Risk limit override not allowed for this token"
+// @Failure      404        {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      422        {object}  handlers.ErrorResponse  "Risk limit exceeded"
+// @Failure      500        {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/templates/{name}/execute [post]
+func (h *Handlers) ExecuteOrderTemplate(w http.ResponseWriter, r *http.Request) {
+	var req services.ExecuteTemplateRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	order, err := h.tradingService.ExecuteOrderTemplate(r.Context(), mux.Vars(r)["name"], &req)
+	if err != nil {
+		status := templateErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			status = riskErrorStatus(err)
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	writeCreated(w, "/api/futures/orders/"+order.ID.Hex(), order.Replayed, order)
+}
+
+// templateErrorStatus maps order template errors to HTTP status codes
+func templateErrorStatus(err error) int {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrRiskOverrideForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrTemplateNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrTemplateExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"futures-options/services"
+)
+
+// GetTradingPause handles GET /api/admin/trading-pause
+// @Summary      Get the trading pause
+// @Description  Show whether trading is paused, the reason, until when and who paused or resumed it
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  models.TradingPause
+// @Router       /api/admin/trading-pause [get]
+func (h *Handlers) GetTradingPause(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.tradingService.GetTradingPause(r.Context()))
+}
+
+// PauseTrading handles POST /api/admin/trading-pause
+// @Summary      Pause trading
+// @Description  Reject orders that may add exposure with 423 and pause the DCA and grid runners until the pause is
+// @Description  deleted or until the optional end time. Cancels and reduce-only or close-position orders are still
+// @Description  allowed. The pause is saved, so it survives a restart.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        pause  body      services.TradingPauseRequest  false  "Optional end time and reason"
+// @Success      200    {object}  models.TradingPause
+// @Failure      400    {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500    {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/admin/trading-pause [post]
+func (h *Handlers) PauseTrading(w http.ResponseWriter, r *http.Request) {
+	var req services.TradingPauseRequest
+	// Both fields are optional, so an empty body pauses until resumed
+	if r.ContentLength != 0 && !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	pause, err := h.tradingService.PauseTrading(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pause)
+}
+
+// ResumeTrading handles DELETE /api/admin/trading-pause
+// @Summary      Resume trading
+// @Description  End the trading pause; does nothing when trading is not paused
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  models.TradingPause
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/admin/trading-pause [delete]
+func (h *Handlers) ResumeTrading(w http.ResponseWriter, r *http.Request) {
+	pause, err := h.tradingService.ResumeTrading(r.Context())
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pause)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/binance"
+	"futures-options/services"
+)
+
+// TransferFunds handles POST /api/account/transfer
+// @Summary      Transfer between wallets
+// @Description  Move an asset between the spot wallet and the USDⓈ-M (FUTURES) or COIN-M futures wallet with a universal transfer. The amount is checked against the source wallet's available balance first, and the transfer is stored with its Binance tran_id.
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Param        request  body      services.TransferRequest  true  "Transfer"
+// @Success      201      {object}  models.Transfer
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501      {object}  handlers.ErrorResponse  "Not available on the testnet or in paper trading mode"
+// @Router       /api/account/transfer [post]
+func (h *Handlers) TransferFunds(w http.ResponseWriter, r *http.Request) {
+	var req services.TransferRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	transfer, err := h.tradingService.TransferFunds(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, transferErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// ListTransfers handles GET /api/account/transfers
+// @Summary      List wallet transfers
+// @Description  List up to 100 transfers per direction from the Binance history, newest first. Without start_time and end_time Binance returns the last 7 days. Stored transfers get their status updated from the history.
+// @Tags         account
+// @Produce      json
+// @Param        direction   query     string  false  "SPOT_TO_FUTURES, FUTURES_TO_SPOT, SPOT_TO_COINM or COINM_TO_SPOT (default all)"
+// @Param        start_time  query     string  false  "At or after (RFC3339 or Unix ms)"
+// @Param        end_time    query     string  false  "At or before (RFC3339 or Unix ms)"
+// @Success      200         {array}   services.TransferHistoryEntry
+// @Failure      400         {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500         {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Failure      501         {object}  handlers.ErrorResponse  "Not available on the testnet or in paper trading mode"
+// @Router       /api/account/transfers [get]
+func (h *Handlers) ListTransfers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	start, err := parseTimeParam(q.Get("start_time"), "start_time")
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	end, err := parseTimeParam(q.Get("end_time"), "end_time")
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	transfers, err := h.tradingService.ListTransfers(r.Context(), q.Get("direction"), start, end)
+	if err != nil {
+		writeServiceError(w, transferErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfers)
+}
+
+func transferErrorStatus(err error) int {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusBadRequest
+	case errors.Is(err, binance.ErrTestnetUnsupported):
+		return http.StatusNotImplemented
+	default:
+		return paperErrorStatus(err)
+	}
+}
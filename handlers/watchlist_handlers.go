@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// AddToWatchlist handles POST /api/watchlist
+// @Summary      Watch a symbol
+// @Description  Add a USDⓈ-M symbol to the watchlist; its mark price, funding rate and 24h ticker are streamed from then on. Adding a watched symbol again returns it with 200.
+// @Tags         watchlist
+// @Accept       json
+// @Produce      json
+// @Param        request  body      services.WatchlistRequest  true  "Symbol"
+// @Success      201      {object}  models.WatchlistEntry
+// @Success      200      {object}  models.WatchlistEntry  "Already watched"
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      409      {object}  handlers.ErrorResponse  "Watchlist full"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/watchlist [post]
+func (h *Handlers) AddToWatchlist(w http.ResponseWriter, r *http.Request) {
+	var req services.WatchlistRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	entry, added, err := h.tradingService.AddToWatchlist(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, watchlistErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if added {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(entry)
+}
+
+// GetWatchlist handles GET /api/watchlist
+// @Summary      Get the watchlist
+// @Description  List the watched symbols with last price, 24h change, mark price and funding rate from the watchlist stream, and whether each has a stored open position or open orders
+// @Tags         watchlist
+// @Produce      json
+// @Success      200  {array}   services.WatchlistItem
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/watchlist [get]
+func (h *Handlers) GetWatchlist(w http.ResponseWriter, r *http.Request) {
+	items, err := h.tradingService.GetWatchlist(r.Context())
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// RemoveFromWatchlist handles DELETE /api/watchlist/{symbol}
+// @Summary      Stop watching a symbol
+// @Description  Remove a symbol from the watchlist and unsubscribe its streams
+// @Tags         watchlist
+// @Produce      json
+// @Param        symbol  path      string  true  "Symbol"
+// @Success      200     {object}  map[string]string
+// @Failure      404     {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500     {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/watchlist/{symbol} [delete]
+func (h *Handlers) RemoveFromWatchlist(w http.ResponseWriter, r *http.Request) {
+	if err := h.tradingService.RemoveFromWatchlist(r.Context(), mux.Vars(r)["symbol"]); err != nil {
+		writeServiceError(w, watchlistErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Symbol removed from watchlist"})
+}
+
+// watchlistErrorStatus maps watchlist errors to HTTP status codes
+func watchlistErrorStatus(err error) int {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrWatchlistSymbolNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrWatchlistFull):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"futures-options/services"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateWebhook handles POST /api/notifications/webhooks
+// @Summary      Register a webhook
+// @Description  Register a URL that receives signed JSON events (ORDER_FILLED, ORDER_CANCELED, ORDER_REJECTED, POSITION_LIQUIDATED, MARGIN_CALL, DAILY_LOSS_LIMIT, STREAM_DOWN, KILL_SWITCH, TEST). Omit event_types to receive every event. The signing secret is generated when omitted and only returned in this response.
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Param        webhook  body      services.CreateWebhookRequest  true  "Webhook"
+// @Success      201      {object}  services.CreatedWebhook
+// @Failure      400      {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500      {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/notifications/webhooks [post]
+func (h *Handlers) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateWebhookRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	webhook, err := h.tradingService.CreateWebhook(r.Context(), &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			status = http.StatusBadRequest
+		}
+		writeServiceError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// ListWebhooks handles GET /api/notifications/webhooks
+// @Summary      List webhooks
+// @Description  List registered webhooks (secrets are never returned)
+// @Tags         notifications
+// @Produce      json
+// @Success      200  {array}   models.Webhook
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/notifications/webhooks [get]
+func (h *Handlers) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.tradingService.ListWebhooks(r.Context())
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+// DeleteWebhook handles DELETE /api/notifications/webhooks/{id}
+// @Summary      Delete a webhook
+// @Description  Stop delivering events to a webhook
+// @Tags         notifications
+// @Produce      json
+// @Param        id   path      string  true  "Webhook ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/notifications/webhooks/{id} [delete]
+func (h *Handlers) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	err := h.tradingService.DeleteWebhook(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, webhookErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Webhook deleted successfully"})
+}
+
+// TestWebhook handles POST /api/notifications/webhooks/{id}/test
+// @Summary      Send a test event
+// @Description  Deliver a signed TEST event to the webhook once, without retries, and report the response status
+// @Tags         notifications
+// @Produce      json
+// @Param        id   path      string  true  "Webhook ID"
+// @Success      200  {object}  services.WebhookTestResult
+// @Failure      400  {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      404  {object}  handlers.ErrorResponse  "Not Found"
+// @Failure      500  {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/notifications/webhooks/{id}/test [post]
+func (h *Handlers) TestWebhook(w http.ResponseWriter, r *http.Request) {
+	result, err := h.tradingService.TestWebhook(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeServiceError(w, webhookErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ListWebhookDeadLetters handles GET /api/notifications/webhooks/dead-letters
+// @Summary      List undelivered webhook events
+// @Description  List the most recent events that failed every delivery attempt, newest first
+// @Tags         notifications
+// @Produce      json
+// @Param        limit  query     int  false  "Maximum entries (default 100)"
+// @Success      200    {array}   models.WebhookDeadLetter
+// @Failure      400    {object}  handlers.ErrorResponse  "Bad Request"
+// @Failure      500    {object}  handlers.ErrorResponse  "Internal Server Error"
+// @Router       /api/notifications/webhooks/dead-letters [get]
+func (h *Handlers) ListWebhookDeadLetters(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseNonNegativeInt(r.URL.Query().Get("limit"), "limit")
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	letters, err := h.tradingService.ListWebhookDeadLetters(r.Context(), limit)
+	if err != nil {
+		writeServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(letters)
+}
+
+// webhookErrorStatus maps webhook lookup errors to HTTP statuses
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrWebhookNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
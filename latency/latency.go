@@ -0,0 +1,116 @@
+// Package latency keeps a rolling window of outbound call latencies and failures, so recent
+// percentiles and error rates can be reported without an external metrics system.
+package latency
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Retention is how far back samples are kept
+const Retention = time.Hour
+
+// maxSamples caps the samples kept per target, dropping the oldest first
+const maxSamples = 20000
+
+// Targets recorded by the instrumented clients
+const (
+	BinanceFuturesREST = "binance_futures_rest"
+	BinanceCoinMREST   = "binance_coinm_rest"
+	BinanceSpotREST    = "binance_spot_rest"
+	BinanceOptionsREST = "binance_options_rest"
+	BinanceWSAPI       = "binance_ws_api"
+	MongoDB            = "mongodb"
+)
+
+type sample struct {
+	at     time.Time
+	took   time.Duration
+	failed bool
+}
+
+// Stats summarizes a target's samples in a window
+type Stats struct {
+	Count     int     `json:"count"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	P50Ms     float64 `json:"p50_ms"`
+	P95Ms     float64 `json:"p95_ms"`
+	MaxMs     float64 `json:"max_ms"`
+}
+
+var (
+	mu      sync.Mutex
+	samples = make(map[string][]sample)
+)
+
+// Record adds a call to target that took took; failed marks calls that did not get an answer
+// from the target, or got an error from the target itself
+func Record(target string, took time.Duration, failed bool) {
+	now := time.Now()
+	mu.Lock()
+	defer mu.Unlock()
+	s := prune(samples[target], now)
+	if len(s) >= maxSamples {
+		s = s[1:]
+	}
+	samples[target] = append(s, sample{at: now, took: took, failed: failed})
+}
+
+// prune drops samples older than Retention
+func prune(s []sample, now time.Time) []sample {
+	cutoff := now.Add(-Retention)
+	i := sort.Search(len(s), func(i int) bool { return !s[i].at.Before(cutoff) })
+	if i == 0 {
+		return s
+	}
+	// Copy so the dropped samples' backing array can be released
+	return append([]sample(nil), s[i:]...)
+}
+
+// Snapshot summarizes every target's samples of the last window (at most Retention)
+func Snapshot(window time.Duration) map[string]Stats {
+	since := time.Now().Add(-window)
+	mu.Lock()
+	durations := make(map[string][]time.Duration, len(samples))
+	failures := make(map[string]int, len(samples))
+	for target, s := range samples {
+		i := sort.Search(len(s), func(i int) bool { return !s[i].at.Before(since) })
+		for _, smp := range s[i:] {
+			durations[target] = append(durations[target], smp.took)
+			if smp.failed {
+				failures[target]++
+			}
+		}
+	}
+	mu.Unlock()
+
+	out := make(map[string]Stats, len(durations))
+	for target, d := range durations {
+		sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+		out[target] = Stats{
+			Count:     len(d),
+			Errors:    failures[target],
+			ErrorRate: float64(failures[target]) / float64(len(d)),
+			P50Ms:     percentile(d, 0.50),
+			P95Ms:     percentile(d, 0.95),
+			MaxMs:     ms(d[len(d)-1]),
+		}
+	}
+	return out
+}
+
+// percentile returns the nearest-rank percentile p of sorted, in milliseconds
+func percentile(sorted []time.Duration, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return ms(sorted[rank])
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,81 @@
+package latency
+
+import (
+	"sort"
+	"time"
+)
+
+// Order placement stages observed by ObserveOrder
+const (
+	// StageBinance is the Binance request, from sending it to its response
+	StageBinance = "binance"
+	// StageTotal runs from the HTTP request reaching the service, or the Binance request for
+	// orders placed in the background, to the order being stored
+	StageTotal = "total"
+)
+
+// OrderBuckets are the upper bounds, in seconds, of the order placement histograms
+var OrderBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// OrderHistogram is the cumulative order placement latency of one symbol, transport and stage
+// since the service started
+type OrderHistogram struct {
+	Symbol    string
+	Transport string
+	Stage     string
+	// Buckets counts the observations at or below each of OrderBuckets
+	Buckets []uint64
+	Count   uint64
+	Sum     float64 // seconds
+}
+
+type orderKey struct {
+	symbol, transport, stage string
+}
+
+var orderHistograms = make(map[orderKey]*OrderHistogram)
+
+// ObserveOrder adds an order placement stage that took took to the histograms
+func ObserveOrder(symbol, transport, stage string, took time.Duration) {
+	seconds := took.Seconds()
+	key := orderKey{symbol: symbol, transport: transport, stage: stage}
+	mu.Lock()
+	defer mu.Unlock()
+	h := orderHistograms[key]
+	if h == nil {
+		h = &OrderHistogram{Symbol: symbol, Transport: transport, Stage: stage, Buckets: make([]uint64, len(OrderBuckets))}
+		orderHistograms[key] = h
+	}
+	for i, bound := range OrderBuckets {
+		if seconds <= bound {
+			h.Buckets[i]++
+		}
+	}
+	h.Count++
+	h.Sum += seconds
+}
+
+// OrderHistograms returns a copy of the order placement histograms sorted by stage, symbol and
+// transport
+func OrderHistograms() []OrderHistogram {
+	mu.Lock()
+	out := make([]OrderHistogram, 0, len(orderHistograms))
+	for _, h := range orderHistograms {
+		c := *h
+		c.Buckets = append([]uint64(nil), h.Buckets...)
+		out = append(out, c)
+	}
+	mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Stage != b.Stage {
+			return a.Stage < b.Stage
+		}
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		return a.Transport < b.Transport
+	})
+	return out
+}
@@ -0,0 +1,80 @@
+// Package lifecycle starts background components and stops them in reverse order on shutdown.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Hook starts or stops a component; ctx bounds how long it may take
+type Hook func(ctx context.Context) error
+
+type component struct {
+	name  string
+	start Hook
+	stop  Hook
+}
+
+// Manager owns the root context that background components run under
+type Manager struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	components []component
+}
+
+// NewManager creates a manager with a fresh root context
+func NewManager() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{ctx: ctx, cancel: cancel}
+}
+
+// Context returns the root context; it is canceled when Shutdown begins
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// Register adds a component; either hook may be nil. Components start in registration
+// order and stop in reverse, so dependencies (e.g. MongoDB) should be registered first.
+func (m *Manager) Register(name string, start, stop Hook) {
+	m.components = append(m.components, component{name: name, start: start, stop: stop})
+}
+
+// Start runs the start hooks in order with the root context, stopping at the first error
+func (m *Manager) Start() error {
+	for _, c := range m.components {
+		if c.start == nil {
+			continue
+		}
+		if err := c.start(m.ctx); err != nil {
+			return fmt.Errorf("failed to start %s: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+// Shutdown cancels the root context and runs the stop hooks in reverse order. Every hook
+// runs even if an earlier one fails or ctx expires; the errors are joined.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.cancel()
+
+	var errs []error
+	for i := len(m.components) - 1; i >= 0; i-- {
+		c := m.components[i]
+		if c.stop == nil {
+			continue
+		}
+		start := time.Now()
+		err := c.stop(ctx)
+		duration := time.Since(start).Milliseconds()
+		if err != nil {
+			slog.Error("component failed to stop", "component", c.name, "duration_ms", duration, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+			continue
+		}
+		slog.Info("component stopped", "component", c.name, "duration_ms", duration)
+	}
+	return errors.Join(errs...)
+}
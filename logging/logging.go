@@ -0,0 +1,78 @@
+// Package logging configures structured logging and carries request metadata through contexts.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+type requestKey struct{}
+
+type requestInfo struct {
+	requestID  string
+	route      string
+	receivedAt time.Time
+}
+
+// Setup installs the default slog logger; format is "json" or "text", level is debug/info/warn/error.
+// Output of the standard log package is routed through it as well.
+func Setup(format, level string) {
+	opts := &slog.HandlerOptions{Level: parseLevel(level)}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "json") {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithRequest attaches the request ID and route template to ctx, noting the current time as
+// when the request was received
+func WithRequest(ctx context.Context, requestID, route string) context.Context {
+	return context.WithValue(ctx, requestKey{}, requestInfo{requestID: requestID, route: route, receivedAt: time.Now()})
+}
+
+// RequestID returns the request ID stored in ctx, if any
+func RequestID(ctx context.Context) string {
+	info, _ := ctx.Value(requestKey{}).(requestInfo)
+	return info.requestID
+}
+
+// Route returns the route stored in ctx, if any
+func Route(ctx context.Context) string {
+	info, _ := ctx.Value(requestKey{}).(requestInfo)
+	return info.route
+}
+
+// ReceivedAt returns when the request stored in ctx was received; zero outside a request
+func ReceivedAt(ctx context.Context) time.Time {
+	info, _ := ctx.Value(requestKey{}).(requestInfo)
+	return info.receivedAt
+}
+
+// FromContext returns the default logger annotated with the request ID and route from ctx
+func FromContext(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+	if info, ok := ctx.Value(requestKey{}).(requestInfo); ok {
+		logger = logger.With("request_id", info.requestID, "route", info.route)
+	}
+	return logger
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,6 +15,12 @@ import (
 	"futures-options/database"
 	_ "futures-options/docs" // Swagger docs (blank import to ensure docs package is linked)
 	"futures-options/handlers"
+	"futures-options/lifecycle"
+	"futures-options/logging"
+	"futures-options/notifications"
+	"futures-options/paper"
+	"futures-options/repository"
+	"futures-options/secrets"
 	"futures-options/services"
 )
 
@@ -37,6 +44,11 @@ import (
 func main() {
 	// Load configuration
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	logging.Setup(cfg.LogFormat, cfg.LogLevel)
+	slog.Info("configuration loaded", "config", cfg)
 
 	// Note: API keys will be loaded from database first (if saved via POST /api/credentials),
 	// then fall back to environment variables if not found in database
@@ -45,29 +57,144 @@ func main() {
 	if err := database.Connect(cfg); err != nil {
 		log.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
-	defer database.Disconnect()
+
+	// Components are stopped in reverse registration order, so MongoDB closes last
+	lc := lifecycle.NewManager()
+	lc.Register("mongodb", nil, database.Disconnect)
+
+	repository.SetOperationTimeout(cfg.MongoOperationTimeout)
 
 	// Create indexes
-	if err := database.CreateIndexes(); err != nil {
+	if err := database.CreateIndexes(cfg); err != nil {
 		log.Printf("Warning: Failed to create indexes: %v", err)
 	}
 
-	// Initialize Binance client
+	// Initialize Binance client; every connection to Binance goes through the configured proxy
+	caCertPath := cfg.BinanceCACertPath
+	if caCertPath != "" {
+		caCertPath = cfg.KeyFilePath(caCertPath)
+	}
+	if err := binance.ConfigureNetwork(cfg.BinanceProxyURL, caCertPath); err != nil {
+		log.Fatalf("Invalid Binance network settings: %v", err)
+	}
 	binanceClient := binance.NewClient(cfg)
 	
 	// Create temporary service to check database for credentials
-	tempService := services.NewTradingService(binanceClient)
+	repos := repository.NewMongoRepositories()
+
+	// In paper trading mode orders are simulated against live mark prices and never reach Binance
+	var tradingAPI services.BinanceAPI = binanceClient
+	if cfg.PaperTrading {
+		paperClient := paper.NewClient(binanceClient, repos.Paper, cfg.PaperStartingBalance)
+		lc.Register("paper trading engine", paperClient.Start, paperClient.Stop)
+		tradingAPI = paperClient
+		log.Println("⚠ PAPER_TRADING=true: orders are simulated and stored in the paper_* collections")
+	}
+	tempService := services.NewTradingService(tradingAPI, repos)
+	// Requests may sign with another stored credential (X-Account); paper trading has one account
+	if !cfg.PaperTrading {
+		accounts := binance.NewAccounts(binanceClient)
+		tempService.SetAccounts(accounts)
+		lc.Register("binance accounts", nil, accounts.Close)
+	}
+
+	// Audit entries are written in the background and flushed on shutdown
+	auditLogger := services.NewAuditLogger(repos.Audit, cfg.AuditBufferSize)
+	tempService.SetAuditLogger(auditLogger)
+	lc.Register("audit log", nil, auditLogger.Close)
+	tempService.SetRawResponseLimit(cfg.RawResponseMaxBytes)
+	tempService.SetRiskOverridePrincipals(cfg.RiskOverridePrincipals)
+	tempService.SetDailyLossLimit(cfg.DailyLossLimit)
+	tempService.SetEquityFillNotional(cfg.EquityFillNotional)
+	tempService.SetMarginRatioWarning(cfg.MarginRatioWarning)
+	tempService.SetFundingAlertThreshold(cfg.FundingAlertThreshold)
+	tempService.SetClientOrderIDPrefix(cfg.ClientOrderIDPrefix)
+	tempService.SetScheduledOrderGrace(cfg.ScheduledOrderGrace)
+	tempService.SetExportTimeout(cfg.ExportTimeout)
+	tempService.SetRetentionPolicy(services.RetentionPolicy{
+		OrderDays:            cfg.OrderRetentionDays,
+		AuditDays:            cfg.AuditRetentionDays,
+		RiskEventDays:        cfg.RiskEventRetentionDays,
+		OrderArchiveInterval: cfg.OrderArchiveInterval,
+	})
+
+	// Secrets are encrypted at rest with a key derived from CREDENTIALS_MASTER_KEY
+	if cfg.CredentialsMasterKey != "" {
+		cipher, err := secrets.NewCipher(cfg.CredentialsMasterKey)
+		if err != nil {
+			log.Fatalf("Invalid CREDENTIALS_MASTER_KEY: %v", err)
+		}
+		tempService.SetCipher(cipher)
+
+		// Re-encrypt rows saved before encryption was enabled
+		migrated, err := tempService.MigrateCredentialEncryption(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to encrypt stored credentials: %v", err)
+		}
+		if migrated > 0 {
+			log.Printf("✓ Encrypted %d plaintext credential(s) at rest", migrated)
+		}
+	} else {
+		log.Println("⚠ Warning: CREDENTIALS_MASTER_KEY not set, secret keys are stored in plaintext")
+	}
+
+	// Signed webhook deliveries run in the background; pending retries are dead-lettered on shutdown
+	webhooks := tempService.EnableWebhooks(services.WebhookOptions{
+		MaxAttempts: cfg.WebhookMaxAttempts,
+		BaseDelay:   cfg.WebhookRetryBaseDelay,
+		Timeout:     cfg.WebhookTimeout,
+	})
+	lc.Register("webhooks", nil, webhooks.Close)
+
+	// Telegram alerts are sent when a bot token and chat ID are configured
+	if cfg.TelegramBotToken != "" && cfg.TelegramChatID != "" {
+		telegram := notifications.NewTelegramNotifier(notifications.TelegramConfig{
+			BotToken:  cfg.TelegramBotToken,
+			ChatID:    cfg.TelegramChatID,
+			Events:    cfg.TelegramEvents,
+			PerMinute: cfg.TelegramPerMinute,
+		})
+		tempService.AddNotifier(telegram)
+		lc.Register("telegram", nil, telegram.Close)
+		log.Printf("✓ Telegram notifications enabled")
+	}
+
+	// Slack and Discord incoming webhooks share the webhook retry settings
+	if cfg.SlackWebhookURL != "" {
+		slack := notifications.NewSlackNotifier(notifications.ChatWebhookConfig{
+			URL:         cfg.SlackWebhookURL,
+			Events:      cfg.SlackEvents,
+			MaxAttempts: cfg.WebhookMaxAttempts,
+			BaseDelay:   cfg.WebhookRetryBaseDelay,
+		})
+		tempService.AddNotifier(slack)
+		lc.Register("slack", nil, slack.Close)
+		log.Printf("✓ Slack notifications enabled")
+	}
+	if cfg.DiscordWebhookURL != "" {
+		discord := notifications.NewDiscordNotifier(notifications.ChatWebhookConfig{
+			URL:         cfg.DiscordWebhookURL,
+			Events:      cfg.DiscordEvents,
+			MaxAttempts: cfg.WebhookMaxAttempts,
+			BaseDelay:   cfg.WebhookRetryBaseDelay,
+		})
+		tempService.AddNotifier(discord)
+		lc.Register("discord", nil, discord.Close)
+		log.Printf("✓ Discord notifications enabled")
+	}
 	
 	// Priority: Database first, then environment variables
 	var apiKey, secretKey string
 	var keySource string
+	testnet := cfg.BinanceTestnet
 	
 	// Try to load from database first (credentials saved via API)
 	credentials, err := tempService.GetActiveAPICredentials(context.Background())
 	if err == nil && credentials.APIKey != "" && credentials.SecretKey != "" {
 		apiKey = credentials.APIKey
 		secretKey = credentials.SecretKey
-		keySource = "database"
+		testnet = credentials.IsTestnet // the credential's network overrides BINANCE_TESTNET
+		keySource = services.CredentialSourceDatabase
 		log.Printf("✓ Using API keys from database (saved via POST /api/credentials)")
 		// Show masked API key for security
 		keyLen := len(credentials.APIKey)
@@ -90,7 +217,7 @@ func main() {
 		// Fall back to environment variables
 		apiKey = cfg.BinanceAPIKey
 		secretKey = cfg.BinanceSecretKey
-		keySource = "environment"
+		keySource = services.CredentialSourceEnvironment
 		log.Println("✓ Using API keys from environment variables")
 	} else {
 		log.Println("⚠ Warning: No API keys found in database or environment")
@@ -100,32 +227,100 @@ func main() {
 	
 	// Set API keys if we found them
 	if apiKey != "" && secretKey != "" {
-		binanceClient.SetAPIKeys(apiKey, secretKey)
-		log.Printf("✓ Binance client configured with API keys from %s", keySource)
+		binanceClient.SetCredentials(apiKey, secretKey, testnet)
+		log.Printf("✓ Binance client configured with API keys from %s (network: %s)", keySource, binance.NetworkName(testnet))
+		tempService.SetCredentialSource(keySource)
+		if keySource == services.CredentialSourceDatabase {
+			tempService.SetActiveAccount(credentials.ID.Hex())
+		}
 	}
 
 	// Initialize services (reuse the temp service)
 	tradingService := tempService
+	tradingService.AddNotifier(notifications.NewLogNotifier())
+
+	// Background components run under the lifecycle's root context
+	tradingService.SetBackgroundContext(lc.Context())
+	lc.Register("trading service", func(ctx context.Context) error {
+		// A pause saved before the restart holds before any strategy resumes
+		if err := tradingService.LoadTradingPause(ctx); err != nil {
+			return err
+		}
+		// Exchange info is public, so it is kept fresh with or without keys
+		tradingService.StartExchangeInfoRefresh(ctx, cfg.ExchangeInfoRefreshInterval)
+		// Market data of watched symbols is public too
+		tradingService.StartWatchlist(ctx)
+		// Old terminal orders are archived whatever the trading mode
+		tradingService.StartOrderArchive(ctx)
+
+		// Trading components start when authenticated, or in paper mode, where orders are
+		// reconciled against the simulated book and need no keys
+		if cfg.PaperTrading || (apiKey != "" && secretKey != "") {
+			// Only live trading has a user data stream (order updates, margin calls) to keep alive
+			if !cfg.PaperTrading {
+				if err := tradingService.StartUserDataStream(ctx); err != nil {
+					log.Printf("Warning: Failed to start user data stream: %v", err)
+				}
+				tradingService.StartListenKeyKeepalive(ctx, cfg.ListenKeyKeepaliveInterval)
+			}
+			// Strategies resume only after the local book has been reconciled with Binance
+			tradingService.ReconcileOnStartup(ctx)
+			tradingService.StartOrderReconciler(ctx, cfg.OrderReconcileInterval)
+			tradingService.StartEquitySnapshots(ctx, cfg.EquitySnapshotInterval)
+			tradingService.StartPositionSync(ctx, cfg.PositionSyncInterval)
+			tradingService.StartIncomeSync(ctx, cfg.IncomeSyncInterval)
+			tradingService.StartOrphanOrderSweep(ctx, cfg.OrphanOrderSweepInterval, cfg.OrphanOrderSweepDryRun)
+			if err := tradingService.StartConditionalOrders(ctx); err != nil {
+				log.Printf("Warning: Failed to start conditional orders: %v", err)
+			}
+			if err := tradingService.StartScheduledOrders(ctx); err != nil {
+				log.Printf("Warning: Failed to start scheduled orders: %v", err)
+			}
+			tradingService.StartDCAPlans(ctx, cfg.DCACheckInterval)
+			tradingService.StartGridStrategies(ctx, cfg.GridSyncInterval)
+		}
+		return nil
+	}, tradingService.Shutdown)
+	if err := lc.Start(); err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	// REST API authentication
+	if cfg.AuthDisabled {
+		log.Println("⚠⚠⚠ WARNING: REST API authentication is DISABLED (AUTH_DISABLED=true). Anyone who can reach this port can trade. ⚠⚠⚠")
+	} else if len(cfg.APITokens) == 0 {
+		log.Println("⚠ Warning: API_TOKENS is empty; only tokens stored in the database will be accepted")
+	}
+	authService := services.NewAuthService(repos.Tokens, cfg.APITokens, cfg.AuthDisabled)
 
 	// Initialize handlers
-	h := handlers.NewHandlers(tradingService)
+	h := handlers.NewHandlers(tradingService, authService)
+	h.SetRateLimits(
+		handlers.NewRateLimiter(cfg.RateLimitReadPerMinute, cfg.RateLimitReadBurst),
+		handlers.NewRateLimiter(cfg.RateLimitWritePerMinute, cfg.RateLimitWriteBurst),
+	)
+	h.SetBodyLimits(int64(cfg.MaxBodyBytes), int64(cfg.MaxBatchBodyBytes))
 
 	// Setup routes
 	router := handlers.SetupRoutes(h)
 
 	// Create HTTP server
+	// CSV exports extend their own write deadline past WriteTimeout
 	server := &http.Server{
-		Addr:         ":" + cfg.Port,
-		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              ":" + cfg.Port,
+		Handler:           router,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		MaxHeaderBytes:    cfg.HTTPMaxHeaderBytes,
 	}
+	lc.Register("http server", nil, server.Shutdown)
 
 	// Start server in a goroutine
 	go func() {
 		log.Printf("Server starting on port %s", cfg.Port)
-		log.Printf("Testnet mode: %v", cfg.BinanceTestnet)
+		log.Printf("Testnet mode: %v (effective network: %s)", cfg.BinanceTestnet, tradingService.Network())
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed to start: %v", err)
 		}
@@ -138,12 +333,13 @@ func main() {
 
 	log.Println("Shutting down server...")
 
-	// Graceful shutdown with timeout
+	// Graceful shutdown with timeout: the HTTP server stops first, then background
+	// components, buffered audit entries and finally MongoDB
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	if err := lc.Shutdown(ctx); err != nil {
+		log.Printf("Warning: Shutdown incomplete: %v", err)
 	}
 
 	log.Println("Server exited")
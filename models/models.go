@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -78,21 +79,47 @@ const (
 	OrderSideSell OrderSide = "SELL"
 )
 
-// PositionSide represents long or short
+// PositionSide represents long or short, or BOTH for a one-way mode position
 type PositionSide string
 
 const (
 	PositionSideLong  PositionSide = "LONG"
 	PositionSideShort PositionSide = "SHORT"
+	PositionSideBoth  PositionSide = "BOTH" // one-way mode; the sign of the quantity gives the direction
+)
+
+// Market is the Binance futures market an order or position belongs to
+type Market string
+
+const (
+	MarketUSDM  Market = "usdm"  // USDⓈ-M futures (fapi); quantity in the base asset, margin and PnL in USDT
+	MarketCoinM Market = "coinm" // COIN-M futures (dapi); quantity in contracts, margin and PnL in the base coin
+)
+
+// OrderSource is the part of the service that placed a futures order
+type OrderSource string
+
+const (
+	OrderSourceManual      OrderSource = "manual"      // order, batch and position size endpoints
+	OrderSourceTemplate    OrderSource = "template"    // an executed order template
+	OrderSourceGrid        OrderSource = "grid"
+	OrderSourceDCA         OrderSource = "dca"
+	OrderSourceConditional OrderSource = "conditional"
+	OrderSourceScheduled   OrderSource = "scheduled"
+	OrderSourceSpread      OrderSource = "spread"      // a leg, or the unwind, of a calendar spread
+	OrderSourceExternal    OrderSource = "external" // placed elsewhere and imported, e.g. by reconciliation
 )
 
 // FuturesOrder represents a futures trading order
 type FuturesOrder struct {
 	ID                    primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
 	Symbol                string               `bson:"symbol" json:"symbol"`
+	Market                Market               `bson:"market,omitempty" json:"market,omitempty"` // empty for orders placed before COIN-M support (usdm)
+	AccountID             string               `bson:"account_id,omitempty" json:"account_id,omitempty"` // credential the order was signed with; empty for environment keys
 	Side                  OrderSide            `bson:"side" json:"side"`
 	OrderType             OrderType            `bson:"order_type" json:"order_type"`
 	Quantity              float64              `bson:"quantity" json:"quantity"`
+	QuoteQuantity         float64              `bson:"quote_quantity,omitempty" json:"quote_quantity,omitempty"` // requested notional the quantity was derived from
 	Price                 float64              `bson:"price,omitempty" json:"price,omitempty"`
 	StopPrice             float64              `bson:"stop_price,omitempty" json:"stop_price,omitempty"`
 	ActivationPrice       float64              `bson:"activation_price,omitempty" json:"activation_price,omitempty"` // For TRAILING_STOP_MARKET
@@ -109,15 +136,49 @@ type FuturesOrder struct {
 	NewOrderRespType      string               `bson:"new_order_resp_type,omitempty" json:"new_order_resp_type,omitempty"` // ACK, RESULT
 	BinanceOrderID        int64                `bson:"binance_order_id,omitempty" json:"binance_order_id,omitempty"`
 	ClientOrderID         string                `bson:"client_order_id,omitempty" json:"client_order_id,omitempty"`
+	StrategyID            string                `bson:"strategy_id,omitempty" json:"strategy_id,omitempty"` // grid, DCA, conditional or scheduled order, or calendar spread, that placed it
+	Source                OrderSource           `bson:"source,omitempty" json:"source,omitempty"` // empty for orders stored before sources were recorded
+	Template              string                `bson:"template,omitempty" json:"template,omitempty"`       // order template it was executed from
 	Status                string                `bson:"status" json:"status"`
+	ExecutedQty           float64               `bson:"executed_qty,omitempty" json:"executed_qty"`
+	AvgFillPrice          float64               `bson:"avg_fill_price,omitempty" json:"avg_fill_price"`
+	CumQuote              float64               `bson:"cum_quote,omitempty" json:"cum_quote"` // USDⓈ-M only; COIN-M orders are sized in contracts
+	LastFillTime          *time.Time            `bson:"last_fill_time,omitempty" json:"last_fill_time,omitempty"`
+	MissingOnExchange     bool                  `bson:"missing_on_exchange,omitempty" json:"missing_on_exchange,omitempty"`
+	ReconciledAt          *time.Time            `bson:"reconciled_at,omitempty" json:"reconciled_at,omitempty"`
+	ReconcileNote         string                `bson:"reconcile_note,omitempty" json:"reconcile_note,omitempty"` // why reconciliation imported or closed it
+	Paper                 bool                  `bson:"paper,omitempty" json:"paper,omitempty"` // simulated by the paper trading engine
+	Latency               *OrderLatency         `bson:"latency,omitempty" json:"latency,omitempty"` // placement timings; unset for imported and older orders
+	AppliedDefaults       *SymbolDefaults       `bson:"applied_defaults,omitempty" json:"applied_defaults,omitempty"` // fields the request left empty and the symbol's settings filled in
+	RawResponse           json.RawMessage       `bson:"raw_response,omitempty" json:"raw_response,omitempty"` // Last Binance create/modify/cancel response
 	CreatedAt             time.Time             `bson:"created_at" json:"created_at"`
 	UpdatedAt             time.Time             `bson:"updated_at" json:"updated_at"`
+	Corrections           []string              `bson:"-" json:"corrections,omitempty"` // request fields dropped before placing, e.g. quantity next to close_position
+	Journal               *JournalEntry         `bson:"-" json:"journal,omitempty"` // latest journal entry, when listed with include_journal
+	JournalEntries        []*JournalEntry       `bson:"-" json:"journal_entries,omitempty"` // every journal entry, when fetched by ID with include_journal
+	Replayed              bool                  `bson:"-" json:"replayed,omitempty"` // returned for a client_order_id already placed instead of a new order
+}
+
+// OrderTransportREST labels orders placed through the REST API
+const OrderTransportREST = "rest"
+
+// OrderLatency records how long placing an order took, from the HTTP request to Binance's
+// acknowledgment
+type OrderLatency struct {
+	Transport    string     `bson:"transport" json:"transport"`
+	ReceivedAt   *time.Time `bson:"received_at,omitempty" json:"received_at,omitempty"` // HTTP request received; unset for orders placed in the background
+	RequestedAt  time.Time  `bson:"requested_at" json:"requested_at"`                   // Binance request sent
+	RespondedAt  time.Time  `bson:"responded_at" json:"responded_at"`                   // Binance response received
+	TransactTime *time.Time `bson:"transact_time,omitempty" json:"transact_time,omitempty"` // Binance's time of the order in its response, by its clock
+	BinanceMs    float64    `bson:"binance_ms" json:"binance_ms"`                       // request sent to response received
+	TotalMs      float64    `bson:"total_ms" json:"total_ms"`                           // received (or Binance request sent) to the order being stored
 }
 
 // OptionsOrder represents an options trading order
 type OptionsOrder struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Symbol        string             `bson:"symbol" json:"symbol"`
+	AccountID     string             `bson:"account_id,omitempty" json:"account_id,omitempty"` // credential the order was signed with
 	Side          OrderSide          `bson:"side" json:"side"`
 	OrderType     OrderType          `bson:"order_type" json:"order_type"`
 	Quantity      float64            `bson:"quantity" json:"quantity"`
@@ -127,8 +188,68 @@ type OptionsOrder struct {
 	OptionType    string             `bson:"option_type" json:"option_type"` // CALL or PUT
 	BinanceOrderID int64             `bson:"binance_order_id,omitempty" json:"binance_order_id,omitempty"`
 	Status        string             `bson:"status" json:"status"`
+	RawResponse   json.RawMessage    `bson:"raw_response,omitempty" json:"raw_response,omitempty"` // Binance create response
 	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+	Journal       *JournalEntry      `bson:"-" json:"journal,omitempty"` // latest journal entry, when listed with include_journal
+	JournalEntries []*JournalEntry    `bson:"-" json:"journal_entries,omitempty"` // every journal entry, when fetched by ID with include_journal
+}
+
+// SpotOrder represents a spot market or limit order, used for hedging and treasury moves
+type SpotOrder struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Symbol            string             `bson:"symbol" json:"symbol"`
+	AccountID         string             `bson:"account_id,omitempty" json:"account_id,omitempty"` // credential the order was signed with
+	Side              OrderSide          `bson:"side" json:"side"`
+	OrderType         OrderType          `bson:"order_type" json:"order_type"` // MARKET or LIMIT
+	Quantity          float64            `bson:"quantity,omitempty" json:"quantity,omitempty"`             // base asset
+	QuoteQuantity     float64            `bson:"quote_quantity,omitempty" json:"quote_quantity,omitempty"` // quote asset; market orders only
+	Price             float64            `bson:"price,omitempty" json:"price,omitempty"`
+	TimeInForce       TimeInForce        `bson:"time_in_force,omitempty" json:"time_in_force,omitempty"`
+	BinanceOrderID    int64              `bson:"binance_order_id,omitempty" json:"binance_order_id,omitempty"`
+	ClientOrderID     string             `bson:"client_order_id,omitempty" json:"client_order_id,omitempty"`
+	Status            string             `bson:"status" json:"status"`
+	ExecutedQuantity  float64            `bson:"executed_quantity,omitempty" json:"executed_quantity,omitempty"`
+	CumulativeQuote   float64            `bson:"cumulative_quote,omitempty" json:"cumulative_quote,omitempty"`
+	AvgPrice          float64            `bson:"avg_price,omitempty" json:"avg_price,omitempty"`
+	MissingOnExchange bool               `bson:"missing_on_exchange,omitempty" json:"missing_on_exchange,omitempty"`
+	ReconciledAt      *time.Time         `bson:"reconciled_at,omitempty" json:"reconciled_at,omitempty"`
+	RawResponse       json.RawMessage    `bson:"raw_response,omitempty" json:"raw_response,omitempty"` // Binance create response
+	CreatedAt         time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt         time.Time          `bson:"updated_at" json:"updated_at"`
+	Journal           *JournalEntry      `bson:"-" json:"journal,omitempty"` // latest journal entry, when listed with include_journal
+	JournalEntries    []*JournalEntry    `bson:"-" json:"journal_entries,omitempty"` // every journal entry, when fetched by ID with include_journal
+	Replayed          bool               `bson:"-" json:"replayed,omitempty"` // returned for a client_order_id already placed instead of a new order
+}
+
+// SpotBalance is the spot wallet balance of one asset
+type SpotBalance struct {
+	Asset  string  `json:"asset"`
+	Free   float64 `json:"free"`
+	Locked float64 `json:"locked"`
+}
+
+// TransferDirection is the wallet pair of a transfer; FUTURES is the USDⓈ-M wallet
+type TransferDirection string
+
+const (
+	TransferSpotToFutures TransferDirection = "SPOT_TO_FUTURES"
+	TransferFuturesToSpot TransferDirection = "FUTURES_TO_SPOT"
+	TransferSpotToCoinM   TransferDirection = "SPOT_TO_COINM"
+	TransferCoinMToSpot   TransferDirection = "COINM_TO_SPOT"
+)
+
+// Transfer is a wallet transfer submitted through the API
+type Transfer struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TranID    int64              `bson:"tran_id" json:"tran_id"` // Binance transfer ID
+	Asset     string             `bson:"asset" json:"asset"`
+	Amount    float64            `bson:"amount" json:"amount"`
+	Direction TransferDirection  `bson:"direction" json:"direction"`
+	Status    string             `bson:"status" json:"status"` // PENDING until the history reports CONFIRMED or FAILED
+	CreatedBy string             `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
 // Position represents an open position
@@ -136,15 +257,29 @@ type Position struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Symbol        string             `bson:"symbol" json:"symbol"`
 	Type          string             `bson:"type" json:"type"` // FUTURES or OPTIONS
-	Side          PositionSide       `bson:"side" json:"side"`
+	Market        Market             `bson:"market,omitempty" json:"market,omitempty"` // futures only; empty for positions synced before COIN-M support (usdm)
+	Side          PositionSide       `bson:"side" json:"side"` // futures: LONG or SHORT in hedge mode, BOTH in one-way mode
+	AccountID     string             `bson:"account_id" json:"account_id,omitempty"` // credential the position belongs to; part of its key
 	Quantity      float64            `bson:"quantity" json:"quantity"`
 	EntryPrice    float64            `bson:"entry_price" json:"entry_price"`
 	CurrentPrice  float64            `bson:"current_price,omitempty" json:"current_price,omitempty"`
 	UnrealizedPnl float64            `bson:"unrealized_pnl,omitempty" json:"unrealized_pnl,omitempty"`
 	Leverage      int                `bson:"leverage,omitempty" json:"leverage,omitempty"`
+	ContractSize  float64            `bson:"contract_size,omitempty" json:"contract_size,omitempty"` // COIN-M: USD value of one contract
+	Notional      float64            `bson:"notional,omitempty" json:"notional,omitempty"`           // at the current price; USDT for usdm, MarginAsset for coinm
+	MarginAsset   string             `bson:"margin_asset,omitempty" json:"margin_asset,omitempty"`   // asset Notional and UnrealizedPnl are in
 	StrikePrice   float64            `bson:"strike_price,omitempty" json:"strike_price,omitempty"`
 	ExpiryDate    time.Time          `bson:"expiry_date,omitempty" json:"expiry_date,omitempty"`
 	OptionType    string             `bson:"option_type,omitempty" json:"option_type,omitempty"`
+	AtRisk        bool               `bson:"at_risk,omitempty" json:"at_risk,omitempty"`
+	MarginCallPrice float64          `bson:"margin_call_price,omitempty" json:"margin_call_price,omitempty"`
+	MarginRatio   float64            `bson:"-" json:"margin_ratio,omitempty"`    // live maintenance margin over margin balance; 1 means liquidation
+	MarginWarning bool               `bson:"-" json:"margin_warning,omitempty"` // margin ratio at or above MARGIN_RATIO_WARNING
+	// FundingPaid is the funding the position paid since it opened, negative when it received more
+	// than it paid; hedge-mode legs share each payment by notional
+	FundingPaid   float64            `bson:"funding_paid,omitempty" json:"funding_paid"`
+	FundingSince  *time.Time         `bson:"funding_since,omitempty" json:"funding_since,omitempty"` // when accrual started; earlier payments belong to a closed position
+	Paper         bool               `bson:"paper,omitempty" json:"paper,omitempty"` // simulated by the paper trading engine
 	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
 }
@@ -153,13 +288,25 @@ type Position struct {
 type APICredentials struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	APIKey        string             `bson:"api_key" json:"api_key"`
-	SecretKey     string             `bson:"secret_key" json:"secret_key"`
+	SecretKey     string             `bson:"secret_key" json:"-"` // never serialized; see services.CredentialResponse
 	IsActive      bool               `bson:"is_active" json:"is_active"`
 	IsTestnet     bool               `bson:"is_testnet" json:"is_testnet"`
+	ValidationStatus CredentialValidationStatus `bson:"validation_status,omitempty" json:"validation_status,omitempty"`
+	ValidatedAt      *time.Time         `bson:"validated_at,omitempty" json:"validated_at,omitempty"`
+	Ed25519Seed      string             `bson:"ed25519_seed,omitempty" json:"-"` // encrypted like SecretKey; signs WS-API requests
+	Ed25519PublicKey string             `bson:"ed25519_public_key,omitempty" json:"ed25519_public_key,omitempty"` // base64, registered with Binance
 	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
+// CredentialValidationStatus represents the result of checking credentials against Binance
+type CredentialValidationStatus string
+
+const (
+	CredentialValid   CredentialValidationStatus = "VALID"
+	CredentialSkipped CredentialValidationStatus = "SKIPPED"
+)
+
 // PositionModeConfig represents position mode configuration
 type PositionModeConfig struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -167,6 +314,705 @@ type PositionModeConfig struct {
 	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
+// RiskEventType represents the kind of risk event
+type RiskEventType string
+
+const (
+	RiskEventMarginCall    RiskEventType = "MARGIN_CALL"
+	RiskEventSymbolHalted  RiskEventType = "SYMBOL_HALTED"  // a strategy's symbol left TRADING
+	RiskEventSymbolResumed RiskEventType = "SYMBOL_RESUMED" // a strategy's symbol is TRADING again
+)
+
+// RiskEvent represents a risk warning received from Binance, or a change in a strategy symbol's status
+type RiskEvent struct {
+	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Type               RiskEventType      `bson:"type" json:"type"`
+	Symbol             string             `bson:"symbol" json:"symbol"`
+	PositionSide       PositionSide       `bson:"position_side,omitempty" json:"position_side,omitempty"`
+	PositionAmount     float64            `bson:"position_amount" json:"position_amount"`
+	MarginType         string             `bson:"margin_type,omitempty" json:"margin_type,omitempty"`
+	MarkPrice          float64            `bson:"mark_price" json:"mark_price"`
+	UnrealizedPnl      float64            `bson:"unrealized_pnl" json:"unrealized_pnl"`
+	MaintenanceMargin  float64            `bson:"maintenance_margin" json:"maintenance_margin"`
+	IsolatedWallet     float64            `bson:"isolated_wallet,omitempty" json:"isolated_wallet,omitempty"`
+	CrossWalletBalance float64            `bson:"cross_wallet_balance" json:"cross_wallet_balance"`
+	Status             string             `bson:"status,omitempty" json:"status,omitempty"`                   // symbol status events only
+	PreviousStatus     string             `bson:"previous_status,omitempty" json:"previous_status,omitempty"` // symbol status events only
+	EventTime          time.Time          `bson:"event_time" json:"event_time"`
+	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
+}
+
+const (
+	// RiskLimitDefaultSymbol is the symbol of the limit applied to symbols without their own
+	RiskLimitDefaultSymbol = "DEFAULT"
+	// RiskLimitGlobalSymbol is the symbol of the order rate limit counted across all symbols
+	RiskLimitGlobalSymbol = "GLOBAL"
+)
+
+// RiskLimit caps the position a symbol may reach and how fast new orders may be placed for it;
+// zero means no cap
+type RiskLimit struct {
+	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Symbol             string             `bson:"symbol" json:"symbol"`
+	MaxNotional        float64            `bson:"max_notional,omitempty" json:"max_notional,omitempty"` // in quote asset at mark price
+	MaxQuantity        float64            `bson:"max_quantity,omitempty" json:"max_quantity,omitempty"` // in base asset
+	MaxOrdersPerMinute int                `bson:"max_orders_per_minute,omitempty" json:"max_orders_per_minute,omitempty"`
+	MaxOrdersPer10s    int                `bson:"max_orders_per_10s,omitempty" json:"max_orders_per_10s,omitempty"`
+	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt          time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// SymbolDefaults are order fields a symbol's settings supply when a request leaves them empty
+type SymbolDefaults struct {
+	Leverage     int          `bson:"leverage,omitempty" json:"leverage,omitempty"`
+	WorkingType  WorkingType  `bson:"working_type,omitempty" json:"working_type,omitempty"`   // stop, take profit and trailing stop orders
+	PositionSide PositionSide `bson:"position_side,omitempty" json:"position_side,omitempty"` // hedge mode
+	TimeInForce  TimeInForce  `bson:"time_in_force,omitempty" json:"time_in_force,omitempty"` // LIMIT orders
+}
+
+// SymbolSettings are a USDⓈ-M symbol's order defaults and its margin type. Leverage and margin
+// type are what was last applied on Binance, through the settings or the leverage and margin
+// type endpoints.
+type SymbolSettings struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Symbol         string             `bson:"symbol" json:"symbol"`
+	SymbolDefaults `bson:",inline"`
+	MarginType     string             `bson:"margin_type,omitempty" json:"margin_type,omitempty"` // ISOLATED or CROSSED
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// SymbolPolicyMode selects how TradingPolicy.Symbols restricts trading
+type SymbolPolicyMode string
+
+const (
+	SymbolPolicyOff       SymbolPolicyMode = "off"       // every symbol may be traded
+	SymbolPolicyWhitelist SymbolPolicyMode = "whitelist" // only the listed symbols may be traded
+	SymbolPolicyBlacklist SymbolPolicyMode = "blacklist" // the listed symbols may not be traded
+)
+
+// TradingPolicyID is the ID of the single trading policy document
+const TradingPolicyID = "symbols"
+
+// TradingPolicy restricts the symbols new orders may be placed on
+type TradingPolicy struct {
+	ID        string           `bson:"_id" json:"-"`
+	Mode      SymbolPolicyMode `bson:"mode" json:"mode"`
+	Symbols   []string         `bson:"symbols" json:"symbols"`
+	UpdatedBy string           `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+	UpdatedAt time.Time        `bson:"updated_at" json:"updated_at"`
+}
+
+// TradingPauseID is the ID of the trading pause document, kept alongside the trading policy
+const TradingPauseID = "pause"
+
+// TradingPause blocks orders that may add exposure, e.g. during exchange maintenance or news
+type TradingPause struct {
+	ID        string     `bson:"_id" json:"-"`
+	Paused    bool       `bson:"paused" json:"paused"`
+	Reason    string     `bson:"reason,omitempty" json:"reason,omitempty"`
+	Until     *time.Time `bson:"until,omitempty" json:"until,omitempty"` // trading resumes at this time; nil until resumed by hand
+	PausedBy  string     `bson:"paused_by,omitempty" json:"paused_by,omitempty"`
+	PausedAt  *time.Time `bson:"paused_at,omitempty" json:"paused_at,omitempty"`
+	ResumedBy string     `bson:"resumed_by,omitempty" json:"resumed_by,omitempty"` // empty when the pause expired
+	ResumedAt *time.Time `bson:"resumed_at,omitempty" json:"resumed_at,omitempty"`
+}
+
+// Income types synced from Binance's income history that count towards PnL
+const (
+	IncomeTypeRealizedPnl = "REALIZED_PNL"
+	IncomeTypeCommission  = "COMMISSION"
+	IncomeTypeFundingFee  = "FUNDING_FEE"
+)
+
+// IncomeRecord is an entry of the futures income history (realized PnL, fees, funding)
+type IncomeRecord struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TranID     int64              `bson:"tran_id" json:"tran_id"`
+	Symbol     string             `bson:"symbol" json:"symbol"`
+	IncomeType string             `bson:"income_type" json:"income_type"`
+	Income     float64            `bson:"income" json:"income"` // negative for costs
+	Asset      string             `bson:"asset" json:"asset"`
+	Info       string             `bson:"info,omitempty" json:"info,omitempty"`
+	TradeID    string             `bson:"trade_id,omitempty" json:"trade_id,omitempty"`
+	Time       time.Time          `bson:"time" json:"time"`
+}
+
+// PnLBucket aggregates the income of one symbol, UTC day, or symbol and day, or the fills of one
+// order source
+type PnLBucket struct {
+	Symbol        string  `bson:"symbol,omitempty" json:"symbol,omitempty"`
+	Day           string  `bson:"day,omitempty" json:"day,omitempty"` // YYYY-MM-DD
+	Source        string  `bson:"source,omitempty" json:"source,omitempty"`
+	RealizedPnl   float64 `bson:"realized_pnl" json:"realized_pnl"`
+	Commission    float64 `bson:"commission" json:"commission"` // negative, as reported by Binance
+	FundingFees   float64 `bson:"funding_fees" json:"funding_fees"`
+	NetPnl        float64 `bson:"-" json:"net_pnl"`
+	UnrealizedPnl float64 `bson:"-" json:"unrealized_pnl,omitempty"`
+	Trades        int64   `bson:"trades" json:"trades"` // realized PnL entries, one per closing fill
+	Wins          int64   `bson:"wins" json:"wins"`
+	WinRate       float64 `bson:"-" json:"win_rate"`
+}
+
+// Fill products
+const (
+	FillProductFutures = "futures"
+	FillProductOptions = "options"
+)
+
+// Fill is one execution of an order: a futures trade from the user data stream, or what an
+// options order executed when it was placed
+type Fill struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Product         string             `bson:"product" json:"product"`
+	AccountID       string             `bson:"account_id,omitempty" json:"account_id,omitempty"`
+	Symbol          string             `bson:"symbol" json:"symbol"`
+	Source          OrderSource        `bson:"source,omitempty" json:"source,omitempty"` // of the parent order; futures only
+	Side            OrderSide          `bson:"side" json:"side"`
+	PositionSide    PositionSide       `bson:"position_side,omitempty" json:"position_side,omitempty"`
+	Quantity        float64            `bson:"quantity" json:"quantity"`
+	Price           float64            `bson:"price" json:"price"`
+	RealizedPnl     float64            `bson:"realized_pnl" json:"realized_pnl"`
+	Commission      float64            `bson:"commission" json:"commission"` // positive, as reported on the fill
+	CommissionAsset string             `bson:"commission_asset,omitempty" json:"commission_asset,omitempty"`
+	Maker           bool               `bson:"maker" json:"maker"`
+	TradeID         int64              `bson:"trade_id" json:"trade_id,omitempty"` // 0 for options
+	BinanceOrderID  int64              `bson:"binance_order_id" json:"binance_order_id"`
+	ClientOrderID   string             `bson:"client_order_id,omitempty" json:"client_order_id,omitempty"`
+	FillTime        time.Time          `bson:"fill_time" json:"fill_time"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Equity snapshot triggers
+const (
+	EquityTriggerScheduled = "scheduled"
+	EquityTriggerFill      = "fill"
+)
+
+// EquitySnapshot records the futures account value at a point in time
+type EquitySnapshot struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WalletBalance float64            `bson:"wallet_balance" json:"wallet_balance"`
+	UnrealizedPnl float64            `bson:"unrealized_pnl" json:"unrealized_pnl"`
+	MarginBalance float64            `bson:"margin_balance" json:"margin_balance"` // wallet balance plus unrealized PnL
+	Trigger       string             `bson:"trigger" json:"trigger"`
+	Symbol        string             `bson:"symbol,omitempty" json:"symbol,omitempty"` // the large fill's symbol
+	Time          time.Time          `bson:"time" json:"time"`
+}
+
+// PaperAccountID is the ID of the single paper trading account document
+const PaperAccountID = "paper"
+
+// PaperOrder is an order held by the paper trading engine in place of Binance
+type PaperOrder struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrderID       int64              `bson:"order_id" json:"order_id"`
+	ClientOrderID string             `bson:"client_order_id,omitempty" json:"client_order_id,omitempty"`
+	Symbol        string             `bson:"symbol" json:"symbol"`
+	Side          string             `bson:"side" json:"side"`
+	PositionSide  string             `bson:"position_side" json:"position_side"` // BOTH in one-way mode
+	Type          string             `bson:"type" json:"type"`                   // STOP and TAKE_PROFIT become LIMIT once triggered
+	OrigType      string             `bson:"orig_type" json:"orig_type"`
+	Quantity      float64            `bson:"quantity" json:"quantity"`
+	Price         float64            `bson:"price,omitempty" json:"price,omitempty"`
+	StopPrice     float64            `bson:"stop_price,omitempty" json:"stop_price,omitempty"`
+	TimeInForce   string             `bson:"time_in_force,omitempty" json:"time_in_force,omitempty"`
+	ReduceOnly    bool               `bson:"reduce_only,omitempty" json:"reduce_only,omitempty"`
+	ClosePosition bool               `bson:"close_position,omitempty" json:"close_position,omitempty"`
+	Leverage      int                `bson:"leverage,omitempty" json:"leverage,omitempty"`
+	Status        string             `bson:"status" json:"status"` // NEW, FILLED, CANCELED or EXPIRED
+	ExecutedQty   float64            `bson:"executed_qty" json:"executed_qty"`
+	AvgPrice      float64            `bson:"avg_price,omitempty" json:"avg_price,omitempty"`
+	RealizedPnl   float64            `bson:"realized_pnl,omitempty" json:"realized_pnl,omitempty"`
+	Commission    float64            `bson:"commission,omitempty" json:"commission,omitempty"`
+	FilledAt      *time.Time         `bson:"filled_at,omitempty" json:"filled_at,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// PaperPosition is a position held by the paper trading engine; short amounts are negative
+type PaperPosition struct {
+	Symbol       string    `bson:"symbol" json:"symbol"`
+	PositionSide string    `bson:"position_side" json:"position_side"`
+	Amount       float64   `bson:"amount" json:"amount"`
+	EntryPrice   float64   `bson:"entry_price" json:"entry_price"`
+	Leverage     int       `bson:"leverage" json:"leverage"`
+	UpdatedAt    time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// PaperAccount is the paper trading engine's virtual wallet
+type PaperAccount struct {
+	ID        string    `bson:"_id" json:"-"`
+	Asset     string    `bson:"asset" json:"asset"`
+	Balance   float64   `bson:"balance" json:"balance"` // wallet balance: starting balance plus realized PnL minus fees
+	DualSide  bool      `bson:"dual_side" json:"dual_side"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// AuditAction identifies the kind of audited trading action
+type AuditAction string
+
+const (
+	AuditOrderCreate      AuditAction = "ORDER_CREATE"
+	AuditOrderModify      AuditAction = "ORDER_MODIFY"
+	AuditOrderCancel      AuditAction = "ORDER_CANCEL"
+	AuditBatchOrderCreate AuditAction = "BATCH_ORDER_CREATE"
+	AuditPositionMode     AuditAction = "POSITION_MODE_CHANGE"
+	AuditLeverageChange   AuditAction = "LEVERAGE_CHANGE"
+	AuditCredentialSave   AuditAction = "CREDENTIAL_SAVE"
+	AuditCredentialUpdate AuditAction = "CREDENTIAL_UPDATE"
+	AuditCredentialDelete AuditAction = "CREDENTIAL_DELETE"
+	AuditCredentialReload AuditAction = "CREDENTIAL_RELOAD"
+	AuditCredentialKeyGenerate AuditAction = "CREDENTIAL_KEY_GENERATE"
+	AuditCredentialKeyImport   AuditAction = "CREDENTIAL_KEY_IMPORT"
+	AuditDailyLossReset   AuditAction = "DAILY_LOSS_RESET"
+	AuditOrderThrottled   AuditAction = "ORDER_THROTTLED"
+	AuditWalletTransfer   AuditAction = "WALLET_TRANSFER"
+	AuditTradingPolicy    AuditAction = "TRADING_POLICY_CHANGE"
+	AuditTradingPause     AuditAction = "TRADING_PAUSE"
+	AuditTradingResume    AuditAction = "TRADING_RESUME"
+	AuditUserStreamRestart AuditAction = "USER_STREAM_RESTART"
+	AuditMarginTypeChange AuditAction = "MARGIN_TYPE_CHANGE"
+)
+
+// AuditEntry records a trading action, the sanitized request and what Binance answered
+type AuditEntry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Timestamp time.Time          `bson:"timestamp" json:"timestamp"`
+	RequestID string             `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	Route     string             `bson:"route,omitempty" json:"route,omitempty"`
+	Action    AuditAction        `bson:"action" json:"action"`
+	Symbol    string             `bson:"symbol,omitempty" json:"symbol,omitempty"`
+	Request   interface{}        `bson:"request,omitempty" json:"request,omitempty"`
+	Response  interface{}        `bson:"response,omitempty" json:"response,omitempty"`
+	Error     string             `bson:"error,omitempty" json:"error,omitempty"`
+	ErrorCode int64              `bson:"error_code,omitempty" json:"error_code,omitempty"`
+	LatencyMs int64              `bson:"latency_ms" json:"latency_ms"`
+}
+
+// APIToken is a bearer token for the local REST API; only its SHA-256 hash is stored
+type APIToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Label     string             `bson:"label" json:"label"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	Prefix    string             `bson:"prefix" json:"prefix"` // first characters of the token, for identification
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Webhook is a callback URL that receives signed notification events
+type Webhook struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	URL        string             `bson:"url" json:"url"`
+	Secret     string             `bson:"secret" json:"-"` // HMAC key, encrypted at rest when CREDENTIALS_MASTER_KEY is set
+	EventTypes []string           `bson:"event_types,omitempty" json:"event_types,omitempty"` // empty receives every event
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// WebhookDeadLetter records an event that could not be delivered to a webhook
+type WebhookDeadLetter struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WebhookID  primitive.ObjectID `bson:"webhook_id" json:"webhook_id"`
+	URL        string             `bson:"url" json:"url"`
+	EventID    string             `bson:"event_id" json:"event_id"`
+	EventType  string             `bson:"event_type" json:"event_type"`
+	Payload    string             `bson:"payload" json:"payload"`
+	Attempts   int                `bson:"attempts" json:"attempts"`
+	LastStatus int                `bson:"last_status,omitempty" json:"last_status,omitempty"` // HTTP status of the last attempt, if any
+	LastError  string             `bson:"last_error" json:"last_error"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// ConditionalStatus is the lifecycle state of a conditional order
+type ConditionalStatus string
+
+const (
+	ConditionalPending    ConditionalStatus = "PENDING"    // waiting for the condition
+	ConditionalTriggering ConditionalStatus = "TRIGGERING" // condition met, order being submitted
+	ConditionalTriggered  ConditionalStatus = "TRIGGERED"  // order submitted
+	ConditionalFailed     ConditionalStatus = "FAILED"     // order submission failed or was interrupted
+	ConditionalCanceled   ConditionalStatus = "CANCELED"
+)
+
+// Price sources a condition can watch, named as Binance's working types
+const (
+	PriceSourceMark     = "MARK_PRICE"
+	PriceSourceContract = "CONTRACT_PRICE" // last traded price
+)
+
+// ConditionalOrder is an order held locally until a price condition on a (possibly different)
+// symbol is met
+type ConditionalOrder struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Symbol       string             `bson:"symbol" json:"symbol"` // symbol whose price is watched
+	Comparator   string             `bson:"comparator" json:"comparator"`
+	TriggerPrice float64            `bson:"trigger_price" json:"trigger_price"`
+	PriceSource  string             `bson:"price_source" json:"price_source"`
+	// Group links conditions so that the first to trigger cancels the others (OCO)
+	Group     string            `bson:"group,omitempty" json:"group,omitempty"`
+	Order     OrderTemplate     `bson:"order" json:"order"`
+	Status    ConditionalStatus `bson:"status" json:"status"`
+	CreatedBy string            `bson:"created_by,omitempty" json:"created_by,omitempty"` // principal, for risk limit overrides
+
+	TriggeredAt    *time.Time `bson:"triggered_at,omitempty" json:"triggered_at,omitempty"`
+	TriggeredPrice float64    `bson:"triggered_price,omitempty" json:"triggered_price,omitempty"`
+	OrderID        int64      `bson:"order_id,omitempty" json:"order_id,omitempty"` // Binance order ID of the submitted order
+	Error          string     `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt      time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+// ScheduleStatus is the lifecycle state of a scheduled order
+type ScheduleStatus string
+
+const (
+	SchedulePending   ScheduleStatus = "PENDING"
+	ScheduleExecuting ScheduleStatus = "EXECUTING" // claimed, order about to be or being submitted
+	ScheduleExecuted  ScheduleStatus = "EXECUTED"
+	ScheduleFailed    ScheduleStatus = "FAILED"  // submission failed or was interrupted
+	ScheduleSkipped   ScheduleStatus = "SKIPPED" // missed and not fired, per its missed policy
+	ScheduleCanceled  ScheduleStatus = "CANCELED"
+)
+
+// Missed policies decide what happens to a schedule whose time passed while it could not fire,
+// e.g. during a restart
+const (
+	MissedPolicySkip = "SKIP" // never fire late
+	MissedPolicyFire = "FIRE" // fire at once if no later than the grace period
+)
+
+// ScheduledOrder is an order submitted at a set time
+type ScheduledOrder struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ExecuteAt    time.Time          `bson:"execute_at" json:"execute_at"`
+	MissedPolicy string             `bson:"missed_policy" json:"missed_policy"`
+	GraceSeconds int                `bson:"grace_seconds" json:"grace_seconds"`
+	Order        OrderTemplate      `bson:"order" json:"order"`
+	Status       ScheduleStatus     `bson:"status" json:"status"`
+	CreatedBy    string             `bson:"created_by,omitempty" json:"created_by,omitempty"` // principal, for risk limit overrides
+
+	// SentAt is when submission began and AckedAt when it returned; the latencies are measured
+	// from ExecuteAt
+	SentAt        *time.Time `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+	AckedAt       *time.Time `bson:"acked_at,omitempty" json:"acked_at,omitempty"`
+	SendLatencyMs *float64   `bson:"send_latency_ms,omitempty" json:"send_latency_ms,omitempty"`
+	AckLatencyMs  *float64   `bson:"ack_latency_ms,omitempty" json:"ack_latency_ms,omitempty"`
+	OrderID       int64      `bson:"order_id,omitempty" json:"order_id,omitempty"` // Binance order ID of the submitted order
+	Error         string     `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt     time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+// DCAStatus is the lifecycle state of a DCA plan
+type DCAStatus string
+
+const (
+	DCAActive    DCAStatus = "ACTIVE"
+	DCAPaused    DCAStatus = "PAUSED"
+	DCACapped    DCAStatus = "CAPPED"    // max exposure reached; the take profit is still watched
+	DCACompleted DCAStatus = "COMPLETED" // take profit filled
+	DCACanceled  DCAStatus = "CANCELED"
+)
+
+// DCAPlan builds a position in steps, on an interval or on each price step against the position
+type DCAPlan struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Symbol            string             `bson:"symbol" json:"symbol"`
+	Side              OrderSide          `bson:"side" json:"side"`
+	PositionSide      PositionSide       `bson:"position_side,omitempty" json:"position_side,omitempty"`
+	Leverage          int                `bson:"leverage,omitempty" json:"leverage,omitempty"`
+	OrderNotional     float64            `bson:"order_notional,omitempty" json:"order_notional,omitempty"` // quote amount per order
+	OrderQuantity     float64            `bson:"order_quantity,omitempty" json:"order_quantity,omitempty"` // or base quantity per order
+	IntervalSeconds   int64              `bson:"interval_seconds,omitempty" json:"interval_seconds,omitempty"`
+	PriceStepPercent  float64            `bson:"price_step_percent,omitempty" json:"price_step_percent,omitempty"`
+	MaxExposure       float64            `bson:"max_exposure" json:"max_exposure"` // cap on the filled notional
+	TakeProfitPercent float64            `bson:"take_profit_percent,omitempty" json:"take_profit_percent,omitempty"`
+	Status            DCAStatus          `bson:"status" json:"status"`
+	CreatedBy         string             `bson:"created_by,omitempty" json:"created_by,omitempty"`
+
+	Orders          []DCAOrder `bson:"orders" json:"orders"`
+	FilledQuantity  float64    `bson:"filled_quantity" json:"filled_quantity"`
+	AvgEntryPrice   float64    `bson:"avg_entry_price" json:"avg_entry_price"` // blended entry of the filled orders
+	Exposure        float64    `bson:"exposure" json:"exposure"`               // filled notional plus that of orders not yet filled
+	RemainingBudget float64    `bson:"remaining_budget" json:"remaining_budget"`
+	LastFillPrice   float64    `bson:"last_fill_price,omitempty" json:"last_fill_price,omitempty"` // reference for price steps
+	NextOrderAt     *time.Time `bson:"next_order_at,omitempty" json:"next_order_at,omitempty"`
+
+	TakeProfitOrderID  int64   `bson:"take_profit_order_id,omitempty" json:"take_profit_order_id,omitempty"`
+	TakeProfitPrice    float64 `bson:"take_profit_price,omitempty" json:"take_profit_price,omitempty"`
+	TakeProfitQuantity float64 `bson:"take_profit_quantity,omitempty" json:"take_profit_quantity,omitempty"`
+	TakeProfitExecuted float64 `bson:"take_profit_executed,omitempty" json:"-"` // of the current take profit order, already counted
+	ClosedQuantity     float64 `bson:"closed_quantity" json:"closed_quantity"`
+	RealizedPnL        float64 `bson:"realized_pnl" json:"realized_pnl"` // from take profit fills, before fees
+
+	// Filled in on reads from the current mark price
+	MarkPrice     float64 `bson:"-" json:"mark_price,omitempty"`
+	UnrealizedPnL float64 `bson:"-" json:"unrealized_pnl"`
+
+	LastError string    `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// DCAOrder is one order placed by a DCA plan
+type DCAOrder struct {
+	OrderID       int64     `bson:"order_id" json:"order_id"`
+	ClientOrderID string    `bson:"client_order_id" json:"client_order_id"`
+	Quantity      float64   `bson:"quantity" json:"quantity"`
+	Status        string    `bson:"status" json:"status"`
+	FilledQty     float64   `bson:"filled_qty" json:"filled_qty"`
+	AvgPrice      float64   `bson:"avg_price,omitempty" json:"avg_price,omitempty"`
+	EstPrice      float64   `bson:"est_price" json:"-"` // mark price when placed, for the exposure of unfilled orders
+	PlacedAt      time.Time `bson:"placed_at" json:"placed_at"`
+}
+
+// GridStatus is the lifecycle state of a grid strategy
+type GridStatus string
+
+const (
+	GridRunning GridStatus = "RUNNING"
+	GridStopped GridStatus = "STOPPED"
+	GridFailed  GridStatus = "FAILED" // the initial position could not be opened
+)
+
+// Grid directions
+const (
+	GridLong    = "LONG"    // opens a long for the grids above the price, which sell it off as it rises
+	GridShort   = "SHORT"   // opens a short for the grids below the price, which buy it back as it falls
+	GridNeutral = "NEUTRAL" // no initial position
+)
+
+// GridStrategy trades a price range split into grids, each buying at its lower and selling at
+// its upper price in turn
+type GridStrategy struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Symbol     string             `bson:"symbol" json:"symbol"`
+	LowerPrice float64            `bson:"lower_price" json:"lower_price"`
+	UpperPrice float64            `bson:"upper_price" json:"upper_price"`
+	GridCount  int                `bson:"grid_count" json:"grid_count"`
+	Quantity   float64            `bson:"quantity" json:"quantity"` // per grid order
+	Direction  string             `bson:"direction" json:"direction"`
+	Leverage   int                `bson:"leverage,omitempty" json:"leverage,omitempty"`
+	Status     GridStatus         `bson:"status" json:"status"`
+	CreatedBy  string             `bson:"created_by,omitempty" json:"created_by,omitempty"`
+
+	Grids          []Grid  `bson:"grids" json:"grids"`
+	StartPrice     float64 `bson:"start_price" json:"start_price"`
+	InitialOrderID int64   `bson:"initial_order_id,omitempty" json:"initial_order_id,omitempty"`
+	Position       float64 `bson:"position" json:"position"` // net quantity the strategy holds, negative when short
+	FillCount      int     `bson:"fill_count" json:"fill_count"`
+	RealizedProfit float64 `bson:"realized_profit" json:"realized_profit"` // grid profit of completed round trips, before fees
+	OrdersPlaced   int     `bson:"orders_placed" json:"orders_placed"`     // numbers client order IDs
+
+	FlattenOrderID int64      `bson:"flatten_order_id,omitempty" json:"flatten_order_id,omitempty"`
+	StoppedAt      *time.Time `bson:"stopped_at,omitempty" json:"stopped_at,omitempty"`
+	LastError      string     `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	CreatedAt      time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+// Grid is one price interval of a grid strategy. It waits to buy at LowerPrice or to sell at
+// UpperPrice; Holding means its last fill opened a position that the next one closes.
+type Grid struct {
+	Index         int     `bson:"index" json:"index"`
+	LowerPrice    float64 `bson:"lower_price" json:"lower_price"`
+	UpperPrice    float64 `bson:"upper_price" json:"upper_price"`
+	Side          string  `bson:"side" json:"side"` // side of the open order
+	Holding       bool    `bson:"holding" json:"holding"`
+	OrderID       int64   `bson:"order_id,omitempty" json:"order_id,omitempty"`
+	ClientOrderID string  `bson:"client_order_id,omitempty" json:"client_order_id,omitempty"`
+	Fills         int     `bson:"fills" json:"fills"`
+	RoundTrips    int     `bson:"round_trips" json:"round_trips"`
+	Profit        float64 `bson:"profit" json:"profit"`
+	Error         string  `bson:"error,omitempty" json:"error,omitempty"` // why its order could not be placed
+}
+
+// SpreadStatus is the state of a calendar spread
+type SpreadStatus string
+
+const (
+	SpreadPending SpreadStatus = "PENDING" // legs placed, waiting for both to fill
+	SpreadOpen    SpreadStatus = "OPEN"    // both legs filled
+	SpreadBroken  SpreadStatus = "BROKEN"  // a leg failed or did not fill in time; its filled portion is still held
+	SpreadUnwound SpreadStatus = "UNWOUND" // the filled legs were closed
+	SpreadFailed  SpreadStatus = "FAILED"  // neither leg could be placed
+)
+
+// Calendar spread directions. The basis is the far leg's price minus the near leg's.
+const (
+	SpreadLongBasis  = "LONG_BASIS"  // buys the far leg and sells the near one, gaining as the basis widens
+	SpreadShortBasis = "SHORT_BASIS" // sells the far leg and buys the near one, gaining as it narrows
+)
+
+// CalendarSpread is a pair of USDⓈ-M orders on two contracts of one pair, e.g. the perpetual and
+// a quarterly, placed together to trade the basis between them
+type CalendarSpread struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Direction    string             `bson:"direction" json:"direction"`
+	PriceMode    string             `bson:"price_mode" json:"price_mode"`
+	Near         SpreadLeg          `bson:"near" json:"near"`
+	Far          SpreadLeg          `bson:"far" json:"far"`
+	Status       SpreadStatus       `bson:"status" json:"status"`
+	AutoUnwind   bool               `bson:"auto_unwind,omitempty" json:"auto_unwind,omitempty"`
+	FillDeadline time.Time          `bson:"fill_deadline" json:"fill_deadline"` // both legs must have filled by then
+	EntryBasis   float64            `bson:"entry_basis,omitempty" json:"entry_basis,omitempty"` // of the average fill prices, once both legs filled
+	OrdersPlaced int                `bson:"orders_placed" json:"orders_placed"`                 // numbers client order IDs
+	AccountID    string             `bson:"account_id,omitempty" json:"account_id,omitempty"`
+	CreatedBy    string             `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	LastError    string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+
+	// Valued at the current mark prices when the spread is fetched
+	CurrentBasis float64 `bson:"-" json:"current_basis,omitempty"`
+	BasisPnL     float64 `bson:"-" json:"basis_pnl,omitempty"` // of the filled quantities, before fees and funding
+}
+
+// SpreadLeg is one order of a calendar spread
+type SpreadLeg struct {
+	Symbol        string  `bson:"symbol" json:"symbol"`
+	Side          string  `bson:"side" json:"side"`
+	PositionSide  string  `bson:"position_side,omitempty" json:"position_side,omitempty"` // LONG or SHORT in hedge mode
+	Quantity      float64 `bson:"quantity" json:"quantity"`
+	Price         float64 `bson:"price,omitempty" json:"price,omitempty"` // limit price in MARK mode
+	OrderID       int64   `bson:"order_id,omitempty" json:"order_id,omitempty"`
+	ClientOrderID string  `bson:"client_order_id,omitempty" json:"client_order_id,omitempty"`
+	Status        string  `bson:"status,omitempty" json:"status,omitempty"` // of the order on Binance
+	FilledQty     float64 `bson:"filled_qty" json:"filled_qty"`
+	AvgPrice      float64 `bson:"avg_price,omitempty" json:"avg_price,omitempty"`
+	UnwindOrderID int64   `bson:"unwind_order_id,omitempty" json:"unwind_order_id,omitempty"`
+	Error         string  `bson:"error,omitempty" json:"error,omitempty"` // why the order could not be placed
+
+	MarkPrice float64 `bson:"-" json:"mark_price,omitempty"`
+	PnL       float64 `bson:"-" json:"pnl,omitempty"`
+}
+
+// Kline is a closed USDⓈ-M futures candlestick, cached from Binance
+type Kline struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	Symbol      string             `bson:"symbol" json:"symbol"`
+	Interval    string             `bson:"interval" json:"interval"` // e.g. 1m, 1h, 1d
+	OpenTime    time.Time          `bson:"open_time" json:"open_time"`
+	CloseTime   time.Time          `bson:"close_time" json:"close_time"`
+	Open        float64            `bson:"open" json:"open"`
+	High        float64            `bson:"high" json:"high"`
+	Low         float64            `bson:"low" json:"low"`
+	Close       float64            `bson:"close" json:"close"`
+	Volume      float64            `bson:"volume" json:"volume"`
+	QuoteVolume float64            `bson:"quote_volume" json:"quote_volume"`
+	Trades      int64              `bson:"trades" json:"trades"`
+}
+
+// Targets of journal entries
+const (
+	JournalFuturesOrder = "futures_order"
+	JournalOptionsOrder = "options_order"
+	JournalSpotOrder    = "spot_order"
+	JournalPosition     = "position"
+)
+
+// JournalEntry is a note and tags attached to a stored order or position for later review
+type JournalEntry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrderID   primitive.ObjectID `bson:"order_id" json:"order_id"` // Mongo ID of the order or position
+	Target    string             `bson:"target" json:"target"`     // futures_order, options_order, spot_order or position
+	Symbol    string             `bson:"symbol" json:"symbol"`
+	Note      string             `bson:"note,omitempty" json:"note,omitempty"`
+	Tags      []string           `bson:"tags" json:"tags"` // lower case, e.g. news spike
+	CreatedBy string             `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// OrderTemplate is a stored advanced futures order request
+type OrderTemplate struct {
+	Symbol                  string     `bson:"symbol" json:"symbol"`
+	Side                    string     `bson:"side" json:"side"`
+	OrderType               string     `bson:"order_type" json:"order_type"`
+	Quantity                float64    `bson:"quantity" json:"quantity"`
+	QuoteQuantity           float64    `bson:"quote_quantity,omitempty" json:"quote_quantity,omitempty"`
+	Price                   float64    `bson:"price,omitempty" json:"price,omitempty"`
+	StopPrice               float64    `bson:"stop_price,omitempty" json:"stop_price,omitempty"`
+	ActivationPrice         float64    `bson:"activation_price,omitempty" json:"activation_price,omitempty"`
+	CallbackRate            float64    `bson:"callback_rate,omitempty" json:"callback_rate,omitempty"`
+	Leverage                int        `bson:"leverage" json:"leverage"`
+	PositionSide            string     `bson:"position_side,omitempty" json:"position_side,omitempty"`
+	TimeInForce             string     `bson:"time_in_force,omitempty" json:"time_in_force,omitempty"`
+	WorkingType             string     `bson:"working_type,omitempty" json:"working_type,omitempty"`
+	ReduceOnly              bool       `bson:"reduce_only,omitempty" json:"reduce_only,omitempty"`
+	ClosePosition           bool       `bson:"close_position,omitempty" json:"close_position,omitempty"`
+	SelfTradePreventionMode string     `bson:"self_trade_prevention_mode,omitempty" json:"self_trade_prevention_mode,omitempty"`
+	PriceMatch              string     `bson:"price_match,omitempty" json:"price_match,omitempty"`
+	NewOrderRespType        string     `bson:"new_order_resp_type,omitempty" json:"new_order_resp_type,omitempty"`
+	ClientOrderID           string     `bson:"client_order_id,omitempty" json:"client_order_id,omitempty"`
+	GoodTillDate            *time.Time `bson:"good_till_date,omitempty" json:"good_till_date,omitempty"`
+	OverrideRiskLimits      bool       `bson:"override_risk_limits,omitempty" json:"override_risk_limits,omitempty"`
+}
+
+// NamedOrderTemplate is an order skeleton saved under a name and replayed with the quantity and
+// prices supplied at execution
+type NamedOrderTemplate struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	Order     OrderTemplate      `bson:"order" json:"order"` // zero quantity and prices are placeholders
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// WatchlistEntry is a symbol on the watchlist
+type WatchlistEntry struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Symbol  string             `bson:"symbol" json:"symbol"`
+	AddedAt time.Time          `bson:"added_at" json:"added_at"`
+}
+
+// ReconciliationReport records what a reconciliation with Binance changed locally
+type ReconciliationReport struct {
+	ID            primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	Trigger       string                `bson:"trigger" json:"trigger"` // startup, manual or stream_restart
+	StartedAt     time.Time             `bson:"started_at" json:"started_at"`
+	FinishedAt    time.Time             `bson:"finished_at" json:"finished_at"`
+	OrdersChecked int                   `bson:"orders_checked" json:"orders_checked"`
+	Imported      []ReconciledOrder     `bson:"imported,omitempty" json:"imported,omitempty"` // open on Binance, missing locally
+	Updated       []ReconciledOrder     `bson:"updated,omitempty" json:"updated,omitempty"`   // status drifted
+	Closed        []ReconciledOrder     `bson:"closed,omitempty" json:"closed,omitempty"`     // unknown to Binance
+	Positions     []ReconciledPositions `bson:"positions,omitempty" json:"positions,omitempty"`
+	Errors        []string              `bson:"errors,omitempty" json:"errors,omitempty"`
+}
+
+// ReconciledOrder is one order a reconciliation changed
+type ReconciledOrder struct {
+	BinanceOrderID int64  `bson:"binance_order_id" json:"binance_order_id"`
+	ClientOrderID  string `bson:"client_order_id,omitempty" json:"client_order_id,omitempty"`
+	Symbol         string `bson:"symbol" json:"symbol"`
+	Market         Market `bson:"market" json:"market"`
+	From           string `bson:"from,omitempty" json:"from,omitempty"` // empty for imported orders
+	To             string `bson:"to" json:"to"`
+	Note           string `bson:"note,omitempty" json:"note,omitempty"`
+}
+
+// ReconciledPositions is the outcome of refreshing one market's stored positions
+type ReconciledPositions struct {
+	Market   Market `bson:"market" json:"market"`
+	Upserted int64  `bson:"upserted" json:"upserted"`
+	Cleared  int64  `bson:"cleared" json:"cleared"`
+	Skipped  int    `bson:"skipped,omitempty" json:"skipped,omitempty"`
+}
+
+// JobRun records the latest run of a background job and its run counts
+type JobRun struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	Name            string             `bson:"name" json:"name"`
+	Trigger         string             `bson:"trigger" json:"trigger"` // scheduled or manual
+	StartedAt       time.Time          `bson:"started_at" json:"started_at"`
+	FinishedAt      time.Time          `bson:"finished_at" json:"finished_at"`
+	DurationMs      int64              `bson:"duration_ms" json:"duration_ms"`
+	Error           string             `bson:"error,omitempty" json:"error,omitempty"`
+	Runs            int64              `bson:"runs" json:"runs"`
+	Failures        int64              `bson:"failures" json:"failures"`
+	LastSucceededAt *time.Time         `bson:"last_succeeded_at,omitempty" json:"last_succeeded_at,omitempty"`
+}
+
 // WebSocketMessage represents a WebSocket message
 type WebSocketMessage struct {
 	EventType string      `json:"e"`
@@ -0,0 +1,207 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// chatWebhookQueueSize bounds the messages waiting to be posted; further notifications are dropped
+	chatWebhookQueueSize = 100
+	chatWebhookTimeout   = 10 * time.Second
+	// chatWebhookMaxBackoff caps the delay between delivery attempts
+	chatWebhookMaxBackoff = 5 * time.Minute
+)
+
+// ChatWebhookConfig configures a Slack or Discord incoming webhook destination
+type ChatWebhookConfig struct {
+	URL string
+	// Events lists the event types to post; empty posts every event type
+	Events      []string
+	MaxAttempts int           // attempts per message before it is dropped
+	BaseDelay   time.Duration // first retry delay, doubled per attempt
+}
+
+// ChatWebhookNotifier posts formatted messages to a chat incoming webhook (Slack or Discord).
+// Messages are queued and posted in order in the background; failed posts are retried with
+// exponential backoff, or after the Retry-After the service asks for.
+type ChatWebhookNotifier struct {
+	name   string
+	url    string
+	format func(n *Notification) ([]byte, error)
+	events eventFilter
+	stats  deliveryStats
+	client *http.Client
+
+	maxAttempts int
+	baseDelay   time.Duration
+
+	queue chan *Notification
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newChatWebhookNotifier(name string, cfg ChatWebhookConfig, format func(*Notification) ([]byte, error)) *ChatWebhookNotifier {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	c := &ChatWebhookNotifier{
+		name:        name,
+		url:         cfg.URL,
+		format:      format,
+		events:      newEventFilter(cfg.Events),
+		client:      &http.Client{Timeout: chatWebhookTimeout},
+		maxAttempts: cfg.MaxAttempts,
+		baseDelay:   cfg.BaseDelay,
+		queue:       make(chan *Notification, chatWebhookQueueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+// Name returns the notifier name
+func (c *ChatWebhookNotifier) Name() string {
+	return c.name
+}
+
+// Notify queues n if its event type is enabled for this destination
+func (c *ChatWebhookNotifier) Notify(ctx context.Context, n *Notification) error {
+	if !c.events.allows(n.EventType) {
+		return nil
+	}
+	select {
+	case c.queue <- n:
+		return nil
+	default:
+		return fmt.Errorf("%s queue full, dropping %q", c.name, n.Title)
+	}
+}
+
+// Send posts n once, immediately and regardless of the enabled event types
+func (c *ChatWebhookNotifier) Send(ctx context.Context, n *Notification) error {
+	body, err := c.format(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message: %w", c.name, err)
+	}
+	_, err = c.post(ctx, body)
+	c.stats.record(err)
+	return err
+}
+
+// Status reports the delivery counters and the last success and failure
+func (c *ChatWebhookNotifier) Status() DestinationStatus {
+	return c.stats.status(c.name, c.events, len(c.queue))
+}
+
+// Close stops accepting notifications and posts the queued ones, making a single attempt
+// each instead of retrying
+func (c *ChatWebhookNotifier) Close(ctx context.Context) error {
+	c.once.Do(func() {
+		close(c.stop)
+		close(c.queue)
+	})
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s messages not sent: %w", c.name, ctx.Err())
+	}
+}
+
+func (c *ChatWebhookNotifier) run() {
+	defer close(c.done)
+	for n := range c.queue {
+		err := c.deliver(n)
+		c.stats.record(err)
+		if err != nil {
+			slog.Warn("failed to post notification", "notifier", c.name, "event_type", n.EventType, "error", err)
+		}
+	}
+}
+
+// deliver posts n until it succeeds, the attempts run out or the notifier is closed
+func (c *ChatWebhookNotifier) deliver(n *Notification) error {
+	body, err := c.format(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message: %w", c.name, err)
+	}
+
+	delay := c.baseDelay
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), chatWebhookTimeout)
+		retryAfter, err := c.post(ctx, body)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt >= c.maxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		wait := delay
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		select {
+		case <-c.stop:
+			return fmt.Errorf("%w (shutting down before retry)", err)
+		case <-time.After(wait):
+		}
+		delay *= 2
+		if delay > chatWebhookMaxBackoff {
+			delay = chatWebhookMaxBackoff
+		}
+	}
+}
+
+// post makes one delivery attempt; on 429 it also returns the Retry-After delay
+func (c *ChatWebhookNotifier) post(ctx context.Context, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build %s request", c.name)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// The webhook URL is a credential, so report only the underlying error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return 0, fmt.Errorf("%s request failed: %w", c.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		return 0, nil
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("%s returned %d: %s", c.name, resp.StatusCode, bytes.TrimSpace(detail))
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return parseRetryAfter(resp.Header.Get("Retry-After")), err
+	}
+	return 0, err
+}
+
+// parseRetryAfter reads a Retry-After header given in (possibly fractional) seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,73 @@
+package notifications
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// discordMaxFields is the most fields Discord accepts in one embed
+const discordMaxFields = 25
+
+// Embed colors by priority
+const (
+	discordColorLow    = 0x95A5A6
+	discordColorNormal = 0x3498DB
+	discordColorHigh   = 0xE74C3C
+)
+
+// NewDiscordNotifier posts notifications to a Discord webhook as embeds
+func NewDiscordNotifier(cfg ChatWebhookConfig) *ChatWebhookNotifier {
+	return newChatWebhookNotifier("discord", cfg, formatDiscordMessage)
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Footer      *discordFooter `json:"footer,omitempty"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+}
+
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+// formatDiscordMessage renders n as one embed colored by priority, with the fields inline
+func formatDiscordMessage(n *Notification) ([]byte, error) {
+	embed := discordEmbed{
+		Title:       n.Title,
+		Description: n.Message,
+		Color:       discordColorNormal,
+	}
+	switch n.Priority {
+	case PriorityHigh:
+		embed.Color = discordColorHigh
+	case PriorityLow:
+		embed.Color = discordColorLow
+	}
+
+	for _, k := range orderedFieldKeys(n) {
+		if len(embed.Fields) == discordMaxFields {
+			break
+		}
+		value := n.Fields[k]
+		if value == "" {
+			value = "-" // Discord rejects empty field values
+		}
+		embed.Fields = append(embed.Fields, discordField{Name: k, Value: value, Inline: true})
+	}
+	if n.EventType != "" {
+		embed.Footer = &discordFooter{Text: n.EventType}
+	}
+	if !n.CreatedAt.IsZero() {
+		embed.Timestamp = n.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	return json.Marshal(map[string]interface{}{"embeds": []discordEmbed{embed}})
+}
@@ -0,0 +1,208 @@
+package notifications
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Priority represents how urgently a notification should be delivered
+type Priority string
+
+const (
+	PriorityLow    Priority = "LOW"
+	PriorityNormal Priority = "NORMAL"
+	PriorityHigh   Priority = "HIGH"
+)
+
+// Event types tag notifications so webhooks and chat channels can subscribe to them
+const (
+	EventOrderFilled        = "ORDER_FILLED"
+	EventOrderCanceled      = "ORDER_CANCELED"
+	EventOrderRejected      = "ORDER_REJECTED"
+	EventPositionLiquidated = "POSITION_LIQUIDATED"
+	EventMarginCall         = "MARGIN_CALL"
+	EventDailyLossLimit     = "DAILY_LOSS_LIMIT"
+	EventStreamDown         = "STREAM_DOWN"
+	EventKillSwitch         = "KILL_SWITCH"
+	EventTradingPause       = "TRADING_PAUSE"
+	EventSymbolStatus       = "SYMBOL_STATUS"
+	EventFundingPayment     = "FUNDING_PAYMENT"
+	EventTest               = "TEST"
+)
+
+// EventTypes lists the event types that can be subscribed to
+var EventTypes = []string{
+	EventOrderFilled, EventOrderCanceled, EventOrderRejected, EventPositionLiquidated,
+	EventMarginCall, EventDailyLossLimit, EventStreamDown, EventKillSwitch, EventTradingPause,
+	EventSymbolStatus, EventFundingPayment, EventTest,
+}
+
+// Sender is a notifier that can deliver a notification immediately, bypassing its
+// event filter and queue, so the channel's setup can be verified
+type Sender interface {
+	Notifier
+	Send(ctx context.Context, n *Notification) error
+}
+
+// Notification is a single message pushed to the configured notifiers
+type Notification struct {
+	Title     string            `json:"title"`
+	Message   string            `json:"message"`
+	Priority  Priority          `json:"priority"`
+	EventType string            `json:"event_type,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Notifier delivers notifications to an external channel
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// StatusReporter is a notifier that tracks its deliveries
+type StatusReporter interface {
+	Notifier
+	Status() DestinationStatus
+}
+
+// DestinationStatus summarizes the deliveries to one notification destination
+type DestinationStatus struct {
+	Name        string     `json:"name"`
+	Events      []string   `json:"events,omitempty"` // empty means every event type
+	Queued      int        `json:"queued"`
+	Sent        int64      `json:"sent"`
+	Failed      int64      `json:"failed"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastFailure *time.Time `json:"last_failure,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// eventFilter holds the enabled event types; nil enables every type
+type eventFilter map[string]bool
+
+func newEventFilter(events []string) eventFilter {
+	if len(events) == 0 {
+		return nil
+	}
+	f := make(eventFilter, len(events))
+	for _, e := range events {
+		f[strings.ToUpper(strings.TrimSpace(e))] = true
+	}
+	return f
+}
+
+func (f eventFilter) allows(eventType string) bool {
+	return f == nil || f[eventType]
+}
+
+func (f eventFilter) list() []string {
+	events := make([]string, 0, len(f))
+	for e := range f {
+		events = append(events, e)
+	}
+	sort.Strings(events)
+	return events
+}
+
+// deliveryStats records the outcome of each delivery for DestinationStatus
+type deliveryStats struct {
+	mu          sync.Mutex
+	sent        int64
+	failed      int64
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   string
+}
+
+func (d *deliveryStats) record(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err != nil {
+		d.failed++
+		d.lastFailure = time.Now()
+		d.lastError = err.Error()
+		return
+	}
+	d.sent++
+	d.lastSuccess = time.Now()
+}
+
+func (d *deliveryStats) status(name string, events eventFilter, queued int) DestinationStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := DestinationStatus{
+		Name:      name,
+		Events:    events.list(),
+		Queued:    queued,
+		Sent:      d.sent,
+		Failed:    d.failed,
+		LastError: d.lastError,
+	}
+	if !d.lastSuccess.IsZero() {
+		t := d.lastSuccess
+		s.LastSuccess = &t
+	}
+	if !d.lastFailure.IsZero() {
+		t := d.lastFailure
+		s.LastFailure = &t
+	}
+	return s
+}
+
+// fieldOrder puts the trading fields first when a notification is rendered
+var fieldOrder = []string{"symbol", "side", "position_side", "type", "quantity", "filled_quantity", "price", "average_price", "realized_pnl"}
+
+// orderedFieldKeys returns the keys of n.Fields: the trading fields in fieldOrder, then the
+// rest alphabetically
+func orderedFieldKeys(n *Notification) []string {
+	keys := make([]string, 0, len(n.Fields))
+	known := make(map[string]bool, len(fieldOrder))
+	for _, k := range fieldOrder {
+		known[k] = true
+		if _, ok := n.Fields[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	var rest []string
+	for k := range n.Fields {
+		if !known[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// LogNotifier writes notifications to the application log
+type LogNotifier struct{}
+
+// NewLogNotifier creates a notifier that logs every notification
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// Name returns the notifier name
+func (l *LogNotifier) Name() string {
+	return "log"
+}
+
+// Notify logs the notification with its fields in a stable order
+func (l *LogNotifier) Notify(ctx context.Context, n *Notification) error {
+	keys := make([]string, 0, len(n.Fields))
+	for k := range n.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+n.Fields[k])
+	}
+	log.Printf("[NOTIFY][%s] %s: %s %s", n.Priority, n.Title, n.Message, strings.Join(parts, " "))
+	return nil
+}
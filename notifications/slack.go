@@ -0,0 +1,65 @@
+package notifications
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// slackMaxSectionFields is the most fields Slack renders in one section block
+const slackMaxSectionFields = 10
+
+// NewSlackNotifier posts notifications to a Slack incoming webhook as Block Kit messages
+func NewSlackNotifier(cfg ChatWebhookConfig) *ChatWebhookNotifier {
+	return newChatWebhookNotifier("slack", cfg, formatSlackMessage)
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+// formatSlackMessage renders n as a header, the message, the fields two per row and the event
+// type; text is the fallback shown in notifications
+func formatSlackMessage(n *Notification) ([]byte, error) {
+	title := n.Title
+	if n.Priority == PriorityHigh {
+		title = "⚠ " + title
+	}
+	blocks := []slackBlock{{Type: "header", Text: &slackText{Type: "plain_text", Text: title}}}
+	if n.Message != "" {
+		blocks = append(blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: slackEscape(n.Message)}})
+	}
+
+	var fields []slackText
+	for _, k := range orderedFieldKeys(n) {
+		fields = append(fields, slackText{Type: "mrkdwn", Text: "*" + slackEscape(k) + "*\n" + slackEscape(n.Fields[k])})
+	}
+	for len(fields) > 0 {
+		size := len(fields)
+		if size > slackMaxSectionFields {
+			size = slackMaxSectionFields
+		}
+		blocks = append(blocks, slackBlock{Type: "section", Fields: fields[:size]})
+		fields = fields[size:]
+	}
+
+	if n.EventType != "" {
+		blocks = append(blocks, slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: string(n.Priority) + " · " + n.EventType}}})
+	}
+	return json.Marshal(map[string]interface{}{
+		"text":   n.Title + ": " + n.Message,
+		"blocks": blocks,
+	})
+}
+
+// slackEscape escapes the characters Slack treats as markup in mrkdwn text
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,268 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	telegramAPIBase = "https://api.telegram.org"
+	// telegramQueueSize bounds the messages waiting to be sent; further notifications are dropped
+	telegramQueueSize = 100
+	// telegramMaxAttempts is how often a message is retried after Telegram answers 429
+	telegramMaxAttempts = 3
+	telegramTimeout     = 10 * time.Second
+)
+
+// TelegramConfig configures the Telegram notifier
+type TelegramConfig struct {
+	BotToken string
+	ChatID   string
+	// Events lists the event types to send; empty sends every event type
+	Events []string
+	// PerMinute caps the messages sent per minute (Telegram allows about 20 per minute in a group)
+	PerMinute int
+}
+
+// TelegramNotifier sends notifications to a Telegram chat through the Bot API. Messages are
+// queued and sent in the background, spaced to stay under the configured rate.
+type TelegramNotifier struct {
+	token  string
+	chatID string
+	events eventFilter
+	stats  deliveryStats
+	client *http.Client
+
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time // earliest time the next message may be sent
+
+	queue chan *Notification
+	done  chan struct{}
+	once  sync.Once
+}
+
+// NewTelegramNotifier starts a Telegram notifier; Close it on shutdown
+func NewTelegramNotifier(cfg TelegramConfig) *TelegramNotifier {
+	perMinute := cfg.PerMinute
+	if perMinute <= 0 {
+		perMinute = 20
+	}
+	t := &TelegramNotifier{
+		token:    cfg.BotToken,
+		chatID:   cfg.ChatID,
+		client:   &http.Client{Timeout: telegramTimeout},
+		interval: time.Minute / time.Duration(perMinute),
+		queue:    make(chan *Notification, telegramQueueSize),
+		done:     make(chan struct{}),
+		events:   newEventFilter(cfg.Events),
+	}
+	go t.run()
+	return t
+}
+
+// Name returns the notifier name
+func (t *TelegramNotifier) Name() string {
+	return "telegram"
+}
+
+// Enabled reports whether notifications of eventType are sent
+func (t *TelegramNotifier) Enabled(eventType string) bool {
+	return t.events.allows(eventType)
+}
+
+// Status reports the delivery counters and the last success and failure
+func (t *TelegramNotifier) Status() DestinationStatus {
+	return t.stats.status(t.Name(), t.events, len(t.queue))
+}
+
+// Notify queues n if its event type is enabled; it never blocks on Telegram
+func (t *TelegramNotifier) Notify(ctx context.Context, n *Notification) error {
+	if !t.Enabled(n.EventType) {
+		return nil
+	}
+	select {
+	case t.queue <- n:
+		return nil
+	default:
+		return fmt.Errorf("telegram queue full, dropping %q", n.Title)
+	}
+}
+
+// Send delivers n immediately, regardless of the enabled event types
+func (t *TelegramNotifier) Send(ctx context.Context, n *Notification) error {
+	err := t.send(ctx, n)
+	t.stats.record(err)
+	return err
+}
+
+// Close stops accepting notifications and waits until the queued ones are sent
+func (t *TelegramNotifier) Close(ctx context.Context) error {
+	t.once.Do(func() {
+		close(t.queue)
+	})
+	select {
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("telegram messages not sent: %w", ctx.Err())
+	}
+}
+
+func (t *TelegramNotifier) run() {
+	defer close(t.done)
+	for n := range t.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err := t.send(ctx, n)
+		t.stats.record(err)
+		if err != nil {
+			slog.Warn("failed to send telegram message", "event_type", n.EventType, "error", err)
+		}
+		cancel()
+	}
+}
+
+// telegramResponse is the Bot API's reply envelope
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// send posts n, waiting for the rate limit and retrying when Telegram answers 429
+func (t *TelegramNotifier) send(ctx context.Context, n *Notification) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  t.chatID,
+		"text":                     formatTelegramMessage(n),
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode telegram message: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := t.wait(ctx); err != nil {
+			return err
+		}
+		retryAfter, err := t.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if retryAfter <= 0 || attempt >= telegramMaxAttempts {
+			return err
+		}
+		t.backOff(retryAfter)
+	}
+}
+
+// post makes one sendMessage call; on 429 it returns the delay Telegram asked for
+func (t *TelegramNotifier) post(ctx context.Context, body []byte) (time.Duration, error) {
+	endpoint := telegramAPIBase + "/bot" + t.token + "/sendMessage"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.New("failed to build telegram request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// The URL holds the bot token, so report only the underlying error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return 0, fmt.Errorf("telegram request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result telegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil && resp.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("failed to decode telegram response: %w", err)
+	}
+	if resp.StatusCode == http.StatusOK && result.OK {
+		return 0, nil
+	}
+
+	err = fmt.Errorf("telegram returned %d: %s", resp.StatusCode, result.Description)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter := time.Duration(result.Parameters.RetryAfter) * time.Second
+		if retryAfter <= 0 {
+			retryAfter = time.Second
+		}
+		return retryAfter, err
+	}
+	return 0, err
+}
+
+// wait blocks until the rate limit allows another message
+func (t *TelegramNotifier) wait(ctx context.Context) error {
+	t.mu.Lock()
+	now := time.Now()
+	at := t.next
+	if at.Before(now) {
+		at = now
+	}
+	t.next = at.Add(t.interval)
+	t.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// backOff holds further messages until Telegram's retry_after has passed
+func (t *TelegramNotifier) backOff(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := time.Now().Add(d); until.After(t.next) {
+		t.next = until
+	}
+}
+
+// formatTelegramMessage renders n as Telegram HTML: a bold title, the message and its fields,
+// trading fields first
+func formatTelegramMessage(n *Notification) string {
+	var b strings.Builder
+	if n.Priority == PriorityHigh {
+		b.WriteString("⚠ ")
+	}
+	b.WriteString("<b>" + html.EscapeString(n.Title) + "</b>")
+	if n.Message != "" {
+		b.WriteString("\n" + html.EscapeString(n.Message))
+	}
+
+	keys := orderedFieldKeys(n)
+	if len(keys) > 0 {
+		b.WriteString("\n")
+	}
+	for _, k := range keys {
+		b.WriteString("\n" + html.EscapeString(k) + ": <code>" + html.EscapeString(n.Fields[k]) + "</code>")
+	}
+	if n.EventType != "" {
+		b.WriteString("\n\n#" + strings.ToLower(n.EventType))
+	}
+	return b.String()
+}
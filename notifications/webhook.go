@@ -0,0 +1,64 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Headers sent with every webhook request
+const (
+	SignatureHeader = "X-Webhook-Signature" // "sha256=" + hex HMAC-SHA256 of the body
+	EventTypeHeader = "X-Webhook-Event"
+	EventIDHeader   = "X-Webhook-Id"
+)
+
+// WebhookEvent is the JSON body posted to webhooks
+type WebhookEvent struct {
+	ID        string            `json:"id"`
+	EventType string            `json:"event_type"`
+	Title     string            `json:"title"`
+	Message   string            `json:"message"`
+	Priority  Priority          `json:"priority"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Sign returns the signature header value for body: "sha256=" followed by the hex HMAC-SHA256
+// of the body keyed with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// PostWebhook sends one signed delivery attempt and returns the response status. A non-2xx
+// status is returned together with an error.
+func PostWebhook(ctx context.Context, client *http.Client, url, secret string, event *WebhookEvent, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+	req.Header.Set(EventTypeHeader, event.EventType)
+	req.Header.Set(EventIDHeader, event.ID)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
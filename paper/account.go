@@ -0,0 +1,214 @@
+package paper
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Income types reported for simulated fills, as in Binance's income history
+const (
+	incomeRealizedPnl = "REALIZED_PNL"
+	incomeCommission  = "COMMISSION"
+)
+
+// AccountSnapshot is the paper account's wallet, margin and positions
+type AccountSnapshot struct {
+	Paper            bool                    `json:"paper"`
+	Asset            string                  `json:"asset"`
+	WalletBalance    float64                 `json:"wallet_balance"`
+	UnrealizedPnl    float64                 `json:"unrealized_pnl"`
+	MarginBalance    float64                 `json:"margin_balance"`
+	UsedMargin       float64                 `json:"used_margin"`
+	AvailableBalance float64                 `json:"available_balance"`
+	DualSidePosition bool                    `json:"dual_side_position"`
+	OpenOrders       int                     `json:"open_orders"`
+	Positions        []*futures.PositionRisk `json:"positions,omitempty"`
+	UpdatedAt        time.Time               `json:"updated_at"`
+}
+
+// AccountStatus returns the paper account with its positions
+func (c *Client) AccountStatus(ctx context.Context) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	snapshot := c.snapshot()
+	snapshot.Positions = c.positionRisks()
+	return snapshot, nil
+}
+
+// AccountBalance returns the paper account's wallet and margin
+func (c *Client) AccountBalance(ctx context.Context) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.snapshot(), nil
+}
+
+// snapshot builds the account view. c.mu must be held.
+func (c *Client) snapshot() *AccountSnapshot {
+	var unrealized float64
+	for _, p := range c.positions {
+		unrealized += c.unrealizedPnl(p)
+	}
+	return &AccountSnapshot{
+		Paper:            true,
+		Asset:            c.account.Asset,
+		WalletBalance:    c.account.Balance,
+		UnrealizedPnl:    unrealized,
+		MarginBalance:    c.account.Balance + unrealized,
+		UsedMargin:       c.usedMargin(),
+		AvailableBalance: c.availableBalance(),
+		DualSidePosition: c.account.DualSide,
+		OpenOrders:       len(c.open),
+		UpdatedAt:        c.account.UpdatedAt,
+	}
+}
+
+// GetFuturesAccount returns the paper account's totals in Binance's format
+func (c *Client) GetFuturesAccount(ctx context.Context) (*futures.Account, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.snapshot()
+	return &futures.Account{
+		CanTrade:              true,
+		UpdateTime:            s.UpdatedAt.UnixMilli(),
+		TotalInitialMargin:    formatFloat(s.UsedMargin),
+		TotalWalletBalance:    formatFloat(s.WalletBalance),
+		TotalUnrealizedProfit: formatFloat(s.UnrealizedPnl),
+		TotalMarginBalance:    formatFloat(s.MarginBalance),
+		AvailableBalance:      formatFloat(s.AvailableBalance),
+	}, nil
+}
+
+// GetFuturesPositions returns the non-zero paper positions valued at the latest mark price
+func (c *Client) GetFuturesPositions(ctx context.Context) ([]*futures.PositionRisk, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.positionRisks(), nil
+}
+
+// positionRisks converts the open positions to Binance's format. c.mu must be held.
+func (c *Client) positionRisks() []*futures.PositionRisk {
+	var risks []*futures.PositionRisk
+	for _, p := range c.positions {
+		if math.Abs(p.Amount) < epsilon {
+			continue
+		}
+		mark, ok := c.marks[p.Symbol]
+		if !ok {
+			mark = p.EntryPrice
+		}
+		risks = append(risks, &futures.PositionRisk{
+			EntryPrice:       formatFloat(p.EntryPrice),
+			MarginType:       "cross",
+			Leverage:         strconv.Itoa(p.Leverage),
+			MarkPrice:        formatFloat(mark),
+			PositionAmt:      formatFloat(p.Amount),
+			Symbol:           p.Symbol,
+			UnRealizedProfit: formatFloat(c.unrealizedPnl(p)),
+			PositionSide:     p.PositionSide,
+			Notional:         formatFloat(p.Amount * mark),
+		})
+	}
+	sort.Slice(risks, func(i, j int) bool {
+		if risks[i].Symbol != risks[j].Symbol {
+			return risks[i].Symbol < risks[j].Symbol
+		}
+		return risks[i].PositionSide < risks[j].PositionSide
+	})
+	return risks
+}
+
+// GetIncomeHistory reports the realized PnL and commission of paper fills since start
+func (c *Client) GetIncomeHistory(ctx context.Context, start time.Time) ([]*futures.IncomeHistory, error) {
+	filled, err := c.repo.ListFilledSince(ctx, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paper income history: %w", err)
+	}
+
+	var records []*futures.IncomeHistory
+	for _, o := range filled {
+		at := o.FilledAt.UnixMilli()
+		if o.RealizedPnl != 0 {
+			records = append(records, &futures.IncomeHistory{
+				Asset: marginAsset, Income: formatFloat(o.RealizedPnl), IncomeType: incomeRealizedPnl,
+				Symbol: o.Symbol, Time: at, TranID: o.OrderID,
+			})
+		}
+		if o.Commission != 0 {
+			records = append(records, &futures.IncomeHistory{
+				Asset: marginAsset, Income: formatFloat(-o.Commission), IncomeType: incomeCommission,
+				Symbol: o.Symbol, Time: at, TranID: o.OrderID,
+			})
+		}
+	}
+	return records, nil
+}
+
+// SetPositionMode switches between one-way and hedge mode; like Binance it refuses while
+// orders or positions are open
+func (c *Client) SetPositionMode(ctx context.Context, dualSide bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.account.DualSide == dualSide {
+		return apiError(-4059, "No need to change position side.")
+	}
+	if len(c.open) > 0 {
+		return apiError(-4067, "Position side cannot be changed if there exists open orders.")
+	}
+	for _, p := range c.positions {
+		if math.Abs(p.Amount) >= epsilon {
+			return apiError(-4068, "Position side cannot be changed if there exists position.")
+		}
+	}
+
+	c.account.DualSide = dualSide
+	c.account.UpdatedAt = time.Now()
+	if err := c.repo.SaveAccount(ctx, c.account); err != nil {
+		return fmt.Errorf("failed to save paper account: %w", err)
+	}
+	return nil
+}
+
+// ChangeLeverage applies leverage to symbol's open positions; orders without a leverage of
+// their own then use it
+func (c *Client) ChangeLeverage(ctx context.Context, symbol string, leverage int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, p := range c.positions {
+		if p.Symbol != symbol || p.Leverage == leverage {
+			continue
+		}
+		p.Leverage = leverage
+		p.UpdatedAt = time.Now()
+		if err := c.repo.SavePosition(ctx, p); err != nil {
+			return fmt.Errorf("failed to save paper position: %w", err)
+		}
+	}
+	return nil
+}
+
+// ChangeMarginType accepts CROSSED, the only margin type simulated; paper positions all share
+// the wallet balance
+func (c *Client) ChangeMarginType(ctx context.Context, symbol, marginType string) error {
+	if marginType != string(futures.MarginTypeCrossed) {
+		return fmt.Errorf("%s margin is not simulated in paper trading", marginType)
+	}
+	return nil
+}
+
+// GetADLQuantile returns nil: paper positions are never auto-deleveraged
+func (c *Client) GetADLQuantile(ctx context.Context, symbol string) (map[string]int, error) {
+	return nil, nil
+}
+
+// GetPositionMode reports whether hedge mode is on
+func (c *Client) GetPositionMode(ctx context.Context) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.account.DualSide, nil
+}
@@ -0,0 +1,198 @@
+// Package paper simulates order execution against live Binance mark prices, so the API can be
+// exercised without placing orders on any exchange.
+package paper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+const (
+	// marginAsset is the asset the virtual wallet is held in
+	marginAsset = "USDT"
+	// defaultLeverage matches Binance's default for new symbols
+	defaultLeverage = 20
+	// streamRetryDelay is how long to wait before reconnecting the mark price stream
+	streamRetryDelay = 5 * time.Second
+	// storeTimeout bounds each write of engine state triggered by the mark price stream
+	storeTimeout = 5 * time.Second
+)
+
+// Client stands in for *binance.Client in paper trading mode. Orders, positions, income and the
+// position mode are simulated and stored through repository.PaperRepo; everything else (market
+// data, ping, credentials, the circuit breaker) is served by the embedded real client.
+type Client struct {
+	*binance.Client
+
+	repo            repository.PaperRepo
+	startingBalance float64
+
+	mu        sync.Mutex // guards everything below
+	marks     map[string]float64
+	open      map[int64]*models.PaperOrder
+	positions map[string]*models.PaperPosition // by positionKey
+	account   *models.PaperAccount
+	lastID    int64
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewClient wraps real, which is used for market data only
+func NewClient(real *binance.Client, repo repository.PaperRepo, startingBalance float64) *Client {
+	return &Client{
+		Client:          real,
+		repo:            repo,
+		startingBalance: startingBalance,
+		marks:           make(map[string]float64),
+		open:            make(map[int64]*models.PaperOrder),
+		positions:       make(map[string]*models.PaperPosition),
+		done:            make(chan struct{}),
+	}
+}
+
+// Start loads the stored book and subscribes to the mark price stream until ctx is done
+func (c *Client) Start(ctx context.Context) error {
+	if err := c.load(ctx); err != nil {
+		return err
+	}
+	go c.streamMarkPrices(ctx)
+	return nil
+}
+
+// Stop waits for the mark price stream to close; it ends when the context passed to Start is done
+func (c *Client) Stop(ctx context.Context) error {
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) load(ctx context.Context) error {
+	account, err := c.repo.GetAccount(ctx)
+	if errors.Is(err, repository.ErrNotFound) {
+		now := time.Now()
+		account = &models.PaperAccount{Asset: marginAsset, Balance: c.startingBalance, CreatedAt: now, UpdatedAt: now}
+		err = c.repo.SaveAccount(ctx, account)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load paper account: %w", err)
+	}
+
+	open, err := c.repo.ListOpenOrders(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load paper orders: %w", err)
+	}
+	positions, err := c.repo.ListPositions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load paper positions: %w", err)
+	}
+	lastID, err := c.repo.MaxOrderID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load paper order IDs: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.account = account
+	c.lastID = lastID
+	for _, o := range open {
+		c.open[o.OrderID] = o
+	}
+	for _, p := range positions {
+		c.positions[positionKey(p.Symbol, p.PositionSide)] = p
+	}
+	slog.Info("paper trading engine loaded", "balance", account.Balance, "open_orders", len(open), "positions", len(positions))
+	return nil
+}
+
+// streamMarkPrices feeds every symbol's mark price to the matcher, reconnecting on failure
+func (c *Client) streamMarkPrices(ctx context.Context) {
+	defer c.stopOnce.Do(func() { close(c.done) })
+
+	for {
+		doneC, stopC, err := futures.WsAllMarkPriceServeWithRate(time.Second, func(event futures.WsAllMarkPriceEvent) {
+			for _, e := range event {
+				if price, err := strconv.ParseFloat(e.MarkPrice, 64); err == nil && price > 0 {
+					c.onMarkPrice(ctx, e.Symbol, price)
+				}
+			}
+		}, func(err error) {
+			slog.Warn("paper trading mark price stream error", "error", err)
+		})
+		if err != nil {
+			slog.Warn("failed to connect paper trading mark price stream", "error", err)
+		} else {
+			select {
+			case <-ctx.Done():
+				close(stopC)
+				<-doneC
+				return
+			case <-doneC:
+				slog.Warn("paper trading mark price stream closed, reconnecting")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(streamRetryDelay):
+		}
+	}
+}
+
+// onMarkPrice records a symbol's mark price and fills the open orders it triggers
+func (c *Client) onMarkPrice(ctx context.Context, symbol string, price float64) {
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), storeTimeout)
+	defer cancel()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.marks[symbol] = price
+	for _, o := range c.sortedOpenOrders(symbol) {
+		if err := c.match(storeCtx, o, price, false); err != nil {
+			slog.Warn("failed to fill paper order", "symbol", symbol, "binance_order_id", o.OrderID, "error", err)
+		}
+	}
+}
+
+// markPrice returns the latest streamed mark price, fetching it from Binance before the stream
+// has delivered one (through the shared price cache). c.mu must not be held.
+func (c *Client) markPrice(ctx context.Context, symbol string) (float64, error) {
+	c.mu.Lock()
+	price, ok := c.marks[symbol]
+	c.mu.Unlock()
+	if ok {
+		return price, nil
+	}
+
+	mark, err := c.Client.GetPrice(ctx, symbol, futures.WorkingTypeMarkPrice, 0)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	c.marks[symbol] = mark.Value
+	c.mu.Unlock()
+	return mark.Value, nil
+}
+
+func positionKey(symbol, positionSide string) string {
+	return symbol + "/" + positionSide
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,49 @@
+package paper
+
+import (
+	"context"
+	"errors"
+
+	"futures-options/binance"
+
+	"github.com/adshao/go-binance/v2/delivery"
+)
+
+// errCoinMUnsupported is returned by the COIN-M methods, which would otherwise reach the real
+// account through the embedded client. The contract size lookup is market data and stays live.
+var errCoinMUnsupported = errors.New("COIN-M futures are not simulated by the paper trading engine")
+
+// CreateDeliveryOrder is not simulated
+func (c *Client) CreateDeliveryOrder(ctx context.Context, req *binance.AdvancedOrderRequest) (*delivery.CreateOrderResponse, error) {
+	return nil, errCoinMUnsupported
+}
+
+// CancelDeliveryOrders is not simulated
+func (c *Client) CancelDeliveryOrders(ctx context.Context, symbol string, orderIDs []int64, clientOrderIDs []string) ([]*delivery.CancelOrderResponse, error) {
+	return nil, errCoinMUnsupported
+}
+
+// GetDeliveryOrder is not simulated
+func (c *Client) GetDeliveryOrder(ctx context.Context, symbol string, orderID int64) (*delivery.Order, error) {
+	return nil, errCoinMUnsupported
+}
+
+// ListOpenDeliveryOrders is not simulated
+func (c *Client) ListOpenDeliveryOrders(ctx context.Context, symbol string) ([]*delivery.Order, error) {
+	return nil, errCoinMUnsupported
+}
+
+// GetDeliveryAccount is not simulated
+func (c *Client) GetDeliveryAccount(ctx context.Context) (*delivery.Account, error) {
+	return nil, errCoinMUnsupported
+}
+
+// GetDeliveryBalance is not simulated
+func (c *Client) GetDeliveryBalance(ctx context.Context) ([]*delivery.Balance, error) {
+	return nil, errCoinMUnsupported
+}
+
+// GetDeliveryPositions is not simulated
+func (c *Client) GetDeliveryPositions(ctx context.Context) ([]*delivery.PositionRisk, error) {
+	return nil, errCoinMUnsupported
+}
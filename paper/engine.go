@@ -0,0 +1,201 @@
+package paper
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"futures-options/models"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Fees charged on simulated fills, as a fraction of the notional (Binance's base tier)
+const (
+	takerFee = 0.0005
+	makerFee = 0.0002
+)
+
+// Simulated order statuses
+const (
+	statusNew      = string(futures.OrderStatusTypeNew)
+	statusFilled   = string(futures.OrderStatusTypeFilled)
+	statusCanceled = string(futures.OrderStatusTypeCanceled)
+	statusExpired  = string(futures.OrderStatusTypeExpired)
+)
+
+// epsilon treats float residue from position arithmetic as flat
+const epsilon = 1e-9
+
+// sortedOpenOrders returns symbol's open orders oldest first, so they fill in placement order
+func (c *Client) sortedOpenOrders(symbol string) []*models.PaperOrder {
+	var orders []*models.PaperOrder
+	for _, o := range c.open {
+		if symbol == "" || o.Symbol == symbol {
+			orders = append(orders, o)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].OrderID < orders[j].OrderID })
+	return orders
+}
+
+// stopTriggered reports whether a STOP/TAKE_PROFIT order's stop price has been reached
+func stopTriggered(o *models.PaperOrder, mark float64) bool {
+	buy := o.Side == string(futures.SideTypeBuy)
+	switch futures.OrderType(o.Type) {
+	case futures.OrderTypeStop, futures.OrderTypeStopMarket:
+		// Stops buy on the way up and sell on the way down
+		return (buy && mark >= o.StopPrice) || (!buy && mark <= o.StopPrice)
+	case futures.OrderTypeTakeProfit, futures.OrderTypeTakeProfitMarket:
+		return (buy && mark <= o.StopPrice) || (!buy && mark >= o.StopPrice)
+	}
+	return false
+}
+
+// match fills o if mark reaches it. taker is true when o was just placed (or just triggered),
+// in which case a crossing limit fills at the mark price rather than its own. c.mu must be held.
+func (c *Client) match(ctx context.Context, o *models.PaperOrder, mark float64, taker bool) error {
+	switch futures.OrderType(o.Type) {
+	case futures.OrderTypeMarket:
+		return c.fill(ctx, o, mark, takerFee)
+	case futures.OrderTypeStopMarket, futures.OrderTypeTakeProfitMarket:
+		if stopTriggered(o, mark) {
+			return c.fill(ctx, o, mark, takerFee)
+		}
+		return nil
+	case futures.OrderTypeStop, futures.OrderTypeTakeProfit:
+		if !stopTriggered(o, mark) {
+			return nil
+		}
+		// Once triggered the order rests as a plain limit order
+		o.Type = string(futures.OrderTypeLimit)
+		o.UpdatedAt = time.Now()
+		if err := c.repo.SaveOrder(ctx, o); err != nil {
+			return err
+		}
+		taker = true
+	}
+
+	buy := o.Side == string(futures.SideTypeBuy)
+	if (buy && mark > o.Price) || (!buy && mark < o.Price) {
+		return nil
+	}
+	if taker {
+		return c.fill(ctx, o, mark, takerFee)
+	}
+	return c.fill(ctx, o, o.Price, makerFee)
+}
+
+// fill executes o in full at price, updating its position and the wallet. Reduce-only and
+// close-position orders only execute the part that reduces the position; with nothing to
+// reduce they expire. c.mu must be held.
+func (c *Client) fill(ctx context.Context, o *models.PaperOrder, price, fee float64) error {
+	now := time.Now()
+	key := positionKey(o.Symbol, o.PositionSide)
+	pos, ok := c.positions[key]
+	if !ok {
+		pos = &models.PaperPosition{Symbol: o.Symbol, PositionSide: o.PositionSide, Leverage: defaultLeverage}
+	}
+
+	qty := o.Quantity
+	signed := qty
+	if o.Side == string(futures.SideTypeSell) {
+		signed = -qty
+	}
+	if o.ReduceOnly || o.ClosePosition {
+		if math.Abs(pos.Amount) < epsilon || math.Signbit(signed) == math.Signbit(pos.Amount) {
+			return c.finish(ctx, o, statusExpired, now)
+		}
+		if o.ClosePosition || qty > math.Abs(pos.Amount) {
+			qty = math.Abs(pos.Amount)
+			signed = -pos.Amount
+		}
+	}
+
+	var realized float64
+	if math.Abs(pos.Amount) >= epsilon && math.Signbit(signed) != math.Signbit(pos.Amount) {
+		closed := math.Min(qty, math.Abs(pos.Amount))
+		realized = closed * (price - pos.EntryPrice)
+		if pos.Amount < 0 {
+			realized = -realized
+		}
+	}
+
+	next := pos.Amount + signed
+	switch {
+	case math.Abs(next) < epsilon:
+		next = 0
+		pos.EntryPrice = 0
+	case math.Abs(pos.Amount) < epsilon || math.Signbit(next) != math.Signbit(pos.Amount):
+		// Opened, or flipped through zero: the remainder was entered at this price
+		pos.EntryPrice = price
+	case math.Signbit(signed) == math.Signbit(pos.Amount):
+		pos.EntryPrice = (math.Abs(pos.Amount)*pos.EntryPrice + qty*price) / math.Abs(next)
+	}
+	pos.Amount = next
+	if o.Leverage > 0 {
+		pos.Leverage = o.Leverage
+	}
+	pos.UpdatedAt = now
+
+	commission := qty * price * fee
+	c.account.Balance += realized - commission
+	c.account.UpdatedAt = now
+
+	o.ExecutedQty = qty
+	o.AvgPrice = price
+	o.RealizedPnl = realized
+	o.Commission = commission
+	o.FilledAt = &now
+
+	c.positions[key] = pos
+	if err := c.repo.SavePosition(ctx, pos); err != nil {
+		return err
+	}
+	if err := c.repo.SaveAccount(ctx, c.account); err != nil {
+		return err
+	}
+	return c.finish(ctx, o, statusFilled, now)
+}
+
+// finish moves o out of the open book with the given final status. c.mu must be held.
+func (c *Client) finish(ctx context.Context, o *models.PaperOrder, status string, now time.Time) error {
+	o.Status = status
+	o.UpdatedAt = now
+	delete(c.open, o.OrderID)
+	return c.repo.SaveOrder(ctx, o)
+}
+
+// unrealizedPnl is the PnL of p at the latest mark price, or 0 before one is known. c.mu must be held.
+func (c *Client) unrealizedPnl(p *models.PaperPosition) float64 {
+	mark, ok := c.marks[p.Symbol]
+	if !ok {
+		return 0
+	}
+	return p.Amount * (mark - p.EntryPrice)
+}
+
+// usedMargin is the initial margin of every open position at its leverage. c.mu must be held.
+func (c *Client) usedMargin() float64 {
+	var used float64
+	for _, p := range c.positions {
+		mark, ok := c.marks[p.Symbol]
+		if !ok {
+			mark = p.EntryPrice
+		}
+		if p.Leverage > 0 {
+			used += math.Abs(p.Amount) * mark / float64(p.Leverage)
+		}
+	}
+	return used
+}
+
+// availableBalance is the wallet plus unrealized PnL minus the margin in use. c.mu must be held.
+func (c *Client) availableBalance() float64 {
+	available := c.account.Balance - c.usedMargin()
+	for _, p := range c.positions {
+		available += c.unrealizedPnl(p)
+	}
+	return available
+}
@@ -0,0 +1,348 @@
+package paper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+)
+
+// apiError mimics the error Binance returns for the same condition, so handlers map it to the
+// same HTTP status as in live trading
+func apiError(code int64, message string) error {
+	return &common.APIError{Code: code, Message: message}
+}
+
+var errOrderNotFound = apiError(-2013, "Order does not exist.")
+
+// CreateFuturesOrder simulates a MARKET or LIMIT order
+func (c *Client) CreateFuturesOrder(ctx context.Context, symbol string, side futures.SideType, orderType futures.OrderType, quantity, price decimal.Decimal, leverage int, clientOrderID string) (*futures.CreateOrderResponse, error) {
+	return c.place(ctx, &binance.AdvancedOrderRequest{
+		Symbol:        symbol,
+		Side:          string(side),
+		OrderType:     string(orderType),
+		Quantity:      quantity,
+		Price:         price,
+		Leverage:      leverage,
+		ClientOrderID: clientOrderID,
+	})
+}
+
+// CreateAdvancedFuturesOrder simulates MARKET, LIMIT, STOP, STOP_MARKET, TAKE_PROFIT and
+// TAKE_PROFIT_MARKET orders; other types are rejected
+func (c *Client) CreateAdvancedFuturesOrder(ctx context.Context, req *binance.AdvancedOrderRequest) (*futures.CreateOrderResponse, error) {
+	return c.place(ctx, req)
+}
+
+// CreateBatchOrders places each order in turn; like the live client the responses keep the
+// request order, with nil and a *binance.BatchOrderError for orders that failed
+func (c *Client) CreateBatchOrders(ctx context.Context, orders []*binance.AdvancedOrderRequest) ([]*futures.CreateOrderResponse, error) {
+	responses := make([]*futures.CreateOrderResponse, len(orders))
+	errs := make([]error, len(orders))
+	failed := 0
+	for i, req := range orders {
+		if responses[i], errs[i] = c.place(ctx, req); errs[i] != nil {
+			failed++
+		}
+	}
+	switch {
+	case failed == 0:
+		return responses, nil
+	case failed == len(orders):
+		return nil, &binance.BatchOrderError{Errors: errs}
+	default:
+		return responses, &binance.BatchOrderError{Errors: errs}
+	}
+}
+
+func (c *Client) place(ctx context.Context, req *binance.AdvancedOrderRequest) (*futures.CreateOrderResponse, error) {
+	orderType := futures.OrderType(req.OrderType)
+	switch orderType {
+	case futures.OrderTypeMarket, futures.OrderTypeStopMarket, futures.OrderTypeTakeProfitMarket:
+	case futures.OrderTypeLimit, futures.OrderTypeStop, futures.OrderTypeTakeProfit:
+		if !req.Price.IsPositive() {
+			return nil, apiError(-1102, "Mandatory parameter 'price' was not sent, was empty/null, or malformed.")
+		}
+	default:
+		return nil, apiError(-1116, fmt.Sprintf("Invalid orderType: %s is not simulated in paper trading.", req.OrderType))
+	}
+
+	// Fetching the mark price also rejects unknown symbols
+	mark, err := c.markPrice(ctx, req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	positionSide := req.PositionSide
+	if positionSide == "" && !c.account.DualSide {
+		positionSide = string(futures.PositionSideTypeBoth)
+	}
+	dualSide := positionSide == string(futures.PositionSideTypeLong) || positionSide == string(futures.PositionSideTypeShort)
+	if dualSide != c.account.DualSide {
+		return nil, apiError(-4061, "Order's position side does not match user's setting.")
+	}
+
+	now := time.Now()
+	o := &models.PaperOrder{
+		ClientOrderID: req.ClientOrderID,
+		Symbol:        req.Symbol,
+		Side:          req.Side,
+		PositionSide:  positionSide,
+		Type:          req.OrderType,
+		OrigType:      req.OrderType,
+		Quantity:      req.Quantity.InexactFloat64(),
+		Price:         req.Price.InexactFloat64(),
+		StopPrice:     req.StopPrice.InexactFloat64(),
+		TimeInForce:   req.TimeInForce,
+		ReduceOnly:    req.ReduceOnly,
+		ClosePosition: req.ClosePosition,
+		Leverage:      req.Leverage,
+		Status:        statusNew,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if stopTriggered(o, mark) {
+		return nil, apiError(-2021, "Order would immediately trigger.")
+	}
+	buy := o.Side == string(futures.SideTypeBuy)
+	crosses := orderType == futures.OrderTypeLimit && ((buy && mark <= o.Price) || (!buy && mark >= o.Price))
+	if o.TimeInForce == string(futures.TimeInForceTypeGTX) && crosses {
+		return nil, apiError(-5022, "Due to the order could not be executed as maker, the Post Only order will be rejected.")
+	}
+	if !o.ReduceOnly && !o.ClosePosition {
+		if err := c.checkMargin(o, mark); err != nil {
+			return nil, err
+		}
+	}
+
+	c.lastID++
+	o.OrderID = c.lastID
+	if o.ClientOrderID == "" {
+		o.ClientOrderID = "paper-" + strconv.FormatInt(o.OrderID, 10)
+	}
+	c.open[o.OrderID] = o
+	if err := c.repo.SaveOrder(ctx, o); err != nil {
+		delete(c.open, o.OrderID)
+		return nil, fmt.Errorf("failed to save paper order: %w", err)
+	}
+
+	if err := c.match(ctx, o, mark, true); err != nil {
+		return nil, fmt.Errorf("failed to fill paper order: %w", err)
+	}
+	if o.Status == statusNew && (o.TimeInForce == string(futures.TimeInForceTypeIOC) || o.TimeInForce == string(futures.TimeInForceTypeFOK)) {
+		if err := c.finish(ctx, o, statusExpired, now); err != nil {
+			return nil, err
+		}
+	}
+	return createResponse(o), nil
+}
+
+// checkMargin rejects o if its initial margin exceeds the available balance. c.mu must be held.
+func (c *Client) checkMargin(o *models.PaperOrder, mark float64) error {
+	leverage := o.Leverage
+	if leverage <= 0 {
+		leverage = defaultLeverage
+		if p, ok := c.positions[positionKey(o.Symbol, o.PositionSide)]; ok && p.Leverage > 0 {
+			leverage = p.Leverage
+		}
+	}
+	price := mark
+	if o.Price > 0 {
+		price = o.Price
+	}
+	if o.Quantity*price/float64(leverage) > c.availableBalance() {
+		return apiError(-2019, "Margin is insufficient.")
+	}
+	return nil
+}
+
+// ModifyFuturesOrder changes the quantity and prices of an open order, filling it if it now crosses
+func (c *Client) ModifyFuturesOrder(ctx context.Context, req *binance.ModifyOrderRequest) (*futures.CreateOrderResponse, error) {
+	mark, err := c.markPrice(ctx, req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	o := c.findOpen(req.Symbol, req.OrderID, req.ClientOrderID)
+	if o == nil {
+		return nil, errOrderNotFound
+	}
+	if req.Quantity.IsPositive() {
+		o.Quantity = req.Quantity.InexactFloat64()
+	}
+	if req.Price.IsPositive() {
+		o.Price = req.Price.InexactFloat64()
+	}
+	if req.StopPrice.IsPositive() {
+		o.StopPrice = req.StopPrice.InexactFloat64()
+	}
+	o.UpdatedAt = time.Now()
+	if err := c.repo.SaveOrder(ctx, o); err != nil {
+		return nil, fmt.Errorf("failed to save paper order: %w", err)
+	}
+	if err := c.match(ctx, o, mark, true); err != nil {
+		return nil, fmt.Errorf("failed to fill paper order: %w", err)
+	}
+	return createResponse(o), nil
+}
+
+// CancelBatchOrders cancels open orders, skipping the ones that are not open like the live client
+func (c *Client) CancelBatchOrders(ctx context.Context, symbol string, orderIDs []int64, clientOrderIDs []string) ([]*futures.CancelOrderResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var targets []*models.PaperOrder
+	for _, id := range orderIDs {
+		if o := c.findOpen(symbol, id, ""); o != nil {
+			targets = append(targets, o)
+		}
+	}
+	for _, id := range clientOrderIDs {
+		if o := c.findOpen(symbol, 0, id); o != nil {
+			targets = append(targets, o)
+		}
+	}
+
+	var responses []*futures.CancelOrderResponse
+	now := time.Now()
+	for _, o := range targets {
+		if _, open := c.open[o.OrderID]; !open {
+			continue // listed by both IDs
+		}
+		if err := c.finish(ctx, o, statusCanceled, now); err != nil {
+			return responses, fmt.Errorf("failed to save paper order: %w", err)
+		}
+		responses = append(responses, cancelResponse(o))
+	}
+	return responses, nil
+}
+
+// GetFuturesOrder returns an order of any status
+func (c *Client) GetFuturesOrder(ctx context.Context, symbol string, orderID int64) (*futures.Order, error) {
+	c.mu.Lock()
+	if o := c.findOpen(symbol, orderID, ""); o != nil {
+		view := orderView(o)
+		c.mu.Unlock()
+		return view, nil
+	}
+	c.mu.Unlock()
+
+	o, err := c.repo.FindOrder(ctx, symbol, orderID, "")
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paper order: %w", err)
+	}
+	return orderView(o), nil
+}
+
+// ListOpenFuturesOrders returns the open orders of symbol, or of every symbol when it is empty
+func (c *Client) ListOpenFuturesOrders(ctx context.Context, symbol string) ([]*futures.Order, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var orders []*futures.Order
+	for _, o := range c.sortedOpenOrders(symbol) {
+		orders = append(orders, orderView(o))
+	}
+	return orders, nil
+}
+
+// findOpen looks up an open order by ID, or by client order ID when id is 0. c.mu must be held.
+func (c *Client) findOpen(symbol string, id int64, clientOrderID string) *models.PaperOrder {
+	if id != 0 {
+		if o, ok := c.open[id]; ok && o.Symbol == symbol {
+			return o
+		}
+		return nil
+	}
+	for _, o := range c.open {
+		if o.Symbol == symbol && o.ClientOrderID == clientOrderID {
+			return o
+		}
+	}
+	return nil
+}
+
+func createResponse(o *models.PaperOrder) *futures.CreateOrderResponse {
+	return &futures.CreateOrderResponse{
+		Symbol:           o.Symbol,
+		OrderID:          o.OrderID,
+		ClientOrderID:    o.ClientOrderID,
+		Price:            formatFloat(o.Price),
+		OrigQuantity:     formatFloat(o.Quantity),
+		ExecutedQuantity: formatFloat(o.ExecutedQty),
+		CumQuote:         formatFloat(o.ExecutedQty * o.AvgPrice),
+		ReduceOnly:       o.ReduceOnly,
+		Status:           futures.OrderStatusType(o.Status),
+		StopPrice:        formatFloat(o.StopPrice),
+		TimeInForce:      futures.TimeInForceType(o.TimeInForce),
+		Type:             futures.OrderType(o.Type),
+		Side:             futures.SideType(o.Side),
+		UpdateTime:       o.UpdatedAt.UnixMilli(),
+		AvgPrice:         formatFloat(o.AvgPrice),
+		PositionSide:     futures.PositionSideType(o.PositionSide),
+		ClosePosition:    o.ClosePosition,
+	}
+}
+
+func orderView(o *models.PaperOrder) *futures.Order {
+	return &futures.Order{
+		Symbol:           o.Symbol,
+		OrderID:          o.OrderID,
+		ClientOrderID:    o.ClientOrderID,
+		Price:            formatFloat(o.Price),
+		ReduceOnly:       o.ReduceOnly,
+		OrigQuantity:     formatFloat(o.Quantity),
+		ExecutedQuantity: formatFloat(o.ExecutedQty),
+		CumQuantity:      formatFloat(o.ExecutedQty),
+		CumQuote:         formatFloat(o.ExecutedQty * o.AvgPrice),
+		Status:           futures.OrderStatusType(o.Status),
+		TimeInForce:      futures.TimeInForceType(o.TimeInForce),
+		Type:             futures.OrderType(o.Type),
+		Side:             futures.SideType(o.Side),
+		StopPrice:        formatFloat(o.StopPrice),
+		Time:             o.CreatedAt.UnixMilli(),
+		UpdateTime:       o.UpdatedAt.UnixMilli(),
+		AvgPrice:         formatFloat(o.AvgPrice),
+		OrigType:         o.OrigType,
+		PositionSide:     futures.PositionSideType(o.PositionSide),
+		ClosePosition:    o.ClosePosition,
+	}
+}
+
+func cancelResponse(o *models.PaperOrder) *futures.CancelOrderResponse {
+	return &futures.CancelOrderResponse{
+		ClientOrderID:    o.ClientOrderID,
+		CumQuantity:      formatFloat(o.ExecutedQty),
+		CumQuote:         formatFloat(o.ExecutedQty * o.AvgPrice),
+		ExecutedQuantity: formatFloat(o.ExecutedQty),
+		OrderID:          o.OrderID,
+		OrigQuantity:     formatFloat(o.Quantity),
+		Price:            formatFloat(o.Price),
+		ReduceOnly:       o.ReduceOnly,
+		Side:             futures.SideType(o.Side),
+		Status:           futures.OrderStatusType(o.Status),
+		StopPrice:        formatFloat(o.StopPrice),
+		Symbol:           o.Symbol,
+		TimeInForce:      futures.TimeInForceType(o.TimeInForce),
+		Type:             futures.OrderType(o.Type),
+		UpdateTime:       o.UpdatedAt.UnixMilli(),
+		OrigType:         o.OrigType,
+		PositionSide:     futures.PositionSideType(o.PositionSide),
+	}
+}
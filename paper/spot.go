@@ -0,0 +1,34 @@
+package paper
+
+import (
+	"context"
+	"errors"
+
+	"futures-options/binance"
+
+	spot "github.com/adshao/go-binance/v2"
+)
+
+// errSpotUnsupported is returned by the spot account methods, which would otherwise reach the
+// real account through the embedded client. Spot symbol filters are market data and stay live.
+var errSpotUnsupported = errors.New("spot orders are not simulated by the paper trading engine")
+
+// CreateSpotOrder is not simulated
+func (c *Client) CreateSpotOrder(ctx context.Context, req *binance.SpotOrderRequest) (*spot.CreateOrderResponse, error) {
+	return nil, errSpotUnsupported
+}
+
+// GetSpotOrder is not simulated
+func (c *Client) GetSpotOrder(ctx context.Context, symbol string, orderID int64) (*spot.Order, error) {
+	return nil, errSpotUnsupported
+}
+
+// ListOpenSpotOrders is not simulated
+func (c *Client) ListOpenSpotOrders(ctx context.Context, symbol string) ([]*spot.Order, error) {
+	return nil, errSpotUnsupported
+}
+
+// GetSpotBalances is not simulated
+func (c *Client) GetSpotBalances(ctx context.Context) ([]spot.Balance, error) {
+	return nil, errSpotUnsupported
+}
@@ -0,0 +1,23 @@
+package paper
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"futures-options/binance"
+)
+
+// errTransfersUnsupported is returned by the wallet transfer methods; the paper wallet has no
+// spot side to move funds to or from
+var errTransfersUnsupported = errors.New("wallet transfers are not simulated by the paper trading engine")
+
+// UniversalTransfer is not simulated
+func (c *Client) UniversalTransfer(ctx context.Context, transferType, asset string, amount float64) (int64, error) {
+	return 0, errTransfersUnsupported
+}
+
+// ListUniversalTransfers is not simulated
+func (c *Client) ListUniversalTransfers(ctx context.Context, transferType string, start, end time.Time) ([]*binance.TransferRecord, error) {
+	return nil, errTransfersUnsupported
+}
@@ -0,0 +1,2428 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"futures-options/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NewMemoryRepositories builds in-memory repositories, mainly for tests and local experiments
+func NewMemoryRepositories() *Repositories {
+	return &Repositories{
+		Tx:            memoryTransactor{},
+		FuturesOrders: NewMemoryFuturesOrderRepo(),
+		OptionsOrders: NewMemoryOptionsOrderRepo(),
+		SpotOrders:    NewMemorySpotOrderRepo(),
+		Transfers:     NewMemoryTransferRepo(),
+		Positions:     NewMemoryPositionRepo(),
+		RiskEvents:    NewMemoryRiskEventRepo(),
+		Credentials:   NewMemoryCredentialsRepo(),
+		Audit:         NewMemoryAuditRepo(),
+		Tokens:        NewMemoryTokenRepo(),
+		RiskLimits:    NewMemoryRiskLimitRepo(),
+		Settings:      NewMemorySymbolSettingsRepo(),
+		Policy:        NewMemoryTradingPolicyRepo(),
+		Templates:     NewMemoryOrderTemplateRepo(),
+		Watchlist:     NewMemoryWatchlistRepo(),
+		Reconcile:     NewMemoryReconciliationRepo(),
+		Jobs:          NewMemoryJobRepo(),
+		Income:        NewMemoryIncomeRepo(),
+		Fills:         NewMemoryFillRepo(),
+		Equity:        NewMemoryEquityRepo(),
+		Webhooks:      NewMemoryWebhookRepo(),
+		Conditional:   NewMemoryConditionalOrderRepo(),
+		Scheduled:     NewMemoryScheduledOrderRepo(),
+		DCAPlans:      NewMemoryDCAPlanRepo(),
+		Grids:         NewMemoryGridStrategyRepo(),
+		Spreads:       NewMemoryCalendarSpreadRepo(),
+		Klines:        NewMemoryKlineRepo(),
+		Journal:       NewMemoryJournalRepo(),
+		Paper:         NewMemoryPaperRepo(),
+	}
+}
+
+// applySet applies a Mongo-style $set document to v by round-tripping through BSON,
+// so field names follow the same bson tags as in MongoDB
+func applySet(v interface{}, set bson.M) error {
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return err
+	}
+	doc := bson.M{}
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+	for k, val := range set {
+		doc[k] = val
+	}
+	raw, err = bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, v)
+}
+
+// orderFields is the subset of order fields used for filtering and paging
+type orderFields struct {
+	id             primitive.ObjectID
+	symbol         string
+	status         string
+	side           string
+	createdAt      time.Time
+	clientOrderID  string
+	binanceOrderID int64
+	strategyID     string
+	accountID      string
+	executedQty    float64
+	source         string
+}
+
+// matchesLookup applies the client order ID, Binance ID, strategy, source and free-text filters of q
+func (f orderFields) matchesLookup(q *OrderQuery) bool {
+	if q.ClientOrderIDPrefix != "" && !strings.HasPrefix(f.clientOrderID, q.ClientOrderIDPrefix) ||
+		q.BinanceOrderID > 0 && f.binanceOrderID != q.BinanceOrderID ||
+		q.StrategyID != "" && f.strategyID != q.StrategyID ||
+		q.Source != "" && f.source != q.Source {
+		return false
+	}
+	if q.Text == "" {
+		return true
+	}
+	id, _ := strconv.ParseInt(q.Text, 10, 64)
+	return strings.HasPrefix(f.clientOrderID, q.Text) || f.strategyID == q.Text || id > 0 && f.binanceOrderID == id
+}
+
+// partiallyFilled reports whether the order matches OrderQuery.PartiallyFilled
+func (f orderFields) partiallyFilled() bool {
+	return f.status == "PARTIALLY_FILLED" || f.executedQty > 0 && f.status != "FILLED"
+}
+
+// matchOrders returns the indexes of the items matching q's filters, in its sort order
+func matchOrders(q *OrderQuery, n int, fields func(i int) orderFields) []int {
+	var matched []int
+	for i := 0; i < n; i++ {
+		f := fields(i)
+		if q.Symbol != "" && f.symbol != q.Symbol ||
+			q.AccountID != "" && f.accountID != q.AccountID ||
+			q.Status != "" && f.status != q.Status ||
+			q.Side != "" && f.side != q.Side ||
+			q.StartTime != nil && f.createdAt.Before(*q.StartTime) ||
+			q.EndTime != nil && f.createdAt.After(*q.EndTime) ||
+			q.PartiallyFilled && !f.partiallyFilled() ||
+			!f.matchesLookup(q) {
+			continue
+		}
+		matched = append(matched, i)
+	}
+
+	less := func(a, b orderFields) bool {
+		if !a.createdAt.Equal(b.createdAt) {
+			return a.createdAt.Before(b.createdAt)
+		}
+		return a.id.Hex() < b.id.Hex()
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := fields(matched[i]), fields(matched[j])
+		if q.SortAsc {
+			return less(a, b)
+		}
+		return less(b, a)
+	})
+	return matched
+}
+
+// pageOrders filters, sorts and pages items the same way the Mongo repositories do
+func pageOrders(q *OrderQuery, n int, fields func(i int) orderFields) ([]int, int64, error) {
+	matched := matchOrders(q, n, fields)
+	total := int64(len(matched))
+
+	start := 0
+	if q.BeforeID != "" {
+		id, err := primitive.ObjectIDFromHex(q.BeforeID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		start = -1
+		for i, idx := range matched {
+			if fields(idx).id == id {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			return nil, 0, fmt.Errorf("%w: %s not found", ErrInvalidCursor, q.BeforeID)
+		}
+	} else if q.Offset > 0 {
+		start = int(q.Offset)
+	}
+	if start > len(matched) {
+		start = len(matched)
+	}
+
+	end := start + int(q.PageLimit()) + 1
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[start:end], total, nil
+}
+
+// MemoryFuturesOrderRepo is an in-memory FuturesOrderRepo
+type MemoryFuturesOrderRepo struct {
+	mu       sync.RWMutex
+	orders   []*models.FuturesOrder
+	archived []*models.FuturesOrder
+}
+
+func NewMemoryFuturesOrderRepo() *MemoryFuturesOrderRepo {
+	return &MemoryFuturesOrderRepo{}
+}
+
+func (r *MemoryFuturesOrderRepo) Insert(ctx context.Context, order *models.FuturesOrder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.orders {
+		if o.ID == order.ID || order.BinanceOrderID > 0 && o.BinanceOrderID == order.BinanceOrderID {
+			return ErrDuplicate
+		}
+	}
+	copied := *order
+	r.orders = append(r.orders, &copied)
+	return nil
+}
+
+func (r *MemoryFuturesOrderRepo) InsertMany(ctx context.Context, orders []*models.FuturesOrder) ([]int, error) {
+	var duplicates []int
+	for i, order := range orders {
+		if err := r.Insert(ctx, order); err != nil {
+			duplicates = append(duplicates, i)
+		}
+	}
+	return duplicates, nil
+}
+
+func (r *MemoryFuturesOrderRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.FuturesOrder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, o := range r.orders {
+		if o.ID == id {
+			copied := *o
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryFuturesOrderRepo) FindByBinanceID(ctx context.Context, binanceOrderID int64) (*models.FuturesOrder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, o := range r.orders {
+		if o.BinanceOrderID == binanceOrderID {
+			copied := *o
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryFuturesOrderRepo) FindByClientOrderID(ctx context.Context, accountID, clientOrderID string) (*models.FuturesOrder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var newest *models.FuturesOrder
+	for _, o := range r.orders {
+		if o.ClientOrderID == clientOrderID && o.AccountID == accountID && (newest == nil || o.CreatedAt.After(newest.CreatedAt)) {
+			newest = o
+		}
+	}
+	if newest == nil {
+		return nil, ErrNotFound
+	}
+	copied := *newest
+	return &copied, nil
+}
+
+func (r *MemoryFuturesOrderRepo) List(ctx context.Context, query *OrderQuery) ([]*models.FuturesOrder, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	idx, total, err := pageOrders(query, len(r.orders), func(i int) orderFields {
+		o := r.orders[i]
+		return orderFields{o.ID, o.Symbol, o.Status, string(o.Side), o.CreatedAt, o.ClientOrderID, o.BinanceOrderID, o.StrategyID, o.AccountID, o.ExecutedQty, string(o.Source)}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	orders := make([]*models.FuturesOrder, 0, len(idx))
+	for _, i := range idx {
+		copied := *r.orders[i]
+		if !query.IncludeRaw {
+			copied.RawResponse = nil
+		}
+		orders = append(orders, &copied)
+	}
+	return orders, total, nil
+}
+
+func (r *MemoryFuturesOrderRepo) Each(ctx context.Context, query *OrderQuery, fn func(*models.FuturesOrder) error) error {
+	r.mu.RLock()
+	idx := matchOrders(query, len(r.orders), func(i int) orderFields {
+		o := r.orders[i]
+		return orderFields{o.ID, o.Symbol, o.Status, string(o.Side), o.CreatedAt, o.ClientOrderID, o.BinanceOrderID, o.StrategyID, o.AccountID, o.ExecutedQty, string(o.Source)}
+	})
+	orders := make([]models.FuturesOrder, len(idx))
+	for n, i := range idx {
+		orders[n] = *r.orders[i]
+		if !query.IncludeRaw {
+			orders[n].RawResponse = nil
+		}
+	}
+	r.mu.RUnlock()
+
+	// fn runs unlocked so it may write back to the repository
+	for i := range orders {
+		if err := fn(&orders[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openOrders copies the orders EachOpen streams, without their raw responses
+func (r *MemoryFuturesOrderRepo) openOrders() []*models.FuturesOrder {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var orders []*models.FuturesOrder
+	for _, o := range r.orders {
+		if (o.Status == "NEW" || o.Status == "PARTIALLY_FILLED") && o.BinanceOrderID > 0 && !o.MissingOnExchange {
+			copied := *o
+			copied.RawResponse = nil
+			orders = append(orders, &copied)
+		}
+	}
+	return orders
+}
+
+func (r *MemoryFuturesOrderRepo) EachOpen(ctx context.Context, fn func(*models.FuturesOrder) error) error {
+	for _, o := range r.openOrders() {
+		if err := fn(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryFuturesOrderRepo) ListOpen(ctx context.Context, afterID primitive.ObjectID, limit int) ([]*models.FuturesOrder, error) {
+	orders := r.openOrders()
+	// Hex IDs sort in the same order as the IDs themselves
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID.Hex() < orders[j].ID.Hex() })
+	start := sort.Search(len(orders), func(i int) bool { return orders[i].ID.Hex() > afterID.Hex() })
+	orders = orders[start:]
+	if len(orders) > limit {
+		orders = orders[:limit]
+	}
+	return orders, nil
+}
+
+func (r *MemoryFuturesOrderRepo) UpdateByRef(ctx context.Context, binanceOrderID int64, clientOrderID string, update *FuturesOrderUpdate) (*models.FuturesOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.orders {
+		if binanceOrderID > 0 && o.BinanceOrderID == binanceOrderID || binanceOrderID == 0 && o.ClientOrderID == clientOrderID {
+			if err := applySet(o, update.set()); err != nil {
+				return nil, err
+			}
+			copied := *o
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryFuturesOrderRepo) UpdateFill(ctx context.Context, binanceOrderID int64, clientOrderID string, update *FuturesOrderUpdate) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.orders {
+		if binanceOrderID > 0 && o.BinanceOrderID == binanceOrderID || binanceOrderID == 0 && o.ClientOrderID == clientOrderID {
+			if o.ExecutedQty > update.Fill.ExecutedQty {
+				return false, nil
+			}
+			return true, applySet(o, update.set())
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryFuturesOrderRepo) ArchiveTerminal(ctx context.Context, before time.Time, limit int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var candidates []*models.FuturesOrder
+	for _, o := range r.orders {
+		if !o.UpdatedAt.Before(before) {
+			continue
+		}
+		for _, status := range TerminalOrderStatuses {
+			if o.Status == status {
+				candidates = append(candidates, o)
+				break
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].UpdatedAt.Before(candidates[j].UpdatedAt) })
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+	archived := make(map[*models.FuturesOrder]bool, len(candidates))
+	for _, o := range candidates {
+		archived[o] = true
+	}
+	kept := r.orders[:0]
+	for _, o := range r.orders {
+		if !archived[o] {
+			kept = append(kept, o)
+		}
+	}
+	r.orders = kept
+	r.archived = append(r.archived, candidates...)
+	return len(candidates), nil
+}
+
+func (r *MemoryFuturesOrderRepo) SetStatus(ctx context.Context, symbol string, orderIDs []int64, clientOrderIDs []string, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	contains := func(ids []int64, id int64) bool {
+		for _, v := range ids {
+			if v == id {
+				return true
+			}
+		}
+		return false
+	}
+	containsString := func(ids []string, id string) bool {
+		for _, v := range ids {
+			if v == id {
+				return true
+			}
+		}
+		return false
+	}
+	for _, o := range r.orders {
+		if o.Symbol != symbol ||
+			len(orderIDs) > 0 && !contains(orderIDs, o.BinanceOrderID) ||
+			len(clientOrderIDs) > 0 && !containsString(clientOrderIDs, o.ClientOrderID) {
+			continue
+		}
+		o.Status = status
+		o.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// MemoryOptionsOrderRepo is an in-memory OptionsOrderRepo
+type MemoryOptionsOrderRepo struct {
+	mu     sync.RWMutex
+	orders []*models.OptionsOrder
+}
+
+func NewMemoryOptionsOrderRepo() *MemoryOptionsOrderRepo {
+	return &MemoryOptionsOrderRepo{}
+}
+
+func (r *MemoryOptionsOrderRepo) Insert(ctx context.Context, order *models.OptionsOrder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.orders {
+		if o.ID == order.ID || order.BinanceOrderID > 0 && o.BinanceOrderID == order.BinanceOrderID {
+			return ErrDuplicate
+		}
+	}
+	copied := *order
+	r.orders = append(r.orders, &copied)
+	return nil
+}
+
+func (r *MemoryOptionsOrderRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.OptionsOrder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, o := range r.orders {
+		if o.ID == id {
+			copied := *o
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryOptionsOrderRepo) FindByBinanceID(ctx context.Context, binanceOrderID int64) (*models.OptionsOrder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, o := range r.orders {
+		if o.BinanceOrderID == binanceOrderID {
+			copied := *o
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryOptionsOrderRepo) List(ctx context.Context, query *OrderQuery) ([]*models.OptionsOrder, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	idx, total, err := pageOrders(query, len(r.orders), func(i int) orderFields {
+		o := r.orders[i]
+		return orderFields{o.ID, o.Symbol, o.Status, string(o.Side), o.CreatedAt, "", o.BinanceOrderID, "", o.AccountID, 0, ""}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	orders := make([]*models.OptionsOrder, 0, len(idx))
+	for _, i := range idx {
+		copied := *r.orders[i]
+		if !query.IncludeRaw {
+			copied.RawResponse = nil
+		}
+		orders = append(orders, &copied)
+	}
+	return orders, total, nil
+}
+
+func (r *MemoryOptionsOrderRepo) Each(ctx context.Context, query *OrderQuery, fn func(*models.OptionsOrder) error) error {
+	r.mu.RLock()
+	idx := matchOrders(query, len(r.orders), func(i int) orderFields {
+		o := r.orders[i]
+		return orderFields{o.ID, o.Symbol, o.Status, string(o.Side), o.CreatedAt, "", o.BinanceOrderID, "", o.AccountID, 0, ""}
+	})
+	orders := make([]models.OptionsOrder, len(idx))
+	for n, i := range idx {
+		orders[n] = *r.orders[i]
+		if !query.IncludeRaw {
+			orders[n].RawResponse = nil
+		}
+	}
+	r.mu.RUnlock()
+
+	for i := range orders {
+		if err := fn(&orders[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemorySpotOrderRepo is an in-memory SpotOrderRepo
+type MemorySpotOrderRepo struct {
+	mu     sync.RWMutex
+	orders []*models.SpotOrder
+}
+
+func NewMemorySpotOrderRepo() *MemorySpotOrderRepo {
+	return &MemorySpotOrderRepo{}
+}
+
+func (r *MemorySpotOrderRepo) Insert(ctx context.Context, order *models.SpotOrder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.orders {
+		if o.ID == order.ID || order.BinanceOrderID > 0 && o.BinanceOrderID == order.BinanceOrderID {
+			return ErrDuplicate
+		}
+	}
+	copied := *order
+	r.orders = append(r.orders, &copied)
+	return nil
+}
+
+func (r *MemorySpotOrderRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.SpotOrder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, o := range r.orders {
+		if o.ID == id {
+			copied := *o
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemorySpotOrderRepo) FindByBinanceID(ctx context.Context, binanceOrderID int64) (*models.SpotOrder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, o := range r.orders {
+		if o.BinanceOrderID == binanceOrderID {
+			copied := *o
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemorySpotOrderRepo) FindByClientOrderID(ctx context.Context, accountID, clientOrderID string) (*models.SpotOrder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var newest *models.SpotOrder
+	for _, o := range r.orders {
+		if o.ClientOrderID == clientOrderID && o.AccountID == accountID && (newest == nil || o.CreatedAt.After(newest.CreatedAt)) {
+			newest = o
+		}
+	}
+	if newest == nil {
+		return nil, ErrNotFound
+	}
+	copied := *newest
+	return &copied, nil
+}
+
+func (r *MemorySpotOrderRepo) List(ctx context.Context, query *OrderQuery) ([]*models.SpotOrder, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	idx, total, err := pageOrders(query, len(r.orders), func(i int) orderFields {
+		o := r.orders[i]
+		return orderFields{o.ID, o.Symbol, o.Status, string(o.Side), o.CreatedAt, o.ClientOrderID, o.BinanceOrderID, "", o.AccountID, 0, ""}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	orders := make([]*models.SpotOrder, 0, len(idx))
+	for _, i := range idx {
+		copied := *r.orders[i]
+		if !query.IncludeRaw {
+			copied.RawResponse = nil
+		}
+		orders = append(orders, &copied)
+	}
+	return orders, total, nil
+}
+
+func (r *MemorySpotOrderRepo) ListOpen(ctx context.Context) ([]*models.SpotOrder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var orders []*models.SpotOrder
+	for _, o := range r.orders {
+		if (o.Status == "NEW" || o.Status == "PARTIALLY_FILLED") && o.BinanceOrderID > 0 && !o.MissingOnExchange {
+			copied := *o
+			copied.RawResponse = nil
+			orders = append(orders, &copied)
+		}
+	}
+	return orders, nil
+}
+
+func (r *MemorySpotOrderRepo) UpdateByRef(ctx context.Context, binanceOrderID int64, clientOrderID string, update *SpotOrderUpdate) (*models.SpotOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.orders {
+		if binanceOrderID > 0 && o.BinanceOrderID == binanceOrderID || binanceOrderID == 0 && o.ClientOrderID == clientOrderID {
+			if err := applySet(o, update.set()); err != nil {
+				return nil, err
+			}
+			copied := *o
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// MemoryTransferRepo is an in-memory TransferRepo
+type MemoryTransferRepo struct {
+	mu        sync.RWMutex
+	transfers []*models.Transfer
+}
+
+func NewMemoryTransferRepo() *MemoryTransferRepo {
+	return &MemoryTransferRepo{}
+}
+
+func (r *MemoryTransferRepo) Insert(ctx context.Context, transfer *models.Transfer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.transfers {
+		if t.ID == transfer.ID || t.TranID == transfer.TranID {
+			return ErrDuplicate
+		}
+	}
+	copied := *transfer
+	r.transfers = append(r.transfers, &copied)
+	return nil
+}
+
+func (r *MemoryTransferRepo) SetStatus(ctx context.Context, tranID int64, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.transfers {
+		if t.TranID == tranID && t.Status != status {
+			t.Status = status
+			t.UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+// MemoryPositionRepo is an in-memory PositionRepo
+type MemoryPositionRepo struct {
+	mu        sync.RWMutex
+	positions []*models.Position
+	mode      *models.PositionModeConfig
+}
+
+func NewMemoryPositionRepo() *MemoryPositionRepo {
+	return &MemoryPositionRepo{}
+}
+
+func (r *MemoryPositionRepo) List(ctx context.Context, positionType, accountID string) ([]*models.Position, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var positions []*models.Position
+	for _, p := range r.positions {
+		if positionType != "" && p.Type != positionType || accountID != "" && p.AccountID != accountID {
+			continue
+		}
+		copied := *p
+		positions = append(positions, &copied)
+	}
+	return positions, nil
+}
+
+func (r *MemoryPositionRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Position, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, p := range r.positions {
+		if p.ID == id {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryPositionRepo) Each(ctx context.Context, positionType, accountID string, fn func(*models.Position) error) error {
+	positions, _ := r.List(ctx, positionType, accountID)
+	for _, p := range positions {
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryPositionRepo) Upsert(ctx context.Context, position *models.Position) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, p := range r.positions {
+		if p.Symbol == position.Symbol && p.Type == position.Type && p.Side == position.Side && p.AccountID == position.AccountID {
+			copied := *position
+			copied.ID = p.ID
+			if copied.FundingPaid == 0 {
+				copied.FundingPaid = p.FundingPaid
+			}
+			if copied.FundingSince == nil {
+				copied.FundingSince = p.FundingSince
+			}
+			r.positions[i] = &copied
+			return nil
+		}
+	}
+	copied := *position
+	if copied.ID.IsZero() {
+		copied.ID = primitive.NewObjectID()
+	}
+	if copied.FundingSince == nil {
+		since := copied.UpdatedAt
+		copied.FundingSince = &since
+	}
+	r.positions = append(r.positions, &copied)
+	return nil
+}
+
+func (r *MemoryPositionRepo) SyncMarket(ctx context.Context, market models.Market, accountID string, positions []*models.Position, keep []string) (int64, int64, error) {
+	kept := map[string]bool{}
+	for _, symbol := range keep {
+		kept[symbol] = true
+	}
+	live := map[string]bool{}
+	for _, position := range positions {
+		live[position.Symbol+"/"+string(position.Side)+"/"+position.AccountID] = true
+		if err := r.Upsert(ctx, position); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var cleared int64
+	remaining := r.positions[:0]
+	for _, p := range r.positions {
+		pMarket := p.Market
+		if pMarket == "" {
+			pMarket = models.MarketUSDM
+		}
+		inScope := p.AccountID == accountID || p.AccountID == ""
+		if p.Type == "FUTURES" && pMarket == market && inScope && !kept[p.Symbol] && !live[p.Symbol+"/"+string(p.Side)+"/"+p.AccountID] {
+			cleared++
+			continue
+		}
+		remaining = append(remaining, p)
+	}
+	r.positions = remaining
+	return int64(len(positions)), cleared, nil
+}
+
+func (r *MemoryPositionRepo) AddFunding(ctx context.Context, id primitive.ObjectID, amount float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.positions {
+		if p.ID == id {
+			p.FundingPaid += amount
+		}
+	}
+	return nil
+}
+
+func (r *MemoryPositionRepo) FlagMarginCall(ctx context.Context, symbol string, side models.PositionSide, accountID string, markPrice float64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.positions {
+		if p.Symbol == symbol && p.Type == "FUTURES" && p.Side == side && p.AccountID == accountID {
+			p.AtRisk = true
+			p.MarginCallPrice = markPrice
+			p.UpdatedAt = at
+		}
+	}
+	return nil
+}
+
+func (r *MemoryPositionRepo) ResetFunding(ctx context.Context, symbol string, side models.PositionSide, accountID string, since time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.positions {
+		if p.Symbol == symbol && p.Type == "FUTURES" && p.Side == side && p.AccountID == accountID {
+			p.FundingPaid = 0
+			p.FundingSince = &since
+		}
+	}
+	return nil
+}
+
+func (r *MemoryPositionRepo) SavePositionMode(ctx context.Context, mode *models.PositionModeConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *mode
+	r.mode = &copied
+	return nil
+}
+
+// MemoryRiskEventRepo is an in-memory RiskEventRepo
+type MemoryRiskEventRepo struct {
+	mu     sync.RWMutex
+	events []*models.RiskEvent
+}
+
+func NewMemoryRiskEventRepo() *MemoryRiskEventRepo {
+	return &MemoryRiskEventRepo{}
+}
+
+func (r *MemoryRiskEventRepo) Insert(ctx context.Context, event *models.RiskEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	copied := *event
+	r.events = append(r.events, &copied)
+	return nil
+}
+
+func (r *MemoryRiskEventRepo) List(ctx context.Context, symbol string, limit int64) ([]*models.RiskEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var events []*models.RiskEvent
+	for _, e := range r.events {
+		if symbol == "" || e.Symbol == symbol {
+			copied := *e
+			events = append(events, &copied)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].CreatedAt.After(events[j].CreatedAt) })
+	if limit > 0 && int64(len(events)) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// MemoryCredentialsRepo is an in-memory CredentialsRepo
+type MemoryCredentialsRepo struct {
+	mu          sync.RWMutex
+	credentials []*models.APICredentials
+}
+
+func NewMemoryCredentialsRepo() *MemoryCredentialsRepo {
+	return &MemoryCredentialsRepo{}
+}
+
+func (r *MemoryCredentialsRepo) List(ctx context.Context, activeOnly bool) ([]*models.APICredentials, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*models.APICredentials
+	for _, c := range r.credentials {
+		if activeOnly && !c.IsActive {
+			continue
+		}
+		copied := *c
+		out = append(out, &copied)
+	}
+	return out, nil
+}
+
+func (r *MemoryCredentialsRepo) find(match func(c *models.APICredentials) bool) (*models.APICredentials, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var found *models.APICredentials
+	for _, c := range r.credentials {
+		if match(c) && (found == nil || c.UpdatedAt.After(found.UpdatedAt)) {
+			found = c
+		}
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+	copied := *found
+	return &copied, nil
+}
+
+func (r *MemoryCredentialsRepo) FindActive(ctx context.Context) (*models.APICredentials, error) {
+	return r.find(func(c *models.APICredentials) bool { return c.IsActive })
+}
+
+func (r *MemoryCredentialsRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.APICredentials, error) {
+	return r.find(func(c *models.APICredentials) bool { return c.ID == id })
+}
+
+func (r *MemoryCredentialsRepo) FindByAPIKey(ctx context.Context, apiKey string) (*models.APICredentials, error) {
+	return r.find(func(c *models.APICredentials) bool { return c.APIKey == apiKey })
+}
+
+func (r *MemoryCredentialsRepo) Insert(ctx context.Context, credentials *models.APICredentials) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.credentials {
+		if c.ID == credentials.ID || c.APIKey == credentials.APIKey {
+			return ErrDuplicate
+		}
+	}
+	copied := *credentials
+	r.credentials = append(r.credentials, &copied)
+	return nil
+}
+
+func (r *MemoryCredentialsRepo) Update(ctx context.Context, credentials *models.APICredentials) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, c := range r.credentials {
+		if c.ID == credentials.ID {
+			copied := *credentials
+			copied.APIKey = c.APIKey
+			copied.CreatedAt = c.CreatedAt
+			copied.Ed25519Seed = c.Ed25519Seed
+			copied.Ed25519PublicKey = c.Ed25519PublicKey
+			r.credentials[i] = &copied
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryCredentialsRepo) SetEd25519Key(ctx context.Context, id primitive.ObjectID, seed, publicKey string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.credentials {
+		if c.ID == id {
+			c.Ed25519Seed = seed
+			c.Ed25519PublicKey = publicKey
+			c.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryCredentialsRepo) Activate(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.credentials {
+		c.IsActive = c.ID == id
+	}
+	return nil
+}
+
+func (r *MemoryCredentialsRepo) DeleteInactive(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, c := range r.credentials {
+		if c.ID == id && !c.IsActive {
+			r.credentials = append(r.credentials[:i], r.credentials[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MemoryAuditRepo is an in-memory AuditRepo
+type MemoryAuditRepo struct {
+	mu      sync.Mutex
+	entries []*models.AuditEntry
+}
+
+func NewMemoryAuditRepo() *MemoryAuditRepo {
+	return &MemoryAuditRepo{}
+}
+
+func (r *MemoryAuditRepo) InsertMany(ctx context.Context, entries []*models.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, entry := range entries {
+		if entry.ID.IsZero() {
+			entry.ID = primitive.NewObjectID()
+		}
+		copied := *entry
+		r.entries = append(r.entries, &copied)
+	}
+	return nil
+}
+
+func (r *MemoryAuditRepo) List(ctx context.Context, query *AuditQuery) ([]*models.AuditEntry, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []*models.AuditEntry
+	for _, entry := range r.entries {
+		if query.Since != nil && entry.Timestamp.Before(*query.Since) ||
+			query.Action != "" && string(entry.Action) != query.Action ||
+			query.Symbol != "" && entry.Symbol != query.Symbol {
+			continue
+		}
+		copied := *entry
+		matched = append(matched, &copied)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].Timestamp.After(matched[j].Timestamp)
+	})
+
+	total := int64(len(matched))
+	start := query.Offset
+	if start > total {
+		start = total
+	}
+	end := start + query.PageLimit()
+	if end > total {
+		end = total
+	}
+	return matched[start:end], total, nil
+}
+
+// MemoryTokenRepo is an in-memory TokenRepo
+type MemoryTokenRepo struct {
+	mu     sync.RWMutex
+	tokens []*models.APIToken
+}
+
+func NewMemoryTokenRepo() *MemoryTokenRepo {
+	return &MemoryTokenRepo{}
+}
+
+func (r *MemoryTokenRepo) Insert(ctx context.Context, token *models.APIToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.TokenHash == token.TokenHash {
+			return fmt.Errorf("%w: token_hash %s", ErrDuplicate, token.Prefix)
+		}
+	}
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	copied := *token
+	r.tokens = append(r.tokens, &copied)
+	return nil
+}
+
+func (r *MemoryTokenRepo) FindByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryTokenRepo) List(ctx context.Context) ([]*models.APIToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*models.APIToken, 0, len(r.tokens))
+	for i := len(r.tokens) - 1; i >= 0; i-- {
+		copied := *r.tokens[i]
+		out = append(out, &copied)
+	}
+	return out, nil
+}
+
+func (r *MemoryTokenRepo) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, t := range r.tokens {
+		if t.ID == id {
+			r.tokens = append(r.tokens[:i], r.tokens[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MemoryRiskLimitRepo is an in-memory RiskLimitRepo
+type MemoryRiskLimitRepo struct {
+	mu     sync.RWMutex
+	limits map[string]*models.RiskLimit
+}
+
+func NewMemoryRiskLimitRepo() *MemoryRiskLimitRepo {
+	return &MemoryRiskLimitRepo{limits: make(map[string]*models.RiskLimit)}
+}
+
+func (r *MemoryRiskLimitRepo) List(ctx context.Context) ([]*models.RiskLimit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*models.RiskLimit, 0, len(r.limits))
+	for _, l := range r.limits {
+		copied := *l
+		out = append(out, &copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out, nil
+}
+
+func (r *MemoryRiskLimitRepo) FindBySymbol(ctx context.Context, symbol string) (*models.RiskLimit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	l, ok := r.limits[symbol]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *l
+	return &copied, nil
+}
+
+func (r *MemoryRiskLimitRepo) Upsert(ctx context.Context, limit *models.RiskLimit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.limits[limit.Symbol]; ok {
+		limit.ID = existing.ID
+		limit.CreatedAt = existing.CreatedAt
+	} else if limit.ID.IsZero() {
+		limit.ID = primitive.NewObjectID()
+	}
+	copied := *limit
+	r.limits[limit.Symbol] = &copied
+	return nil
+}
+
+func (r *MemoryRiskLimitRepo) Delete(ctx context.Context, symbol string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.limits[symbol]; !ok {
+		return false, nil
+	}
+	delete(r.limits, symbol)
+	return true, nil
+}
+
+// MemorySymbolSettingsRepo is an in-memory SymbolSettingsRepo
+type MemorySymbolSettingsRepo struct {
+	mu       sync.RWMutex
+	settings map[string]*models.SymbolSettings
+}
+
+func NewMemorySymbolSettingsRepo() *MemorySymbolSettingsRepo {
+	return &MemorySymbolSettingsRepo{settings: make(map[string]*models.SymbolSettings)}
+}
+
+func (r *MemorySymbolSettingsRepo) List(ctx context.Context) ([]*models.SymbolSettings, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*models.SymbolSettings, 0, len(r.settings))
+	for _, s := range r.settings {
+		copied := *s
+		out = append(out, &copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out, nil
+}
+
+func (r *MemorySymbolSettingsRepo) FindBySymbol(ctx context.Context, symbol string) (*models.SymbolSettings, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.settings[symbol]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *s
+	return &copied, nil
+}
+
+func (r *MemorySymbolSettingsRepo) Upsert(ctx context.Context, settings *models.SymbolSettings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.settings[settings.Symbol]; ok {
+		settings.ID = existing.ID
+		settings.CreatedAt = existing.CreatedAt
+	} else if settings.ID.IsZero() {
+		settings.ID = primitive.NewObjectID()
+	}
+	copied := *settings
+	r.settings[settings.Symbol] = &copied
+	return nil
+}
+
+func (r *MemorySymbolSettingsRepo) Update(ctx context.Context, symbol string, update *SymbolSettingsUpdate) (*models.SymbolSettings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.settings[symbol]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if err := applySet(s, update.set()); err != nil {
+		return nil, err
+	}
+	copied := *s
+	return &copied, nil
+}
+
+func (r *MemorySymbolSettingsRepo) Delete(ctx context.Context, symbol string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.settings[symbol]; !ok {
+		return false, nil
+	}
+	delete(r.settings, symbol)
+	return true, nil
+}
+
+// MemoryTradingPolicyRepo is an in-memory TradingPolicyRepo
+type MemoryTradingPolicyRepo struct {
+	mu     sync.RWMutex
+	policy *models.TradingPolicy
+	pause  *models.TradingPause
+}
+
+func NewMemoryTradingPolicyRepo() *MemoryTradingPolicyRepo {
+	return &MemoryTradingPolicyRepo{}
+}
+
+func copyTradingPolicy(p *models.TradingPolicy) *models.TradingPolicy {
+	copied := *p
+	copied.Symbols = append([]string{}, p.Symbols...)
+	return &copied
+}
+
+func (r *MemoryTradingPolicyRepo) Get(ctx context.Context) (*models.TradingPolicy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.policy == nil {
+		return nil, ErrNotFound
+	}
+	return copyTradingPolicy(r.policy), nil
+}
+
+func (r *MemoryTradingPolicyRepo) Save(ctx context.Context, policy *models.TradingPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	policy.ID = models.TradingPolicyID
+	r.policy = copyTradingPolicy(policy)
+	return nil
+}
+
+func (r *MemoryTradingPolicyRepo) AddSymbols(ctx context.Context, symbols []string, updatedBy string, updatedAt time.Time) (*models.TradingPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.policy == nil {
+		r.policy = &models.TradingPolicy{ID: models.TradingPolicyID, Mode: models.SymbolPolicyOff}
+	}
+	for _, symbol := range symbols {
+		if !slices.Contains(r.policy.Symbols, symbol) {
+			r.policy.Symbols = append(r.policy.Symbols, symbol)
+		}
+	}
+	r.policy.UpdatedBy, r.policy.UpdatedAt = updatedBy, updatedAt
+	return copyTradingPolicy(r.policy), nil
+}
+
+func (r *MemoryTradingPolicyRepo) RemoveSymbol(ctx context.Context, symbol, updatedBy string, updatedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.policy == nil {
+		return false, nil
+	}
+	i := slices.Index(r.policy.Symbols, symbol)
+	if i < 0 {
+		return false, nil
+	}
+	r.policy.Symbols = slices.Delete(r.policy.Symbols, i, i+1)
+	r.policy.UpdatedBy, r.policy.UpdatedAt = updatedBy, updatedAt
+	return true, nil
+}
+
+func (r *MemoryTradingPolicyRepo) GetPause(ctx context.Context) (*models.TradingPause, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.pause == nil {
+		return nil, ErrNotFound
+	}
+	copied := *r.pause
+	return &copied, nil
+}
+
+func (r *MemoryTradingPolicyRepo) SavePause(ctx context.Context, pause *models.TradingPause) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pause.ID = models.TradingPauseID
+	copied := *pause
+	r.pause = &copied
+	return nil
+}
+
+// MemoryOrderTemplateRepo is an in-memory OrderTemplateRepo
+type MemoryOrderTemplateRepo struct {
+	mu        sync.RWMutex
+	templates map[string]*models.NamedOrderTemplate
+}
+
+func NewMemoryOrderTemplateRepo() *MemoryOrderTemplateRepo {
+	return &MemoryOrderTemplateRepo{templates: make(map[string]*models.NamedOrderTemplate)}
+}
+
+func (r *MemoryOrderTemplateRepo) List(ctx context.Context) ([]*models.NamedOrderTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*models.NamedOrderTemplate, 0, len(r.templates))
+	for _, t := range r.templates {
+		copied := *t
+		out = append(out, &copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func (r *MemoryOrderTemplateRepo) FindByName(ctx context.Context, name string) (*models.NamedOrderTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.templates[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *t
+	return &copied, nil
+}
+
+func (r *MemoryOrderTemplateRepo) Insert(ctx context.Context, template *models.NamedOrderTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.templates[template.Name]; ok {
+		return ErrDuplicate
+	}
+	if template.ID.IsZero() {
+		template.ID = primitive.NewObjectID()
+	}
+	copied := *template
+	r.templates[template.Name] = &copied
+	return nil
+}
+
+func (r *MemoryOrderTemplateRepo) Update(ctx context.Context, template *models.NamedOrderTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.templates[template.Name]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.Order = template.Order
+	existing.UpdatedAt = template.UpdatedAt
+	*template = *existing
+	return nil
+}
+
+func (r *MemoryOrderTemplateRepo) Delete(ctx context.Context, name string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.templates[name]; !ok {
+		return false, nil
+	}
+	delete(r.templates, name)
+	return true, nil
+}
+
+// MemoryWatchlistRepo is an in-memory WatchlistRepo
+type MemoryWatchlistRepo struct {
+	mu      sync.RWMutex
+	entries map[string]*models.WatchlistEntry
+}
+
+func NewMemoryWatchlistRepo() *MemoryWatchlistRepo {
+	return &MemoryWatchlistRepo{entries: make(map[string]*models.WatchlistEntry)}
+}
+
+func (r *MemoryWatchlistRepo) List(ctx context.Context) ([]*models.WatchlistEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*models.WatchlistEntry, 0, len(r.entries))
+	for _, e := range r.entries {
+		copied := *e
+		out = append(out, &copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out, nil
+}
+
+func (r *MemoryWatchlistRepo) Add(ctx context.Context, entry *models.WatchlistEntry) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[entry.Symbol]; ok {
+		return false, nil
+	}
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	copied := *entry
+	r.entries[entry.Symbol] = &copied
+	return true, nil
+}
+
+func (r *MemoryWatchlistRepo) Remove(ctx context.Context, symbol string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[symbol]; !ok {
+		return false, nil
+	}
+	delete(r.entries, symbol)
+	return true, nil
+}
+
+// MemoryReconciliationRepo is an in-memory ReconciliationRepo
+type MemoryReconciliationRepo struct {
+	mu      sync.RWMutex
+	reports []*models.ReconciliationReport
+}
+
+func NewMemoryReconciliationRepo() *MemoryReconciliationRepo {
+	return &MemoryReconciliationRepo{}
+}
+
+func (r *MemoryReconciliationRepo) Insert(ctx context.Context, report *models.ReconciliationReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if report.ID.IsZero() {
+		report.ID = primitive.NewObjectID()
+	}
+	copied := *report
+	r.reports = append(r.reports, &copied)
+	return nil
+}
+
+func (r *MemoryReconciliationRepo) List(ctx context.Context, limit int64) ([]*models.ReconciliationReport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*models.ReconciliationReport, 0, len(r.reports))
+	for _, report := range r.reports {
+		copied := *report
+		out = append(out, &copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.After(out[j].StartedAt) })
+	if limit > 0 && int64(len(out)) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// memoryTransactor runs functions without a transaction; the memory repositories cannot roll back
+type memoryTransactor struct{}
+
+func (memoryTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (memoryTransactor) Transactional() bool {
+	return false
+}
+
+// MemoryJobRepo is an in-memory JobRepo
+type MemoryJobRepo struct {
+	mu   sync.RWMutex
+	runs map[string]*models.JobRun
+}
+
+func NewMemoryJobRepo() *MemoryJobRepo {
+	return &MemoryJobRepo{runs: make(map[string]*models.JobRun)}
+}
+
+func (r *MemoryJobRepo) RecordRun(ctx context.Context, run *models.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if previous, ok := r.runs[run.Name]; ok {
+		run.ID = previous.ID
+		run.Runs = previous.Runs
+		run.Failures = previous.Failures
+		run.LastSucceededAt = previous.LastSucceededAt
+	} else {
+		run.ID = primitive.NewObjectID()
+		run.Runs, run.Failures, run.LastSucceededAt = 0, 0, nil
+	}
+	run.Runs++
+	if run.Error != "" {
+		run.Failures++
+	} else {
+		finished := run.FinishedAt
+		run.LastSucceededAt = &finished
+	}
+	copied := *run
+	r.runs[run.Name] = &copied
+	return nil
+}
+
+func (r *MemoryJobRepo) List(ctx context.Context) ([]*models.JobRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*models.JobRun, 0, len(r.runs))
+	for _, run := range r.runs {
+		copied := *run
+		out = append(out, &copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// MemoryPaperRepo is an in-memory PaperRepo
+type MemoryPaperRepo struct {
+	mu        sync.RWMutex
+	orders    map[int64]*models.PaperOrder
+	positions map[string]*models.PaperPosition
+	account   *models.PaperAccount
+}
+
+func NewMemoryPaperRepo() *MemoryPaperRepo {
+	return &MemoryPaperRepo{
+		orders:    make(map[int64]*models.PaperOrder),
+		positions: make(map[string]*models.PaperPosition),
+	}
+}
+
+func (r *MemoryPaperRepo) SaveOrder(ctx context.Context, order *models.PaperOrder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if order.ID.IsZero() {
+		order.ID = primitive.NewObjectID()
+	}
+	copied := *order
+	r.orders[order.OrderID] = &copied
+	return nil
+}
+
+func (r *MemoryPaperRepo) FindOrder(ctx context.Context, symbol string, orderID int64, clientOrderID string) (*models.PaperOrder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, o := range r.orders {
+		if o.Symbol != symbol {
+			continue
+		}
+		if (orderID != 0 && o.OrderID == orderID) || (orderID == 0 && o.ClientOrderID == clientOrderID) {
+			copied := *o
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryPaperRepo) ListOpenOrders(ctx context.Context) ([]*models.PaperOrder, error) {
+	return r.findOrders(func(o *models.PaperOrder) bool { return o.Status == "NEW" }), nil
+}
+
+func (r *MemoryPaperRepo) ListFilledSince(ctx context.Context, since time.Time) ([]*models.PaperOrder, error) {
+	return r.findOrders(func(o *models.PaperOrder) bool { return o.FilledAt != nil && !o.FilledAt.Before(since) }), nil
+}
+
+func (r *MemoryPaperRepo) findOrders(match func(*models.PaperOrder) bool) []*models.PaperOrder {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*models.PaperOrder
+	for _, o := range r.orders {
+		if match(o) {
+			copied := *o
+			out = append(out, &copied)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].OrderID < out[j].OrderID })
+	return out
+}
+
+func (r *MemoryPaperRepo) MaxOrderID(ctx context.Context) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var max int64
+	for id := range r.orders {
+		if id > max {
+			max = id
+		}
+	}
+	return max, nil
+}
+
+func (r *MemoryPaperRepo) ListPositions(ctx context.Context) ([]*models.PaperPosition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*models.PaperPosition, 0, len(r.positions))
+	for _, p := range r.positions {
+		copied := *p
+		out = append(out, &copied)
+	}
+	return out, nil
+}
+
+func (r *MemoryPaperRepo) SavePosition(ctx context.Context, position *models.PaperPosition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *position
+	r.positions[position.Symbol+"/"+position.PositionSide] = &copied
+	return nil
+}
+
+func (r *MemoryPaperRepo) GetAccount(ctx context.Context) (*models.PaperAccount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.account == nil {
+		return nil, ErrNotFound
+	}
+	copied := *r.account
+	return &copied, nil
+}
+
+func (r *MemoryPaperRepo) SaveAccount(ctx context.Context, account *models.PaperAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	account.ID = models.PaperAccountID
+	copied := *account
+	r.account = &copied
+	return nil
+}
+
+// MemoryIncomeRepo is an in-memory IncomeRepo
+type MemoryIncomeRepo struct {
+	mu      sync.RWMutex
+	records map[string]*models.IncomeRecord
+}
+
+func NewMemoryIncomeRepo() *MemoryIncomeRepo {
+	return &MemoryIncomeRepo{records: make(map[string]*models.IncomeRecord)}
+}
+
+func (r *MemoryIncomeRepo) Upsert(ctx context.Context, records []*models.IncomeRecord) ([]*models.IncomeRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var inserted []*models.IncomeRecord
+	for _, rec := range records {
+		key := fmt.Sprintf("%d/%s/%s", rec.TranID, rec.IncomeType, rec.Symbol)
+		if _, ok := r.records[key]; ok {
+			continue
+		}
+		copied := *rec
+		if copied.ID.IsZero() {
+			copied.ID = primitive.NewObjectID()
+		}
+		r.records[key] = &copied
+		inserted = append(inserted, rec)
+	}
+	return inserted, nil
+}
+
+func (r *MemoryIncomeRepo) LatestTime(ctx context.Context) (time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var latest time.Time
+	for _, rec := range r.records {
+		if rec.Time.After(latest) {
+			latest = rec.Time
+		}
+	}
+	if latest.IsZero() {
+		return time.Time{}, ErrNotFound
+	}
+	return latest, nil
+}
+
+func (r *MemoryIncomeRepo) AggregatePnL(ctx context.Context, query *PnLQuery) ([]*models.PnLBucket, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	buckets := make(map[string]*models.PnLBucket)
+	for _, rec := range r.records {
+		if rec.Time.Before(query.Start) || !rec.Time.Before(query.End) {
+			continue
+		}
+		b := &models.PnLBucket{}
+		if query.BySymbol {
+			b.Symbol = rec.Symbol
+		}
+		if query.ByDay {
+			b.Day = rec.Time.UTC().Format("2006-01-02")
+		}
+		key := b.Day + "/" + b.Symbol
+		if existing, ok := buckets[key]; ok {
+			b = existing
+		}
+
+		switch rec.IncomeType {
+		case models.IncomeTypeRealizedPnl:
+			b.RealizedPnl += rec.Income
+			b.Trades++
+			if rec.Income > 0 {
+				b.Wins++
+			}
+		case models.IncomeTypeCommission:
+			b.Commission += rec.Income
+		case models.IncomeTypeFundingFee:
+			b.FundingFees += rec.Income
+		default:
+			continue
+		}
+		buckets[key] = b
+	}
+
+	out := make([]*models.PnLBucket, 0, len(buckets))
+	for _, b := range buckets {
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Day != out[j].Day {
+			return out[i].Day < out[j].Day
+		}
+		return out[i].Symbol < out[j].Symbol
+	})
+	return out, nil
+}
+
+// MemoryFillRepo is an in-memory FillRepo
+type MemoryFillRepo struct {
+	mu    sync.RWMutex
+	fills []*models.Fill
+}
+
+func NewMemoryFillRepo() *MemoryFillRepo {
+	return &MemoryFillRepo{}
+}
+
+func (r *MemoryFillRepo) Insert(ctx context.Context, fill *models.Fill) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range r.fills {
+		if f.Product == fill.Product && f.Symbol == fill.Symbol && f.BinanceOrderID == fill.BinanceOrderID && f.TradeID == fill.TradeID {
+			return false, nil
+		}
+	}
+	if fill.ID.IsZero() {
+		fill.ID = primitive.NewObjectID()
+	}
+	copied := *fill
+	r.fills = append(r.fills, &copied)
+	return true, nil
+}
+
+func (r *MemoryFillRepo) List(ctx context.Context, q *FillQuery) ([]*models.Fill, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := make(map[string]bool, len(q.Products))
+	for _, p := range q.Products {
+		products[p] = true
+	}
+	var matched []*models.Fill
+	for _, f := range r.fills {
+		if len(products) > 0 && !products[f.Product] {
+			continue
+		}
+		if q.Symbol != "" && f.Symbol != q.Symbol {
+			continue
+		}
+		if q.AccountID != "" && f.AccountID != q.AccountID {
+			continue
+		}
+		matched = append(matched, f)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if !a.FillTime.Equal(b.FillTime) {
+			return a.FillTime.After(b.FillTime)
+		}
+		return a.ID.Hex() > b.ID.Hex()
+	})
+
+	start := 0
+	if q.BeforeID != "" {
+		id, err := primitive.ObjectIDFromHex(q.BeforeID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		start = -1
+		for i, f := range matched {
+			if f.ID == id {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			return nil, fmt.Errorf("%w: %s not found", ErrInvalidCursor, q.BeforeID)
+		}
+	}
+	end := start + int(q.PageLimit()) + 1
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	out := make([]*models.Fill, 0, end-start)
+	for _, f := range matched[start:end] {
+		copied := *f
+		out = append(out, &copied)
+	}
+	return out, nil
+}
+
+func (r *MemoryFillRepo) AggregatePnLBySource(ctx context.Context, query *PnLQuery) ([]*models.PnLBucket, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	buckets := make(map[string]*models.PnLBucket)
+	for _, f := range r.fills {
+		if f.Product != models.FillProductFutures || f.FillTime.Before(query.Start) || !f.FillTime.Before(query.End) {
+			continue
+		}
+		b, ok := buckets[string(f.Source)]
+		if !ok {
+			b = &models.PnLBucket{Source: string(f.Source)}
+			buckets[b.Source] = b
+		}
+		b.RealizedPnl += f.RealizedPnl
+		b.Commission -= f.Commission
+		if f.RealizedPnl != 0 {
+			b.Trades++
+		}
+		if f.RealizedPnl > 0 {
+			b.Wins++
+		}
+	}
+
+	out := make([]*models.PnLBucket, 0, len(buckets))
+	for _, b := range buckets {
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Source < out[j].Source })
+	return out, nil
+}
+
+// MemoryEquityRepo is an in-memory EquityRepo
+type MemoryEquityRepo struct {
+	mu        sync.RWMutex
+	snapshots []*models.EquitySnapshot
+}
+
+func NewMemoryEquityRepo() *MemoryEquityRepo {
+	return &MemoryEquityRepo{}
+}
+
+func (r *MemoryEquityRepo) Insert(ctx context.Context, snapshot *models.EquitySnapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if snapshot.ID.IsZero() {
+		snapshot.ID = primitive.NewObjectID()
+	}
+	copied := *snapshot
+	r.snapshots = append(r.snapshots, &copied)
+	return nil
+}
+
+func (r *MemoryEquityRepo) List(ctx context.Context, start, end time.Time) ([]*models.EquitySnapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*models.EquitySnapshot
+	for _, snap := range r.snapshots {
+		if snap.Time.Before(start) || !snap.Time.Before(end) {
+			continue
+		}
+		copied := *snap
+		out = append(out, &copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out, nil
+}
+
+// MemoryKlineRepo is an in-memory KlineRepo
+type MemoryKlineRepo struct {
+	mu     sync.RWMutex
+	klines map[string]*models.Kline
+}
+
+func NewMemoryKlineRepo() *MemoryKlineRepo {
+	return &MemoryKlineRepo{klines: make(map[string]*models.Kline)}
+}
+
+func (r *MemoryKlineRepo) Upsert(ctx context.Context, klines []*models.Kline) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range klines {
+		key := fmt.Sprintf("%s/%s/%d", k.Symbol, k.Interval, k.OpenTime.UnixMilli())
+		copied := *k
+		if existing, ok := r.klines[key]; ok {
+			copied.ID = existing.ID
+		} else if copied.ID.IsZero() {
+			copied.ID = primitive.NewObjectID()
+		}
+		r.klines[key] = &copied
+	}
+	return nil
+}
+
+func (r *MemoryKlineRepo) List(ctx context.Context, symbol, interval string, start, end time.Time) ([]*models.Kline, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*models.Kline
+	for _, k := range r.klines {
+		if k.Symbol != symbol || k.Interval != interval || k.OpenTime.Before(start) || !k.OpenTime.Before(end) {
+			continue
+		}
+		copied := *k
+		out = append(out, &copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].OpenTime.Before(out[j].OpenTime) })
+	return out, nil
+}
+
+// MemoryJournalRepo is an in-memory JournalRepo
+type MemoryJournalRepo struct {
+	mu      sync.RWMutex
+	entries []*models.JournalEntry
+}
+
+func NewMemoryJournalRepo() *MemoryJournalRepo {
+	return &MemoryJournalRepo{}
+}
+
+// copyJournalEntry copies entry including its tags, so stored entries are not shared with callers
+func copyJournalEntry(entry *models.JournalEntry) *models.JournalEntry {
+	copied := *entry
+	copied.Tags = append([]string(nil), entry.Tags...)
+	return &copied
+}
+
+func (r *MemoryJournalRepo) Insert(ctx context.Context, entry *models.JournalEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	r.entries = append(r.entries, copyJournalEntry(entry))
+	return nil
+}
+
+func (r *MemoryJournalRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.JournalEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, e := range r.entries {
+		if e.ID == id {
+			return copyJournalEntry(e), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryJournalRepo) List(ctx context.Context, query *JournalQuery) ([]*models.JournalEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []*models.JournalEntry{}
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		e := r.entries[i]
+		if query.OrderID != nil && e.OrderID != *query.OrderID || query.Symbol != "" && e.Symbol != query.Symbol {
+			continue
+		}
+		if !hasAllTags(e.Tags, query.Tags) {
+			continue
+		}
+		out = append(out, copyJournalEntry(e))
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if query.Limit > 0 && int64(len(out)) > query.Limit {
+		out = out[:query.Limit]
+	}
+	return out, nil
+}
+
+// hasAllTags reports whether tags contains every one of want
+func hasAllTags(tags, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, t := range tags {
+			if t == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *MemoryJournalRepo) Latest(ctx context.Context, orderIDs []primitive.ObjectID) (map[primitive.ObjectID]*models.JournalEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	wanted := make(map[primitive.ObjectID]bool, len(orderIDs))
+	for _, id := range orderIDs {
+		wanted[id] = true
+	}
+	latest := make(map[primitive.ObjectID]*models.JournalEntry)
+	for _, e := range r.entries {
+		if !wanted[e.OrderID] {
+			continue
+		}
+		if current, ok := latest[e.OrderID]; !ok || !e.CreatedAt.Before(current.CreatedAt) {
+			latest[e.OrderID] = copyJournalEntry(e)
+		}
+	}
+	return latest, nil
+}
+
+func (r *MemoryJournalRepo) Update(ctx context.Context, entry *models.JournalEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.entries {
+		if e.ID == entry.ID {
+			e.Note = entry.Note
+			e.Tags = append([]string(nil), entry.Tags...)
+			e.UpdatedAt = entry.UpdatedAt
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryJournalRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, e := range r.entries {
+		if e.ID == id {
+			r.entries = append(r.entries[:i], r.entries[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryJournalRepo) Tags(ctx context.Context, prefix string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, e := range r.entries {
+		for _, t := range e.Tags {
+			if !seen[t] && strings.HasPrefix(t, prefix) {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// MemoryWebhookRepo is an in-memory WebhookRepo
+type MemoryWebhookRepo struct {
+	mu          sync.RWMutex
+	webhooks    []*models.Webhook
+	deadLetters []*models.WebhookDeadLetter
+}
+
+func NewMemoryWebhookRepo() *MemoryWebhookRepo {
+	return &MemoryWebhookRepo{}
+}
+
+func (r *MemoryWebhookRepo) Insert(ctx context.Context, webhook *models.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if webhook.ID.IsZero() {
+		webhook.ID = primitive.NewObjectID()
+	}
+	copied := *webhook
+	r.webhooks = append(r.webhooks, &copied)
+	return nil
+}
+
+func (r *MemoryWebhookRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, w := range r.webhooks {
+		if w.ID == id {
+			copied := *w
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryWebhookRepo) List(ctx context.Context) ([]*models.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*models.Webhook, 0, len(r.webhooks))
+	for _, w := range r.webhooks {
+		copied := *w
+		out = append(out, &copied)
+	}
+	return out, nil
+}
+
+func (r *MemoryWebhookRepo) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, w := range r.webhooks {
+		if w.ID == id {
+			r.webhooks = append(r.webhooks[:i], r.webhooks[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryWebhookRepo) InsertDeadLetter(ctx context.Context, letter *models.WebhookDeadLetter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if letter.ID.IsZero() {
+		letter.ID = primitive.NewObjectID()
+	}
+	copied := *letter
+	r.deadLetters = append(r.deadLetters, &copied)
+	return nil
+}
+
+func (r *MemoryWebhookRepo) ListDeadLetters(ctx context.Context, limit int64) ([]*models.WebhookDeadLetter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []*models.WebhookDeadLetter
+	for i := len(r.deadLetters) - 1; i >= 0 && int64(len(out)) < limit; i-- {
+		copied := *r.deadLetters[i]
+		out = append(out, &copied)
+	}
+	return out, nil
+}
+
+// MemoryConditionalOrderRepo is an in-memory ConditionalOrderRepo
+type MemoryConditionalOrderRepo struct {
+	mu     sync.Mutex
+	orders []*models.ConditionalOrder
+}
+
+func NewMemoryConditionalOrderRepo() *MemoryConditionalOrderRepo {
+	return &MemoryConditionalOrderRepo{}
+}
+
+func (r *MemoryConditionalOrderRepo) Insert(ctx context.Context, order *models.ConditionalOrder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if order.ID.IsZero() {
+		order.ID = primitive.NewObjectID()
+	}
+	copied := *order
+	r.orders = append(r.orders, &copied)
+	return nil
+}
+
+func (r *MemoryConditionalOrderRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ConditionalOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.orders {
+		if o.ID == id {
+			copied := *o
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryConditionalOrderRepo) List(ctx context.Context, status models.ConditionalStatus) ([]*models.ConditionalOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*models.ConditionalOrder
+	for i := len(r.orders) - 1; i >= 0; i-- {
+		if status == "" || r.orders[i].Status == status {
+			copied := *r.orders[i]
+			out = append(out, &copied)
+		}
+	}
+	return out, nil
+}
+
+func (r *MemoryConditionalOrderRepo) Transition(ctx context.Context, id primitive.ObjectID, from models.ConditionalStatus, update *ConditionalOrderUpdate) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.orders {
+		if o.ID == id && o.Status == from {
+			return true, applySet(o, update.set())
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryConditionalOrderRepo) TransitionGroup(ctx context.Context, group string, except primitive.ObjectID, from models.ConditionalStatus, update *ConditionalOrderUpdate) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var changed int64
+	for _, o := range r.orders {
+		if o.Group == group && o.ID != except && o.Status == from {
+			if err := applySet(o, update.set()); err != nil {
+				return changed, err
+			}
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// MemoryScheduledOrderRepo is an in-memory ScheduledOrderRepo
+type MemoryScheduledOrderRepo struct {
+	mu     sync.Mutex
+	orders []*models.ScheduledOrder
+}
+
+func NewMemoryScheduledOrderRepo() *MemoryScheduledOrderRepo {
+	return &MemoryScheduledOrderRepo{}
+}
+
+func (r *MemoryScheduledOrderRepo) Insert(ctx context.Context, order *models.ScheduledOrder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if order.ID.IsZero() {
+		order.ID = primitive.NewObjectID()
+	}
+	copied := *order
+	r.orders = append(r.orders, &copied)
+	return nil
+}
+
+func (r *MemoryScheduledOrderRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ScheduledOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.orders {
+		if o.ID == id {
+			copied := *o
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryScheduledOrderRepo) List(ctx context.Context, status models.ScheduleStatus) ([]*models.ScheduledOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*models.ScheduledOrder
+	for _, o := range r.orders {
+		if status == "" || o.Status == status {
+			copied := *o
+			out = append(out, &copied)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].ExecuteAt.Before(out[j].ExecuteAt) })
+	return out, nil
+}
+
+func (r *MemoryScheduledOrderRepo) Transition(ctx context.Context, id primitive.ObjectID, from models.ScheduleStatus, update *ScheduledOrderUpdate) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.orders {
+		if o.ID == id && o.Status == from {
+			return true, applySet(o, update.set())
+		}
+	}
+	return false, nil
+}
+
+// MemoryDCAPlanRepo is an in-memory DCAPlanRepo
+type MemoryDCAPlanRepo struct {
+	mu    sync.Mutex
+	plans []*models.DCAPlan
+}
+
+func NewMemoryDCAPlanRepo() *MemoryDCAPlanRepo {
+	return &MemoryDCAPlanRepo{}
+}
+
+// copyDCAPlan copies plan including its orders, so stored plans are not shared with callers
+func copyDCAPlan(plan *models.DCAPlan) *models.DCAPlan {
+	copied := *plan
+	copied.Orders = append([]models.DCAOrder(nil), plan.Orders...)
+	return &copied
+}
+
+func (r *MemoryDCAPlanRepo) Insert(ctx context.Context, plan *models.DCAPlan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if plan.ID.IsZero() {
+		plan.ID = primitive.NewObjectID()
+	}
+	r.plans = append(r.plans, copyDCAPlan(plan))
+	return nil
+}
+
+func (r *MemoryDCAPlanRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.DCAPlan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.plans {
+		if p.ID == id {
+			return copyDCAPlan(p), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryDCAPlanRepo) List(ctx context.Context, status models.DCAStatus) ([]*models.DCAPlan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*models.DCAPlan
+	for i := len(r.plans) - 1; i >= 0; i-- {
+		if status == "" || r.plans[i].Status == status {
+			out = append(out, copyDCAPlan(r.plans[i]))
+		}
+	}
+	return out, nil
+}
+
+func (r *MemoryDCAPlanRepo) Update(ctx context.Context, plan *models.DCAPlan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, p := range r.plans {
+		if p.ID == plan.ID {
+			r.plans[i] = copyDCAPlan(plan)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// MemoryGridStrategyRepo is an in-memory GridStrategyRepo
+type MemoryGridStrategyRepo struct {
+	mu         sync.Mutex
+	strategies []*models.GridStrategy
+}
+
+func NewMemoryGridStrategyRepo() *MemoryGridStrategyRepo {
+	return &MemoryGridStrategyRepo{}
+}
+
+// copyGridStrategy copies strategy including its grids, so stored strategies are not shared with callers
+func copyGridStrategy(strategy *models.GridStrategy) *models.GridStrategy {
+	copied := *strategy
+	copied.Grids = append([]models.Grid(nil), strategy.Grids...)
+	return &copied
+}
+
+func (r *MemoryGridStrategyRepo) Insert(ctx context.Context, strategy *models.GridStrategy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if strategy.ID.IsZero() {
+		strategy.ID = primitive.NewObjectID()
+	}
+	r.strategies = append(r.strategies, copyGridStrategy(strategy))
+	return nil
+}
+
+func (r *MemoryGridStrategyRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.GridStrategy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.strategies {
+		if s.ID == id {
+			return copyGridStrategy(s), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryGridStrategyRepo) List(ctx context.Context, status models.GridStatus) ([]*models.GridStrategy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*models.GridStrategy
+	for i := len(r.strategies) - 1; i >= 0; i-- {
+		if status == "" || r.strategies[i].Status == status {
+			out = append(out, copyGridStrategy(r.strategies[i]))
+		}
+	}
+	return out, nil
+}
+
+func (r *MemoryGridStrategyRepo) Update(ctx context.Context, strategy *models.GridStrategy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, s := range r.strategies {
+		if s.ID == strategy.ID {
+			r.strategies[i] = copyGridStrategy(strategy)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// MemoryCalendarSpreadRepo is an in-memory CalendarSpreadRepo
+type MemoryCalendarSpreadRepo struct {
+	mu      sync.Mutex
+	spreads []*models.CalendarSpread
+}
+
+func NewMemoryCalendarSpreadRepo() *MemoryCalendarSpreadRepo {
+	return &MemoryCalendarSpreadRepo{}
+}
+
+func (r *MemoryCalendarSpreadRepo) Insert(ctx context.Context, spread *models.CalendarSpread) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if spread.ID.IsZero() {
+		spread.ID = primitive.NewObjectID()
+	}
+	copied := *spread
+	r.spreads = append(r.spreads, &copied)
+	return nil
+}
+
+func (r *MemoryCalendarSpreadRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.CalendarSpread, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, sp := range r.spreads {
+		if sp.ID == id {
+			copied := *sp
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryCalendarSpreadRepo) Update(ctx context.Context, spread *models.CalendarSpread) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, sp := range r.spreads {
+		if sp.ID == spread.ID {
+			copied := *spread
+			r.spreads[i] = &copied
+			return nil
+		}
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,2308 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"futures-options/database"
+	"futures-options/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// operationTimeout bounds each repository call whose context has no earlier deadline, so a hung
+// MongoDB node fails the call instead of blocking order placement; 0 disables it
+var operationTimeout = 10 * time.Second
+
+// SetOperationTimeout changes the per-call timeout of the MongoDB repositories
+func SetOperationTimeout(d time.Duration) {
+	operationTimeout = d
+}
+
+// withTimeout applies operationTimeout to ctx unless ctx already ends sooner. Streaming calls
+// (Each, EachOpen) are not bounded, since their callbacks may take as long as they need.
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if operationTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= operationTimeout {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, operationTimeout)
+}
+
+// NewMongoRepositories builds MongoDB-backed repositories; database.Connect must have been called
+func NewMongoRepositories() *Repositories {
+	return &Repositories{
+		Tx:            &mongoTransactor{client: database.Client, enabled: database.TransactionsSupported},
+		FuturesOrders: &mongoFuturesOrderRepo{coll: database.FuturesCollection, archive: database.FuturesArchiveCollection},
+		OptionsOrders: &mongoOptionsOrderRepo{coll: database.OptionsCollection},
+		SpotOrders:    &mongoSpotOrderRepo{coll: database.SpotOrdersCollection},
+		Transfers:     &mongoTransferRepo{coll: database.TransfersCollection},
+		Positions:     &mongoPositionRepo{coll: database.PositionsCollection, modeColl: database.PositionModeCollection},
+		RiskEvents:    &mongoRiskEventRepo{coll: database.RiskEventsCollection},
+		Credentials:   &mongoCredentialsRepo{coll: database.APICredentialsCollection},
+		Audit:         &mongoAuditRepo{coll: database.AuditLogCollection},
+		Tokens:        &mongoTokenRepo{coll: database.APITokensCollection},
+		RiskLimits:    &mongoRiskLimitRepo{coll: database.RiskLimitsCollection},
+		Settings:      &mongoSymbolSettingsRepo{coll: database.SymbolSettingsCollection},
+		Policy:        &mongoTradingPolicyRepo{coll: database.TradingPolicyCollection},
+		Templates:     &mongoOrderTemplateRepo{coll: database.OrderTemplatesCollection},
+		Watchlist:     &mongoWatchlistRepo{coll: database.WatchlistCollection},
+		Reconcile:     &mongoReconciliationRepo{coll: database.ReconciliationReportsCollection},
+		Jobs:          &mongoJobRepo{coll: database.JobsCollection},
+		Income:        &mongoIncomeRepo{coll: database.IncomeCollection},
+		Fills:         &mongoFillRepo{coll: database.FillsCollection},
+		Equity:        &mongoEquityRepo{coll: database.EquitySnapshotsCollection},
+		Webhooks: &mongoWebhookRepo{
+			webhooks:    database.WebhooksCollection,
+			deadLetters: database.WebhookDeadLettersCollection,
+		},
+		Conditional: &mongoConditionalOrderRepo{coll: database.ConditionalOrdersCollection},
+		Scheduled:   &mongoScheduledOrderRepo{coll: database.ScheduledOrdersCollection},
+		DCAPlans:    &mongoDCAPlanRepo{coll: database.DCAPlansCollection},
+		Grids:       &mongoGridStrategyRepo{coll: database.GridStrategiesCollection},
+		Spreads:     &mongoCalendarSpreadRepo{coll: database.CalendarSpreadsCollection},
+		Klines:      &mongoKlineRepo{coll: database.KlinesCollection},
+		Journal:     &mongoJournalRepo{coll: database.JournalEntriesCollection},
+		Paper: &mongoPaperRepo{
+			orders:    database.PaperOrdersCollection,
+			positions: database.PaperPositionsCollection,
+			accounts:  database.PaperAccountCollection,
+		},
+	}
+}
+
+// mapError converts driver errors into repository errors
+func mapError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case err == mongo.ErrNoDocuments:
+		return ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return fmt.Errorf("%w: %v", ErrDuplicate, err)
+	default:
+		return err
+	}
+}
+
+// bulkDuplicates splits an unordered InsertMany error into the indexes of the documents
+// rejected as duplicate keys and whatever other error remains
+func bulkDuplicates(err error) ([]int, error) {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) {
+		return nil, mapError(err)
+	}
+	var duplicates []int
+	var rest []mongo.BulkWriteError
+	for _, writeErr := range bulkErr.WriteErrors {
+		// Same codes as mongo.IsDuplicateKeyError, which does not accept a single write error
+		if writeErr.Code == 11000 || writeErr.Code == 11001 || writeErr.Code == 12582 {
+			duplicates = append(duplicates, writeErr.Index)
+			continue
+		}
+		rest = append(rest, writeErr)
+	}
+	if len(rest) == 0 && bulkErr.WriteConcernError == nil {
+		return duplicates, nil
+	}
+	bulkErr.WriteErrors = rest
+	return duplicates, bulkErr
+}
+
+// clientOrderFilter matches the orders of accountID with a client order ID; orders signed with
+// environment keys are stored without an account
+func clientOrderFilter(accountID, clientOrderID string) bson.M {
+	filter := bson.M{"client_order_id": clientOrderID, "account_id": accountID}
+	if accountID == "" {
+		filter["account_id"] = nil
+	}
+	return filter
+}
+
+// orderFilter builds the Mongo filter for the query, without the paging cursor
+func orderFilter(q *OrderQuery) bson.M {
+	filter := bson.M{}
+	if q.Symbol != "" {
+		filter["symbol"] = q.Symbol
+	}
+	if q.AccountID != "" {
+		filter["account_id"] = q.AccountID
+	}
+	if q.Status != "" {
+		filter["status"] = q.Status
+	}
+	if q.Side != "" {
+		filter["side"] = q.Side
+	}
+	if q.StartTime != nil || q.EndTime != nil {
+		createdAt := bson.M{}
+		if q.StartTime != nil {
+			createdAt["$gte"] = *q.StartTime
+		}
+		if q.EndTime != nil {
+			createdAt["$lte"] = *q.EndTime
+		}
+		filter["created_at"] = createdAt
+	}
+	if q.ClientOrderIDPrefix != "" {
+		filter["client_order_id"] = bson.M{"$regex": "^" + regexp.QuoteMeta(q.ClientOrderIDPrefix)}
+	}
+	if q.BinanceOrderID > 0 {
+		filter["binance_order_id"] = q.BinanceOrderID
+	}
+	if q.StrategyID != "" {
+		filter["strategy_id"] = q.StrategyID
+	}
+	if q.Source != "" {
+		filter["source"] = q.Source
+	}
+	if q.Text != "" {
+		text := bson.A{
+			bson.M{"client_order_id": bson.M{"$regex": "^" + regexp.QuoteMeta(q.Text)}},
+			bson.M{"strategy_id": q.Text},
+		}
+		if id, err := strconv.ParseInt(q.Text, 10, 64); err == nil && id > 0 {
+			text = append(text, bson.M{"binance_order_id": id})
+		}
+		filter["$or"] = text
+	}
+	if q.PartiallyFilled {
+		filter["$and"] = bson.A{bson.M{"$or": bson.A{
+			bson.M{"status": "PARTIALLY_FILLED"},
+			bson.M{"executed_qty": bson.M{"$gt": 0}, "status": bson.M{"$ne": "FILLED"}},
+		}}}
+	}
+	return filter
+}
+
+// cursorLookupError reports a cursor whose document does not exist as ErrInvalidCursor; other
+// lookup failures are returned as they are
+func cursorLookupError(err error, cursor string) error {
+	if err = mapError(err); errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%w: %s not found", ErrInvalidCursor, cursor)
+	}
+	return fmt.Errorf("failed to look up cursor: %w", err)
+}
+
+// orderPageFilter adds the keyset condition for the cursor to the base filter
+func orderPageFilter(ctx context.Context, coll *mongo.Collection, q *OrderQuery, base bson.M) (bson.M, error) {
+	if q.BeforeID == "" {
+		return base, nil
+	}
+
+	id, err := primitive.ObjectIDFromHex(q.BeforeID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	var anchor struct {
+		CreatedAt time.Time `bson:"created_at"`
+	}
+	if err := coll.FindOne(ctx, bson.M{"_id": id}).Decode(&anchor); err != nil {
+		return nil, cursorLookupError(err, q.BeforeID)
+	}
+
+	op := "$lt"
+	if q.SortAsc {
+		op = "$gt"
+	}
+	keyset := bson.M{"$or": bson.A{
+		bson.M{"created_at": bson.M{op: anchor.CreatedAt}},
+		bson.M{"created_at": anchor.CreatedAt, "_id": bson.M{op: id}},
+	}}
+
+	return bson.M{"$and": bson.A{base, keyset}}, nil
+}
+
+// orderFindOptions builds sort/skip/limit options; one extra document is fetched to detect a next page
+func orderFindOptions(q *OrderQuery) *options.FindOptions {
+	direction := -1
+	if q.SortAsc {
+		direction = 1
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(q.PageLimit() + 1)
+	if q.Offset > 0 && q.BeforeID == "" {
+		opts.SetSkip(q.Offset)
+	}
+	if !q.IncludeRaw {
+		opts.SetProjection(bson.M{"raw_response": 0})
+	}
+	return opts
+}
+
+// listOrders runs a paged order query against coll and decodes into out
+func listOrders(ctx context.Context, coll *mongo.Collection, q *OrderQuery, out interface{}) (int64, error) {
+	filter := orderFilter(q)
+
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	pageFilter, err := orderPageFilter(ctx, coll, q, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	cursor, err := coll.Find(ctx, pageFilter, orderFindOptions(q))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query orders: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, out); err != nil {
+		return 0, fmt.Errorf("failed to decode orders: %w", err)
+	}
+	return total, nil
+}
+
+// orderStreamOptions sorts like orderFindOptions but without paging, so every match is returned
+func orderStreamOptions(q *OrderQuery) *options.FindOptions {
+	direction := -1
+	if q.SortAsc {
+		direction = 1
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: direction}, {Key: "_id", Value: direction}})
+	if !q.IncludeRaw {
+		opts.SetProjection(bson.M{"raw_response": 0})
+	}
+	return opts
+}
+
+// openOrderFilter matches orders that are still working on Binance and worth reconciling
+var openOrderFilter = bson.M{
+	"status":              bson.M{"$in": []string{"NEW", "PARTIALLY_FILLED"}},
+	"binance_order_id":    bson.M{"$gt": 0},
+	"missing_on_exchange": bson.M{"$ne": true},
+}
+
+// eachDocument decodes the documents matching filter one at a time and passes each to fn, so
+// large result sets are never held in memory; it stops at the first error fn returns
+func eachDocument[T any](ctx context.Context, coll *mongo.Collection, filter interface{}, opts *options.FindOptions, fn func(*T) error) error {
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc T
+		if err := cursor.Decode(&doc); err != nil {
+			return fmt.Errorf("failed to decode document: %w", err)
+		}
+		if err := fn(&doc); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+type mongoFuturesOrderRepo struct {
+	coll    *mongo.Collection
+	archive *mongo.Collection
+}
+
+func (r *mongoFuturesOrderRepo) Insert(ctx context.Context, order *models.FuturesOrder) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := r.coll.InsertOne(ctx, order)
+	return mapError(err)
+}
+
+func (r *mongoFuturesOrderRepo) InsertMany(ctx context.Context, orders []*models.FuturesOrder) ([]int, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if len(orders) == 0 {
+		return nil, nil
+	}
+	docs := make([]interface{}, len(orders))
+	for i, order := range orders {
+		docs[i] = order
+	}
+	_, err := r.coll.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	return bulkDuplicates(err)
+}
+
+func (r *mongoFuturesOrderRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.FuturesOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var order models.FuturesOrder
+	if err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&order); err != nil {
+		return nil, mapError(err)
+	}
+	return &order, nil
+}
+
+func (r *mongoFuturesOrderRepo) FindByBinanceID(ctx context.Context, binanceOrderID int64) (*models.FuturesOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var order models.FuturesOrder
+	if err := r.coll.FindOne(ctx, bson.M{"binance_order_id": binanceOrderID}).Decode(&order); err != nil {
+		return nil, mapError(err)
+	}
+	return &order, nil
+}
+
+func (r *mongoFuturesOrderRepo) FindByClientOrderID(ctx context.Context, accountID, clientOrderID string) (*models.FuturesOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var order models.FuturesOrder
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if err := r.coll.FindOne(ctx, clientOrderFilter(accountID, clientOrderID), opts).Decode(&order); err != nil {
+		return nil, mapError(err)
+	}
+	return &order, nil
+}
+
+func (r *mongoFuturesOrderRepo) List(ctx context.Context, query *OrderQuery) ([]*models.FuturesOrder, int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	orders := []*models.FuturesOrder{}
+	total, err := listOrders(ctx, r.coll, query, &orders)
+	if err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+func (r *mongoFuturesOrderRepo) Each(ctx context.Context, query *OrderQuery, fn func(*models.FuturesOrder) error) error {
+	return eachDocument(ctx, r.coll, orderFilter(query), orderStreamOptions(query), fn)
+}
+
+func (r *mongoFuturesOrderRepo) EachOpen(ctx context.Context, fn func(*models.FuturesOrder) error) error {
+	opts := options.Find().SetProjection(bson.M{"raw_response": 0})
+	return eachDocument(ctx, r.coll, openOrderFilter, opts, fn)
+}
+
+func (r *mongoFuturesOrderRepo) ListOpen(ctx context.Context, afterID primitive.ObjectID, limit int) ([]*models.FuturesOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{}
+	for k, v := range openOrderFilter {
+		filter[k] = v
+	}
+	filter["_id"] = bson.M{"$gt": afterID}
+	opts := options.Find().
+		SetProjection(bson.M{"raw_response": 0}).
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query open orders: %w", err)
+	}
+	orders := []*models.FuturesOrder{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, fmt.Errorf("failed to decode open orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (r *mongoFuturesOrderRepo) ArchiveTerminal(ctx context.Context, before time.Time, limit int) (int, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{"status": bson.M{"$in": TerminalOrderStatuses}, "updated_at": bson.M{"$lt": before}}
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query orders to archive: %w", err)
+	}
+	var docs []bson.Raw
+	if err := cursor.All(ctx, &docs); err != nil {
+		return 0, fmt.Errorf("failed to decode orders to archive: %w", err)
+	}
+	if len(docs) == 0 {
+		return 0, nil
+	}
+
+	// Replacing by _id keeps a rerun after a partial failure from duplicating archived orders
+	writes := make([]mongo.WriteModel, len(docs))
+	ids := make([]interface{}, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.Lookup("_id")
+		writes[i] = mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": ids[i]}).SetReplacement(doc).SetUpsert(true)
+	}
+	if _, err := r.archive.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return 0, fmt.Errorf("failed to write order archive: %w", err)
+	}
+	filter["_id"] = bson.M{"$in": ids}
+	result, err := r.coll.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove archived orders: %w", err)
+	}
+	return int(result.DeletedCount), nil
+}
+
+func (r *mongoFuturesOrderRepo) UpdateByRef(ctx context.Context, binanceOrderID int64, clientOrderID string, update *FuturesOrderUpdate) (*models.FuturesOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{}
+	if binanceOrderID > 0 {
+		filter["binance_order_id"] = binanceOrderID
+	} else {
+		filter["client_order_id"] = clientOrderID
+	}
+
+	var order models.FuturesOrder
+	err := r.coll.FindOneAndUpdate(ctx, filter, bson.M{"$set": update.set()}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&order)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &order, nil
+}
+
+func (r *mongoFuturesOrderRepo) UpdateFill(ctx context.Context, binanceOrderID int64, clientOrderID string, update *FuturesOrderUpdate) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{"executed_qty": bson.M{"$not": bson.M{"$gt": update.Fill.ExecutedQty}}}
+	if binanceOrderID > 0 {
+		filter["binance_order_id"] = binanceOrderID
+	} else {
+		filter["client_order_id"] = clientOrderID
+	}
+
+	result, err := r.coll.UpdateOne(ctx, filter, bson.M{"$set": update.set()})
+	if err != nil {
+		return false, mapError(err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *mongoFuturesOrderRepo) SetStatus(ctx context.Context, symbol string, orderIDs []int64, clientOrderIDs []string, status string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{"symbol": symbol}
+	if len(orderIDs) > 0 {
+		filter["binance_order_id"] = bson.M{"$in": orderIDs}
+	}
+	if len(clientOrderIDs) > 0 {
+		filter["client_order_id"] = bson.M{"$in": clientOrderIDs}
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":     status,
+			"updated_at": time.Now(),
+		},
+	}
+
+	_, err := r.coll.UpdateMany(ctx, filter, update)
+	return err
+}
+
+type mongoOptionsOrderRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoOptionsOrderRepo) Insert(ctx context.Context, order *models.OptionsOrder) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := r.coll.InsertOne(ctx, order)
+	return mapError(err)
+}
+
+func (r *mongoOptionsOrderRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.OptionsOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var order models.OptionsOrder
+	if err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&order); err != nil {
+		return nil, mapError(err)
+	}
+	return &order, nil
+}
+
+func (r *mongoOptionsOrderRepo) FindByBinanceID(ctx context.Context, binanceOrderID int64) (*models.OptionsOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var order models.OptionsOrder
+	if err := r.coll.FindOne(ctx, bson.M{"binance_order_id": binanceOrderID}).Decode(&order); err != nil {
+		return nil, mapError(err)
+	}
+	return &order, nil
+}
+
+func (r *mongoOptionsOrderRepo) List(ctx context.Context, query *OrderQuery) ([]*models.OptionsOrder, int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	orders := []*models.OptionsOrder{}
+	total, err := listOrders(ctx, r.coll, query, &orders)
+	if err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+func (r *mongoOptionsOrderRepo) Each(ctx context.Context, query *OrderQuery, fn func(*models.OptionsOrder) error) error {
+	return eachDocument(ctx, r.coll, orderFilter(query), orderStreamOptions(query), fn)
+}
+
+type mongoSpotOrderRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoSpotOrderRepo) Insert(ctx context.Context, order *models.SpotOrder) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := r.coll.InsertOne(ctx, order)
+	return mapError(err)
+}
+
+func (r *mongoSpotOrderRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.SpotOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var order models.SpotOrder
+	if err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&order); err != nil {
+		return nil, mapError(err)
+	}
+	return &order, nil
+}
+
+func (r *mongoSpotOrderRepo) FindByBinanceID(ctx context.Context, binanceOrderID int64) (*models.SpotOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var order models.SpotOrder
+	if err := r.coll.FindOne(ctx, bson.M{"binance_order_id": binanceOrderID}).Decode(&order); err != nil {
+		return nil, mapError(err)
+	}
+	return &order, nil
+}
+
+func (r *mongoSpotOrderRepo) FindByClientOrderID(ctx context.Context, accountID, clientOrderID string) (*models.SpotOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var order models.SpotOrder
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if err := r.coll.FindOne(ctx, clientOrderFilter(accountID, clientOrderID), opts).Decode(&order); err != nil {
+		return nil, mapError(err)
+	}
+	return &order, nil
+}
+
+func (r *mongoSpotOrderRepo) List(ctx context.Context, query *OrderQuery) ([]*models.SpotOrder, int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	orders := []*models.SpotOrder{}
+	total, err := listOrders(ctx, r.coll, query, &orders)
+	if err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+func (r *mongoSpotOrderRepo) ListOpen(ctx context.Context) ([]*models.SpotOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	cursor, err := r.coll.Find(ctx, openOrderFilter, options.Find().SetProjection(bson.M{"raw_response": 0}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query open spot orders: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	orders := []*models.SpotOrder{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, fmt.Errorf("failed to decode spot orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (r *mongoSpotOrderRepo) UpdateByRef(ctx context.Context, binanceOrderID int64, clientOrderID string, update *SpotOrderUpdate) (*models.SpotOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{}
+	if binanceOrderID > 0 {
+		filter["binance_order_id"] = binanceOrderID
+	} else {
+		filter["client_order_id"] = clientOrderID
+	}
+
+	var order models.SpotOrder
+	err := r.coll.FindOneAndUpdate(ctx, filter, bson.M{"$set": update.set()}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&order)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &order, nil
+}
+
+type mongoTransferRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoTransferRepo) Insert(ctx context.Context, transfer *models.Transfer) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := r.coll.InsertOne(ctx, transfer)
+	return mapError(err)
+}
+
+func (r *mongoTransferRepo) SetStatus(ctx context.Context, tranID int64, status string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{"tran_id": tranID, "status": bson.M{"$ne": status}}
+	update := bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}}
+	_, err := r.coll.UpdateOne(ctx, filter, update)
+	return err
+}
+
+type mongoPositionRepo struct {
+	coll     *mongo.Collection
+	modeColl *mongo.Collection
+}
+
+func (r *mongoPositionRepo) List(ctx context.Context, positionType, accountID string) ([]*models.Position, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var positions []*models.Position
+	err := r.Each(ctx, positionType, accountID, func(position *models.Position) error {
+		positions = append(positions, position)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list positions: %w", err)
+	}
+	return positions, nil
+}
+
+func (r *mongoPositionRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Position, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var position models.Position
+	if err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&position); err != nil {
+		return nil, mapError(err)
+	}
+	return &position, nil
+}
+
+func (r *mongoPositionRepo) Each(ctx context.Context, positionType, accountID string, fn func(*models.Position) error) error {
+	filter := bson.M{}
+	if positionType != "" {
+		filter["type"] = positionType
+	}
+	if accountID != "" {
+		filter["account_id"] = accountID
+	}
+	return eachDocument(ctx, r.coll, filter, options.Find(), fn)
+}
+
+func (r *mongoPositionRepo) Upsert(ctx context.Context, position *models.Position) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := positionKey(position)
+	update := bson.M{"$set": position, "$setOnInsert": bson.M{"funding_since": position.UpdatedAt}}
+
+	opts := options.Update().SetUpsert(true)
+	_, err := r.coll.UpdateOne(ctx, filter, update, opts)
+	return err
+}
+
+func (r *mongoPositionRepo) SyncMarket(ctx context.Context, market models.Market, accountID string, positions []*models.Position, keep []string) (int64, int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	writes := make([]mongo.WriteModel, 0, len(positions)+1)
+	live := make(bson.A, 0, len(positions))
+	for _, position := range positions {
+		key := positionKey(position)
+		live = append(live, bson.M{"symbol": position.Symbol, "side": position.Side, "account_id": position.AccountID})
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(key).
+			SetUpdate(bson.M{
+				"$set":         position,
+				"$setOnInsert": bson.M{"funding_since": position.UpdatedAt},
+				// A margin call flag lasts until the next sync; $set skips the empty fields
+				"$unset": bson.M{"at_risk": "", "margin_call_price": ""},
+			}).
+			SetUpsert(true))
+	}
+
+	marketFilter := interface{}(market)
+	if market == models.MarketUSDM {
+		marketFilter = bson.M{"$in": bson.A{models.MarketUSDM, nil}}
+	}
+	stale := bson.M{
+		"type":       "FUTURES",
+		"market":     marketFilter,
+		"account_id": bson.M{"$in": bson.A{accountID, "", nil}},
+		"symbol":     bson.M{"$nin": append([]string{}, keep...)},
+	}
+	if len(live) > 0 {
+		stale["$nor"] = live
+	}
+	writes = append(writes, mongo.NewDeleteManyModel().SetFilter(stale))
+
+	result, err := r.coll.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, 0, err
+	}
+	return result.MatchedCount + result.UpsertedCount, result.DeletedCount, nil
+}
+
+func (r *mongoPositionRepo) AddFunding(ctx context.Context, id primitive.ObjectID, amount float64) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := r.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"funding_paid": amount}})
+	return mapError(err)
+}
+
+func (r *mongoPositionRepo) FlagMarginCall(ctx context.Context, symbol string, side models.PositionSide, accountID string, markPrice float64, at time.Time) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{"symbol": symbol, "type": "FUTURES", "side": side, "account_id": accountID}
+	update := bson.M{"$set": bson.M{"at_risk": true, "margin_call_price": markPrice, "updated_at": at}}
+	_, err := r.coll.UpdateOne(ctx, filter, update)
+	return mapError(err)
+}
+
+func (r *mongoPositionRepo) ResetFunding(ctx context.Context, symbol string, side models.PositionSide, accountID string, since time.Time) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{"symbol": symbol, "type": "FUTURES", "side": side, "account_id": accountID}
+	_, err := r.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"funding_paid": 0, "funding_since": since}})
+	return mapError(err)
+}
+
+// positionKey identifies a stored position: hedge-mode legs share a symbol and type
+func positionKey(position *models.Position) bson.M {
+	return bson.M{"symbol": position.Symbol, "type": position.Type, "side": position.Side, "account_id": position.AccountID}
+}
+
+func (r *mongoPositionRepo) SavePositionMode(ctx context.Context, mode *models.PositionModeConfig) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{}
+	update := bson.M{"$set": mode}
+	opts := options.Update().SetUpsert(true)
+
+	_, err := r.modeColl.UpdateOne(ctx, filter, update, opts)
+	return err
+}
+
+type mongoRiskEventRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoRiskEventRepo) Insert(ctx context.Context, event *models.RiskEvent) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := r.coll.InsertOne(ctx, event)
+	return mapError(err)
+}
+
+func (r *mongoRiskEventRepo) List(ctx context.Context, symbol string, limit int64) ([]*models.RiskEvent, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{}
+	if symbol != "" {
+		filter["symbol"] = symbol
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	cursor, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query risk events: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []*models.RiskEvent
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode risk events: %w", err)
+	}
+	return events, nil
+}
+
+type mongoCredentialsRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoCredentialsRepo) List(ctx context.Context, activeOnly bool) ([]*models.APICredentials, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{}
+	if activeOnly {
+		filter["is_active"] = true
+	}
+
+	cursor, err := r.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API credentials: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var credentials []*models.APICredentials
+	if err = cursor.All(ctx, &credentials); err != nil {
+		return nil, fmt.Errorf("failed to decode API credentials: %w", err)
+	}
+	return credentials, nil
+}
+
+func (r *mongoCredentialsRepo) findOne(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (*models.APICredentials, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	credentials := &models.APICredentials{}
+	if err := r.coll.FindOne(ctx, filter, opts...).Decode(credentials); err != nil {
+		return nil, mapError(err)
+	}
+	return credentials, nil
+}
+
+func (r *mongoCredentialsRepo) FindActive(ctx context.Context) (*models.APICredentials, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	// Sorting makes "the active credential" deterministic if several are flagged
+	return r.findOne(ctx, bson.M{"is_active": true}, options.FindOne().SetSort(bson.D{{Key: "updated_at", Value: -1}}))
+}
+
+func (r *mongoCredentialsRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.APICredentials, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoCredentialsRepo) FindByAPIKey(ctx context.Context, apiKey string) (*models.APICredentials, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	return r.findOne(ctx, bson.M{"api_key": apiKey})
+}
+
+func (r *mongoCredentialsRepo) Insert(ctx context.Context, credentials *models.APICredentials) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := r.coll.InsertOne(ctx, credentials)
+	return mapError(err)
+}
+
+func (r *mongoCredentialsRepo) Update(ctx context.Context, credentials *models.APICredentials) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	// The ID, API key and creation time never change, so only the mutable fields are set
+	update := bson.M{"$set": bson.M{
+		"secret_key":        credentials.SecretKey,
+		"is_active":         credentials.IsActive,
+		"is_testnet":        credentials.IsTestnet,
+		"validation_status": credentials.ValidationStatus,
+		"validated_at":      credentials.ValidatedAt,
+		"updated_at":        credentials.UpdatedAt,
+	}}
+	result, err := r.coll.UpdateOne(ctx, bson.M{"_id": credentials.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoCredentialsRepo) SetEd25519Key(ctx context.Context, id primitive.ObjectID, seed, publicKey string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	update := bson.M{"$set": bson.M{"ed25519_seed": seed, "ed25519_public_key": publicKey, "updated_at": time.Now()}}
+	result, err := r.coll.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoCredentialsRepo) Activate(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: "is_active", Value: bson.D{{Key: "$eq", Value: bson.A{"$_id", id}}}},
+		}}},
+	}
+	_, err := r.coll.UpdateMany(ctx, bson.M{}, pipeline)
+	return err
+}
+
+func (r *mongoCredentialsRepo) DeleteInactive(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	result, err := r.coll.DeleteOne(ctx, bson.M{"_id": id, "is_active": false})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+type mongoAuditRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoAuditRepo) InsertMany(ctx context.Context, entries []*models.AuditEntry) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if len(entries) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		docs[i] = entry
+	}
+	_, err := r.coll.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	return err
+}
+
+func (r *mongoAuditRepo) List(ctx context.Context, query *AuditQuery) ([]*models.AuditEntry, int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{}
+	if query.Since != nil {
+		filter["timestamp"] = bson.M{"$gte": *query.Since}
+	}
+	if query.Action != "" {
+		filter["action"] = query.Action
+	}
+	if query.Symbol != "" {
+		filter["symbol"] = query.Symbol
+	}
+
+	total, err := r.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(query.PageLimit())
+	if query.Offset > 0 {
+		opts.SetSkip(query.Offset)
+	}
+
+	cursor, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*models.AuditEntry
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode audit entries: %w", err)
+	}
+	return entries, total, nil
+}
+
+type mongoTokenRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoTokenRepo) Insert(ctx context.Context, token *models.APIToken) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := r.coll.InsertOne(ctx, token)
+	return mapError(err)
+}
+
+func (r *mongoTokenRepo) FindByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	token := &models.APIToken{}
+	if err := r.coll.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(token); err != nil {
+		return nil, mapError(err)
+	}
+	return token, nil
+}
+
+func (r *mongoTokenRepo) List(ctx context.Context) ([]*models.APIToken, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	cursor, err := r.coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API tokens: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var tokens []*models.APIToken
+	if err = cursor.All(ctx, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode API tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+func (r *mongoTokenRepo) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	result, err := r.coll.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+type mongoRiskLimitRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoRiskLimitRepo) List(ctx context.Context) ([]*models.RiskLimit, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	cursor, err := r.coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "symbol", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query risk limits: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var limits []*models.RiskLimit
+	if err = cursor.All(ctx, &limits); err != nil {
+		return nil, fmt.Errorf("failed to decode risk limits: %w", err)
+	}
+	return limits, nil
+}
+
+func (r *mongoRiskLimitRepo) FindBySymbol(ctx context.Context, symbol string) (*models.RiskLimit, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	limit := &models.RiskLimit{}
+	if err := r.coll.FindOne(ctx, bson.M{"symbol": symbol}).Decode(limit); err != nil {
+		return nil, mapError(err)
+	}
+	return limit, nil
+}
+
+func (r *mongoRiskLimitRepo) Upsert(ctx context.Context, limit *models.RiskLimit) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	update := bson.M{
+		"$set": bson.M{
+			"max_notional":          limit.MaxNotional,
+			"max_quantity":          limit.MaxQuantity,
+			"max_orders_per_minute": limit.MaxOrdersPerMinute,
+			"max_orders_per_10s":    limit.MaxOrdersPer10s,
+			"updated_at":            limit.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{"created_at": limit.CreatedAt},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return mapError(r.coll.FindOneAndUpdate(ctx, bson.M{"symbol": limit.Symbol}, update, opts).Decode(limit))
+}
+
+func (r *mongoRiskLimitRepo) Delete(ctx context.Context, symbol string) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	result, err := r.coll.DeleteOne(ctx, bson.M{"symbol": symbol})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+type mongoSymbolSettingsRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoSymbolSettingsRepo) List(ctx context.Context) ([]*models.SymbolSettings, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	cursor, err := r.coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "symbol", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query symbol settings: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var settings []*models.SymbolSettings
+	if err = cursor.All(ctx, &settings); err != nil {
+		return nil, fmt.Errorf("failed to decode symbol settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (r *mongoSymbolSettingsRepo) FindBySymbol(ctx context.Context, symbol string) (*models.SymbolSettings, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	settings := &models.SymbolSettings{}
+	if err := r.coll.FindOne(ctx, bson.M{"symbol": symbol}).Decode(settings); err != nil {
+		return nil, mapError(err)
+	}
+	return settings, nil
+}
+
+func (r *mongoSymbolSettingsRepo) Upsert(ctx context.Context, settings *models.SymbolSettings) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	update := bson.M{
+		"$set": bson.M{
+			"leverage":      settings.Leverage,
+			"working_type":  settings.WorkingType,
+			"position_side": settings.PositionSide,
+			"time_in_force": settings.TimeInForce,
+			"margin_type":   settings.MarginType,
+			"updated_at":    settings.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{"created_at": settings.CreatedAt},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return mapError(r.coll.FindOneAndUpdate(ctx, bson.M{"symbol": settings.Symbol}, update, opts).Decode(settings))
+}
+
+func (r *mongoSymbolSettingsRepo) Update(ctx context.Context, symbol string, update *SymbolSettingsUpdate) (*models.SymbolSettings, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	settings := &models.SymbolSettings{}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err := r.coll.FindOneAndUpdate(ctx, bson.M{"symbol": symbol}, bson.M{"$set": update.set()}, opts).Decode(settings); err != nil {
+		return nil, mapError(err)
+	}
+	return settings, nil
+}
+
+func (r *mongoSymbolSettingsRepo) Delete(ctx context.Context, symbol string) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	result, err := r.coll.DeleteOne(ctx, bson.M{"symbol": symbol})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+type mongoTradingPolicyRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoTradingPolicyRepo) Get(ctx context.Context) (*models.TradingPolicy, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	policy := &models.TradingPolicy{}
+	if err := r.coll.FindOne(ctx, bson.M{"_id": models.TradingPolicyID}).Decode(policy); err != nil {
+		return nil, mapError(err)
+	}
+	return policy, nil
+}
+
+func (r *mongoTradingPolicyRepo) Save(ctx context.Context, policy *models.TradingPolicy) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	policy.ID = models.TradingPolicyID
+	_, err := r.coll.ReplaceOne(ctx, bson.M{"_id": policy.ID}, policy, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *mongoTradingPolicyRepo) AddSymbols(ctx context.Context, symbols []string, updatedBy string, updatedAt time.Time) (*models.TradingPolicy, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	update := bson.M{
+		"$addToSet":    bson.M{"symbols": bson.M{"$each": symbols}},
+		"$set":         bson.M{"updated_by": updatedBy, "updated_at": updatedAt},
+		"$setOnInsert": bson.M{"mode": models.SymbolPolicyOff},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	policy := &models.TradingPolicy{}
+	if err := r.coll.FindOneAndUpdate(ctx, bson.M{"_id": models.TradingPolicyID}, update, opts).Decode(policy); err != nil {
+		return nil, mapError(err)
+	}
+	return policy, nil
+}
+
+func (r *mongoTradingPolicyRepo) RemoveSymbol(ctx context.Context, symbol, updatedBy string, updatedAt time.Time) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	result, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": models.TradingPolicyID, "symbols": symbol},
+		bson.M{"$pull": bson.M{"symbols": symbol}, "$set": bson.M{"updated_by": updatedBy, "updated_at": updatedAt}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+func (r *mongoTradingPolicyRepo) GetPause(ctx context.Context) (*models.TradingPause, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	pause := &models.TradingPause{}
+	if err := r.coll.FindOne(ctx, bson.M{"_id": models.TradingPauseID}).Decode(pause); err != nil {
+		return nil, mapError(err)
+	}
+	return pause, nil
+}
+
+func (r *mongoTradingPolicyRepo) SavePause(ctx context.Context, pause *models.TradingPause) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	pause.ID = models.TradingPauseID
+	_, err := r.coll.ReplaceOne(ctx, bson.M{"_id": pause.ID}, pause, options.Replace().SetUpsert(true))
+	return err
+}
+
+type mongoOrderTemplateRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoOrderTemplateRepo) List(ctx context.Context) ([]*models.NamedOrderTemplate, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	cursor, err := r.coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query order templates: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var templates []*models.NamedOrderTemplate
+	if err = cursor.All(ctx, &templates); err != nil {
+		return nil, fmt.Errorf("failed to decode order templates: %w", err)
+	}
+	return templates, nil
+}
+
+func (r *mongoOrderTemplateRepo) FindByName(ctx context.Context, name string) (*models.NamedOrderTemplate, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	template := &models.NamedOrderTemplate{}
+	if err := r.coll.FindOne(ctx, bson.M{"name": name}).Decode(template); err != nil {
+		return nil, mapError(err)
+	}
+	return template, nil
+}
+
+func (r *mongoOrderTemplateRepo) Insert(ctx context.Context, template *models.NamedOrderTemplate) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	_, err := r.coll.InsertOne(ctx, template)
+	return mapError(err)
+}
+
+func (r *mongoOrderTemplateRepo) Update(ctx context.Context, template *models.NamedOrderTemplate) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	update := bson.M{"$set": bson.M{"order": template.Order, "updated_at": template.UpdatedAt}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	return mapError(r.coll.FindOneAndUpdate(ctx, bson.M{"name": template.Name}, update, opts).Decode(template))
+}
+
+func (r *mongoOrderTemplateRepo) Delete(ctx context.Context, name string) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	result, err := r.coll.DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+type mongoWatchlistRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoWatchlistRepo) List(ctx context.Context) ([]*models.WatchlistEntry, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	cursor, err := r.coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "symbol", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watchlist: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*models.WatchlistEntry
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode watchlist: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *mongoWatchlistRepo) Add(ctx context.Context, entry *models.WatchlistEntry) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	update := bson.M{"$setOnInsert": bson.M{"symbol": entry.Symbol, "added_at": entry.AddedAt}}
+	result, err := r.coll.UpdateOne(ctx, bson.M{"symbol": entry.Symbol}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return false, mapError(err)
+	}
+	return result.UpsertedCount > 0, nil
+}
+
+func (r *mongoWatchlistRepo) Remove(ctx context.Context, symbol string) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	result, err := r.coll.DeleteOne(ctx, bson.M{"symbol": symbol})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+type mongoReconciliationRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoReconciliationRepo) Insert(ctx context.Context, report *models.ReconciliationReport) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if report.ID.IsZero() {
+		report.ID = primitive.NewObjectID()
+	}
+	_, err := r.coll.InsertOne(ctx, report)
+	return mapError(err)
+}
+
+func (r *mongoReconciliationRepo) List(ctx context.Context, limit int64) ([]*models.ReconciliationReport, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}}).SetLimit(limit)
+	cursor, err := r.coll.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reconciliation reports: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var reports []*models.ReconciliationReport
+	if err = cursor.All(ctx, &reports); err != nil {
+		return nil, fmt.Errorf("failed to decode reconciliation reports: %w", err)
+	}
+	return reports, nil
+}
+
+type mongoTransactor struct {
+	client  *mongo.Client
+	enabled bool
+}
+
+func (t *mongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !t.enabled || mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	session, err := t.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start MongoDB session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	// Transactions must read from the primary whatever MONGODB_READ_PREFERENCE says
+	opts := options.Transaction().SetReadPreference(readpref.Primary())
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	}, opts)
+	return err
+}
+
+func (t *mongoTransactor) Transactional() bool {
+	return t.enabled
+}
+
+type mongoJobRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoJobRepo) RecordRun(ctx context.Context, run *models.JobRun) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	set := bson.M{
+		"trigger":     run.Trigger,
+		"started_at":  run.StartedAt,
+		"finished_at": run.FinishedAt,
+		"duration_ms": run.DurationMs,
+		"error":       run.Error,
+	}
+	inc := bson.M{"runs": 1}
+	if run.Error != "" {
+		inc["failures"] = 1
+	} else {
+		set["last_succeeded_at"] = run.FinishedAt
+	}
+	update := bson.M{"$set": set, "$inc": inc}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return mapError(r.coll.FindOneAndUpdate(ctx, bson.M{"name": run.Name}, update, opts).Decode(run))
+}
+
+func (r *mongoJobRepo) List(ctx context.Context) ([]*models.JobRun, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	cursor, err := r.coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job runs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var runs []*models.JobRun
+	if err = cursor.All(ctx, &runs); err != nil {
+		return nil, fmt.Errorf("failed to decode job runs: %w", err)
+	}
+	return runs, nil
+}
+
+type mongoPaperRepo struct {
+	orders    *mongo.Collection
+	positions *mongo.Collection
+	accounts  *mongo.Collection
+}
+
+func (r *mongoPaperRepo) SaveOrder(ctx context.Context, order *models.PaperOrder) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if order.ID.IsZero() {
+		order.ID = primitive.NewObjectID()
+	}
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.orders.ReplaceOne(ctx, bson.M{"order_id": order.OrderID}, order, opts)
+	return mapError(err)
+}
+
+func (r *mongoPaperRepo) FindOrder(ctx context.Context, symbol string, orderID int64, clientOrderID string) (*models.PaperOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{"symbol": symbol}
+	if orderID != 0 {
+		filter["order_id"] = orderID
+	} else {
+		filter["client_order_id"] = clientOrderID
+	}
+	order := &models.PaperOrder{}
+	if err := r.orders.FindOne(ctx, filter).Decode(order); err != nil {
+		return nil, mapError(err)
+	}
+	return order, nil
+}
+
+func (r *mongoPaperRepo) ListOpenOrders(ctx context.Context) ([]*models.PaperOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	return r.findOrders(ctx, bson.M{"status": "NEW"})
+}
+
+func (r *mongoPaperRepo) ListFilledSince(ctx context.Context, since time.Time) ([]*models.PaperOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	return r.findOrders(ctx, bson.M{"filled_at": bson.M{"$gte": since}})
+}
+
+func (r *mongoPaperRepo) findOrders(ctx context.Context, filter bson.M) ([]*models.PaperOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	cursor, err := r.orders.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "order_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query paper orders: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var orders []*models.PaperOrder
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, fmt.Errorf("failed to decode paper orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (r *mongoPaperRepo) MaxOrderID(ctx context.Context) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	order := &models.PaperOrder{}
+	opts := options.FindOne().SetSort(bson.D{{Key: "order_id", Value: -1}})
+	err := mapError(r.orders.FindOne(ctx, bson.M{}, opts).Decode(order))
+	if err == ErrNotFound {
+		return 0, nil
+	}
+	return order.OrderID, err
+}
+
+func (r *mongoPaperRepo) ListPositions(ctx context.Context) ([]*models.PaperPosition, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	cursor, err := r.positions.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query paper positions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var positions []*models.PaperPosition
+	if err = cursor.All(ctx, &positions); err != nil {
+		return nil, fmt.Errorf("failed to decode paper positions: %w", err)
+	}
+	return positions, nil
+}
+
+func (r *mongoPaperRepo) SavePosition(ctx context.Context, position *models.PaperPosition) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{"symbol": position.Symbol, "position_side": position.PositionSide}
+	_, err := r.positions.ReplaceOne(ctx, filter, position, options.Replace().SetUpsert(true))
+	return mapError(err)
+}
+
+func (r *mongoPaperRepo) GetAccount(ctx context.Context) (*models.PaperAccount, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	account := &models.PaperAccount{}
+	if err := r.accounts.FindOne(ctx, bson.M{"_id": models.PaperAccountID}).Decode(account); err != nil {
+		return nil, mapError(err)
+	}
+	return account, nil
+}
+
+func (r *mongoPaperRepo) SaveAccount(ctx context.Context, account *models.PaperAccount) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	account.ID = models.PaperAccountID
+	_, err := r.accounts.ReplaceOne(ctx, bson.M{"_id": account.ID}, account, options.Replace().SetUpsert(true))
+	return mapError(err)
+}
+
+type mongoIncomeRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoIncomeRepo) Upsert(ctx context.Context, records []*models.IncomeRecord) ([]*models.IncomeRecord, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if len(records) == 0 {
+		return nil, nil
+	}
+	writes := make([]mongo.WriteModel, len(records))
+	for i, rec := range records {
+		filter := bson.M{"tran_id": rec.TranID, "income_type": rec.IncomeType, "symbol": rec.Symbol}
+		writes[i] = mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(bson.M{"$setOnInsert": rec}).SetUpsert(true)
+	}
+	result, err := r.coll.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store income history: %w", err)
+	}
+	inserted := make([]*models.IncomeRecord, 0, len(result.UpsertedIDs))
+	for i, rec := range records {
+		if _, ok := result.UpsertedIDs[int64(i)]; ok {
+			inserted = append(inserted, rec)
+		}
+	}
+	return inserted, nil
+}
+
+func (r *mongoIncomeRepo) LatestTime(ctx context.Context) (time.Time, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	record := &models.IncomeRecord{}
+	opts := options.FindOne().SetSort(bson.D{{Key: "time", Value: -1}})
+	if err := r.coll.FindOne(ctx, bson.M{}, opts).Decode(record); err != nil {
+		return time.Time{}, mapError(err)
+	}
+	return record.Time, nil
+}
+
+func (r *mongoIncomeRepo) AggregatePnL(ctx context.Context, query *PnLQuery) ([]*models.PnLBucket, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	isType := func(incomeType string) bson.M {
+		return bson.M{"$eq": bson.A{"$income_type", incomeType}}
+	}
+	sumIf := func(cond, value interface{}) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{cond, value, 0}}}
+	}
+
+	groupID := bson.M{}
+	if query.BySymbol {
+		groupID["symbol"] = "$symbol"
+	}
+	if query.ByDay {
+		groupID["day"] = bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$time", "timezone": "UTC"}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"time":        bson.M{"$gte": query.Start, "$lt": query.End},
+			"income_type": bson.M{"$in": bson.A{models.IncomeTypeRealizedPnl, models.IncomeTypeCommission, models.IncomeTypeFundingFee}},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          groupID,
+			"realized_pnl": sumIf(isType(models.IncomeTypeRealizedPnl), "$income"),
+			"commission":   sumIf(isType(models.IncomeTypeCommission), "$income"),
+			"funding_fees": sumIf(isType(models.IncomeTypeFundingFee), "$income"),
+			"trades":       sumIf(isType(models.IncomeTypeRealizedPnl), 1),
+			"wins":         sumIf(bson.M{"$and": bson.A{isType(models.IncomeTypeRealizedPnl), bson.M{"$gt": bson.A{"$income", 0}}}}, 1),
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":          0,
+			"symbol":       "$_id.symbol",
+			"day":          "$_id.day",
+			"realized_pnl": 1,
+			"commission":   1,
+			"funding_fees": 1,
+			"trades":       1,
+			"wins":         1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "day", Value: 1}, {Key: "symbol", Value: 1}}}},
+	}
+
+	cursor, err := r.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate income: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var buckets []*models.PnLBucket
+	if err = cursor.All(ctx, &buckets); err != nil {
+		return nil, fmt.Errorf("failed to decode PnL buckets: %w", err)
+	}
+	return buckets, nil
+}
+
+type mongoFillRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoFillRepo) Insert(ctx context.Context, fill *models.Fill) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if fill.ID.IsZero() {
+		fill.ID = primitive.NewObjectID()
+	}
+	filter := bson.M{"product": fill.Product, "symbol": fill.Symbol, "binance_order_id": fill.BinanceOrderID, "trade_id": fill.TradeID}
+	res, err := r.coll.UpdateOne(ctx, filter, bson.M{"$setOnInsert": fill}, options.Update().SetUpsert(true))
+	if err != nil {
+		return false, mapError(err)
+	}
+	return res.UpsertedCount > 0, nil
+}
+
+func (r *mongoFillRepo) List(ctx context.Context, q *FillQuery) ([]*models.Fill, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	filter := bson.M{}
+	if len(q.Products) > 0 {
+		filter["product"] = bson.M{"$in": q.Products}
+	}
+	if q.Symbol != "" {
+		filter["symbol"] = q.Symbol
+	}
+	if q.AccountID != "" {
+		filter["account_id"] = q.AccountID
+	}
+	if q.BeforeID != "" {
+		id, err := primitive.ObjectIDFromHex(q.BeforeID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		var anchor struct {
+			FillTime time.Time `bson:"fill_time"`
+		}
+		if err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&anchor); err != nil {
+			return nil, cursorLookupError(err, q.BeforeID)
+		}
+		filter = bson.M{"$and": bson.A{filter, bson.M{"$or": bson.A{
+			bson.M{"fill_time": bson.M{"$lt": anchor.FillTime}},
+			bson.M{"fill_time": anchor.FillTime, "_id": bson.M{"$lt": id}},
+		}}}}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "fill_time", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(q.PageLimit() + 1)
+	cursor, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer cursor.Close(ctx)
+	fills := []*models.Fill{}
+	if err := cursor.All(ctx, &fills); err != nil {
+		return nil, mapError(err)
+	}
+	return fills, nil
+}
+
+func (r *mongoFillRepo) AggregatePnLBySource(ctx context.Context, query *PnLQuery) ([]*models.PnLBucket, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	sumIf := func(cond, value interface{}) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{cond, value, 0}}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"product":   models.FillProductFutures,
+			"fill_time": bson.M{"$gte": query.Start, "$lt": query.End},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          "$source",
+			"realized_pnl": bson.M{"$sum": "$realized_pnl"},
+			// Fills record commission as a positive amount; income history reports it negative
+			"commission": bson.M{"$sum": bson.M{"$multiply": bson.A{"$commission", -1}}},
+			"trades":     sumIf(bson.M{"$ne": bson.A{"$realized_pnl", 0}}, 1),
+			"wins":       sumIf(bson.M{"$gt": bson.A{"$realized_pnl", 0}}, 1),
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":          0,
+			"source":       "$_id",
+			"realized_pnl": 1,
+			"commission":   1,
+			"trades":       1,
+			"wins":         1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "source", Value: 1}}}},
+	}
+
+	cursor, err := r.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate fills: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var buckets []*models.PnLBucket
+	if err = cursor.All(ctx, &buckets); err != nil {
+		return nil, fmt.Errorf("failed to decode PnL buckets: %w", err)
+	}
+	return buckets, nil
+}
+
+type mongoEquityRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoEquityRepo) Insert(ctx context.Context, snapshot *models.EquitySnapshot) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if snapshot.ID.IsZero() {
+		snapshot.ID = primitive.NewObjectID()
+	}
+	_, err := r.coll.InsertOne(ctx, snapshot)
+	return mapError(err)
+}
+
+func (r *mongoEquityRepo) List(ctx context.Context, start, end time.Time) ([]*models.EquitySnapshot, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{"time": bson.M{"$gte": start, "$lt": end}}
+	cursor, err := r.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "time", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query equity snapshots: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var snapshots []*models.EquitySnapshot
+	if err = cursor.All(ctx, &snapshots); err != nil {
+		return nil, fmt.Errorf("failed to decode equity snapshots: %w", err)
+	}
+	return snapshots, nil
+}
+
+type mongoKlineRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoKlineRepo) Upsert(ctx context.Context, klines []*models.Kline) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if len(klines) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, len(klines))
+	for i, k := range klines {
+		filter := bson.M{"symbol": k.Symbol, "interval": k.Interval, "open_time": k.OpenTime}
+		writes[i] = mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(k).SetUpsert(true)
+	}
+	_, err := r.coll.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return fmt.Errorf("failed to store klines: %w", err)
+	}
+	return nil
+}
+
+func (r *mongoKlineRepo) List(ctx context.Context, symbol, interval string, start, end time.Time) ([]*models.Kline, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{"symbol": symbol, "interval": interval, "open_time": bson.M{"$gte": start, "$lt": end}}
+	cursor, err := r.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "open_time", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query klines: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var klines []*models.Kline
+	if err = cursor.All(ctx, &klines); err != nil {
+		return nil, fmt.Errorf("failed to decode klines: %w", err)
+	}
+	return klines, nil
+}
+
+type mongoJournalRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoJournalRepo) Insert(ctx context.Context, entry *models.JournalEntry) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	_, err := r.coll.InsertOne(ctx, entry)
+	return mapError(err)
+}
+
+func (r *mongoJournalRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.JournalEntry, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	var entry models.JournalEntry
+	if err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&entry); err != nil {
+		return nil, mapError(err)
+	}
+	return &entry, nil
+}
+
+func (r *mongoJournalRepo) List(ctx context.Context, query *JournalQuery) ([]*models.JournalEntry, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{}
+	if query.OrderID != nil {
+		filter["order_id"] = *query.OrderID
+	}
+	if query.Symbol != "" {
+		filter["symbol"] = query.Symbol
+	}
+	if len(query.Tags) > 0 {
+		filter["tags"] = bson.M{"$all": query.Tags}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	if query.Limit > 0 {
+		opts.SetLimit(query.Limit)
+	}
+	cursor, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query journal entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	entries := []*models.JournalEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode journal entries: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *mongoJournalRepo) Latest(ctx context.Context, orderIDs []primitive.ObjectID) (map[primitive.ObjectID]*models.JournalEntry, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	latest := make(map[primitive.ObjectID]*models.JournalEntry)
+	if len(orderIDs) == 0 {
+		return latest, nil
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"order_id": bson.M{"$in": orderIDs}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$order_id", "entry": bson.M{"$first": "$$ROOT"}}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$entry"}}},
+	}
+	cursor, err := r.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query journal entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*models.JournalEntry
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode journal entries: %w", err)
+	}
+	for _, entry := range entries {
+		latest[entry.OrderID] = entry
+	}
+	return latest, nil
+}
+
+func (r *mongoJournalRepo) Update(ctx context.Context, entry *models.JournalEntry) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	set := bson.M{"note": entry.Note, "tags": entry.Tags, "updated_at": entry.UpdatedAt}
+	result, err := r.coll.UpdateOne(ctx, bson.M{"_id": entry.ID}, bson.M{"$set": set})
+	if err != nil {
+		return mapError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoJournalRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	result, err := r.coll.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return mapError(err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoJournalRepo) Tags(ctx context.Context, prefix string) ([]string, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{}
+	if prefix != "" {
+		filter["tags"] = bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}
+	}
+	values, err := r.coll.Distinct(ctx, "tags", filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journal tags: %w", err)
+	}
+	// Entries matching the prefix carry other tags too
+	tags := []string{}
+	for _, v := range values {
+		if tag, ok := v.(string); ok && strings.HasPrefix(tag, prefix) {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+type mongoWebhookRepo struct {
+	webhooks    *mongo.Collection
+	deadLetters *mongo.Collection
+}
+
+func (r *mongoWebhookRepo) Insert(ctx context.Context, webhook *models.Webhook) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if webhook.ID.IsZero() {
+		webhook.ID = primitive.NewObjectID()
+	}
+	_, err := r.webhooks.InsertOne(ctx, webhook)
+	return mapError(err)
+}
+
+func (r *mongoWebhookRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	webhook := &models.Webhook{}
+	if err := r.webhooks.FindOne(ctx, bson.M{"_id": id}).Decode(webhook); err != nil {
+		return nil, mapError(err)
+	}
+	return webhook, nil
+}
+
+func (r *mongoWebhookRepo) List(ctx context.Context) ([]*models.Webhook, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	cursor, err := r.webhooks.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var webhooks []*models.Webhook
+	if err = cursor.All(ctx, &webhooks); err != nil {
+		return nil, fmt.Errorf("failed to decode webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func (r *mongoWebhookRepo) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	result, err := r.webhooks.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+func (r *mongoWebhookRepo) InsertDeadLetter(ctx context.Context, letter *models.WebhookDeadLetter) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if letter.ID.IsZero() {
+		letter.ID = primitive.NewObjectID()
+	}
+	_, err := r.deadLetters.InsertOne(ctx, letter)
+	return mapError(err)
+}
+
+func (r *mongoWebhookRepo) ListDeadLetters(ctx context.Context, limit int64) ([]*models.WebhookDeadLetter, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit)
+	cursor, err := r.deadLetters.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook dead letters: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var letters []*models.WebhookDeadLetter
+	if err = cursor.All(ctx, &letters); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook dead letters: %w", err)
+	}
+	return letters, nil
+}
+
+type mongoConditionalOrderRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoConditionalOrderRepo) Insert(ctx context.Context, order *models.ConditionalOrder) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if order.ID.IsZero() {
+		order.ID = primitive.NewObjectID()
+	}
+	_, err := r.coll.InsertOne(ctx, order)
+	return mapError(err)
+}
+
+func (r *mongoConditionalOrderRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ConditionalOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	order := &models.ConditionalOrder{}
+	if err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(order); err != nil {
+		return nil, mapError(err)
+	}
+	return order, nil
+}
+
+func (r *mongoConditionalOrderRepo) List(ctx context.Context, status models.ConditionalStatus) ([]*models.ConditionalOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	cursor, err := r.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conditional orders: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var orders []*models.ConditionalOrder
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, fmt.Errorf("failed to decode conditional orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (r *mongoConditionalOrderRepo) Transition(ctx context.Context, id primitive.ObjectID, from models.ConditionalStatus, update *ConditionalOrderUpdate) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	result, err := r.coll.UpdateOne(ctx, bson.M{"_id": id, "status": from}, bson.M{"$set": update.set()})
+	if err != nil {
+		return false, fmt.Errorf("failed to update conditional order: %w", err)
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+func (r *mongoConditionalOrderRepo) TransitionGroup(ctx context.Context, group string, except primitive.ObjectID, from models.ConditionalStatus, update *ConditionalOrderUpdate) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{"group": group, "status": from, "_id": bson.M{"$ne": except}}
+	result, err := r.coll.UpdateMany(ctx, filter, bson.M{"$set": update.set()})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update conditional order group: %w", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+type mongoScheduledOrderRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoScheduledOrderRepo) Insert(ctx context.Context, order *models.ScheduledOrder) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if order.ID.IsZero() {
+		order.ID = primitive.NewObjectID()
+	}
+	_, err := r.coll.InsertOne(ctx, order)
+	return mapError(err)
+}
+
+func (r *mongoScheduledOrderRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ScheduledOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	order := &models.ScheduledOrder{}
+	if err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(order); err != nil {
+		return nil, mapError(err)
+	}
+	return order, nil
+}
+
+func (r *mongoScheduledOrderRepo) List(ctx context.Context, status models.ScheduleStatus) ([]*models.ScheduledOrder, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	cursor, err := r.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "execute_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scheduled orders: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var orders []*models.ScheduledOrder
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, fmt.Errorf("failed to decode scheduled orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (r *mongoScheduledOrderRepo) Transition(ctx context.Context, id primitive.ObjectID, from models.ScheduleStatus, update *ScheduledOrderUpdate) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	result, err := r.coll.UpdateOne(ctx, bson.M{"_id": id, "status": from}, bson.M{"$set": update.set()})
+	if err != nil {
+		return false, fmt.Errorf("failed to update scheduled order: %w", err)
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+type mongoDCAPlanRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoDCAPlanRepo) Insert(ctx context.Context, plan *models.DCAPlan) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if plan.ID.IsZero() {
+		plan.ID = primitive.NewObjectID()
+	}
+	_, err := r.coll.InsertOne(ctx, plan)
+	return mapError(err)
+}
+
+func (r *mongoDCAPlanRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.DCAPlan, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	plan := &models.DCAPlan{}
+	if err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(plan); err != nil {
+		return nil, mapError(err)
+	}
+	return plan, nil
+}
+
+func (r *mongoDCAPlanRepo) List(ctx context.Context, status models.DCAStatus) ([]*models.DCAPlan, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	cursor, err := r.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query DCA plans: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var plans []*models.DCAPlan
+	if err = cursor.All(ctx, &plans); err != nil {
+		return nil, fmt.Errorf("failed to decode DCA plans: %w", err)
+	}
+	return plans, nil
+}
+
+func (r *mongoDCAPlanRepo) Update(ctx context.Context, plan *models.DCAPlan) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	result, err := r.coll.ReplaceOne(ctx, bson.M{"_id": plan.ID}, plan)
+	if err != nil {
+		return fmt.Errorf("failed to update DCA plan: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type mongoGridStrategyRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoGridStrategyRepo) Insert(ctx context.Context, strategy *models.GridStrategy) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if strategy.ID.IsZero() {
+		strategy.ID = primitive.NewObjectID()
+	}
+	_, err := r.coll.InsertOne(ctx, strategy)
+	return mapError(err)
+}
+
+func (r *mongoGridStrategyRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.GridStrategy, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	strategy := &models.GridStrategy{}
+	if err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(strategy); err != nil {
+		return nil, mapError(err)
+	}
+	return strategy, nil
+}
+
+func (r *mongoGridStrategyRepo) List(ctx context.Context, status models.GridStatus) ([]*models.GridStrategy, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	cursor, err := r.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query grid strategies: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var strategies []*models.GridStrategy
+	if err = cursor.All(ctx, &strategies); err != nil {
+		return nil, fmt.Errorf("failed to decode grid strategies: %w", err)
+	}
+	return strategies, nil
+}
+
+func (r *mongoGridStrategyRepo) Update(ctx context.Context, strategy *models.GridStrategy) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	result, err := r.coll.ReplaceOne(ctx, bson.M{"_id": strategy.ID}, strategy)
+	if err != nil {
+		return fmt.Errorf("failed to update grid strategy: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type mongoCalendarSpreadRepo struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoCalendarSpreadRepo) Insert(ctx context.Context, spread *models.CalendarSpread) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	if spread.ID.IsZero() {
+		spread.ID = primitive.NewObjectID()
+	}
+	_, err := r.coll.InsertOne(ctx, spread)
+	return mapError(err)
+}
+
+func (r *mongoCalendarSpreadRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.CalendarSpread, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	spread := &models.CalendarSpread{}
+	if err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(spread); err != nil {
+		return nil, mapError(err)
+	}
+	return spread, nil
+}
+
+func (r *mongoCalendarSpreadRepo) Update(ctx context.Context, spread *models.CalendarSpread) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	result, err := r.coll.ReplaceOne(ctx, bson.M{"_id": spread.ID}, spread)
+	if err != nil {
+		return fmt.Errorf("failed to update calendar spread: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"futures-options/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestBulkDuplicates(t *testing.T) {
+	writeErr := func(index, code int) mongo.BulkWriteError {
+		return mongo.BulkWriteError{WriteError: mongo.WriteError{Index: index, Code: code, Message: "E11000 duplicate key error"}}
+	}
+	other := errors.New("connection reset")
+
+	tests := []struct {
+		name           string
+		err            error
+		wantDuplicates []int
+		wantErr        bool
+	}{
+		{name: "no error"},
+		{
+			name:           "duplicate keys only",
+			err:            mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{writeErr(1, 11000), writeErr(3, 11001)}},
+			wantDuplicates: []int{1, 3},
+		},
+		{
+			name:           "duplicate and validation errors",
+			err:            mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{writeErr(0, 11000), writeErr(2, 121)}},
+			wantDuplicates: []int{0},
+			wantErr:        true,
+		},
+		{
+			name:           "write concern error",
+			err:            mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{writeErr(4, 12582)}, WriteConcernError: &mongo.WriteConcernError{Code: 64}},
+			wantDuplicates: []int{4},
+			wantErr:        true,
+		},
+		{name: "not a bulk error", err: other, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			duplicates, err := bulkDuplicates(tt.err)
+			if !reflect.DeepEqual(duplicates, tt.wantDuplicates) {
+				t.Errorf("duplicates = %v, want %v", duplicates, tt.wantDuplicates)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			// Only the non-duplicate write errors are left in the returned exception
+			var bulkErr mongo.BulkWriteException
+			if errors.As(err, &bulkErr) {
+				for _, we := range bulkErr.WriteErrors {
+					if we.Code == 11000 || we.Code == 11001 || we.Code == 12582 {
+						t.Errorf("duplicate key error at %d left in the exception", we.Index)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryInsertManyReportsDuplicates(t *testing.T) {
+	repos := NewMemoryRepositories()
+	ctx := context.Background()
+	if err := repos.FuturesOrders.Insert(ctx, &models.FuturesOrder{ID: primitive.NewObjectID(), BinanceOrderID: 2}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	batch := []*models.FuturesOrder{
+		{ID: primitive.NewObjectID(), BinanceOrderID: 1},
+		{ID: primitive.NewObjectID(), BinanceOrderID: 2},
+		{ID: primitive.NewObjectID(), BinanceOrderID: 3},
+		{ID: primitive.NewObjectID(), BinanceOrderID: 3},
+	}
+	duplicates, err := repos.FuturesOrders.InsertMany(ctx, batch)
+	if err != nil {
+		t.Fatalf("InsertMany: %v", err)
+	}
+	if !reflect.DeepEqual(duplicates, []int{1, 3}) {
+		t.Errorf("duplicates = %v, want [1 3]", duplicates)
+	}
+}
+
+func TestPositionKeySeparatesHedgeLegs(t *testing.T) {
+	long := &models.Position{Symbol: "BTCUSDT", Type: "FUTURES", Side: "LONG", AccountID: "acct"}
+	short := &models.Position{Symbol: "BTCUSDT", Type: "FUTURES", Side: "SHORT", AccountID: "acct"}
+	if reflect.DeepEqual(positionKey(long), positionKey(short)) {
+		t.Errorf("hedge legs share the key %v", positionKey(long))
+	}
+	other := &models.Position{Symbol: "BTCUSDT", Type: "FUTURES", Side: "LONG", AccountID: "other"}
+	if reflect.DeepEqual(positionKey(long), positionKey(other)) {
+		t.Errorf("accounts share the key %v", positionKey(long))
+	}
+}
+
+func TestMemoryListRejectsInvalidCursor(t *testing.T) {
+	repos := NewMemoryRepositories()
+	ctx := context.Background()
+	if err := repos.FuturesOrders.Insert(ctx, &models.FuturesOrder{ID: primitive.NewObjectID(), BinanceOrderID: 1}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	for _, cursor := range []string{"not-an-id", primitive.NewObjectID().Hex()} {
+		if _, _, err := repos.FuturesOrders.List(ctx, &OrderQuery{BeforeID: cursor}); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("orders before %q: err = %v, want ErrInvalidCursor", cursor, err)
+		}
+		if _, err := repos.Fills.List(ctx, &FillQuery{BeforeID: cursor}); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("fills before %q: err = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
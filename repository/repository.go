@@ -0,0 +1,517 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"futures-options/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrNotFound is returned when no document matches
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicate is returned when an insert violates a unique index
+	ErrDuplicate = errors.New("duplicate key")
+	// ErrInvalidCursor is returned when a listing's BeforeID is not an ID or not in the listing
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+const (
+	DefaultOrderPageLimit = 100
+	MaxOrderPageLimit     = 1000
+)
+
+// OrderQuery holds the filters and paging options for order listings
+type OrderQuery struct {
+	Symbol    string
+	Status    string
+	Side      string
+	AccountID string // credential the orders were signed with; empty for every account
+	StartTime *time.Time
+	EndTime   *time.Time
+	Limit     int64
+	Offset    int64
+	BeforeID  string // ID of the last item of the previous page (next_cursor)
+	SortAsc   bool
+	// IncludeRaw returns the stored raw Binance responses, which are omitted by default
+	IncludeRaw bool
+	// IncludeJournal attaches each order's latest journal entry; applied by the service layer
+	IncludeJournal bool
+
+	// Order lookups; only futures orders carry client order and strategy IDs and a source
+	ClientOrderIDPrefix string // client order IDs starting with this, the exact ID included
+	BinanceOrderID      int64
+	StrategyID          string
+	Source              string
+	// Text matches a Binance order ID (when numeric), a client order ID prefix or a strategy ID
+	Text string
+	// PartiallyFilled matches orders that are PARTIALLY_FILLED, or that filled part of their
+	// quantity and then ended without filling, e.g. were canceled; only futures orders record fills
+	PartiallyFilled bool
+}
+
+// PageLimit returns the effective page size
+func (q *OrderQuery) PageLimit() int64 {
+	if q.Limit <= 0 {
+		return DefaultOrderPageLimit
+	}
+	if q.Limit > MaxOrderPageLimit {
+		return MaxOrderPageLimit
+	}
+	return q.Limit
+}
+
+// FuturesOrderRepo persists futures orders
+type FuturesOrderRepo interface {
+	Insert(ctx context.Context, order *models.FuturesOrder) error
+	// InsertMany inserts all orders in one write. Orders that violate a unique index are skipped
+	// and their indexes returned; other failures do not stop the remaining orders being inserted.
+	InsertMany(ctx context.Context, orders []*models.FuturesOrder) (duplicates []int, err error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.FuturesOrder, error)
+	FindByBinanceID(ctx context.Context, binanceOrderID int64) (*models.FuturesOrder, error)
+	// FindByClientOrderID returns the newest order of accountID ("" for environment keys) with the
+	// given client order ID
+	FindByClientOrderID(ctx context.Context, accountID, clientOrderID string) (*models.FuturesOrder, error)
+	// List returns up to PageLimit()+1 orders so callers can detect a next page, plus the total match count
+	List(ctx context.Context, query *OrderQuery) ([]*models.FuturesOrder, int64, error)
+	// Each streams every order matching the query's filters to fn, in its sort order; paging is
+	// ignored. It stops at the first error fn returns.
+	Each(ctx context.Context, query *OrderQuery, fn func(*models.FuturesOrder) error) error
+	// EachOpen streams the orders with a Binance ID that are NEW or PARTIALLY_FILLED and not flagged
+	// missing, without their raw responses
+	EachOpen(ctx context.Context, fn func(*models.FuturesOrder) error) error
+	// ListOpen returns up to limit of the orders EachOpen streams, in ID order, starting after
+	// afterID; the zero ID starts at the first
+	ListOpen(ctx context.Context, afterID primitive.ObjectID, limit int) ([]*models.FuturesOrder, error)
+	// UpdateByRef applies update to the order identified by Binance order ID, or client order ID
+	// when the former is 0, and returns the updated order
+	UpdateByRef(ctx context.Context, binanceOrderID int64, clientOrderID string, update *FuturesOrderUpdate) (*models.FuturesOrder, error)
+	// UpdateFill applies update, which must carry a Fill, to the order identified like UpdateByRef,
+	// unless its stored executed quantity is already above the fill's, i.e. the update is older than
+	// the last one applied. It reports whether the order was updated.
+	UpdateFill(ctx context.Context, binanceOrderID int64, clientOrderID string, update *FuturesOrderUpdate) (bool, error)
+	// SetStatus updates the status of a symbol's orders matching any of the given IDs
+	SetStatus(ctx context.Context, symbol string, orderIDs []int64, clientOrderIDs []string, status string) error
+	// ArchiveTerminal moves up to limit orders in a TerminalOrderStatuses status last updated before
+	// before to the archive, oldest first, and returns how many were moved
+	ArchiveTerminal(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+// TerminalOrderStatuses are the futures order statuses that can no longer change; only orders in
+// one of them are archived
+var TerminalOrderStatuses = []string{"FILLED", "CANCELED", "EXPIRED", "EXPIRED_IN_MATCH", "REJECTED"}
+
+// OptionsOrderRepo persists options orders
+type OptionsOrderRepo interface {
+	Insert(ctx context.Context, order *models.OptionsOrder) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.OptionsOrder, error)
+	FindByBinanceID(ctx context.Context, binanceOrderID int64) (*models.OptionsOrder, error)
+	List(ctx context.Context, query *OrderQuery) ([]*models.OptionsOrder, int64, error)
+	// Each streams every order matching the query's filters to fn; see FuturesOrderRepo.Each
+	Each(ctx context.Context, query *OrderQuery, fn func(*models.OptionsOrder) error) error
+}
+
+// SpotOrderRepo persists spot orders
+type SpotOrderRepo interface {
+	Insert(ctx context.Context, order *models.SpotOrder) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.SpotOrder, error)
+	FindByBinanceID(ctx context.Context, binanceOrderID int64) (*models.SpotOrder, error)
+	// FindByClientOrderID returns the newest order of accountID with the given client order ID
+	FindByClientOrderID(ctx context.Context, accountID, clientOrderID string) (*models.SpotOrder, error)
+	List(ctx context.Context, query *OrderQuery) ([]*models.SpotOrder, int64, error)
+	// ListOpen returns orders with a Binance ID that are NEW or PARTIALLY_FILLED and not flagged missing
+	ListOpen(ctx context.Context) ([]*models.SpotOrder, error)
+	// UpdateByRef applies update to the order identified by Binance order ID, or client order ID when the former is 0
+	UpdateByRef(ctx context.Context, binanceOrderID int64, clientOrderID string, update *SpotOrderUpdate) (*models.SpotOrder, error)
+}
+
+// TransferRepo persists wallet transfers
+type TransferRepo interface {
+	Insert(ctx context.Context, transfer *models.Transfer) error
+	// SetStatus updates the status of the transfer with Binance ID tranID, if one is stored
+	SetStatus(ctx context.Context, tranID int64, status string) error
+}
+
+// PositionRepo persists positions and the position mode setting
+type PositionRepo interface {
+	// List returns the positions of positionType and accountID; empty values match all
+	List(ctx context.Context, positionType, accountID string) ([]*models.Position, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Position, error)
+	// Each streams the positions of positionType and accountID (all when empty) to fn, stopping at its first error
+	Each(ctx context.Context, positionType, accountID string, fn func(*models.Position) error) error
+	// Upsert creates or updates the position keyed by symbol, type, side and account, so the LONG and
+	// SHORT legs of a hedge-mode position are stored separately. A new position accrues funding from
+	// its UpdatedAt; the funding of an existing one is kept.
+	Upsert(ctx context.Context, position *models.Position) error
+	// SyncMarket upserts positions and deletes the stored FUTURES positions of market and accountID
+	// whose symbol and side are not among them, unless their symbol is in keep, in a single bulk
+	// write. Positions stored without a market count as usdm; positions stored without an account
+	// belong to every account's sync, so they are replaced by the first one.
+	SyncMarket(ctx context.Context, market models.Market, accountID string, positions []*models.Position, keep []string) (upserted, cleared int64, err error)
+	// AddFunding adds amount to the funding paid by the position with the given ID
+	AddFunding(ctx context.Context, id primitive.ObjectID, amount float64) error
+	// FlagMarginCall marks the FUTURES position of accountID on symbol and side at risk after a
+	// margin call at markPrice; the next SyncMarket clears the flag
+	FlagMarginCall(ctx context.Context, symbol string, side models.PositionSide, accountID string, markPrice float64, at time.Time) error
+	// ResetFunding zeroes the funding paid by accountID's FUTURES position on symbol and side, and
+	// restarts its accrual at since, as the position closed then
+	ResetFunding(ctx context.Context, symbol string, side models.PositionSide, accountID string, since time.Time) error
+	SavePositionMode(ctx context.Context, mode *models.PositionModeConfig) error
+}
+
+// RiskEventRepo persists margin calls and symbol status changes
+type RiskEventRepo interface {
+	Insert(ctx context.Context, event *models.RiskEvent) error
+	// List returns events newest first, only those on symbol unless it is empty, up to limit when positive
+	List(ctx context.Context, symbol string, limit int64) ([]*models.RiskEvent, error)
+}
+
+// CredentialsRepo persists Binance API credentials
+type CredentialsRepo interface {
+	List(ctx context.Context, activeOnly bool) ([]*models.APICredentials, error)
+	FindActive(ctx context.Context) (*models.APICredentials, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.APICredentials, error)
+	FindByAPIKey(ctx context.Context, apiKey string) (*models.APICredentials, error)
+	Insert(ctx context.Context, credentials *models.APICredentials) error
+	// Update stores the mutable fields of credentials (matched by ID); the API key, creation
+	// time and Ed25519 key are left unchanged
+	Update(ctx context.Context, credentials *models.APICredentials) error
+	// SetEd25519Key stores the encrypted seed and public key of the Ed25519 key bound to id
+	SetEd25519Key(ctx context.Context, id primitive.ObjectID, seed, publicKey string) error
+	// Activate marks id as the only active credential in a single operation
+	Activate(ctx context.Context, id primitive.ObjectID) error
+	// DeleteInactive deletes id only if it is not active and reports whether it did
+	DeleteInactive(ctx context.Context, id primitive.ObjectID) (bool, error)
+}
+
+// AuditQuery holds the filters and paging options for audit log listings
+type AuditQuery struct {
+	Since  *time.Time
+	Action string
+	Symbol string
+	Limit  int64
+	Offset int64
+}
+
+// PageLimit returns the effective page size
+func (q *AuditQuery) PageLimit() int64 {
+	if q.Limit <= 0 {
+		return DefaultOrderPageLimit
+	}
+	if q.Limit > MaxOrderPageLimit {
+		return MaxOrderPageLimit
+	}
+	return q.Limit
+}
+
+// AuditRepo persists audit log entries
+type AuditRepo interface {
+	InsertMany(ctx context.Context, entries []*models.AuditEntry) error
+	// List returns a page of entries, newest first, plus the total match count
+	List(ctx context.Context, query *AuditQuery) ([]*models.AuditEntry, int64, error)
+}
+
+// TokenRepo persists hashed REST API tokens
+type TokenRepo interface {
+	Insert(ctx context.Context, token *models.APIToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*models.APIToken, error)
+	List(ctx context.Context) ([]*models.APIToken, error)
+	// Delete removes a token and reports whether it existed
+	Delete(ctx context.Context, id primitive.ObjectID) (bool, error)
+}
+
+// RiskLimitRepo persists per-symbol position limits
+type RiskLimitRepo interface {
+	List(ctx context.Context) ([]*models.RiskLimit, error)
+	FindBySymbol(ctx context.Context, symbol string) (*models.RiskLimit, error)
+	// Upsert creates or replaces the limit keyed by symbol
+	Upsert(ctx context.Context, limit *models.RiskLimit) error
+	// Delete removes a symbol's limit and reports whether it existed
+	Delete(ctx context.Context, symbol string) (bool, error)
+}
+
+// SymbolSettingsRepo persists per-symbol order defaults
+type SymbolSettingsRepo interface {
+	List(ctx context.Context) ([]*models.SymbolSettings, error)
+	FindBySymbol(ctx context.Context, symbol string) (*models.SymbolSettings, error)
+	// Upsert creates or replaces the settings keyed by symbol
+	Upsert(ctx context.Context, settings *models.SymbolSettings) error
+	// Update changes a symbol's settings and returns them, or ErrNotFound when the symbol has
+	// none
+	Update(ctx context.Context, symbol string, update *SymbolSettingsUpdate) (*models.SymbolSettings, error)
+	// Delete removes a symbol's settings and reports whether they existed
+	Delete(ctx context.Context, symbol string) (bool, error)
+}
+
+// TradingPolicyRepo persists the symbol whitelist or blacklist and the trading pause
+type TradingPolicyRepo interface {
+	// Get returns the policy, or ErrNotFound when none was ever saved
+	Get(ctx context.Context) (*models.TradingPolicy, error)
+	// Save creates or replaces the policy
+	Save(ctx context.Context, policy *models.TradingPolicy) error
+	// AddSymbols adds symbols to the policy's list, creating a policy that is off when there is
+	// none, and returns the updated policy
+	AddSymbols(ctx context.Context, symbols []string, updatedBy string, updatedAt time.Time) (*models.TradingPolicy, error)
+	// RemoveSymbol removes a symbol from the list and reports whether it was listed
+	RemoveSymbol(ctx context.Context, symbol, updatedBy string, updatedAt time.Time) (bool, error)
+	// GetPause returns the trading pause, or ErrNotFound when trading was never paused
+	GetPause(ctx context.Context) (*models.TradingPause, error)
+	// SavePause creates or replaces the trading pause
+	SavePause(ctx context.Context, pause *models.TradingPause) error
+}
+
+// OrderTemplateRepo persists named order templates
+type OrderTemplateRepo interface {
+	List(ctx context.Context) ([]*models.NamedOrderTemplate, error)
+	FindByName(ctx context.Context, name string) (*models.NamedOrderTemplate, error)
+	// Insert stores a new template, or returns ErrDuplicate if the name is taken
+	Insert(ctx context.Context, template *models.NamedOrderTemplate) error
+	// Update replaces the order of the named template, or returns ErrNotFound
+	Update(ctx context.Context, template *models.NamedOrderTemplate) error
+	// Delete removes a template and reports whether it existed
+	Delete(ctx context.Context, name string) (bool, error)
+}
+
+// WatchlistRepo persists the watched symbols
+type WatchlistRepo interface {
+	// List returns the entries by symbol
+	List(ctx context.Context) ([]*models.WatchlistEntry, error)
+	// Add stores the entry unless its symbol is already watched, reporting whether it was added
+	Add(ctx context.Context, entry *models.WatchlistEntry) (bool, error)
+	// Remove deletes a symbol's entry and reports whether it existed
+	Remove(ctx context.Context, symbol string) (bool, error)
+}
+
+// ReconciliationRepo persists reconciliation reports
+type ReconciliationRepo interface {
+	Insert(ctx context.Context, report *models.ReconciliationReport) error
+	// List returns up to limit reports, newest first
+	List(ctx context.Context, limit int64) ([]*models.ReconciliationReport, error)
+}
+
+// JobRepo persists the latest run of each background job
+type JobRepo interface {
+	// RecordRun stores run as the job's latest, counting it (and its failure) in the job's totals.
+	// run's Runs, Failures and LastSucceededAt are set to the stored values.
+	RecordRun(ctx context.Context, run *models.JobRun) error
+	// List returns every job's latest run by name
+	List(ctx context.Context) ([]*models.JobRun, error)
+}
+
+// Transactor groups writes across repositories
+type Transactor interface {
+	// WithTransaction runs fn in a transaction when the store supports one, committing if fn
+	// returns nil and rolling back otherwise; repository calls must use the ctx fn receives.
+	// Without transaction support, or inside another transaction, fn just runs with ctx.
+	// fn may run more than once if the transaction hits a transient error.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	// Transactional reports whether WithTransaction rolls back on error
+	Transactional() bool
+}
+
+// PnLQuery selects the income to aggregate and how to bucket it
+type PnLQuery struct {
+	Start    time.Time // inclusive
+	End      time.Time // exclusive
+	BySymbol bool
+	ByDay    bool // UTC days
+}
+
+// IncomeRepo persists the synced futures income history
+type IncomeRepo interface {
+	// Upsert stores records, skipping ones already stored, and returns the records it stored
+	Upsert(ctx context.Context, records []*models.IncomeRecord) (inserted []*models.IncomeRecord, err error)
+	// LatestTime returns the time of the newest record, or ErrNotFound when there are none
+	LatestTime(ctx context.Context) (time.Time, error)
+	// AggregatePnL sums realized PnL, commission and funding per bucket, ordered by day then symbol
+	AggregatePnL(ctx context.Context, query *PnLQuery) ([]*models.PnLBucket, error)
+}
+
+// FillQuery holds the filters and paging options for fill listings
+type FillQuery struct {
+	Products  []string // empty for every product
+	Symbol    string
+	AccountID string // empty for every account
+	Limit     int64
+	BeforeID  string // ID of the last fill of the previous page (next_cursor)
+}
+
+// PageLimit returns the effective page size
+func (q *FillQuery) PageLimit() int64 {
+	if q.Limit <= 0 {
+		return DefaultOrderPageLimit
+	}
+	if q.Limit > MaxOrderPageLimit {
+		return MaxOrderPageLimit
+	}
+	return q.Limit
+}
+
+// FillRepo persists order fills
+type FillRepo interface {
+	// Insert stores a fill unless the same trade of the order is already stored; inserted is false then
+	Insert(ctx context.Context, fill *models.Fill) (inserted bool, err error)
+	// List returns up to PageLimit()+1 fills, newest fill time first, so callers can detect a next page
+	List(ctx context.Context, query *FillQuery) ([]*models.Fill, error)
+	// AggregatePnLBySource sums the realized PnL and commission of futures fills in the query's
+	// period per order source, ordered by source; its grouping options are ignored
+	AggregatePnLBySource(ctx context.Context, query *PnLQuery) ([]*models.PnLBucket, error)
+}
+
+// EquityRepo persists account equity snapshots
+type EquityRepo interface {
+	Insert(ctx context.Context, snapshot *models.EquitySnapshot) error
+	// List returns the snapshots taken in [start, end), oldest first
+	List(ctx context.Context, start, end time.Time) ([]*models.EquitySnapshot, error)
+}
+
+// JournalQuery filters journal entries
+type JournalQuery struct {
+	OrderID *primitive.ObjectID
+	Symbol  string
+	Tags    []string // entries carrying every one of them
+	Limit   int64
+}
+
+// JournalRepo persists journal entries on orders and positions
+type JournalRepo interface {
+	Insert(ctx context.Context, entry *models.JournalEntry) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.JournalEntry, error)
+	// List returns the entries matching query, newest first
+	List(ctx context.Context, query *JournalQuery) ([]*models.JournalEntry, error)
+	// Latest returns the newest entry of each of orderIDs that has one
+	Latest(ctx context.Context, orderIDs []primitive.ObjectID) (map[primitive.ObjectID]*models.JournalEntry, error)
+	// Update stores the entry's note, tags and update time
+	Update(ctx context.Context, entry *models.JournalEntry) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// Tags returns the distinct tags in use starting with prefix, sorted
+	Tags(ctx context.Context, prefix string) ([]string, error)
+}
+
+// KlineRepo caches closed klines fetched from Binance
+type KlineRepo interface {
+	// Upsert stores klines, replacing any stored with the same symbol, interval and open time
+	Upsert(ctx context.Context, klines []*models.Kline) error
+	// List returns the symbol's klines of interval opening in [start, end), oldest first
+	List(ctx context.Context, symbol, interval string, start, end time.Time) ([]*models.Kline, error)
+}
+
+// WebhookRepo persists webhook subscriptions and undeliverable events
+type WebhookRepo interface {
+	Insert(ctx context.Context, webhook *models.Webhook) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error)
+	List(ctx context.Context) ([]*models.Webhook, error)
+	// Delete removes a webhook and reports whether it existed
+	Delete(ctx context.Context, id primitive.ObjectID) (bool, error)
+	InsertDeadLetter(ctx context.Context, letter *models.WebhookDeadLetter) error
+	// ListDeadLetters returns the newest dead letters first, up to limit
+	ListDeadLetters(ctx context.Context, limit int64) ([]*models.WebhookDeadLetter, error)
+}
+
+// ConditionalOrderRepo persists locally triggered conditional orders. Status changes are
+// conditional on the current status, so a condition is claimed for triggering only once.
+type ConditionalOrderRepo interface {
+	Insert(ctx context.Context, order *models.ConditionalOrder) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.ConditionalOrder, error)
+	// List returns conditional orders newest first, only those with status unless it is empty
+	List(ctx context.Context, status models.ConditionalStatus) ([]*models.ConditionalOrder, error)
+	// Transition applies update to the order if its status is still from and reports whether it did
+	Transition(ctx context.Context, id primitive.ObjectID, from models.ConditionalStatus, update *ConditionalOrderUpdate) (bool, error)
+	// TransitionGroup applies update to the group's orders with status from, except the one with
+	// ID except, and returns how many changed
+	TransitionGroup(ctx context.Context, group string, except primitive.ObjectID, from models.ConditionalStatus, update *ConditionalOrderUpdate) (int64, error)
+}
+
+// ScheduledOrderRepo persists orders scheduled for a set time. Like ConditionalOrderRepo, status
+// changes are conditional on the current status.
+type ScheduledOrderRepo interface {
+	Insert(ctx context.Context, order *models.ScheduledOrder) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.ScheduledOrder, error)
+	// List returns scheduled orders by execution time, only those with status unless it is empty
+	List(ctx context.Context, status models.ScheduleStatus) ([]*models.ScheduledOrder, error)
+	// Transition applies update to the order if its status is still from and reports whether it did
+	Transition(ctx context.Context, id primitive.ObjectID, from models.ScheduleStatus, update *ScheduledOrderUpdate) (bool, error)
+}
+
+// DCAPlanRepo persists DCA plans
+type DCAPlanRepo interface {
+	Insert(ctx context.Context, plan *models.DCAPlan) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.DCAPlan, error)
+	// List returns plans newest first, only those with status unless it is empty
+	List(ctx context.Context, status models.DCAStatus) ([]*models.DCAPlan, error)
+	// Update replaces the stored plan
+	Update(ctx context.Context, plan *models.DCAPlan) error
+}
+
+// GridStrategyRepo persists grid strategies
+type GridStrategyRepo interface {
+	Insert(ctx context.Context, strategy *models.GridStrategy) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.GridStrategy, error)
+	// List returns strategies newest first, only those with status unless it is empty
+	List(ctx context.Context, status models.GridStatus) ([]*models.GridStrategy, error)
+	// Update replaces the stored strategy
+	Update(ctx context.Context, strategy *models.GridStrategy) error
+}
+
+// CalendarSpreadRepo persists calendar spreads
+type CalendarSpreadRepo interface {
+	Insert(ctx context.Context, spread *models.CalendarSpread) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.CalendarSpread, error)
+	// Update replaces the stored spread
+	Update(ctx context.Context, spread *models.CalendarSpread) error
+}
+
+// PaperRepo persists the paper trading engine's orders, positions and account
+type PaperRepo interface {
+	// SaveOrder creates or replaces the order keyed by its order ID
+	SaveOrder(ctx context.Context, order *models.PaperOrder) error
+	// FindOrder looks an order up by order ID, or client order ID when the former is 0
+	FindOrder(ctx context.Context, symbol string, orderID int64, clientOrderID string) (*models.PaperOrder, error)
+	ListOpenOrders(ctx context.Context) ([]*models.PaperOrder, error)
+	// ListFilledSince returns orders filled at or after since, oldest first
+	ListFilledSince(ctx context.Context, since time.Time) ([]*models.PaperOrder, error)
+	// MaxOrderID returns the highest order ID issued so far, or 0
+	MaxOrderID(ctx context.Context) (int64, error)
+	ListPositions(ctx context.Context) ([]*models.PaperPosition, error)
+	// SavePosition creates or replaces the position keyed by symbol and position side
+	SavePosition(ctx context.Context, position *models.PaperPosition) error
+	// GetAccount returns ErrNotFound until the account is first saved
+	GetAccount(ctx context.Context) (*models.PaperAccount, error)
+	SaveAccount(ctx context.Context, account *models.PaperAccount) error
+}
+
+// Repositories groups the repositories the services depend on
+type Repositories struct {
+	Tx            Transactor
+	FuturesOrders FuturesOrderRepo
+	OptionsOrders OptionsOrderRepo
+	SpotOrders    SpotOrderRepo
+	Transfers     TransferRepo
+	Positions     PositionRepo
+	RiskEvents    RiskEventRepo
+	Credentials   CredentialsRepo
+	Audit         AuditRepo
+	Tokens        TokenRepo
+	RiskLimits    RiskLimitRepo
+	Settings      SymbolSettingsRepo
+	Policy        TradingPolicyRepo
+	Templates     OrderTemplateRepo
+	Watchlist     WatchlistRepo
+	Reconcile     ReconciliationRepo
+	Jobs          JobRepo
+	Income        IncomeRepo
+	Fills         FillRepo
+	Equity        EquityRepo
+	Webhooks      WebhookRepo
+	Conditional   ConditionalOrderRepo
+	Scheduled     ScheduledOrderRepo
+	DCAPlans      DCAPlanRepo
+	Grids         GridStrategyRepo
+	Spreads       CalendarSpreadRepo
+	Klines        KlineRepo
+	Journal       JournalRepo
+	Paper         PaperRepo
+}
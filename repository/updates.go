@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"futures-options/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// FuturesOrderUpdate lists the fields of a stored futures order to change. Zero values leave
+// the stored field as it is.
+type FuturesOrderUpdate struct {
+	Status    string
+	Quantity  float64
+	Price     float64
+	StopPrice float64
+	// Fill, when set, replaces the stored execution, including quantities that went back to zero
+	Fill              *FuturesOrderFill
+	RawResponse       json.RawMessage
+	MissingOnExchange *bool // set to clear the flag as well as to raise it
+	ReconcileNote     string
+	ReconciledAt      time.Time
+	UpdatedAt         time.Time
+}
+
+// FuturesOrderFill is the execution of a futures order as Binance last reported it
+type FuturesOrderFill struct {
+	ExecutedQty  float64
+	AvgFillPrice float64
+	CumQuote     float64
+	LastFillTime *time.Time // nil keeps the stored time
+}
+
+func (u *FuturesOrderUpdate) set() bson.M {
+	set := bson.M{}
+	if u.Status != "" {
+		set["status"] = u.Status
+	}
+	if u.Quantity > 0 {
+		set["quantity"] = u.Quantity
+	}
+	if u.Price > 0 {
+		set["price"] = u.Price
+	}
+	if u.StopPrice > 0 {
+		set["stop_price"] = u.StopPrice
+	}
+	if u.Fill != nil {
+		set["executed_qty"] = u.Fill.ExecutedQty
+		set["avg_fill_price"] = u.Fill.AvgFillPrice
+		set["cum_quote"] = u.Fill.CumQuote
+		if u.Fill.LastFillTime != nil {
+			set["last_fill_time"] = *u.Fill.LastFillTime
+		}
+	}
+	if u.RawResponse != nil {
+		set["raw_response"] = u.RawResponse
+	}
+	if u.MissingOnExchange != nil {
+		set["missing_on_exchange"] = *u.MissingOnExchange
+	}
+	if u.ReconcileNote != "" {
+		set["reconcile_note"] = u.ReconcileNote
+	}
+	if !u.ReconciledAt.IsZero() {
+		set["reconciled_at"] = u.ReconciledAt
+	}
+	if !u.UpdatedAt.IsZero() {
+		set["updated_at"] = u.UpdatedAt
+	}
+	return set
+}
+
+// SpotOrderUpdate lists the fields of a stored spot order to change. Zero values leave the
+// stored field as it is.
+type SpotOrderUpdate struct {
+	Status string
+	// Fill, when set, replaces the stored execution
+	Fill              *SpotOrderFill
+	MissingOnExchange *bool
+	ReconciledAt      time.Time
+	UpdatedAt         time.Time
+}
+
+// SpotOrderFill is the execution of a spot order as Binance last reported it
+type SpotOrderFill struct {
+	ExecutedQuantity float64
+	CumulativeQuote  float64
+	AvgPrice         float64
+}
+
+func (u *SpotOrderUpdate) set() bson.M {
+	set := bson.M{}
+	if u.Status != "" {
+		set["status"] = u.Status
+	}
+	if u.Fill != nil {
+		set["executed_quantity"] = u.Fill.ExecutedQuantity
+		set["cumulative_quote"] = u.Fill.CumulativeQuote
+		set["avg_price"] = u.Fill.AvgPrice
+	}
+	if u.MissingOnExchange != nil {
+		set["missing_on_exchange"] = *u.MissingOnExchange
+	}
+	if !u.ReconciledAt.IsZero() {
+		set["reconciled_at"] = u.ReconciledAt
+	}
+	if !u.UpdatedAt.IsZero() {
+		set["updated_at"] = u.UpdatedAt
+	}
+	return set
+}
+
+// ConditionalOrderUpdate lists the fields of a conditional order to change along with its
+// status. Zero values leave the stored field as it is.
+type ConditionalOrderUpdate struct {
+	Status         models.ConditionalStatus
+	Error          string
+	TriggeredAt    time.Time
+	TriggeredPrice float64
+	OrderID        int64
+	UpdatedAt      time.Time
+}
+
+func (u *ConditionalOrderUpdate) set() bson.M {
+	set := bson.M{"status": u.Status}
+	if u.Error != "" {
+		set["error"] = u.Error
+	}
+	if !u.TriggeredAt.IsZero() {
+		set["triggered_at"] = u.TriggeredAt
+	}
+	if u.TriggeredPrice > 0 {
+		set["triggered_price"] = u.TriggeredPrice
+	}
+	if u.OrderID > 0 {
+		set["order_id"] = u.OrderID
+	}
+	if !u.UpdatedAt.IsZero() {
+		set["updated_at"] = u.UpdatedAt
+	}
+	return set
+}
+
+// ScheduledOrderUpdate lists the fields of a scheduled order to change along with its status.
+// Zero values leave the stored field as it is.
+type ScheduledOrderUpdate struct {
+	Status        models.ScheduleStatus
+	Error         string
+	SentAt        time.Time
+	AckedAt       time.Time
+	SendLatencyMs *float64
+	AckLatencyMs  *float64
+	OrderID       int64
+	UpdatedAt     time.Time
+}
+
+func (u *ScheduledOrderUpdate) set() bson.M {
+	set := bson.M{"status": u.Status}
+	if u.Error != "" {
+		set["error"] = u.Error
+	}
+	if !u.SentAt.IsZero() {
+		set["sent_at"] = u.SentAt
+	}
+	if !u.AckedAt.IsZero() {
+		set["acked_at"] = u.AckedAt
+	}
+	if u.SendLatencyMs != nil {
+		set["send_latency_ms"] = *u.SendLatencyMs
+	}
+	if u.AckLatencyMs != nil {
+		set["ack_latency_ms"] = *u.AckLatencyMs
+	}
+	if u.OrderID > 0 {
+		set["order_id"] = u.OrderID
+	}
+	if !u.UpdatedAt.IsZero() {
+		set["updated_at"] = u.UpdatedAt
+	}
+	return set
+}
+
+// SymbolSettingsUpdate lists the fields of a symbol's settings to change. Zero values leave the
+// stored field as it is.
+type SymbolSettingsUpdate struct {
+	Leverage   int
+	MarginType string
+	UpdatedAt  time.Time
+}
+
+func (u *SymbolSettingsUpdate) set() bson.M {
+	set := bson.M{}
+	if u.Leverage > 0 {
+		set["leverage"] = u.Leverage
+	}
+	if u.MarginType != "" {
+		set["margin_type"] = u.MarginType
+	}
+	if !u.UpdatedAt.IsZero() {
+		set["updated_at"] = u.UpdatedAt
+	}
+	return set
+}
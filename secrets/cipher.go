@@ -0,0 +1,84 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// encryptedPrefix marks values produced by Cipher.Encrypt so plaintext rows can be told apart
+const encryptedPrefix = "enc:v1:"
+
+// Cipher encrypts secrets with an AES-256-GCM data key derived from a master key
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher derives the data key from masterKey and prepares the AES-256-GCM cipher
+func NewCipher(masterKey string) (*Cipher, error) {
+	if strings.TrimSpace(masterKey) == "" {
+		return nil, errors.New("master key is empty")
+	}
+
+	// Derive a 32-byte data key so any master key length yields AES-256
+	mac := sha256.New()
+	mac.Write([]byte("futures-options/credentials/v1"))
+	mac.Write([]byte(masterKey))
+	dataKey := mac.Sum(nil)
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt encrypts plaintext and returns a prefixed base64 string; already encrypted values are returned as-is
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value produced by Encrypt; values without the prefix are treated as legacy plaintext
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode encrypted value: %w", err)
+	}
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", errors.New("encrypted value is too short")
+	}
+	plaintext, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value (wrong master key?): %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"futures-options/binance"
+	"futures-options/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrAccountNotFound is returned when the selected account is not a stored credential
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrAccountUnavailable is returned when an account other than the active one is selected
+	// without an account registry, i.e. in paper trading mode
+	ErrAccountUnavailable = errors.New("account selection is not available in paper trading mode")
+	// ErrAccountNotSupported is returned when an operation that only signs with the active
+	// credential is asked to use another account
+	ErrAccountNotSupported = errors.New("this operation only supports the active account")
+)
+
+type accountKey struct{}
+
+// selectedAccount is the stored credential a request chose to sign with
+type selectedAccount struct {
+	id     string
+	client BinanceAPI
+}
+
+// SetAccounts sets the registry that builds clients for accounts other than the active one
+func (s *TradingService) SetAccounts(accounts *binance.Accounts) {
+	s.accounts = accounts
+}
+
+// SetActiveAccount records the ID of the stored credential the default client signs with; it is
+// stamped on the orders and positions of requests that select no account
+func (s *TradingService) SetActiveAccount(id string) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.activeAccount = id
+}
+
+// ActiveAccount returns the ID of the stored credential the default client signs with, or "" when
+// the keys come from the environment
+func (s *TradingService) ActiveAccount() string {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.activeAccount
+}
+
+// WithAccount makes the requests made with the returned context sign with the stored credential
+// id. The active credential keeps using the default client; any other is built on first use.
+func (s *TradingService) WithAccount(ctx context.Context, id string) (context.Context, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	if id == s.ActiveAccount() {
+		return context.WithValue(ctx, accountKey{}, &selectedAccount{id: id, client: s.binanceClient}), nil
+	}
+
+	credentials, err := s.repos.Credentials.FindByID(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load account credentials: %w", err)
+	}
+	if s.accounts == nil {
+		return nil, ErrAccountUnavailable
+	}
+	if err := s.decryptCredentials(credentials); err != nil {
+		return nil, err
+	}
+	client := s.accounts.Client(id, credentials.APIKey, credentials.SecretKey, credentials.IsTestnet)
+	return context.WithValue(ctx, accountKey{}, &selectedAccount{id: id, client: client}), nil
+}
+
+// withOrderAccounts applies the accounts named in the bodies of orders placed together. An order
+// without one uses the request's account (X-Account, else the active credential); all of them
+// must resolve to that same account, so one request never spans several.
+func (s *TradingService) withOrderAccounts(ctx context.Context, batch bool, accounts ...string) (context.Context, error) {
+	selected := AccountFromContext(ctx)
+	account, seen := "", false
+	v := &validator{}
+	for i, id := range accounts {
+		if id == "" {
+			id = s.accountID(ctx)
+		}
+		field := "account"
+		if batch {
+			field = fmt.Sprintf("orders[%d].account", i)
+		}
+		switch {
+		case selected != "" && id != selected:
+			v.add(field, RuleEnum, "must match the account selected with X-Account")
+		case seen && id != account:
+			v.add(field, RuleEnum, "must match the other orders; a batch cannot mix accounts")
+		default:
+			account, seen = id, true
+		}
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	if !seen || account == s.accountID(ctx) {
+		return ctx, nil
+	}
+	return s.WithAccount(ctx, account)
+}
+
+// requireActiveAccount rejects requests that selected an account other than the active one
+func (s *TradingService) requireActiveAccount(ctx context.Context) error {
+	if id := AccountFromContext(ctx); id != "" && id != s.ActiveAccount() {
+		return ErrAccountNotSupported
+	}
+	return nil
+}
+
+// AccountFromContext returns the account selected with WithAccount, or "" if there is none
+func AccountFromContext(ctx context.Context) string {
+	if account, ok := ctx.Value(accountKey{}).(*selectedAccount); ok {
+		return account.id
+	}
+	return ""
+}
+
+// api returns the Binance client requests made with ctx sign with
+func (s *TradingService) api(ctx context.Context) BinanceAPI {
+	if account, ok := ctx.Value(accountKey{}).(*selectedAccount); ok {
+		return account.client
+	}
+	return s.binanceClient
+}
+
+// accountID returns the account stamped on documents written for ctx: the selected account, else
+// the active credential
+func (s *TradingService) accountID(ctx context.Context) string {
+	if id := AccountFromContext(ctx); id != "" {
+		return id
+	}
+	return s.ActiveAccount()
+}
+
+// releaseAccount drops the cached client of a credential that was deleted or became active
+func (s *TradingService) releaseAccount(id string) {
+	if s.accounts != nil {
+		s.accounts.Remove(id)
+	}
+}
@@ -2,20 +2,50 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"futures-options/binance"
-	"futures-options/database"
+	"futures-options/logging"
 	"futures-options/models"
+	"futures-options/repository"
 
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/shopspring/decimal"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrOrderNotFound is returned when a stored order to update does not exist
+var ErrOrderNotFound = errors.New("order not found")
+
 // CreateAdvancedFuturesOrder creates an advanced futures order with all features
 func (s *TradingService) CreateAdvancedFuturesOrder(ctx context.Context, req *AdvancedOrderRequest) (*models.FuturesOrder, error) {
+	ctx, err := s.withOrderAccounts(ctx, false, req.Account)
+	if err != nil {
+		return nil, err
+	}
+	if replayed, err := s.replayedFuturesOrder(ctx, req.ClientOrderID); replayed != nil || err != nil {
+		return replayed, err
+	}
+	if err := s.checkOrderAllowed(ctx, openingSymbols(req)...); err != nil {
+		return nil, err
+	}
+	s.tagOrderSource(req)
+	if models.Market(req.Market) == models.MarketCoinM {
+		return s.createCoinMOrder(ctx, req)
+	}
+	if err := s.checkSymbolStatus(ctx, req.Symbol); err != nil {
+		return nil, err
+	}
+	if err := s.applySymbolDefaults(ctx, req); err != nil {
+		return nil, err
+	}
+	req.normalizeClosePosition()
+	if err := s.resolveQuoteQuantity(ctx, "", req); err != nil {
+		return nil, err
+	}
+
 	// Convert to Binance advanced request
 	binanceReq := &binance.AdvancedOrderRequest{
 		Symbol:                req.Symbol,
@@ -39,22 +69,33 @@ func (s *TradingService) CreateAdvancedFuturesOrder(ctx context.Context, req *Ad
 		GoodTillDate:          req.GoodTillDate,
 	}
 
+	if err := s.checkRiskLimits(ctx, []riskOrder{advancedRiskOrder(req)}, req.OverrideRiskLimits); err != nil {
+		return nil, err
+	}
+
 	// Create order on Binance
-	binanceOrder, err := s.binanceClient.CreateAdvancedFuturesOrder(ctx, binanceReq)
+	start := time.Now()
+	binanceOrder, err := s.api(ctx).CreateAdvancedFuturesOrder(ctx, binanceReq)
+	responded := time.Now()
+	s.recordAudit(ctx, models.AuditOrderCreate, req.Symbol, req, binanceOrder, err, start)
 	if err != nil {
+		s.notifyOrderRejected(ctx, req.Symbol, err)
 		return nil, fmt.Errorf("failed to create order on Binance: %w", err)
 	}
+	logging.FromContext(ctx).Info("futures order placed", "symbol", req.Symbol, "binance_order_id", binanceOrder.OrderID, "status", binanceOrder.Status)
 
 	// Save to MongoDB
 	futuresOrder := &models.FuturesOrder{
 		ID:                    primitive.NewObjectID(),
 		Symbol:                req.Symbol,
+		Market:                models.MarketUSDM,
 		Side:                  models.OrderSide(req.Side),
 		OrderType:             models.OrderType(req.OrderType),
-		Quantity:              req.Quantity,
-		Price:                 req.Price,
-		StopPrice:             req.StopPrice,
-		ActivationPrice:       req.ActivationPrice,
+		Quantity:              req.Quantity.InexactFloat64(),
+		QuoteQuantity:         req.QuoteQuantity,
+		Price:                 req.Price.InexactFloat64(),
+		StopPrice:             req.StopPrice.InexactFloat64(),
+		ActivationPrice:       req.ActivationPrice.InexactFloat64(),
 		CallbackRate:          req.CallbackRate,
 		Leverage:              req.Leverage,
 		PositionSide:          models.PositionSide(req.PositionSide),
@@ -67,76 +108,135 @@ func (s *TradingService) CreateAdvancedFuturesOrder(ctx context.Context, req *Ad
 		NewOrderRespType:      req.NewOrderRespType,
 		ClientOrderID:         req.ClientOrderID,
 		GoodTillDate:          req.GoodTillDate,
+		Template:              req.Template,
+		Source:                req.Source,
+		Corrections:           req.Corrections,
+		AppliedDefaults:       req.AppliedDefaults,
 		BinanceOrderID:        binanceOrder.OrderID,
 		Status:                string(binanceOrder.Status),
+		RawResponse:           s.rawResponse(binanceOrder),
 		CreatedAt:             time.Now(),
 		UpdatedAt:             time.Now(),
 	}
+	createOrderFill(binanceOrder).apply(futuresOrder)
+	stampOrderLatency(ctx, futuresOrder, models.OrderTransportREST, start, responded, binanceOrder.UpdateTime)
 
-	_, err = database.FuturesCollection.InsertOne(ctx, futuresOrder)
-	if err != nil {
-		return nil, fmt.Errorf("failed to save order to database: %w", err)
-	}
-
-	return futuresOrder, nil
+	return s.saveFuturesOrder(ctx, futuresOrder)
 }
 
-// ModifyFuturesOrder modifies an existing futures order
+// ModifyFuturesOrder modifies an existing futures order. The stored order is only updated once
+// Binance confirms the change, with the values Binance echoes back; with ForceLocal the stored
+// order is corrected from the request without contacting Binance.
 func (s *TradingService) ModifyFuturesOrder(ctx context.Context, req *ModifyOrderRequest) (*models.FuturesOrder, error) {
+	if req.OrderID <= 0 && req.ClientOrderID == "" {
+		return nil, fmt.Errorf("either orderID or clientOrderID must be provided")
+	}
+	if req.ForceLocal {
+		return s.modifyLocalFuturesOrder(ctx, req)
+	}
+
 	// Modify order on Binance
-	_, err := s.binanceClient.ModifyFuturesOrder(ctx, &binance.ModifyOrderRequest{
-		Symbol:         req.Symbol,
-		OrderID:        req.OrderID,
-		ClientOrderID:  req.ClientOrderID,
-		Quantity:       req.Quantity,
-		Price:          req.Price,
-		StopPrice:      req.StopPrice,
+	start := time.Now()
+	binanceOrder, err := s.api(ctx).ModifyFuturesOrder(ctx, &binance.ModifyOrderRequest{
+		Symbol:          req.Symbol,
+		OrderID:         req.OrderID,
+		ClientOrderID:   req.ClientOrderID,
+		Quantity:        req.Quantity,
+		Price:           req.Price,
+		StopPrice:       req.StopPrice,
 		ActivationPrice: req.ActivationPrice,
-		CallbackRate:   req.CallbackRate,
-		PriceMatch:     req.PriceMatch,
+		CallbackRate:    req.CallbackRate,
+		PriceMatch:      req.PriceMatch,
 	})
+	s.recordAudit(ctx, models.AuditOrderModify, req.Symbol, req, binanceOrder, err, start)
 	if err != nil {
-		// If modification fails, still update database
-		// In production, you might want to handle this differently
+		return nil, fmt.Errorf("failed to modify order on Binance: %w", err)
 	}
+	logging.FromContext(ctx).Info("futures order modified", "symbol", req.Symbol, "binance_order_id", binanceOrder.OrderID, "status", binanceOrder.Status)
 
-	// Update the database record
-	filter := bson.M{}
-	if req.OrderID > 0 {
-		filter["binance_order_id"] = req.OrderID
-	} else if req.ClientOrderID != "" {
-		filter["client_order_id"] = req.ClientOrderID
-	} else {
-		return nil, fmt.Errorf("either orderID or clientOrderID must be provided")
+	// Binance echoes the order's quantity and prices; unparseable or zero ones keep the stored values
+	update := &repository.FuturesOrderUpdate{
+		Status:      string(binanceOrder.Status),
+		RawResponse: s.rawResponse(binanceOrder),
+		UpdatedAt:   time.Now(),
 	}
+	update.Quantity, _ = strconv.ParseFloat(binanceOrder.OrigQuantity, 64)
+	update.Price, _ = strconv.ParseFloat(binanceOrder.Price, 64)
+	update.StopPrice, _ = strconv.ParseFloat(binanceOrder.StopPrice, 64)
 
-	updateData := bson.M{
-		"updated_at": time.Now(),
+	order, err := s.repos.FuturesOrders.UpdateByRef(ctx, binanceOrder.OrderID, "", update)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("order was modified on Binance but is not stored: %w", ErrOrderNotFound)
 	}
-
-	if req.Quantity > 0 {
-		updateData["quantity"] = req.Quantity
-	}
-	if req.Price > 0 {
-		updateData["price"] = req.Price
-	}
-	if req.StopPrice > 0 {
-		updateData["stop_price"] = req.StopPrice
+	if err != nil {
+		return nil, fmt.Errorf("order was modified on Binance but the stored order could not be updated: %w", err)
 	}
+	return order, nil
+}
 
-	update := bson.M{"$set": updateData}
+// modifyLocalFuturesOrder applies a modification to the stored order only, for operators
+// correcting a record that has drifted from Binance
+func (s *TradingService) modifyLocalFuturesOrder(ctx context.Context, req *ModifyOrderRequest) (*models.FuturesOrder, error) {
+	update := &repository.FuturesOrderUpdate{
+		Quantity:  req.Quantity.InexactFloat64(),
+		Price:     req.Price.InexactFloat64(),
+		StopPrice: req.StopPrice.InexactFloat64(),
+		UpdatedAt: time.Now(),
+	}
 
-	var order models.FuturesOrder
-	err = database.FuturesCollection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&order)
+	start := time.Now()
+	order, err := s.repos.FuturesOrders.UpdateByRef(ctx, req.OrderID, req.ClientOrderID, update)
+	s.recordAudit(ctx, models.AuditOrderModify, req.Symbol, req, nil, err, start)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrOrderNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update order: %w", err)
 	}
-
-	return &order, nil
+	logging.FromContext(ctx).Warn("stored futures order corrected without contacting Binance", "symbol", req.Symbol, "binance_order_id", order.BinanceOrderID)
+	return order, nil
 }
 
 // CreateBatchOrders creates multiple orders at once
 func (s *TradingService) CreateBatchOrders(ctx context.Context, req *BatchOrderRequest) (*BatchOrderResponse, error) {
+	accounts := make([]string, len(req.Orders))
+	for i := range req.Orders {
+		accounts[i] = req.Orders[i].Account
+	}
+	ctx, err := s.withOrderAccounts(ctx, true, accounts...)
+	if err != nil {
+		return nil, err
+	}
+	opening := make([]*AdvancedOrderRequest, len(req.Orders))
+	symbols := make([]string, len(req.Orders))
+	for i := range req.Orders {
+		opening[i] = &req.Orders[i]
+		symbols[i] = req.Orders[i].Symbol
+	}
+	if err := s.checkOrderAllowed(ctx, openingSymbols(opening...)...); err != nil {
+		return nil, err
+	}
+	if err := s.checkSymbolStatus(ctx, symbols...); err != nil {
+		return nil, err
+	}
+	demand := make(map[string]*orderDemand)
+	for i := range req.Orders {
+		addOrderDemand(demand, req.Orders[i].Symbol, req.Orders[i].OrderType)
+	}
+	if err := s.checkOpenOrderCaps(ctx, demand); err != nil {
+		return nil, err
+	}
+	for i := range req.Orders {
+		s.tagOrderSource(&req.Orders[i])
+		if err := s.applySymbolDefaults(ctx, &req.Orders[i]); err != nil {
+			return nil, err
+		}
+		req.Orders[i].normalizeClosePosition()
+		if err := s.resolveQuoteQuantity(ctx, fmt.Sprintf("orders[%d].", i), &req.Orders[i]); err != nil {
+			return nil, err
+		}
+	}
+
 	var orders []*binance.AdvancedOrderRequest
 	for _, orderReq := range req.Orders {
 		orders = append(orders, &binance.AdvancedOrderRequest{
@@ -160,78 +260,134 @@ func (s *TradingService) CreateBatchOrders(ctx context.Context, req *BatchOrderR
 		})
 	}
 
-	binanceOrders, err := s.binanceClient.CreateBatchOrders(ctx, orders)
-	if err != nil {
+	riskOrders := make([]riskOrder, len(req.Orders))
+	for i := range req.Orders {
+		riskOrders[i] = advancedRiskOrder(&req.Orders[i])
+	}
+	if err := s.checkRiskLimits(ctx, riskOrders, req.OverrideRiskLimits); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	binanceOrders, err := s.api(ctx).CreateBatchOrders(ctx, orders)
+	responded := time.Now()
+	s.recordAudit(ctx, models.AuditBatchOrderCreate, "", req, binanceOrders, err, start)
+	var batchErr *binance.BatchOrderError
+	if err != nil && (!errors.As(err, &batchErr) || len(binanceOrders) == 0) {
+		s.notifyOrderRejected(ctx, "", err)
 		return nil, fmt.Errorf("failed to create batch orders: %w", err)
 	}
 
-	// Save to MongoDB
-	var savedOrders []*models.FuturesOrder
+	// Orders that failed have no response; report them and save the rest in one write
+	response := &BatchOrderResponse{}
+	var placed []*models.FuturesOrder
 	for i, binanceOrder := range binanceOrders {
 		if i >= len(req.Orders) {
 			break
 		}
 		orderReq := req.Orders[i]
+		if binanceOrder == nil {
+			if batchErr != nil && i < len(batchErr.Errors) && batchErr.Errors[i] != nil {
+				s.notifyOrderRejected(ctx, orderReq.Symbol, batchErr.Errors[i])
+				response.Errors = append(response.Errors, fmt.Sprintf("order %d (%s): %v", i, orderReq.Symbol, batchErr.Errors[i]))
+			}
+			continue
+		}
 
-		futuresOrder := &models.FuturesOrder{
+		order := &models.FuturesOrder{
 			ID:                    primitive.NewObjectID(),
 			Symbol:                orderReq.Symbol,
+			Market:                models.MarketUSDM,
 			Side:                  models.OrderSide(orderReq.Side),
 			OrderType:             models.OrderType(orderReq.OrderType),
-			Quantity:              orderReq.Quantity,
-			Price:                 orderReq.Price,
-			StopPrice:             orderReq.StopPrice,
+			Quantity:              orderReq.Quantity.InexactFloat64(),
+			QuoteQuantity:         orderReq.QuoteQuantity,
+			Price:                 orderReq.Price.InexactFloat64(),
+			StopPrice:             orderReq.StopPrice.InexactFloat64(),
 			Leverage:              orderReq.Leverage,
 			PositionSide:          models.PositionSide(orderReq.PositionSide),
+			ClientOrderID:         orderReq.ClientOrderID,
+			Source:                orderReq.Source,
+			Corrections:           orderReq.Corrections,
+			AppliedDefaults:       orderReq.AppliedDefaults,
 			BinanceOrderID:        binanceOrder.OrderID,
 			Status:                string(binanceOrder.Status),
+			RawResponse:           s.rawResponse(binanceOrder),
 			CreatedAt:             time.Now(),
 			UpdatedAt:             time.Now(),
 		}
-
-		_, err = database.FuturesCollection.InsertOne(ctx, futuresOrder)
-		if err != nil {
-			continue
-		}
-
-		savedOrders = append(savedOrders, futuresOrder)
+		createOrderFill(binanceOrder).apply(order)
+		stampOrderLatency(ctx, order, models.OrderTransportREST, start, responded, binanceOrder.UpdateTime)
+		placed = append(placed, order)
 	}
 
-	return &BatchOrderResponse{
-		Orders: savedOrders,
-	}, nil
+	saved, err := s.saveFuturesOrders(ctx, placed)
+	if err != nil {
+		// The orders are live on Binance, so report the failed write rather than the batch
+		logging.FromContext(ctx).Error("failed to save batch orders", "orders", len(placed), "error", err)
+		response.Errors = append(response.Errors, err.Error())
+	}
+	response.Orders = saved
+	return response, nil
 }
 
 // CancelBatchOrders cancels multiple orders
 func (s *TradingService) CancelBatchOrders(ctx context.Context, symbol string, orderIDs []int64, clientOrderIDs []string) error {
-	_, err := s.binanceClient.CancelBatchOrders(ctx, symbol, orderIDs, clientOrderIDs)
+	start := time.Now()
+	canceled, err := s.api(ctx).CancelBatchOrders(ctx, symbol, orderIDs, clientOrderIDs)
+	s.recordAudit(ctx, models.AuditOrderCancel, symbol, map[string]interface{}{
+		"symbol":           symbol,
+		"order_ids":        orderIDs,
+		"client_order_ids": clientOrderIDs,
+	}, canceled, err, start)
 	if err != nil {
 		return fmt.Errorf("failed to cancel batch orders: %w", err)
 	}
 
 	// Update status in MongoDB
-	filter := bson.M{"symbol": symbol}
-	if len(orderIDs) > 0 {
-		filter["binance_order_id"] = bson.M{"$in": orderIDs}
-	}
-	if len(clientOrderIDs) > 0 {
-		filter["client_order_id"] = bson.M{"$in": clientOrderIDs}
+	if err := s.repos.FuturesOrders.SetStatus(ctx, symbol, orderIDs, clientOrderIDs, "CANCELED"); err != nil {
+		return err
 	}
 
-	update := bson.M{
-		"$set": bson.M{
-			"status":    "CANCELED",
-			"updated_at": time.Now(),
-		},
+	// Keep each order's cancel response for debugging
+	for _, resp := range canceled {
+		if resp == nil {
+			continue
+		}
+		raw := s.rawResponse(resp)
+		if raw == nil {
+			continue
+		}
+		update := &repository.FuturesOrderUpdate{RawResponse: raw, UpdatedAt: time.Now()}
+		if _, err := s.repos.FuturesOrders.UpdateByRef(ctx, resp.OrderID, resp.ClientOrderID, update); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			logging.FromContext(ctx).Warn("failed to store cancel response", "symbol", resp.Symbol, "binance_order_id", resp.OrderID, "error", err)
+		}
 	}
+	return nil
+}
 
-	_, err = database.FuturesCollection.UpdateMany(ctx, filter, update)
-	return err
+// ChangeLeverage sets a USDⓈ-M symbol's leverage on Binance. Orders skip the change when the
+// leverage they ask for is already set, so this is the way to force it. A symbol with stored
+// settings gets the new leverage as its default.
+func (s *TradingService) ChangeLeverage(ctx context.Context, req *LeverageRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	start := time.Now()
+	err := s.api(ctx).ChangeLeverage(ctx, req.Symbol, req.Leverage)
+	s.recordAudit(ctx, models.AuditLeverageChange, req.Symbol, req, nil, err, start)
+	if err != nil {
+		return err
+	}
+	s.syncSymbolSettings(ctx, req.Symbol, &repository.SymbolSettingsUpdate{Leverage: req.Leverage})
+	return nil
 }
 
 // SetPositionMode sets position mode (One-way or Hedge)
 func (s *TradingService) SetPositionMode(ctx context.Context, dualSide bool) error {
-	err := s.binanceClient.SetPositionMode(ctx, dualSide)
+	start := time.Now()
+	err := s.api(ctx).SetPositionMode(ctx, dualSide)
+	s.recordAudit(ctx, models.AuditPositionMode, "", map[string]interface{}{"dual_side": dualSide}, nil, err, start)
 	if err != nil {
 		return err
 	}
@@ -248,17 +404,12 @@ func (s *TradingService) SetPositionMode(ctx context.Context, dualSide bool) err
 		UpdatedAt: time.Now(),
 	}
 
-	filter := bson.M{}
-	update := bson.M{"$set": config}
-	opts := options.Update().SetUpsert(true)
-
-	_, err = database.DB.Collection("position_mode").UpdateOne(ctx, filter, update, opts)
-	return err
+	return s.repos.Positions.SavePositionMode(ctx, config)
 }
 
 // GetPositionMode gets current position mode
 func (s *TradingService) GetPositionMode(ctx context.Context) (*models.PositionModeConfig, error) {
-	dualSide, err := s.binanceClient.GetPositionMode(ctx)
+	dualSide, err := s.api(ctx).GetPositionMode(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -276,41 +427,121 @@ func (s *TradingService) GetPositionMode(ctx context.Context) (*models.PositionM
 
 // Request types
 type AdvancedOrderRequest struct {
-	Symbol                string     `json:"symbol"`
-	Side                  string     `json:"side"`
-	OrderType             string     `json:"order_type"`
-	Quantity              float64    `json:"quantity"`
-	Price                 float64    `json:"price,omitempty"`
-	StopPrice             float64    `json:"stop_price,omitempty"`
-	ActivationPrice       float64    `json:"activation_price,omitempty"`
-	CallbackRate          float64    `json:"callback_rate,omitempty"`
-	Leverage              int        `json:"leverage"`
-	PositionSide          string     `json:"position_side,omitempty"`
-	TimeInForce           string     `json:"time_in_force,omitempty"`
-	WorkingType           string     `json:"working_type,omitempty"`
-	ReduceOnly            bool       `json:"reduce_only,omitempty"`
-	ClosePosition         bool       `json:"close_position,omitempty"`
-	SelfTradePreventionMode string   `json:"self_trade_prevention_mode,omitempty"`
-	PriceMatch            string     `json:"price_match,omitempty"`
-	NewOrderRespType      string     `json:"new_order_resp_type,omitempty"`
-	ClientOrderID         string     `json:"client_order_id,omitempty"`
-	GoodTillDate          *time.Time `json:"good_till_date,omitempty"`
+	Symbol    string          `json:"symbol"`
+	Side      string          `json:"side"`
+	OrderType string          `json:"order_type"`
+	Quantity  decimal.Decimal `json:"quantity"`
+	// QuoteQuantity sizes the order by notional instead of quantity; usdm only
+	QuoteQuantity           float64         `json:"quote_quantity,omitempty"`
+	Price                   decimal.Decimal `json:"price"`
+	StopPrice               decimal.Decimal `json:"stop_price"`
+	ActivationPrice         decimal.Decimal `json:"activation_price"`
+	CallbackRate            float64         `json:"callback_rate,omitempty"`
+	Leverage                int             `json:"leverage"`
+	PositionSide            string          `json:"position_side,omitempty"`
+	TimeInForce             string          `json:"time_in_force,omitempty"`
+	WorkingType             string          `json:"working_type,omitempty"`
+	ReduceOnly              bool            `json:"reduce_only,omitempty"`
+	ClosePosition           bool            `json:"close_position,omitempty"`
+	SelfTradePreventionMode string          `json:"self_trade_prevention_mode,omitempty"`
+	PriceMatch              string          `json:"price_match,omitempty"`
+	NewOrderRespType        string          `json:"new_order_resp_type,omitempty"`
+	ClientOrderID           string          `json:"client_order_id,omitempty"`
+	GoodTillDate            *time.Time      `json:"good_till_date,omitempty"`
+	// Market is usdm (default) or coinm; coinm quantities are whole contracts and STP, price
+	// match and GTD are not available
+	Market string `json:"market,omitempty"`
+	// OverrideRiskLimits skips the position limits; only allowed for RISK_OVERRIDE_PRINCIPALS
+	OverrideRiskLimits bool `json:"override_risk_limits,omitempty"`
+	// Account is the ID of the stored credential to sign with, like X-Account; in a batch every
+	// order must resolve to the same account
+	Account string `json:"account,omitempty"`
+	// Template names the order template the request was built from, recorded on the order
+	Template string `json:"-"`
+	// Source is what placed the order, manual unless set by a template or strategy
+	Source models.OrderSource `json:"-"`
+	// Corrections lists the fields dropped by normalizeClosePosition, returned with the order
+	Corrections []string `json:"-"`
+	// AppliedDefaults lists the fields applySymbolDefaults filled in, returned with the order
+	AppliedDefaults *models.SymbolDefaults `json:"-"`
+}
+
+// UnmarshalJSON takes the quote quantity and callback rate as JSON numbers or decimal strings; the
+// quantity and prices are decimals, which decode from either form themselves
+func (r *AdvancedOrderRequest) UnmarshalJSON(data []byte) error {
+	type plain AdvancedOrderRequest
+	return decodeDecimalFields(data, (*plain)(r))
 }
 
 type ModifyOrderRequest struct {
-	Symbol         string  `json:"symbol"`
-	OrderID        int64   `json:"order_id,omitempty"`
-	ClientOrderID  string  `json:"client_order_id,omitempty"`
-	Quantity       float64 `json:"quantity,omitempty"`
-	Price          float64 `json:"price,omitempty"`
-	StopPrice      float64 `json:"stop_price,omitempty"`
-	ActivationPrice float64 `json:"activation_price,omitempty"`
-	CallbackRate   float64 `json:"callback_rate,omitempty"`
-	PriceMatch     string  `json:"price_match,omitempty"`
+	Symbol          string          `json:"symbol"`
+	OrderID         int64           `json:"order_id,omitempty"`
+	ClientOrderID   string          `json:"client_order_id,omitempty"`
+	Quantity        decimal.Decimal `json:"quantity"`
+	Price           decimal.Decimal `json:"price"`
+	StopPrice       decimal.Decimal `json:"stop_price"`
+	ActivationPrice decimal.Decimal `json:"activation_price"`
+	CallbackRate    float64         `json:"callback_rate,omitempty"`
+	PriceMatch      string          `json:"price_match,omitempty"`
+	// ForceLocal updates only the stored order, without modifying it on Binance
+	ForceLocal bool `json:"force_local,omitempty"`
+}
+
+// UnmarshalJSON takes the callback rate as a JSON number or decimal string and rejects unknown
+// fields; the quantity and prices are decimals
+func (r *ModifyOrderRequest) UnmarshalJSON(data []byte) error {
+	type plain ModifyOrderRequest
+	return decodeDecimalFields(data, (*plain)(r))
+}
+
+// tagOrderSource defaults req's source to manual and generates a client order ID tagged with it
+// when the request has none
+func (s *TradingService) tagOrderSource(req *AdvancedOrderRequest) {
+	if req.Source == "" {
+		req.Source = models.OrderSourceManual
+	}
+	if req.ClientOrderID == "" {
+		req.ClientOrderID = s.newClientOrderID(req.Source)
+	}
+}
+
+// advancedRiskOrder extracts what the risk limit check needs from an advanced order
+func advancedRiskOrder(req *AdvancedOrderRequest) riskOrder {
+	price := req.Price
+	if price.IsZero() {
+		price = req.StopPrice
+	}
+	return riskOrder{
+		Market:       models.Market(req.Market),
+		Symbol:       req.Symbol,
+		Side:         req.Side,
+		PositionSide: req.PositionSide,
+		Quantity:     req.Quantity.InexactFloat64(),
+		Price:        price.InexactFloat64(),
+		ReduceOnly:   req.ReduceOnly || req.ClosePosition,
+	}
 }
 
 type BatchOrderRequest struct {
 	Orders []AdvancedOrderRequest `json:"orders"`
+	// OverrideRiskLimits skips the position limits for the whole batch (per-order flags are ignored)
+	OverrideRiskLimits bool `json:"override_risk_limits,omitempty"`
+}
+
+// LeverageRequest sets the leverage of a USDⓈ-M symbol
+type LeverageRequest struct {
+	Symbol   string `json:"symbol"`
+	Leverage int    `json:"leverage"`
+}
+
+// Validate checks the symbol and that the leverage is within Binance's range
+func (r *LeverageRequest) Validate() error {
+	v := &validator{}
+	v.required("symbol", r.Symbol)
+	if r.Leverage < 1 || r.Leverage > MaxLeverage {
+		v.add("leverage", RuleRange, fmt.Sprintf("must be between 1 and %d", MaxLeverage))
+	}
+	return v.err()
 }
 
 type BatchOrderResponse struct {
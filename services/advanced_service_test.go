@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+)
+
+func TestCreateAdvancedFuturesOrder(t *testing.T) {
+	rejected := &common.APIError{Code: -2019, Message: "Margin is insufficient."}
+
+	tests := []struct {
+		name     string
+		settings *models.SymbolSettings
+		req      AdvancedOrderRequest
+		binance  error
+		// checks on the request sent to Binance
+		wantLeverage      int
+		wantQuantity      string
+		wantClosePosition bool
+		wantCorrections   int
+		wantErr           error
+	}{
+		{
+			name:         "request leverage is passed on",
+			req:          AdvancedOrderRequest{Symbol: "BTCUSDT", Side: "BUY", OrderType: "MARKET", Quantity: decimal.RequireFromString("0.01"), Leverage: 15},
+			wantLeverage: 15,
+			wantQuantity: "0.01",
+		},
+		{
+			name:         "symbol settings leverage fills an empty one",
+			settings:     &models.SymbolSettings{Symbol: "BTCUSDT", SymbolDefaults: models.SymbolDefaults{Leverage: 7}},
+			req:          AdvancedOrderRequest{Symbol: "BTCUSDT", Side: "BUY", OrderType: "MARKET", Quantity: decimal.RequireFromString("0.01")},
+			wantLeverage: 7,
+			wantQuantity: "0.01",
+		},
+		{
+			name:         "request leverage wins over symbol settings",
+			settings:     &models.SymbolSettings{Symbol: "BTCUSDT", SymbolDefaults: models.SymbolDefaults{Leverage: 7}},
+			req:          AdvancedOrderRequest{Symbol: "BTCUSDT", Side: "BUY", OrderType: "MARKET", Quantity: decimal.RequireFromString("0.01"), Leverage: 3},
+			wantLeverage: 3,
+			wantQuantity: "0.01",
+		},
+		{
+			name:              "close position drops quantity and reduce only",
+			req:               AdvancedOrderRequest{Symbol: "BTCUSDT", Side: "SELL", OrderType: "STOP_MARKET", StopPrice: decimal.NewFromInt(45000), Quantity: decimal.RequireFromString("0.5"), ReduceOnly: true, ClosePosition: true},
+			wantQuantity:      "0",
+			wantClosePosition: true,
+			wantCorrections:   2,
+		},
+		{
+			name:    "Binance rejection is returned with its code",
+			req:     AdvancedOrderRequest{Symbol: "BTCUSDT", Side: "BUY", OrderType: "MARKET", Quantity: decimal.RequireFromString("0.01")},
+			binance: rejected,
+			wantErr: rejected,
+		},
+		{
+			name:    "open circuit breaker is returned as is",
+			req:     AdvancedOrderRequest{Symbol: "BTCUSDT", Side: "BUY", OrderType: "MARKET", Quantity: decimal.RequireFromString("0.01")},
+			binance: binance.ErrCircuitOpen,
+			wantErr: binance.ErrCircuitOpen,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock, repos := newTestService(t)
+			ctx := context.Background()
+			if tt.settings != nil {
+				tt.settings.CreatedAt = time.Now()
+				if err := repos.Settings.Upsert(ctx, tt.settings); err != nil {
+					t.Fatalf("Upsert settings: %v", err)
+				}
+			}
+			mock.CreateAdvancedFuturesOrderFunc = func(ctx context.Context, req *binance.AdvancedOrderRequest) (*futures.CreateOrderResponse, error) {
+				if tt.binance != nil {
+					return nil, tt.binance
+				}
+				return &futures.CreateOrderResponse{Symbol: req.Symbol, OrderID: 42, Status: futures.OrderStatusTypeNew}, nil
+			}
+
+			req := tt.req
+			order, err := s.CreateAdvancedFuturesOrder(ctx, &req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				var apiErr *common.APIError
+				if errors.As(tt.wantErr, &apiErr) && binance.APIErrorCode(err) != apiErr.Code {
+					t.Errorf("APIErrorCode = %d, want %d", binance.APIErrorCode(err), apiErr.Code)
+				}
+				if _, err := repos.FuturesOrders.FindByBinanceID(ctx, 42); !errors.Is(err, repository.ErrNotFound) {
+					t.Errorf("rejected order was stored: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateAdvancedFuturesOrder: %v", err)
+			}
+
+			calls := mock.CallsTo("CreateAdvancedFuturesOrder")
+			if len(calls) != 1 {
+				t.Fatalf("Binance called %d times, want 1", len(calls))
+			}
+			sent := calls[0].Args[0].(*binance.AdvancedOrderRequest)
+			if sent.Leverage != tt.wantLeverage {
+				t.Errorf("sent leverage %d, want %d", sent.Leverage, tt.wantLeverage)
+			}
+			if sent.Quantity.String() != tt.wantQuantity {
+				t.Errorf("sent quantity %s, want %s", sent.Quantity, tt.wantQuantity)
+			}
+			if sent.ClosePosition != tt.wantClosePosition || tt.wantClosePosition && sent.ReduceOnly {
+				t.Errorf("sent close_position %v reduce_only %v", sent.ClosePosition, sent.ReduceOnly)
+			}
+			if len(order.Corrections) != tt.wantCorrections {
+				t.Errorf("corrections = %q, want %d", order.Corrections, tt.wantCorrections)
+			}
+			if _, err := repos.FuturesOrders.FindByBinanceID(ctx, 42); err != nil {
+				t.Errorf("order not stored: %v", err)
+			}
+		})
+	}
+}
+
+func TestModifyFuturesOrder(t *testing.T) {
+	noChange := &common.APIError{Code: -5027, Message: "No need to modify the order."}
+	echo := func(ctx context.Context, req *binance.ModifyOrderRequest) (*futures.CreateOrderResponse, error) {
+		return &futures.CreateOrderResponse{Symbol: req.Symbol, OrderID: 42, Status: futures.OrderStatusTypeNew, OrigQuantity: req.Quantity.String(), Price: req.Price.String()}, nil
+	}
+
+	tests := []struct {
+		name    string
+		stored  bool // a NEW order 42 / "modify-1" at quantity 1, price 100
+		req     ModifyOrderRequest
+		binance func(ctx context.Context, req *binance.ModifyOrderRequest) (*futures.CreateOrderResponse, error)
+		// wantCalls is the number of Binance modify requests
+		wantCalls    int
+		wantQuantity float64
+		wantPrice    float64
+		wantErr      error
+		wantAnyErr   bool
+	}{
+		{
+			name:         "Binance modification is stored",
+			stored:       true,
+			req:          ModifyOrderRequest{Symbol: "BTCUSDT", OrderID: 42, Quantity: decimal.RequireFromString("0.5"), Price: decimal.RequireFromString("99.9")},
+			binance:      echo,
+			wantCalls:    1,
+			wantQuantity: 0.5,
+			wantPrice:    99.9,
+		},
+		{
+			name:   "unparseable echo keeps the stored values",
+			stored: true,
+			req:    ModifyOrderRequest{Symbol: "BTCUSDT", ClientOrderID: "modify-1", Quantity: decimal.NewFromInt(2), Price: decimal.NewFromInt(101)},
+			binance: func(ctx context.Context, req *binance.ModifyOrderRequest) (*futures.CreateOrderResponse, error) {
+				return &futures.CreateOrderResponse{Symbol: req.Symbol, OrderID: 42, Status: futures.OrderStatusTypeNew, OrigQuantity: "", Price: "n/a"}, nil
+			},
+			wantCalls:    1,
+			wantQuantity: 1,
+			wantPrice:    100,
+		},
+		{
+			name:   "Binance rejection leaves the stored order",
+			stored: true,
+			req:    ModifyOrderRequest{Symbol: "BTCUSDT", OrderID: 42, Quantity: decimal.NewFromInt(1), Price: decimal.NewFromInt(100)},
+			binance: func(context.Context, *binance.ModifyOrderRequest) (*futures.CreateOrderResponse, error) {
+				return nil, noChange
+			},
+			wantCalls: 1,
+			wantErr:   noChange,
+		},
+		{
+			name:      "modified on Binance but not stored",
+			req:       ModifyOrderRequest{Symbol: "BTCUSDT", OrderID: 42, Quantity: decimal.NewFromInt(2), Price: decimal.NewFromInt(101)},
+			binance:   echo,
+			wantCalls: 1,
+			wantErr:   ErrOrderNotFound,
+		},
+		{
+			name:         "force local by Binance order ID",
+			stored:       true,
+			req:          ModifyOrderRequest{Symbol: "BTCUSDT", OrderID: 42, Price: decimal.RequireFromString("98.5"), ForceLocal: true},
+			wantQuantity: 1,
+			wantPrice:    98.5,
+		},
+		{
+			name:    "force local on an unknown order",
+			req:     ModifyOrderRequest{Symbol: "BTCUSDT", ClientOrderID: "missing", Quantity: decimal.NewFromInt(2), ForceLocal: true},
+			wantErr: ErrOrderNotFound,
+		},
+		{
+			name:       "no order reference",
+			stored:     true,
+			req:        ModifyOrderRequest{Symbol: "BTCUSDT", Quantity: decimal.NewFromInt(2)},
+			wantAnyErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock, repos := newTestService(t)
+			ctx := context.Background()
+			if tt.stored {
+				storeOrder(t, repos, &models.FuturesOrder{Symbol: "BTCUSDT", BinanceOrderID: 42, ClientOrderID: "modify-1", Quantity: 1, Price: 100})
+			}
+			mock.ModifyFuturesOrderFunc = tt.binance
+
+			req := tt.req
+			order, err := s.ModifyFuturesOrder(ctx, &req)
+			if calls := mock.CallsTo("ModifyFuturesOrder"); len(calls) != tt.wantCalls {
+				t.Errorf("Binance called %d times, want %d", len(calls), tt.wantCalls)
+			}
+			if tt.wantErr != nil || tt.wantAnyErr {
+				if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if tt.stored {
+					stored, findErr := repos.FuturesOrders.FindByBinanceID(ctx, 42)
+					if findErr != nil {
+						t.Fatalf("FindByBinanceID: %v", findErr)
+					}
+					if stored.Quantity != 1 || stored.Price != 100 {
+						t.Errorf("stored order changed to quantity %v price %v", stored.Quantity, stored.Price)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ModifyFuturesOrder: %v", err)
+			}
+			if order.Quantity != tt.wantQuantity || order.Price != tt.wantPrice {
+				t.Errorf("order = quantity %v price %v, want %v %v", order.Quantity, order.Price, tt.wantQuantity, tt.wantPrice)
+			}
+			stored, err := repos.FuturesOrders.FindByBinanceID(ctx, 42)
+			if err != nil {
+				t.Fatalf("FindByBinanceID: %v", err)
+			}
+			if stored.Quantity != tt.wantQuantity || stored.Price != tt.wantPrice {
+				t.Errorf("stored = quantity %v price %v, want %v %v", stored.Quantity, stored.Price, tt.wantQuantity, tt.wantPrice)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/logging"
+	"futures-options/models"
+	"futures-options/repository"
+)
+
+// auditFlushInterval bounds how long an entry waits in the buffer before being written
+const auditFlushInterval = time.Second
+
+// auditBatchSize is the maximum number of entries written in one insert
+const auditBatchSize = 100
+
+// AuditQuery holds the filters and paging options for audit log listings
+type AuditQuery = repository.AuditQuery
+
+// AuditLogger writes audit entries asynchronously through a buffered channel
+type AuditLogger struct {
+	repo    repository.AuditRepo
+	entries chan *models.AuditEntry
+	done    chan struct{}
+	// mu guards closed, so Log never sends on the channel Close has closed
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewAuditLogger starts the background writer; call Close on shutdown to flush pending entries
+func NewAuditLogger(repo repository.AuditRepo, bufferSize int) *AuditLogger {
+	if bufferSize <= 0 {
+		bufferSize = 1000
+	}
+	l := &AuditLogger{
+		repo:    repo,
+		entries: make(chan *models.AuditEntry, bufferSize),
+		done:    make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Log queues an entry without blocking; entries are dropped (and logged) when the buffer is full
+// or the logger was closed
+func (l *AuditLogger) Log(entry *models.AuditEntry) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.closed {
+		slog.Warn("audit logger closed, dropping entry", "action", entry.Action, "request_id", entry.RequestID)
+		return
+	}
+	select {
+	case l.entries <- entry:
+	default:
+		slog.Warn("audit buffer full, dropping entry", "action", entry.Action, "request_id", entry.RequestID)
+	}
+}
+
+// Close stops accepting entries and waits until the buffered ones are written or ctx is done
+func (l *AuditLogger) Close(ctx context.Context) error {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.entries)
+	}
+	l.mu.Unlock()
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("audit entries not flushed: %w", ctx.Err())
+	}
+}
+
+func (l *AuditLogger) run() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(auditFlushInterval)
+	defer ticker.Stop()
+
+	var batch []*models.AuditEntry
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := l.repo.InsertMany(ctx, batch); err != nil {
+			slog.Error("failed to write audit entries", "count", len(batch), "error", err)
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case entry, ok := <-l.entries:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= auditBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// SetAuditLogger enables audit logging of trading actions
+func (s *TradingService) SetAuditLogger(l *AuditLogger) {
+	s.audit = l
+}
+
+// recordAudit queues an audit entry for an action that started at start
+func (s *TradingService) recordAudit(ctx context.Context, action models.AuditAction, symbol string, request, response interface{}, err error, start time.Time) {
+	if s.audit == nil {
+		return
+	}
+
+	entry := &models.AuditEntry{
+		Timestamp: start,
+		Action:    action,
+		Symbol:    symbol,
+		Request:   sanitizeAuditPayload(request),
+		Response:  sanitizeAuditPayload(response),
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	entry.RequestID = logging.RequestID(ctx)
+	entry.Route = logging.Route(ctx)
+	if err != nil {
+		entry.Error = err.Error()
+		entry.ErrorCode = binance.APIErrorCode(err)
+	}
+
+	s.audit.Log(entry)
+}
+
+// GetAuditLog retrieves a page of audit entries, newest first
+func (s *TradingService) GetAuditLog(ctx context.Context, query *AuditQuery) (*AuditLogPage, error) {
+	entries, total, err := s.repos.Audit.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLogPage{Items: entries, Total: total}, nil
+}
+
+// AuditLogPage is a page of audit entries
+type AuditLogPage struct {
+	Items []*models.AuditEntry `json:"items"`
+	Total int64                `json:"total"`
+}
+
+// sanitizeAuditPayload converts v to plain JSON values and masks anything that looks like a secret
+func sanitizeAuditPayload(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil
+	}
+	return redactSecrets(decoded)
+}
+
+func redactSecrets(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, field := range value {
+			switch key := strings.ToLower(k); {
+			case isSecretField(key):
+				value[k] = "****"
+				continue
+			case strings.Contains(key, "api_key") || strings.Contains(key, "apikey"):
+				// API keys are identifiers; keep enough of them to tell credentials apart
+				if str, ok := field.(string); ok {
+					value[k] = MaskSecret(str)
+				} else {
+					value[k] = "****"
+				}
+				continue
+			}
+			value[k] = redactSecrets(field)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactSecrets(item)
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+// isSecretField reports whether a lower-cased field name holds a secret that must never be stored
+func isSecretField(name string) bool {
+	for _, marker := range []string{"secret", "password", "private_key", "signature", "token"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
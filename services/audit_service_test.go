@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"futures-options/models"
+	"futures-options/repository"
+)
+
+func TestAuditLoggerFlushesOnClose(t *testing.T) {
+	repo := repository.NewMemoryAuditRepo()
+	l := NewAuditLogger(repo, 10)
+	for i := 0; i < 3; i++ {
+		l.Log(&models.AuditEntry{Timestamp: time.Now(), Action: models.AuditOrderCreate})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := l.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, total, _ := repo.List(ctx, &repository.AuditQuery{}); total != 3 {
+		t.Errorf("%d entries written, want 3", total)
+	}
+
+	// Entries logged after Close are dropped, not sent on the closed channel
+	l.Log(&models.AuditEntry{Timestamp: time.Now(), Action: models.AuditOrderCreate})
+	if err := l.Close(ctx); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+// TestAuditLoggerLogDuringClose logs from several goroutines while the logger closes; run it
+// with -race
+func TestAuditLoggerLogDuringClose(t *testing.T) {
+	l := NewAuditLogger(repository.NewMemoryAuditRepo(), 1000)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				l.Log(&models.AuditEntry{Timestamp: time.Now(), Action: models.AuditOrderCreate})
+			}
+		}()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := l.Close(ctx); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	wg.Wait()
+}
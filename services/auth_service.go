@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"futures-options/models"
+	"futures-options/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// apiTokenPrefix marks tokens issued by this service
+const apiTokenPrefix = "fo_"
+
+var (
+	// ErrUnauthorized is returned when a request has no valid API token
+	ErrUnauthorized = errors.New("missing or invalid API token")
+	// ErrTokenNotFound is returned when no stored token matches the given ID
+	ErrTokenNotFound = errors.New("token not found")
+)
+
+// AnonymousPrincipal is the principal of requests when authentication is disabled
+const AnonymousPrincipal = "anonymous"
+
+type principalKey struct{}
+
+// WithPrincipal attaches the authenticated principal to ctx
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal attached by WithPrincipal, or "" if there is none
+func PrincipalFromContext(ctx context.Context) string {
+	principal, _ := ctx.Value(principalKey{}).(string)
+	return principal
+}
+
+// AuthService checks REST API tokens configured in the environment or stored hashed in MongoDB
+type AuthService struct {
+	repo         repository.TokenRepo
+	staticHashes []string // SHA-256 of the tokens from API_TOKENS
+	disabled     bool
+}
+
+// NewAuthService builds the token checker; staticTokens come from configuration
+func NewAuthService(repo repository.TokenRepo, staticTokens []string, disabled bool) *AuthService {
+	hashes := make([]string, len(staticTokens))
+	for i, token := range staticTokens {
+		hashes[i] = hashToken(token)
+	}
+	return &AuthService{repo: repo, staticHashes: hashes, disabled: disabled}
+}
+
+// Disabled reports whether authentication is turned off
+func (a *AuthService) Disabled() bool {
+	return a.disabled
+}
+
+// HasStaticTokens reports whether any token is configured in the environment
+func (a *AuthService) HasStaticTokens() bool {
+	return len(a.staticHashes) > 0
+}
+
+// Authenticate returns the principal (token label) for a presented token
+func (a *AuthService) Authenticate(ctx context.Context, token string) (string, error) {
+	if a.disabled {
+		return AnonymousPrincipal, nil
+	}
+	if token == "" {
+		return "", ErrUnauthorized
+	}
+
+	hash := hashToken(token)
+	for i, static := range a.staticHashes {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(static)) == 1 {
+			return fmt.Sprintf("env-token-%d", i+1), nil
+		}
+	}
+
+	stored, err := a.repo.FindByHash(ctx, hash)
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", ErrUnauthorized
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up API token: %w", err)
+	}
+	return stored.Label, nil
+}
+
+// CreateAPITokenRequest holds the label of a new token
+type CreateAPITokenRequest struct {
+	Label string `json:"label"`
+}
+
+// Validate checks a token creation request
+func (r *CreateAPITokenRequest) Validate() error {
+	v := &validator{}
+	v.required("label", r.Label)
+	return v.err()
+}
+
+// CreatedAPIToken is returned once on creation; the plaintext token cannot be retrieved later
+type CreatedAPIToken struct {
+	*models.APIToken
+	Token string `json:"token"`
+}
+
+// CreateToken issues a new random token and stores its hash
+func (a *AuthService) CreateToken(ctx context.Context, req *CreateAPITokenRequest) (*CreatedAPIToken, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := apiTokenPrefix + hex.EncodeToString(raw)
+
+	record := &models.APIToken{
+		ID:        primitive.NewObjectID(),
+		Label:     strings.TrimSpace(req.Label),
+		TokenHash: hashToken(token),
+		Prefix:    token[:len(apiTokenPrefix)+6],
+		CreatedAt: time.Now(),
+	}
+	if err := a.repo.Insert(ctx, record); err != nil {
+		return nil, fmt.Errorf("failed to save API token: %w", err)
+	}
+	return &CreatedAPIToken{APIToken: record, Token: token}, nil
+}
+
+// ListTokens returns the stored tokens without their hashes
+func (a *AuthService) ListTokens(ctx context.Context) ([]*models.APIToken, error) {
+	return a.repo.List(ctx)
+}
+
+// DeleteToken revokes a stored token
+func (a *AuthService) DeleteToken(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	deleted, err := a.repo.Delete(ctx, objectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete API token: %w", err)
+	}
+	if !deleted {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"fmt"
+)
+
+// runBackground runs fn in a goroutine that Shutdown waits for
+func (s *TradingService) runBackground(fn func()) {
+	s.bgWG.Add(1)
+	go func() {
+		defer s.bgWG.Done()
+		fn()
+	}()
+}
+
+// Shutdown closes the user data stream and the WS-API connection and waits for background work
+// to finish. The background context must already be canceled; ctx bounds the wait.
+func (s *TradingService) Shutdown(ctx context.Context) error {
+	s.stateMu.Lock()
+	ws := s.wsClient
+	s.wsClient = nil
+	s.stateMu.Unlock()
+	if ws != nil {
+		ws.Close()
+	}
+	s.resetWSAPIClient()
+
+	done := make(chan struct{})
+	go func() {
+		s.bgWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background work did not finish: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/strategy"
+)
+
+// defaultBacktestCommissionRate is Binance's base USDⓈ-M taker rate, charged on every fill
+// unless the request sets its own
+const defaultBacktestCommissionRate = 0.0004
+
+// BacktestRequest replays a grid or DCA configuration, as accepted by the strategy endpoints, over
+// the symbol's klines of interval in [start_time, end_time)
+type BacktestRequest struct {
+	Symbol    string    `json:"symbol"`
+	Interval  string    `json:"interval"`           // kline interval, e.g. 15m
+	StartTime time.Time `json:"start_time"`         // RFC 3339
+	EndTime   time.Time `json:"end_time,omitempty"` // default: now
+	// CommissionRate is the fraction of each fill's notional paid as commission; default 0.0004
+	CommissionRate *float64                   `json:"commission_rate,omitempty"`
+	Grid           *CreateGridStrategyRequest `json:"grid,omitempty"`
+	DCA            *CreateDCAPlanRequest      `json:"dca,omitempty"`
+}
+
+// Validate checks the range and the strategy configuration; the strategy takes the request's
+// symbol when it does not name one
+func (r *BacktestRequest) Validate() error {
+	v := &validator{}
+	v.required("symbol", r.Symbol)
+	r.Symbol = strings.ToUpper(r.Symbol)
+	validateKlineRange(v, "", r.Interval, r.StartTime, r.EndTime)
+	if r.CommissionRate != nil && (*r.CommissionRate < 0 || *r.CommissionRate >= 0.01) {
+		v.add("commission_rate", RuleRange, "must be at least 0 and below 0.01")
+	}
+
+	switch {
+	case (r.Grid == nil) == (r.DCA == nil):
+		v.add("grid", RuleRequired, "exactly one of grid and dca is required")
+	case r.Grid != nil:
+		v.nested("grid.", r.Grid.backtestSymbol(r.Symbol))
+	default:
+		v.nested("dca.", r.DCA.backtestSymbol(r.Symbol))
+	}
+	return v.err()
+}
+
+// backtestSymbol defaults the strategy's symbol to symbol and validates it
+func (r *CreateGridStrategyRequest) backtestSymbol(symbol string) error {
+	if r.Symbol == "" {
+		r.Symbol = symbol
+	}
+	if !strings.EqualFold(r.Symbol, symbol) {
+		v := &validator{}
+		v.add("symbol", RuleEnum, "must match the backtest symbol")
+		return v.err()
+	}
+	r.Symbol = symbol
+	return r.Validate()
+}
+
+// backtestSymbol defaults the plan's symbol to symbol and validates it
+func (r *CreateDCAPlanRequest) backtestSymbol(symbol string) error {
+	if r.Symbol == "" {
+		r.Symbol = symbol
+	}
+	if !strings.EqualFold(r.Symbol, symbol) {
+		v := &validator{}
+		v.add("symbol", RuleEnum, "must match the backtest symbol")
+		return v.err()
+	}
+	r.Symbol = symbol
+	return r.Validate()
+}
+
+// Backtest replays a grid strategy or DCA plan over the symbol's klines, loaded through the kline
+// cache, and returns the simulated trades, profit, drawdown and final position. Nothing is
+// stored or sent to Binance besides the kline requests. The fill model is described in the
+// strategy package: a limit order fills when a candle touches its price.
+func (s *TradingService) Backtest(ctx context.Context, req *BacktestRequest) (*strategy.BacktestResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	filters, err := s.api(ctx).GetSymbolFilters(ctx, req.Symbol)
+	if errors.Is(err, binance.ErrUnknownSymbol) {
+		v := &validator{}
+		v.add("symbol", RuleEnum, "is not a listed futures symbol")
+		return nil, v.err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	klines, err := s.GetKlines(ctx, req.Symbol, req.Interval, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	if len(klines) == 0 {
+		v := &validator{}
+		v.add("start_time", RuleRange, "the range holds no closed candles")
+		return nil, v.err()
+	}
+
+	cfg := strategy.BacktestConfig{Filters: filters, CommissionRate: defaultBacktestCommissionRate}
+	if req.CommissionRate != nil {
+		cfg.CommissionRate = *req.CommissionRate
+	}
+	if req.DCA != nil {
+		return strategy.BacktestDCA(newDCAPlan(req.DCA, klines[0].OpenTime), klines, cfg), nil
+	}
+
+	v := &validator{}
+	quantity := checkGridFit(v, "grid.", req.Grid, filters, klines[0].Open, "opening price")
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	st := &models.GridStrategy{
+		Symbol:     req.Symbol,
+		LowerPrice: req.Grid.LowerPrice,
+		UpperPrice: req.Grid.UpperPrice,
+		GridCount:  req.Grid.GridCount,
+		Quantity:   quantity,
+		Direction:  req.Grid.Direction,
+		Leverage:   req.Grid.Leverage,
+		Status:     models.GridRunning,
+		CreatedAt:  klines[0].OpenTime,
+		UpdatedAt:  klines[len(klines)-1].CloseTime,
+	}
+	return strategy.BacktestGrid(st, klines, cfg), nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/config"
+
+	spot "github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/delivery"
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+)
+
+// BinanceAPI is the subset of the Binance client the trading service depends on.
+// *binance.Client satisfies it; binance/binancetest provides a programmable mock.
+type BinanceAPI interface {
+	// Futures orders
+	CreateFuturesOrder(ctx context.Context, symbol string, side futures.SideType, orderType futures.OrderType, quantity, price decimal.Decimal, leverage int, clientOrderID string) (*futures.CreateOrderResponse, error)
+	CreateAdvancedFuturesOrder(ctx context.Context, req *binance.AdvancedOrderRequest) (*futures.CreateOrderResponse, error)
+	ModifyFuturesOrder(ctx context.Context, req *binance.ModifyOrderRequest) (*futures.CreateOrderResponse, error)
+	CreateBatchOrders(ctx context.Context, orders []*binance.AdvancedOrderRequest) ([]*futures.CreateOrderResponse, error)
+	CancelBatchOrders(ctx context.Context, symbol string, orderIDs []int64, clientOrderIDs []string) ([]*futures.CancelOrderResponse, error)
+	GetFuturesOrder(ctx context.Context, symbol string, orderID int64) (*futures.Order, error)
+	ListOpenFuturesOrders(ctx context.Context, symbol string) ([]*futures.Order, error)
+
+	// COIN-M (delivery) futures; quantities are in contracts
+	CreateDeliveryOrder(ctx context.Context, req *binance.AdvancedOrderRequest) (*delivery.CreateOrderResponse, error)
+	CancelDeliveryOrders(ctx context.Context, symbol string, orderIDs []int64, clientOrderIDs []string) ([]*delivery.CancelOrderResponse, error)
+	GetDeliveryOrder(ctx context.Context, symbol string, orderID int64) (*delivery.Order, error)
+	ListOpenDeliveryOrders(ctx context.Context, symbol string) ([]*delivery.Order, error)
+	GetDeliveryAccount(ctx context.Context) (*delivery.Account, error)
+	GetDeliveryBalance(ctx context.Context) ([]*delivery.Balance, error)
+	GetDeliveryPositions(ctx context.Context) ([]*delivery.PositionRisk, error)
+	GetContractSize(ctx context.Context, symbol string) (float64, error)
+
+	// Spot
+	CreateSpotOrder(ctx context.Context, req *binance.SpotOrderRequest) (*spot.CreateOrderResponse, error)
+	GetSpotOrder(ctx context.Context, symbol string, orderID int64) (*spot.Order, error)
+	ListOpenSpotOrders(ctx context.Context, symbol string) ([]*spot.Order, error)
+	GetSpotBalances(ctx context.Context) ([]spot.Balance, error)
+
+	// Wallet transfers
+	UniversalTransfer(ctx context.Context, transferType, asset string, amount float64) (int64, error)
+	ListUniversalTransfers(ctx context.Context, transferType string, start, end time.Time) ([]*binance.TransferRecord, error)
+
+	// Symbol rules
+	GetSymbolFilters(ctx context.Context, symbol string) (*binance.SymbolFilters, error)
+	GetSpotSymbolFilters(ctx context.Context, symbol string) (*binance.SymbolFilters, error)
+	RefreshExchangeInfo(ctx context.Context, markets ...string) ([]binance.ExchangeInfoStatus, error)
+	ExchangeInfoStatus() []binance.ExchangeInfoStatus
+
+	// Market prices
+	GetPrice(ctx context.Context, symbol string, source futures.WorkingType, maxAge time.Duration) (binance.Price, error)
+	GetKlines(ctx context.Context, symbol, interval string, start, end time.Time) ([]*futures.Kline, error)
+	SubscribePrices(ctx context.Context, handle func(updates []binance.PriceUpdate)) error
+	SubscribeSymbols(ctx context.Context, symbols []string, handle func(update *binance.SymbolUpdate)) error
+
+	// Account and positions
+	GetFuturesAccount(ctx context.Context) (*futures.Account, error)
+	GetFuturesPositions(ctx context.Context) ([]*futures.PositionRisk, error)
+	GetADLQuantile(ctx context.Context, symbol string) (map[string]int, error)
+	GetFundingRate(ctx context.Context, symbol string) (*binance.FundingRate, error)
+	GetIncomeHistory(ctx context.Context, start time.Time) ([]*futures.IncomeHistory, error)
+	GetLeverageBrackets(ctx context.Context, symbol string) ([]futures.Bracket, error)
+	ChangeLeverage(ctx context.Context, symbol string, leverage int) error
+	ChangeMarginType(ctx context.Context, symbol, marginType string) error
+	LeverageCacheStats() binance.LeverageCacheStats
+	SetPositionMode(ctx context.Context, dualSide bool) error
+	GetPositionMode(ctx context.Context) (bool, error)
+
+	// Clients and credentials
+	Futures() *futures.Client
+	Options() *binance.OptionsClient
+	SetCredentials(apiKey, secretKey string, testnet bool)
+	ValidateAPIKeys(ctx context.Context, apiKey, secretKey string, testnet bool) error
+	EffectiveConfig() *config.Config
+	IsTestnet() bool
+	Ping(ctx context.Context) error
+	Breaker() *binance.CircuitBreaker
+	CheckServerTime(ctx context.Context) (*binance.ServerTimeCheck, error)
+	ProbeNetwork(ctx context.Context) *binance.NetworkDiagnostics
+	SetTimeOffset(offsetMs int64)
+}
+
+var _ BinanceAPI = (*binance.Client)(nil)
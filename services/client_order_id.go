@@ -0,0 +1,155 @@
+package services
+
+import (
+	"crypto/rand"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"futures-options/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxClientOrderIDLength is Binance's limit on newClientOrderId
+	maxClientOrderIDLength = 36
+	// compactIDLength is the length of a strategy's ObjectID in base 62
+	compactIDLength = 17
+)
+
+// clientOrderIDPattern is the character set Binance accepts in newClientOrderId
+var clientOrderIDPattern = regexp.MustCompile(`^[.A-Z:/a-z0-9_-]{1,36}$`)
+
+// OrderSources lists the values of the source filter of the futures order endpoints
+var OrderSources = []string{
+	string(models.OrderSourceManual), string(models.OrderSourceTemplate), string(models.OrderSourceGrid),
+	string(models.OrderSourceDCA), string(models.OrderSourceConditional), string(models.OrderSourceScheduled),
+	string(models.OrderSourceSpread), string(models.OrderSourceExternal),
+}
+
+// clientOrderIDTags name each order source in the client order IDs generated for it. Grid, DCA,
+// conditional, scheduled and spread tags are followed by the ID of the strategy, which routes the
+// order back to it.
+var clientOrderIDTags = map[models.OrderSource]string{
+	models.OrderSourceManual:      "man",
+	models.OrderSourceTemplate:    "tpl",
+	models.OrderSourceGrid:        gridClientIDTag,
+	models.OrderSourceDCA:         dcaClientIDTag,
+	models.OrderSourceConditional: conditionalClientIDTag,
+	models.OrderSourceScheduled:   scheduledClientIDTag,
+	models.OrderSourceSpread:      spreadClientIDTag,
+}
+
+// SetClientOrderIDPrefix sets the prefix of the client order IDs the service generates, e.g.
+// "fo-" for fo-grid-... and fo-man-...; config validation keeps it short enough for every ID to
+// fit. Without one, IDs keep the unprefixed <tag>-... form and strategy IDs stay in hex.
+func (s *TradingService) SetClientOrderIDPrefix(prefix string) {
+	s.clientOrderIDPrefix = prefix
+}
+
+// newClientOrderID returns a client order ID tagged with source and a random suffix, so the order
+// can be told apart from external ones and its placement retried safely
+func (s *TradingService) newClientOrderID(source models.OrderSource) string {
+	suffix := make([]byte, 12)
+	if _, err := rand.Read(suffix); err != nil {
+		// Binance assigns an ID instead; the order's source is still stored
+		return ""
+	}
+	return s.clientOrderIDPrefix + clientOrderIDTags[source] + "-" + base62(suffix)
+}
+
+// strategyClientOrderID returns the client order ID of a strategy's order: its tag, the strategy
+// ID and, when n > 0, the order's number within the strategy. With a prefix configured the ID is
+// written in base 62, since the hex form would not fit Binance's 36 characters.
+func (s *TradingService) strategyClientOrderID(source models.OrderSource, id primitive.ObjectID, n int) string {
+	clientOrderID := clientOrderIDTags[source] + "-" + id.Hex()
+	if s.clientOrderIDPrefix != "" {
+		clientOrderID = s.clientOrderIDPrefix + clientOrderIDTags[source] + "-" + base62(id[:])
+	}
+	if n > 0 {
+		clientOrderID += "-" + strconv.Itoa(n)
+	}
+	return clientOrderID
+}
+
+// parseClientOrderID returns the source a client order ID was generated for and, for strategy
+// orders, the strategy's ID. IDs generated before a prefix was configured are still recognized.
+// Other IDs return "".
+func (s *TradingService) parseClientOrderID(clientOrderID string) (models.OrderSource, string) {
+	if s.clientOrderIDPrefix != "" {
+		if rest, ok := strings.CutPrefix(clientOrderID, s.clientOrderIDPrefix); ok {
+			if source, strategyID := parseTaggedClientOrderID(rest); source != "" {
+				return source, strategyID
+			}
+		}
+	}
+	return parseTaggedClientOrderID(clientOrderID)
+}
+
+// parseTaggedClientOrderID parses a client order ID without its prefix
+func parseTaggedClientOrderID(clientOrderID string) (models.OrderSource, string) {
+	tag, rest, ok := strings.Cut(clientOrderID, "-")
+	if !ok {
+		return "", ""
+	}
+	for source, sourceTag := range clientOrderIDTags {
+		if tag != sourceTag {
+			continue
+		}
+		if source == models.OrderSourceManual || source == models.OrderSourceTemplate {
+			return source, ""
+		}
+		encoded, _, _ := strings.Cut(rest, "-")
+		if id, ok := decodeStrategyID(encoded); ok {
+			return source, id.Hex()
+		}
+		return "", ""
+	}
+	return "", ""
+}
+
+// strategyIDOf returns the ID of the grid, DCA, conditional or scheduled order, or calendar
+// spread, encoded in a client order ID generated for it (e.g. grid-<id>-3), or "" for any other
+// client order ID
+func (s *TradingService) strategyIDOf(clientOrderID string) string {
+	_, strategyID := s.parseClientOrderID(clientOrderID)
+	return strategyID
+}
+
+// sourceOf returns the source a client order ID was generated for; orders with any other ID were
+// placed outside the service
+func (s *TradingService) sourceOf(clientOrderID string) models.OrderSource {
+	if source, _ := s.parseClientOrderID(clientOrderID); source != "" {
+		return source
+	}
+	return models.OrderSourceExternal
+}
+
+// base62 writes b as a base 62 number, zero padded to the length of a 12 byte value
+func base62(b []byte) string {
+	encoded := new(big.Int).SetBytes(b).Text(62)
+	if len(encoded) < compactIDLength {
+		encoded = strings.Repeat("0", compactIDLength-len(encoded)) + encoded
+	}
+	return encoded
+}
+
+// decodeStrategyID parses a strategy ID written in hex or, with a prefix configured, base 62
+func decodeStrategyID(encoded string) (primitive.ObjectID, bool) {
+	switch len(encoded) {
+	case 24:
+		id, err := primitive.ObjectIDFromHex(encoded)
+		return id, err == nil
+	case compactIDLength:
+		n, ok := new(big.Int).SetString(encoded, 62)
+		if !ok || n.BitLen() > 96 {
+			return primitive.NilObjectID, false
+		}
+		var id primitive.ObjectID
+		n.FillBytes(id[:])
+		return id, true
+	}
+	return primitive.NilObjectID, false
+}
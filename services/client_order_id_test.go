@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"futures-options/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCreateFuturesOrderTagsClientOrderID(t *testing.T) {
+	for _, prefix := range []string{"", "fo-"} {
+		s, mock, _ := newTestService(t)
+		s.SetClientOrderIDPrefix(prefix)
+
+		_, err := s.CreateFuturesOrder(context.Background(), &CreateFuturesOrderRequest{
+			Symbol:    "BTCUSDT",
+			Side:      "BUY",
+			OrderType: "LIMIT",
+			Quantity:  decimal.RequireFromString("0.002"),
+			Price:     decimal.RequireFromString("50000"),
+		})
+		if err != nil {
+			t.Fatalf("prefix %q: CreateFuturesOrder: %v", prefix, err)
+		}
+		calls := mock.CallsTo("CreateFuturesOrder")
+		if len(calls) != 1 {
+			t.Fatalf("prefix %q: CreateFuturesOrder called %d times, want 1", prefix, len(calls))
+		}
+		sent := calls[0].Args[6].(string)
+		if !strings.HasPrefix(sent, prefix+"man-") || !clientOrderIDPattern.MatchString(sent) {
+			t.Errorf("prefix %q: sent client order ID %q", prefix, sent)
+		}
+		if source := s.sourceOf(sent); source != models.OrderSourceManual {
+			t.Errorf("prefix %q: source of %q = %s, want manual", prefix, sent, source)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/logging"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// marketOf returns the market of an order or position, treating unset as USDⓈ-M
+func marketOf(m models.Market) models.Market {
+	if m == "" {
+		return models.MarketUSDM
+	}
+	return m
+}
+
+// checkCoinM rejects COIN-M requests in paper trading mode, which only simulates USDⓈ-M
+func (s *TradingService) checkCoinM() error {
+	if s.Paper() {
+		return fmt.Errorf("COIN-M futures are %w", ErrPaperUnsupported)
+	}
+	return nil
+}
+
+// coinMarginAsset returns the coin a COIN-M symbol is margined in (BTC for BTCUSD_PERP)
+func coinMarginAsset(symbol string) string {
+	pair, _, _ := strings.Cut(symbol, "_")
+	return strings.TrimSuffix(pair, "USD")
+}
+
+// createCoinMOrder places an advanced order on the COIN-M (delivery) API; req.Quantity is a
+// number of contracts
+func (s *TradingService) createCoinMOrder(ctx context.Context, req *AdvancedOrderRequest) (*models.FuturesOrder, error) {
+	if err := s.checkCoinM(); err != nil {
+		return nil, err
+	}
+	if err := s.checkRiskLimits(ctx, []riskOrder{advancedRiskOrder(req)}, req.OverrideRiskLimits); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	binanceOrder, err := s.api(ctx).CreateDeliveryOrder(ctx, &binance.AdvancedOrderRequest{
+		Symbol:          req.Symbol,
+		Side:            req.Side,
+		OrderType:       req.OrderType,
+		Quantity:        req.Quantity,
+		Price:           req.Price,
+		StopPrice:       req.StopPrice,
+		ActivationPrice: req.ActivationPrice,
+		CallbackRate:    req.CallbackRate,
+		Leverage:        req.Leverage,
+		PositionSide:    req.PositionSide,
+		TimeInForce:     req.TimeInForce,
+		WorkingType:     req.WorkingType,
+		ReduceOnly:      req.ReduceOnly,
+		ClosePosition:   req.ClosePosition,
+		ClientOrderID:   req.ClientOrderID,
+	})
+	s.recordAudit(ctx, models.AuditOrderCreate, req.Symbol, req, binanceOrder, err, start)
+	if err != nil {
+		s.notifyOrderRejected(ctx, req.Symbol, err)
+		return nil, fmt.Errorf("failed to create order on Binance: %w", err)
+	}
+	logging.FromContext(ctx).Info("coin-m order placed", "symbol", req.Symbol, "binance_order_id", binanceOrder.OrderID, "status", binanceOrder.Status)
+
+	now := time.Now()
+	order := &models.FuturesOrder{
+		ID:              primitive.NewObjectID(),
+		Symbol:          req.Symbol,
+		Market:          models.MarketCoinM,
+		Side:            models.OrderSide(req.Side),
+		OrderType:       models.OrderType(req.OrderType),
+		Quantity:        req.Quantity.InexactFloat64(),
+		Price:           req.Price.InexactFloat64(),
+		StopPrice:       req.StopPrice.InexactFloat64(),
+		ActivationPrice: req.ActivationPrice.InexactFloat64(),
+		CallbackRate:    req.CallbackRate,
+		Leverage:        req.Leverage,
+		PositionSide:    models.PositionSide(req.PositionSide),
+		TimeInForce:     models.TimeInForce(req.TimeInForce),
+		WorkingType:     models.WorkingType(req.WorkingType),
+		ReduceOnly:      req.ReduceOnly,
+		ClosePosition:   req.ClosePosition,
+		ClientOrderID:   req.ClientOrderID,
+		Source:          req.Source,
+		BinanceOrderID:  binanceOrder.OrderID,
+		Status:          string(binanceOrder.Status),
+		RawResponse:     s.rawResponse(binanceOrder),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	createDeliveryOrderFill(binanceOrder).apply(order)
+	return s.saveFuturesOrder(ctx, order)
+}
+
+// CancelCoinMOrders cancels COIN-M orders by Binance or client order ID
+func (s *TradingService) CancelCoinMOrders(ctx context.Context, symbol string, orderIDs []int64, clientOrderIDs []string) error {
+	if err := s.checkCoinM(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	canceled, err := s.api(ctx).CancelDeliveryOrders(ctx, symbol, orderIDs, clientOrderIDs)
+	s.recordAudit(ctx, models.AuditOrderCancel, symbol, map[string]interface{}{
+		"market":           models.MarketCoinM,
+		"symbol":           symbol,
+		"order_ids":        orderIDs,
+		"client_order_ids": clientOrderIDs,
+	}, canceled, err, start)
+	if err != nil {
+		return fmt.Errorf("failed to cancel coin-m orders: %w", err)
+	}
+
+	if err := s.repos.FuturesOrders.SetStatus(ctx, symbol, orderIDs, clientOrderIDs, "CANCELED"); err != nil {
+		return err
+	}
+	for _, resp := range canceled {
+		raw := s.rawResponse(resp)
+		if raw == nil {
+			continue
+		}
+		update := &repository.FuturesOrderUpdate{RawResponse: raw, UpdatedAt: time.Now()}
+		if _, err := s.repos.FuturesOrders.UpdateByRef(ctx, resp.OrderID, resp.ClientOrderID, update); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			logging.FromContext(ctx).Warn("failed to store cancel response", "symbol", resp.Symbol, "binance_order_id", resp.OrderID, "error", err)
+		}
+	}
+	return nil
+}
+
+// openOrderFills returns the status and fill of every open order of symbol on its market, by order ID
+func (s *TradingService) openOrderFills(ctx context.Context, market models.Market, symbol string) (map[int64]*orderFill, error) {
+	open := make(map[int64]*orderFill)
+	if marketOf(market) == models.MarketCoinM {
+		orders, err := s.api(ctx).ListOpenDeliveryOrders(ctx, symbol)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range orders {
+			open[o.OrderID] = deliveryOrderFill(o)
+		}
+		return open, nil
+	}
+
+	orders, err := s.listOpenFuturesOrders(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range orders {
+		open[o.OrderID] = futuresOrderFill(o)
+	}
+	return open, nil
+}
+
+// liveOrderFill queries the current status and fill of a single order on its market
+func (s *TradingService) liveOrderFill(ctx context.Context, market models.Market, symbol string, orderID int64) (*orderFill, error) {
+	if marketOf(market) == models.MarketCoinM {
+		order, err := s.api(ctx).GetDeliveryOrder(ctx, symbol, orderID)
+		if err != nil {
+			return nil, err
+		}
+		return deliveryOrderFill(order), nil
+	}
+	order, err := s.api(ctx).GetFuturesOrder(ctx, symbol, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return futuresOrderFill(order), nil
+}
+
+// syncCoinMPositions stores the open COIN-M positions. Binance reports the unrealized PnL in the
+// margin coin; the notional is converted to coin as well, since a contract is worth a fixed USD
+// amount: contracts * contract size / mark price.
+func (s *TradingService) syncCoinMPositions(ctx context.Context) (*PositionSyncSummary, error) {
+	if err := s.checkCoinM(); err != nil {
+		return nil, err
+	}
+	binancePositions, err := s.api(ctx).GetDeliveryPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coin-m positions from Binance: %w", err)
+	}
+
+	summary := &PositionSyncSummary{Market: models.MarketCoinM}
+	accountID := s.accountID(ctx)
+	var positions []*models.Position
+	var keep []string
+	for _, bp := range binancePositions {
+		var p decimalParser
+		contracts := p.float("positionAmt", bp.PositionAmt)
+		entryPrice := p.float("entryPrice", bp.EntryPrice)
+		markPrice := p.float("markPrice", bp.MarkPrice)
+		unrealizedPnl := p.float("unRealizedProfit", bp.UnRealizedProfit)
+		leverage := p.int("leverage", bp.Leverage)
+		if p.err != nil {
+			summary.skip(ctx, bp.Symbol, p.err)
+			keep = append(keep, bp.Symbol)
+			continue
+		}
+		if contracts == 0 {
+			continue
+		}
+		contractSize, err := s.api(ctx).GetContractSize(ctx, bp.Symbol)
+		if err != nil {
+			summary.skip(ctx, bp.Symbol, fmt.Errorf("failed to get contract size: %w", err))
+			keep = append(keep, bp.Symbol)
+			continue
+		}
+
+		var notional float64
+		if markPrice > 0 {
+			notional = math.Abs(contracts) * contractSize / markPrice
+		}
+
+		positions = append(positions, &models.Position{
+			Symbol:        bp.Symbol,
+			Type:          "FUTURES",
+			Market:        models.MarketCoinM,
+			Side:          positionSideOf(bp.PositionSide),
+			Quantity:      contracts,
+			EntryPrice:    entryPrice,
+			CurrentPrice:  markPrice,
+			UnrealizedPnl: unrealizedPnl,
+			Leverage:      leverage,
+			ContractSize:  contractSize,
+			Notional:      notional,
+			MarginAsset:   coinMarginAsset(bp.Symbol),
+			AccountID:     accountID,
+			UpdatedAt:     time.Now(),
+		})
+	}
+
+	// Upserts and removals commit together, so a failed sync leaves the previous positions
+	err = s.repos.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+		var txErr error
+		summary.Upserted, summary.Cleared, txErr = s.repos.Positions.SyncMarket(ctx, models.MarketCoinM, accountID, positions, keep)
+		return txErr
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update positions: %w", err)
+	}
+	return summary, nil
+}
+
+// GetCoinMAccount returns the COIN-M futures account over REST; COIN-M has no WS-API account methods
+func (s *TradingService) GetCoinMAccount(ctx context.Context) (interface{}, error) {
+	if err := s.checkCoinM(); err != nil {
+		return nil, err
+	}
+	return s.api(ctx).GetDeliveryAccount(ctx)
+}
+
+// GetCoinMBalance returns the COIN-M futures balance of every margin coin
+func (s *TradingService) GetCoinMBalance(ctx context.Context) (interface{}, error) {
+	if err := s.checkCoinM(); err != nil {
+		return nil, err
+	}
+	return s.api(ctx).GetDeliveryBalance(ctx)
+}
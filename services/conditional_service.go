@@ -0,0 +1,435 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// priceStreamRetryDelay is how long to wait before reconnecting the price stream
+	priceStreamRetryDelay = 5 * time.Second
+	// conditionalSubmitTimeout bounds the submission of a triggered order
+	conditionalSubmitTimeout = 30 * time.Second
+	// conditionalClientIDTag marks the client order IDs generated for triggered orders
+	conditionalClientIDTag = "cond"
+)
+
+var (
+	// ErrConditionalNotFound is returned when no conditional order matches the given ID
+	ErrConditionalNotFound = errors.New("conditional order not found")
+	// ErrConditionalNotPending is returned when canceling a condition that already triggered or ended
+	ErrConditionalNotPending = errors.New("conditional order is no longer pending")
+)
+
+// Comparators a condition can use
+const (
+	ComparatorGTE = ">="
+	ComparatorLTE = "<="
+	ComparatorGT  = ">"
+	ComparatorLT  = "<"
+)
+
+var (
+	comparators         = []string{ComparatorGTE, ComparatorLTE, ComparatorGT, ComparatorLT}
+	priceSources        = []string{models.PriceSourceMark, models.PriceSourceContract}
+	conditionalStatuses = []string{
+		string(models.ConditionalPending), string(models.ConditionalTriggering), string(models.ConditionalTriggered),
+		string(models.ConditionalFailed), string(models.ConditionalCanceled),
+	}
+)
+
+// CreateConditionalOrderRequest holds an order back until symbol's price meets the condition
+type CreateConditionalOrderRequest struct {
+	Symbol       string  `json:"symbol"`     // symbol whose price is watched; may differ from order.symbol
+	Comparator   string  `json:"comparator"` // >=, <=, > or <
+	TriggerPrice float64 `json:"trigger_price"`
+	PriceSource  string  `json:"price_source,omitempty"` // MARK_PRICE (default) or CONTRACT_PRICE
+	// Group links conditions: the first one to trigger cancels the others
+	Group string               `json:"group,omitempty"`
+	Order AdvancedOrderRequest `json:"order"`
+}
+
+// UnmarshalJSON takes the trigger_price as JSON numbers or decimal strings
+func (r *CreateConditionalOrderRequest) UnmarshalJSON(data []byte) error {
+	type plain CreateConditionalOrderRequest
+	return decodeDecimalFields(data, (*plain)(r))
+}
+
+// Validate checks the condition and the order to fire
+func (r *CreateConditionalOrderRequest) Validate() error {
+	v := &validator{}
+	v.required("symbol", r.Symbol)
+	v.required("comparator", r.Comparator)
+	v.oneOf("comparator", r.Comparator, comparators...)
+	v.positive("trigger_price", r.TriggerPrice)
+	v.oneOf("price_source", r.PriceSource, priceSources...)
+	r.Order.validate(v, "order.")
+	return v.err()
+}
+
+// conditionalEngine holds the pending conditions by watched symbol
+type conditionalEngine struct {
+	mu      sync.Mutex
+	pending map[string][]*models.ConditionalOrder
+}
+
+// conditionMet reports whether price satisfies the condition
+func conditionMet(c *models.ConditionalOrder, price float64) bool {
+	switch c.Comparator {
+	case ComparatorGTE:
+		return price >= c.TriggerPrice
+	case ComparatorLTE:
+		return price <= c.TriggerPrice
+	case ComparatorGT:
+		return price > c.TriggerPrice
+	case ComparatorLT:
+		return price < c.TriggerPrice
+	}
+	return false
+}
+
+// CreateConditionalOrder stores a condition; it is evaluated from the next price update on
+func (s *TradingService) CreateConditionalOrder(ctx context.Context, req *CreateConditionalOrderRequest) (*models.ConditionalOrder, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	principal := PrincipalFromContext(ctx)
+	if req.Order.OverrideRiskLimits && !s.canOverrideRiskLimits(principal) {
+		return nil, ErrRiskOverrideForbidden
+	}
+
+	now := time.Now()
+	c := &models.ConditionalOrder{
+		ID:           primitive.NewObjectID(),
+		Symbol:       strings.ToUpper(req.Symbol),
+		Comparator:   req.Comparator,
+		TriggerPrice: req.TriggerPrice,
+		PriceSource:  req.PriceSource,
+		Group:        req.Group,
+		Order:        orderTemplate(&req.Order),
+		Status:       models.ConditionalPending,
+		CreatedBy:    principal,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if c.PriceSource == "" {
+		c.PriceSource = models.PriceSourceMark
+	}
+	// A fixed client order ID lets the submitted order be found after an interrupted trigger
+	if c.Order.ClientOrderID == "" {
+		c.Order.ClientOrderID = s.strategyClientOrderID(models.OrderSourceConditional, c.ID, 0)
+	}
+
+	if err := s.repos.Conditional.Insert(ctx, c); err != nil {
+		return nil, fmt.Errorf("failed to save conditional order: %w", err)
+	}
+	s.trackConditional(c)
+	slog.Info("conditional order created", "conditional_id", c.ID.Hex(), "symbol", c.Symbol,
+		"comparator", c.Comparator, "trigger_price", c.TriggerPrice, "price_source", c.PriceSource)
+	return c, nil
+}
+
+// ListConditionalOrders returns conditional orders newest first, optionally by status
+func (s *TradingService) ListConditionalOrders(ctx context.Context, status string) ([]*models.ConditionalOrder, error) {
+	status = strings.ToUpper(status)
+	v := &validator{}
+	v.oneOf("status", status, conditionalStatuses...)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return s.repos.Conditional.List(ctx, models.ConditionalStatus(status))
+}
+
+// GetConditionalOrder returns one conditional order
+func (s *TradingService) GetConditionalOrder(ctx context.Context, id string) (*models.ConditionalOrder, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	c, err := s.repos.Conditional.FindByID(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrConditionalNotFound
+	}
+	return c, err
+}
+
+// CancelConditionalOrder cancels a pending condition; its group siblings are left alone
+func (s *TradingService) CancelConditionalOrder(ctx context.Context, id string) (*models.ConditionalOrder, error) {
+	c, err := s.GetConditionalOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	ok, err := s.repos.Conditional.Transition(ctx, c.ID, models.ConditionalPending, &repository.ConditionalOrderUpdate{
+		Status:    models.ConditionalCanceled,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrConditionalNotPending
+	}
+	s.untrackConditional(c.ID)
+	c.Status = models.ConditionalCanceled
+	c.UpdatedAt = now
+	return c, nil
+}
+
+// StartConditionalOrders loads the pending conditions and evaluates them against the live price
+// streams until ctx is done. Conditions interrupted while their order was being submitted are
+// marked FAILED rather than submitted again.
+func (s *TradingService) StartConditionalOrders(ctx context.Context) error {
+	interrupted, err := s.repos.Conditional.List(ctx, models.ConditionalTriggering)
+	if err != nil {
+		return fmt.Errorf("failed to load conditional orders: %w", err)
+	}
+	for _, c := range interrupted {
+		msg := fmt.Sprintf("interrupted while submitting; check for an order with client order ID %s before recreating", c.Order.ClientOrderID)
+		if _, err := s.repos.Conditional.Transition(ctx, c.ID, models.ConditionalTriggering, &repository.ConditionalOrderUpdate{
+			Status:    models.ConditionalFailed,
+			Error:     msg,
+			UpdatedAt: time.Now(),
+		}); err != nil {
+			return fmt.Errorf("failed to update conditional order: %w", err)
+		}
+		slog.Warn("conditional order was interrupted while triggering", "conditional_id", c.ID.Hex(), "client_order_id", c.Order.ClientOrderID)
+	}
+
+	if err := s.loadPendingConditionals(ctx); err != nil {
+		return err
+	}
+
+	s.runBackground(func() {
+		for {
+			err := s.binanceClient.SubscribePrices(ctx, func(updates []binance.PriceUpdate) {
+				s.evaluateConditionals(ctx, updates)
+			})
+			if err != nil {
+				slog.Warn("conditional order price stream failed, reconnecting", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(priceStreamRetryDelay):
+			}
+		}
+	})
+	return nil
+}
+
+// loadPendingConditionals replaces the in-memory conditions with the stored pending ones
+func (s *TradingService) loadPendingConditionals(ctx context.Context) error {
+	pending, err := s.repos.Conditional.List(ctx, models.ConditionalPending)
+	if err != nil {
+		return fmt.Errorf("failed to load conditional orders: %w", err)
+	}
+	bySymbol := make(map[string][]*models.ConditionalOrder)
+	for _, c := range pending {
+		bySymbol[c.Symbol] = append(bySymbol[c.Symbol], c)
+	}
+	s.conditional.mu.Lock()
+	s.conditional.pending = bySymbol
+	s.conditional.mu.Unlock()
+	return nil
+}
+
+func (s *TradingService) trackConditional(c *models.ConditionalOrder) {
+	s.conditional.mu.Lock()
+	defer s.conditional.mu.Unlock()
+	if s.conditional.pending == nil {
+		s.conditional.pending = make(map[string][]*models.ConditionalOrder)
+	}
+	s.conditional.pending[c.Symbol] = append(s.conditional.pending[c.Symbol], c)
+}
+
+func (s *TradingService) untrackConditional(id primitive.ObjectID) {
+	s.conditional.mu.Lock()
+	defer s.conditional.mu.Unlock()
+	s.removeConditionalsLocked(func(c *models.ConditionalOrder) bool { return c.ID == id })
+}
+
+// removeConditionalsLocked drops the conditions matching remove. s.conditional.mu must be held.
+func (s *TradingService) removeConditionalsLocked(remove func(c *models.ConditionalOrder) bool) {
+	for symbol, list := range s.conditional.pending {
+		kept := list[:0]
+		for _, c := range list {
+			if !remove(c) {
+				kept = append(kept, c)
+			}
+		}
+		if len(kept) == 0 {
+			delete(s.conditional.pending, symbol)
+		} else {
+			s.conditional.pending[symbol] = kept
+		}
+	}
+}
+
+// evaluateConditionals fires the conditions met by updates. A met condition and its group
+// siblings leave the pending set at once, so a group fires at most one order.
+func (s *TradingService) evaluateConditionals(ctx context.Context, updates []binance.PriceUpdate) {
+	type firing struct {
+		c     *models.ConditionalOrder
+		price float64
+	}
+	var fire []firing
+	// While trading is paused, conditions that would add exposure stay pending
+	paused := s.tradingPaused(ctx)
+
+	s.conditional.mu.Lock()
+	for _, u := range updates {
+		for _, c := range s.conditional.pending[u.Symbol] {
+			if c.PriceSource != string(u.Source) || !conditionMet(c, u.Price) {
+				continue
+			}
+			if paused && !c.Order.ReduceOnly && !c.Order.ClosePosition {
+				continue
+			}
+			fire = append(fire, firing{c: c, price: u.Price})
+			s.removeConditionalsLocked(func(other *models.ConditionalOrder) bool {
+				return other.ID == c.ID || c.Group != "" && other.Group == c.Group
+			})
+			break // the list was modified; remaining conditions are checked on the next update
+		}
+	}
+	s.conditional.mu.Unlock()
+
+	for _, f := range fire {
+		f := f
+		s.runBackground(func() {
+			s.triggerConditional(context.WithoutCancel(ctx), f.c, f.price)
+		})
+	}
+}
+
+// triggerConditional claims c, cancels its group siblings and submits its order, recording the
+// outcome. The claim only succeeds while c is still PENDING, so the order is submitted at most once.
+func (s *TradingService) triggerConditional(ctx context.Context, c *models.ConditionalOrder, price float64) {
+	log := slog.With("conditional_id", c.ID.Hex(), "symbol", c.Symbol)
+	now := time.Now()
+	// The claim and the group cancellation commit together where transactions are supported; if
+	// the siblings cannot be canceled the claim is rolled back and c stays pending
+	var claimed bool
+	var canceled int64
+	var groupErr error
+	err := s.repos.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		claimed, err = s.repos.Conditional.Transition(ctx, c.ID, models.ConditionalPending, &repository.ConditionalOrderUpdate{
+			Status:         models.ConditionalTriggering,
+			TriggeredAt:    now,
+			TriggeredPrice: price,
+			UpdatedAt:      now,
+		})
+		if err != nil || !claimed || c.Group == "" {
+			return err
+		}
+		canceled, groupErr = s.repos.Conditional.TransitionGroup(ctx, c.Group, c.ID, models.ConditionalPending, &repository.ConditionalOrderUpdate{
+			Status:    models.ConditionalCanceled,
+			Error:     "canceled by " + c.ID.Hex() + " in group " + c.Group,
+			UpdatedAt: now,
+		})
+		if groupErr != nil && s.repos.Tx.Transactional() {
+			return fmt.Errorf("failed to cancel conditional order group: %w", groupErr)
+		}
+		return nil
+	})
+	if err != nil || !claimed {
+		if err != nil {
+			log.Error("failed to claim conditional order", "error", err)
+		}
+		// Canceled or claimed elsewhere meanwhile; restore any siblings that are still pending
+		if err := s.loadPendingConditionals(ctx); err != nil {
+			log.Error("failed to reload conditional orders", "error", err)
+		}
+		return
+	}
+	log.Info("conditional order triggered", "price", price, "comparator", c.Comparator, "trigger_price", c.TriggerPrice)
+	if groupErr != nil {
+		log.Error("failed to cancel conditional order group", "group", c.Group, "error", groupErr)
+	} else if canceled > 0 {
+		log.Info("conditional order group canceled", "group", c.Group, "canceled", canceled)
+	}
+
+	submitCtx, cancel := context.WithTimeout(WithPrincipal(ctx, c.CreatedBy), conditionalSubmitTimeout)
+	defer cancel()
+	req := advancedOrderRequest(c.Order)
+	req.Source = models.OrderSourceConditional
+	order, err := s.CreateAdvancedFuturesOrder(submitCtx, req)
+	update := &repository.ConditionalOrderUpdate{UpdatedAt: time.Now()}
+	if err != nil {
+		log.Warn("conditional order submission failed", "error", err)
+		update.Status = models.ConditionalFailed
+		update.Error = err.Error()
+	} else {
+		update.Status = models.ConditionalTriggered
+		update.OrderID = order.BinanceOrderID
+	}
+	if _, err := s.repos.Conditional.Transition(ctx, c.ID, models.ConditionalTriggering, update); err != nil {
+		log.Error("failed to record conditional order outcome", "error", err)
+	}
+}
+
+func orderTemplate(r *AdvancedOrderRequest) models.OrderTemplate {
+	return models.OrderTemplate{
+		Symbol:                  r.Symbol,
+		Side:                    r.Side,
+		OrderType:               r.OrderType,
+		Quantity:                r.Quantity.InexactFloat64(),
+		QuoteQuantity:           r.QuoteQuantity,
+		Price:                   r.Price.InexactFloat64(),
+		StopPrice:               r.StopPrice.InexactFloat64(),
+		ActivationPrice:         r.ActivationPrice.InexactFloat64(),
+		CallbackRate:            r.CallbackRate,
+		Leverage:                r.Leverage,
+		PositionSide:            r.PositionSide,
+		TimeInForce:             r.TimeInForce,
+		WorkingType:             r.WorkingType,
+		ReduceOnly:              r.ReduceOnly,
+		ClosePosition:           r.ClosePosition,
+		SelfTradePreventionMode: r.SelfTradePreventionMode,
+		PriceMatch:              r.PriceMatch,
+		NewOrderRespType:        r.NewOrderRespType,
+		ClientOrderID:           r.ClientOrderID,
+		GoodTillDate:            r.GoodTillDate,
+		OverrideRiskLimits:      r.OverrideRiskLimits,
+	}
+}
+
+func advancedOrderRequest(t models.OrderTemplate) *AdvancedOrderRequest {
+	return &AdvancedOrderRequest{
+		Symbol:                  t.Symbol,
+		Side:                    t.Side,
+		OrderType:               t.OrderType,
+		Quantity:                decimal.NewFromFloat(t.Quantity),
+		QuoteQuantity:           t.QuoteQuantity,
+		Price:                   decimal.NewFromFloat(t.Price),
+		StopPrice:               decimal.NewFromFloat(t.StopPrice),
+		ActivationPrice:         decimal.NewFromFloat(t.ActivationPrice),
+		CallbackRate:            t.CallbackRate,
+		Leverage:                t.Leverage,
+		PositionSide:            t.PositionSide,
+		TimeInForce:             t.TimeInForce,
+		WorkingType:             t.WorkingType,
+		ReduceOnly:              t.ReduceOnly,
+		ClosePosition:           t.ClosePosition,
+		SelfTradePreventionMode: t.SelfTradePreventionMode,
+		PriceMatch:              t.PriceMatch,
+		NewOrderRespType:        t.NewOrderRespType,
+		ClientOrderID:           t.ClientOrderID,
+		GoodTillDate:            t.GoodTillDate,
+		OverrideRiskLimits:      t.OverrideRiskLimits,
+	}
+}
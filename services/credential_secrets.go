@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"futures-options/models"
+	"futures-options/secrets"
+)
+
+// SetCipher configures the cipher used to encrypt stored secrets at rest
+func (s *TradingService) SetCipher(c *secrets.Cipher) {
+	s.cipher = c
+}
+
+// requireMasterKey refuses to store mainnet secrets when no master key is configured; config
+// validation only covers the network named by BINANCE_TESTNET
+func (s *TradingService) requireMasterKey(isTestnet bool) error {
+	if !isTestnet && s.cipher == nil {
+		return ErrMasterKeyRequired
+	}
+	return nil
+}
+
+// encryptSecret encrypts a secret for storage; without a cipher the value is stored as-is
+func (s *TradingService) encryptSecret(plaintext string) (string, error) {
+	if s.cipher == nil {
+		return plaintext, nil
+	}
+	ciphertext, err := s.cipher.Encrypt(plaintext)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	return ciphertext, nil
+}
+
+// decryptSecret returns the plaintext of a secret stored by encryptSecret
+func (s *TradingService) decryptSecret(stored string) (string, error) {
+	if !secrets.IsEncrypted(stored) {
+		return stored, nil
+	}
+	if s.cipher == nil {
+		return "", fmt.Errorf("secret is encrypted but no master key is configured")
+	}
+	return s.cipher.Decrypt(stored)
+}
+
+// decryptCredentials replaces the stored secret with its plaintext for internal use
+func (s *TradingService) decryptCredentials(credentials *models.APICredentials) error {
+	if !secrets.IsEncrypted(credentials.SecretKey) {
+		return nil
+	}
+	if s.cipher == nil {
+		return fmt.Errorf("credential %s is encrypted but no master key is configured", credentials.ID.Hex())
+	}
+	plaintext, err := s.cipher.Decrypt(credentials.SecretKey)
+	if err != nil {
+		return err
+	}
+	credentials.SecretKey = plaintext
+	return nil
+}
+
+// MigrateCredentialEncryption encrypts any credentials still stored in plaintext and returns how many were updated
+func (s *TradingService) MigrateCredentialEncryption(ctx context.Context) (int, error) {
+	if s.cipher == nil {
+		return 0, fmt.Errorf("no master key configured")
+	}
+
+	all, err := s.repos.Credentials.List(ctx, false)
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, credentials := range all {
+		if credentials.SecretKey == "" || secrets.IsEncrypted(credentials.SecretKey) {
+			continue
+		}
+
+		encrypted, err := s.encryptSecret(credentials.SecretKey)
+		if err != nil {
+			return migrated, err
+		}
+		credentials.SecretKey = encrypted
+		if err := s.repos.Credentials.Update(ctx, credentials); err != nil {
+			return migrated, fmt.Errorf("failed to encrypt credential %s: %w", credentials.ID.Hex(), err)
+		}
+		migrated++
+	}
+
+	return migrated, nil
+}
+
+// Operations reported by CredentialResponse.Operation when credentials are saved
+const (
+	CredentialCreated = "created"
+	CredentialUpdated = "updated"
+)
+
+// CredentialResponse is the API representation of stored credentials with secrets redacted
+type CredentialResponse struct {
+	ID               string                            `json:"id"`
+	APIKeyMasked     string                            `json:"api_key_masked"`
+	SecretKey        string                            `json:"secret_key"` // always redacted
+	IsActive         bool                              `json:"is_active"`
+	IsTestnet        bool                              `json:"is_testnet"`
+	AppliedLive      bool                              `json:"applied_live,omitempty"`
+	Operation        string                            `json:"operation,omitempty"` // CredentialCreated or CredentialUpdated, on save
+	ValidationStatus models.CredentialValidationStatus `json:"validation_status,omitempty"`
+	ValidatedAt      *time.Time                        `json:"validated_at,omitempty"`
+	Ed25519PublicKey string                            `json:"ed25519_public_key,omitempty"`
+	CreatedAt        time.Time                         `json:"created_at"`
+	UpdatedAt        time.Time                         `json:"updated_at"`
+}
+
+// NewCredentialResponse maps stored credentials to their redacted API representation
+func NewCredentialResponse(c *models.APICredentials) *CredentialResponse {
+	return &CredentialResponse{
+		ID:               c.ID.Hex(),
+		APIKeyMasked:     MaskSecret(c.APIKey),
+		SecretKey:        MaskSecret(c.SecretKey),
+		IsActive:         c.IsActive,
+		IsTestnet:        c.IsTestnet,
+		ValidationStatus: c.ValidationStatus,
+		ValidatedAt:      c.ValidatedAt,
+		Ed25519PublicKey: c.Ed25519PublicKey,
+		CreatedAt:        c.CreatedAt,
+		UpdatedAt:        c.UpdatedAt,
+	}
+}
+
+// MaskSecret keeps the first and last 4 characters of long values and fully redacts short ones
+func MaskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) <= 12 || secrets.IsEncrypted(value) {
+		return "****"
+	}
+	return value[:4] + "****" + value[len(value)-4:]
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrInvalidID is returned when a path ID is not a valid ObjectID
+	ErrInvalidID = errors.New("invalid id")
+	// ErrCredentialNotFound is returned when no credential matches the given ID
+	ErrCredentialNotFound = errors.New("credential not found")
+	// ErrCredentialActive is returned when trying to delete the active credential
+	ErrCredentialActive = errors.New("credential is active; activate another credential or deactivate it first")
+	// ErrCredentialValidation is returned when Binance rejects the submitted keys
+	ErrCredentialValidation = errors.New("credential validation failed")
+	// ErrMasterKeyRequired is returned when a mainnet credential would be stored or activated
+	// without CREDENTIALS_MASTER_KEY to encrypt its secrets
+	ErrMasterKeyRequired = errors.New("master key required: set CREDENTIALS_MASTER_KEY to store or activate mainnet credentials")
+)
+
+// UpdateAPICredentialsRequest holds the mutable fields of a stored credential
+type UpdateAPICredentialsRequest struct {
+	IsActive  *bool `json:"is_active,omitempty"`
+	IsTestnet *bool `json:"is_testnet,omitempty"`
+}
+
+// activateCredential marks id as the only active credential
+func (s *TradingService) activateCredential(ctx context.Context, id primitive.ObjectID) error {
+	if err := s.repos.Credentials.Activate(ctx, id); err != nil {
+		return fmt.Errorf("failed to activate credential: %w", err)
+	}
+	return nil
+}
+
+// GetAPICredential returns a stored credential by ID
+func (s *TradingService) GetAPICredential(ctx context.Context, id string) (*models.APICredentials, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	credentials, err := s.repos.Credentials.FindByID(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCredentialNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API credentials: %w", err)
+	}
+	if err := s.decryptCredentials(credentials); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// UpdateAPICredentials toggles is_active / is_testnet on a stored credential
+func (s *TradingService) UpdateAPICredentials(ctx context.Context, id string, req *UpdateAPICredentialsRequest) (*models.APICredentials, error) {
+	start := time.Now()
+	credentials, err := s.updateAPICredentials(ctx, id, req)
+	s.recordAudit(ctx, models.AuditCredentialUpdate, "", map[string]interface{}{"id": id, "update": req}, credentials, err, start)
+	return credentials, err
+}
+
+func (s *TradingService) updateAPICredentials(ctx context.Context, id string, req *UpdateAPICredentialsRequest) (*models.APICredentials, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	credentials, err := s.repos.Credentials.FindByID(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCredentialNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API credentials: %w", err)
+	}
+
+	credentials.UpdatedAt = time.Now()
+	if req.IsTestnet != nil {
+		credentials.IsTestnet = *req.IsTestnet
+	}
+	activate := req.IsActive != nil && *req.IsActive
+	if req.IsTestnet != nil || activate {
+		if err := s.requireMasterKey(credentials.IsTestnet); err != nil {
+			return nil, err
+		}
+	}
+	if req.IsActive != nil && !*req.IsActive {
+		credentials.IsActive = false
+	}
+
+	if err := s.repos.Credentials.Update(ctx, credentials); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrCredentialNotFound
+		}
+		return nil, fmt.Errorf("failed to update API credentials: %w", err)
+	}
+
+	if activate {
+		if err := s.activateCredential(ctx, objectID); err != nil {
+			return nil, err
+		}
+		credentials.IsActive = true
+	}
+
+	if err := s.decryptCredentials(credentials); err != nil {
+		return nil, err
+	}
+	if activate {
+		s.applyIfActive(credentials)
+	}
+	return credentials, nil
+}
+
+// DeleteAPICredentials removes a stored credential; the active credential cannot be deleted
+func (s *TradingService) DeleteAPICredentials(ctx context.Context, id string) error {
+	start := time.Now()
+	err := s.deleteAPICredentials(ctx, id)
+	s.recordAudit(ctx, models.AuditCredentialDelete, "", map[string]interface{}{"id": id}, nil, err, start)
+	return err
+}
+
+func (s *TradingService) deleteAPICredentials(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	existing, err := s.repos.Credentials.FindByID(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrCredentialNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load API credentials: %w", err)
+	}
+	if existing.IsActive {
+		return ErrCredentialActive
+	}
+
+	// Guard against activation racing with the delete
+	deleted, err := s.repos.Credentials.DeleteInactive(ctx, objectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete API credentials: %w", err)
+	}
+	if !deleted {
+		return ErrCredentialActive
+	}
+	s.releaseAccount(id)
+	return nil
+}
+
+// SetBackgroundContext sets the context used by background components restarted at runtime
+func (s *TradingService) SetBackgroundContext(ctx context.Context) {
+	s.bgCtx = ctx
+}
+
+// ApplyCredentials switches the live Binance clients to the given credentials
+func (s *TradingService) ApplyCredentials(credentials *models.APICredentials) {
+	s.credMu.Lock()
+	defer s.credMu.Unlock()
+
+	s.binanceClient.SetCredentials(credentials.APIKey, credentials.SecretKey, credentials.IsTestnet)
+	s.SetActiveAccount(credentials.ID.Hex())
+	s.releaseAccount(credentials.ID.Hex())
+	slog.Info("applied API keys", "api_key", MaskSecret(credentials.APIKey), "network", binance.NetworkName(credentials.IsTestnet))
+
+	s.SetCredentialSource(CredentialSourceDatabase)
+	s.resetWSAPIClient()
+
+	// The user data stream is bound to the old key's listen key
+	if s.bgCtx != nil {
+		s.stateMu.Lock()
+		old := s.wsClient
+		s.wsClient = nil
+		s.stateMu.Unlock()
+		if old != nil {
+			old.Close()
+		}
+		if err := s.StartUserDataStream(s.bgCtx); err != nil {
+			slog.Warn("failed to restart user data stream", "error", err)
+		}
+	}
+}
+
+// applyIfActive applies active credentials live and reports whether it did
+func (s *TradingService) applyIfActive(credentials *models.APICredentials) bool {
+	if !credentials.IsActive || credentials.APIKey == "" || credentials.SecretKey == "" {
+		return false
+	}
+	s.ApplyCredentials(credentials)
+	return true
+}
+
+// ReloadActiveCredentials re-reads the active credential from MongoDB and applies it live
+func (s *TradingService) ReloadActiveCredentials(ctx context.Context) (*models.APICredentials, error) {
+	start := time.Now()
+	credentials, err := s.GetActiveAPICredentials(ctx)
+	s.recordAudit(ctx, models.AuditCredentialReload, "", nil, credentials, err, start)
+	if err != nil {
+		return nil, err
+	}
+	s.ApplyCredentials(credentials)
+	return credentials, nil
+}
+
+// Network returns the network ("testnet" or "mainnet") the live clients are using
+func (s *TradingService) Network() string {
+	return binance.NetworkName(s.binanceClient.IsTestnet())
+}
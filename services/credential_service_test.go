@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/config"
+	"futures-options/models"
+	"futures-options/repository"
+	"futures-options/secrets"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const testFuturesTestnetURL = "https://futures-testnet.invalid"
+
+func TestApplyCredentialsNetwork(t *testing.T) {
+	tests := []struct {
+		name        string
+		envTestnet  bool
+		credentials *models.APICredentials // nil keeps the environment keys
+		wantTestnet bool
+	}{
+		{name: "env mainnet keys", envTestnet: false, wantTestnet: false},
+		{name: "env testnet keys", envTestnet: true, wantTestnet: true},
+		{name: "env mainnet, mainnet credential", envTestnet: false, credentials: &models.APICredentials{IsTestnet: false}, wantTestnet: false},
+		{name: "env mainnet, testnet credential", envTestnet: false, credentials: &models.APICredentials{IsTestnet: true}, wantTestnet: true},
+		{name: "env testnet, mainnet credential", envTestnet: true, credentials: &models.APICredentials{IsTestnet: false}, wantTestnet: false},
+		{name: "env testnet, testnet credential", envTestnet: true, credentials: &models.APICredentials{IsTestnet: true}, wantTestnet: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				BinanceAPIKey:            "env-key",
+				BinanceSecretKey:         "env-secret",
+				BinanceTestnet:           tt.envTestnet,
+				BinanceFuturesTestnetURL: testFuturesTestnetURL,
+			}
+			client := binance.NewClient(cfg)
+			// main applies environment testnet keys once it knows there are no stored ones
+			client.SetCredentials(cfg.BinanceAPIKey, cfg.BinanceSecretKey, cfg.BinanceTestnet)
+			s := NewTradingService(client, repository.NewMemoryRepositories())
+
+			wantKey := "env-key"
+			if tt.credentials != nil {
+				tt.credentials.ID = primitive.NewObjectID()
+				tt.credentials.APIKey = "db-key"
+				tt.credentials.SecretKey = "db-secret"
+				s.ApplyCredentials(tt.credentials)
+				wantKey = "db-key"
+			}
+
+			if got := client.IsTestnet(); got != tt.wantTestnet {
+				t.Errorf("IsTestnet = %v, want %v", got, tt.wantTestnet)
+			}
+			if got, want := s.Network(), binance.NetworkName(tt.wantTestnet); got != want {
+				t.Errorf("Network = %s, want %s", got, want)
+			}
+			effective := client.EffectiveConfig()
+			if effective.BinanceTestnet != tt.wantTestnet || effective.BinanceAPIKey != wantKey {
+				t.Errorf("effective config testnet %v key %q, want %v %q", effective.BinanceTestnet, effective.BinanceAPIKey, tt.wantTestnet, wantKey)
+			}
+			baseURL := client.Futures().BaseURL
+			if tt.wantTestnet && baseURL != testFuturesTestnetURL || !tt.wantTestnet && baseURL == testFuturesTestnetURL {
+				t.Errorf("futures base URL = %s with testnet %v", baseURL, tt.wantTestnet)
+			}
+			// The environment's flag is only a default; it must not change with the credential
+			if cfg.BinanceTestnet != tt.envTestnet {
+				t.Errorf("config testnet flag changed to %v", cfg.BinanceTestnet)
+			}
+		})
+	}
+}
+
+// failingCredentialsRepo fails the calls given an error, standing in for an unreachable MongoDB
+type failingCredentialsRepo struct {
+	*repository.MemoryCredentialsRepo
+	findErr, insertErr, updateErr error
+	// missFirstFind reports the first lookup as not found, as if a concurrent save had not
+	// stored the key yet
+	missFirstFind bool
+}
+
+func (r *failingCredentialsRepo) FindByAPIKey(ctx context.Context, apiKey string) (*models.APICredentials, error) {
+	if r.findErr != nil {
+		return nil, r.findErr
+	}
+	if r.missFirstFind {
+		r.missFirstFind = false
+		return nil, repository.ErrNotFound
+	}
+	return r.MemoryCredentialsRepo.FindByAPIKey(ctx, apiKey)
+}
+
+func (r *failingCredentialsRepo) Insert(ctx context.Context, credentials *models.APICredentials) error {
+	if r.insertErr != nil {
+		return r.insertErr
+	}
+	return r.MemoryCredentialsRepo.Insert(ctx, credentials)
+}
+
+func (r *failingCredentialsRepo) Update(ctx context.Context, credentials *models.APICredentials) error {
+	if r.updateErr != nil {
+		return r.updateErr
+	}
+	return r.MemoryCredentialsRepo.Update(ctx, credentials)
+}
+
+func TestSaveAPICredentials(t *testing.T) {
+	mongoDown := errors.New("server selection timeout")
+	rejected := errors.New("invalid API-key, IP, or permissions for action")
+
+	tests := []struct {
+		name     string
+		stored   bool // "stored-key" is already saved, on mainnet
+		repo     failingCredentialsRepo
+		validate error
+		req      SaveAPICredentialsRequest
+		// wantCreated is checked on success, with the number of stored credentials
+		wantCreated bool
+		wantStatus  models.CredentialValidationStatus
+		wantErr     error
+	}{
+		{
+			name:        "new key",
+			req:         SaveAPICredentialsRequest{APIKey: "new-key", SecretKey: "secret", IsTestnet: true},
+			wantCreated: true,
+			wantStatus:  models.CredentialValid,
+		},
+		{
+			name:       "existing key is updated",
+			stored:     true,
+			req:        SaveAPICredentialsRequest{APIKey: "stored-key", SecretKey: "rotated", IsTestnet: true},
+			wantStatus: models.CredentialValid,
+		},
+		{
+			name:        "validation skipped",
+			req:         SaveAPICredentialsRequest{APIKey: "new-key", SecretKey: "secret", SkipValidation: true, IsTestnet: true},
+			wantCreated: true,
+			wantStatus:  models.CredentialSkipped,
+		},
+		{
+			name:       "key saved concurrently is updated",
+			stored:     true,
+			repo:       failingCredentialsRepo{missFirstFind: true},
+			req:        SaveAPICredentialsRequest{APIKey: "stored-key", SecretKey: "rotated", IsTestnet: true},
+			wantStatus: models.CredentialValid,
+		},
+		{
+			name:     "rejected by Binance",
+			validate: rejected,
+			req:      SaveAPICredentialsRequest{APIKey: "new-key", SecretKey: "secret", IsTestnet: true},
+			wantErr:  ErrCredentialValidation,
+		},
+		{
+			name:    "lookup fails",
+			repo:    failingCredentialsRepo{findErr: mongoDown},
+			req:     SaveAPICredentialsRequest{APIKey: "new-key", SecretKey: "secret", IsTestnet: true},
+			wantErr: mongoDown,
+		},
+		{
+			name:    "insert fails",
+			repo:    failingCredentialsRepo{insertErr: mongoDown},
+			req:     SaveAPICredentialsRequest{APIKey: "new-key", SecretKey: "secret", IsTestnet: true},
+			wantErr: mongoDown,
+		},
+		{
+			name:    "update fails",
+			stored:  true,
+			repo:    failingCredentialsRepo{updateErr: mongoDown},
+			req:     SaveAPICredentialsRequest{APIKey: "stored-key", SecretKey: "rotated", IsTestnet: true},
+			wantErr: mongoDown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock, repos := newTestService(t)
+			ctx := context.Background()
+			repo := tt.repo
+			repo.MemoryCredentialsRepo = repository.NewMemoryCredentialsRepo()
+			repos.Credentials = &repo
+			var storedID primitive.ObjectID
+			if tt.stored {
+				storedID = primitive.NewObjectID()
+				stored := &models.APICredentials{ID: storedID, APIKey: "stored-key", SecretKey: "secret", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+				if err := repo.MemoryCredentialsRepo.Insert(ctx, stored); err != nil {
+					t.Fatalf("Insert: %v", err)
+				}
+			}
+			mock.ValidateAPIKeysFunc = func(ctx context.Context, apiKey, secretKey string, testnet bool) error {
+				return tt.validate
+			}
+
+			req := tt.req
+			saved, err := s.SaveAPICredentials(ctx, &req)
+			stored, _ := repo.MemoryCredentialsRepo.List(ctx, false)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				for _, c := range stored {
+					if c.APIKey != "stored-key" || c.SecretKey != "secret" {
+						t.Errorf("failed save left credentials %q / %q", c.APIKey, c.SecretKey)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SaveAPICredentials: %v", err)
+			}
+
+			if saved.Created != tt.wantCreated {
+				t.Errorf("created = %v, want %v", saved.Created, tt.wantCreated)
+			}
+			if len(stored) != 1 {
+				t.Fatalf("%d credentials stored, want 1", len(stored))
+			}
+			c := stored[0]
+			if tt.stored && c.ID != storedID {
+				t.Errorf("stored key was saved under a new ID")
+			}
+			if c.APIKey != req.APIKey || c.SecretKey != req.SecretKey || c.IsTestnet != req.IsTestnet {
+				t.Errorf("stored %q / %q testnet %v", c.APIKey, c.SecretKey, c.IsTestnet)
+			}
+			if c.ValidationStatus != tt.wantStatus {
+				t.Errorf("validation status = %s, want %s", c.ValidationStatus, tt.wantStatus)
+			}
+			if validated := c.ValidatedAt != nil; validated != !req.SkipValidation {
+				t.Errorf("validated_at = %v with validation skipped %v", c.ValidatedAt, req.SkipValidation)
+			}
+			validations := len(mock.CallsTo("ValidateAPIKeys"))
+			if req.SkipValidation && validations != 0 || !req.SkipValidation && validations != 1 {
+				t.Errorf("ValidateAPIKeys called %d times", validations)
+			}
+		})
+	}
+}
+
+func TestNewCredentialResponseRedactsKeys(t *testing.T) {
+	c := &models.APICredentials{ID: primitive.NewObjectID(), APIKey: "abcdEFGHIJKLMNOPwxyz", SecretKey: "secretSECRETsecret1234"}
+	body, err := json.Marshal(NewCredentialResponse(c))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	for _, key := range []string{c.APIKey, c.SecretKey} {
+		if strings.Contains(string(body), key) {
+			t.Errorf("response %s contains %q", body, key)
+		}
+	}
+	if !strings.Contains(string(body), `"api_key_masked":"abcd****wxyz"`) {
+		t.Errorf("response %s lacks the masked API key", body)
+	}
+}
+
+func TestMainnetCredentialsRequireMasterKey(t *testing.T) {
+	ctx := context.Background()
+	s, _, repos := newTestService(t)
+
+	_, err := s.SaveAPICredentials(ctx, &SaveAPICredentialsRequest{APIKey: "mainnet-key", SecretKey: "secret", IsActive: true, SkipValidation: true})
+	if !errors.Is(err, ErrMasterKeyRequired) {
+		t.Fatalf("SaveAPICredentials mainnet: err = %v, want ErrMasterKeyRequired", err)
+	}
+	if all, _ := repos.Credentials.List(ctx, false); len(all) != 0 {
+		t.Fatalf("stored %d credentials, want none", len(all))
+	}
+
+	testnet := &models.APICredentials{ID: primitive.NewObjectID(), APIKey: "testnet-key", SecretKey: "secret", IsTestnet: true}
+	mainnet := &models.APICredentials{ID: primitive.NewObjectID(), APIKey: "stored-key", SecretKey: "secret"}
+	for _, c := range []*models.APICredentials{testnet, mainnet} {
+		if err := repos.Credentials.Insert(ctx, c); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+	no, yes := false, true
+	if _, err := s.UpdateAPICredentials(ctx, testnet.ID.Hex(), &UpdateAPICredentialsRequest{IsTestnet: &no}); !errors.Is(err, ErrMasterKeyRequired) {
+		t.Errorf("switching to mainnet: err = %v, want ErrMasterKeyRequired", err)
+	}
+	if stored, _ := repos.Credentials.FindByID(ctx, testnet.ID); !stored.IsTestnet {
+		t.Errorf("refused switch still stored the credential as mainnet")
+	}
+	if _, err := s.UpdateAPICredentials(ctx, mainnet.ID.Hex(), &UpdateAPICredentialsRequest{IsActive: &yes}); !errors.Is(err, ErrMasterKeyRequired) {
+		t.Errorf("activating mainnet: err = %v, want ErrMasterKeyRequired", err)
+	}
+	if _, err := s.GenerateEd25519Key(ctx, mainnet.ID.Hex(), false); !errors.Is(err, ErrMasterKeyRequired) {
+		t.Errorf("GenerateEd25519Key mainnet: err = %v, want ErrMasterKeyRequired", err)
+	}
+
+	// Testnet credentials may still be stored in plaintext
+	if _, err := s.UpdateAPICredentials(ctx, testnet.ID.Hex(), &UpdateAPICredentialsRequest{IsActive: &yes}); err != nil {
+		t.Errorf("activating testnet: %v", err)
+	}
+	if _, err := s.GenerateEd25519Key(ctx, testnet.ID.Hex(), false); err != nil {
+		t.Errorf("GenerateEd25519Key testnet: %v", err)
+	}
+
+	cipher, err := secrets.NewCipher("0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	s.SetCipher(cipher)
+	if _, err := s.SaveAPICredentials(ctx, &SaveAPICredentialsRequest{APIKey: "mainnet-key", SecretKey: "secret", SkipValidation: true}); err != nil {
+		t.Fatalf("SaveAPICredentials with a master key: %v", err)
+	}
+	stored, err := repos.Credentials.FindByAPIKey(ctx, "mainnet-key")
+	if err != nil || !secrets.IsEncrypted(stored.SecretKey) {
+		t.Errorf("stored secret %q, err %v; want it encrypted", stored.SecretKey, err)
+	}
+}
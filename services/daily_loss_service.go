@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"futures-options/logging"
+	"futures-options/models"
+	"futures-options/notifications"
+)
+
+// dailyPnLRefreshInterval bounds how often order checks recompute the day's PnL from Binance
+const dailyPnLRefreshInterval = 10 * time.Second
+
+// dailyLossEventType tags the notification sent when the daily loss lock engages
+const dailyLossEventType = notifications.EventDailyLossLimit
+
+var (
+	// ErrDailyLossLocked is returned for position-increasing orders once the daily loss limit is hit
+	ErrDailyLossLocked = errors.New("daily loss limit reached, new positions are blocked until 00:00 UTC")
+	// ErrRiskResetForbidden is returned when a caller not allowed to clear the daily loss lock tries to
+	ErrRiskResetForbidden = errors.New("this API token may not reset the daily loss lock")
+)
+
+// realizedIncomeTypes are the income records counted towards the day's realized PnL
+var realizedIncomeTypes = map[string]bool{
+	models.IncomeTypeRealizedPnl: true,
+	models.IncomeTypeCommission:  true,
+	models.IncomeTypeFundingFee:  true,
+}
+
+// DailyPnL is an account's running PnL of the current UTC day and the state of its daily loss lock
+type DailyPnL struct {
+	Day           string     `json:"day"`                  // UTC date, YYYY-MM-DD
+	AccountID     string     `json:"account_id,omitempty"` // empty for the environment keys
+	RealizedPnl   float64    `json:"realized_pnl"`
+	UnrealizedPnl float64    `json:"unrealized_pnl"`
+	TotalPnl      float64    `json:"total_pnl"`
+	LossLimit     float64    `json:"loss_limit"`               // 0 when the limit is disabled
+	LockThreshold float64    `json:"lock_threshold,omitempty"` // total PnL at or below which new positions are blocked
+	Locked        bool       `json:"locked"`
+	LockedAt      *time.Time `json:"locked_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Paper         bool       `json:"paper,omitempty"`
+}
+
+// dailyLossState is guarded by TradingService.dailyLossMu. The limit applies to every account;
+// the PnL and the lock are kept per account.
+type dailyLossState struct {
+	limit    float64
+	accounts map[string]*accountDailyLoss // by account ID, "" for the environment keys
+}
+
+// accountDailyLoss is an account's PnL for the day and its lock level
+type accountDailyLoss struct {
+	pnl       DailyPnL
+	threshold float64 // lock level for pnl.Day; a reset moves it one more limit below the current PnL
+}
+
+// account returns the state of account id for day, starting a new UTC day unlocked with the full
+// limit
+func (d *dailyLossState) account(id, day string, paper bool) *accountDailyLoss {
+	if d.accounts == nil {
+		d.accounts = make(map[string]*accountDailyLoss)
+	}
+	state, ok := d.accounts[id]
+	if !ok {
+		state = &accountDailyLoss{}
+		d.accounts[id] = state
+	}
+	if state.pnl.Day != day {
+		state.pnl = DailyPnL{Day: day, AccountID: id, Paper: paper}
+		state.threshold = -d.limit
+	}
+	return state
+}
+
+// SetDailyLossLimit sets the loss (a positive amount in the margin asset) after which new positions
+// are blocked for the rest of the UTC day; 0 disables the limit. Each account is limited on its
+// own PnL.
+func (s *TradingService) SetDailyLossLimit(limit float64) {
+	s.dailyLossMu.Lock()
+	defer s.dailyLossMu.Unlock()
+	s.dailyLoss.limit = limit
+	for _, state := range s.dailyLoss.accounts {
+		state.threshold = -limit
+	}
+}
+
+// GetDailyPnL returns today's realized and unrealized PnL of the request's account and whether its
+// loss lock is engaged
+func (s *TradingService) GetDailyPnL(ctx context.Context) (*DailyPnL, error) {
+	return s.refreshDailyPnL(ctx, false)
+}
+
+// ResetDailyLossLock clears the request's account's lock early. The next lock engages after another full limit of
+// losses from the current PnL. Only principals in RISK_OVERRIDE_PRINCIPALS may reset it.
+func (s *TradingService) ResetDailyLossLock(ctx context.Context) (*DailyPnL, error) {
+	start := time.Now()
+	pnl, err := s.resetDailyLossLock(ctx)
+	s.recordAudit(ctx, models.AuditDailyLossReset, "", nil, pnl, err, start)
+	return pnl, err
+}
+
+func (s *TradingService) resetDailyLossLock(ctx context.Context) (*DailyPnL, error) {
+	principal := PrincipalFromContext(ctx)
+	if !s.canOverrideRiskLimits(principal) {
+		return nil, ErrRiskResetForbidden
+	}
+	if _, err := s.refreshDailyPnL(ctx, true); err != nil {
+		return nil, err
+	}
+
+	s.dailyLossMu.Lock()
+	state := s.dailyLoss.account(s.accountID(ctx), time.Now().UTC().Format("2006-01-02"), s.Paper())
+	state.pnl.Locked = false
+	state.pnl.LockedAt = nil
+	state.threshold = state.pnl.TotalPnl - s.dailyLoss.limit
+	if s.dailyLoss.limit > 0 {
+		state.pnl.LockThreshold = state.threshold
+	}
+	pnl := state.pnl
+	s.dailyLossMu.Unlock()
+
+	logging.FromContext(ctx).Warn("daily loss lock reset", "principal", principal, "account_id", pnl.AccountID, "total_pnl", pnl.TotalPnl)
+	return &pnl, nil
+}
+
+// checkDailyLoss rejects orders that may add exposure while the daily loss lock of the request's
+// account is engaged. Orders that only reduce a position always pass: reduce-only and
+// close-position orders, and hedge-mode orders closing a leg, which cannot be sent reduce-only.
+func (s *TradingService) checkDailyLoss(ctx context.Context, orders []riskOrder) error {
+	s.dailyLossMu.Lock()
+	limit := s.dailyLoss.limit
+	s.dailyLossMu.Unlock()
+	if limit <= 0 {
+		return nil
+	}
+
+	increasing := false
+	for _, o := range orders {
+		if !o.reduces() {
+			increasing = true
+			break
+		}
+	}
+	if !increasing {
+		return nil
+	}
+
+	pnl, err := s.refreshDailyPnL(ctx, false)
+	if err != nil {
+		return fmt.Errorf("failed to check daily loss limit: %w", err)
+	}
+	if pnl.Locked {
+		return fmt.Errorf("%w (today's PnL %s, lock at %s)", ErrDailyLossLocked, formatFloat(pnl.TotalPnl), formatFloat(pnl.LockThreshold))
+	}
+	return nil
+}
+
+// refreshDailyPnL recomputes today's PnL of the request's account from Binance unless the cached
+// value is recent enough, engaging the account's lock (and notifying) when the total falls to its
+// lock threshold. Binance is queried without holding dailyLossMu, so a slow call does not hold up
+// other accounts' order checks; only the result is published under it.
+func (s *TradingService) refreshDailyPnL(ctx context.Context, force bool) (*DailyPnL, error) {
+	accountID := s.accountID(ctx)
+	paper := s.Paper()
+	now := time.Now().UTC()
+	day := now.Format("2006-01-02")
+
+	s.dailyLossMu.Lock()
+	state := s.dailyLoss.account(accountID, day, paper)
+	if !force && !state.pnl.UpdatedAt.IsZero() && now.Sub(state.pnl.UpdatedAt) < dailyPnLRefreshInterval {
+		pnl := state.pnl
+		s.dailyLossMu.Unlock()
+		return &pnl, nil
+	}
+	s.dailyLossMu.Unlock()
+
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	income, err := s.api(ctx).GetIncomeHistory(ctx, dayStart)
+	if err != nil {
+		return nil, err
+	}
+	positions, err := s.api(ctx).GetFuturesPositions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var realized, unrealized float64
+	for _, record := range income {
+		if realizedIncomeTypes[record.IncomeType] {
+			amount, _ := strconv.ParseFloat(record.Income, 64)
+			realized += amount
+		}
+	}
+	for _, p := range positions {
+		amount, _ := strconv.ParseFloat(p.UnRealizedProfit, 64)
+		unrealized += amount
+	}
+
+	s.dailyLossMu.Lock()
+	state = s.dailyLoss.account(accountID, day, paper)
+	engaged := false
+	// A refresh that started later may have published first; its figures are kept
+	if !state.pnl.UpdatedAt.After(now) {
+		limit := s.dailyLoss.limit
+		state.pnl.RealizedPnl = realized
+		state.pnl.UnrealizedPnl = unrealized
+		state.pnl.TotalPnl = realized + unrealized
+		state.pnl.LossLimit = limit
+		state.pnl.UpdatedAt = now
+		if limit > 0 {
+			state.pnl.LockThreshold = state.threshold
+			if !state.pnl.Locked && state.pnl.TotalPnl <= state.threshold {
+				state.pnl.Locked = true
+				state.pnl.LockedAt = &now
+				engaged = true
+			}
+		}
+	}
+	pnl := state.pnl
+	s.dailyLossMu.Unlock()
+
+	if engaged {
+		s.notifyDailyLossLock(ctx, pnl)
+	}
+	return &pnl, nil
+}
+
+func (s *TradingService) notifyDailyLossLock(ctx context.Context, pnl DailyPnL) {
+	logging.FromContext(ctx).Warn("daily loss limit reached, blocking new positions",
+		"account_id", pnl.AccountID, "total_pnl", pnl.TotalPnl, "lock_threshold", pnl.LockThreshold)
+
+	s.notify(ctx, &notifications.Notification{
+		Title:     "Daily loss limit reached",
+		Message:   fmt.Sprintf("Today's PnL of %s crossed %s; new positions are blocked until 00:00 UTC or a reset", formatFloat(pnl.TotalPnl), formatFloat(pnl.LockThreshold)),
+		Priority:  notifications.PriorityHigh,
+		EventType: dailyLossEventType,
+		Fields: map[string]string{
+			"day":            pnl.Day,
+			"account_id":     pnl.AccountID,
+			"realized_pnl":   formatFloat(pnl.RealizedPnl),
+			"unrealized_pnl": formatFloat(pnl.UnrealizedPnl),
+			"loss_limit":     formatFloat(pnl.LossLimit),
+		},
+	})
+}
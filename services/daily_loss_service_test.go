@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"futures-options/binance/binancetest"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// lossIncome reports a realized loss of amount for the day
+func lossIncome(amount string) func(ctx context.Context, start time.Time) ([]*futures.IncomeHistory, error) {
+	return func(ctx context.Context, start time.Time) ([]*futures.IncomeHistory, error) {
+		return []*futures.IncomeHistory{{IncomeType: "REALIZED_PNL", Income: amount}}, nil
+	}
+}
+
+func TestDailyLossLockIsPerAccount(t *testing.T) {
+	s, active, _ := newTestService(t)
+	s.SetDailyLossLimit(100)
+	active.GetIncomeHistoryFunc = lossIncome("-150")
+	other := binancetest.NewMockClient(nil)
+	other.GetIncomeHistoryFunc = lossIncome("-20")
+	ctx := context.Background()
+	otherCtx := context.WithValue(ctx, accountKey{}, &selectedAccount{id: "other-account", client: other})
+	buy := []riskOrder{{Symbol: "BTCUSDT", Side: "BUY", Quantity: 1}}
+
+	if err := s.checkDailyLoss(ctx, buy); !errors.Is(err, ErrDailyLossLocked) {
+		t.Fatalf("active account: err = %v, want ErrDailyLossLocked", err)
+	}
+	// The other account's PnL is its own, and it is not blocked by the active account's lock
+	if err := s.checkDailyLoss(otherCtx, buy); err != nil {
+		t.Fatalf("other account: %v", err)
+	}
+	pnl, err := s.GetDailyPnL(otherCtx)
+	if err != nil {
+		t.Fatalf("GetDailyPnL: %v", err)
+	}
+	if pnl.AccountID != "other-account" || pnl.TotalPnl != -20 || pnl.Locked {
+		t.Errorf("other account PnL = %+v", pnl)
+	}
+	// The cached active account state is unchanged by the other account's refresh
+	if err := s.checkDailyLoss(ctx, buy); !errors.Is(err, ErrDailyLossLocked) {
+		t.Errorf("active account after other refresh: err = %v, want ErrDailyLossLocked", err)
+	}
+}
+
+func TestDailyLossLockLetsHedgeModeCloses(t *testing.T) {
+	s, mock, _ := newTestService(t)
+	s.SetDailyLossLimit(100)
+	mock.GetIncomeHistoryFunc = lossIncome("-150")
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		order   riskOrder
+		wantErr bool
+	}{
+		{"opening a long", riskOrder{Symbol: "BTCUSDT", Side: "BUY", PositionSide: "LONG", Quantity: 1}, true},
+		{"opening a short", riskOrder{Symbol: "BTCUSDT", Side: "SELL", PositionSide: "SHORT", Quantity: 1}, true},
+		{"one-way order", riskOrder{Symbol: "BTCUSDT", Side: "SELL", Quantity: 1}, true},
+		{"closing a long", riskOrder{Symbol: "BTCUSDT", Side: "SELL", PositionSide: "LONG", Quantity: 1}, false},
+		{"closing a short", riskOrder{Symbol: "BTCUSDT", Side: "BUY", PositionSide: "SHORT", Quantity: 1}, false},
+		{"reduce-only", riskOrder{Symbol: "BTCUSDT", Side: "SELL", Quantity: 1, ReduceOnly: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.checkDailyLoss(ctx, []riskOrder{tt.order})
+			if locked := errors.Is(err, ErrDailyLossLocked); locked != tt.wantErr {
+				t.Errorf("err = %v, want locked %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDailyLossRefreshDoesNotBlockChecks(t *testing.T) {
+	s, active, _ := newTestService(t)
+	s.SetDailyLossLimit(100)
+	other := binancetest.NewMockClient(nil)
+	ctx := context.Background()
+	otherCtx := context.WithValue(ctx, accountKey{}, &selectedAccount{id: "other-account", client: other})
+	buy := []riskOrder{{Symbol: "BTCUSDT", Side: "BUY", Quantity: 1}}
+	if err := s.checkDailyLoss(otherCtx, buy); err != nil {
+		t.Fatalf("other account: %v", err)
+	}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	active.GetIncomeHistoryFunc = func(ctx context.Context, start time.Time) ([]*futures.IncomeHistory, error) {
+		close(started)
+		<-release
+		return nil, nil
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.checkDailyLoss(ctx, buy) }()
+	<-started
+
+	checked := make(chan error, 1)
+	go func() { checked <- s.checkDailyLoss(otherCtx, buy) }()
+	select {
+	case err := <-checked:
+		if err != nil {
+			t.Errorf("other account: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("order check waited for another account's Binance call")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("active account: %v", err)
+	}
+}
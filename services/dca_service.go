@@ -0,0 +1,561 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/repository"
+	"futures-options/strategy"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// minDCAInterval is the shortest interval between the orders of an interval plan
+	minDCAInterval = time.Minute
+	// dcaRetryDelay is how long a plan waits after a failed order before trying again
+	dcaRetryDelay = time.Minute
+	// dcaClientIDTag marks the client order IDs of DCA orders
+	dcaClientIDTag = "dca"
+)
+
+var (
+	// ErrDCAPlanNotFound is returned when no DCA plan matches the given ID
+	ErrDCAPlanNotFound = errors.New("DCA plan not found")
+	// ErrDCAPlanState is returned when a plan cannot be paused, resumed or canceled from its status
+	ErrDCAPlanState = errors.New("DCA plan status does not allow this change")
+)
+
+var dcaStatuses = []string{
+	string(models.DCAActive), string(models.DCAPaused), string(models.DCACapped),
+	string(models.DCACompleted), string(models.DCACanceled),
+}
+
+// CreateDCAPlanRequest configures a dollar-cost-averaging plan. Each order is sized by notional or
+// quantity and placed every interval or each time the price moves price_step_percent against the
+// last fill, until the filled notional reaches max_exposure.
+type CreateDCAPlanRequest struct {
+	Symbol            string  `json:"symbol"`
+	Side              string  `json:"side"`                    // BUY accumulates a long, SELL a short
+	PositionSide      string  `json:"position_side,omitempty"` // LONG or SHORT in hedge mode
+	Leverage          int     `json:"leverage,omitempty"`
+	OrderNotional     float64 `json:"order_notional,omitempty"` // quote amount per order
+	OrderQuantity     float64 `json:"order_quantity,omitempty"` // or base quantity per order
+	Interval          string  `json:"interval,omitempty"`       // e.g. 4h
+	PriceStepPercent  float64 `json:"price_step_percent,omitempty"`
+	MaxExposure       float64 `json:"max_exposure"`
+	TakeProfitPercent float64 `json:"take_profit_percent,omitempty"` // from the blended entry
+}
+
+// Validate checks the plan's sizing, trigger and limits
+func (r *CreateDCAPlanRequest) Validate() error {
+	v := &validator{}
+	v.required("symbol", r.Symbol)
+	v.required("side", r.Side)
+	v.oneOf("side", r.Side, orderSides...)
+	v.oneOf("position_side", r.PositionSide, positionSides...)
+	v.leverage("leverage", r.Leverage)
+	v.nonNegative("order_notional", r.OrderNotional)
+	v.nonNegative("order_quantity", r.OrderQuantity)
+	if (r.OrderNotional > 0) == (r.OrderQuantity > 0) {
+		v.add("order_notional", RuleRequired, "exactly one of order_notional and order_quantity is required")
+	}
+	if r.Interval != "" {
+		if d, err := time.ParseDuration(r.Interval); err != nil {
+			v.add("interval", RuleType, "must be a duration such as 30m or 4h")
+		} else if d < minDCAInterval {
+			v.add("interval", RuleRange, "must be at least 1m")
+		}
+	}
+	if r.PriceStepPercent < 0 || r.PriceStepPercent >= 100 {
+		v.add("price_step_percent", RuleRange, "must be between 0 and 100")
+	}
+	if (r.Interval != "") == (r.PriceStepPercent > 0) {
+		v.add("interval", RuleRequired, "exactly one of interval and price_step_percent is required")
+	}
+	v.positive("max_exposure", r.MaxExposure)
+	if r.TakeProfitPercent < 0 || r.TakeProfitPercent >= 100 {
+		v.add("take_profit_percent", RuleRange, "must be between 0 and 100")
+	}
+	return v.err()
+}
+
+// CreateDCAPlan stores a plan and places its first order
+func (s *TradingService) CreateDCAPlan(ctx context.Context, req *CreateDCAPlanRequest) (*models.DCAPlan, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	p := newDCAPlan(req, time.Now())
+	if err := s.checkOrderAllowed(ctx, p.Symbol); err != nil {
+		return nil, err
+	}
+	p.CreatedBy = PrincipalFromContext(ctx)
+	if err := s.repos.DCAPlans.Insert(ctx, p); err != nil {
+		return nil, fmt.Errorf("failed to save DCA plan: %w", err)
+	}
+	slog.Info("DCA plan created", "dca_plan_id", p.ID.Hex(), "symbol", p.Symbol, "side", p.Side, "max_exposure", p.MaxExposure)
+
+	s.dcaMu.Lock()
+	s.stepDCAPlan(ctx, p)
+	s.dcaMu.Unlock()
+	s.valueDCAPlans(ctx, []*models.DCAPlan{p})
+	return p, nil
+}
+
+// newDCAPlan builds an active plan from a validated request
+func newDCAPlan(req *CreateDCAPlanRequest, now time.Time) *models.DCAPlan {
+	interval, _ := time.ParseDuration(req.Interval)
+	return &models.DCAPlan{
+		ID:                primitive.NewObjectID(),
+		Symbol:            strings.ToUpper(req.Symbol),
+		Side:              models.OrderSide(req.Side),
+		PositionSide:      models.PositionSide(req.PositionSide),
+		Leverage:          req.Leverage,
+		OrderNotional:     req.OrderNotional,
+		OrderQuantity:     req.OrderQuantity,
+		IntervalSeconds:   int64(interval / time.Second),
+		PriceStepPercent:  req.PriceStepPercent,
+		MaxExposure:       req.MaxExposure,
+		TakeProfitPercent: req.TakeProfitPercent,
+		Status:            models.DCAActive,
+		Orders:            []models.DCAOrder{},
+		RemainingBudget:   req.MaxExposure,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+}
+
+// ListDCAPlans returns DCA plans newest first, optionally by status, with their unrealized PnL
+func (s *TradingService) ListDCAPlans(ctx context.Context, status string) ([]*models.DCAPlan, error) {
+	status = strings.ToUpper(status)
+	v := &validator{}
+	v.oneOf("status", status, dcaStatuses...)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	plans, err := s.repos.DCAPlans.List(ctx, models.DCAStatus(status))
+	if err != nil {
+		return nil, err
+	}
+	s.valueDCAPlans(ctx, plans)
+	return plans, nil
+}
+
+// GetDCAPlan returns one DCA plan with its unrealized PnL
+func (s *TradingService) GetDCAPlan(ctx context.Context, id string) (*models.DCAPlan, error) {
+	p, err := s.findDCAPlan(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.valueDCAPlans(ctx, []*models.DCAPlan{p})
+	return p, nil
+}
+
+// PauseDCAPlan stops an active plan from placing orders; its take profit is still maintained
+func (s *TradingService) PauseDCAPlan(ctx context.Context, id string) (*models.DCAPlan, error) {
+	return s.setDCAPlanStatus(ctx, id, models.DCAPaused, models.DCAActive)
+}
+
+// ResumeDCAPlan lets a paused plan place orders again
+func (s *TradingService) ResumeDCAPlan(ctx context.Context, id string) (*models.DCAPlan, error) {
+	return s.setDCAPlanStatus(ctx, id, models.DCAActive, models.DCAPaused)
+}
+
+// CancelDCAPlan ends a plan and cancels its take profit order; the position is left open
+func (s *TradingService) CancelDCAPlan(ctx context.Context, id string) (*models.DCAPlan, error) {
+	return s.setDCAPlanStatus(ctx, id, models.DCACanceled, models.DCAActive, models.DCAPaused, models.DCACapped)
+}
+
+func (s *TradingService) setDCAPlanStatus(ctx context.Context, id string, to models.DCAStatus, from ...models.DCAStatus) (*models.DCAPlan, error) {
+	s.dcaMu.Lock()
+	defer s.dcaMu.Unlock()
+
+	p, err := s.findDCAPlan(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	allowed := false
+	for _, status := range from {
+		allowed = allowed || p.Status == status
+	}
+	if !allowed {
+		return nil, ErrDCAPlanState
+	}
+
+	if to == models.DCACanceled && p.TakeProfitOrderID != 0 {
+		if err := s.cancelDCATakeProfit(ctx, p); err != nil {
+			return nil, err
+		}
+	}
+	if to != models.DCACanceled || p.Status != models.DCACompleted {
+		p.Status = to
+	}
+	p.UpdatedAt = time.Now()
+	if err := s.repos.DCAPlans.Update(ctx, p); err != nil {
+		return nil, err
+	}
+	slog.Info("DCA plan status changed", "dca_plan_id", p.ID.Hex(), "status", p.Status)
+	s.valueDCAPlans(ctx, []*models.DCAPlan{p})
+	return p, nil
+}
+
+func (s *TradingService) findDCAPlan(ctx context.Context, id string) (*models.DCAPlan, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	p, err := s.repos.DCAPlans.FindByID(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDCAPlanNotFound
+	}
+	return p, err
+}
+
+// StartDCAPlans steps the running DCA plans every interval until ctx is done
+func (s *TradingService) StartDCAPlans(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	s.runBackground(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// A pass that has started finishes its orders even if shutdown begins
+				s.runDCAPlans(context.WithoutCancel(ctx))
+			}
+		}
+	})
+}
+
+// runDCAPlans steps every plan that can still place orders or has a take profit to watch
+func (s *TradingService) runDCAPlans(ctx context.Context) {
+	for _, status := range []models.DCAStatus{models.DCAActive, models.DCAPaused, models.DCACapped} {
+		plans, err := s.repos.DCAPlans.List(ctx, status)
+		if err != nil {
+			slog.Error("failed to load DCA plans", "error", err)
+			return
+		}
+		for _, listed := range plans {
+			s.dcaMu.Lock()
+			// Reload under the lock in case the plan was paused or canceled meanwhile
+			if p, err := s.repos.DCAPlans.FindByID(ctx, listed.ID); err != nil {
+				slog.Error("failed to load DCA plan", "dca_plan_id", listed.ID.Hex(), "error", err)
+			} else if p.Status == status {
+				s.stepDCAPlan(ctx, p)
+			}
+			s.dcaMu.Unlock()
+		}
+	}
+}
+
+// stepDCAPlan records the fills of p's orders, places its next order when due, keeps its take
+// profit in line with the position and saves it. s.dcaMu must be held.
+func (s *TradingService) stepDCAPlan(ctx context.Context, p *models.DCAPlan) {
+	ctx = WithPrincipal(ctx, p.CreatedBy)
+	log := slog.With("dca_plan_id", p.ID.Hex(), "symbol", p.Symbol)
+
+	s.syncDCAOrders(ctx, p)
+	s.syncDCATakeProfit(ctx, p)
+	if err := s.advanceDCAPlan(ctx, p); err != nil {
+		log.Warn("DCA plan step failed", "error", err)
+		p.LastError = err.Error()
+	}
+	p.UpdatedAt = time.Now()
+	if err := s.repos.DCAPlans.Update(ctx, p); err != nil {
+		log.Error("failed to save DCA plan", "error", err)
+	}
+}
+
+func (s *TradingService) advanceDCAPlan(ctx context.Context, p *models.DCAPlan) error {
+	if p.Status == models.DCACompleted {
+		return nil
+	}
+	// Nothing can be placed while the symbol is not open for trading; the plan resumes with it
+	if s.symbolHalted(p.Symbol) {
+		return nil
+	}
+	filters, err := s.api(ctx).GetSymbolFilters(ctx, p.Symbol)
+	if err != nil {
+		return err
+	}
+
+	// The take profit is still maintained while trading is paused; it only reduces the position
+	if p.Status == models.DCAActive && !s.tradingPaused(ctx) {
+		mark, err := s.currentMarkPrice(ctx, p.Symbol)
+		if err != nil {
+			return err
+		}
+		if dcaOrderDue(p, mark, time.Now()) {
+			if err := s.placeDCAOrder(ctx, p, mark, filters); err != nil {
+				retryAt := time.Now().Add(dcaRetryDelay)
+				p.NextOrderAt = &retryAt
+				return err
+			}
+			s.syncDCAOrders(ctx, p)
+		}
+	}
+	return s.maintainDCATakeProfit(ctx, p, filters)
+}
+
+// dcaOrderDue reports whether p should place its next order now: when its interval has passed,
+// or the price has moved a step against its last fill. The first order is due at once.
+func dcaOrderDue(p *models.DCAPlan, mark float64, now time.Time) bool {
+	if p.NextOrderAt != nil && now.Before(*p.NextOrderAt) {
+		return false
+	}
+	for _, o := range p.Orders {
+		if !dcaOrderDone(o.Status) {
+			return false // wait for the previous order to fill
+		}
+	}
+	if _, ok := strategy.DCAStepPrice(p); ok {
+		return strategy.DCAStepReached(p, mark)
+	}
+	return true
+}
+
+// placeDCAOrder places p's next market order, shrunk to the remaining budget. A budget too small
+// for the symbol's minimum order caps the plan instead.
+func (s *TradingService) placeDCAOrder(ctx context.Context, p *models.DCAPlan, mark float64, filters *binance.SymbolFilters) error {
+	quantity := strategy.DCAOrderQuantity(p, mark, filters)
+	if quantity <= 0 {
+		p.Status = models.DCACapped
+		p.NextOrderAt = nil
+		slog.Info("DCA plan reached its max exposure", "dca_plan_id", p.ID.Hex(), "exposure", p.Exposure, "max_exposure", p.MaxExposure)
+		return nil
+	}
+
+	now := time.Now()
+	clientOrderID := s.strategyClientOrderID(models.OrderSourceDCA, p.ID, len(p.Orders)+1)
+	order, err := s.CreateAdvancedFuturesOrder(ctx, &AdvancedOrderRequest{
+		Symbol:           p.Symbol,
+		Side:             string(p.Side),
+		OrderType:        string(models.OrderTypeMarket),
+		Quantity:         decimal.NewFromFloat(quantity),
+		Leverage:         p.Leverage,
+		PositionSide:     string(p.PositionSide),
+		NewOrderRespType: "RESULT",
+		ClientOrderID:    clientOrderID,
+		Source:           models.OrderSourceDCA,
+	})
+	if err != nil {
+		return err
+	}
+	p.LastError = ""
+	p.Orders = append(p.Orders, models.DCAOrder{
+		OrderID:       order.BinanceOrderID,
+		ClientOrderID: clientOrderID,
+		Quantity:      quantity,
+		Status:        order.Status,
+		EstPrice:      mark,
+		PlacedAt:      now,
+	})
+	if p.IntervalSeconds > 0 {
+		next := now.Add(time.Duration(p.IntervalSeconds) * time.Second)
+		p.NextOrderAt = &next
+	} else {
+		p.NextOrderAt = nil
+	}
+	recomputeDCAPosition(p)
+	slog.Info("DCA order placed", "dca_plan_id", p.ID.Hex(), "symbol", p.Symbol, "quantity", quantity, "binance_order_id", order.BinanceOrderID)
+	return nil
+}
+
+// syncDCAOrders fetches the fills of p's unfinished orders and updates the blended entry
+func (s *TradingService) syncDCAOrders(ctx context.Context, p *models.DCAPlan) {
+	for i := range p.Orders {
+		o := &p.Orders[i]
+		if dcaOrderDone(o.Status) {
+			continue
+		}
+		live, err := s.api(ctx).GetFuturesOrder(ctx, p.Symbol, o.OrderID)
+		if err != nil {
+			slog.Warn("failed to get DCA order", "dca_plan_id", p.ID.Hex(), "binance_order_id", o.OrderID, "error", err)
+			continue
+		}
+		s.recordOrderFill(ctx, p.Symbol, o.OrderID, "", futuresOrderFill(live))
+		o.Status = string(live.Status)
+		o.FilledQty, _ = strconv.ParseFloat(live.ExecutedQuantity, 64)
+		o.AvgPrice, _ = strconv.ParseFloat(live.AvgPrice, 64)
+	}
+	recomputeDCAPosition(p)
+}
+
+// recomputeDCAPosition derives the filled quantity, blended entry, exposure and budget from p's orders
+func recomputeDCAPosition(p *models.DCAPlan) {
+	var quantity, cost, pending float64
+	for _, o := range p.Orders {
+		quantity += o.FilledQty
+		cost += o.FilledQty * o.AvgPrice
+		if !dcaOrderDone(o.Status) {
+			pending += (o.Quantity - o.FilledQty) * o.EstPrice
+		}
+		if o.FilledQty > 0 && o.AvgPrice > 0 {
+			p.LastFillPrice = o.AvgPrice
+		}
+	}
+	p.FilledQuantity = quantity
+	p.AvgEntryPrice = 0
+	if quantity > 0 {
+		p.AvgEntryPrice = cost / quantity
+	}
+	p.Exposure = cost + pending
+	p.RemainingBudget = math.Max(0, p.MaxExposure-p.Exposure)
+}
+
+// syncDCATakeProfit books the fills of p's take profit order. A filled take profit completes the
+// plan; one canceled or expired elsewhere is replaced by maintainDCATakeProfit.
+func (s *TradingService) syncDCATakeProfit(ctx context.Context, p *models.DCAPlan) {
+	if p.TakeProfitOrderID == 0 {
+		return
+	}
+	live, err := s.api(ctx).GetFuturesOrder(ctx, p.Symbol, p.TakeProfitOrderID)
+	if err != nil {
+		slog.Warn("failed to get DCA take profit order", "dca_plan_id", p.ID.Hex(), "binance_order_id", p.TakeProfitOrderID, "error", err)
+		return
+	}
+	s.recordOrderFill(ctx, p.Symbol, p.TakeProfitOrderID, "", futuresOrderFill(live))
+	executed, _ := strconv.ParseFloat(live.ExecutedQuantity, 64)
+	avgPrice, _ := strconv.ParseFloat(live.AvgPrice, 64)
+	if closed := executed - p.TakeProfitExecuted; closed > 0 {
+		p.ClosedQuantity += closed
+		p.RealizedPnL += (avgPrice - p.AvgEntryPrice) * closed * strategy.DCADirection(p)
+		p.TakeProfitExecuted = executed
+	}
+
+	status := string(live.Status)
+	if !dcaOrderDone(status) {
+		return
+	}
+	if status == string(futures.OrderStatusTypeFilled) {
+		p.Status = models.DCACompleted
+		p.NextOrderAt = nil
+		slog.Info("DCA plan take profit filled", "dca_plan_id", p.ID.Hex(), "symbol", p.Symbol, "realized_pnl", p.RealizedPnL)
+	}
+	p.TakeProfitOrderID = 0
+	p.TakeProfitQuantity = 0
+	p.TakeProfitExecuted = 0
+}
+
+// maintainDCATakeProfit keeps one reduce-only limit order closing p's open quantity at the take
+// profit percent from the blended entry, replacing it when the position changes
+func (s *TradingService) maintainDCATakeProfit(ctx context.Context, p *models.DCAPlan, filters *binance.SymbolFilters) error {
+	if p.TakeProfitPercent <= 0 || p.Status == models.DCACompleted || p.Status == models.DCACanceled {
+		return nil
+	}
+	quantity := roundToStep(p.FilledQuantity-p.ClosedQuantity, filters.StepSize, strategy.RoundDown)
+	if quantity <= 0 || quantity < filters.MinQty {
+		return nil
+	}
+	if p.TakeProfitOrderID != 0 {
+		if p.TakeProfitQuantity == quantity {
+			return nil
+		}
+		if err := s.cancelDCATakeProfit(ctx, p); err != nil {
+			return err
+		}
+		if p.Status == models.DCACompleted {
+			return nil
+		}
+		// Fills booked by the cancellation shrink the position
+		quantity = roundToStep(p.FilledQuantity-p.ClosedQuantity, filters.StepSize, strategy.RoundDown)
+		if quantity <= 0 || quantity < filters.MinQty {
+			return nil
+		}
+	}
+
+	exit := models.OrderSideSell
+	if p.Side == models.OrderSideSell {
+		exit = models.OrderSideBuy
+	}
+	price := strategy.DCATakeProfitPrice(p, filters.TickSize)
+	order, err := s.CreateAdvancedFuturesOrder(ctx, &AdvancedOrderRequest{
+		Symbol:       p.Symbol,
+		Side:         string(exit),
+		OrderType:    string(models.OrderTypeLimit),
+		Quantity:     decimal.NewFromFloat(quantity),
+		Price:        decimal.NewFromFloat(price),
+		TimeInForce:  string(models.TimeInForceGTC),
+		PositionSide: string(p.PositionSide),
+		ReduceOnly:   p.PositionSide == "", // hedge mode closes through the position side instead
+	})
+	if err != nil {
+		return fmt.Errorf("failed to place take profit: %w", err)
+	}
+	p.TakeProfitOrderID = order.BinanceOrderID
+	p.TakeProfitPrice = price
+	p.TakeProfitQuantity = quantity
+	p.TakeProfitExecuted = 0
+	slog.Info("DCA take profit placed", "dca_plan_id", p.ID.Hex(), "symbol", p.Symbol, "price", price, "quantity", quantity)
+	return nil
+}
+
+// cancelDCATakeProfit cancels p's take profit order and books whatever it filled before that
+func (s *TradingService) cancelDCATakeProfit(ctx context.Context, p *models.DCAPlan) error {
+	if err := s.CancelBatchOrders(ctx, p.Symbol, []int64{p.TakeProfitOrderID}, nil); err != nil {
+		slog.Warn("failed to cancel DCA take profit", "dca_plan_id", p.ID.Hex(), "binance_order_id", p.TakeProfitOrderID, "error", err)
+	}
+	s.syncDCATakeProfit(ctx, p)
+	if p.TakeProfitOrderID != 0 {
+		return fmt.Errorf("take profit order %d is still open", p.TakeProfitOrderID)
+	}
+	return nil
+}
+
+// valueDCAPlans fills in the mark price and unrealized PnL of the plans' open quantity
+func (s *TradingService) valueDCAPlans(ctx context.Context, plans []*models.DCAPlan) {
+	marks := make(map[string]float64)
+	for _, p := range plans {
+		open := p.FilledQuantity - p.ClosedQuantity
+		if open <= 0 {
+			continue
+		}
+		mark, ok := marks[p.Symbol]
+		if !ok {
+			var err error
+			if mark, err = s.currentMarkPrice(ctx, p.Symbol); err != nil {
+				slog.Warn("failed to value DCA plan", "dca_plan_id", p.ID.Hex(), "error", err)
+			}
+			marks[p.Symbol] = mark
+		}
+		if mark > 0 {
+			p.MarkPrice = mark
+			p.UnrealizedPnL = (mark - p.AvgEntryPrice) * open * strategy.DCADirection(p)
+		}
+	}
+}
+
+// currentMarkPrice returns symbol's mark price from the price cache
+func (s *TradingService) currentMarkPrice(ctx context.Context, symbol string) (float64, error) {
+	price, err := s.api(ctx).GetPrice(ctx, symbol, futures.WorkingTypeMarkPrice, 0)
+	if err != nil {
+		return 0, err
+	}
+	return price.Value, nil
+}
+
+// dcaOrderDone reports whether an order status is final
+func dcaOrderDone(status string) bool {
+	switch futures.OrderStatusType(status) {
+	case futures.OrderStatusTypeFilled, futures.OrderStatusTypeCanceled, futures.OrderStatusTypeExpired,
+		futures.OrderStatusTypeRejected:
+		return true
+	}
+	return status == "EXPIRED_IN_MATCH"
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+var float64Type = reflect.TypeOf(float64(0))
+
+// decodeDecimalFields decodes the JSON object data into v, a pointer to a request struct, taking
+// each of its float64 fields as either a JSON number or a decimal string such as "0.00000001".
+// Strings are parsed as decimals and handed to the decoder as number literals, so they convert to
+// float64 exactly like the number would. Unknown fields are rejected, as a request decoder's
+// DisallowUnknownFields does not reach a custom UnmarshalJSON.
+func decodeDecimalFields(data []byte, v interface{}) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err == nil && fields != nil {
+		t := reflect.TypeOf(v).Elem()
+		for key, raw := range fields {
+			if len(raw) == 0 || raw[0] != '"' {
+				continue
+			}
+			name, ok := decimalFieldName(t, key)
+			if !ok {
+				continue
+			}
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return err
+			}
+			d, err := decimal.NewFromString(strings.TrimSpace(s))
+			if err != nil {
+				return &json.UnmarshalTypeError{Value: "non-decimal string", Type: float64Type, Field: name}
+			}
+			fields[key] = json.RawMessage(d.String())
+		}
+		if data, err = json.Marshal(fields); err != nil {
+			return err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// decimalFieldName returns the JSON name of t's float64 field that key decodes into, matched
+// case-insensitively like encoding/json does
+func decimalFieldName(t reflect.Type, key string) (string, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || f.Type != float64Type {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if strings.EqualFold(name, key) {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/database"
+	"futures-options/latency"
+)
+
+// defaultClockDriftThreshold applies when BINANCE_CLOCK_DRIFT_THRESHOLD is not positive
+const defaultClockDriftThreshold = 500 * time.Millisecond
+
+// latencyProbeTimeout bounds each probe of the latency diagnostics
+const latencyProbeTimeout = 5 * time.Second
+
+// Latency probes, in the order they are reported
+var latencyProbes = []string{"binance_ping", "binance_server_time", "binance_signed_account", "binance_ws_api", "mongodb_ping"}
+
+// ClockDiagnostics reports how far the local clock is from Binance's and whether signed requests
+// account for it
+type ClockDiagnostics struct {
+	binance.ServerTimeCheck
+	RecvWindowMs int64 `json:"recv_window_ms"`
+	// ExceedsRecvWindow is whether a request stamped with the uncorrected local clock would be
+	// rejected with -1021: more than 1000ms ahead of the server or more than recvWindow behind
+	ExceedsRecvWindow bool  `json:"exceeds_recv_window"`
+	ThresholdMs       int64 `json:"threshold_ms"`
+	// DriftMs is the offset signed requests do not yet account for: measured less applied
+	DriftMs int64 `json:"drift_ms"`
+	// Resynced is set when the drift exceeded the threshold and the measured offset was applied
+	Resynced bool   `json:"resynced"`
+	Advice   string `json:"advice,omitempty"`
+}
+
+// CheckClock measures the local clock against Binance server time on the active network. When
+// the offset signed requests apply is more than BINANCE_CLOCK_DRIFT_THRESHOLD off the measured
+// one, the measured offset is applied to every signed REST request from then on.
+func (s *TradingService) CheckClock(ctx context.Context) (*ClockDiagnostics, error) {
+	check, err := s.binanceClient.CheckServerTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	threshold := s.binanceClient.EffectiveConfig().ClockDriftThreshold
+	if threshold <= 0 {
+		threshold = defaultClockDriftThreshold
+	}
+
+	recvWindow := binance.RecvWindowOf(ctx, s.binanceClient.EffectiveConfig().BinanceRecvWindow)
+
+	d := &ClockDiagnostics{
+		ServerTimeCheck:   *check,
+		RecvWindowMs:      recvWindow,
+		ExceedsRecvWindow: check.OffsetMs > binance.MaxTimestampAheadMs || -check.OffsetMs > recvWindow,
+		ThresholdMs:       threshold.Milliseconds(),
+		DriftMs:           check.OffsetMs - check.AppliedOffsetMs,
+	}
+	if abs64(d.DriftMs) > d.ThresholdMs {
+		s.binanceClient.SetTimeOffset(check.OffsetMs)
+		slog.Warn("local clock drifts from Binance server time; applied measured offset to signed requests",
+			"offset_ms", check.OffsetMs, "previous_offset_ms", check.AppliedOffsetMs, "network", check.Network)
+		d.Resynced = true
+		d.AppliedOffsetMs = check.OffsetMs
+	}
+	if abs64(check.OffsetMs) > d.ThresholdMs {
+		d.Advice = fmt.Sprintf("The local clock is %dms %s Binance server time. Signed requests now correct for it, "+
+			"but the offset is only measured when this endpoint is called; synchronize the host clock (e.g. enable NTP) to fix it for good.",
+			abs64(check.OffsetMs), aheadOrBehind(check.OffsetMs))
+	}
+	return d, nil
+}
+
+// ProbeNetwork reports the proxy each Binance client connects through and whether its endpoint is
+// reachable that way
+func (s *TradingService) ProbeNetwork(ctx context.Context) *binance.NetworkDiagnostics {
+	return s.binanceClient.ProbeNetwork(ctx)
+}
+
+// LatencyDiagnostics times a probe of each dependency now and summarizes the latency and errors
+// of the calls the service made to them recently
+type LatencyDiagnostics struct {
+	Probes map[string]*HealthCheckResult `json:"probes"`
+	// Recent holds, per target, the calls of the last WindowMinutes
+	Recent        map[string]latency.Stats `json:"recent"`
+	WindowMinutes int                      `json:"window_minutes"`
+	Timestamp     time.Time                `json:"timestamp"`
+}
+
+// DiagnoseLatency runs the probes concurrently: a REST ping, a server time fetch, a signed account
+// request, a WS-API account.status round trip and a MongoDB ping. Recent covers every REST,
+// WS-API and MongoDB call made in the last window, however it was started.
+func (s *TradingService) DiagnoseLatency(ctx context.Context, window time.Duration) *LatencyDiagnostics {
+	checks := map[string]func(context.Context) error{
+		"binance_ping": s.binanceClient.Ping,
+		"binance_server_time": func(ctx context.Context) error {
+			_, err := s.binanceClient.CheckServerTime(ctx)
+			return err
+		},
+		"binance_signed_account": func(ctx context.Context) error {
+			_, err := s.binanceClient.GetFuturesAccount(ctx)
+			return err
+		},
+		"binance_ws_api": func(ctx context.Context) error {
+			_, err := s.getAccountStatusWS(ctx)
+			return err
+		},
+		"mongodb_ping": database.Ping,
+	}
+
+	d := &LatencyDiagnostics{Probes: make(map[string]*HealthCheckResult, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range latencyProbes {
+		wg.Add(1)
+		go func(name string, check func(context.Context) error) {
+			defer wg.Done()
+			result := runTimedCheck(ctx, latencyProbeTimeout, check)
+			mu.Lock()
+			d.Probes[name] = result
+			mu.Unlock()
+		}(name, checks[name])
+	}
+	wg.Wait()
+
+	d.Recent = latency.Snapshot(window)
+	d.WindowMinutes = int(window / time.Minute)
+	d.Timestamp = time.Now()
+	return d
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// aheadOrBehind describes the direction of a local minus server offset
+func aheadOrBehind(offsetMs int64) string {
+	if offsetMs > 0 {
+		return "ahead of"
+	}
+	return "behind"
+}
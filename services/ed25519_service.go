@@ -0,0 +1,273 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Ed25519Key is the Ed25519 key bound to a credential. The private seed is only returned when
+// asked for at generation; otherwise it is only kept encrypted in the database.
+type Ed25519Key struct {
+	CredentialID   string `json:"credential_id"`
+	APIKeyMasked   string `json:"api_key_masked"`
+	PublicKeyHEX   string `json:"publicKeyHEX"`
+	PublicKeyB64   string `json:"publicKeyB64"`
+	PrivateSeedHEX string `json:"privateSeedHEX,omitempty"`
+	PrivateSeedB64 string `json:"privateSeedB64,omitempty"`
+}
+
+// GenerateEd25519Key generates an Ed25519 key for the credential with the given ID (the active
+// credential when empty) and stores its seed encrypted on the credential, replacing any key it
+// had. WS-API requests are signed with the active credential's key from then on.
+func (s *TradingService) GenerateEd25519Key(ctx context.Context, credentialID string, includePrivate bool) (*Ed25519Key, error) {
+	start := time.Now()
+	key, err := s.generateEd25519Key(ctx, credentialID, includePrivate)
+	var response interface{}
+	if key != nil {
+		// The audit log never sees the seed
+		response = map[string]string{"credential_id": key.CredentialID, "public_key": key.PublicKeyB64}
+	}
+	s.recordAudit(ctx, models.AuditCredentialKeyGenerate, "", map[string]interface{}{"credential_id": credentialID}, response, err, start)
+	return key, err
+}
+
+func (s *TradingService) generateEd25519Key(ctx context.Context, credentialID string, includePrivate bool) (*Ed25519Key, error) {
+	credentials, err := s.keyCredential(ctx, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	key, err := s.storeEd25519Key(ctx, credentials, priv)
+	if err != nil {
+		return nil, err
+	}
+	if includePrivate {
+		key.PrivateSeedHEX = hex.EncodeToString(priv.Seed())
+		key.PrivateSeedB64 = base64.StdEncoding.EncodeToString(priv.Seed())
+	}
+	return key, nil
+}
+
+// ImportEd25519KeyRequest is an Ed25519 key already registered with Binance
+type ImportEd25519KeyRequest struct {
+	CredentialID      string `json:"credential_id,omitempty"`       // default: the active credential
+	PrivateKey        string `json:"private_key"`                   // PKCS#8 PEM, or a 32-byte seed or 64-byte key in hex or base64
+	ExpectedPublicKey string `json:"expected_public_key,omitempty"` // hex or base64; the import fails if the key does not match
+}
+
+// Validate checks the request fields
+func (r *ImportEd25519KeyRequest) Validate() error {
+	v := &validator{}
+	v.required("private_key", r.PrivateKey)
+	return v.err()
+}
+
+// ImportEd25519Key stores an existing Ed25519 key on the credential with the given ID (the active
+// credential when empty), replacing any key it had. The returned key never includes the seed.
+func (s *TradingService) ImportEd25519Key(ctx context.Context, req *ImportEd25519KeyRequest) (*Ed25519Key, error) {
+	start := time.Now()
+	key, err := s.importEd25519Key(ctx, req)
+	var response interface{}
+	if key != nil {
+		response = map[string]string{"credential_id": key.CredentialID, "public_key": key.PublicKeyB64}
+	}
+	s.recordAudit(ctx, models.AuditCredentialKeyImport, "", map[string]interface{}{"credential_id": req.CredentialID}, response, err, start)
+	return key, err
+}
+
+func (s *TradingService) importEd25519Key(ctx context.Context, req *ImportEd25519KeyRequest) (*Ed25519Key, error) {
+	priv, err := parseEd25519PrivateKey(req.PrivateKey)
+	if err != nil {
+		v := &validator{}
+		v.add("private_key", RuleType, err.Error())
+		return nil, v.err()
+	}
+	if req.ExpectedPublicKey != "" {
+		expected, ok := decodeKeyBytes(req.ExpectedPublicKey)
+		if !ok || len(expected) != ed25519.PublicKeySize {
+			v := &validator{}
+			v.add("expected_public_key", RuleType, fmt.Sprintf("must be a %d-byte public key in hex or base64", ed25519.PublicKeySize))
+			return nil, v.err()
+		}
+		if !bytes.Equal(expected, priv.Public().(ed25519.PublicKey)) {
+			v := &validator{}
+			v.add("expected_public_key", RuleEnum, "does not match the public key derived from private_key")
+			return nil, v.err()
+		}
+	}
+
+	credentials, err := s.keyCredential(ctx, req.CredentialID)
+	if err != nil {
+		return nil, err
+	}
+	return s.storeEd25519Key(ctx, credentials, priv)
+}
+
+// storeEd25519Key stores the seed of priv encrypted on credentials, with its public key
+func (s *TradingService) storeEd25519Key(ctx context.Context, credentials *models.APICredentials, priv ed25519.PrivateKey) (*Ed25519Key, error) {
+	if err := s.requireMasterKey(credentials.IsTestnet); err != nil {
+		return nil, err
+	}
+	encryptedSeed, err := s.encryptSecret(base64.StdEncoding.EncodeToString(priv.Seed()))
+	if err != nil {
+		return nil, err
+	}
+	pub := priv.Public().(ed25519.PublicKey)
+	publicKey := base64.StdEncoding.EncodeToString(pub)
+	if err := s.repos.Credentials.SetEd25519Key(ctx, credentials.ID, encryptedSeed, publicKey); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrCredentialNotFound
+		}
+		return nil, fmt.Errorf("failed to store Ed25519 key: %w", err)
+	}
+	// The pooled WS-API connection signs with the key it was created with
+	s.resetWSAPIClient()
+	return &Ed25519Key{
+		CredentialID: credentials.ID.Hex(),
+		APIKeyMasked: MaskSecret(credentials.APIKey),
+		PublicKeyHEX: hex.EncodeToString(pub),
+		PublicKeyB64: publicKey,
+	}, nil
+}
+
+// parseEd25519PrivateKey parses a PKCS#8 PEM private key, or a raw 32-byte seed or 64-byte
+// private key in hex or base64, checking that a 64-byte key's public half matches its seed
+func parseEd25519PrivateKey(input string) (ed25519.PrivateKey, error) {
+	input = strings.TrimSpace(input)
+	if strings.HasPrefix(input, "-----BEGIN") {
+		block, _ := pem.Decode([]byte(input))
+		if block == nil {
+			return nil, errors.New("is not valid PEM")
+		}
+		if block.Type != "PRIVATE KEY" {
+			return nil, fmt.Errorf("is a %q PEM block; expected an unencrypted PKCS#8 \"PRIVATE KEY\"", block.Type)
+		}
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("is not a valid PKCS#8 key: %v", err)
+		}
+		key, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("is a %T, not an Ed25519 key", parsed)
+		}
+		return key, nil
+	}
+
+	raw, ok := decodeKeyBytes(input)
+	if !ok {
+		return nil, errors.New("must be PKCS#8 PEM, hex or base64")
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		key := ed25519.NewKeyFromSeed(raw[:ed25519.SeedSize])
+		if !bytes.Equal(key, raw) {
+			return nil, errors.New("is not an Ed25519 key: its public half does not match its seed")
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("decodes to %d bytes; expected a %d-byte seed or %d-byte private key", len(raw), ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+}
+
+// decodeKeyBytes decodes hex, or standard or URL-safe base64 (padded or not)
+func decodeKeyBytes(input string) ([]byte, bool) {
+	input = strings.TrimSpace(input)
+	if b, err := hex.DecodeString(input); err == nil {
+		return b, true
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if b, err := enc.DecodeString(input); err == nil {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// keyCredential loads the credential with the given ID, or the active credential when id is empty
+func (s *TradingService) keyCredential(ctx context.Context, id string) (*models.APICredentials, error) {
+	var credentials *models.APICredentials
+	var err error
+	if id == "" {
+		credentials, err = s.repos.Credentials.FindActive(ctx)
+	} else {
+		objectID, parseErr := primitive.ObjectIDFromHex(id)
+		if parseErr != nil {
+			return nil, ErrInvalidID
+		}
+		credentials, err = s.repos.Credentials.FindByID(ctx, objectID)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCredentialNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API credentials: %w", err)
+	}
+	return credentials, nil
+}
+
+// activeEd25519Key returns the stored Ed25519 key of the active credential, or nil when there is
+// none or the live clients use other API keys (e.g. from the environment)
+func (s *TradingService) activeEd25519Key(ctx context.Context) (ed25519.PrivateKey, error) {
+	credentials, err := s.repos.Credentials.FindActive(ctx)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load active credentials: %w", err)
+	}
+	if credentials.Ed25519Seed == "" {
+		return nil, nil
+	}
+	if apiKey := s.binanceClient.EffectiveConfig().BinanceAPIKey; apiKey != "" && apiKey != credentials.APIKey {
+		return nil, nil
+	}
+
+	encoded, err := s.decryptSecret(credentials.Ed25519Seed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt Ed25519 key of credential %s: %w", credentials.ID.Hex(), err)
+	}
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("stored Ed25519 key of credential %s is invalid", credentials.ID.Hex())
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// newWSAPIClient connects to the WS-API, signing with the active credential's stored Ed25519 key
+// when there is one and with the key file otherwise
+func (s *TradingService) newWSAPIClient(ctx context.Context) (*binance.WSAPIClient, error) {
+	key, err := s.activeEd25519Key(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := binance.NewWSAPIClient(s.binanceClient.EffectiveConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect WS API: %w", err)
+	}
+	if key != nil {
+		ws.SetPrivateKey(key)
+	}
+	return ws, nil
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"futures-options/models"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+const (
+	// defaultEquityCurveRange is the curve period when no start is given
+	defaultEquityCurveRange = 30 * 24 * time.Hour
+	// equityFillSnapshotGap is the minimum time between snapshots taken after large fills, so a
+	// burst of fills records the account once
+	equityFillSnapshotGap = 10 * time.Second
+)
+
+// equityState holds the fill-triggered snapshot settings
+type equityState struct {
+	fillNotional float64
+	lastFill     time.Time
+}
+
+// EquityCurveQuery selects the curve period and the spacing of its points
+type EquityCurveQuery struct {
+	Start      *time.Time
+	End        *time.Time
+	Resolution time.Duration // 0 returns every snapshot
+}
+
+// Validate checks the query
+func (q *EquityCurveQuery) Validate() error {
+	v := &validator{}
+	v.nonNegative("resolution", float64(q.Resolution))
+	if q.Start != nil && q.End != nil && !q.Start.Before(*q.End) {
+		v.add("start", RuleRange, "must be before end")
+	}
+	return v.err()
+}
+
+// EquityCurve is the account value over a period. Drawdown and return are computed on the margin
+// balance of every snapshot in the period, before downsampling; deposits and withdrawals count as
+// gains and losses.
+type EquityCurve struct {
+	Start          time.Time                `json:"start"`
+	End            time.Time                `json:"end"`
+	Resolution     string                   `json:"resolution,omitempty"`
+	Points         []*models.EquitySnapshot `json:"points"`
+	StartEquity    float64                  `json:"start_equity"`
+	EndEquity      float64                  `json:"end_equity"`
+	ReturnPct      float64                  `json:"return_pct"`
+	MaxDrawdown    float64                  `json:"max_drawdown"`
+	MaxDrawdownPct float64                  `json:"max_drawdown_pct"`
+	Paper          bool                     `json:"paper,omitempty"`
+}
+
+// SetEquityFillNotional sets the fill size (quantity times price) that triggers an extra equity
+// snapshot from the user data stream; 0 disables fill-triggered snapshots
+func (s *TradingService) SetEquityFillNotional(notional float64) {
+	s.equityMu.Lock()
+	defer s.equityMu.Unlock()
+	s.equity.fillNotional = notional
+}
+
+// RecordEquitySnapshot stores the futures account's current wallet balance, unrealized PnL and margin balance
+func (s *TradingService) RecordEquitySnapshot(ctx context.Context, trigger, symbol string) (*models.EquitySnapshot, error) {
+	account, err := s.api(ctx).GetFuturesAccount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account for equity snapshot: %w", err)
+	}
+	wallet, _ := strconv.ParseFloat(account.TotalWalletBalance, 64)
+	unrealized, _ := strconv.ParseFloat(account.TotalUnrealizedProfit, 64)
+	margin, _ := strconv.ParseFloat(account.TotalMarginBalance, 64)
+
+	snapshot := &models.EquitySnapshot{
+		WalletBalance: wallet,
+		UnrealizedPnl: unrealized,
+		MarginBalance: margin,
+		Trigger:       trigger,
+		Symbol:        symbol,
+		Time:          time.Now().UTC(),
+	}
+	if err := s.repos.Equity.Insert(ctx, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to store equity snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// StartEquitySnapshots registers the equity snapshot job, recording a snapshot every interval
+func (s *TradingService) StartEquitySnapshots(ctx context.Context, interval time.Duration) {
+	s.RegisterJob(ctx, Job{Name: JobEquitySnapshot, Interval: interval, Run: func(ctx context.Context) error {
+		_, err := s.RecordEquitySnapshot(ctx, models.EquityTriggerScheduled, "")
+		return err
+	}})
+}
+
+// snapshotAfterFill records an equity snapshot when a user data stream fill is at least the
+// configured notional
+func (s *TradingService) snapshotAfterFill(ctx context.Context, update *futures.WsOrderTradeUpdate) {
+	if update.ExecutionType != futures.OrderExecutionTypeTrade {
+		return
+	}
+	qty, _ := strconv.ParseFloat(update.LastFilledQty, 64)
+	price, _ := strconv.ParseFloat(update.LastFilledPrice, 64)
+
+	s.equityMu.Lock()
+	now := time.Now()
+	large := s.equity.fillNotional > 0 && qty*price >= s.equity.fillNotional
+	if !large || now.Sub(s.equity.lastFill) < equityFillSnapshotGap {
+		s.equityMu.Unlock()
+		return
+	}
+	s.equity.lastFill = now
+	s.equityMu.Unlock()
+
+	// The account call must not hold up the stream
+	symbol := update.Symbol
+	s.runBackground(func() {
+		if _, err := s.RecordEquitySnapshot(context.WithoutCancel(ctx), models.EquityTriggerFill, symbol); err != nil {
+			slog.Warn("equity snapshot after fill failed", "symbol", symbol, "error", err)
+		}
+	})
+}
+
+// GetEquityCurve returns the stored equity snapshots for the period, keeping the last snapshot of
+// each resolution interval, with the period's return and maximum drawdown
+func (s *TradingService) GetEquityCurve(ctx context.Context, query *EquityCurveQuery) (*EquityCurve, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	end := time.Now()
+	if query.End != nil {
+		end = *query.End
+	}
+	start := end.Add(-defaultEquityCurveRange)
+	if query.Start != nil {
+		start = *query.Start
+	}
+
+	snapshots, err := s.repos.Equity.List(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	curve := &EquityCurve{Start: start, End: end, Points: downsampleEquity(snapshots, query.Resolution), Paper: s.Paper()}
+	if query.Resolution > 0 {
+		curve.Resolution = query.Resolution.String()
+	}
+	if len(snapshots) == 0 {
+		return curve, nil
+	}
+
+	curve.StartEquity = snapshots[0].MarginBalance
+	curve.EndEquity = snapshots[len(snapshots)-1].MarginBalance
+	if curve.StartEquity > 0 {
+		curve.ReturnPct = (curve.EndEquity - curve.StartEquity) / curve.StartEquity * 100
+	}
+	peak := snapshots[0].MarginBalance
+	for _, snap := range snapshots {
+		if snap.MarginBalance > peak {
+			peak = snap.MarginBalance
+		}
+		if drawdown := peak - snap.MarginBalance; drawdown > curve.MaxDrawdown {
+			curve.MaxDrawdown = drawdown
+			if peak > 0 {
+				curve.MaxDrawdownPct = drawdown / peak * 100
+			}
+		}
+	}
+	return curve, nil
+}
+
+// downsampleEquity keeps the last snapshot of each resolution interval (aligned to UTC, so 24h
+// intervals start at midnight); snapshots must be oldest first
+func downsampleEquity(snapshots []*models.EquitySnapshot, resolution time.Duration) []*models.EquitySnapshot {
+	if resolution <= 0 {
+		return snapshots
+	}
+	points := make([]*models.EquitySnapshot, 0, len(snapshots))
+	var bucket time.Time
+	for _, snap := range snapshots {
+		b := snap.Time.Truncate(resolution)
+		if len(points) > 0 && b.Equal(bucket) {
+			points[len(points)-1] = snap
+			continue
+		}
+		bucket = b
+		points = append(points, snap)
+	}
+	return points
+}
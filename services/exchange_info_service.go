@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"futures-options/binance"
+)
+
+// RefreshExchangeInfo reloads the cached exchange info of the given markets, or of USDⓈ-M and
+// every market in use when none are given. A market whose fetch fails keeps its previous entry
+// and reports the error in its status.
+func (s *TradingService) RefreshExchangeInfo(ctx context.Context, markets []string) ([]binance.ExchangeInfoStatus, error) {
+	v := &validator{}
+	for i, m := range markets {
+		markets[i] = strings.ToLower(m)
+		v.oneOf("market", markets[i], binance.ExchangeInfoMarkets...)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return s.binanceClient.RefreshExchangeInfo(ctx, markets...)
+}
+
+// ExchangeInfoStatus returns the age and state of each market's cached exchange info
+func (s *TradingService) ExchangeInfoStatus() []binance.ExchangeInfoStatus {
+	return s.binanceClient.ExchangeInfoStatus()
+}
+
+// StartExchangeInfoRefresh registers the exchange info refresh job, reloading the cached exchange
+// info every interval so symbol rules pick up new listings without a restart. Each run then checks
+// the status of the strategies' symbols.
+func (s *TradingService) StartExchangeInfoRefresh(ctx context.Context, interval time.Duration) {
+	s.RegisterJob(ctx, Job{Name: JobExchangeInfoRefresh, Interval: interval, Run: func(ctx context.Context) error {
+		statuses, err := s.binanceClient.RefreshExchangeInfo(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			if status.LastError != "" {
+				slog.Warn("exchange info is stale", "market", status.Market, "age_seconds", int64(status.AgeSeconds), "error", status.LastError)
+			}
+		}
+		s.checkStrategySymbols(ctx)
+		return nil
+	}})
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"futures-options/models"
+)
+
+// Export products
+const (
+	ExportProductFutures = "futures"
+	ExportProductOptions = "options"
+)
+
+const (
+	// defaultExportTimeout bounds an export when SetExportTimeout was not called
+	defaultExportTimeout = 10 * time.Minute
+	// exportFlushRows is how many rows are buffered before they are sent to the client
+	exportFlushRows = 500
+)
+
+// ExportQuery selects the orders or trades to export
+type ExportQuery struct {
+	Product string // futures (default) or options
+	Start   *time.Time
+	End     *time.Time
+	// Columns lists the columns to write, in order; all when empty
+	Columns []string
+	// AccountID limits the export to one account's orders; all accounts when empty
+	AccountID string
+}
+
+// Export is a validated export ready to be streamed with WriteCSV
+type Export struct {
+	Filename string
+
+	header []string
+	each   func(ctx context.Context, row func([]string) error) error
+}
+
+// exportColumn is one CSV column of an export of T
+type exportColumn[T any] struct {
+	name  string
+	value func(*T) string
+}
+
+// exportFill is the executed part of an order as echoed in its stored Binance response
+type exportFill struct {
+	ExecutedQty string `json:"executedQty"`
+	AvgPrice    string `json:"avgPrice"`
+}
+
+// futuresExportRow is a futures order plus its fill, for trade exports
+type futuresExportRow struct {
+	order *models.FuturesOrder
+	fill  exportFill
+}
+
+// optionsExportRow is an options order plus its fill, for trade exports
+type optionsExportRow struct {
+	order *models.OptionsOrder
+	fill  exportFill
+}
+
+var futuresExportColumns = []exportColumn[futuresExportRow]{
+	{"id", func(r *futuresExportRow) string { return r.order.ID.Hex() }},
+	{"created_at", func(r *futuresExportRow) string { return exportTime(r.order.CreatedAt) }},
+	{"updated_at", func(r *futuresExportRow) string { return exportTime(r.order.UpdatedAt) }},
+	{"symbol", func(r *futuresExportRow) string { return r.order.Symbol }},
+	{"market", func(r *futuresExportRow) string { return string(marketOf(r.order.Market)) }},
+	{"side", func(r *futuresExportRow) string { return string(r.order.Side) }},
+	{"order_type", func(r *futuresExportRow) string { return string(r.order.OrderType) }},
+	{"position_side", func(r *futuresExportRow) string { return string(r.order.PositionSide) }},
+	{"quantity", func(r *futuresExportRow) string { return formatFloat(r.order.Quantity) }},
+	{"price", func(r *futuresExportRow) string { return formatFloat(r.order.Price) }},
+	{"stop_price", func(r *futuresExportRow) string { return formatFloat(r.order.StopPrice) }},
+	{"executed_qty", func(r *futuresExportRow) string { return exportDecimal(r.fill.ExecutedQty) }},
+	{"avg_price", func(r *futuresExportRow) string { return exportDecimal(r.fill.AvgPrice) }},
+	{"leverage", func(r *futuresExportRow) string { return strconv.Itoa(r.order.Leverage) }},
+	{"time_in_force", func(r *futuresExportRow) string { return string(r.order.TimeInForce) }},
+	{"reduce_only", func(r *futuresExportRow) string { return strconv.FormatBool(r.order.ReduceOnly) }},
+	{"status", func(r *futuresExportRow) string { return r.order.Status }},
+	{"binance_order_id", func(r *futuresExportRow) string { return strconv.FormatInt(r.order.BinanceOrderID, 10) }},
+	{"client_order_id", func(r *futuresExportRow) string { return r.order.ClientOrderID }},
+	{"paper", func(r *futuresExportRow) string { return strconv.FormatBool(r.order.Paper) }},
+}
+
+var optionsExportColumns = []exportColumn[optionsExportRow]{
+	{"id", func(r *optionsExportRow) string { return r.order.ID.Hex() }},
+	{"created_at", func(r *optionsExportRow) string { return exportTime(r.order.CreatedAt) }},
+	{"updated_at", func(r *optionsExportRow) string { return exportTime(r.order.UpdatedAt) }},
+	{"symbol", func(r *optionsExportRow) string { return r.order.Symbol }},
+	{"side", func(r *optionsExportRow) string { return string(r.order.Side) }},
+	{"order_type", func(r *optionsExportRow) string { return string(r.order.OrderType) }},
+	{"option_type", func(r *optionsExportRow) string { return r.order.OptionType }},
+	{"strike_price", func(r *optionsExportRow) string { return formatFloat(r.order.StrikePrice) }},
+	{"expiry_date", func(r *optionsExportRow) string { return exportTime(r.order.ExpiryDate) }},
+	{"quantity", func(r *optionsExportRow) string { return formatFloat(r.order.Quantity) }},
+	{"price", func(r *optionsExportRow) string { return formatFloat(r.order.Price) }},
+	{"executed_qty", func(r *optionsExportRow) string { return exportDecimal(r.fill.ExecutedQty) }},
+	{"avg_price", func(r *optionsExportRow) string { return exportDecimal(r.fill.AvgPrice) }},
+	{"status", func(r *optionsExportRow) string { return r.order.Status }},
+	{"binance_order_id", func(r *optionsExportRow) string { return strconv.FormatInt(r.order.BinanceOrderID, 10) }},
+}
+
+// SetExportTimeout bounds how long a single export may run; 0 uses the default of 10m
+func (s *TradingService) SetExportTimeout(timeout time.Duration) {
+	s.exportTimeout = timeout
+}
+
+// ExportTimeout returns how long a single export may run
+func (s *TradingService) ExportTimeout() time.Duration {
+	if s.exportTimeout <= 0 {
+		return defaultExportTimeout
+	}
+	return s.exportTimeout
+}
+
+// ExportOrders prepares a CSV export of every stored order of the product created in the
+// period, oldest first
+func (s *TradingService) ExportOrders(q *ExportQuery) (*Export, error) {
+	return s.prepareExport(q, "orders", false)
+}
+
+// ExportTrades prepares a CSV export of the orders that traded: those FILLED or with an executed
+// quantity in their stored Binance response
+func (s *TradingService) ExportTrades(q *ExportQuery) (*Export, error) {
+	return s.prepareExport(q, "trades", true)
+}
+
+func (s *TradingService) prepareExport(q *ExportQuery, kind string, tradesOnly bool) (*Export, error) {
+	q.Product = strings.ToLower(q.Product)
+	v := &validator{}
+	v.oneOf("product", q.Product, ExportProductFutures, ExportProductOptions)
+	if q.Start != nil && q.End != nil && !q.End.After(*q.Start) {
+		v.add("end", RuleRange, "must be after start")
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	query := &OrderQuery{AccountID: q.AccountID, StartTime: q.Start, EndTime: q.End, SortAsc: true, IncludeRaw: tradesOnly}
+	export := &Export{}
+	var err error
+	if q.Product == ExportProductOptions {
+		var columns []exportColumn[optionsExportRow]
+		if columns, err = selectExportColumns(optionsExportColumns, q.Columns); err != nil {
+			return nil, err
+		}
+		export.header = exportHeader(columns)
+		export.each = func(ctx context.Context, row func([]string) error) error {
+			return s.repos.OptionsOrders.Each(ctx, query, func(o *models.OptionsOrder) error {
+				r := optionsExportRow{order: o, fill: parseExportFill(o.RawResponse)}
+				if tradesOnly && !exportTraded(o.Status, r.fill) {
+					return nil
+				}
+				return row(exportValues(columns, &r))
+			})
+		}
+	} else {
+		q.Product = ExportProductFutures
+		var columns []exportColumn[futuresExportRow]
+		if columns, err = selectExportColumns(futuresExportColumns, q.Columns); err != nil {
+			return nil, err
+		}
+		export.header = exportHeader(columns)
+		export.each = func(ctx context.Context, row func([]string) error) error {
+			return s.repos.FuturesOrders.Each(ctx, query, func(o *models.FuturesOrder) error {
+				r := futuresExportRow{order: o, fill: futuresExportFill(o)}
+				if tradesOnly && !exportTraded(o.Status, r.fill) {
+					return nil
+				}
+				return row(exportValues(columns, &r))
+			})
+		}
+	}
+	export.Filename = fmt.Sprintf("%s-%s-%s.csv", q.Product, kind, time.Now().UTC().Format("20060102-150405"))
+	return export, nil
+}
+
+// WriteCSV streams the export to w, a header row first. Rows are flushed in small batches (and
+// w flushed too if it has a Flush method), so memory use does not grow with the export.
+func (e *Export) WriteCSV(ctx context.Context, w io.Writer) error {
+	flusher, _ := w.(interface{ Flush() })
+	cw := csv.NewWriter(w)
+	flush := func() error {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	if err := cw.Write(e.header); err != nil {
+		return err
+	}
+	rows := 0
+	err := e.each(ctx, func(values []string) error {
+		if err := cw.Write(values); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushRows == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("export stopped after %d rows: %w", rows, err)
+	}
+	return flush()
+}
+
+// selectExportColumns returns the named columns in the order given, or all when names is empty
+func selectExportColumns[T any](all []exportColumn[T], names []string) ([]exportColumn[T], error) {
+	if len(names) == 0 {
+		return all, nil
+	}
+	selected := make([]exportColumn[T], 0, len(names))
+	known := make([]string, len(all))
+	for i, c := range all {
+		known[i] = c.name
+	}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for _, c := range all {
+			if c.name == name {
+				selected = append(selected, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			v := &validator{}
+			v.add("columns", RuleEnum, fmt.Sprintf("unknown column %q; must be among %s", name, strings.Join(known, ", ")))
+			return nil, v.err()
+		}
+	}
+	return selected, nil
+}
+
+func exportHeader[T any](columns []exportColumn[T]) []string {
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.name
+	}
+	return header
+}
+
+func exportValues[T any](columns []exportColumn[T], row *T) []string {
+	values := make([]string, len(columns))
+	for i, c := range columns {
+		values[i] = c.value(row)
+	}
+	return values
+}
+
+func parseExportFill(raw json.RawMessage) exportFill {
+	var fill exportFill
+	if len(raw) > 0 {
+		json.Unmarshal(raw, &fill)
+	}
+	return fill
+}
+
+// futuresExportFill returns the fill recorded on o, else the one in its raw response, for orders
+// stored before fills were tracked
+func futuresExportFill(o *models.FuturesOrder) exportFill {
+	if o.ExecutedQty > 0 {
+		return exportFill{ExecutedQty: formatFloat(o.ExecutedQty), AvgPrice: formatFloat(o.AvgFillPrice)}
+	}
+	return parseExportFill(o.RawResponse)
+}
+
+// exportTraded reports whether an order traded, going by its status and stored fill
+func exportTraded(status string, fill exportFill) bool {
+	if status == "FILLED" {
+		return true
+	}
+	executed, _ := strconv.ParseFloat(fill.ExecutedQty, 64)
+	return executed > 0
+}
+
+// exportTime formats t as RFC3339 in UTC, or empty when unset
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// exportDecimal re-formats a decimal string from Binance as a plain number, 0 when missing
+func exportDecimal(value string) string {
+	f, _ := strconv.ParseFloat(value, 64)
+	return formatFloat(f)
+}
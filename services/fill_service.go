@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/logging"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// FillQuery holds the filters and paging options for the fills feed
+type FillQuery = repository.FillQuery
+
+// FillPage is a page of fills, newest first
+type FillPage struct {
+	Items      []*models.Fill `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// GetFills returns a page of recorded fills, newest fill time first. With an account selected,
+// only that account's fills are listed.
+func (s *TradingService) GetFills(ctx context.Context, query *FillQuery) (*FillPage, error) {
+	query.AccountID = AccountFromContext(ctx)
+	fills, err := s.repos.Fills.List(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fills: %w", err)
+	}
+
+	page := &FillPage{Items: fills}
+	if int64(len(fills)) > query.PageLimit() {
+		page.Items = fills[:query.PageLimit()]
+		page.NextCursor = page.Items[len(page.Items)-1].ID.Hex()
+	}
+	return page, nil
+}
+
+// recordStreamFill stores the trade of a user data stream order update. Updates that executed
+// nothing (new, canceled or expired orders) are skipped. The stream belongs to the active account.
+func (s *TradingService) recordStreamFill(ctx context.Context, u *futures.WsOrderTradeUpdate) {
+	if u.ExecutionType != futures.OrderExecutionTypeTrade {
+		return
+	}
+	fill := &models.Fill{
+		Product:         models.FillProductFutures,
+		AccountID:       s.ActiveAccount(),
+		Symbol:          u.Symbol,
+		Side:            models.OrderSide(u.Side),
+		PositionSide:    models.PositionSide(u.PositionSide),
+		CommissionAsset: u.CommissionAsset,
+		Maker:           u.IsMaker,
+		TradeID:         u.TradeID,
+		BinanceOrderID:  u.ID,
+		ClientOrderID:   u.ClientOrderID,
+		FillTime:        time.UnixMilli(u.TradeTime),
+		CreatedAt:       time.Now(),
+	}
+	fill.Quantity, _ = strconv.ParseFloat(u.LastFilledQty, 64)
+	fill.Price, _ = strconv.ParseFloat(u.LastFilledPrice, 64)
+	fill.RealizedPnl, _ = strconv.ParseFloat(u.RealizedPnL, 64)
+	fill.Commission, _ = strconv.ParseFloat(u.Commission, 64)
+	fill.Source = s.sourceOf(u.ClientOrderID)
+	if fill.Source == models.OrderSourceExternal {
+		// Orders placed without a generated client order ID still have their source stored
+		if order, err := s.repos.FuturesOrders.FindByBinanceID(ctx, u.ID); err == nil && order.Source != "" {
+			fill.Source = order.Source
+		}
+	}
+	s.recordFill(ctx, fill)
+}
+
+// recordOptionsFill stores what an options order executed when it was placed. Binance reports
+// the order's total execution only, so it is stored as a single fill at the average price.
+func (s *TradingService) recordOptionsFill(ctx context.Context, order *binance.OptionsOrderResponse) {
+	executed, _ := strconv.ParseFloat(order.ExecutedQty, 64)
+	if executed <= 0 {
+		return
+	}
+	fillTime := time.UnixMilli(order.UpdateTime)
+	if order.UpdateTime <= 0 {
+		fillTime = time.Now()
+	}
+	fill := &models.Fill{
+		Product:        models.FillProductOptions,
+		AccountID:      s.accountID(ctx),
+		Symbol:         order.Symbol,
+		Side:           models.OrderSide(order.Side),
+		Quantity:       executed,
+		BinanceOrderID: order.OrderID,
+		FillTime:       fillTime,
+		CreatedAt:      time.Now(),
+	}
+	fill.Price, _ = strconv.ParseFloat(order.AvgPrice, 64)
+	fill.Commission, _ = strconv.ParseFloat(order.Fee, 64)
+	s.recordFill(ctx, fill)
+}
+
+// recordFill stores a fill; a failure is logged, as the order itself is already recorded
+func (s *TradingService) recordFill(ctx context.Context, fill *models.Fill) {
+	if _, err := s.repos.Fills.Insert(ctx, fill); err != nil {
+		logging.FromContext(ctx).Warn("failed to record fill", "symbol", fill.Symbol, "binance_order_id", fill.BinanceOrderID, "error", err)
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"futures-options/logging"
+	"futures-options/models"
+	"futures-options/notifications"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// SetFundingAlertThreshold sets the size of a single funding payment above which a
+// FUNDING_PAYMENT notification is sent; 0 disables the notification
+func (s *TradingService) SetFundingAlertThreshold(amount float64) {
+	s.fundingAlertThreshold = amount
+}
+
+// accrueFunding adds the funding payments among newly synced income records to the open
+// positions of their symbol. Income history reports funding per symbol, so the legs of a hedge-mode
+// position share each payment by notional. Payments made before a position's funding_since
+// belong to a position that was closed since and are not accrued.
+func (s *TradingService) accrueFunding(ctx context.Context, records []*models.IncomeRecord) {
+	var funding []*models.IncomeRecord
+	for _, rec := range records {
+		if rec.IncomeType == models.IncomeTypeFundingFee && rec.Income != 0 {
+			funding = append(funding, rec)
+		}
+	}
+	if len(funding) == 0 {
+		return
+	}
+
+	log := logging.FromContext(ctx)
+	positions, err := s.repos.Positions.List(ctx, "FUTURES", s.accountID(ctx))
+	if err != nil {
+		log.Warn("failed to load positions for funding accrual", "error", err)
+		return
+	}
+	for _, rec := range funding {
+		var legs []*models.Position
+		var notional float64
+		for _, p := range positions {
+			if p.Symbol != rec.Symbol || marketOf(p.Market) != models.MarketUSDM {
+				continue
+			}
+			if p.FundingSince != nil && rec.Time.Before(*p.FundingSince) {
+				continue
+			}
+			legs = append(legs, p)
+			notional += p.Notional
+		}
+		for _, p := range legs {
+			share := 1 / float64(len(legs))
+			if notional > 0 {
+				share = p.Notional / notional
+			}
+			// Binance reports funding paid as negative income
+			if err := s.repos.Positions.AddFunding(ctx, p.ID, -rec.Income*share); err != nil {
+				log.Warn("failed to accrue funding", "symbol", p.Symbol, "side", p.Side, "error", err)
+				continue
+			}
+			p.FundingPaid += -rec.Income * share
+		}
+		s.notifyFundingPayment(ctx, rec, legs)
+	}
+}
+
+// notifyFundingPayment sends a FUNDING_PAYMENT notification when a payment is larger than the
+// configured threshold. Funding received is not reported.
+func (s *TradingService) notifyFundingPayment(ctx context.Context, rec *models.IncomeRecord, legs []*models.Position) {
+	paid := -rec.Income
+	if s.fundingAlertThreshold <= 0 || paid <= s.fundingAlertThreshold {
+		return
+	}
+	var total float64
+	for _, p := range legs {
+		total += p.FundingPaid
+	}
+	s.notify(ctx, &notifications.Notification{
+		Title:     "Funding payment",
+		Message:   fmt.Sprintf("%s paid %s %s in funding", rec.Symbol, strconv.FormatFloat(paid, 'f', -1, 64), rec.Asset),
+		Priority:  notifications.PriorityNormal,
+		EventType: notifications.EventFundingPayment,
+		Fields: map[string]string{
+			"symbol":       rec.Symbol,
+			"amount":       strconv.FormatFloat(paid, 'f', -1, 64),
+			"asset":        rec.Asset,
+			"funding_paid": strconv.FormatFloat(total, 'f', -1, 64),
+			"time":         rec.Time.Format(time.RFC3339),
+		},
+	})
+}
+
+// resetClosedFunding restarts the funding accrual of the positions an account update reports
+// closed, so a position reopened on the same symbol and side starts from zero
+func (s *TradingService) resetClosedFunding(ctx context.Context, event *futures.WsUserDataEvent) {
+	at := time.UnixMilli(event.TransactionTime)
+	for _, p := range event.AccountUpdate.Positions {
+		amount, err := strconv.ParseFloat(p.Amount, 64)
+		if err != nil || amount != 0 {
+			continue
+		}
+		if err := s.repos.Positions.ResetFunding(ctx, p.Symbol, positionSideOf(string(p.Side)), s.ActiveAccount(), at); err != nil {
+			logging.FromContext(ctx).Warn("failed to reset funding accrual", "symbol", p.Symbol, "side", p.Side, "error", err)
+		}
+	}
+}
+
+// projectedFunding returns what a position pays at the next funding time at rate: positive when
+// it pays, negative when it receives. Longs pay a positive rate.
+func projectedFunding(positionSide string, quantity, notional, rate float64) float64 {
+	cost := rate * math.Abs(notional)
+	if positionSide == string(models.PositionSideShort) || positionSide != string(models.PositionSideLong) && quantity < 0 {
+		return -cost
+	}
+	return cost
+}
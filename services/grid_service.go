@@ -0,0 +1,469 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/repository"
+	"futures-options/strategy"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxGridCount bounds the number of grids, each of which keeps an open order
+	maxGridCount = 200
+	// gridClientIDTag marks the client order IDs of grid orders, which are routed back to their
+	// strategy from the user data stream
+	gridClientIDTag = "grid"
+)
+
+var (
+	// ErrGridNotFound is returned when no grid strategy matches the given ID
+	ErrGridNotFound = errors.New("grid strategy not found")
+	// ErrGridNotRunning is returned when stopping a strategy that already stopped
+	ErrGridNotRunning = errors.New("grid strategy is not running")
+)
+
+var (
+	gridDirections = []string{models.GridLong, models.GridShort, models.GridNeutral}
+	gridStatuses   = []string{string(models.GridRunning), string(models.GridStopped), string(models.GridFailed)}
+)
+
+// CreateGridStrategyRequest splits lower_price..upper_price into grid_count grids
+type CreateGridStrategyRequest struct {
+	Symbol     string  `json:"symbol"`
+	LowerPrice float64 `json:"lower_price"`
+	UpperPrice float64 `json:"upper_price"`
+	GridCount  int     `json:"grid_count"`
+	Quantity   float64 `json:"quantity"`  // per grid order
+	Direction  string  `json:"direction"` // LONG, SHORT or NEUTRAL
+	Leverage   int     `json:"leverage,omitempty"`
+}
+
+// UnmarshalJSON takes the price range and per-grid quantity as JSON numbers or decimal strings
+func (r *CreateGridStrategyRequest) UnmarshalJSON(data []byte) error {
+	type plain CreateGridStrategyRequest
+	return decodeDecimalFields(data, (*plain)(r))
+}
+
+// Validate checks the range, grid count and size
+func (r *CreateGridStrategyRequest) Validate() error {
+	v := &validator{}
+	v.required("symbol", r.Symbol)
+	v.positive("lower_price", r.LowerPrice)
+	v.positive("upper_price", r.UpperPrice)
+	if r.UpperPrice <= r.LowerPrice {
+		v.add("upper_price", RuleRange, "must be above lower_price")
+	}
+	if r.GridCount < 2 || r.GridCount > maxGridCount {
+		v.add("grid_count", RuleRange, fmt.Sprintf("must be between 2 and %d", maxGridCount))
+	}
+	v.positive("quantity", r.Quantity)
+	r.Direction = strings.ToUpper(r.Direction)
+	v.required("direction", r.Direction)
+	v.oneOf("direction", r.Direction, gridDirections...)
+	v.leverage("leverage", r.Leverage)
+	return v.err()
+}
+
+// CreateGridStrategy lays out the grids around the current price, opens the initial position of
+// LONG and SHORT strategies and places every grid's order
+func (s *TradingService) CreateGridStrategy(ctx context.Context, req *CreateGridStrategyRequest) (*models.GridStrategy, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	req.Symbol = strings.ToUpper(req.Symbol)
+	if err := s.checkOrderAllowed(ctx, req.Symbol); err != nil {
+		return nil, err
+	}
+
+	filters, err := s.api(ctx).GetSymbolFilters(ctx, req.Symbol)
+	if errors.Is(err, binance.ErrUnknownSymbol) {
+		v := &validator{}
+		v.add("symbol", RuleEnum, "is not a listed futures symbol")
+		return nil, v.err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	mark, err := s.currentMarkPrice(ctx, req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &validator{}
+	quantity := checkGridFit(v, "", req, filters, mark, "current price")
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	// Every grid rests a limit order; the initial position is opened at market
+	if err := s.checkOpenOrderCaps(ctx, map[string]*orderDemand{req.Symbol: {orders: req.GridCount}}); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	st := &models.GridStrategy{
+		ID:         primitive.NewObjectID(),
+		Symbol:     req.Symbol,
+		LowerPrice: req.LowerPrice,
+		UpperPrice: req.UpperPrice,
+		GridCount:  req.GridCount,
+		Quantity:   quantity,
+		Direction:  req.Direction,
+		Leverage:   req.Leverage,
+		Status:     models.GridRunning,
+		CreatedBy:  PrincipalFromContext(ctx),
+		StartPrice: mark,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	held := strategy.LayoutGrids(st, filters.TickSize, mark)
+	if err := s.repos.Grids.Insert(ctx, st); err != nil {
+		return nil, fmt.Errorf("failed to save grid strategy: %w", err)
+	}
+	log := slog.With("grid_id", st.ID.Hex(), "symbol", st.Symbol)
+	log.Info("grid strategy created", "direction", st.Direction, "grids", st.GridCount, "lower_price", st.LowerPrice, "upper_price", st.UpperPrice)
+
+	ctx = WithPrincipal(ctx, st.CreatedBy)
+	s.gridMu.Lock()
+	defer s.gridMu.Unlock()
+
+	// Leverage is set with the first order only
+	leverage := st.Leverage
+	if held > 0 {
+		side := models.OrderSideBuy
+		if st.Direction == models.GridShort {
+			side = models.OrderSideSell
+		}
+		st.OrdersPlaced++
+		order, err := s.CreateAdvancedFuturesOrder(ctx, &AdvancedOrderRequest{
+			Symbol:        st.Symbol,
+			Side:          string(side),
+			OrderType:     string(models.OrderTypeMarket),
+			Quantity:      decimal.NewFromFloat(held),
+			Leverage:      leverage,
+			ClientOrderID: s.gridClientOrderID(st),
+			Source:        models.OrderSourceGrid,
+		})
+		if err != nil {
+			st.Status = models.GridFailed
+			st.LastError = "failed to open the initial position: " + err.Error()
+			s.saveGridStrategy(ctx, st)
+			return nil, err
+		}
+		st.InitialOrderID = order.BinanceOrderID
+		st.Position = held
+		if side == models.OrderSideSell {
+			st.Position = -held
+		}
+		leverage = 0
+	}
+	for i := range st.Grids {
+		if err := s.placeGridOrder(ctx, st, &st.Grids[i], leverage); err == nil {
+			leverage = 0
+		}
+	}
+	s.saveGridStrategy(ctx, st)
+	return st, nil
+}
+
+// checkGridFit checks that req's range contains price, named priceName in the error, and fits
+// the symbol's tick and lot sizes, and returns the per grid quantity rounded to the lot size.
+// Fields are reported under prefix.
+func checkGridFit(v *validator, prefix string, req *CreateGridStrategyRequest, filters *binance.SymbolFilters, price float64, priceName string) float64 {
+	if price <= req.LowerPrice || price >= req.UpperPrice {
+		v.add(prefix+"lower_price", RuleRange, fmt.Sprintf("the range must contain the %s %s", priceName, formatFloat(price)))
+	}
+	if (req.UpperPrice-req.LowerPrice)/float64(req.GridCount) < 2*filters.TickSize {
+		v.add(prefix+"grid_count", RuleRange, "leaves grids narrower than two price ticks")
+	}
+	quantity := roundToStep(req.Quantity, filters.StepSize, strategy.RoundDown)
+	if quantity < filters.MinQty || quantity*req.LowerPrice < filters.MinNotional {
+		v.add(prefix+"quantity", RuleRange, fmt.Sprintf("is below the symbol's minimum quantity %s or notional %s",
+			formatFloat(filters.MinQty), formatFloat(filters.MinNotional)))
+	}
+	return quantity
+}
+
+// placeGridOrder places g's limit order: a buy at its lower or a sell at its upper price
+func (s *TradingService) placeGridOrder(ctx context.Context, st *models.GridStrategy, g *models.Grid, leverage int) error {
+	price := strategy.GridOrderPrice(g)
+	st.OrdersPlaced++
+	clientOrderID := s.gridClientOrderID(st)
+	order, err := s.CreateAdvancedFuturesOrder(ctx, &AdvancedOrderRequest{
+		Symbol:        st.Symbol,
+		Side:          g.Side,
+		OrderType:     string(models.OrderTypeLimit),
+		Quantity:      decimal.NewFromFloat(st.Quantity),
+		Price:         decimal.NewFromFloat(price),
+		TimeInForce:   string(models.TimeInForceGTC),
+		Leverage:      leverage,
+		ClientOrderID: clientOrderID,
+		Source:        models.OrderSourceGrid,
+	})
+	if err != nil {
+		slog.Warn("failed to place grid order", "grid_id", st.ID.Hex(), "grid", g.Index, "side", g.Side, "price", price, "error", err)
+		g.Error = err.Error()
+		st.LastError = fmt.Sprintf("grid %d: %v", g.Index, err)
+		return err
+	}
+	g.OrderID = order.BinanceOrderID
+	g.ClientOrderID = clientOrderID
+	g.Error = ""
+	return nil
+}
+
+// applyGridFill books the fill of g's order and, while st runs, places the opposite order one
+// level away. A fill that closes what the grid's previous fill opened earns the grid's spread.
+func (s *TradingService) applyGridFill(ctx context.Context, st *models.GridStrategy, g *models.Grid) {
+	side := g.Side
+	strategy.ApplyGridFill(st, g)
+	slog.Info("grid order filled", "grid_id", st.ID.Hex(), "grid", g.Index, "side", side, "binance_order_id", g.OrderID,
+		"fills", st.FillCount, "realized_profit", st.RealizedProfit)
+
+	g.OrderID = 0
+	g.ClientOrderID = ""
+	if st.Status == models.GridRunning && !s.strategyPaused(ctx, st.Symbol) {
+		s.placeGridOrder(ctx, st, g, 0)
+	}
+}
+
+// handleGridOrderUpdate routes a grid order's fill from the user data stream to its strategy
+func (s *TradingService) handleGridOrderUpdate(ctx context.Context, u *futures.WsOrderTradeUpdate) {
+	if u.Status != futures.OrderStatusTypeFilled {
+		return
+	}
+	source, hexID := s.parseClientOrderID(u.ClientOrderID)
+	if source != models.OrderSourceGrid {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return
+	}
+	// Placing the next order must not hold up the stream
+	s.runBackground(func() {
+		ctx := context.WithoutCancel(ctx)
+		s.gridMu.Lock()
+		defer s.gridMu.Unlock()
+
+		st, err := s.repos.Grids.FindByID(ctx, id)
+		if err != nil {
+			slog.Error("failed to load grid strategy", "grid_id", hexID, "error", err)
+			return
+		}
+		if st.Status != models.GridRunning {
+			return
+		}
+		for i := range st.Grids {
+			if st.Grids[i].OrderID == u.ID {
+				s.applyGridFill(WithPrincipal(ctx, st.CreatedBy), st, &st.Grids[i])
+				s.saveGridStrategy(ctx, st)
+				return
+			}
+		}
+	})
+}
+
+// StartGridStrategies catches the running strategies up with fills missed while the server was
+// down, then keeps checking their orders every interval until ctx is done. The check also covers
+// paper trading, which has no user data stream, and re-places orders canceled outside the bot.
+func (s *TradingService) StartGridStrategies(ctx context.Context, interval time.Duration) {
+	s.runBackground(func() {
+		s.syncGridStrategies(context.WithoutCancel(ctx))
+		if interval <= 0 {
+			return
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.syncGridStrategies(context.WithoutCancel(ctx))
+			}
+		}
+	})
+}
+
+func (s *TradingService) syncGridStrategies(ctx context.Context) {
+	running, err := s.repos.Grids.List(ctx, models.GridRunning)
+	if err != nil {
+		slog.Error("failed to load grid strategies", "error", err)
+		return
+	}
+	for _, listed := range running {
+		s.gridMu.Lock()
+		// Reload under the lock in case the strategy was stopped meanwhile
+		if st, err := s.repos.Grids.FindByID(ctx, listed.ID); err != nil {
+			slog.Error("failed to load grid strategy", "grid_id", listed.ID.Hex(), "error", err)
+		} else if st.Status == models.GridRunning && s.syncGridOrders(WithPrincipal(ctx, st.CreatedBy), st) {
+			s.saveGridStrategy(ctx, st)
+		}
+		s.gridMu.Unlock()
+	}
+}
+
+// syncGridOrders checks each grid's order on Binance, booking fills and, while st runs,
+// replacing orders that are missing or ended without filling. It reports whether st changed.
+// s.gridMu must be held.
+func (s *TradingService) syncGridOrders(ctx context.Context, st *models.GridStrategy) bool {
+	changed := false
+	for i := range st.Grids {
+		g := &st.Grids[i]
+		if g.OrderID != 0 {
+			live, err := s.api(ctx).GetFuturesOrder(ctx, st.Symbol, g.OrderID)
+			if err != nil {
+				slog.Warn("failed to get grid order", "grid_id", st.ID.Hex(), "grid", g.Index, "binance_order_id", g.OrderID, "error", err)
+				continue
+			}
+			s.recordOrderFill(ctx, st.Symbol, g.OrderID, "", futuresOrderFill(live))
+			switch live.Status {
+			case futures.OrderStatusTypeFilled:
+				s.applyGridFill(ctx, st, g)
+				changed = true
+				continue
+			case futures.OrderStatusTypeCanceled, futures.OrderStatusTypeExpired, futures.OrderStatusTypeRejected:
+				g.OrderID = 0
+				g.ClientOrderID = ""
+				changed = true
+			default:
+				continue
+			}
+		}
+		// Missing orders wait out a trading pause or a symbol halt and are placed on the first pass after it
+		if st.Status == models.GridRunning && !s.strategyPaused(ctx, st.Symbol) {
+			s.placeGridOrder(ctx, st, g, 0)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// ListGridStrategies returns grid strategies newest first, optionally by status
+func (s *TradingService) ListGridStrategies(ctx context.Context, status string) ([]*models.GridStrategy, error) {
+	status = strings.ToUpper(status)
+	v := &validator{}
+	v.oneOf("status", status, gridStatuses...)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return s.repos.Grids.List(ctx, models.GridStatus(status))
+}
+
+// GetGridStrategy returns one grid strategy with its grids, fill count and profit
+func (s *TradingService) GetGridStrategy(ctx context.Context, id string) (*models.GridStrategy, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	st, err := s.repos.Grids.FindByID(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrGridNotFound
+	}
+	return st, err
+}
+
+// StopGridStrategy cancels a strategy's open orders, booking fills that beat the cancellation,
+// and with flatten closes its accumulated position with a reduce-only market order
+func (s *TradingService) StopGridStrategy(ctx context.Context, id string, flatten bool) (*models.GridStrategy, error) {
+	s.gridMu.Lock()
+	defer s.gridMu.Unlock()
+
+	st, err := s.GetGridStrategy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if st.Status != models.GridRunning {
+		return nil, ErrGridNotRunning
+	}
+	ctx = WithPrincipal(ctx, st.CreatedBy)
+
+	now := time.Now()
+	st.Status = models.GridStopped
+	st.StoppedAt = &now
+	var orderIDs []int64
+	for _, g := range st.Grids {
+		if g.OrderID != 0 {
+			orderIDs = append(orderIDs, g.OrderID)
+		}
+	}
+	if len(orderIDs) > 0 {
+		if err := s.CancelBatchOrders(ctx, st.Symbol, orderIDs, nil); err != nil {
+			slog.Warn("failed to cancel grid orders", "grid_id", st.ID.Hex(), "error", err)
+		}
+	}
+	s.syncGridOrders(ctx, st)
+	slog.Info("grid strategy stopped", "grid_id", st.ID.Hex(), "fills", st.FillCount, "realized_profit", st.RealizedProfit, "position", st.Position)
+
+	if flatten {
+		if err := s.flattenGridPosition(ctx, st); err != nil {
+			st.LastError = "failed to flatten position: " + err.Error()
+			s.saveGridStrategy(ctx, st)
+			return nil, fmt.Errorf("grid strategy stopped but its position was not flattened: %w", err)
+		}
+	}
+	s.saveGridStrategy(ctx, st)
+	return st, nil
+}
+
+// flattenGridPosition closes st's net position with a reduce-only market order
+func (s *TradingService) flattenGridPosition(ctx context.Context, st *models.GridStrategy) error {
+	filters, err := s.api(ctx).GetSymbolFilters(ctx, st.Symbol)
+	if err != nil {
+		return err
+	}
+	step := filters.StepSize
+	if filters.MarketStepSize > 0 {
+		step = filters.MarketStepSize
+	}
+	quantity := roundToStep(math.Abs(st.Position), step, strategy.RoundNearest)
+	if quantity <= 0 {
+		return nil
+	}
+	side := models.OrderSideSell
+	if st.Position < 0 {
+		side = models.OrderSideBuy
+	}
+	st.OrdersPlaced++
+	order, err := s.CreateAdvancedFuturesOrder(ctx, &AdvancedOrderRequest{
+		Symbol:        st.Symbol,
+		Side:          string(side),
+		OrderType:     string(models.OrderTypeMarket),
+		Quantity:      decimal.NewFromFloat(quantity),
+		ReduceOnly:    true,
+		ClientOrderID: s.gridClientOrderID(st),
+		Source:        models.OrderSourceGrid,
+	})
+	if err != nil {
+		return err
+	}
+	st.FlattenOrderID = order.BinanceOrderID
+	st.Position = 0
+	slog.Info("grid position flattened", "grid_id", st.ID.Hex(), "side", side, "quantity", quantity)
+	return nil
+}
+
+func (s *TradingService) saveGridStrategy(ctx context.Context, st *models.GridStrategy) {
+	st.UpdatedAt = time.Now()
+	if err := s.repos.Grids.Update(ctx, st); err != nil {
+		slog.Error("failed to save grid strategy", "grid_id", st.ID.Hex(), "error", err)
+	}
+}
+
+// gridClientOrderID numbers st's orders; call after incrementing st.OrdersPlaced
+func (s *TradingService) gridClientOrderID(st *models.GridStrategy) string {
+	return s.strategyClientOrderID(models.OrderSourceGrid, st.ID, st.OrdersPlaced)
+}
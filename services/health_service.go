@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/database"
+)
+
+// healthCheckTimeout bounds each dependency check so a hung dependency cannot stall the probe
+const healthCheckTimeout = 2 * time.Second
+
+// Credential sources reported by the health endpoint
+const (
+	CredentialSourceDatabase    = "database"
+	CredentialSourceEnvironment = "environment"
+	CredentialSourceNone        = "none"
+)
+
+// Health statuses
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusUnhealthy = "unhealthy"
+)
+
+// HealthCheckResult is the outcome of a single dependency check
+type HealthCheckResult struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthReport describes the state of the service and its dependencies
+type HealthReport struct {
+	Status                  string                        `json:"status"`
+	Network                 string                        `json:"network"`
+	CredentialSource        string                        `json:"credential_source,omitempty"`
+	UserDataStreamConnected bool                          `json:"user_data_stream_connected"`
+	Paper                   bool                          `json:"paper,omitempty"`
+	BinanceCircuit          binance.BreakerSnapshot       `json:"binance_circuit"`
+	Checks                  map[string]*HealthCheckResult `json:"checks,omitempty"`
+	Failing                 []string                      `json:"failing,omitempty"`
+	Timestamp               time.Time                     `json:"timestamp"`
+}
+
+// SetCredentialSource records where the live API keys came from (database, environment or none)
+func (s *TradingService) SetCredentialSource(source string) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.credentialSource = source
+}
+
+// CredentialSource returns where the live API keys came from
+func (s *TradingService) CredentialSource() string {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	if s.credentialSource == "" {
+		return CredentialSourceNone
+	}
+	return s.credentialSource
+}
+
+// UserDataStreamConnected reports whether the user data stream is currently connected
+func (s *TradingService) UserDataStreamConnected() bool {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.wsClient != nil && s.wsClient.IsConnected()
+}
+
+// BinanceCircuit returns the state of the circuit breaker guarding Binance requests
+func (s *TradingService) BinanceCircuit() binance.BreakerSnapshot {
+	return s.binanceClient.Breaker().Snapshot()
+}
+
+// RateLimitStatus is the latest usage of Binance's rate limits, reported by REST responses (per
+// API) and WS-API responses
+type RateLimitStatus struct {
+	Limits []binance.RateLimit `json:"limits"`
+}
+
+// BinanceRateLimits returns the latest usage of Binance's rate limits over REST and the WS-API
+func (s *TradingService) BinanceRateLimits() *RateLimitStatus {
+	return &RateLimitStatus{Limits: binance.RateLimits()}
+}
+
+// LeverageCacheStats returns how often orders could skip the change-leverage call
+func (s *TradingService) LeverageCacheStats() binance.LeverageCacheStats {
+	return s.binanceClient.LeverageCacheStats()
+}
+
+// DatabasePoolStats returns the MongoDB connection pool counters
+func (s *TradingService) DatabasePoolStats() database.PoolStats {
+	return database.GetPoolStats()
+}
+
+// CheckHealth reports the service state; unless quick is set it also pings MongoDB and Binance
+func (s *TradingService) CheckHealth(ctx context.Context, quick bool) *HealthReport {
+	report := &HealthReport{
+		Status:                  HealthStatusHealthy,
+		Network:                 s.Network(),
+		CredentialSource:        s.CredentialSource(),
+		UserDataStreamConnected: s.UserDataStreamConnected(),
+		Paper:                   s.Paper(),
+		BinanceCircuit:          s.BinanceCircuit(),
+		Timestamp:               time.Now(),
+	}
+	if quick {
+		return report
+	}
+
+	checks := map[string]func(context.Context) error{
+		"mongodb": database.Ping,
+		"binance": s.binanceClient.Ping,
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	report.Checks = make(map[string]*HealthCheckResult, len(checks))
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) error) {
+			defer wg.Done()
+			result := runHealthCheck(ctx, check)
+			mu.Lock()
+			report.Checks[name] = result
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	for _, name := range []string{"mongodb", "binance"} {
+		if report.Checks[name].Status != HealthStatusHealthy {
+			report.Status = HealthStatusUnhealthy
+			report.Failing = append(report.Failing, name)
+		}
+	}
+	return report
+}
+
+// runHealthCheck runs one check with a timeout and measures its latency
+func runHealthCheck(ctx context.Context, check func(context.Context) error) *HealthCheckResult {
+	return runTimedCheck(ctx, healthCheckTimeout, check)
+}
+
+// runTimedCheck runs check with the given timeout and measures its latency
+func runTimedCheck(ctx context.Context, timeout time.Duration, check func(context.Context) error) *HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := &HealthCheckResult{
+		Status:    HealthStatusHealthy,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = HealthStatusUnhealthy
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"futures-options/models"
+)
+
+// Job run triggers recorded on models.JobRun
+const (
+	JobTriggerScheduled = "scheduled"
+	JobTriggerManual    = "manual"
+)
+
+// Names of the built-in background jobs
+const (
+	JobExchangeInfoRefresh = "exchange-info-refresh"
+	JobOrderReconcile      = "order-reconcile"
+	JobEquitySnapshot      = "equity-snapshot"
+	JobPositionSync        = "position-sync"
+	JobIncomeSync          = "income-sync"
+	JobListenKeyKeepalive  = "listen-key-keepalive"
+)
+
+// jobJitter spreads each wait by up to this fraction of the interval either way, so jobs sharing
+// an interval do not call Binance at the same moment
+const jobJitter = 0.1
+
+var (
+	// ErrJobNotFound is returned for a job name that is not registered
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobRunning is returned when a job is triggered while its previous run is still going
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// Job is periodic background work run by the job scheduler
+type Job struct {
+	Name     string
+	Interval time.Duration // 0 registers the job for manual runs only
+	Run      func(ctx context.Context) error
+}
+
+type scheduledJob struct {
+	Job
+	running atomic.Bool
+
+	mu      sync.Mutex
+	nextRun time.Time
+}
+
+// jobScheduler holds the registered jobs in registration order
+type jobScheduler struct {
+	mu   sync.Mutex
+	jobs []*scheduledJob
+}
+
+// JobStatus is a registered job with its schedule and latest run
+type JobStatus struct {
+	Name      string         `json:"name"`
+	Interval  string         `json:"interval,omitempty"` // empty for manual-only jobs
+	Enabled   bool           `json:"enabled"`            // runs on its interval
+	Running   bool           `json:"running"`
+	NextRunAt *time.Time     `json:"next_run_at,omitempty"`
+	LastRun   *models.JobRun `json:"last_run,omitempty"`
+}
+
+// RegisterJob adds a job and, when it has an interval, runs it every interval (with jitter) until
+// ctx is done; Shutdown waits for it. A run never overlaps the job's previous run. Registering a
+// name twice keeps the first job.
+func (s *TradingService) RegisterJob(ctx context.Context, job Job) {
+	j := &scheduledJob{Job: job}
+	s.jobs.mu.Lock()
+	for _, existing := range s.jobs.jobs {
+		if existing.Name == job.Name {
+			s.jobs.mu.Unlock()
+			slog.Warn("job already registered", "job", job.Name)
+			return
+		}
+	}
+	s.jobs.jobs = append(s.jobs.jobs, j)
+	s.jobs.mu.Unlock()
+
+	if job.Interval <= 0 {
+		return
+	}
+	s.runBackground(func() {
+		for {
+			wait := jitter(job.Interval)
+			j.mu.Lock()
+			j.nextRun = time.Now().Add(wait)
+			j.mu.Unlock()
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			// A run that has started finishes its writes even if shutdown begins
+			if _, err := s.runJob(context.WithoutCancel(ctx), j, JobTriggerScheduled); errors.Is(err, ErrJobRunning) {
+				slog.Info("skipping job run, previous run still going", "job", job.Name)
+			}
+		}
+	})
+}
+
+// ListJobs returns the registered jobs in registration order with their latest recorded run
+func (s *TradingService) ListJobs(ctx context.Context) ([]*JobStatus, error) {
+	runs, err := s.repos.Jobs.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job runs: %w", err)
+	}
+	lastRun := make(map[string]*models.JobRun, len(runs))
+	for _, run := range runs {
+		lastRun[run.Name] = run
+	}
+
+	s.jobs.mu.Lock()
+	jobs := append([]*scheduledJob(nil), s.jobs.jobs...)
+	s.jobs.mu.Unlock()
+
+	statuses := make([]*JobStatus, len(jobs))
+	for i, j := range jobs {
+		status := &JobStatus{
+			Name:    j.Name,
+			Enabled: j.Interval > 0,
+			Running: j.running.Load(),
+			LastRun: lastRun[j.Name],
+		}
+		if j.Interval > 0 {
+			status.Interval = j.Interval.String()
+			j.mu.Lock()
+			if !j.nextRun.IsZero() {
+				next := j.nextRun
+				status.NextRunAt = &next
+			}
+			j.mu.Unlock()
+		}
+		statuses[i] = status
+	}
+	return statuses, nil
+}
+
+// RunJob runs the named job now and returns the recorded run; a failed run is reported in the
+// run's error rather than returned
+func (s *TradingService) RunJob(ctx context.Context, name string) (*models.JobRun, error) {
+	s.jobs.mu.Lock()
+	var job *scheduledJob
+	for _, j := range s.jobs.jobs {
+		if j.Name == name {
+			job = j
+			break
+		}
+	}
+	s.jobs.mu.Unlock()
+	if job == nil {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+	// The run is not cut short if the client goes away
+	return s.runJob(context.WithoutCancel(ctx), job, JobTriggerManual)
+}
+
+// runJob runs j unless it is already running and records the run
+func (s *TradingService) runJob(ctx context.Context, j *scheduledJob, trigger string) (*models.JobRun, error) {
+	if !j.running.CompareAndSwap(false, true) {
+		return nil, ErrJobRunning
+	}
+	defer j.running.Store(false)
+
+	run := &models.JobRun{Name: j.Name, Trigger: trigger, StartedAt: time.Now()}
+	err := j.Run(ctx)
+	run.FinishedAt = time.Now()
+	run.DurationMs = run.FinishedAt.Sub(run.StartedAt).Milliseconds()
+	if err != nil {
+		run.Error = err.Error()
+		slog.Warn("job failed", "job", j.Name, "trigger", trigger, "duration_ms", run.DurationMs, "error", err)
+	}
+
+	if err := s.repos.Jobs.RecordRun(ctx, run); err != nil {
+		slog.Warn("failed to record job run", "job", j.Name, "error", err)
+	}
+	return run, nil
+}
+
+// jitter returns interval moved by a random amount of up to jobJitter of it either way
+func jitter(interval time.Duration) time.Duration {
+	spread := time.Duration(float64(interval) * jobJitter)
+	if spread <= 0 {
+		return interval
+	}
+	return interval - spread + time.Duration(rand.Int63n(int64(2*spread)))
+}
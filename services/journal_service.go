@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"futures-options/models"
+	"futures-options/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrJournalTargetNotFound is returned when no order or position has the ID being annotated
+	ErrJournalTargetNotFound = errors.New("order or position not found")
+	// ErrJournalEntryNotFound is returned when no journal entry has the ID
+	ErrJournalEntryNotFound = errors.New("journal entry not found")
+)
+
+// Journal limits
+const (
+	maxJournalNote      = 5000
+	maxJournalTags      = 20
+	maxJournalTag       = 50
+	defaultJournalLimit = 100
+	maxJournalLimit     = 1000
+)
+
+// JournalEntryRequest creates or replaces a journal entry. Tags are trimmed, lower cased and
+// deduplicated.
+type JournalEntryRequest struct {
+	Note string   `json:"note,omitempty"`
+	Tags []string `json:"tags,omitempty"` // e.g. ["breakout", "news"]
+}
+
+// Validate normalizes the tags and checks that the entry says something
+func (r *JournalEntryRequest) Validate() error {
+	v := &validator{}
+	r.Note = strings.TrimSpace(r.Note)
+	r.Tags = normalizeTags(r.Tags)
+	if r.Note == "" && len(r.Tags) == 0 {
+		v.add("note", RuleRequired, "a note or at least one tag is required")
+	}
+	if len(r.Note) > maxJournalNote {
+		v.add("note", RuleRange, fmt.Sprintf("must be at most %d characters", maxJournalNote))
+	}
+	if len(r.Tags) > maxJournalTags {
+		v.add("tags", RuleRange, fmt.Sprintf("must be at most %d tags", maxJournalTags))
+	}
+	for _, tag := range r.Tags {
+		if len(tag) > maxJournalTag {
+			v.add("tags", RuleRange, fmt.Sprintf("each tag must be at most %d characters", maxJournalTag))
+			break
+		}
+	}
+	return v.err()
+}
+
+// normalizeTags trims, lower cases and deduplicates tags, dropping empty ones and keeping the
+// first occurrence's position
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// JournalFilter selects journal entries; empty fields match everything
+type JournalFilter struct {
+	OrderID string // Mongo ID of the order or position
+	Symbol  string
+	Tags    []string // entries carrying every one of them
+	Limit   int64    // default 100, max 1000
+}
+
+// CreateJournalEntry annotates the futures, options or spot order or the position stored under
+// targetID. The entry records the target's symbol and the principal that wrote it.
+func (s *TradingService) CreateJournalEntry(ctx context.Context, targetID string, req *JournalEntryRequest) (*models.JournalEntry, error) {
+	objectID, err := primitive.ObjectIDFromHex(targetID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	target, symbol, err := s.journalTarget(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry := &models.JournalEntry{
+		OrderID:   objectID,
+		Target:    target,
+		Symbol:    symbol,
+		Note:      req.Note,
+		Tags:      req.Tags,
+		CreatedBy: PrincipalFromContext(ctx),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repos.Journal.Insert(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to save journal entry: %w", err)
+	}
+	return entry, nil
+}
+
+// journalTarget finds what id refers to, trying futures, options and spot orders and then
+// positions, and returns its kind and symbol
+func (s *TradingService) journalTarget(ctx context.Context, id primitive.ObjectID) (string, string, error) {
+	lookups := []struct {
+		target string
+		find   func() (string, error)
+	}{
+		{models.JournalFuturesOrder, func() (string, error) {
+			o, err := s.repos.FuturesOrders.FindByID(ctx, id)
+			if err != nil {
+				return "", err
+			}
+			return o.Symbol, nil
+		}},
+		{models.JournalOptionsOrder, func() (string, error) {
+			o, err := s.repos.OptionsOrders.FindByID(ctx, id)
+			if err != nil {
+				return "", err
+			}
+			return o.Symbol, nil
+		}},
+		{models.JournalSpotOrder, func() (string, error) {
+			o, err := s.repos.SpotOrders.FindByID(ctx, id)
+			if err != nil {
+				return "", err
+			}
+			return o.Symbol, nil
+		}},
+		{models.JournalPosition, func() (string, error) {
+			p, err := s.repos.Positions.FindByID(ctx, id)
+			if err != nil {
+				return "", err
+			}
+			return p.Symbol, nil
+		}},
+	}
+	for _, lookup := range lookups {
+		symbol, err := lookup.find()
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("failed to look up journal target: %w", err)
+		}
+		return lookup.target, symbol, nil
+	}
+	return "", "", ErrJournalTargetNotFound
+}
+
+// ListJournalEntries returns the entries matching filter, newest first
+func (s *TradingService) ListJournalEntries(ctx context.Context, filter *JournalFilter) ([]*models.JournalEntry, error) {
+	v := &validator{}
+	if filter.Limit > maxJournalLimit {
+		v.add("limit", RuleRange, fmt.Sprintf("must be at most %d", maxJournalLimit))
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	query := &repository.JournalQuery{
+		Symbol: strings.ToUpper(filter.Symbol),
+		Tags:   normalizeTags(filter.Tags),
+		Limit:  filter.Limit,
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultJournalLimit
+	}
+	if filter.OrderID != "" {
+		objectID, err := primitive.ObjectIDFromHex(filter.OrderID)
+		if err != nil {
+			return nil, ErrInvalidID
+		}
+		query.OrderID = &objectID
+	}
+	entries, err := s.repos.Journal.List(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journal entries: %w", err)
+	}
+	return entries, nil
+}
+
+// UpdateJournalEntry replaces the note and tags of entry id
+func (s *TradingService) UpdateJournalEntry(ctx context.Context, id string, req *JournalEntryRequest) (*models.JournalEntry, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	entry, err := s.repos.Journal.FindByID(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrJournalEntryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journal entry: %w", err)
+	}
+
+	entry.Note = req.Note
+	entry.Tags = req.Tags
+	entry.UpdatedAt = time.Now()
+	err = s.repos.Journal.Update(ctx, entry)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrJournalEntryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update journal entry: %w", err)
+	}
+	return entry, nil
+}
+
+// DeleteJournalEntry removes entry id
+func (s *TradingService) DeleteJournalEntry(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	err = s.repos.Journal.Delete(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrJournalEntryNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete journal entry: %w", err)
+	}
+	return nil
+}
+
+// JournalTags returns the distinct tags in use starting with prefix, sorted, for autocomplete
+func (s *TradingService) JournalTags(ctx context.Context, prefix string) ([]string, error) {
+	tags, err := s.repos.Journal.Tags(ctx, strings.ToLower(strings.TrimSpace(prefix)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journal tags: %w", err)
+	}
+	return tags, nil
+}
+
+// latestJournalEntries returns the newest journal entry of each of ids that has one, for order
+// listings with include_journal
+func (s *TradingService) latestJournalEntries(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*models.JournalEntry, error) {
+	latest, err := s.repos.Journal.Latest(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load journal entries: %w", err)
+	}
+	return latest, nil
+}
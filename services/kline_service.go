@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+)
+
+// maxKlines bounds the candles one request loads
+const maxKlines = 50000
+
+// klineIntervals lists the supported kline intervals for validation messages
+var klineIntervals = func() []string {
+	intervals := make([]string, 0, len(binance.KlineIntervals))
+	for interval := range binance.KlineIntervals {
+		intervals = append(intervals, interval)
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return binance.KlineIntervals[intervals[i]] < binance.KlineIntervals[intervals[j]]
+	})
+	return intervals
+}()
+
+// validateKlineRange checks a kline interval and time range, reporting fields under prefix. A
+// zero end means now.
+func validateKlineRange(v *validator, prefix, interval string, start, end time.Time) {
+	length, ok := binance.KlineIntervals[interval]
+	if !ok {
+		v.add(prefix+"interval", RuleEnum, "must be one of "+strings.Join(klineIntervals, ", "))
+	}
+	if start.IsZero() {
+		v.add(prefix+"start_time", RuleRequired, "is required")
+		return
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if !end.After(start) {
+		v.add(prefix+"end_time", RuleRange, "must be after start_time")
+		return
+	}
+	if ok && end.Sub(start)/length > maxKlines {
+		v.add(prefix+"start_time", RuleRange, fmt.Sprintf("the range spans more than %d candles of %s", maxKlines, interval))
+	}
+}
+
+// GetKlines returns symbol's closed klines of interval opening in [start, end), oldest first; a
+// zero end means now. They are served from the kline cache when it holds every candle of the
+// range, and otherwise fetched from Binance and cached.
+func (s *TradingService) GetKlines(ctx context.Context, symbol, interval string, start, end time.Time) ([]*models.Kline, error) {
+	v := &validator{}
+	v.required("symbol", symbol)
+	validateKlineRange(v, "", interval, start, end)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	symbol = strings.ToUpper(symbol)
+	now := time.Now()
+	if end.IsZero() || end.After(now) {
+		end = now
+	}
+
+	cached, err := s.repos.Klines.List(ctx, symbol, interval, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load klines: %w", err)
+	}
+	// Candles open on multiples of the interval; those closed by now are expected in the cache.
+	// Before a symbol's listing there are none, so such ranges are always fetched.
+	length := binance.KlineIntervals[interval]
+	first := start.Truncate(length)
+	if first.Before(start) {
+		first = first.Add(length)
+	}
+	expected := 0
+	if last := end.Add(-length); !last.Before(first) {
+		expected = int(last.Sub(first)/length) + 1
+	}
+	if len(cached) >= expected {
+		return cached, nil
+	}
+
+	fetched, err := s.api(ctx).GetKlines(ctx, symbol, interval, start, end)
+	if err != nil {
+		return nil, err
+	}
+	klines := make([]*models.Kline, 0, len(fetched))
+	for _, k := range fetched {
+		kline := &models.Kline{
+			Symbol:    symbol,
+			Interval:  interval,
+			OpenTime:  time.UnixMilli(k.OpenTime).UTC(),
+			CloseTime: time.UnixMilli(k.CloseTime).UTC(),
+			Trades:    k.TradeNum,
+		}
+		kline.Open, _ = strconv.ParseFloat(k.Open, 64)
+		kline.High, _ = strconv.ParseFloat(k.High, 64)
+		kline.Low, _ = strconv.ParseFloat(k.Low, 64)
+		kline.Close, _ = strconv.ParseFloat(k.Close, 64)
+		kline.Volume, _ = strconv.ParseFloat(k.Volume, 64)
+		kline.QuoteVolume, _ = strconv.ParseFloat(k.QuoteAssetVolume, 64)
+		// The candle still forming is neither returned nor cached
+		if !kline.CloseTime.Before(now) {
+			continue
+		}
+		klines = append(klines, kline)
+	}
+	if err := s.repos.Klines.Upsert(ctx, klines); err != nil {
+		slog.Warn("failed to cache klines", "symbol", symbol, "interval", interval, "error", err)
+	}
+	return klines, nil
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"futures-options/logging"
+	"futures-options/models"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// defaultMarginRatioWarning flags positions whose maintenance margin uses 80% of their margin balance
+const defaultMarginRatioWarning = 0.8
+
+var marginTypes = []string{string(futures.MarginTypeIsolated), string(futures.MarginTypeCrossed)}
+
+// LiquidationRequest describes a prospective position
+type LiquidationRequest struct {
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"` // BUY (long) or SELL (short)
+	Quantity   float64 `json:"quantity"`
+	EntryPrice float64 `json:"entry_price"`
+	Leverage   int     `json:"leverage"`
+	MarginType string  `json:"margin_type,omitempty"` // ISOLATED or CROSSED (default)
+	// WalletBalance is the cross margin wallet; when omitted the account's wallet balance is used
+	// and the maintenance margin and unrealized PnL of the other cross positions are included
+	WalletBalance *float64 `json:"wallet_balance,omitempty"`
+}
+
+// Validate checks the request fields
+func (r *LiquidationRequest) Validate() error {
+	v := &validator{}
+	v.required("symbol", r.Symbol)
+	v.required("side", r.Side)
+	v.oneOf("side", r.Side, orderSides...)
+	v.positive("quantity", r.Quantity)
+	v.positive("entry_price", r.EntryPrice)
+	if r.Leverage < 1 || r.Leverage > MaxLeverage {
+		v.add("leverage", RuleRange, fmt.Sprintf("must be between 1 and %d", MaxLeverage))
+	}
+	v.oneOf("margin_type", r.MarginType, marginTypes...)
+	if r.WalletBalance != nil {
+		v.nonNegative("wallet_balance", *r.WalletBalance)
+	}
+	return v.err()
+}
+
+// LiquidationEstimate is the margin required by a prospective position and where it would be
+// liquidated, using the symbol's leverage bracket for the position's notional
+type LiquidationEstimate struct {
+	Symbol            string  `json:"symbol"`
+	Side              string  `json:"side"`
+	MarginType        string  `json:"margin_type"`
+	Notional          float64 `json:"notional"`
+	InitialMargin     float64 `json:"initial_margin"`
+	MaintenanceMargin float64 `json:"maintenance_margin"`
+	MaintMarginRatio  float64 `json:"maint_margin_ratio"`
+	MaintAmount       float64 `json:"maint_amount"`
+	Bracket           int     `json:"bracket"`
+	MaxLeverage       int     `json:"max_leverage"`
+	// WalletBalance is the margin backing the position: the initial margin when isolated,
+	// otherwise the cross wallet balance less other positions' maintenance margin plus their unrealized PnL
+	WalletBalance float64 `json:"wallet_balance"`
+	// LiquidationPrice is 0 when the margin covers any price move (e.g. an over-collateralized long)
+	LiquidationPrice float64 `json:"liquidation_price"`
+}
+
+// SetMarginRatioWarning sets the margin ratio (maintenance margin over margin balance, 1 means
+// liquidation) at which positions are flagged; 0 uses the default of 0.8
+func (s *TradingService) SetMarginRatioWarning(ratio float64) {
+	s.marginRatioWarning = ratio
+}
+
+// CalculateLiquidation estimates the liquidation price and margin of a prospective one-way mode position
+func (s *TradingService) CalculateLiquidation(ctx context.Context, req *LiquidationRequest) (*LiquidationEstimate, error) {
+	req.Symbol = strings.ToUpper(strings.TrimSpace(req.Symbol))
+	req.Side = strings.ToUpper(req.Side)
+	req.MarginType = strings.ToUpper(req.MarginType)
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.MarginType == "" {
+		req.MarginType = string(futures.MarginTypeCrossed)
+	}
+
+	brackets, err := s.api(ctx).GetLeverageBrackets(ctx, req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	notional := req.Quantity * req.EntryPrice
+	bracket := bracketFor(brackets, notional)
+	if req.Leverage > bracket.InitialLeverage {
+		v := &validator{}
+		v.add("leverage", RuleRange, fmt.Sprintf("must be at most %d for a notional of %s", bracket.InitialLeverage, strconv.FormatFloat(notional, 'f', -1, 64)))
+		return nil, v.err()
+	}
+
+	estimate := &LiquidationEstimate{
+		Symbol:            req.Symbol,
+		Side:              req.Side,
+		MarginType:        req.MarginType,
+		Notional:          notional,
+		InitialMargin:     notional / float64(req.Leverage),
+		MaintenanceMargin: notional*bracket.MaintMarginRatio - bracket.Cum,
+		MaintMarginRatio:  bracket.MaintMarginRatio,
+		MaintAmount:       bracket.Cum,
+		Bracket:           bracket.Bracket,
+		MaxLeverage:       bracket.InitialLeverage,
+	}
+
+	switch {
+	case req.MarginType == string(futures.MarginTypeIsolated):
+		estimate.WalletBalance = estimate.InitialMargin
+	case req.WalletBalance != nil:
+		estimate.WalletBalance = *req.WalletBalance
+	default:
+		if estimate.WalletBalance, err = s.crossMarginAvailable(ctx, req.Symbol); err != nil {
+			return nil, err
+		}
+	}
+
+	// Binance's one-way mode formula: (WB + cum - side*qty*entry) / (qty*MMR - side*qty)
+	side := 1.0
+	if req.Side == string(models.OrderSideSell) {
+		side = -1
+	}
+	price := (estimate.WalletBalance + bracket.Cum - side*req.Quantity*req.EntryPrice) /
+		(req.Quantity*bracket.MaintMarginRatio - side*req.Quantity)
+	if price > 0 && !math.IsInf(price, 0) {
+		estimate.LiquidationPrice = price
+	}
+	return estimate, nil
+}
+
+// crossMarginAvailable returns the account's wallet balance less the maintenance margin of the
+// cross positions on other symbols, plus their unrealized PnL
+func (s *TradingService) crossMarginAvailable(ctx context.Context, symbol string) (float64, error) {
+	account, err := s.api(ctx).GetFuturesAccount(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get wallet balance: %w", err)
+	}
+	balance, _ := strconv.ParseFloat(account.TotalWalletBalance, 64)
+
+	positions, err := s.api(ctx).GetFuturesPositions(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get positions: %w", err)
+	}
+	for _, p := range positions {
+		amount, _ := strconv.ParseFloat(p.PositionAmt, 64)
+		if amount == 0 || p.Symbol == symbol || isIsolated(p) {
+			continue
+		}
+		maint, err := s.maintenanceMargin(ctx, p)
+		if err != nil {
+			return 0, err
+		}
+		unrealized, _ := strconv.ParseFloat(p.UnRealizedProfit, 64)
+		balance += unrealized - maint
+	}
+	return balance, nil
+}
+
+// maintenanceMargin is the maintenance margin of an open position at its current notional
+func (s *TradingService) maintenanceMargin(ctx context.Context, p *futures.PositionRisk) (float64, error) {
+	brackets, err := s.api(ctx).GetLeverageBrackets(ctx, p.Symbol)
+	if err != nil {
+		return 0, err
+	}
+	notional, _ := strconv.ParseFloat(p.Notional, 64)
+	notional = math.Abs(notional)
+	b := bracketFor(brackets, notional)
+	return notional*b.MaintMarginRatio - b.Cum, nil
+}
+
+// bracketFor returns the bracket whose notional range contains notional, or the last one
+func bracketFor(brackets []futures.Bracket, notional float64) futures.Bracket {
+	for _, b := range brackets {
+		if notional >= b.NotionalFloor && notional < b.NotionalCap {
+			return b
+		}
+	}
+	return brackets[len(brackets)-1]
+}
+
+func isIsolated(p *futures.PositionRisk) bool {
+	return strings.EqualFold(p.MarginType, "isolated")
+}
+
+// attachMarginRatios sets the live margin ratio of each futures position: maintenance margin over
+// the isolated margin plus unrealized PnL, or for cross positions the cross maintenance margin
+// over the account's cross margin balance. Positions are returned without ratios if Binance
+// cannot be reached.
+func (s *TradingService) attachMarginRatios(ctx context.Context, positions []*models.Position) {
+	hasFutures := false
+	for _, p := range positions {
+		hasFutures = hasFutures || p.Type == "FUTURES"
+	}
+	if !hasFutures {
+		return
+	}
+
+	ratios, err := s.marginRatios(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to compute margin ratios", "error", err)
+		return
+	}
+	threshold := s.marginRatioWarning
+	if threshold <= 0 {
+		threshold = defaultMarginRatioWarning
+	}
+	for _, p := range positions {
+		ratio, ok := ratios[p.Symbol+"/"+string(p.Side)]
+		if p.Type != "FUTURES" || !ok {
+			continue
+		}
+		p.MarginRatio = ratio
+		p.MarginWarning = ratio >= threshold
+	}
+}
+
+// marginRatios returns the margin ratio of each open position keyed by symbol and position side
+func (s *TradingService) marginRatios(ctx context.Context) (map[string]float64, error) {
+	positions, err := s.api(ctx).GetFuturesPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+	account, err := s.api(ctx).GetFuturesAccount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	return s.marginRatiosOf(ctx, positions, account)
+}
+
+// marginRatiosOf computes marginRatios from positions and account already read from Binance
+func (s *TradingService) marginRatiosOf(ctx context.Context, positions []*futures.PositionRisk, account *futures.Account) (map[string]float64, error) {
+	ratios := make(map[string]float64)
+	var cross []string
+	var crossMaint float64
+	for _, p := range positions {
+		amount, _ := strconv.ParseFloat(p.PositionAmt, 64)
+		if amount == 0 {
+			continue
+		}
+		maint, err := s.maintenanceMargin(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		key := p.Symbol + "/" + p.PositionSide
+		if isIsolated(p) {
+			wallet, _ := strconv.ParseFloat(p.IsolatedWallet, 64)
+			unrealized, _ := strconv.ParseFloat(p.UnRealizedProfit, 64)
+			if balance := wallet + unrealized; balance > 0 {
+				ratios[key] = maint / balance
+			} else {
+				ratios[key] = 1
+			}
+			continue
+		}
+		cross = append(cross, key)
+		crossMaint += maint
+	}
+
+	// Cross positions share the cross wallet, so they share one ratio
+	crossBalance := parseFloatOr(account.TotalCrossWalletBalance, account.TotalWalletBalance) +
+		parseFloatOr(account.TotalCrossUnPnl, account.TotalUnrealizedProfit)
+	crossRatio := 1.0
+	if crossBalance > 0 {
+		crossRatio = crossMaint / crossBalance
+	}
+	for _, key := range cross {
+		ratios[key] = crossRatio
+	}
+	return ratios, nil
+}
+
+// parseFloatOr parses value, falling back to fallback when value is empty
+func parseFloatOr(value, fallback string) float64 {
+	if value == "" {
+		value = fallback
+	}
+	f, _ := strconv.ParseFloat(value, 64)
+	return f
+}
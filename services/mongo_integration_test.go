@@ -0,0 +1,96 @@
+//go:build integration
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"futures-options/binance/binancetest"
+	"futures-options/config"
+	"futures-options/database"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newMongoTestService connects to the replica set in MONGODB_TEST_URI, using a database of its
+// own that is dropped when the test ends
+func newMongoTestService(t *testing.T) (*TradingService, *repository.Repositories) {
+	t.Helper()
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI is not set")
+	}
+	t.Setenv("MONGODB_URI", uri)
+	t.Setenv("MONGODB_DATABASE", fmt.Sprintf("futures_options_test_%d", time.Now().UnixNano()))
+	cfg := config.Load()
+	if err := database.Connect(cfg); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx := context.Background()
+		database.DB.Drop(ctx)
+		database.Disconnect(ctx)
+	})
+	if !database.TransactionsSupported {
+		t.Skip("MONGODB_TEST_URI is not a replica set")
+	}
+	if err := database.CreateIndexes(cfg); err != nil {
+		t.Fatalf("CreateIndexes: %v", err)
+	}
+	repos := repository.NewMongoRepositories()
+	return NewTradingService(binancetest.NewMockClient(nil), repos), repos
+}
+
+func TestMongoTransactionRollsBack(t *testing.T) {
+	_, repos := newMongoTestService(t)
+	ctx := context.Background()
+	failed := errors.New("second write failed")
+
+	order := &models.FuturesOrder{ID: primitive.NewObjectID(), Symbol: "BTCUSDT", BinanceOrderID: 5001, Status: "NEW", CreatedAt: time.Now()}
+	err := repos.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := repos.FuturesOrders.Insert(ctx, order); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("WithTransaction = %v, want %v", err, failed)
+	}
+	if _, err := repos.FuturesOrders.FindByBinanceID(ctx, 5001); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("order written in the aborted transaction was kept: %v", err)
+	}
+}
+
+func TestMongoSaveFuturesOrdersRetriesDuplicateBatch(t *testing.T) {
+	s, repos := newMongoTestService(t)
+	ctx := context.Background()
+	recorded := &models.FuturesOrder{ID: primitive.NewObjectID(), Symbol: "BTCUSDT", BinanceOrderID: 6002, ClientOrderID: "recorded", Status: "NEW", CreatedAt: time.Now()}
+	if err := repos.FuturesOrders.Insert(ctx, recorded); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	// The duplicate aborts the transaction, so the batch is written again without one
+	batch := make([]*models.FuturesOrder, 3)
+	for i := range batch {
+		batch[i] = &models.FuturesOrder{ID: primitive.NewObjectID(), Symbol: "BTCUSDT", BinanceOrderID: int64(6001 + i), Status: "NEW", CreatedAt: time.Now()}
+	}
+	saved, err := s.saveFuturesOrders(ctx, batch)
+	if err != nil {
+		t.Fatalf("saveFuturesOrders: %v", err)
+	}
+	if len(saved) != 3 || saved[1].ClientOrderID != "recorded" {
+		t.Fatalf("saved %d orders, second %q", len(saved), saved[1].ClientOrderID)
+	}
+	for _, id := range []int64{6001, 6003} {
+		if _, err := repos.FuturesOrders.FindByBinanceID(ctx, id); err != nil {
+			t.Errorf("order %d not stored: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"futures-options/notifications"
+)
+
+// ErrNoNotificationChannels is returned when no chat channel (Telegram, Slack or Discord) is configured
+var ErrNoNotificationChannels = errors.New("no notification channels are configured")
+
+// NotificationTestResult reports whether a test message reached one channel
+type NotificationTestResult struct {
+	Channel string `json:"channel"`
+	Sent    bool   `json:"sent"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SendTestNotification sends a TEST message directly through every configured chat channel,
+// ignoring their event filters, and reports the outcome per channel
+func (s *TradingService) SendTestNotification(ctx context.Context) ([]NotificationTestResult, error) {
+	n := &notifications.Notification{
+		Title:     "Test notification",
+		Message:   "Notifications from futures-options are set up correctly",
+		Priority:  notifications.PriorityLow,
+		EventType: notifications.EventTest,
+		CreatedAt: time.Now(),
+	}
+	var results []NotificationTestResult
+	for _, notifier := range s.notifiers {
+		sender, ok := notifier.(notifications.Sender)
+		if !ok {
+			continue
+		}
+		result := NotificationTestResult{Channel: sender.Name(), Sent: true}
+		if err := sender.Send(ctx, n); err != nil {
+			result.Sent = false
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return nil, ErrNoNotificationChannels
+	}
+	return results, nil
+}
+
+// GetNotificationStatus reports the deliveries to each configured chat channel
+func (s *TradingService) GetNotificationStatus() []notifications.DestinationStatus {
+	statuses := []notifications.DestinationStatus{}
+	for _, notifier := range s.notifiers {
+		if reporter, ok := notifier.(notifications.StatusReporter); ok {
+			statuses = append(statuses, reporter.Status())
+		}
+	}
+	return statuses
+}
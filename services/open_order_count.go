@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// openOrderResyncAfter is how long counts kept current by the user data stream are trusted
+// before the open orders are listed again, in case the stream missed updates while reconnecting
+const openOrderResyncAfter = 5 * time.Minute
+
+// OpenOrderCapError rejects orders placed together that would take a symbol past the open order
+// cap of its exchange info filters, before any of them is sent
+type OpenOrderCapError struct {
+	Symbol string `json:"symbol"`
+	// Conditional is set when the cap on stop, take-profit and trailing orders would be exceeded
+	Conditional bool `json:"conditional"`
+	Open        int  `json:"open"`
+	Adding      int  `json:"adding"`
+	Max         int  `json:"max"`
+}
+
+func (e *OpenOrderCapError) Error() string {
+	kind := "open orders"
+	if e.Conditional {
+		kind = "open conditional orders"
+	}
+	return fmt.Sprintf("%s has %d %s; placing %d more would exceed its cap of %d", e.Symbol, e.Open, kind, e.Adding, e.Max)
+}
+
+// OpenOrderCount is the number of a symbol's open USDⓈ-M orders and the caps Binance applies
+type OpenOrderCount struct {
+	Symbol            string `json:"symbol"`
+	OpenOrders        int    `json:"open_orders"`
+	ConditionalOrders int    `json:"conditional_orders"`
+	// The caps are 0 when the exchange info lists none for the symbol
+	MaxOpenOrders        int `json:"max_open_orders,omitempty"`
+	MaxConditionalOrders int `json:"max_conditional_orders,omitempty"`
+	// Live is set when the counts are kept current by the user data stream rather than listed
+	// from Binance for this request
+	Live     bool      `json:"live"`
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+// conditionalOrderTypes are the order types counted against MAX_NUM_ALGO_ORDERS
+var conditionalOrderTypes = map[string]bool{
+	string(models.OrderTypeStop):               true,
+	string(models.OrderTypeStopMarket):         true,
+	string(models.OrderTypeTakeProfit):         true,
+	string(models.OrderTypeTakeProfitMarket):   true,
+	string(models.OrderTypeTrailingStopMarket): true,
+}
+
+// symbolOrders are the open orders of one symbol, by order ID, with whether each is conditional
+type symbolOrders struct {
+	orders   map[int64]bool
+	syncedAt time.Time
+}
+
+// accountOrders are an account's open orders by symbol. syncedAt is when the orders of every
+// symbol were last listed; symbols missing since then have none.
+type accountOrders struct {
+	symbols  map[string]*symbolOrders
+	syncedAt time.Time
+}
+
+// openOrderBook tracks the open USDⓈ-M orders of each account: replaced whenever they are listed
+// from Binance and updated from the user data stream in between
+type openOrderBook struct {
+	mu       sync.Mutex
+	accounts map[string]*accountOrders
+}
+
+// replace records the orders listed for symbol, or for every symbol when it is empty
+func (b *openOrderBook) replace(account, symbol string, orders []*futures.Order, at time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.accounts == nil {
+		b.accounts = make(map[string]*accountOrders)
+	}
+	a := b.accounts[account]
+	if a == nil || symbol == "" {
+		a = &accountOrders{symbols: make(map[string]*symbolOrders)}
+		b.accounts[account] = a
+	}
+	if symbol == "" {
+		a.syncedAt = at
+	} else {
+		a.symbols[symbol] = &symbolOrders{orders: make(map[int64]bool), syncedAt: at}
+	}
+	for _, o := range orders {
+		entry := a.symbols[o.Symbol]
+		if entry == nil {
+			entry = &symbolOrders{orders: make(map[int64]bool), syncedAt: at}
+			a.symbols[o.Symbol] = entry
+		}
+		entry.orders[o.OrderID] = conditionalOrderTypes[string(o.Type)]
+	}
+}
+
+// apply updates the tracked orders of u's symbol from an ORDER_TRADE_UPDATE. Symbols whose orders
+// were never listed are left untracked.
+func (b *openOrderBook) apply(account string, u *futures.WsOrderTradeUpdate) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	a := b.accounts[account]
+	if a == nil {
+		return
+	}
+	entry := a.symbols[u.Symbol]
+	if entry == nil {
+		if a.syncedAt.IsZero() {
+			return
+		}
+		entry = &symbolOrders{orders: make(map[int64]bool), syncedAt: a.syncedAt}
+		a.symbols[u.Symbol] = entry
+	}
+	switch u.Status {
+	case futures.OrderStatusTypeNew, futures.OrderStatusTypePartiallyFilled:
+		entry.orders[u.ID] = conditionalOrderTypes[string(u.Type)]
+	default:
+		delete(entry.orders, u.ID)
+	}
+}
+
+// counts returns the tracked open and conditional orders of symbol and when they were last
+// listed; ok is false when they never were
+func (b *openOrderBook) counts(account, symbol string) (open, conditional int, syncedAt time.Time, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	a := b.accounts[account]
+	if a == nil {
+		return 0, 0, time.Time{}, false
+	}
+	entry := a.symbols[symbol]
+	if entry == nil {
+		return 0, 0, a.syncedAt, !a.syncedAt.IsZero()
+	}
+	for _, isConditional := range entry.orders {
+		open++
+		if isConditional {
+			conditional++
+		}
+	}
+	return open, conditional, entry.syncedAt, true
+}
+
+// listOpenFuturesOrders lists the open USDⓈ-M orders of symbol, or of every symbol when it is
+// empty, and records them for the open order counts
+func (s *TradingService) listOpenFuturesOrders(ctx context.Context, symbol string) ([]*futures.Order, error) {
+	at := time.Now()
+	orders, err := s.api(ctx).ListOpenFuturesOrders(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	s.openOrders.replace(s.accountID(ctx), symbol, orders, at)
+	return orders, nil
+}
+
+// openOrderCount counts symbol's open orders. Counts the user data stream keeps current are used
+// while it is connected for the account; otherwise the orders are listed from Binance.
+func (s *TradingService) openOrderCount(ctx context.Context, symbol string) (*OpenOrderCount, error) {
+	account := s.accountID(ctx)
+	if s.UserDataStreamConnected() && account == s.ActiveAccount() {
+		open, conditional, syncedAt, ok := s.openOrders.counts(account, symbol)
+		if ok && time.Since(syncedAt) < openOrderResyncAfter {
+			return &OpenOrderCount{Symbol: symbol, OpenOrders: open, ConditionalOrders: conditional, Live: true, SyncedAt: syncedAt}, nil
+		}
+	}
+	if _, err := s.listOpenFuturesOrders(ctx, symbol); err != nil {
+		return nil, fmt.Errorf("failed to list open orders: %w", err)
+	}
+	open, conditional, syncedAt, _ := s.openOrders.counts(account, symbol)
+	return &OpenOrderCount{Symbol: symbol, OpenOrders: open, ConditionalOrders: conditional, SyncedAt: syncedAt}, nil
+}
+
+// GetOpenOrderCount returns the number of symbol's open orders with the caps from its exchange
+// info filters
+func (s *TradingService) GetOpenOrderCount(ctx context.Context, symbol string) (*OpenOrderCount, error) {
+	v := &validator{}
+	v.required("symbol", symbol)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	symbol = strings.ToUpper(symbol)
+	filters, err := s.api(ctx).GetSymbolFilters(ctx, symbol)
+	if errors.Is(err, binance.ErrUnknownSymbol) {
+		v.add("symbol", RuleEnum, "is not a listed futures symbol")
+		return nil, v.err()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.openOrderCount(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	count.MaxOpenOrders = filters.MaxNumOrders
+	count.MaxConditionalOrders = filters.MaxNumAlgoOrders
+	return count, nil
+}
+
+// orderDemand is how many resting orders, and how many of them conditional, a request adds to
+// a symbol
+type orderDemand struct {
+	orders      int
+	conditional int
+}
+
+// addOrderDemand counts an order of orderType on symbol; market orders do not rest and are skipped
+func addOrderDemand(demand map[string]*orderDemand, symbol, orderType string) {
+	orderType = strings.ToUpper(orderType)
+	if orderType == string(models.OrderTypeMarket) {
+		return
+	}
+	symbol = strings.ToUpper(symbol)
+	d := demand[symbol]
+	if d == nil {
+		d = &orderDemand{}
+		demand[symbol] = d
+	}
+	d.orders++
+	if conditionalOrderTypes[orderType] {
+		d.conditional++
+	}
+}
+
+// checkOpenOrderCaps rejects orders placed together unless all of them fit under each symbol's
+// open order caps. Symbols without caps, or whose filters or open orders cannot be loaded, are
+// left to Binance to enforce.
+func (s *TradingService) checkOpenOrderCaps(ctx context.Context, demand map[string]*orderDemand) error {
+	for symbol, d := range demand {
+		filters, err := s.api(ctx).GetSymbolFilters(ctx, symbol)
+		if err != nil || filters.MaxNumOrders <= 0 && filters.MaxNumAlgoOrders <= 0 {
+			continue
+		}
+		count, err := s.openOrderCount(ctx, symbol)
+		if err != nil {
+			slog.Warn("skipping the open order cap check", "symbol", symbol, "error", err)
+			continue
+		}
+		if filters.MaxNumOrders > 0 && count.OpenOrders+d.orders > filters.MaxNumOrders {
+			return &OpenOrderCapError{Symbol: symbol, Open: count.OpenOrders, Adding: d.orders, Max: filters.MaxNumOrders}
+		}
+		if filters.MaxNumAlgoOrders > 0 && d.conditional > 0 && count.ConditionalOrders+d.conditional > filters.MaxNumAlgoOrders {
+			return &OpenOrderCapError{Symbol: symbol, Conditional: true, Open: count.ConditionalOrders, Adding: d.conditional, Max: filters.MaxNumAlgoOrders}
+		}
+	}
+	return nil
+}
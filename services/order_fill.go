@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"futures-options/logging"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"github.com/adshao/go-binance/v2/delivery"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// orderFill is the status and execution of a futures order as Binance last reported them
+type orderFill struct {
+	Status       string
+	ExecutedQty  float64
+	AvgPrice     float64
+	CumQuote     float64 // USDⓈ-M only
+	LastFillTime *time.Time
+}
+
+// newOrderFill parses Binance's decimal strings. REST responses carry no fill time, so updateTime
+// (ms) stands in for it once something executed.
+func newOrderFill(status, executedQty, avgPrice, cumQuote string, updateTime int64) *orderFill {
+	f := &orderFill{Status: status}
+	f.ExecutedQty, _ = strconv.ParseFloat(executedQty, 64)
+	f.AvgPrice, _ = strconv.ParseFloat(avgPrice, 64)
+	f.CumQuote, _ = strconv.ParseFloat(cumQuote, 64)
+	if f.ExecutedQty > 0 && updateTime > 0 {
+		t := time.UnixMilli(updateTime)
+		f.LastFillTime = &t
+	}
+	return f
+}
+
+// futuresOrderFill returns the fill of a USDⓈ-M order status query
+func futuresOrderFill(o *futures.Order) *orderFill {
+	return newOrderFill(string(o.Status), o.ExecutedQuantity, o.AvgPrice, o.CumQuote, o.UpdateTime)
+}
+
+// deliveryOrderFill returns the fill of a COIN-M order status query
+func deliveryOrderFill(o *delivery.Order) *orderFill {
+	return newOrderFill(string(o.Status), o.ExecutedQuantity, o.AvgPrice, "", o.UpdateTime)
+}
+
+// createOrderFill returns the fill reported when a USDⓈ-M order was placed; it is empty for ACK
+// responses
+func createOrderFill(r *futures.CreateOrderResponse) *orderFill {
+	return newOrderFill(string(r.Status), r.ExecutedQuantity, r.AvgPrice, r.CumQuote, r.UpdateTime)
+}
+
+// createDeliveryOrderFill returns the fill reported when a COIN-M order was placed
+func createDeliveryOrderFill(r *delivery.CreateOrderResponse) *orderFill {
+	return newOrderFill(string(r.Status), r.ExecutedQuantity, r.AvgPrice, "", r.UpdateTime)
+}
+
+// streamOrderFill returns the fill of a user data stream order update. The stream reports no
+// cumulative quote; for USDⓈ-M orders it is the executed quantity times the average price.
+func streamOrderFill(u *futures.WsOrderTradeUpdate) *orderFill {
+	f := newOrderFill(string(u.Status), u.AccumulatedFilledQty, u.AveragePrice, "", 0)
+	f.CumQuote = f.ExecutedQty * f.AvgPrice
+	if f.ExecutedQty > 0 && u.TradeTime > 0 {
+		t := time.UnixMilli(u.TradeTime)
+		f.LastFillTime = &t
+	}
+	return f
+}
+
+// apply copies the fill onto an order that is not stored yet
+func (f *orderFill) apply(o *models.FuturesOrder) {
+	o.ExecutedQty = f.ExecutedQty
+	o.AvgFillPrice = f.AvgPrice
+	o.CumQuote = f.CumQuote
+	o.LastFillTime = f.LastFillTime
+}
+
+// changed reports whether the fill moves o's status or executed quantity forward
+func (f *orderFill) changed(o *models.FuturesOrder) bool {
+	return f.Status != o.Status || f.ExecutedQty != o.ExecutedQty
+}
+
+// stale reports whether the fill is older than what o already records
+func (f *orderFill) stale(o *models.FuturesOrder) bool {
+	return f.ExecutedQty < o.ExecutedQty
+}
+
+// update returns the update storing the fill
+func (f *orderFill) update(now time.Time) *repository.FuturesOrderUpdate {
+	return &repository.FuturesOrderUpdate{
+		Status: f.Status,
+		Fill: &repository.FuturesOrderFill{
+			ExecutedQty:  f.ExecutedQty,
+			AvgFillPrice: f.AvgPrice,
+			CumQuote:     f.CumQuote,
+			LastFillTime: f.LastFillTime,
+		},
+		UpdatedAt: now,
+	}
+}
+
+// recordOrderFill stores a fill on the order it belongs to. Fills may arrive out of order (the
+// user data stream racing a status query), so one reporting less executed quantity than is
+// already stored is dropped. Orders the service did not record are left to reconciliation.
+func (s *TradingService) recordOrderFill(ctx context.Context, symbol string, binanceOrderID int64, clientOrderID string, fill *orderFill) {
+	if _, err := s.repos.FuturesOrders.UpdateFill(ctx, binanceOrderID, clientOrderID, fill.update(time.Now())); err != nil {
+		logging.FromContext(ctx).Warn("failed to record order fill", "symbol", symbol, "binance_order_id", binanceOrderID, "error", err)
+	}
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/binance/binancetest"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newTestService returns a trading service backed by the in-memory repositories and a mock client
+func newTestService(t testing.TB) (*TradingService, *binancetest.MockClient, *repository.Repositories) {
+	t.Helper()
+	mock := binancetest.NewMockClient(nil)
+	repos := repository.NewMemoryRepositories()
+	return NewTradingService(mock, repos), mock, repos
+}
+
+// storeOrder inserts an open order as if it had been placed earlier
+func storeOrder(t *testing.T, repos *repository.Repositories, order *models.FuturesOrder) {
+	t.Helper()
+	order.ID = primitive.NewObjectID()
+	if order.Status == "" {
+		order.Status = "NEW"
+	}
+	order.CreatedAt = time.Now()
+	order.UpdatedAt = order.CreatedAt
+	if err := repos.FuturesOrders.Insert(context.Background(), order); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+}
+
+func TestCreateAdvancedFuturesOrderStoresOrder(t *testing.T) {
+	s, mock, repos := newTestService(t)
+	mock.CreateAdvancedFuturesOrderFunc = func(ctx context.Context, req *binance.AdvancedOrderRequest) (*futures.CreateOrderResponse, error) {
+		return &futures.CreateOrderResponse{Symbol: req.Symbol, OrderID: 1001, ClientOrderID: req.ClientOrderID, Status: futures.OrderStatusTypeNew}, nil
+	}
+
+	order, err := s.CreateAdvancedFuturesOrder(context.Background(), &AdvancedOrderRequest{
+		Symbol:        "BTCUSDT",
+		Side:          "BUY",
+		OrderType:     "LIMIT",
+		Quantity:      decimal.RequireFromString("0.002"),
+		Price:         decimal.RequireFromString("50000.1"),
+		TimeInForce:   "GTC",
+		ClientOrderID: "create-1",
+	})
+	if err != nil {
+		t.Fatalf("CreateAdvancedFuturesOrder: %v", err)
+	}
+	if order.BinanceOrderID != 1001 || order.Status != "NEW" {
+		t.Fatalf("order = %d %s, want 1001 NEW", order.BinanceOrderID, order.Status)
+	}
+
+	stored, err := repos.FuturesOrders.FindByBinanceID(context.Background(), 1001)
+	if err != nil {
+		t.Fatalf("FindByBinanceID: %v", err)
+	}
+	if stored.Quantity != 0.002 || stored.Price != 50000.1 || stored.ClientOrderID != "create-1" {
+		t.Errorf("stored order = quantity %v price %v client ID %q", stored.Quantity, stored.Price, stored.ClientOrderID)
+	}
+	calls := mock.CallsTo("CreateAdvancedFuturesOrder")
+	if len(calls) != 1 {
+		t.Fatalf("CreateAdvancedFuturesOrder called %d times, want 1", len(calls))
+	}
+	if sent := calls[0].Args[0].(*binance.AdvancedOrderRequest); sent.Quantity.String() != "0.002" || sent.Price.String() != "50000.1" {
+		t.Errorf("sent quantity %s price %s", sent.Quantity, sent.Price)
+	}
+}
+
+func TestModifyFuturesOrderStoresEchoedValues(t *testing.T) {
+	s, mock, repos := newTestService(t)
+	s.SetRawResponseLimit(64 * 1024)
+	storeOrder(t, repos, &models.FuturesOrder{Symbol: "BTCUSDT", BinanceOrderID: 1001, Quantity: 1, Price: 100, StopPrice: 95})
+	mock.ModifyFuturesOrderFunc = func(ctx context.Context, req *binance.ModifyOrderRequest) (*futures.CreateOrderResponse, error) {
+		return &futures.CreateOrderResponse{Symbol: req.Symbol, OrderID: req.OrderID, Status: futures.OrderStatusTypeNew, OrigQuantity: "2", Price: "101.5", StopPrice: "0"}, nil
+	}
+
+	order, err := s.ModifyFuturesOrder(context.Background(), &ModifyOrderRequest{
+		Symbol:   "BTCUSDT",
+		OrderID:  1001,
+		Quantity: decimal.NewFromInt(2),
+		Price:    decimal.RequireFromString("101.5"),
+	})
+	if err != nil {
+		t.Fatalf("ModifyFuturesOrder: %v", err)
+	}
+	if order.Quantity != 2 || order.Price != 101.5 {
+		t.Errorf("order = quantity %v price %v, want 2 101.5", order.Quantity, order.Price)
+	}
+	// Binance reports 0 for prices the order does not carry; the stored one is kept
+	if order.StopPrice != 95 {
+		t.Errorf("stop price = %v, want 95 kept", order.StopPrice)
+	}
+	if len(order.RawResponse) == 0 {
+		t.Error("raw response was not stored")
+	}
+}
+
+func TestModifyFuturesOrderForceLocal(t *testing.T) {
+	s, mock, repos := newTestService(t)
+	storeOrder(t, repos, &models.FuturesOrder{Symbol: "BTCUSDT", ClientOrderID: "local-1", Quantity: 1, Price: 100})
+
+	order, err := s.ModifyFuturesOrder(context.Background(), &ModifyOrderRequest{
+		Symbol:        "BTCUSDT",
+		ClientOrderID: "local-1",
+		Quantity:      decimal.RequireFromString("1.5"),
+		ForceLocal:    true,
+	})
+	if err != nil {
+		t.Fatalf("ModifyFuturesOrder: %v", err)
+	}
+	if order.Quantity != 1.5 || order.Price != 100 {
+		t.Errorf("order = quantity %v price %v, want 1.5 100", order.Quantity, order.Price)
+	}
+	if calls := mock.CallsTo("ModifyFuturesOrder"); len(calls) != 0 {
+		t.Errorf("Binance was called %d times for a local correction", len(calls))
+	}
+}
+
+func TestCreateBatchOrdersStoresPlacedOrders(t *testing.T) {
+	s, mock, repos := newTestService(t)
+	var nextID int64 = 2000
+	mock.CreateBatchOrdersFunc = func(ctx context.Context, orders []*binance.AdvancedOrderRequest) ([]*futures.CreateOrderResponse, error) {
+		responses := make([]*futures.CreateOrderResponse, len(orders))
+		errs := make([]error, len(orders))
+		for i, o := range orders {
+			if i == 1 {
+				errs[i] = &common.APIError{Code: -2019, Message: "Margin is insufficient."}
+				continue
+			}
+			responses[i] = &futures.CreateOrderResponse{Symbol: o.Symbol, OrderID: atomic.AddInt64(&nextID, 1), ClientOrderID: o.ClientOrderID, Status: futures.OrderStatusTypeNew}
+		}
+		return responses, &binance.BatchOrderError{Errors: errs}
+	}
+
+	orders := make([]AdvancedOrderRequest, 3)
+	for i := range orders {
+		orders[i] = AdvancedOrderRequest{Symbol: "BTCUSDT", Side: "BUY", OrderType: "MARKET", Quantity: decimal.RequireFromString("0.001")}
+	}
+	resp, err := s.CreateBatchOrders(context.Background(), &BatchOrderRequest{Orders: orders})
+	if err != nil {
+		t.Fatalf("CreateBatchOrders: %v", err)
+	}
+	if len(resp.Orders) != 2 || len(resp.Errors) != 1 {
+		t.Fatalf("got %d orders and %d errors, want 2 and 1", len(resp.Orders), len(resp.Errors))
+	}
+	for _, id := range []int64{2001, 2002} {
+		if _, err := repos.FuturesOrders.FindByBinanceID(context.Background(), id); err != nil {
+			t.Errorf("order %d not stored: %v", id, err)
+		}
+	}
+}
+
+func TestReconcileFlagsMissingOrder(t *testing.T) {
+	s, mock, repos := newTestService(t)
+	storeOrder(t, repos, &models.FuturesOrder{Symbol: "BTCUSDT", BinanceOrderID: 3001, Quantity: 1})
+	mock.GetFuturesOrderFunc = func(ctx context.Context, symbol string, orderID int64) (*futures.Order, error) {
+		return nil, &common.APIError{Code: -2013, Message: "Order does not exist."}
+	}
+
+	summary, err := s.ReconcileFuturesOrders(context.Background())
+	if err != nil {
+		t.Fatalf("ReconcileFuturesOrders: %v", err)
+	}
+	if summary.Missing != 1 {
+		t.Errorf("missing = %d, want 1", summary.Missing)
+	}
+	stored, err := repos.FuturesOrders.FindByBinanceID(context.Background(), 3001)
+	if err != nil {
+		t.Fatalf("FindByBinanceID: %v", err)
+	}
+	if !stored.MissingOnExchange || stored.ReconciledAt == nil {
+		t.Errorf("stored order missing = %v, reconciled at %v", stored.MissingOnExchange, stored.ReconciledAt)
+	}
+}
+
+func TestReconcilePagesOpenOrders(t *testing.T) {
+	s, mock, repos := newTestService(t)
+	// More than a batch, so the orders are loaded over two pages
+	for i := 0; i < reconcileBatchSize+50; i++ {
+		storeOrder(t, repos, &models.FuturesOrder{Symbol: "BTCUSDT", BinanceOrderID: int64(5000 + i), Quantity: 1})
+	}
+	for i := 0; i < 3; i++ {
+		storeOrder(t, repos, &models.FuturesOrder{Symbol: "ETHUSDT", BinanceOrderID: int64(9000 + i), Quantity: 1})
+	}
+	mock.ListOpenFuturesOrdersFunc = func(ctx context.Context, symbol string) ([]*futures.Order, error) {
+		if symbol == "ETHUSDT" {
+			return nil, errors.New("connection reset")
+		}
+		var open []*futures.Order
+		for i := 0; i < reconcileBatchSize+50; i++ {
+			open = append(open, &futures.Order{Symbol: symbol, OrderID: int64(5000 + i), Status: futures.OrderStatusTypeNew, OrigQuantity: "1", ExecutedQuantity: "0"})
+		}
+		return open, nil
+	}
+
+	summary, err := s.ReconcileFuturesOrders(context.Background())
+	if err != nil {
+		t.Fatalf("ReconcileFuturesOrders: %v", err)
+	}
+	// Orders of a symbol whose open orders could not be listed are checked, with an error each
+	if summary.Checked != reconcileBatchSize+53 || summary.Errors != 3 {
+		t.Errorf("checked %d with %d errors, want %d with 3", summary.Checked, summary.Errors, reconcileBatchSize+53)
+	}
+	if summary.Changed != 0 || summary.Missing != 0 {
+		t.Errorf("changed %d, missing %d for orders still open", summary.Changed, summary.Missing)
+	}
+	if n := len(mock.CallsTo("GetFuturesOrder")); n != 0 {
+		t.Errorf("GetFuturesOrder called %d times for orders listed as open", n)
+	}
+}
+
+func TestHandleMarginCallRecordsEventAndFlagsPosition(t *testing.T) {
+	s, mock, repos := newTestService(t)
+	ctx := context.Background()
+	// Only the long leg of the active account gets the margin call
+	for _, p := range []*models.Position{
+		{Symbol: "BTCUSDT", Type: "FUTURES", Side: models.PositionSideLong, Quantity: 1},
+		{Symbol: "BTCUSDT", Type: "FUTURES", Side: models.PositionSideShort, Quantity: -1},
+		{Symbol: "BTCUSDT", Type: "FUTURES", Side: models.PositionSideLong, Quantity: 2, AccountID: "other-account"},
+	} {
+		p.UpdatedAt = time.Now()
+		if err := repos.Positions.Upsert(ctx, p); err != nil {
+			t.Fatalf("Upsert: %v", err)
+		}
+	}
+
+	event := &futures.WsUserDataEvent{Time: time.Now().UnixMilli(), CrossWalletBalance: "100"}
+	event.MarginCallPositions = []futures.WsPosition{{Symbol: "BTCUSDT", Side: "LONG", Amount: "1", MarkPrice: "42000"}}
+	if err := s.HandleMarginCall(ctx, event); err != nil {
+		t.Fatalf("HandleMarginCall: %v", err)
+	}
+
+	events, err := s.GetRiskEvents(ctx, "BTCUSDT", 10)
+	if err != nil {
+		t.Fatalf("GetRiskEvents: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != models.RiskEventMarginCall || events[0].MarkPrice != 42000 {
+		t.Fatalf("risk events = %+v", events)
+	}
+	positions, _ := repos.Positions.List(ctx, "FUTURES", "")
+	for _, p := range positions {
+		flagged := p.Side == models.PositionSideLong && p.AccountID == ""
+		if p.AtRisk != flagged || flagged && p.MarginCallPrice != 42000 {
+			t.Errorf("%s %q position at risk %v at %v, want flagged %v", p.Side, p.AccountID, p.AtRisk, p.MarginCallPrice, flagged)
+		}
+	}
+
+	// The next position sync clears the flag
+	mock.GetFuturesPositionsFunc = func(ctx context.Context) ([]*futures.PositionRisk, error) {
+		return []*futures.PositionRisk{{Symbol: "BTCUSDT", PositionSide: "LONG", PositionAmt: "1", EntryPrice: "43000", MarkPrice: "42500", UnRealizedProfit: "-500", Notional: "42500", Leverage: "20"}}, nil
+	}
+	if _, err := s.SyncPositionsFromBinance(ctx, models.MarketUSDM); err != nil {
+		t.Fatalf("SyncPositionsFromBinance: %v", err)
+	}
+	positions, _ = repos.Positions.List(ctx, "FUTURES", "")
+	for _, p := range positions {
+		if p.AtRisk || p.MarginCallPrice != 0 {
+			t.Errorf("%s %q position still at risk after a sync", p.Side, p.AccountID)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"futures-options/latency"
+	"futures-options/logging"
+	"futures-options/models"
+	"futures-options/repository"
+)
+
+// defaultLatencyReportRange is the latency report period when no start is given
+const defaultLatencyReportRange = 24 * time.Hour
+
+// stampOrderLatency records on order how long its placement took and adds it to the latency
+// histograms. requested and responded bracket the Binance request; transactTime is the time, in
+// Unix ms, Binance put on its response. Call it just before the order is stored.
+func stampOrderLatency(ctx context.Context, order *models.FuturesOrder, transport string, requested, responded time.Time, transactTime int64) {
+	l := &models.OrderLatency{
+		Transport:   transport,
+		RequestedAt: requested,
+		RespondedAt: responded,
+		BinanceMs:   durationMs(responded.Sub(requested)),
+	}
+	from := requested
+	if received := logging.ReceivedAt(ctx); !received.IsZero() {
+		l.ReceivedAt = &received
+		from = received
+	}
+	if transactTime > 0 {
+		t := time.UnixMilli(transactTime)
+		l.TransactTime = &t
+	}
+	total := time.Since(from)
+	l.TotalMs = durationMs(total)
+	order.Latency = l
+
+	latency.ObserveOrder(order.Symbol, transport, latency.StageBinance, responded.Sub(requested))
+	latency.ObserveOrder(order.Symbol, transport, latency.StageTotal, total)
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// LatencyReportQuery selects the orders of the latency report by creation time and symbol
+type LatencyReportQuery struct {
+	Start  *time.Time
+	End    *time.Time
+	Symbol string
+}
+
+// Validate checks the query
+func (q *LatencyReportQuery) Validate() error {
+	v := &validator{}
+	if q.Start != nil && q.End != nil && !q.Start.Before(*q.End) {
+		v.add("start", RuleRange, "must be before end")
+	}
+	return v.err()
+}
+
+// LatencyReport is the placement latency of the stored orders of a period, per symbol and transport
+type LatencyReport struct {
+	Start   time.Time             `json:"start"`
+	End     time.Time             `json:"end"`
+	Buckets []*OrderLatencyBucket `json:"buckets"`
+	Totals  *OrderLatencyBucket   `json:"totals"`
+}
+
+// OrderLatencyBucket summarizes the placement latency of a symbol's orders sent over one transport
+type OrderLatencyBucket struct {
+	Symbol       string  `json:"symbol,omitempty"`
+	Transport    string  `json:"transport,omitempty"`
+	Orders       int     `json:"orders"`
+	BinanceP50Ms float64 `json:"binance_p50_ms"`
+	BinanceP95Ms float64 `json:"binance_p95_ms"`
+	TotalP50Ms   float64 `json:"total_p50_ms"`
+	TotalP95Ms   float64 `json:"total_p95_ms"`
+	TotalMaxMs   float64 `json:"total_max_ms"`
+
+	binance []float64
+	total   []float64
+}
+
+func (b *OrderLatencyBucket) add(l *models.OrderLatency) {
+	b.Orders++
+	b.binance = append(b.binance, l.BinanceMs)
+	b.total = append(b.total, l.TotalMs)
+}
+
+func (b *OrderLatencyBucket) finish() {
+	if b.Orders == 0 {
+		return
+	}
+	sort.Float64s(b.binance)
+	sort.Float64s(b.total)
+	b.BinanceP50Ms = nearestRank(b.binance, 0.50)
+	b.BinanceP95Ms = nearestRank(b.binance, 0.95)
+	b.TotalP50Ms = nearestRank(b.total, 0.50)
+	b.TotalP95Ms = nearestRank(b.total, 0.95)
+	b.TotalMaxMs = b.total[len(b.total)-1]
+}
+
+// nearestRank returns the nearest-rank percentile p of sorted
+func nearestRank(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// GetOrderLatencyReport aggregates the placement latency stored on the USDⓈ-M orders created in
+// the period. Orders stored before latency was recorded, and imported ones, are left out.
+func (s *TradingService) GetOrderLatencyReport(ctx context.Context, query *LatencyReportQuery) (*LatencyReport, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	end := time.Now()
+	if query.End != nil {
+		end = *query.End
+	}
+	start := end.Add(-defaultLatencyReportRange)
+	if query.Start != nil {
+		start = *query.Start
+	}
+
+	buckets := make(map[string]*OrderLatencyBucket)
+	totals := &OrderLatencyBucket{}
+	err := s.repos.FuturesOrders.Each(ctx, &repository.OrderQuery{
+		Symbol:    strings.ToUpper(query.Symbol),
+		StartTime: &start,
+		EndTime:   &end,
+		SortAsc:   true,
+	}, func(o *models.FuturesOrder) error {
+		if o.Latency == nil {
+			return nil
+		}
+		key := o.Symbol + "/" + o.Latency.Transport
+		b := buckets[key]
+		if b == nil {
+			b = &OrderLatencyBucket{Symbol: o.Symbol, Transport: o.Latency.Transport}
+			buckets[key] = b
+		}
+		b.add(o.Latency)
+		totals.add(o.Latency)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &LatencyReport{Start: start, End: end, Buckets: make([]*OrderLatencyBucket, 0, len(buckets)), Totals: totals}
+	for _, b := range buckets {
+		b.finish()
+		report.Buckets = append(report.Buckets, b)
+	}
+	totals.finish()
+	sort.Slice(report.Buckets, func(i, j int) bool {
+		a, b := report.Buckets[i], report.Buckets[j]
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		return a.Transport < b.Transport
+	})
+	return report, nil
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"futures-options/models"
+	"futures-options/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrderDetailOptions selects what a single order lookup returns besides the order
+type OrderDetailOptions struct {
+	IncludeRaw     bool // the stored raw Binance response
+	IncludeJournal bool // every journal entry on the order, newest first
+}
+
+// findOrder looks up a stored order by Mongo ID, or by Binance order ID when id is a number. Any
+// other id is reported as ErrOrderNotFound, like an unknown one.
+func findOrder[T any](ctx context.Context, id string, byID func(context.Context, primitive.ObjectID) (T, error), byBinanceID func(context.Context, int64) (T, error)) (T, error) {
+	var order T
+	var err error
+	if objectID, idErr := primitive.ObjectIDFromHex(id); idErr == nil {
+		order, err = byID(ctx, objectID)
+	} else if binanceID, numErr := strconv.ParseInt(id, 10, 64); numErr == nil && binanceID > 0 {
+		order, err = byBinanceID(ctx, binanceID)
+	} else {
+		return order, ErrOrderNotFound
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return order, ErrOrderNotFound
+	}
+	if err != nil {
+		return order, fmt.Errorf("failed to load order: %w", err)
+	}
+	return order, nil
+}
+
+// orderJournal returns every journal entry on an order when opts asks for them
+func (s *TradingService) orderJournal(ctx context.Context, orderID primitive.ObjectID, opts OrderDetailOptions) ([]*models.JournalEntry, error) {
+	if !opts.IncludeJournal {
+		return nil, nil
+	}
+	entries, err := s.repos.Journal.List(ctx, &repository.JournalQuery{OrderID: &orderID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load journal entries: %w", err)
+	}
+	return entries, nil
+}
+
+// GetFuturesOrder returns a stored futures order by Mongo ID or Binance order ID. With an account
+// selected, orders of other accounts are not found, as in the listings.
+func (s *TradingService) GetFuturesOrder(ctx context.Context, id string, opts OrderDetailOptions) (*models.FuturesOrder, error) {
+	order, err := findOrder(ctx, id, s.repos.FuturesOrders.FindByID, s.repos.FuturesOrders.FindByBinanceID)
+	if err != nil {
+		return nil, err
+	}
+	if !accountSees(ctx, order.AccountID) {
+		return nil, ErrOrderNotFound
+	}
+	if !opts.IncludeRaw {
+		order.RawResponse = nil
+	}
+	if order.JournalEntries, err = s.orderJournal(ctx, order.ID, opts); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// GetOptionsOrder returns a stored options order by Mongo ID or Binance order ID
+func (s *TradingService) GetOptionsOrder(ctx context.Context, id string, opts OrderDetailOptions) (*models.OptionsOrder, error) {
+	order, err := findOrder(ctx, id, s.repos.OptionsOrders.FindByID, s.repos.OptionsOrders.FindByBinanceID)
+	if err != nil {
+		return nil, err
+	}
+	if !accountSees(ctx, order.AccountID) {
+		return nil, ErrOrderNotFound
+	}
+	if !opts.IncludeRaw {
+		order.RawResponse = nil
+	}
+	if order.JournalEntries, err = s.orderJournal(ctx, order.ID, opts); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// GetSpotOrder returns a stored spot order by Mongo ID or Binance order ID
+func (s *TradingService) GetSpotOrder(ctx context.Context, id string, opts OrderDetailOptions) (*models.SpotOrder, error) {
+	order, err := findOrder(ctx, id, s.repos.SpotOrders.FindByID, s.repos.SpotOrders.FindByBinanceID)
+	if err != nil {
+		return nil, err
+	}
+	if !accountSees(ctx, order.AccountID) {
+		return nil, ErrOrderNotFound
+	}
+	if !opts.IncludeRaw {
+		order.RawResponse = nil
+	}
+	if order.JournalEntries, err = s.orderJournal(ctx, order.ID, opts); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// accountSees reports whether a document of accountID is visible to ctx: every document when no
+// account is selected, else only the selected account's
+func accountSees(ctx context.Context, accountID string) bool {
+	selected := AccountFromContext(ctx)
+	return selected == "" || selected == accountID
+}
+
+// replayedFuturesOrder returns the stored order a client order ID already placed for the request's
+// account, so a retried request gets it back instead of placing a second order. Client order IDs
+// generated for grids, DCA plans and conditional or scheduled orders are left to those.
+func (s *TradingService) replayedFuturesOrder(ctx context.Context, clientOrderID string) (*models.FuturesOrder, error) {
+	if clientOrderID == "" || s.strategyIDOf(clientOrderID) != "" {
+		return nil, nil
+	}
+	order, err := s.repos.FuturesOrders.FindByClientOrderID(ctx, s.accountID(ctx), clientOrderID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up client order ID: %w", err)
+	}
+	order.Replayed = true
+	return order, nil
+}
+
+// replayedSpotOrder returns the stored spot order a client order ID already placed
+func (s *TradingService) replayedSpotOrder(ctx context.Context, clientOrderID string) (*models.SpotOrder, error) {
+	if clientOrderID == "" {
+		return nil, nil
+	}
+	order, err := s.repos.SpotOrders.FindByClientOrderID(ctx, s.accountID(ctx), clientOrderID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up client order ID: %w", err)
+	}
+	order.Replayed = true
+	return order, nil
+}
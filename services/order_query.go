@@ -0,0 +1,33 @@
+package services
+
+import (
+	"futures-options/models"
+	"futures-options/repository"
+)
+
+// OrderQuery holds the filters and paging options for order listings
+type OrderQuery = repository.OrderQuery
+
+// ErrInvalidCursor is returned by listings whose before_id is malformed or not in the listing
+var ErrInvalidCursor = repository.ErrInvalidCursor
+
+// FuturesOrderPage is a page of futures orders
+type FuturesOrderPage struct {
+	Items      []*models.FuturesOrder `json:"items"`
+	Total      int64                  `json:"total"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+// OptionsOrderPage is a page of options orders
+type OptionsOrderPage struct {
+	Items      []*models.OptionsOrder `json:"items"`
+	Total      int64                  `json:"total"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+// SpotOrderPage is a page of spot orders
+type SpotOrderPage struct {
+	Items      []*models.SpotOrder `json:"items"`
+	Total      int64               `json:"total"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"futures-options/models"
+)
+
+// How an order search hit matched, reported in FuturesOrderHit.MatchedBy
+const (
+	MatchBinanceOrderID      = "binance_order_id"
+	MatchClientOrderID       = "client_order_id"        // the exact client order ID
+	MatchClientOrderIDPrefix = "client_order_id_prefix" // a client order ID starting with the search
+	MatchStrategyID          = "strategy_id"
+)
+
+// FuturesOrderHit is a futures order found by a search and the lookup it matched
+type FuturesOrderHit struct {
+	*models.FuturesOrder
+	MatchedBy string `json:"matched_by"`
+}
+
+// FuturesOrderSearchPage is a page of futures order search results
+type FuturesOrderSearchPage struct {
+	Items      []*FuturesOrderHit `json:"items"`
+	Total      int64              `json:"total"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// SearchFuturesOrders finds stored futures orders by client order ID (exact or prefix), Binance
+// order ID, strategy ID or free text, combined with the usual listing filters
+func (s *TradingService) SearchFuturesOrders(ctx context.Context, query *OrderQuery) (*FuturesOrderSearchPage, error) {
+	query.ClientOrderIDPrefix = strings.TrimSpace(query.ClientOrderIDPrefix)
+	query.StrategyID = strings.TrimSpace(query.StrategyID)
+	query.Text = strings.TrimSpace(query.Text)
+	if query.ClientOrderIDPrefix == "" && query.BinanceOrderID == 0 && query.StrategyID == "" && query.Text == "" {
+		v := &validator{}
+		v.add("client_order_id", RuleRequired, "or binance_order_id, strategy_id or q is required")
+		return nil, v.err()
+	}
+
+	query.AccountID = AccountFromContext(ctx)
+	orders, total, err := s.repos.FuturesOrders.List(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search futures orders: %w", err)
+	}
+	page := &FuturesOrderSearchPage{Total: total}
+	if int64(len(orders)) > query.PageLimit() {
+		orders = orders[:query.PageLimit()]
+		page.NextCursor = orders[len(orders)-1].ID.Hex()
+	}
+	page.Items = make([]*FuturesOrderHit, len(orders))
+	for i, o := range orders {
+		page.Items[i] = &FuturesOrderHit{FuturesOrder: o, MatchedBy: orderMatch(query, o)}
+	}
+	return page, nil
+}
+
+// orderMatch names the most specific lookup of query that o satisfies
+func orderMatch(query *OrderQuery, o *models.FuturesOrder) string {
+	id, _ := strconv.ParseInt(query.Text, 10, 64)
+	switch {
+	case query.BinanceOrderID > 0 || id > 0 && o.BinanceOrderID == id:
+		return MatchBinanceOrderID
+	case query.ClientOrderIDPrefix != "" && o.ClientOrderID == query.ClientOrderIDPrefix,
+		query.Text != "" && o.ClientOrderID == query.Text:
+		return MatchClientOrderID
+	case query.StrategyID != "" || query.Text != "" && o.StrategyID == query.Text:
+		return MatchStrategyID
+	default:
+		return MatchClientOrderIDPrefix
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"futures-options/models"
+	"futures-options/repository"
+)
+
+// SetRawResponseLimit caps the size of raw Binance responses stored with orders; 0 disables storage
+func (s *TradingService) SetRawResponseLimit(maxBytes int) {
+	s.rawResponseMaxBytes = maxBytes
+}
+
+// rawResponse marshals a Binance response for storage, or returns nil if it is empty or too large
+func (s *TradingService) rawResponse(v interface{}) json.RawMessage {
+	if v == nil || s.rawResponseMaxBytes <= 0 {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil || string(raw) == "null" {
+		return nil
+	}
+	if len(raw) > s.rawResponseMaxBytes {
+		slog.Warn("Binance response exceeds size limit, not storing it", "bytes", len(raw), "limit", s.rawResponseMaxBytes)
+		return nil
+	}
+	return raw
+}
+
+// saveFuturesOrder inserts a futures order; if an order with the same Binance ID is
+// already recorded (e.g. by the user data stream) the existing document is returned
+func (s *TradingService) saveFuturesOrder(ctx context.Context, order *models.FuturesOrder) (*models.FuturesOrder, error) {
+	order.Paper = s.Paper()
+	order.StrategyID = s.strategyIDOf(order.ClientOrderID)
+	if order.Source == "" {
+		order.Source = s.sourceOf(order.ClientOrderID)
+	}
+	order.AccountID = s.accountID(ctx)
+	err := s.repos.FuturesOrders.Insert(ctx, order)
+	if err == nil {
+		return order, nil
+	}
+	if errors.Is(err, repository.ErrDuplicate) && order.BinanceOrderID > 0 {
+		if existing, findErr := s.repos.FuturesOrders.FindByBinanceID(ctx, order.BinanceOrderID); findErr == nil {
+			return existing, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to save order to database: %w", err)
+}
+
+// errBatchDuplicates aborts the transactional batch insert so it can be retried without one
+var errBatchDuplicates = errors.New("batch contains recorded orders")
+
+// saveFuturesOrders inserts orders in a single write, in a transaction where supported so either
+// every order is stored or none is. Like saveFuturesOrder, an order whose Binance ID is already
+// recorded is replaced by the existing document in the result; such a batch is stored without
+// a transaction, since a duplicate key aborts it.
+func (s *TradingService) saveFuturesOrders(ctx context.Context, orders []*models.FuturesOrder) ([]*models.FuturesOrder, error) {
+	paper, accountID := s.Paper(), s.accountID(ctx)
+	for _, order := range orders {
+		order.Paper = paper
+		order.StrategyID = s.strategyIDOf(order.ClientOrderID)
+		if order.Source == "" {
+			order.Source = s.sourceOf(order.ClientOrderID)
+		}
+		order.AccountID = accountID
+	}
+
+	var duplicates []int
+	var err error
+	if s.repos.Tx.Transactional() {
+		err = s.repos.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+			var txErr error
+			duplicates, txErr = s.repos.FuturesOrders.InsertMany(ctx, orders)
+			if txErr == nil && len(duplicates) > 0 {
+				return errBatchDuplicates
+			}
+			return txErr
+		})
+		if err == nil {
+			return orders, nil
+		}
+		if !errors.Is(err, errBatchDuplicates) {
+			slog.Warn("transactional batch insert failed, retrying without a transaction", "orders", len(orders), "error", err)
+		}
+	}
+	duplicates, err = s.repos.FuturesOrders.InsertMany(ctx, orders)
+
+	saved := make([]*models.FuturesOrder, len(orders))
+	copy(saved, orders)
+	for _, i := range duplicates {
+		saved[i] = nil
+		if orders[i].BinanceOrderID > 0 {
+			if existing, findErr := s.repos.FuturesOrders.FindByBinanceID(ctx, orders[i].BinanceOrderID); findErr == nil {
+				saved[i] = existing
+			}
+		}
+	}
+
+	result := make([]*models.FuturesOrder, 0, len(saved))
+	for _, order := range saved {
+		if order != nil {
+			result = append(result, order)
+		}
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to save orders to database: %w", err)
+	}
+	return result, nil
+}
+
+// saveOptionsOrder inserts an options order, returning the existing document on a duplicate Binance ID
+func (s *TradingService) saveOptionsOrder(ctx context.Context, order *models.OptionsOrder) (*models.OptionsOrder, error) {
+	order.AccountID = s.accountID(ctx)
+	err := s.repos.OptionsOrders.Insert(ctx, order)
+	if err == nil {
+		return order, nil
+	}
+	if errors.Is(err, repository.ErrDuplicate) && order.BinanceOrderID > 0 {
+		if existing, findErr := s.repos.OptionsOrders.FindByBinanceID(ctx, order.BinanceOrderID); findErr == nil {
+			return existing, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to save order to database: %w", err)
+}
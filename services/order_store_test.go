@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSaveFuturesOrdersKeepsRecordedDuplicates(t *testing.T) {
+	s, _, repos := newTestService(t)
+	ctx := context.Background()
+	storeOrder(t, repos, &models.FuturesOrder{Symbol: "BTCUSDT", BinanceOrderID: 4002, ClientOrderID: "recorded", Quantity: 1})
+
+	batch := make([]*models.FuturesOrder, 3)
+	for i := range batch {
+		batch[i] = &models.FuturesOrder{
+			ID:             primitive.NewObjectID(),
+			Symbol:         "BTCUSDT",
+			BinanceOrderID: int64(4001 + i),
+			ClientOrderID:  fmt.Sprintf("batch-%d", i),
+			Quantity:       2,
+			Status:         "NEW",
+			CreatedAt:      time.Now(),
+		}
+	}
+	saved, err := s.saveFuturesOrders(ctx, batch)
+	if err != nil {
+		t.Fatalf("saveFuturesOrders: %v", err)
+	}
+	if len(saved) != 3 {
+		t.Fatalf("saved %d orders, want 3", len(saved))
+	}
+	// The duplicate is replaced by the recorded document, in its place in the batch
+	if saved[1].ClientOrderID != "recorded" || saved[1].Quantity != 1 {
+		t.Errorf("saved[1] = %q quantity %v, want the recorded order", saved[1].ClientOrderID, saved[1].Quantity)
+	}
+	for _, i := range []int{0, 2} {
+		if saved[i] != batch[i] {
+			t.Errorf("saved[%d] is not the inserted order", i)
+		}
+		if _, err := repos.FuturesOrders.FindByBinanceID(ctx, batch[i].BinanceOrderID); err != nil {
+			t.Errorf("order %d not stored: %v", batch[i].BinanceOrderID, err)
+		}
+	}
+	stored, err := repos.FuturesOrders.FindByBinanceID(ctx, 4002)
+	if err != nil || stored.ClientOrderID != "recorded" {
+		t.Errorf("recorded order overwritten: %+v, %v", stored, err)
+	}
+}
+
+// BenchmarkCreateBatchOrders places and stores 50 orders per batch, the single InsertMany write
+// included
+func BenchmarkCreateBatchOrders(b *testing.B) {
+	const batchSize = 50
+	orders := make([]AdvancedOrderRequest, batchSize)
+	for i := range orders {
+		orders[i] = AdvancedOrderRequest{Symbol: "BTCUSDT", Side: "BUY", OrderType: "LIMIT", Quantity: decimal.RequireFromString("0.001"), Price: decimal.NewFromInt(int64(40000 + i)), TimeInForce: "GTC"}
+	}
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		// A fresh store per batch, so the open order caps and the stored order list do not grow
+		b.StopTimer()
+		s, mock, _ := newTestService(b)
+		var nextID int64
+		mock.CreateBatchOrdersFunc = func(ctx context.Context, orders []*binance.AdvancedOrderRequest) ([]*futures.CreateOrderResponse, error) {
+			responses := make([]*futures.CreateOrderResponse, len(orders))
+			for i, o := range orders {
+				responses[i] = &futures.CreateOrderResponse{Symbol: o.Symbol, OrderID: atomic.AddInt64(&nextID, 1), Status: futures.OrderStatusTypeNew}
+			}
+			return responses, nil
+		}
+		req := &BatchOrderRequest{Orders: append([]AdvancedOrderRequest(nil), orders...)}
+		b.StartTimer()
+
+		resp, err := s.CreateBatchOrders(ctx, req)
+		if err != nil {
+			b.Fatalf("CreateBatchOrders: %v", err)
+		}
+		if len(resp.Orders) != batchSize {
+			b.Fatalf("stored %d orders, want %d", len(resp.Orders), batchSize)
+		}
+	}
+}
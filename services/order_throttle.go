@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"futures-options/logging"
+	"futures-options/models"
+)
+
+// Order throttle windows reported in OrderThrottleError.Window
+const (
+	ThrottleWindow10s    = "10s"
+	ThrottleWindowMinute = "1m"
+)
+
+// OrderThrottleError reports orders rejected because too many were created recently
+type OrderThrottleError struct {
+	Symbol      string        `json:"symbol"`       // GLOBAL when the cap across all symbols applied
+	LimitSymbol string        `json:"limit_symbol"` // the symbol, DEFAULT or GLOBAL
+	Window      string        `json:"window"`       // 10s or 1m
+	Max         int           `json:"max"`
+	RetryAfter  time.Duration `json:"-"`
+	RetrySecs   int           `json:"retry_after_seconds"`
+}
+
+func (e *OrderThrottleError) Error() string {
+	return fmt.Sprintf("too many new orders for %s: the %s limit allows %d per %s, retry in %ds",
+		e.Symbol, e.LimitSymbol, e.Max, e.Window, e.RetrySecs)
+}
+
+// orderThrottle remembers when recent orders were accepted, per symbol and across all symbols
+// (under models.RiskLimitGlobalSymbol). Only the last minute is kept.
+type orderThrottle struct {
+	mu     sync.Mutex
+	recent map[string][]time.Time
+}
+
+type throttleWindow struct {
+	name   string
+	length time.Duration
+	max    int
+}
+
+func throttleWindows(limit *models.RiskLimit) []throttleWindow {
+	return []throttleWindow{
+		{name: ThrottleWindow10s, length: 10 * time.Second, max: limit.MaxOrdersPer10s},
+		{name: ThrottleWindowMinute, length: time.Minute, max: limit.MaxOrdersPerMinute},
+	}
+}
+
+// check reports whether n more orders for key fit within limit's windows
+func (t *orderThrottle) check(key string, n int, limit *models.RiskLimit, now time.Time) *OrderThrottleError {
+	if limit == nil {
+		return nil
+	}
+	times := t.recent[key]
+	for _, w := range throttleWindows(limit) {
+		if w.max <= 0 {
+			continue
+		}
+		cutoff := now.Add(-w.length)
+		first := len(times)
+		for i, ts := range times {
+			if ts.After(cutoff) {
+				first = i
+				break
+			}
+		}
+		inWindow := times[first:]
+		if len(inWindow)+n <= w.max {
+			continue
+		}
+
+		// Wait until enough of the orders in the window have aged out
+		retryAfter := w.length
+		if excess := len(inWindow) + n - w.max; n <= w.max && excess <= len(inWindow) {
+			retryAfter = inWindow[excess-1].Add(w.length).Sub(now)
+		}
+		return &OrderThrottleError{
+			Symbol:      key,
+			LimitSymbol: limit.Symbol,
+			Window:      w.name,
+			Max:         w.max,
+			RetryAfter:  retryAfter,
+			RetrySecs:   int(math.Ceil(retryAfter.Seconds())),
+		}
+	}
+	return nil
+}
+
+func (t *orderThrottle) record(key string, n int, now time.Time) {
+	for i := 0; i < n; i++ {
+		t.recent[key] = append(t.recent[key], now)
+	}
+}
+
+func (t *orderThrottle) prune(now time.Time) {
+	cutoff := now.Add(-time.Minute)
+	for key, times := range t.recent {
+		first := len(times)
+		for i, ts := range times {
+			if ts.After(cutoff) {
+				first = i
+				break
+			}
+		}
+		if first == len(times) {
+			delete(t.recent, key)
+		} else if first > 0 {
+			t.recent[key] = append([]time.Time(nil), times[first:]...)
+		}
+	}
+}
+
+// throttleOrders counts orders against the per-symbol (or DEFAULT) and GLOBAL order rate limits,
+// recording them when they fit. Every order creation path shares the same counters; rejections
+// are audited.
+func (s *TradingService) throttleOrders(ctx context.Context, orders []riskOrder, limits map[string]*models.RiskLimit) error {
+	counts := make(map[string]int)
+	var symbols []string
+	for _, o := range orders {
+		if counts[o.Symbol] == 0 {
+			symbols = append(symbols, o.Symbol)
+		}
+		counts[o.Symbol]++
+	}
+
+	now := time.Now()
+	s.throttle.mu.Lock()
+	if s.throttle.recent == nil {
+		s.throttle.recent = make(map[string][]time.Time)
+	}
+	s.throttle.prune(now)
+
+	var throttleErr *OrderThrottleError
+	for _, symbol := range symbols {
+		limit, ok := limits[symbol]
+		if !ok {
+			limit = limits[models.RiskLimitDefaultSymbol]
+		}
+		if throttleErr = s.throttle.check(symbol, counts[symbol], limit, now); throttleErr != nil {
+			break
+		}
+	}
+	if throttleErr == nil {
+		throttleErr = s.throttle.check(models.RiskLimitGlobalSymbol, len(orders), limits[models.RiskLimitGlobalSymbol], now)
+	}
+	if throttleErr == nil {
+		for _, symbol := range symbols {
+			s.throttle.record(symbol, counts[symbol], now)
+		}
+		s.throttle.record(models.RiskLimitGlobalSymbol, len(orders), now)
+	}
+	s.throttle.mu.Unlock()
+
+	if throttleErr != nil {
+		logging.FromContext(ctx).Warn("order throttled",
+			"symbol", throttleErr.Symbol, "limit_symbol", throttleErr.LimitSymbol, "window", throttleErr.Window, "max", throttleErr.Max)
+		s.recordAudit(ctx, models.AuditOrderThrottled, throttleErr.Symbol, map[string]interface{}{"orders": counts}, nil, throttleErr, now)
+		return throttleErr
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"time"
+
+	"futures-options/models"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// JobOrphanOrderSweep is the name of the job canceling protective orders left behind by closed positions
+const JobOrphanOrderSweep = "orphan-order-sweep"
+
+// OrphanSweepResult lists the reduce-only and close-position orders found resting on a flat
+// position, and whether each was canceled
+type OrphanSweepResult struct {
+	DryRun bool `json:"dry_run"`
+	// Checked is the number of open orders looked at
+	Checked   int            `json:"checked"`
+	Orphans   []*OrphanOrder `json:"orphans"`
+	Canceled  int            `json:"canceled"`
+	StartedAt time.Time      `json:"started_at"`
+	Duration  string         `json:"duration"`
+}
+
+// OrphanOrder is a protective order whose position is flat
+type OrphanOrder struct {
+	Symbol string `json:"symbol"`
+	*ProtectiveOrder
+	Canceled bool   `json:"canceled"`
+	Error    string `json:"error,omitempty"`
+}
+
+// StartOrphanOrderSweep registers the orphan order sweep job; with dryRun set the scheduled runs
+// only log the orders they would cancel
+func (s *TradingService) StartOrphanOrderSweep(ctx context.Context, interval time.Duration, dryRun bool) {
+	s.RegisterJob(ctx, Job{Name: JobOrphanOrderSweep, Interval: interval, Run: func(ctx context.Context) error {
+		result, err := s.SweepOrphanOrders(ctx, dryRun)
+		if err != nil {
+			return err
+		}
+		for _, o := range result.Orphans {
+			slog.Info("orphan protective order", "symbol", o.Symbol, "binance_order_id", o.OrderID,
+				"type", o.Type, "dry_run", dryRun, "canceled", o.Canceled, "error", o.Error)
+		}
+		return nil
+	}})
+}
+
+// SweepOrphanOrders finds open USDⓈ-M reduce-only and close-position orders whose position side
+// is flat and, unless dryRun is set, cancels them. A side that also has an open order that is
+// not reduce-only is skipped: its stop may be waiting for that entry to fill.
+func (s *TradingService) SweepOrphanOrders(ctx context.Context, dryRun bool) (*OrphanSweepResult, error) {
+	result := &OrphanSweepResult{DryRun: dryRun, Orphans: []*OrphanOrder{}, StartedAt: time.Now()}
+	defer func() { result.Duration = time.Since(result.StartedAt).String() }()
+
+	// Orders are listed before positions, so an entry order that fills in between still shows up,
+	// as its position, and keeps its protective orders
+	orders, err := s.listOpenFuturesOrders(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open orders: %w", err)
+	}
+	positions, err := s.api(ctx).GetFuturesPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+	result.Checked = len(orders)
+
+	open := make(map[string]bool)
+	for _, p := range positions {
+		if amount, _ := strconv.ParseFloat(p.PositionAmt, 64); amount != 0 {
+			open[p.Symbol+"/"+p.PositionSide] = true
+		}
+	}
+	for _, o := range orders {
+		if !o.ReduceOnly && !o.ClosePosition {
+			open[o.Symbol+"/"+orderPositionSide(o)] = true
+		}
+	}
+
+	bySymbol := make(map[string][]*OrphanOrder)
+	for _, o := range orders {
+		if (!o.ReduceOnly && !o.ClosePosition) || open[o.Symbol+"/"+orderPositionSide(o)] {
+			continue
+		}
+		orphan := &OrphanOrder{Symbol: o.Symbol, ProtectiveOrder: protectiveOrder(o)}
+		result.Orphans = append(result.Orphans, orphan)
+		bySymbol[o.Symbol] = append(bySymbol[o.Symbol], orphan)
+	}
+	sort.Slice(result.Orphans, func(i, j int) bool {
+		a, b := result.Orphans[i], result.Orphans[j]
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		return a.OrderID < b.OrderID
+	})
+	if dryRun {
+		return result, nil
+	}
+
+	for symbol, orphans := range bySymbol {
+		result.Canceled += s.cancelOrphanOrders(ctx, symbol, orphans)
+	}
+	return result, nil
+}
+
+// orderPositionSide is the position side an order belongs to; BOTH in one-way mode
+func orderPositionSide(o *futures.Order) string {
+	if o.PositionSide == "" {
+		return string(futures.PositionSideTypeBoth)
+	}
+	return string(o.PositionSide)
+}
+
+// cancelOrphanOrders cancels the orphan orders of symbol, marks them canceled and returns how
+// many Binance confirmed. Orders that fill or are canceled in the meantime are reported with an
+// error rather than failing the sweep.
+func (s *TradingService) cancelOrphanOrders(ctx context.Context, symbol string, orphans []*OrphanOrder) int {
+	ids := make([]int64, len(orphans))
+	for i, o := range orphans {
+		ids[i] = o.OrderID
+	}
+	start := time.Now()
+	canceled, err := s.api(ctx).CancelBatchOrders(ctx, symbol, ids, nil)
+	s.recordAudit(ctx, models.AuditOrderCancel, symbol, map[string]interface{}{
+		"symbol":    symbol,
+		"order_ids": ids,
+		"reason":    "orphan protective order",
+	}, canceled, err, start)
+	if err != nil {
+		for _, o := range orphans {
+			o.Error = err.Error()
+		}
+		return 0
+	}
+
+	confirmed := make(map[int64]bool, len(canceled))
+	var canceledIDs []int64
+	for _, resp := range canceled {
+		if resp != nil {
+			confirmed[resp.OrderID] = true
+			canceledIDs = append(canceledIDs, resp.OrderID)
+		}
+	}
+	for _, o := range orphans {
+		if o.Canceled = confirmed[o.OrderID]; !o.Canceled {
+			o.Error = "not canceled; it may have filled or been canceled already"
+		}
+	}
+	if len(canceledIDs) > 0 {
+		if err := s.repos.FuturesOrders.SetStatus(ctx, symbol, canceledIDs, nil, "CANCELED"); err != nil {
+			slog.Warn("failed to mark orphan orders canceled", "symbol", symbol, "error", err)
+		}
+	}
+	return len(canceledIDs)
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrPaperUnsupported is returned for features the paper trading engine does not simulate
+var ErrPaperUnsupported = errors.New("not available in paper trading mode")
+
+// paperAccount is implemented by the paper trading client (package paper), which keeps a
+// simulated account instead of the one on Binance
+type paperAccount interface {
+	AccountStatus(ctx context.Context) (interface{}, error)
+	AccountBalance(ctx context.Context) (interface{}, error)
+}
+
+// Paper reports whether orders are simulated by the paper trading engine instead of sent to Binance
+func (s *TradingService) Paper() bool {
+	_, ok := s.binanceClient.(paperAccount)
+	return ok
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"futures-options/logging"
+	"futures-options/models"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// ErrPositionNotFound is returned when a symbol has no open position on Binance
+var ErrPositionNotFound = errors.New("no open position")
+
+// PositionDetail is one USDⓈ-M symbol's position from every source. Stored holds the documents
+// saved by the last sync (cached, see their updated_at); Live, ProtectiveOrders and ADLQuantile
+// were read from Binance at LiveAt.
+type PositionDetail struct {
+	Symbol string             `json:"symbol"`
+	Stored []*models.Position `json:"stored"` // one per side in hedge mode
+	LiveAt time.Time          `json:"live_at"`
+	Live   []*LivePosition    `json:"live"` // one per side in hedge mode
+	// ProtectiveOrders are the symbol's open reduce-only and close-position orders
+	ProtectiveOrders []*ProtectiveOrder `json:"protective_orders"`
+	// ADLQuantile is the auto-deleveraging queue position per side, 0 to 4 (first in line)
+	ADLQuantile map[string]int `json:"adl_quantile,omitempty"`
+	// FundingRate is the rate paid at NextFundingTime; each live position's ProjectedFunding applies
+	// it to the position's notional
+	FundingRate     *float64   `json:"funding_rate,omitempty"`
+	NextFundingTime *time.Time `json:"next_funding_time,omitempty"`
+	// Errors lists the live enrichments that could not be loaded
+	Errors []string `json:"errors,omitempty"`
+}
+
+// LivePosition is a position as reported by Binance's position risk endpoint
+type LivePosition struct {
+	PositionSide     string  `json:"position_side"`
+	Quantity         float64 `json:"quantity"`
+	EntryPrice       float64 `json:"entry_price"`
+	MarkPrice        float64 `json:"mark_price"`
+	LiquidationPrice float64 `json:"liquidation_price"`
+	UnrealizedPnl    float64 `json:"unrealized_pnl"`
+	Notional         float64 `json:"notional"`
+	Leverage         int     `json:"leverage"`
+	MarginType       string  `json:"margin_type"`
+	IsolatedMargin   float64 `json:"isolated_margin,omitempty"`
+	MarginRatio      float64 `json:"margin_ratio,omitempty"` // maintenance margin over margin balance; 1 means liquidation
+	MarginWarning    bool    `json:"margin_warning,omitempty"`
+	ProjectedFunding float64 `json:"projected_funding"` // next funding payment at the current rate; negative when received
+}
+
+// ProtectiveOrder is an open order that can only reduce the position
+type ProtectiveOrder struct {
+	OrderID       int64   `json:"order_id"`
+	ClientOrderID string  `json:"client_order_id,omitempty"`
+	Type          string  `json:"type"`
+	Side          string  `json:"side"`
+	PositionSide  string  `json:"position_side,omitempty"`
+	Quantity      float64 `json:"quantity"`
+	Price         float64 `json:"price,omitempty"`
+	StopPrice     float64 `json:"stop_price,omitempty"`
+	WorkingType   string  `json:"working_type,omitempty"`
+	ReduceOnly    bool    `json:"reduce_only,omitempty"`
+	ClosePosition bool    `json:"close_position,omitempty"`
+}
+
+// GetPositionDetail merges symbol's stored position with its live position risk, margin ratio,
+// protective orders, ADL quantile and projected funding. The live position is required; the other enrichments
+// are best effort and reported in Errors when they fail.
+func (s *TradingService) GetPositionDetail(ctx context.Context, symbol string) (*PositionDetail, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	detail := &PositionDetail{Symbol: symbol, Stored: []*models.Position{}, ProtectiveOrders: []*ProtectiveOrder{}}
+
+	risks, err := s.api(ctx).GetFuturesPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get positions from Binance: %w", err)
+	}
+	detail.LiveAt = time.Now()
+	for _, p := range risks {
+		if p.Symbol != symbol {
+			continue
+		}
+		live, err := livePosition(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s position: %w", symbol, err)
+		}
+		if live.Quantity != 0 {
+			detail.Live = append(detail.Live, live)
+		}
+	}
+	if len(detail.Live) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrPositionNotFound, symbol)
+	}
+
+	err = s.repos.Positions.Each(ctx, "FUTURES", AccountFromContext(ctx), func(p *models.Position) error {
+		if p.Symbol == symbol && marketOf(p.Market) == models.MarketUSDM {
+			detail.Stored = append(detail.Stored, p)
+		}
+		return nil
+	})
+	if err != nil {
+		detail.addError(ctx, "stored position", err)
+	}
+	sort.Slice(detail.Stored, func(i, j int) bool { return detail.Stored[i].Side < detail.Stored[j].Side })
+
+	if ratios, err := s.marginRatios(ctx); err != nil {
+		detail.addError(ctx, "margin ratio", err)
+	} else {
+		threshold := s.marginRatioWarning
+		if threshold <= 0 {
+			threshold = defaultMarginRatioWarning
+		}
+		for _, live := range detail.Live {
+			if ratio, ok := ratios[symbol+"/"+live.PositionSide]; ok {
+				live.MarginRatio = ratio
+				live.MarginWarning = ratio >= threshold
+			}
+		}
+	}
+
+	if orders, err := s.listOpenFuturesOrders(ctx, symbol); err != nil {
+		detail.addError(ctx, "protective orders", err)
+	} else {
+		for _, o := range orders {
+			if o.ReduceOnly || o.ClosePosition {
+				detail.ProtectiveOrders = append(detail.ProtectiveOrders, protectiveOrder(o))
+			}
+		}
+	}
+
+	if detail.ADLQuantile, err = s.api(ctx).GetADLQuantile(ctx, symbol); err != nil {
+		detail.addError(ctx, "ADL quantile", err)
+	}
+
+	if funding, err := s.api(ctx).GetFundingRate(ctx, symbol); err != nil {
+		detail.addError(ctx, "funding rate", err)
+	} else {
+		detail.FundingRate = &funding.Rate
+		detail.NextFundingTime = &funding.NextFundingTime
+		for _, live := range detail.Live {
+			live.ProjectedFunding = projectedFunding(live.PositionSide, live.Quantity, live.Notional, funding.Rate)
+		}
+	}
+	return detail, nil
+}
+
+func (d *PositionDetail) addError(ctx context.Context, what string, err error) {
+	logging.FromContext(ctx).Warn("failed to load position detail", "symbol", d.Symbol, "part", what, "error", err)
+	d.Errors = append(d.Errors, fmt.Sprintf("%s: %v", what, err))
+}
+
+func livePosition(p *futures.PositionRisk) (*LivePosition, error) {
+	var d decimalParser
+	live := &LivePosition{
+		PositionSide:     string(positionSideOf(p.PositionSide)),
+		Quantity:         d.float("positionAmt", p.PositionAmt),
+		EntryPrice:       d.float("entryPrice", p.EntryPrice),
+		MarkPrice:        d.float("markPrice", p.MarkPrice),
+		LiquidationPrice: d.float("liquidationPrice", p.LiquidationPrice),
+		UnrealizedPnl:    d.float("unRealizedProfit", p.UnRealizedProfit),
+		Notional:         math.Abs(d.float("notional", p.Notional)),
+		Leverage:         d.int("leverage", p.Leverage),
+		MarginType:       strings.ToUpper(p.MarginType),
+	}
+	if isIsolated(p) {
+		live.IsolatedMargin = d.float("isolatedMargin", p.IsolatedMargin)
+	}
+	return live, d.err
+}
+
+func protectiveOrder(o *futures.Order) *ProtectiveOrder {
+	quantity, _ := strconv.ParseFloat(o.OrigQuantity, 64)
+	price, _ := strconv.ParseFloat(o.Price, 64)
+	stopPrice, _ := strconv.ParseFloat(o.StopPrice, 64)
+	return &ProtectiveOrder{
+		OrderID:       o.OrderID,
+		ClientOrderID: o.ClientOrderID,
+		Type:          string(o.Type),
+		Side:          string(o.Side),
+		PositionSide:  string(o.PositionSide),
+		Quantity:      quantity,
+		Price:         price,
+		StopPrice:     stopPrice,
+		WorkingType:   string(o.WorkingType),
+		ReduceOnly:    o.ReduceOnly,
+		ClosePosition: o.ClosePosition,
+	}
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/strategy"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+)
+
+// PositionSizeRequest sizes a position so that a move from entry to stop loses a set amount
+type PositionSizeRequest struct {
+	Symbol string `json:"symbol"`
+	// RiskPercent is the share of the account's margin balance to risk, e.g. 1 for 1%
+	RiskPercent float64 `json:"risk_percent,omitempty"`
+	// RiskAmount is the amount to risk in the margin asset, instead of RiskPercent
+	RiskAmount float64 `json:"risk_amount,omitempty"`
+	// EntryPrice may be omitted for MARKET entries, which are then sized at the current mark price
+	EntryPrice float64 `json:"entry_price,omitempty"`
+	StopPrice  float64 `json:"stop_price"`
+	Leverage   int     `json:"leverage"`
+	// OrderType is the entry order type when executing: LIMIT at entry_price (default) or MARKET
+	OrderType string `json:"order_type,omitempty"`
+	// Execute places the entry order and a close-position STOP_MARKET at stop_price as one batch
+	Execute bool `json:"execute,omitempty"`
+}
+
+// UnmarshalJSON takes the risk amount and entry and stop prices as JSON numbers or decimal strings
+func (r *PositionSizeRequest) UnmarshalJSON(data []byte) error {
+	type plain PositionSizeRequest
+	return decodeDecimalFields(data, (*plain)(r))
+}
+
+// Validate checks the request fields
+func (r *PositionSizeRequest) Validate() error {
+	v := &validator{}
+	v.required("symbol", r.Symbol)
+	if r.OrderType == string(models.OrderTypeMarket) {
+		v.nonNegative("entry_price", r.EntryPrice)
+	} else {
+		v.positive("entry_price", r.EntryPrice)
+	}
+	v.positive("stop_price", r.StopPrice)
+	if r.EntryPrice > 0 && r.StopPrice == r.EntryPrice {
+		v.add("stop_price", RuleRange, "must differ from entry_price")
+	}
+	switch {
+	case r.RiskPercent == 0 && r.RiskAmount == 0:
+		v.add("risk_percent", RuleRequired, "or risk_amount must be set")
+	case r.RiskPercent != 0 && r.RiskAmount != 0:
+		v.add("risk_amount", RuleRange, "cannot be combined with risk_percent")
+	case r.RiskPercent < 0 || r.RiskPercent > 100:
+		v.add("risk_percent", RuleRange, "must be between 0 and 100")
+	default:
+		v.nonNegative("risk_amount", r.RiskAmount)
+	}
+	if r.Leverage < 1 || r.Leverage > MaxLeverage {
+		v.add("leverage", RuleRange, fmt.Sprintf("must be between 1 and %d", MaxLeverage))
+	}
+	v.oneOf("order_type", r.OrderType, string(models.OrderTypeLimit), string(models.OrderTypeMarket))
+	return v.err()
+}
+
+// PositionSize is the computed position and whether it can be placed
+type PositionSize struct {
+	Symbol    string `json:"symbol"`
+	Side      string `json:"side"` // BUY when the stop is below the entry, SELL when above
+	OrderType string `json:"order_type"`
+	// Quantity is rounded down to the symbol's step size, so ActualRisk never exceeds RiskAmount
+	Quantity         float64 `json:"quantity"`
+	EntryPrice       float64 `json:"entry_price"`
+	StopPrice        float64 `json:"stop_price"`
+	RiskAmount       float64 `json:"risk_amount"`
+	ActualRisk       float64 `json:"actual_risk"`
+	Notional         float64 `json:"notional"`
+	RequiredMargin   float64 `json:"required_margin"`
+	AvailableBalance float64 `json:"available_balance"`
+	Leverage         int     `json:"leverage"`
+	// Allowed is false when the symbol's filters, the risk limits or the available balance reject
+	// the position; Violations explains why
+	Allowed    bool                   `json:"allowed"`
+	Violations []string               `json:"violations,omitempty"`
+	Orders     []*models.FuturesOrder `json:"orders,omitempty"` // set when executed
+}
+
+// CalculatePositionSize sizes a position from the risk budget and the stop distance and checks it
+// against the symbol's filters, the risk limits and the available balance. With Execute set, an
+// allowed position is placed through CreateBatchOrders; one that is not allowed is returned
+// unplaced with its violations.
+func (s *TradingService) CalculatePositionSize(ctx context.Context, req *PositionSizeRequest) (*PositionSize, error) {
+	req.Symbol = strings.ToUpper(strings.TrimSpace(req.Symbol))
+	req.OrderType = strings.ToUpper(req.OrderType)
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.OrderType == "" {
+		req.OrderType = string(models.OrderTypeLimit)
+	}
+
+	filters, err := s.api(ctx).GetSymbolFilters(ctx, req.Symbol)
+	if errors.Is(err, binance.ErrUnknownSymbol) {
+		v := &validator{}
+		v.add("symbol", RuleEnum, "is not a listed futures symbol")
+		return nil, v.err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if req.EntryPrice == 0 {
+		mark, err := s.api(ctx).GetPrice(ctx, req.Symbol, futures.WorkingTypeMarkPrice, riskPriceMaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get mark price for position sizing: %w", err)
+		}
+		req.EntryPrice = mark.Value
+		if req.StopPrice == req.EntryPrice {
+			v := &validator{}
+			v.add("stop_price", RuleRange, "must differ from the current mark price")
+			return nil, v.err()
+		}
+	}
+	account, err := s.api(ctx).GetFuturesAccount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account for position sizing: %w", err)
+	}
+	marginBalance, _ := strconv.ParseFloat(account.TotalMarginBalance, 64)
+	available, _ := strconv.ParseFloat(account.AvailableBalance, 64)
+
+	size := &PositionSize{
+		Symbol:           req.Symbol,
+		Side:             string(models.OrderSideBuy),
+		OrderType:        req.OrderType,
+		EntryPrice:       roundToStep(req.EntryPrice, filters.TickSize, strategy.RoundNearest),
+		StopPrice:        roundToStep(req.StopPrice, filters.TickSize, strategy.RoundNearest),
+		RiskAmount:       req.RiskAmount,
+		AvailableBalance: available,
+		Leverage:         req.Leverage,
+	}
+	if size.StopPrice > size.EntryPrice {
+		size.Side = string(models.OrderSideSell)
+	}
+	if req.RiskPercent > 0 {
+		size.RiskAmount = marginBalance * req.RiskPercent / 100
+	}
+
+	step, minQty, maxQty := filters.StepSize, filters.MinQty, filters.MaxQty
+	if req.OrderType == string(models.OrderTypeMarket) && filters.MarketStepSize > 0 {
+		step, minQty, maxQty = filters.MarketStepSize, filters.MarketMinQty, filters.MarketMaxQty
+	}
+	stopDistance := math.Abs(size.EntryPrice - size.StopPrice)
+	if stopDistance > 0 {
+		size.Quantity = roundToStep(size.RiskAmount/stopDistance, step, strategy.RoundDown)
+	}
+	size.ActualRisk = size.Quantity * stopDistance
+	size.Notional = size.Quantity * size.EntryPrice
+	size.RequiredMargin = size.Notional / float64(req.Leverage)
+
+	switch {
+	case size.Quantity <= 0 || size.Quantity < minQty:
+		size.Violations = append(size.Violations, fmt.Sprintf("quantity %s is below the minimum of %s", formatFloat(size.Quantity), formatFloat(minQty)))
+	case maxQty > 0 && size.Quantity > maxQty:
+		size.Violations = append(size.Violations, fmt.Sprintf("quantity %s is above the maximum of %s", formatFloat(size.Quantity), formatFloat(maxQty)))
+	}
+	if size.Notional < filters.MinNotional {
+		size.Violations = append(size.Violations, fmt.Sprintf("notional %s is below the minimum of %s", formatFloat(size.Notional), formatFloat(filters.MinNotional)))
+	}
+	if size.RequiredMargin > available {
+		size.Violations = append(size.Violations, fmt.Sprintf("required margin %s exceeds the available balance of %s", formatFloat(size.RequiredMargin), formatFloat(available)))
+	}
+	entry := riskOrder{Symbol: size.Symbol, Side: size.Side, Quantity: size.Quantity, Price: size.EntryPrice}
+	if err := s.previewRiskLimits(ctx, []riskOrder{entry}); err != nil {
+		var riskErr *RiskLimitError
+		if !errors.As(err, &riskErr) && !errors.Is(err, ErrDailyLossLocked) {
+			return nil, err
+		}
+		size.Violations = append(size.Violations, err.Error())
+	}
+	size.Allowed = len(size.Violations) == 0
+
+	if !req.Execute || !size.Allowed {
+		return size, nil
+	}
+	batch, err := s.CreateBatchOrders(ctx, &BatchOrderRequest{Orders: s.positionSizeOrders(size)})
+	if err != nil {
+		return nil, err
+	}
+	size.Orders = batch.Orders
+	return size, nil
+}
+
+// positionSizeOrders builds the entry order and its close-position stop
+func (s *TradingService) positionSizeOrders(size *PositionSize) []AdvancedOrderRequest {
+	entry := AdvancedOrderRequest{
+		Symbol:    size.Symbol,
+		Side:      size.Side,
+		OrderType: size.OrderType,
+		Quantity:  decimal.NewFromFloat(size.Quantity),
+		Leverage:  size.Leverage,
+	}
+	if size.OrderType == string(models.OrderTypeLimit) {
+		entry.Price = decimal.NewFromFloat(size.EntryPrice)
+		entry.TimeInForce = string(models.TimeInForceGTC)
+	}
+
+	exitSide := string(models.OrderSideSell)
+	if size.Side == string(models.OrderSideSell) {
+		exitSide = string(models.OrderSideBuy)
+	}
+	stop := AdvancedOrderRequest{
+		Symbol:        size.Symbol,
+		Side:          exitSide,
+		OrderType:     string(models.OrderTypeStopMarket),
+		StopPrice:     decimal.NewFromFloat(size.StopPrice),
+		WorkingType:   string(models.WorkingTypeMarkPrice),
+		ClosePosition: true,
+	}
+	return []AdvancedOrderRequest{entry, stop}
+}
+
+// roundToStep rounds value to a multiple of step with round (strategy.RoundDown or RoundNearest)
+func roundToStep(value, step float64, round func(decimal.Decimal) decimal.Decimal) float64 {
+	return strategy.RoundFloatToStep(value, step, round)
+}
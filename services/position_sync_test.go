@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"futures-options/models"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func TestSyncPositionsKeepsHedgeLegsApart(t *testing.T) {
+	s, mock, repos := newTestService(t)
+	ctx := context.Background()
+	leg := func(side, amount string) *futures.PositionRisk {
+		return &futures.PositionRisk{Symbol: "BTCUSDT", PositionSide: side, PositionAmt: amount, EntryPrice: "40000", MarkPrice: "41000", UnRealizedProfit: "0", Notional: "0", Leverage: "10"}
+	}
+	live := []*futures.PositionRisk{leg("LONG", "1"), leg("SHORT", "-2")}
+	mock.GetFuturesPositionsFunc = func(ctx context.Context) ([]*futures.PositionRisk, error) {
+		return live, nil
+	}
+
+	if _, err := s.SyncPositionsFromBinance(ctx, models.MarketUSDM); err != nil {
+		t.Fatalf("SyncPositionsFromBinance: %v", err)
+	}
+	quantities := func() map[models.PositionSide]float64 {
+		positions, err := repos.Positions.List(ctx, "FUTURES", "")
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		bySide := map[models.PositionSide]float64{}
+		for _, p := range positions {
+			bySide[p.Side] = p.Quantity
+		}
+		if len(bySide) != len(positions) {
+			t.Fatalf("%d positions stored under %d sides", len(positions), len(bySide))
+		}
+		return bySide
+	}
+	if got := quantities(); len(got) != 2 || got["LONG"] != 1 || got["SHORT"] != -2 {
+		t.Fatalf("stored legs = %v, want LONG 1 and SHORT -2", got)
+	}
+
+	// Closing the short leg clears it and leaves the long one
+	live = []*futures.PositionRisk{leg("LONG", "1.5"), leg("SHORT", "0")}
+	summary, err := s.SyncPositionsFromBinance(ctx, models.MarketUSDM)
+	if err != nil {
+		t.Fatalf("SyncPositionsFromBinance: %v", err)
+	}
+	if summary.Cleared != 1 {
+		t.Errorf("cleared = %d, want 1", summary.Cleared)
+	}
+	if got := quantities(); len(got) != 1 || got["LONG"] != 1.5 {
+		t.Errorf("stored legs = %v, want LONG 1.5", got)
+	}
+}
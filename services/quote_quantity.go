@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/strategy"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+)
+
+// defaultQuoteQuantityTolerance applies when QUOTE_QUANTITY_TOLERANCE is not positive
+const defaultQuoteQuantityTolerance = 0.01
+
+// resolveQuoteQuantity sets the quantity of an order given in quote_quantity, reporting errors
+// under prefix. The order is sized at its own price, else its stop or activation price, else the
+// cached price of its working type (mark price by default).
+func (s *TradingService) resolveQuoteQuantity(ctx context.Context, prefix string, req *AdvancedOrderRequest) error {
+	if req.QuoteQuantity <= 0 {
+		return nil
+	}
+	price := req.Price
+	if !price.IsPositive() {
+		price = req.StopPrice
+	}
+	if !price.IsPositive() {
+		price = req.ActivationPrice
+	}
+	source := futures.WorkingTypeMarkPrice
+	if req.WorkingType == string(models.WorkingTypeContractPrice) {
+		source = futures.WorkingTypeContractPrice
+	}
+	quantity, err := s.quoteQuantity(ctx, prefix, req.Symbol, req.OrderType, price, source, req.QuoteQuantity)
+	if err != nil {
+		return err
+	}
+	req.Quantity = quantity
+	return nil
+}
+
+// quoteQuantity converts a notional of quote into a quantity of symbol at price, or at the cached
+// price from source when price is 0. The quantity is rounded to the nearest step (the market lot
+// size for MARKET orders) and must meet the symbol's minimum quantity and notional. Rounding may
+// move the notional at most QUOTE_QUANTITY_TOLERANCE (a fraction, 1% by default) from quote.
+// The sizing is done on decimals, so the quantity is an exact multiple of the step.
+func (s *TradingService) quoteQuantity(ctx context.Context, prefix, symbol, orderType string, price decimal.Decimal, source futures.WorkingType, quote float64) (decimal.Decimal, error) {
+	filters, err := s.api(ctx).GetSymbolFilters(ctx, symbol)
+	if errors.Is(err, binance.ErrUnknownSymbol) {
+		v := &validator{}
+		v.add(prefix+"symbol", RuleEnum, "is not a listed futures symbol")
+		return decimal.Zero, v.err()
+	}
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if !price.IsPositive() {
+		current, err := s.api(ctx).GetPrice(ctx, symbol, source, riskPriceMaxAge)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("failed to get price for quote_quantity: %w", err)
+		}
+		price = decimal.NewFromFloat(current.Value)
+	}
+	if !price.IsPositive() {
+		return decimal.Zero, fmt.Errorf("no price known for %s to size quote_quantity", symbol)
+	}
+
+	step, minQty, maxQty := filters.StepSize, filters.MinQty, filters.MaxQty
+	if orderType == string(models.OrderTypeMarket) && filters.MarketStepSize > 0 {
+		step, minQty, maxQty = filters.MarketStepSize, filters.MarketMinQty, filters.MarketMaxQty
+	}
+	requested := decimal.NewFromFloat(quote)
+	quantity := strategy.RoundToStep(requested.Div(price), decimal.NewFromFloat(step), strategy.RoundNearest)
+	notional := quantity.Mul(price)
+	tolerance := s.api(ctx).EffectiveConfig().QuoteQuantityTolerance
+	if tolerance <= 0 {
+		tolerance = defaultQuoteQuantityTolerance
+	}
+
+	v := &validator{}
+	field := prefix + "quote_quantity"
+	switch {
+	case !quantity.IsPositive() || quantity.LessThan(decimal.NewFromFloat(minQty)):
+		v.add(field, RuleRange, fmt.Sprintf("sizes to %s at %s, below the minimum quantity of %s", quantity, price, formatFloat(minQty)))
+	case maxQty > 0 && quantity.GreaterThan(decimal.NewFromFloat(maxQty)):
+		v.add(field, RuleRange, fmt.Sprintf("sizes to %s at %s, above the maximum quantity of %s", quantity, price, formatFloat(maxQty)))
+	case notional.LessThan(decimal.NewFromFloat(filters.MinNotional)):
+		v.add(field, RuleRange, fmt.Sprintf("rounds to a notional of %s, below the minimum of %s", notional, formatFloat(filters.MinNotional)))
+	case notional.Sub(requested).Abs().Div(requested).GreaterThan(decimal.NewFromFloat(tolerance)):
+		v.add(field, RuleRange, fmt.Sprintf("rounds to %s at %s, a notional of %s, more than %s%% from the requested amount",
+			quantity, price, notional, formatFloat(tolerance*100)))
+	}
+	if err := v.err(); err != nil {
+		return decimal.Zero, err
+	}
+	return quantity, nil
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// reconcileBatchSize bounds how many local orders are loaded per reconciliation pass
+const reconcileBatchSize = 200
+
+// ReconcileSummary reports the outcome of an order reconciliation pass
+type ReconcileSummary struct {
+	Checked   int               `json:"checked"`
+	Changed   int               `json:"changed"`
+	Missing   int               `json:"missing"`
+	Errors    int               `json:"errors"`
+	Changes   []ReconcileChange `json:"changes,omitempty"`
+	StartedAt time.Time         `json:"started_at"`
+	Duration  string            `json:"duration"`
+}
+
+// ReconcileChange describes a single order whose status was corrected
+type ReconcileChange struct {
+	BinanceOrderID int64  `json:"binance_order_id"`
+	Symbol         string `json:"symbol"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+}
+
+// ReconcileFuturesOrders compares non-terminal local orders with Binance and updates drifted
+// statuses. If loading a page of orders fails, the summary of the orders checked so far is
+// returned with the error.
+func (s *TradingService) ReconcileFuturesOrders(ctx context.Context) (*ReconcileSummary, error) {
+	summary := &ReconcileSummary{StartedAt: time.Now()}
+
+	// Orders are loaded a page at a time, so only one batch is held in memory and no database
+	// cursor stays open while Binance is queried. Each page is grouped by symbol so open orders
+	// are fetched once per symbol per batch.
+	var after primitive.ObjectID
+	for {
+		page, err := s.repos.FuturesOrders.ListOpen(ctx, after, reconcileBatchSize)
+		if err != nil {
+			summary.Duration = time.Since(summary.StartedAt).String()
+			return summary, fmt.Errorf("failed to list open orders after checking %d: %w", summary.Checked, err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		batch := map[string][]*models.FuturesOrder{}
+		for _, order := range page {
+			batch[order.Symbol] = append(batch[order.Symbol], order)
+		}
+		s.reconcileBatch(ctx, batch, summary)
+		if len(page) < reconcileBatchSize {
+			break
+		}
+		after = page[len(page)-1].ID
+	}
+
+	summary.Duration = time.Since(summary.StartedAt).String()
+	return summary, nil
+}
+
+// reconcileBatch reconciles a group of local orders keyed by symbol
+func (s *TradingService) reconcileBatch(ctx context.Context, batch map[string][]*models.FuturesOrder, summary *ReconcileSummary) {
+	for symbol, orders := range batch {
+		// USDⓈ-M and COIN-M symbols never overlap, so every order of a symbol has the same market
+		market := orders[0].Market
+		open, err := s.openOrderFills(ctx, market, symbol)
+		if err != nil {
+			slog.Warn("reconcile: failed to list open orders", "symbol", symbol, "market", marketOf(market), "error", err)
+			summary.Checked += len(orders)
+			summary.Errors += len(orders)
+			continue
+		}
+
+		for _, order := range orders {
+			summary.Checked++
+			now := time.Now()
+
+			fill, ok := open[order.BinanceOrderID]
+			if !ok {
+				// No longer open: ask Binance for its final state
+				live, err := s.liveOrderFill(ctx, market, symbol, order.BinanceOrderID)
+				if err != nil {
+					if binance.IsOrderNotFound(err) {
+						s.markOrderMissing(ctx, order, now, summary)
+						continue
+					}
+					slog.Warn("reconcile: failed to fetch order", "symbol", symbol, "binance_order_id", order.BinanceOrderID, "error", err)
+					summary.Errors++
+					continue
+				}
+				fill = live
+			}
+
+			// The user data stream may have recorded a later fill since the order was read;
+			// UpdateFill then leaves it alone and only the reconciliation time is stored
+			changed := fill.changed(order) && !fill.stale(order)
+			if changed {
+				update := fill.update(now)
+				update.ReconciledAt = now
+				updated, err := s.repos.FuturesOrders.UpdateFill(ctx, order.BinanceOrderID, "", update)
+				if err != nil {
+					slog.Warn("reconcile: failed to update order", "symbol", symbol, "binance_order_id", order.BinanceOrderID, "error", err)
+					summary.Errors++
+					continue
+				}
+				changed = updated
+			}
+			if !changed {
+				if _, err := s.repos.FuturesOrders.UpdateByRef(ctx, order.BinanceOrderID, "", &repository.FuturesOrderUpdate{ReconciledAt: now}); err != nil {
+					slog.Warn("reconcile: failed to update order", "symbol", symbol, "binance_order_id", order.BinanceOrderID, "error", err)
+					summary.Errors++
+					continue
+				}
+			}
+			status := fill.Status
+			if changed && status != order.Status {
+				summary.Changed++
+				summary.Changes = append(summary.Changes, ReconcileChange{
+					BinanceOrderID: order.BinanceOrderID,
+					Symbol:         symbol,
+					From:           order.Status,
+					To:             status,
+				})
+			}
+		}
+	}
+}
+
+// markOrderMissing flags a local order whose Binance counterpart no longer exists
+func (s *TradingService) markOrderMissing(ctx context.Context, order *models.FuturesOrder, now time.Time, summary *ReconcileSummary) {
+	missing := true
+	update := &repository.FuturesOrderUpdate{MissingOnExchange: &missing, ReconciledAt: now, UpdatedAt: now}
+	if _, err := s.repos.FuturesOrders.UpdateByRef(ctx, order.BinanceOrderID, "", update); err != nil {
+		slog.Warn("reconcile: failed to flag missing order", "symbol", order.Symbol, "binance_order_id", order.BinanceOrderID, "error", err)
+		summary.Errors++
+		return
+	}
+	summary.Missing++
+}
+
+// StartOrderReconciler registers the order reconciliation job, running ReconcileFuturesOrders and
+// ReconcileSpotOrders every interval
+func (s *TradingService) StartOrderReconciler(ctx context.Context, interval time.Duration) {
+	s.RegisterJob(ctx, Job{Name: JobOrderReconcile, Interval: interval, Run: func(ctx context.Context) error {
+		summary, err := s.ReconcileFuturesOrders(ctx)
+		if err != nil {
+			return err
+		}
+		if summary.Changed > 0 || summary.Missing > 0 || summary.Errors > 0 {
+			slog.Info("order reconciliation finished",
+				"checked", summary.Checked, "changed", summary.Changed, "missing", summary.Missing, "errors", summary.Errors)
+		}
+
+		spotSummary, err := s.ReconcileSpotOrders(ctx)
+		if err != nil {
+			return fmt.Errorf("spot order reconciliation failed: %w", err)
+		}
+		if spotSummary.Changed > 0 || spotSummary.Missing > 0 || spotSummary.Errors > 0 {
+			slog.Info("spot order reconciliation finished",
+				"checked", spotSummary.Checked, "changed", spotSummary.Changed, "missing", spotSummary.Missing, "errors", spotSummary.Errors)
+		}
+		return nil
+	}})
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"futures-options/logging"
+	"futures-options/models"
+	"futures-options/repository"
+)
+
+// PnL report groupings
+const (
+	PnLGroupSymbol    = "symbol"
+	PnLGroupDay       = "day"
+	PnLGroupSymbolDay = "symbol_day"
+	// PnLGroupSource buckets the recorded futures fills by the source of their order; funding
+	// is not attributed to orders and only appears in the totals
+	PnLGroupSource = "source"
+)
+
+const (
+	// defaultPnLReportRange is the report period when no start is given
+	defaultPnLReportRange = 7 * 24 * time.Hour
+	// incomeSyncLookback is how far back the first income sync reaches; Binance keeps about three months
+	incomeSyncLookback = 90 * 24 * time.Hour
+)
+
+// PnLReportQuery selects the report period and grouping
+type PnLReportQuery struct {
+	Start   *time.Time
+	End     *time.Time
+	GroupBy string
+}
+
+// Validate checks the query
+func (q *PnLReportQuery) Validate() error {
+	v := &validator{}
+	v.oneOf("group_by", q.GroupBy, PnLGroupSymbol, PnLGroupDay, PnLGroupSymbolDay, PnLGroupSource)
+	if q.Start != nil && q.End != nil && !q.Start.Before(*q.End) {
+		v.add("start", RuleRange, "must be before end")
+	}
+	return v.err()
+}
+
+// PnLReport is realized PnL, fees and funding per bucket, plus current unrealized PnL
+type PnLReport struct {
+	Start   time.Time           `json:"start"`
+	End     time.Time           `json:"end"`
+	GroupBy string              `json:"group_by"`
+	Buckets []*models.PnLBucket `json:"buckets"`
+	Totals  *models.PnLBucket   `json:"totals"`
+	// SyncError is set when the income history could not be refreshed from Binance;
+	// the report then covers what was synced before
+	SyncError string `json:"sync_error,omitempty"`
+	Paper     bool   `json:"paper,omitempty"`
+}
+
+// GetPnLReport syncs the income history and aggregates it for the requested period. Unrealized
+// PnL is the current value of open positions, so it only appears per symbol and in the totals.
+func (s *TradingService) GetPnLReport(ctx context.Context, query *PnLReportQuery) (*PnLReport, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	groupBy := query.GroupBy
+	if groupBy == "" {
+		groupBy = PnLGroupSymbol
+	}
+	end := time.Now()
+	if query.End != nil {
+		end = *query.End
+	}
+	start := end.Add(-defaultPnLReportRange)
+	if query.Start != nil {
+		start = *query.Start
+	}
+
+	report := &PnLReport{Start: start, End: end, GroupBy: groupBy, Paper: s.Paper()}
+	if err := s.SyncIncome(ctx); err != nil {
+		logging.FromContext(ctx).Warn("income sync failed, reporting stored income", "error", err)
+		report.SyncError = err.Error()
+	}
+
+	if groupBy == PnLGroupSource {
+		return s.pnlReportBySource(ctx, report)
+	}
+	buckets, err := s.repos.Income.AggregatePnL(ctx, &repository.PnLQuery{
+		Start:    start,
+		End:      end,
+		BySymbol: groupBy != PnLGroupDay,
+		ByDay:    groupBy != PnLGroupSymbol,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	unrealized, err := s.unrealizedBySymbol(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if groupBy == PnLGroupSymbol {
+		bySymbol := make(map[string]*models.PnLBucket, len(buckets))
+		for _, b := range buckets {
+			bySymbol[b.Symbol] = b
+		}
+		for symbol, pnl := range unrealized {
+			b, ok := bySymbol[symbol]
+			if !ok {
+				b = &models.PnLBucket{Symbol: symbol}
+				buckets = append(buckets, b)
+			}
+			b.UnrealizedPnl = pnl
+		}
+	}
+
+	totals := &models.PnLBucket{}
+	for _, b := range buckets {
+		finishPnLBucket(b)
+		totals.RealizedPnl += b.RealizedPnl
+		totals.Commission += b.Commission
+		totals.FundingFees += b.FundingFees
+		totals.Trades += b.Trades
+		totals.Wins += b.Wins
+	}
+	for _, pnl := range unrealized {
+		totals.UnrealizedPnl += pnl
+	}
+	finishPnLBucket(totals)
+
+	report.Buckets = buckets
+	report.Totals = totals
+	return report, nil
+}
+
+// pnlReportBySource fills report with the realized PnL and commission of the recorded futures
+// fills per order source. The totals add the period's funding from the income history.
+func (s *TradingService) pnlReportBySource(ctx context.Context, report *PnLReport) (*PnLReport, error) {
+	query := &repository.PnLQuery{Start: report.Start, End: report.End}
+	buckets, err := s.repos.Fills.AggregatePnLBySource(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	income, err := s.repos.Income.AggregatePnL(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	unrealized, err := s.unrealizedBySymbol(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := &models.PnLBucket{}
+	for _, b := range buckets {
+		finishPnLBucket(b)
+		totals.RealizedPnl += b.RealizedPnl
+		totals.Commission += b.Commission
+		totals.Trades += b.Trades
+		totals.Wins += b.Wins
+	}
+	for _, b := range income {
+		totals.FundingFees += b.FundingFees
+	}
+	for _, pnl := range unrealized {
+		totals.UnrealizedPnl += pnl
+	}
+	finishPnLBucket(totals)
+
+	report.Buckets = buckets
+	report.Totals = totals
+	return report, nil
+}
+
+// finishPnLBucket fills in the derived fields
+func finishPnLBucket(b *models.PnLBucket) {
+	b.NetPnl = b.RealizedPnl + b.Commission + b.FundingFees
+	if b.Trades > 0 {
+		b.WinRate = float64(b.Wins) / float64(b.Trades)
+	}
+}
+
+// unrealizedBySymbol returns the current unrealized PnL of open positions, summed per symbol
+func (s *TradingService) unrealizedBySymbol(ctx context.Context) (map[string]float64, error) {
+	positions, err := s.api(ctx).GetFuturesPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get positions for PnL report: %w", err)
+	}
+	unrealized := make(map[string]float64)
+	for _, p := range positions {
+		amount, _ := strconv.ParseFloat(p.PositionAmt, 64)
+		if amount == 0 {
+			continue
+		}
+		pnl, _ := strconv.ParseFloat(p.UnRealizedProfit, 64)
+		unrealized[p.Symbol] += pnl
+	}
+	return unrealized, nil
+}
+
+// SyncIncome stores the income history recorded on Binance since the newest stored entry and
+// accrues new funding payments to the open positions
+func (s *TradingService) SyncIncome(ctx context.Context) error {
+	s.incomeSyncMu.Lock()
+	defer s.incomeSyncMu.Unlock()
+
+	since := time.Now().Add(-incomeSyncLookback)
+	latest, err := s.repos.Income.LatestTime(ctx)
+	switch {
+	case err == nil:
+		// Entries sharing the newest timestamp are fetched again and skipped as duplicates
+		since = latest
+	case !errors.Is(err, repository.ErrNotFound):
+		return fmt.Errorf("failed to read income sync position: %w", err)
+	}
+
+	history, err := s.api(ctx).GetIncomeHistory(ctx, since)
+	if err != nil {
+		return fmt.Errorf("failed to get income history: %w", err)
+	}
+	records := make([]*models.IncomeRecord, 0, len(history))
+	for _, h := range history {
+		income, _ := strconv.ParseFloat(h.Income, 64)
+		records = append(records, &models.IncomeRecord{
+			TranID:     h.TranID,
+			Symbol:     h.Symbol,
+			IncomeType: h.IncomeType,
+			Income:     income,
+			Asset:      h.Asset,
+			Info:       h.Info,
+			TradeID:    h.TradeID,
+			Time:       time.UnixMilli(h.Time).UTC(),
+		})
+	}
+	inserted, err := s.repos.Income.Upsert(ctx, records)
+	if err != nil {
+		return err
+	}
+	s.accrueFunding(ctx, inserted)
+	return nil
+}
+
+// StartIncomeSync registers the income sync job, storing new Binance income every interval so
+// reports do not wait on a long catch-up
+func (s *TradingService) StartIncomeSync(ctx context.Context, interval time.Duration) {
+	s.RegisterJob(ctx, Job{Name: JobIncomeSync, Interval: interval, Run: s.SyncIncome})
+}
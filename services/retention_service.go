@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"futures-options/database"
+)
+
+// JobOrderArchive moves old terminal futures orders to the archive collection
+const JobOrderArchive = "order-archive"
+
+// orderArchiveBatch is how many orders one archive transaction moves
+const orderArchiveBatch = 500
+
+// RetentionPolicy is how long stored orders and events are kept; 0 keeps them indefinitely
+type RetentionPolicy struct {
+	OrderDays            int           `json:"order_retention_days"`      // terminal orders older than this are archived
+	AuditDays            int           `json:"audit_retention_days"`      // audit entries expire through a TTL index
+	RiskEventDays        int           `json:"risk_event_retention_days"` // user data stream risk events expire through a TTL index
+	OrderArchiveInterval time.Duration `json:"-"`
+}
+
+// RetentionReport is the retention policy with the size of the collections it covers
+type RetentionReport struct {
+	Policy               RetentionPolicy             `json:"policy"`
+	OrderArchiveInterval string                      `json:"order_archive_interval"`
+	Collections          []*database.CollectionStats `json:"collections,omitempty"`
+	Error                string                      `json:"error,omitempty"` // why collection sizes are missing
+}
+
+// SetRetentionPolicy sets the retention policy reported by GetRetention and applied by the
+// order archive job
+func (s *TradingService) SetRetentionPolicy(policy RetentionPolicy) {
+	s.retention = policy
+}
+
+// GetRetention returns the retention policy and the current size of the collections it covers
+func (s *TradingService) GetRetention(ctx context.Context) *RetentionReport {
+	report := &RetentionReport{Policy: s.retention, OrderArchiveInterval: s.retention.OrderArchiveInterval.String()}
+	stats, err := database.RetentionStats(ctx)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	report.Collections = stats
+	return report
+}
+
+// StartOrderArchive registers the order archive job when order retention is configured. Every
+// interval it moves filled, canceled, expired and rejected orders not updated for the retention
+// window to the archive collection; open orders are never archived.
+func (s *TradingService) StartOrderArchive(ctx context.Context) {
+	if s.retention.OrderDays <= 0 {
+		return
+	}
+	s.RegisterJob(ctx, Job{Name: JobOrderArchive, Interval: s.retention.OrderArchiveInterval, Run: s.ArchiveOrders})
+}
+
+// ArchiveOrders moves terminal orders older than the order retention window to the archive
+// collection in batches, each batch in one transaction where supported
+func (s *TradingService) ArchiveOrders(ctx context.Context) error {
+	if s.retention.OrderDays <= 0 {
+		return nil
+	}
+	before := time.Now().AddDate(0, 0, -s.retention.OrderDays)
+	total := 0
+	for ctx.Err() == nil {
+		var moved int
+		err := s.repos.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			moved, err = s.repos.FuturesOrders.ArchiveTerminal(ctx, before, orderArchiveBatch)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to archive orders after %d: %w", total, err)
+		}
+		total += moved
+		if moved < orderArchiveBatch {
+			break
+		}
+	}
+	if total > 0 {
+		slog.Info("archived orders", "count", total, "before", before)
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,370 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"futures-options/logging"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// riskPriceMaxAge is the oldest mark price a notional check accepts: one tick of the mark price
+// stream plus some slack
+const riskPriceMaxAge = 1500 * time.Millisecond
+
+// Risk limit names reported in RiskLimitError.Limit
+const (
+	RiskLimitMaxNotional = "max_notional"
+	RiskLimitMaxQuantity = "max_quantity"
+)
+
+var (
+	// ErrRiskLimitNotFound is returned when a symbol has no stored limit
+	ErrRiskLimitNotFound = errors.New("risk limit not found")
+	// ErrRiskOverrideForbidden is returned when a caller not allowed to bypass risk limits asks to
+	ErrRiskOverrideForbidden = errors.New("this API token may not override risk limits")
+	// ErrMarkPriceUnavailable is returned when a notional limit applies but no price is known
+	ErrMarkPriceUnavailable = errors.New("mark price unavailable for risk check")
+)
+
+// RiskLimitError reports an order rejected because it would breach a position limit
+type RiskLimitError struct {
+	Symbol      string  `json:"symbol"`
+	Limit       string  `json:"limit"`        // max_notional or max_quantity
+	LimitSymbol string  `json:"limit_symbol"` // the symbol, or DEFAULT when the global default applied
+	Max         float64 `json:"max"`
+	Projected   float64 `json:"projected"`
+}
+
+func (e *RiskLimitError) Error() string {
+	return fmt.Sprintf("order would take %s %s to %s, above the %s limit of %s",
+		e.Symbol, e.Limit, formatFloat(e.Projected), e.LimitSymbol, formatFloat(e.Max))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// RiskLimitRequest sets a symbol's limits; a zero value leaves that dimension uncapped
+type RiskLimitRequest struct {
+	MaxNotional        float64 `json:"max_notional,omitempty"`
+	MaxQuantity        float64 `json:"max_quantity,omitempty"`
+	MaxOrdersPerMinute int     `json:"max_orders_per_minute,omitempty"`
+	MaxOrdersPer10s    int     `json:"max_orders_per_10s,omitempty"`
+}
+
+// Validate checks the request fields
+func (r *RiskLimitRequest) Validate() error {
+	v := &validator{}
+	v.nonNegative("max_notional", r.MaxNotional)
+	v.nonNegative("max_quantity", r.MaxQuantity)
+	v.nonNegative("max_orders_per_minute", float64(r.MaxOrdersPerMinute))
+	v.nonNegative("max_orders_per_10s", float64(r.MaxOrdersPer10s))
+	if r.MaxNotional == 0 && r.MaxQuantity == 0 && r.MaxOrdersPerMinute == 0 && r.MaxOrdersPer10s == 0 {
+		v.add("max_notional", RuleRequired, "or max_quantity, max_orders_per_minute or max_orders_per_10s must be set")
+	}
+	return v.err()
+}
+
+// SetRiskOverridePrincipals sets the principals (token labels) allowed to bypass risk limits
+func (s *TradingService) SetRiskOverridePrincipals(principals []string) {
+	s.riskOverridePrincipals = principals
+}
+
+// ListRiskLimits returns every stored limit, including the DEFAULT one
+func (s *TradingService) ListRiskLimits(ctx context.Context) ([]*models.RiskLimit, error) {
+	limits, err := s.repos.RiskLimits.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list risk limits: %w", err)
+	}
+	return limits, nil
+}
+
+// GetRiskLimit returns the limit stored for symbol (DEFAULT for the global default)
+func (s *TradingService) GetRiskLimit(ctx context.Context, symbol string) (*models.RiskLimit, error) {
+	limit, err := s.repos.RiskLimits.FindBySymbol(ctx, normalizeRiskSymbol(symbol))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrRiskLimitNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get risk limit: %w", err)
+	}
+	return limit, nil
+}
+
+// SetRiskLimit creates or replaces the limit for symbol. Changes apply to the next order.
+func (s *TradingService) SetRiskLimit(ctx context.Context, symbol string, req *RiskLimitRequest) (*models.RiskLimit, error) {
+	symbol = normalizeRiskSymbol(symbol)
+	if symbol == models.RiskLimitGlobalSymbol && (req.MaxNotional != 0 || req.MaxQuantity != 0) {
+		// Positions are per symbol, so GLOBAL only caps the order rate
+		v := &validator{}
+		v.add("max_notional", RuleRange, "and max_quantity cannot be set for GLOBAL")
+		return nil, v.err()
+	}
+
+	now := time.Now()
+	limit := &models.RiskLimit{
+		Symbol:             symbol,
+		MaxNotional:        req.MaxNotional,
+		MaxQuantity:        req.MaxQuantity,
+		MaxOrdersPerMinute: req.MaxOrdersPerMinute,
+		MaxOrdersPer10s:    req.MaxOrdersPer10s,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	if err := s.repos.RiskLimits.Upsert(ctx, limit); err != nil {
+		return nil, fmt.Errorf("failed to save risk limit: %w", err)
+	}
+	return limit, nil
+}
+
+// DeleteRiskLimit removes the limit for symbol
+func (s *TradingService) DeleteRiskLimit(ctx context.Context, symbol string) error {
+	deleted, err := s.repos.RiskLimits.Delete(ctx, normalizeRiskSymbol(symbol))
+	if err != nil {
+		return fmt.Errorf("failed to delete risk limit: %w", err)
+	}
+	if !deleted {
+		return ErrRiskLimitNotFound
+	}
+	return nil
+}
+
+func normalizeRiskSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+// riskOrder is the part of an order that the limit check looks at
+type riskOrder struct {
+	Market       models.Market // empty means usdm
+	Symbol       string
+	Side         string
+	PositionSide string // LONG or SHORT in hedge mode; empty or BOTH in one-way mode
+	Quantity     float64
+	Price        float64
+	ReduceOnly   bool // reduce-only and close-position orders never add exposure
+}
+
+// reduces reports whether o can only shrink a position: a reduce-only or close-position order, or
+// in hedge mode, where Binance rejects reduceOnly, a SELL of the LONG leg or a BUY of the SHORT leg
+func (o riskOrder) reduces() bool {
+	switch models.PositionSide(o.PositionSide) {
+	case models.PositionSideLong:
+		return o.ReduceOnly || o.Side == string(models.OrderSideSell)
+	case models.PositionSideShort:
+		return o.ReduceOnly || o.Side == string(models.OrderSideBuy)
+	}
+	return o.ReduceOnly
+}
+
+// exposureKey identifies a position on Binance: one per symbol in one-way mode (side BOTH), one per
+// leg in hedge mode
+type exposureKey struct {
+	symbol string
+	side   models.PositionSide
+}
+
+// symbolExposure is a position's current size and its symbol's mark price on Binance. SHORT legs,
+// and one-way short positions, have a negative amount.
+type symbolExposure struct {
+	amount    float64 // contracts on COIN-M
+	markPrice float64
+}
+
+// checkRiskLimits rejects orders while the daily loss lock is engaged, orders that would take
+// a symbol's net position above its max quantity or max notional (at mark price), and orders
+// beyond the order rate limits. override skips the position limits (not the daily loss lock or
+// order rate) for principals configured in RISK_OVERRIDE_PRINCIPALS.
+func (s *TradingService) checkRiskLimits(ctx context.Context, orders []riskOrder, override bool) error {
+	if err := s.checkDailyLoss(ctx, orders); err != nil {
+		return err
+	}
+
+	if override {
+		principal := PrincipalFromContext(ctx)
+		if !s.canOverrideRiskLimits(principal) {
+			return ErrRiskOverrideForbidden
+		}
+		logging.FromContext(ctx).Warn("risk limits overridden", "principal", principal)
+	}
+
+	limits, err := s.repos.RiskLimits.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load risk limits: %w", err)
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+	bySymbol := make(map[string]*models.RiskLimit, len(limits))
+	for _, l := range limits {
+		bySymbol[l.Symbol] = l
+	}
+
+	if !override {
+		if err := s.checkPositionLimits(ctx, orders, bySymbol); err != nil {
+			return err
+		}
+	}
+
+	// Counted last so orders rejected by other checks don't use up the rate
+	return s.throttleOrders(ctx, orders, bySymbol)
+}
+
+// previewRiskLimits runs the daily loss and position limit checks of checkRiskLimits without
+// counting the orders against the order rate limits
+func (s *TradingService) previewRiskLimits(ctx context.Context, orders []riskOrder) error {
+	if err := s.checkDailyLoss(ctx, orders); err != nil {
+		return err
+	}
+	limits, err := s.repos.RiskLimits.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load risk limits: %w", err)
+	}
+	bySymbol := make(map[string]*models.RiskLimit, len(limits))
+	for _, l := range limits {
+		bySymbol[l.Symbol] = l
+	}
+	return s.checkPositionLimits(ctx, orders, bySymbol)
+}
+
+// checkPositionLimits applies orders in sequence so a batch is checked as a whole; orders that
+// shrink the position are always allowed. Hedge-mode legs are limited separately, each on its own
+// size. COIN-M quantities are in contracts and their notional is the USD face value (contracts *
+// contract size), so limits read the same on both markets.
+func (s *TradingService) checkPositionLimits(ctx context.Context, orders []riskOrder, bySymbol map[string]*models.RiskLimit) error {
+	exposures := make(map[models.Market]map[exposureKey]symbolExposure)
+	projected := make(map[exposureKey]float64)
+	for _, o := range orders {
+		if o.reduces() {
+			continue
+		}
+		limit, ok := bySymbol[o.Symbol]
+		if !ok {
+			if limit, ok = bySymbol[models.RiskLimitDefaultSymbol]; !ok {
+				continue
+			}
+		}
+		if limit.MaxQuantity == 0 && limit.MaxNotional == 0 {
+			continue
+		}
+
+		market := marketOf(o.Market)
+		if _, ok := exposures[market]; !ok {
+			loaded, err := s.loadExposures(ctx, market)
+			if err != nil {
+				return err
+			}
+			exposures[market] = loaded
+		}
+		key := exposureKey{symbol: o.Symbol, side: positionSideOf(o.PositionSide)}
+		exposure := exposures[market][key]
+
+		current, ok := projected[key]
+		if !ok {
+			current = exposure.amount
+		}
+		next := current + o.Quantity
+		if o.Side == string(models.OrderSideSell) {
+			next = current - o.Quantity
+		}
+		projected[key] = next
+		if math.Abs(next) <= math.Abs(current) {
+			continue
+		}
+
+		if limit.MaxQuantity > 0 && math.Abs(next) > limit.MaxQuantity {
+			return &RiskLimitError{Symbol: o.Symbol, Limit: RiskLimitMaxQuantity, LimitSymbol: limit.Symbol, Max: limit.MaxQuantity, Projected: math.Abs(next)}
+		}
+		if limit.MaxNotional > 0 && market == models.MarketCoinM {
+			contractSize, err := s.api(ctx).GetContractSize(ctx, o.Symbol)
+			if err != nil {
+				return fmt.Errorf("failed to get contract size for risk check: %w", err)
+			}
+			if notional := math.Abs(next) * contractSize; notional > limit.MaxNotional {
+				return &RiskLimitError{Symbol: o.Symbol, Limit: RiskLimitMaxNotional, LimitSymbol: limit.Symbol, Max: limit.MaxNotional, Projected: notional}
+			}
+		} else if limit.MaxNotional > 0 {
+			price := exposure.markPrice
+			if price <= 0 {
+				if mark, err := s.api(ctx).GetPrice(ctx, o.Symbol, futures.WorkingTypeMarkPrice, riskPriceMaxAge); err == nil {
+					price = mark.Value
+				} else {
+					price = o.Price
+				}
+			}
+			if price <= 0 {
+				return fmt.Errorf("%w: %s", ErrMarkPriceUnavailable, o.Symbol)
+			}
+			if notional := math.Abs(next) * price; notional > limit.MaxNotional {
+				return &RiskLimitError{Symbol: o.Symbol, Limit: RiskLimitMaxNotional, LimitSymbol: limit.Symbol, Max: limit.MaxNotional, Projected: notional}
+			}
+		}
+	}
+	return nil
+}
+
+// loadExposures fetches the size of every position of market, per hedge-mode leg, and its mark
+// price from Binance for the request's account. The positions are read live rather than from the
+// positions collection: that is only written by the position sync (POSITION_SYNC_INTERVAL, 5
+// minutes by default), so a position opened since would not count against the limits. The call is
+// made once per market per check, however many orders a batch holds.
+func (s *TradingService) loadExposures(ctx context.Context, market models.Market) (map[exposureKey]symbolExposure, error) {
+	if market == models.MarketCoinM {
+		return s.loadCoinMExposures(ctx)
+	}
+	positions, err := s.api(ctx).GetFuturesPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load positions for risk check: %w", err)
+	}
+	exposures := make(map[exposureKey]symbolExposure, len(positions))
+	for _, p := range positions {
+		amount, _ := strconv.ParseFloat(p.PositionAmt, 64)
+		markPrice, _ := strconv.ParseFloat(p.MarkPrice, 64)
+		addExposure(exposures, exposureKey{symbol: p.Symbol, side: positionSideOf(p.PositionSide)}, amount, markPrice)
+	}
+	return exposures, nil
+}
+
+func (s *TradingService) loadCoinMExposures(ctx context.Context) (map[exposureKey]symbolExposure, error) {
+	positions, err := s.api(ctx).GetDeliveryPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load coin-m positions for risk check: %w", err)
+	}
+	exposures := make(map[exposureKey]symbolExposure, len(positions))
+	for _, p := range positions {
+		amount, _ := strconv.ParseFloat(p.PositionAmt, 64)
+		markPrice, _ := strconv.ParseFloat(p.MarkPrice, 64)
+		addExposure(exposures, exposureKey{symbol: p.Symbol, side: positionSideOf(p.PositionSide)}, amount, markPrice)
+	}
+	return exposures, nil
+}
+
+// addExposure adds a position reported by Binance to its entry in exposures
+func addExposure(exposures map[exposureKey]symbolExposure, key exposureKey, amount, markPrice float64) {
+	e := exposures[key]
+	e.amount += amount
+	if markPrice > 0 {
+		e.markPrice = markPrice
+	}
+	exposures[key] = e
+}
+
+func (s *TradingService) canOverrideRiskLimits(principal string) bool {
+	if principal == "" || principal == AnonymousPrincipal {
+		return false
+	}
+	for _, p := range s.riskOverridePrincipals {
+		if p == principal {
+			return true
+		}
+	}
+	return false
+}
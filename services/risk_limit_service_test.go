@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"futures-options/models"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func TestCheckPositionLimitsPerHedgeLeg(t *testing.T) {
+	limits := map[string]*models.RiskLimit{"BTCUSDT": {Symbol: "BTCUSDT", MaxQuantity: 12}}
+	hedged := []*futures.PositionRisk{
+		{Symbol: "BTCUSDT", PositionSide: "LONG", PositionAmt: "10", MarkPrice: "50000"},
+		{Symbol: "BTCUSDT", PositionSide: "SHORT", PositionAmt: "-10", MarkPrice: "50000"},
+	}
+	oneWay := []*futures.PositionRisk{{Symbol: "BTCUSDT", PositionSide: "BOTH", PositionAmt: "10", MarkPrice: "50000"}}
+
+	tests := []struct {
+		name      string
+		positions []*futures.PositionRisk
+		orders    []riskOrder
+		wantErr   bool
+	}{
+		// Netted, the legs would be flat and 5 would fit; the long leg ends at 15
+		{"adding to the long leg", hedged, []riskOrder{{Symbol: "BTCUSDT", Side: "BUY", PositionSide: "LONG", Quantity: 5}}, true},
+		{"adding to the short leg", hedged, []riskOrder{{Symbol: "BTCUSDT", Side: "SELL", PositionSide: "SHORT", Quantity: 5}}, true},
+		{"within the limit on a leg", hedged, []riskOrder{{Symbol: "BTCUSDT", Side: "BUY", PositionSide: "LONG", Quantity: 2}}, false},
+		// Closing orders carry no reduceOnly in hedge mode, and still never add exposure
+		{"closing the short leg", hedged, []riskOrder{{Symbol: "BTCUSDT", Side: "BUY", PositionSide: "SHORT", Quantity: 30}}, false},
+		{"closing the long leg", hedged, []riskOrder{{Symbol: "BTCUSDT", Side: "SELL", PositionSide: "LONG", Quantity: 30}}, false},
+		{"batch on one leg", hedged, []riskOrder{
+			{Symbol: "BTCUSDT", Side: "BUY", PositionSide: "LONG", Quantity: 2},
+			{Symbol: "BTCUSDT", Side: "BUY", PositionSide: "LONG", Quantity: 1},
+		}, true},
+		{"one-way position", oneWay, []riskOrder{{Symbol: "BTCUSDT", Side: "BUY", Quantity: 5}}, true},
+		{"one-way reduce", oneWay, []riskOrder{{Symbol: "BTCUSDT", Side: "SELL", Quantity: 5}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock, _ := newTestService(t)
+			mock.GetFuturesPositionsFunc = func(ctx context.Context) ([]*futures.PositionRisk, error) {
+				return tt.positions, nil
+			}
+			err := s.checkPositionLimits(context.Background(), tt.orders, limits)
+			var riskErr *RiskLimitError
+			if got := errors.As(err, &riskErr); got != tt.wantErr {
+				t.Errorf("err = %v, want a RiskLimitError %v", err, tt.wantErr)
+			}
+			if err != nil && riskErr == nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
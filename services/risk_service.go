@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"futures-options/models"
+	"futures-options/notifications"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// HandleMarginCall records a MARGIN_CALL event, flags the affected positions and alerts the notifiers
+func (s *TradingService) HandleMarginCall(ctx context.Context, event *futures.WsUserDataEvent) error {
+	crossWalletBalance, _ := strconv.ParseFloat(event.CrossWalletBalance, 64)
+	eventTime := time.UnixMilli(event.Time)
+
+	for _, p := range event.MarginCallPositions {
+		amount, _ := strconv.ParseFloat(p.Amount, 64)
+		markPrice, _ := strconv.ParseFloat(p.MarkPrice, 64)
+		unrealizedPnl, _ := strconv.ParseFloat(p.UnrealizedPnL, 64)
+		maintenanceMargin, _ := strconv.ParseFloat(p.MaintenanceMarginRequired, 64)
+		isolatedWallet, _ := strconv.ParseFloat(p.IsolatedWallet, 64)
+
+		riskEvent := &models.RiskEvent{
+			Type:               models.RiskEventMarginCall,
+			Symbol:             p.Symbol,
+			PositionSide:       models.PositionSide(p.Side),
+			PositionAmount:     amount,
+			MarginType:         string(p.MarginType),
+			MarkPrice:          markPrice,
+			UnrealizedPnl:      unrealizedPnl,
+			MaintenanceMargin:  maintenanceMargin,
+			IsolatedWallet:     isolatedWallet,
+			CrossWalletBalance: crossWalletBalance,
+			EventTime:          eventTime,
+			CreatedAt:          time.Now(),
+		}
+
+		if err := s.repos.RiskEvents.Insert(ctx, riskEvent); err != nil {
+			return fmt.Errorf("failed to save risk event: %w", err)
+		}
+
+		// Flag the local position so clients can see it is close to liquidation
+		if err := s.repos.Positions.FlagMarginCall(ctx, p.Symbol, positionSideOf(string(p.Side)), s.ActiveAccount(), markPrice, time.Now()); err != nil {
+			return fmt.Errorf("failed to flag position at risk: %w", err)
+		}
+
+		s.notify(ctx, &notifications.Notification{
+			Title:     "Margin call on " + p.Symbol,
+			Message:   fmt.Sprintf("Position %s %s is close to liquidation at mark price %s", p.Symbol, p.Side, p.MarkPrice),
+			Priority:  notifications.PriorityHigh,
+			EventType: string(models.RiskEventMarginCall),
+			Fields: map[string]string{
+				"symbol":               p.Symbol,
+				"position_side":        string(p.Side),
+				"position_amount":      p.Amount,
+				"mark_price":           p.MarkPrice,
+				"maintenance_margin":   p.MaintenanceMarginRequired,
+				"cross_wallet_balance": event.CrossWalletBalance,
+				"unrealized_pnl":       p.UnrealizedPnL,
+			},
+		})
+	}
+
+	return nil
+}
+
+// GetRiskEvents retrieves recorded risk events, newest first
+func (s *TradingService) GetRiskEvents(ctx context.Context, symbol string, limit int64) ([]*models.RiskEvent, error) {
+	return s.repos.RiskEvents.List(ctx, symbol, limit)
+}
@@ -0,0 +1,392 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/logging"
+	"futures-options/models"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Inputs of the risk summary, reported with how fresh each one is
+const (
+	RiskInputStoredPositions = "stored_positions" // positions collection, as of the last position sync
+	RiskInputMarkPrices      = "mark_prices"      // USDⓈ-M mark price cache
+	RiskInputPositionRisk    = "position_risk"    // Binance position risk: liquidation prices and margin ratios
+	RiskInputAccount         = "account"          // Binance futures account: margin used and available
+	RiskInputOptions         = "options"          // Binance options positions with mark Greeks
+)
+
+// quoteAssets are stripped from USDⓈ-M and options symbols to find the underlying
+var quoteAssets = []string{"USDT", "USDC", "FDUSD", "BUSD", "USD"}
+
+// RiskSummary combines futures and options exposure. Each input is listed with the time it was
+// read; stored positions are as fresh as the last position sync.
+type RiskSummary struct {
+	GeneratedAt   time.Time             `json:"generated_at"`
+	Inputs        []*RiskInput          `json:"inputs"`
+	Underlyings   []*UnderlyingExposure `json:"underlyings"`
+	Positions     []*PositionExposure   `json:"positions"`
+	TotalNotional float64               `json:"total_notional"` // USD, sum of absolute position notionals
+	Margin        *MarginUsage          `json:"margin,omitempty"`
+	// LargestPosition is the position with the largest share of TotalNotional
+	LargestPosition *Concentration `json:"largest_position,omitempty"`
+	Errors          []string       `json:"errors,omitempty"` // inputs that could not be read
+}
+
+// RiskInput is one source of the risk summary and when it was read. For stored positions and
+// cached mark prices AsOf is the oldest entry used.
+type RiskInput struct {
+	Name  string     `json:"name"`
+	AsOf  *time.Time `json:"as_of,omitempty"`
+	Count int        `json:"count"`
+	Error string     `json:"error,omitempty"`
+}
+
+// UnderlyingExposure is the net delta of an underlying across futures and options, in units of
+// the underlying
+type UnderlyingExposure struct {
+	Underlying       string  `json:"underlying"`
+	FuturesDelta     float64 `json:"futures_delta"`
+	OptionsDelta     float64 `json:"options_delta"`
+	NetDelta         float64 `json:"net_delta"`
+	Price            float64 `json:"price,omitempty"`              // latest mark of the underlying's futures, when held
+	NetDeltaNotional float64 `json:"net_delta_notional,omitempty"` // net delta at Price, USD
+}
+
+// PositionExposure is one futures or options position's exposure and distance to liquidation
+type PositionExposure struct {
+	Symbol     string        `json:"symbol"`
+	Type       string        `json:"type"` // FUTURES or OPTIONS
+	Market     models.Market `json:"market,omitempty"`
+	Side       string        `json:"side,omitempty"`
+	Underlying string        `json:"underlying"`
+	Quantity   float64       `json:"quantity"` // signed; contracts for COIN-M and options
+	Delta      float64       `json:"delta"`    // in units of the underlying
+	// Notional is the absolute USD value: size at the mark for futures, premium at the mark for options
+	Notional  float64    `json:"notional"`
+	MarkPrice float64    `json:"mark_price,omitempty"`
+	MarkTime  *time.Time `json:"mark_time,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"` // when the position was read
+	// LiquidationPrice and LiquidationDistancePct (mark to liquidation, as a percentage of the mark)
+	// are USDⓈ-M only
+	LiquidationPrice       float64  `json:"liquidation_price,omitempty"`
+	LiquidationDistancePct *float64 `json:"liquidation_distance_pct,omitempty"`
+	// MarginRatio is maintenance margin over margin balance (1 means liquidation); AtRiskDistance
+	// is how far it is below MARGIN_RATIO_WARNING, negative once past it
+	MarginRatio    *float64 `json:"margin_ratio,omitempty"`
+	AtRiskDistance *float64 `json:"at_risk_distance,omitempty"`
+	AtRisk         bool     `json:"at_risk,omitempty"` // flagged by a margin call or the margin ratio warning
+}
+
+// MarginUsage is the USDⓈ-M futures account's margin
+type MarginUsage struct {
+	AsOf              time.Time `json:"as_of"`
+	MarginBalance     float64   `json:"margin_balance"`
+	InitialMargin     float64   `json:"initial_margin"` // used by positions and open orders
+	MaintenanceMargin float64   `json:"maintenance_margin"`
+	Available         float64   `json:"available"`
+	UsedPct           float64   `json:"used_pct"` // initial margin over margin balance
+}
+
+// Concentration is a position's share of the total notional
+type Concentration struct {
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side,omitempty"`
+	Notional float64 `json:"notional"`
+	SharePct float64 `json:"share_pct"`
+}
+
+// GetRiskSummary combines the stored futures positions, cached mark prices, Binance position risk
+// and account, and options positions with their mark Greeks into one exposure view. Inputs that
+// cannot be read are reported in Errors and left out; the summary fails only when none can.
+func (s *TradingService) GetRiskSummary(ctx context.Context) (*RiskSummary, error) {
+	summary := &RiskSummary{GeneratedAt: time.Now(), Underlyings: []*UnderlyingExposure{}, Positions: []*PositionExposure{}}
+
+	if err := s.addStoredPositions(ctx, summary); err != nil {
+		summary.addError(ctx, RiskInputStoredPositions, err)
+	}
+	s.addPositionRisk(ctx, summary)
+	if err := s.addOptionsExposure(ctx, summary); err != nil {
+		summary.addError(ctx, RiskInputOptions, err)
+	}
+	if len(summary.Errors) == len(summary.Inputs) {
+		return nil, fmt.Errorf("failed to read any risk input: %s", strings.Join(summary.Errors, "; "))
+	}
+
+	summary.aggregate()
+	return summary, nil
+}
+
+// addStoredPositions adds the stored futures positions, valuing USDⓈ-M positions at the cached mark
+func (s *TradingService) addStoredPositions(ctx context.Context, summary *RiskSummary) error {
+	input := &RiskInput{Name: RiskInputStoredPositions}
+	summary.Inputs = append(summary.Inputs, input)
+	marks := &RiskInput{Name: RiskInputMarkPrices}
+	summary.Inputs = append(summary.Inputs, marks)
+
+	var stored []*models.Position
+	err := s.repos.Positions.Each(ctx, "FUTURES", AccountFromContext(ctx), func(p *models.Position) error {
+		if p.Quantity != 0 {
+			stored = append(stored, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range stored {
+		input.Count++
+		input.AsOf = oldest(input.AsOf, p.UpdatedAt)
+
+		exposure := &PositionExposure{
+			Symbol:     p.Symbol,
+			Type:       "FUTURES",
+			Market:     marketOf(p.Market),
+			Side:       string(p.Side),
+			Underlying: underlyingOf(p.Symbol),
+			Quantity:   p.Quantity,
+			MarkPrice:  p.CurrentPrice,
+			UpdatedAt:  p.UpdatedAt,
+			AtRisk:     p.AtRisk,
+		}
+		markTime := p.UpdatedAt
+		if exposure.Market == models.MarketCoinM {
+			// COIN-M contracts are worth a fixed USD amount; the delta is that amount in coin
+			exposure.Notional = math.Abs(p.Quantity) * p.ContractSize
+			if p.CurrentPrice > 0 {
+				exposure.Delta = p.Quantity * p.ContractSize / p.CurrentPrice
+			}
+		} else {
+			if price, err := s.api(ctx).GetPrice(ctx, p.Symbol, futures.WorkingTypeMarkPrice, 0); err == nil {
+				exposure.MarkPrice = price.Value
+				markTime = price.Time
+				marks.Count++
+				marks.AsOf = oldest(marks.AsOf, price.Time)
+			} else {
+				summary.addError(ctx, RiskInputMarkPrices, fmt.Errorf("%s: %w (using the stored price)", p.Symbol, err))
+			}
+			exposure.Delta = p.Quantity
+			exposure.Notional = math.Abs(p.Quantity) * exposure.MarkPrice
+		}
+		exposure.MarkTime = &markTime
+		summary.Positions = append(summary.Positions, exposure)
+	}
+	return nil
+}
+
+// addPositionRisk adds the liquidation price and margin ratio of each USDⓈ-M position, and the
+// account's margin usage
+func (s *TradingService) addPositionRisk(ctx context.Context, summary *RiskSummary) {
+	riskInput := &RiskInput{Name: RiskInputPositionRisk}
+	accountInput := &RiskInput{Name: RiskInputAccount}
+	summary.Inputs = append(summary.Inputs, riskInput, accountInput)
+
+	risks, err := s.api(ctx).GetFuturesPositions(ctx)
+	if err != nil {
+		summary.addError(ctx, RiskInputPositionRisk, err)
+	} else {
+		now := time.Now()
+		riskInput.AsOf = &now
+	}
+	account, err := s.api(ctx).GetFuturesAccount(ctx)
+	if err != nil {
+		summary.addError(ctx, RiskInputAccount, err)
+	} else {
+		now := time.Now()
+		accountInput.AsOf = &now
+		accountInput.Count = 1
+		summary.Margin = marginUsage(account, now)
+	}
+	if risks == nil {
+		return
+	}
+
+	var ratios map[string]float64
+	if account != nil {
+		if ratios, err = s.marginRatiosOf(ctx, risks, account); err != nil {
+			summary.addError(ctx, RiskInputPositionRisk, fmt.Errorf("margin ratios: %w", err))
+		}
+	}
+	threshold := s.marginRatioWarning
+	if threshold <= 0 {
+		threshold = defaultMarginRatioWarning
+	}
+
+	live := make(map[string]*LivePosition)
+	for _, p := range risks {
+		position, err := livePosition(p)
+		if err != nil || position.Quantity == 0 {
+			continue
+		}
+		live[p.Symbol+"/"+position.PositionSide] = position
+		riskInput.Count++
+	}
+	for _, exposure := range summary.Positions {
+		if exposure.Type != "FUTURES" || exposure.Market != models.MarketUSDM {
+			continue
+		}
+		key := exposure.Symbol + "/" + exposure.Side
+		if position, ok := live[key]; ok && position.LiquidationPrice > 0 && position.MarkPrice > 0 {
+			exposure.LiquidationPrice = position.LiquidationPrice
+			distance := math.Abs(position.MarkPrice-position.LiquidationPrice) / position.MarkPrice * 100
+			exposure.LiquidationDistancePct = &distance
+		}
+		if ratio, ok := ratios[key]; ok {
+			distance := threshold - ratio
+			exposure.MarginRatio = &ratio
+			exposure.AtRiskDistance = &distance
+			exposure.AtRisk = exposure.AtRisk || ratio >= threshold
+		}
+	}
+}
+
+// addOptionsExposure adds the options positions with their delta from the options mark endpoint
+func (s *TradingService) addOptionsExposure(ctx context.Context, summary *RiskSummary) error {
+	input := &RiskInput{Name: RiskInputOptions}
+	summary.Inputs = append(summary.Inputs, input)
+	if s.Paper() {
+		return fmt.Errorf("options trading is %w", ErrPaperUnsupported)
+	}
+
+	optionsClient := s.api(ctx).Options()
+	positions, err := optionsClient.GetOptionsPositions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get options positions: %w", err)
+	}
+	now := time.Now()
+	input.AsOf = &now
+	if len(positions) == 0 {
+		return nil
+	}
+	marks, err := optionsClient.GetMarks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get options marks: %w", err)
+	}
+	bySymbol := make(map[string]*binance.OptionsMark, len(marks))
+	for _, m := range marks {
+		bySymbol[m.Symbol] = m
+	}
+
+	for _, p := range positions {
+		if p.Position == 0 {
+			continue
+		}
+		input.Count++
+		exposure := &PositionExposure{
+			Symbol:     p.Symbol,
+			Type:       "OPTIONS",
+			Underlying: underlyingOf(p.Symbol),
+			Quantity:   p.Position,
+			MarkPrice:  p.MarkPrice,
+			MarkTime:   &now,
+			UpdatedAt:  now,
+		}
+		if mark, ok := bySymbol[p.Symbol]; ok {
+			exposure.MarkPrice = mark.MarkPrice
+			exposure.Delta = p.Position * mark.Delta
+		} else {
+			summary.addError(ctx, RiskInputOptions, fmt.Errorf("%s: no mark Greeks, delta left at 0", p.Symbol))
+		}
+		exposure.Notional = math.Abs(p.Position) * exposure.MarkPrice
+		summary.Positions = append(summary.Positions, exposure)
+	}
+	return nil
+}
+
+// aggregate sums the positions into the per-underlying deltas, total notional and concentration
+func (r *RiskSummary) aggregate() {
+	underlyings := make(map[string]*UnderlyingExposure)
+	var largest *PositionExposure
+	for _, p := range r.Positions {
+		u, ok := underlyings[p.Underlying]
+		if !ok {
+			u = &UnderlyingExposure{Underlying: p.Underlying}
+			underlyings[p.Underlying] = u
+		}
+		if p.Type == "OPTIONS" {
+			u.OptionsDelta += p.Delta
+		} else {
+			u.FuturesDelta += p.Delta
+			if p.Market == models.MarketUSDM && p.MarkPrice > 0 {
+				u.Price = p.MarkPrice
+			}
+		}
+		r.TotalNotional += p.Notional
+		if largest == nil || p.Notional > largest.Notional {
+			largest = p
+		}
+	}
+
+	for _, u := range underlyings {
+		u.NetDelta = u.FuturesDelta + u.OptionsDelta
+		u.NetDeltaNotional = u.NetDelta * u.Price
+		r.Underlyings = append(r.Underlyings, u)
+	}
+	sort.Slice(r.Underlyings, func(i, j int) bool { return r.Underlyings[i].Underlying < r.Underlyings[j].Underlying })
+	sort.SliceStable(r.Positions, func(i, j int) bool { return r.Positions[i].Notional > r.Positions[j].Notional })
+
+	if largest != nil && r.TotalNotional > 0 {
+		r.LargestPosition = &Concentration{
+			Symbol:   largest.Symbol,
+			Side:     largest.Side,
+			Notional: largest.Notional,
+			SharePct: largest.Notional / r.TotalNotional * 100,
+		}
+	}
+}
+
+func (r *RiskSummary) addError(ctx context.Context, input string, err error) {
+	logging.FromContext(ctx).Warn("failed to load risk summary input", "input", input, "error", err)
+	r.Errors = append(r.Errors, fmt.Sprintf("%s: %v", input, err))
+	for _, in := range r.Inputs {
+		if in.Name == input && in.Error == "" {
+			in.Error = err.Error()
+		}
+	}
+}
+
+// marginUsage reads the margin of a futures account
+func marginUsage(account *futures.Account, asOf time.Time) *MarginUsage {
+	usage := &MarginUsage{
+		AsOf:              asOf,
+		MarginBalance:     parseFloatOr(account.TotalMarginBalance, ""),
+		InitialMargin:     parseFloatOr(account.TotalInitialMargin, ""),
+		MaintenanceMargin: parseFloatOr(account.TotalMaintMargin, ""),
+		Available:         parseFloatOr(account.AvailableBalance, ""),
+	}
+	if usage.MarginBalance > 0 {
+		usage.UsedPct = usage.InitialMargin / usage.MarginBalance * 100
+	}
+	return usage
+}
+
+// underlyingOf returns the base asset of a futures or options symbol: BTC for BTCUSDT,
+// BTCUSD_PERP, BTCUSDT_240628 and BTC-240628-60000-C
+func underlyingOf(symbol string) string {
+	if i := strings.IndexAny(symbol, "-_"); i > 0 {
+		symbol = symbol[:i]
+	}
+	for _, quote := range quoteAssets {
+		if base := strings.TrimSuffix(symbol, quote); base != symbol && base != "" {
+			return base
+		}
+	}
+	return symbol
+}
+
+// oldest returns the earlier of current and t
+func oldest(current *time.Time, t time.Time) *time.Time {
+	if current == nil || t.Before(*current) {
+		return &t
+	}
+	return current
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"futures-options/models"
+	"futures-options/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// scheduleClaimLead is how early a due schedule is claimed, so the database round trip does
+	// not delay the order itself
+	scheduleClaimLead = 500 * time.Millisecond
+	// scheduleMissedAfter is how late a schedule may run before its missed policy applies
+	scheduleMissedAfter = time.Second
+	// defaultScheduleGrace is the grace period of FIRE schedules that do not set one
+	defaultScheduleGrace = 30 * time.Second
+	// scheduledClientIDTag marks the client order IDs generated for scheduled orders
+	scheduledClientIDTag = "sched"
+)
+
+var (
+	// ErrScheduleNotFound is returned when no scheduled order matches the given ID
+	ErrScheduleNotFound = errors.New("scheduled order not found")
+	// ErrScheduleNotPending is returned when canceling a schedule that already ran or ended
+	ErrScheduleNotPending = errors.New("scheduled order is no longer pending")
+)
+
+var (
+	missedPolicies   = []string{models.MissedPolicySkip, models.MissedPolicyFire}
+	scheduleStatuses = []string{
+		string(models.SchedulePending), string(models.ScheduleExecuting), string(models.ScheduleExecuted),
+		string(models.ScheduleFailed), string(models.ScheduleSkipped), string(models.ScheduleCanceled),
+	}
+)
+
+// CreateScheduledOrderRequest submits an order at ExecuteAt
+type CreateScheduledOrderRequest struct {
+	ExecuteAt time.Time `json:"execute_at"` // RFC 3339, e.g. 2024-01-01T07:59:55.000Z
+	// MissedPolicy applies when the time passes while the schedule cannot run, e.g. during a
+	// restart: SKIP (default) or FIRE, which submits at once if no later than GraceSeconds
+	MissedPolicy string               `json:"missed_policy,omitempty"`
+	GraceSeconds int                  `json:"grace_seconds,omitempty"` // 0 uses the server default
+	Order        AdvancedOrderRequest `json:"order"`
+}
+
+// Validate checks the schedule and the order to submit
+func (r *CreateScheduledOrderRequest) Validate() error {
+	v := &validator{}
+	if r.ExecuteAt.IsZero() {
+		v.add("execute_at", RuleRequired, "is required")
+	}
+	r.MissedPolicy = strings.ToUpper(r.MissedPolicy)
+	v.oneOf("missed_policy", r.MissedPolicy, missedPolicies...)
+	v.nonNegative("grace_seconds", float64(r.GraceSeconds))
+	r.Order.validate(v, "order.")
+	return v.err()
+}
+
+// orderScheduler holds the pending schedules in execution order
+type orderScheduler struct {
+	mu      sync.Mutex
+	pending []*models.ScheduledOrder
+	wake    chan struct{} // signals the scheduler loop that pending changed
+	grace   time.Duration
+}
+
+// SetScheduledOrderGrace sets the grace period of FIRE schedules that do not set one; 0 uses
+// the default of 30s
+func (s *TradingService) SetScheduledOrderGrace(grace time.Duration) {
+	s.scheduler.mu.Lock()
+	defer s.scheduler.mu.Unlock()
+	s.scheduler.grace = grace
+}
+
+// CreateScheduledOrder stores a schedule for a time in the future
+func (s *TradingService) CreateScheduledOrder(ctx context.Context, req *CreateScheduledOrderRequest) (*models.ScheduledOrder, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !req.ExecuteAt.After(now) {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "execute_at", Rule: RuleRange, Message: "must be in the future"}}}
+	}
+	principal := PrincipalFromContext(ctx)
+	if req.Order.OverrideRiskLimits && !s.canOverrideRiskLimits(principal) {
+		return nil, ErrRiskOverrideForbidden
+	}
+
+	o := &models.ScheduledOrder{
+		ID:           primitive.NewObjectID(),
+		ExecuteAt:    req.ExecuteAt,
+		MissedPolicy: req.MissedPolicy,
+		GraceSeconds: req.GraceSeconds,
+		Order:        orderTemplate(&req.Order),
+		Status:       models.SchedulePending,
+		CreatedBy:    principal,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if o.MissedPolicy == "" {
+		o.MissedPolicy = models.MissedPolicySkip
+	}
+	if o.GraceSeconds == 0 {
+		o.GraceSeconds = int(s.scheduleGrace() / time.Second)
+	}
+	// A fixed client order ID lets the submitted order be found after an interrupted execution
+	if o.Order.ClientOrderID == "" {
+		o.Order.ClientOrderID = s.strategyClientOrderID(models.OrderSourceScheduled, o.ID, 0)
+	}
+
+	if err := s.repos.Scheduled.Insert(ctx, o); err != nil {
+		return nil, fmt.Errorf("failed to save scheduled order: %w", err)
+	}
+	s.trackSchedule(o)
+	slog.Info("scheduled order created", "schedule_id", o.ID.Hex(), "symbol", o.Order.Symbol,
+		"execute_at", o.ExecuteAt, "missed_policy", o.MissedPolicy)
+	return o, nil
+}
+
+// ListScheduledOrders returns scheduled orders by execution time, optionally by status
+func (s *TradingService) ListScheduledOrders(ctx context.Context, status string) ([]*models.ScheduledOrder, error) {
+	status = strings.ToUpper(status)
+	v := &validator{}
+	v.oneOf("status", status, scheduleStatuses...)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return s.repos.Scheduled.List(ctx, models.ScheduleStatus(status))
+}
+
+// GetScheduledOrder returns one scheduled order
+func (s *TradingService) GetScheduledOrder(ctx context.Context, id string) (*models.ScheduledOrder, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	o, err := s.repos.Scheduled.FindByID(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrScheduleNotFound
+	}
+	return o, err
+}
+
+// CancelScheduledOrder cancels a pending schedule
+func (s *TradingService) CancelScheduledOrder(ctx context.Context, id string) (*models.ScheduledOrder, error) {
+	o, err := s.GetScheduledOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	ok, err := s.repos.Scheduled.Transition(ctx, o.ID, models.SchedulePending, &repository.ScheduledOrderUpdate{
+		Status:    models.ScheduleCanceled,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrScheduleNotPending
+	}
+	s.untrackSchedule(o.ID)
+	o.Status = models.ScheduleCanceled
+	o.UpdatedAt = now
+	return o, nil
+}
+
+// StartScheduledOrders loads the pending schedules and runs them at their execution times until
+// ctx is done. Schedules whose time passed meanwhile follow their missed policy; those interrupted
+// while their order was being submitted are marked FAILED rather than submitted again.
+func (s *TradingService) StartScheduledOrders(ctx context.Context) error {
+	interrupted, err := s.repos.Scheduled.List(ctx, models.ScheduleExecuting)
+	if err != nil {
+		return fmt.Errorf("failed to load scheduled orders: %w", err)
+	}
+	for _, o := range interrupted {
+		msg := fmt.Sprintf("interrupted while submitting; check for an order with client order ID %s before recreating", o.Order.ClientOrderID)
+		if _, err := s.repos.Scheduled.Transition(ctx, o.ID, models.ScheduleExecuting, &repository.ScheduledOrderUpdate{
+			Status:    models.ScheduleFailed,
+			Error:     msg,
+			UpdatedAt: time.Now(),
+		}); err != nil {
+			return fmt.Errorf("failed to update scheduled order: %w", err)
+		}
+		slog.Warn("scheduled order was interrupted while executing", "schedule_id", o.ID.Hex(), "client_order_id", o.Order.ClientOrderID)
+	}
+
+	pending, err := s.repos.Scheduled.List(ctx, models.SchedulePending)
+	if err != nil {
+		return fmt.Errorf("failed to load scheduled orders: %w", err)
+	}
+	s.scheduler.mu.Lock()
+	s.scheduler.pending = pending
+	s.scheduler.mu.Unlock()
+
+	s.runBackground(func() { s.runScheduler(ctx) })
+	return nil
+}
+
+// runScheduler sleeps until the next schedule is due, or pending changes, and hands due
+// schedules to executeSchedule, each in its own goroutine so that simultaneous ones are not
+// delayed behind each other
+func (s *TradingService) runScheduler(ctx context.Context) {
+	for {
+		var timer *time.Timer
+		var due <-chan time.Time
+		if next, ok := s.nextScheduleTime(); ok {
+			timer = time.NewTimer(time.Until(next) - scheduleClaimLead)
+			due = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-s.scheduler.wake:
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-due:
+		}
+
+		for _, o := range s.popDueSchedules(time.Now().Add(scheduleClaimLead)) {
+			o := o
+			s.runBackground(func() {
+				s.executeSchedule(context.WithoutCancel(ctx), o)
+			})
+		}
+	}
+}
+
+// executeSchedule claims o, waits for its execution time and submits its order, recording the
+// outcome and the latency. The claim only succeeds while o is still PENDING, so the order is
+// submitted at most once.
+func (s *TradingService) executeSchedule(ctx context.Context, o *models.ScheduledOrder) {
+	log := slog.With("schedule_id", o.ID.Hex(), "symbol", o.Order.Symbol)
+	claimed, err := s.repos.Scheduled.Transition(ctx, o.ID, models.SchedulePending, &repository.ScheduledOrderUpdate{
+		Status:    models.ScheduleExecuting,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Error("failed to claim scheduled order; it stays pending until the next restart", "error", err)
+		return
+	}
+	if !claimed {
+		return // canceled meanwhile
+	}
+
+	if wait := time.Until(o.ExecuteAt); wait > 0 {
+		time.Sleep(wait)
+	}
+	if late := time.Since(o.ExecuteAt); late > scheduleMissedAfter {
+		grace := time.Duration(o.GraceSeconds) * time.Second
+		if o.MissedPolicy != models.MissedPolicyFire || late > grace {
+			log.Warn("scheduled order missed, skipping", "late", late, "missed_policy", o.MissedPolicy)
+			if _, err := s.repos.Scheduled.Transition(ctx, o.ID, models.ScheduleExecuting, &repository.ScheduledOrderUpdate{
+				Status:    models.ScheduleSkipped,
+				Error:     fmt.Sprintf("missed by %s", late.Round(time.Millisecond)),
+				UpdatedAt: time.Now(),
+			}); err != nil {
+				log.Error("failed to record scheduled order outcome", "error", err)
+			}
+			return
+		}
+		log.Warn("scheduled order missed, firing within grace period", "late", late, "grace", grace)
+	}
+
+	submitCtx, cancel := context.WithTimeout(WithPrincipal(ctx, o.CreatedBy), conditionalSubmitTimeout)
+	defer cancel()
+	sentAt := time.Now()
+	req := advancedOrderRequest(o.Order)
+	req.Source = models.OrderSourceScheduled
+	order, err := s.CreateAdvancedFuturesOrder(submitCtx, req)
+	ackedAt := time.Now()
+
+	sendLatency, ackLatency := latencyMs(o.ExecuteAt, sentAt), latencyMs(o.ExecuteAt, ackedAt)
+	update := &repository.ScheduledOrderUpdate{
+		SentAt:        sentAt,
+		AckedAt:       ackedAt,
+		SendLatencyMs: &sendLatency,
+		AckLatencyMs:  &ackLatency,
+		UpdatedAt:     ackedAt,
+	}
+	if err != nil {
+		log.Warn("scheduled order submission failed", "error", err)
+		update.Status = models.ScheduleFailed
+		update.Error = err.Error()
+	} else {
+		log.Info("scheduled order executed", "order_id", order.BinanceOrderID,
+			"send_latency_ms", sendLatency, "ack_latency_ms", ackLatency)
+		update.Status = models.ScheduleExecuted
+		update.OrderID = order.BinanceOrderID
+	}
+	if _, err := s.repos.Scheduled.Transition(ctx, o.ID, models.ScheduleExecuting, update); err != nil {
+		log.Error("failed to record scheduled order outcome", "error", err)
+	}
+}
+
+// latencyMs is how far at lies after target, in milliseconds with microsecond precision
+func latencyMs(target, at time.Time) float64 {
+	return float64(at.Sub(target).Microseconds()) / 1000
+}
+
+func (s *TradingService) scheduleGrace() time.Duration {
+	s.scheduler.mu.Lock()
+	defer s.scheduler.mu.Unlock()
+	if s.scheduler.grace > 0 {
+		return s.scheduler.grace
+	}
+	return defaultScheduleGrace
+}
+
+func (s *TradingService) nextScheduleTime() (time.Time, bool) {
+	s.scheduler.mu.Lock()
+	defer s.scheduler.mu.Unlock()
+	if len(s.scheduler.pending) == 0 {
+		return time.Time{}, false
+	}
+	return s.scheduler.pending[0].ExecuteAt, true
+}
+
+// popDueSchedules removes and returns the schedules executing no later than before
+func (s *TradingService) popDueSchedules(before time.Time) []*models.ScheduledOrder {
+	s.scheduler.mu.Lock()
+	defer s.scheduler.mu.Unlock()
+	n := sort.Search(len(s.scheduler.pending), func(i int) bool {
+		return s.scheduler.pending[i].ExecuteAt.After(before)
+	})
+	due := append([]*models.ScheduledOrder(nil), s.scheduler.pending[:n]...)
+	s.scheduler.pending = s.scheduler.pending[n:]
+	return due
+}
+
+func (s *TradingService) trackSchedule(o *models.ScheduledOrder) {
+	s.scheduler.mu.Lock()
+	i := sort.Search(len(s.scheduler.pending), func(i int) bool {
+		return s.scheduler.pending[i].ExecuteAt.After(o.ExecuteAt)
+	})
+	s.scheduler.pending = append(s.scheduler.pending, nil)
+	copy(s.scheduler.pending[i+1:], s.scheduler.pending[i:])
+	s.scheduler.pending[i] = o
+	s.scheduler.mu.Unlock()
+	s.wakeScheduler()
+}
+
+func (s *TradingService) untrackSchedule(id primitive.ObjectID) {
+	s.scheduler.mu.Lock()
+	for i, o := range s.scheduler.pending {
+		if o.ID == id {
+			s.scheduler.pending = append(s.scheduler.pending[:i], s.scheduler.pending[i+1:]...)
+			break
+		}
+	}
+	s.scheduler.mu.Unlock()
+	s.wakeScheduler()
+}
+
+// wakeScheduler makes the scheduler loop recompute its next wake-up
+func (s *TradingService) wakeScheduler() {
+	select {
+	case s.scheduler.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/logging"
+	"futures-options/models"
+	"futures-options/repository"
+	"futures-options/strategy"
+
+	spot "github.com/adshao/go-binance/v2"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// spotTimeInForces are the time in force values spot limit orders accept; GTX is futures only
+var spotTimeInForces = []string{string(models.TimeInForceGTC), string(models.TimeInForceIOC), string(models.TimeInForceFOK)}
+
+// CreateSpotOrderRequest is a spot market or limit order. Market orders set either quantity
+// (base asset) or quote_quantity (quote asset, e.g. spend 100 USDT).
+type CreateSpotOrderRequest struct {
+	Symbol        string          `json:"symbol"`
+	Side          string          `json:"side"`       // BUY or SELL
+	OrderType     string          `json:"order_type"` // MARKET or LIMIT
+	Quantity      decimal.Decimal `json:"quantity"`
+	QuoteQuantity float64         `json:"quote_quantity,omitempty"`
+	Price         decimal.Decimal `json:"price"`
+	TimeInForce   string          `json:"time_in_force,omitempty"` // GTC (default), IOC or FOK; limit orders only
+	ClientOrderID string          `json:"client_order_id,omitempty"`
+}
+
+// UnmarshalJSON takes the quote_quantity as a JSON number or decimal string; the quantity and price
+// are decimals, which decode from either form themselves
+func (r *CreateSpotOrderRequest) UnmarshalJSON(data []byte) error {
+	type plain CreateSpotOrderRequest
+	return decodeDecimalFields(data, (*plain)(r))
+}
+
+// Validate checks the order type and which size fields it needs
+func (r *CreateSpotOrderRequest) Validate() error {
+	v := &validator{}
+	v.required("symbol", r.Symbol)
+	v.required("side", r.Side)
+	v.oneOf("side", r.Side, orderSides...)
+	v.required("order_type", r.OrderType)
+	v.oneOf("order_type", r.OrderType, string(models.OrderTypeMarket), string(models.OrderTypeLimit))
+	v.nonNegativeDecimal("quantity", r.Quantity)
+	v.nonNegative("quote_quantity", r.QuoteQuantity)
+	v.nonNegativeDecimal("price", r.Price)
+	v.oneOf("time_in_force", r.TimeInForce, spotTimeInForces...)
+
+	if r.OrderType == string(models.OrderTypeLimit) {
+		v.positiveDecimal("quantity", r.Quantity)
+		v.positiveDecimal("price", r.Price)
+		if r.QuoteQuantity > 0 {
+			v.add("quote_quantity", RuleEnum, "is only supported for MARKET orders")
+		}
+	} else {
+		if r.Quantity.IsPositive() == (r.QuoteQuantity > 0) {
+			v.add("quantity", RuleRequired, "exactly one of quantity or quote_quantity is required")
+		}
+		if r.TimeInForce != "" {
+			v.add("time_in_force", RuleEnum, "is only supported for LIMIT orders")
+		}
+	}
+	return v.err()
+}
+
+// CreateSpotOrder rounds the order to the spot symbol's filters, places it and stores it in the
+// spot orders collection. It is not subject to the futures risk limits.
+func (s *TradingService) CreateSpotOrder(ctx context.Context, req *CreateSpotOrderRequest) (*models.SpotOrder, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if s.Paper() {
+		return nil, fmt.Errorf("spot orders are %w", ErrPaperUnsupported)
+	}
+	req.Symbol = strings.ToUpper(req.Symbol)
+	if replayed, err := s.replayedSpotOrder(ctx, req.ClientOrderID); replayed != nil || err != nil {
+		return replayed, err
+	}
+	if err := s.checkOrderAllowed(ctx, req.Symbol); err != nil {
+		return nil, err
+	}
+
+	filters, err := s.api(ctx).GetSpotSymbolFilters(ctx, req.Symbol)
+	if errors.Is(err, binance.ErrUnknownSymbol) {
+		v := &validator{}
+		v.add("symbol", RuleEnum, "is not a listed spot symbol")
+		return nil, v.err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !symbolOpen(filters) {
+		return nil, &SymbolStatusError{Symbol: req.Symbol, Status: filters.Status}
+	}
+	if err := applySpotFilters(req, filters); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	binanceOrder, err := s.api(ctx).CreateSpotOrder(ctx, &binance.SpotOrderRequest{
+		Symbol:        req.Symbol,
+		Side:          req.Side,
+		OrderType:     req.OrderType,
+		Quantity:      req.Quantity,
+		QuoteQuantity: req.QuoteQuantity,
+		Price:         req.Price,
+		TimeInForce:   req.TimeInForce,
+		ClientOrderID: req.ClientOrderID,
+	})
+	s.recordAudit(ctx, models.AuditOrderCreate, req.Symbol, req, binanceOrder, err, start)
+	if err != nil {
+		s.notifyOrderRejected(ctx, req.Symbol, err)
+		return nil, fmt.Errorf("failed to create spot order on Binance: %w", err)
+	}
+	logging.FromContext(ctx).Info("spot order placed", "symbol", req.Symbol, "binance_order_id", binanceOrder.OrderID, "status", binanceOrder.Status)
+
+	now := time.Now()
+	order := &models.SpotOrder{
+		ID:             primitive.NewObjectID(),
+		Symbol:         req.Symbol,
+		Side:           models.OrderSide(req.Side),
+		OrderType:      models.OrderType(req.OrderType),
+		Quantity:       req.Quantity.InexactFloat64(),
+		QuoteQuantity:  req.QuoteQuantity,
+		Price:          req.Price.InexactFloat64(),
+		TimeInForce:    models.TimeInForce(req.TimeInForce),
+		BinanceOrderID: binanceOrder.OrderID,
+		ClientOrderID:  binanceOrder.ClientOrderID,
+		Status:         string(binanceOrder.Status),
+		RawResponse:    s.rawResponse(binanceOrder),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	order.ExecutedQuantity, order.CumulativeQuote, order.AvgPrice = spotFill(binanceOrder.ExecutedQuantity, binanceOrder.CummulativeQuoteQuantity)
+	return s.saveSpotOrder(ctx, order)
+}
+
+// applySpotFilters rounds quantity down to the lot step and price to the tick, then checks the
+// lot and notional limits, all on decimals. The notional of a market order sized in the base asset
+// is left to Binance, which checks it against its own average price.
+func applySpotFilters(req *CreateSpotOrderRequest, f *binance.SymbolFilters) error {
+	step, minQty, maxQty := f.StepSize, f.MinQty, f.MaxQty
+	if req.OrderType == string(models.OrderTypeMarket) {
+		// Spot MARKET_LOT_SIZE often leaves the step at 0, meaning LOT_SIZE applies
+		if f.MarketStepSize > 0 {
+			step = f.MarketStepSize
+		}
+		if f.MarketMinQty > 0 {
+			minQty = f.MarketMinQty
+		}
+		if f.MarketMaxQty > 0 {
+			maxQty = f.MarketMaxQty
+		}
+	}
+
+	v := &validator{}
+	if req.Quantity.IsPositive() {
+		req.Quantity = strategy.RoundToStep(req.Quantity, decimal.NewFromFloat(step), strategy.RoundDown)
+		if req.Quantity.LessThan(decimal.NewFromFloat(minQty)) || !req.Quantity.IsPositive() {
+			v.add("quantity", RuleRange, fmt.Sprintf("is below the symbol's minimum quantity %s", formatFloat(minQty)))
+		} else if maxQty > 0 && req.Quantity.GreaterThan(decimal.NewFromFloat(maxQty)) {
+			v.add("quantity", RuleRange, fmt.Sprintf("is above the symbol's maximum quantity %s", formatFloat(maxQty)))
+		}
+	}
+	if req.Price.IsPositive() {
+		req.Price = strategy.RoundToStep(req.Price, decimal.NewFromFloat(f.TickSize), strategy.RoundNearest)
+	}
+
+	var notional decimal.Decimal
+	switch {
+	case req.QuoteQuantity > 0:
+		notional = decimal.NewFromFloat(req.QuoteQuantity)
+	case req.OrderType == string(models.OrderTypeLimit):
+		notional = req.Quantity.Mul(req.Price)
+	}
+	if notional.IsPositive() && notional.LessThan(decimal.NewFromFloat(f.MinNotional)) {
+		field := "quantity"
+		if req.QuoteQuantity > 0 {
+			field = "quote_quantity"
+		}
+		v.add(field, RuleRange, fmt.Sprintf("is below the symbol's minimum notional %s", formatFloat(f.MinNotional)))
+	}
+	return v.err()
+}
+
+// spotFill parses the executed quantity and cumulative quote of a spot order and derives the
+// average fill price
+func spotFill(executed, cumulativeQuote string) (qty, quote, avg float64) {
+	qty, _ = strconv.ParseFloat(executed, 64)
+	quote, _ = strconv.ParseFloat(cumulativeQuote, 64)
+	if qty > 0 {
+		avg = quote / qty
+	}
+	return qty, quote, avg
+}
+
+// saveSpotOrder inserts a spot order, returning the existing document on a duplicate Binance ID
+func (s *TradingService) saveSpotOrder(ctx context.Context, order *models.SpotOrder) (*models.SpotOrder, error) {
+	order.AccountID = s.accountID(ctx)
+	err := s.repos.SpotOrders.Insert(ctx, order)
+	if err == nil {
+		return order, nil
+	}
+	if errors.Is(err, repository.ErrDuplicate) && order.BinanceOrderID > 0 {
+		if existing, findErr := s.repos.SpotOrders.FindByBinanceID(ctx, order.BinanceOrderID); findErr == nil {
+			return existing, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to save order to database: %w", err)
+}
+
+// GetSpotOrders retrieves a page of spot orders
+func (s *TradingService) GetSpotOrders(ctx context.Context, query *OrderQuery) (*SpotOrderPage, error) {
+	query.AccountID = AccountFromContext(ctx)
+	orders, total, err := s.repos.SpotOrders.List(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query spot orders: %w", err)
+	}
+
+	page := &SpotOrderPage{Items: orders, Total: total}
+	if int64(len(orders)) > query.PageLimit() {
+		page.Items = orders[:query.PageLimit()]
+		page.NextCursor = page.Items[len(page.Items)-1].ID.Hex()
+	}
+	if query.IncludeJournal {
+		ids := make([]primitive.ObjectID, len(page.Items))
+		for i, o := range page.Items {
+			ids[i] = o.ID
+		}
+		latest, err := s.latestJournalEntries(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range page.Items {
+			o.Journal = latest[o.ID]
+		}
+	}
+
+	return page, nil
+}
+
+// GetSpotBalances returns the spot wallet assets with a non-zero free or locked balance
+func (s *TradingService) GetSpotBalances(ctx context.Context) ([]*models.SpotBalance, error) {
+	if s.Paper() {
+		return nil, fmt.Errorf("spot balances are %w", ErrPaperUnsupported)
+	}
+	raw, err := s.api(ctx).GetSpotBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := []*models.SpotBalance{}
+	for _, b := range raw {
+		free, _ := strconv.ParseFloat(b.Free, 64)
+		locked, _ := strconv.ParseFloat(b.Locked, 64)
+		if free == 0 && locked == 0 {
+			continue
+		}
+		balances = append(balances, &models.SpotBalance{Asset: b.Asset, Free: free, Locked: locked})
+	}
+	return balances, nil
+}
+
+// ReconcileSpotOrders is ReconcileFuturesOrders for spot orders; fills are synced along with the status
+func (s *TradingService) ReconcileSpotOrders(ctx context.Context) (*ReconcileSummary, error) {
+	summary := &ReconcileSummary{StartedAt: time.Now()}
+	if s.Paper() {
+		summary.Duration = time.Since(summary.StartedAt).String()
+		return summary, nil
+	}
+
+	orders, err := s.repos.SpotOrders.ListOpen(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bySymbol := map[string][]*models.SpotOrder{}
+	for _, o := range orders {
+		bySymbol[o.Symbol] = append(bySymbol[o.Symbol], o)
+	}
+
+	for symbol, local := range bySymbol {
+		open, err := s.api(ctx).ListOpenSpotOrders(ctx, symbol)
+		if err != nil {
+			slog.Warn("reconcile: failed to list open spot orders", "symbol", symbol, "error", err)
+			summary.Errors += len(local)
+			continue
+		}
+		live := make(map[int64]*spot.Order, len(open))
+		for _, o := range open {
+			live[o.OrderID] = o
+		}
+
+		for _, order := range local {
+			summary.Checked++
+			now := time.Now()
+
+			remote, ok := live[order.BinanceOrderID]
+			if !ok {
+				// No longer open: ask Binance for its final state
+				remote, err = s.api(ctx).GetSpotOrder(ctx, symbol, order.BinanceOrderID)
+				if err != nil {
+					if binance.IsOrderNotFound(err) {
+						s.markSpotOrderMissing(ctx, order, now, summary)
+						continue
+					}
+					slog.Warn("reconcile: failed to fetch spot order", "symbol", symbol, "binance_order_id", order.BinanceOrderID, "error", err)
+					summary.Errors++
+					continue
+				}
+			}
+
+			status := string(remote.Status)
+			executed, quote, avg := spotFill(remote.ExecutedQuantity, remote.CummulativeQuoteQuantity)
+			update := &repository.SpotOrderUpdate{ReconciledAt: now}
+			if status != order.Status || executed != order.ExecutedQuantity {
+				update.Status = status
+				update.Fill = &repository.SpotOrderFill{ExecutedQuantity: executed, CumulativeQuote: quote, AvgPrice: avg}
+				update.UpdatedAt = now
+			}
+			if _, err := s.repos.SpotOrders.UpdateByRef(ctx, order.BinanceOrderID, "", update); err != nil {
+				slog.Warn("reconcile: failed to update spot order", "symbol", symbol, "binance_order_id", order.BinanceOrderID, "error", err)
+				summary.Errors++
+				continue
+			}
+			if status != order.Status {
+				summary.Changed++
+				summary.Changes = append(summary.Changes, ReconcileChange{
+					BinanceOrderID: order.BinanceOrderID,
+					Symbol:         symbol,
+					From:           order.Status,
+					To:             status,
+				})
+			}
+		}
+	}
+
+	summary.Duration = time.Since(summary.StartedAt).String()
+	return summary, nil
+}
+
+// markSpotOrderMissing flags a local spot order whose Binance counterpart no longer exists
+func (s *TradingService) markSpotOrderMissing(ctx context.Context, order *models.SpotOrder, now time.Time, summary *ReconcileSummary) {
+	missing := true
+	update := &repository.SpotOrderUpdate{MissingOnExchange: &missing, ReconciledAt: now, UpdatedAt: now}
+	if _, err := s.repos.SpotOrders.UpdateByRef(ctx, order.BinanceOrderID, "", update); err != nil {
+		slog.Warn("reconcile: failed to flag missing spot order", "symbol", order.Symbol, "binance_order_id", order.BinanceOrderID, "error", err)
+		summary.Errors++
+		return
+	}
+	summary.Missing++
+}
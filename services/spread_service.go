@@ -0,0 +1,552 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/repository"
+	"futures-options/strategy"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// spreadClientIDTag marks the client order IDs of calendar spread legs and their unwinds
+	spreadClientIDTag = "sprd"
+	// defaultSpreadFillTimeout is how long both legs get to fill when the request sets no timeout
+	defaultSpreadFillTimeout = 30 * time.Second
+	// maxSpreadFillTimeout bounds fill_timeout_seconds; a spread left legged for longer is no
+	// longer the trade that was asked for
+	maxSpreadFillTimeout = 10 * time.Minute
+)
+
+// Spread price modes
+const (
+	SpreadPriceMarket = "MARKET" // both legs are market orders
+	SpreadPriceMark   = "MARK"   // both legs are GTC limit orders at their current mark price
+)
+
+var (
+	// ErrSpreadNotFound is returned when no calendar spread matches the given ID
+	ErrSpreadNotFound = errors.New("calendar spread not found")
+	// ErrSpreadState is returned when unwinding a spread that is pending, failed or already unwound
+	ErrSpreadState = errors.New("calendar spread status does not allow unwinding")
+)
+
+// CreateSpreadRequest opens a calendar spread. The near leg trades quantity; the far leg's quantity
+// is sized to the same notional at the current mark prices.
+type CreateSpreadRequest struct {
+	NearSymbol string  `json:"near_symbol"` // e.g. BTCUSDT
+	FarSymbol  string  `json:"far_symbol"`  // e.g. BTCUSDT_250926
+	Direction  string  `json:"direction"`   // LONG_BASIS or SHORT_BASIS
+	Quantity   float64 `json:"quantity"`
+	PriceMode  string  `json:"price_mode,omitempty"` // MARKET (default) or MARK
+	Leverage   int     `json:"leverage,omitempty"`
+	// FillTimeoutSeconds is how long both legs get to fill before the spread counts as broken
+	FillTimeoutSeconds int `json:"fill_timeout_seconds,omitempty"`
+	// AutoUnwind closes the filled portion of a broken spread right away instead of leaving it
+	// to POST /api/futures/spread/{id}/unwind
+	AutoUnwind bool `json:"auto_unwind,omitempty"`
+}
+
+// UnmarshalJSON takes the near leg quantity as JSON numbers or decimal strings
+func (r *CreateSpreadRequest) UnmarshalJSON(data []byte) error {
+	type plain CreateSpreadRequest
+	return decodeDecimalFields(data, (*plain)(r))
+}
+
+// Validate checks the symbols, direction, sizing and timeout
+func (r *CreateSpreadRequest) Validate() error {
+	v := &validator{}
+	r.NearSymbol = strings.ToUpper(strings.TrimSpace(r.NearSymbol))
+	r.FarSymbol = strings.ToUpper(strings.TrimSpace(r.FarSymbol))
+	v.required("near_symbol", r.NearSymbol)
+	v.required("far_symbol", r.FarSymbol)
+	if r.NearSymbol != "" && r.FarSymbol != "" {
+		nearPair, _, _ := strings.Cut(r.NearSymbol, "_")
+		farPair, _, _ := strings.Cut(r.FarSymbol, "_")
+		switch {
+		case r.NearSymbol == r.FarSymbol:
+			v.add("far_symbol", RuleEnum, "must differ from near_symbol")
+		case nearPair != farPair:
+			v.add("far_symbol", RuleEnum, "must be a contract of the same pair as near_symbol")
+		}
+	}
+	r.Direction = strings.ToUpper(r.Direction)
+	v.required("direction", r.Direction)
+	v.oneOf("direction", r.Direction, models.SpreadLongBasis, models.SpreadShortBasis)
+	v.positive("quantity", r.Quantity)
+	r.PriceMode = strings.ToUpper(r.PriceMode)
+	if r.PriceMode == "" {
+		r.PriceMode = SpreadPriceMarket
+	}
+	v.oneOf("price_mode", r.PriceMode, SpreadPriceMarket, SpreadPriceMark)
+	v.leverage("leverage", r.Leverage)
+	if r.FillTimeoutSeconds < 0 || time.Duration(r.FillTimeoutSeconds)*time.Second > maxSpreadFillTimeout {
+		v.add("fill_timeout_seconds", RuleRange, fmt.Sprintf("must be between 1 and %d, or 0 for %d", int(maxSpreadFillTimeout.Seconds()), int(defaultSpreadFillTimeout.Seconds())))
+	}
+	return v.err()
+}
+
+// CreateCalendarSpread places both legs of a spread in one batch, tagged with the spread's ID,
+// and watches that both fill by the deadline
+func (s *TradingService) CreateCalendarSpread(ctx context.Context, req *CreateSpreadRequest) (*models.CalendarSpread, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	near, err := s.spreadLegFilters(ctx, "near_symbol", req.NearSymbol)
+	if err != nil {
+		return nil, err
+	}
+	far, err := s.spreadLegFilters(ctx, "far_symbol", req.FarSymbol)
+	if err != nil {
+		return nil, err
+	}
+	nearMark, err := s.currentMarkPrice(ctx, req.NearSymbol)
+	if err != nil {
+		return nil, err
+	}
+	farMark, err := s.currentMarkPrice(ctx, req.FarSymbol)
+	if err != nil {
+		return nil, err
+	}
+	dualSide, err := s.api(ctx).GetPositionMode(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position mode: %w", err)
+	}
+
+	// Market orders are sized to the market lot size
+	nearStep, farStep := near.StepSize, far.StepSize
+	if req.PriceMode == SpreadPriceMarket {
+		nearStep, farStep = marketStep(near), marketStep(far)
+	}
+	nearQty := roundToStep(req.Quantity, nearStep, strategy.RoundDown)
+	farQty := roundToStep(nearQty*nearMark/farMark, farStep, strategy.RoundNearest)
+	v := &validator{}
+	if nearQty < near.MinQty || farQty < far.MinQty {
+		v.add("quantity", RuleRange, fmt.Sprintf("leaves a leg below its minimum quantity: %s %s or %s %s",
+			formatFloat(near.MinQty), near.Symbol, formatFloat(far.MinQty), far.Symbol))
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	timeout := defaultSpreadFillTimeout
+	if req.FillTimeoutSeconds > 0 {
+		timeout = time.Duration(req.FillTimeoutSeconds) * time.Second
+	}
+	now := time.Now()
+	sp := &models.CalendarSpread{
+		ID:           primitive.NewObjectID(),
+		Direction:    req.Direction,
+		PriceMode:    req.PriceMode,
+		Near:         models.SpreadLeg{Symbol: req.NearSymbol, Quantity: nearQty},
+		Far:          models.SpreadLeg{Symbol: req.FarSymbol, Quantity: farQty},
+		Status:       models.SpreadPending,
+		AutoUnwind:   req.AutoUnwind,
+		FillDeadline: now.Add(timeout),
+		AccountID:    s.accountID(ctx),
+		CreatedBy:    PrincipalFromContext(ctx),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	sp.Near.Side, sp.Far.Side = string(models.OrderSideSell), string(models.OrderSideBuy)
+	if sp.Direction == models.SpreadShortBasis {
+		sp.Near.Side, sp.Far.Side = sp.Far.Side, sp.Near.Side
+	}
+	for _, leg := range []*models.SpreadLeg{&sp.Near, &sp.Far} {
+		if dualSide {
+			leg.PositionSide = string(models.PositionSideLong)
+			if leg.Side == string(models.OrderSideSell) {
+				leg.PositionSide = string(models.PositionSideShort)
+			}
+		}
+	}
+	if req.PriceMode == SpreadPriceMark {
+		sp.Near.Price = roundToStep(nearMark, near.TickSize, strategy.RoundNearest)
+		sp.Far.Price = roundToStep(farMark, far.TickSize, strategy.RoundNearest)
+	}
+	if err := s.repos.Spreads.Insert(ctx, sp); err != nil {
+		return nil, fmt.Errorf("failed to save calendar spread: %w", err)
+	}
+
+	s.spreadMu.Lock()
+	defer s.spreadMu.Unlock()
+	orders := make([]AdvancedOrderRequest, 0, 2)
+	for _, leg := range []*models.SpreadLeg{&sp.Near, &sp.Far} {
+		sp.OrdersPlaced++
+		leg.ClientOrderID = s.spreadClientOrderID(sp)
+		order := AdvancedOrderRequest{
+			Symbol:        leg.Symbol,
+			Side:          leg.Side,
+			OrderType:     string(models.OrderTypeMarket),
+			Quantity:      decimal.NewFromFloat(leg.Quantity),
+			PositionSide:  leg.PositionSide,
+			Leverage:      req.Leverage,
+			ClientOrderID: leg.ClientOrderID,
+			Source:        models.OrderSourceSpread,
+		}
+		if leg.Price > 0 {
+			order.OrderType = string(models.OrderTypeLimit)
+			order.Price = decimal.NewFromFloat(leg.Price)
+			order.TimeInForce = string(models.TimeInForceGTC)
+		}
+		orders = append(orders, order)
+	}
+	resp, err := s.CreateBatchOrders(ctx, &BatchOrderRequest{Orders: orders})
+	if err != nil {
+		// The batch fails as a whole only when neither leg was placed
+		sp.Status = models.SpreadFailed
+		sp.LastError = err.Error()
+		s.saveCalendarSpread(ctx, sp)
+		return nil, err
+	}
+	applySpreadOrders(sp, resp)
+	slog.Info("calendar spread placed", "spread_id", sp.ID.Hex(), "near", sp.Near.Symbol, "far", sp.Far.Symbol,
+		"direction", sp.Direction, "near_quantity", sp.Near.Quantity, "far_quantity", sp.Far.Quantity)
+
+	s.settleSpread(ctx, sp, time.Now())
+	s.saveCalendarSpread(ctx, sp)
+	if sp.Status == models.SpreadPending {
+		s.watchSpread(context.WithoutCancel(ctx), sp.ID, sp.FillDeadline)
+	}
+	return sp, nil
+}
+
+// spreadLegFilters returns a leg symbol's filters, rejecting symbols missing from the USDⓈ-M
+// exchange info under field
+func (s *TradingService) spreadLegFilters(ctx context.Context, field, symbol string) (*binance.SymbolFilters, error) {
+	filters, err := s.api(ctx).GetSymbolFilters(ctx, symbol)
+	if errors.Is(err, binance.ErrUnknownSymbol) {
+		v := &validator{}
+		v.add(field, RuleEnum, "is not a listed futures symbol")
+		return nil, v.err()
+	}
+	return filters, err
+}
+
+// marketStep is the lot size market orders on f's symbol are rounded to
+func marketStep(f *binance.SymbolFilters) float64 {
+	if f.MarketStepSize > 0 {
+		return f.MarketStepSize
+	}
+	return f.StepSize
+}
+
+// applySpreadOrders records the orders and errors of the batch that placed sp's legs. A leg with
+// neither was sent but could not be stored.
+func applySpreadOrders(sp *models.CalendarSpread, resp *BatchOrderResponse) {
+	for _, leg := range []*models.SpreadLeg{&sp.Near, &sp.Far} {
+		for _, o := range resp.Orders {
+			if o.ClientOrderID == leg.ClientOrderID {
+				leg.OrderID = o.BinanceOrderID
+				leg.Status = o.Status
+				leg.FilledQty = o.ExecutedQty
+				leg.AvgPrice = o.AvgFillPrice
+			}
+		}
+		if leg.OrderID != 0 {
+			continue
+		}
+		leg.Error = "not recorded; look it up by its client order ID"
+		for _, msg := range resp.Errors {
+			if strings.Contains(msg, "("+leg.Symbol+")") {
+				leg.Error = msg
+			}
+		}
+	}
+}
+
+// settleSpread refreshes the legs of a pending spread from Binance. It opens once both filled and
+// breaks when a leg failed, ended without filling completely or missed the deadline at now; the
+// open remainder of a broken spread is canceled and, with auto unwind, its filled portion closed.
+// s.spreadMu must be held.
+func (s *TradingService) settleSpread(ctx context.Context, sp *models.CalendarSpread, now time.Time) {
+	if sp.Status != models.SpreadPending {
+		return
+	}
+	broken := ""
+	for _, leg := range []*models.SpreadLeg{&sp.Near, &sp.Far} {
+		if leg.OrderID == 0 {
+			broken = fmt.Sprintf("the %s leg was not placed: %s", leg.Symbol, leg.Error)
+			continue
+		}
+		s.refreshSpreadLeg(ctx, sp, leg)
+		switch futures.OrderStatusType(leg.Status) {
+		case futures.OrderStatusTypeCanceled, futures.OrderStatusTypeExpired, futures.OrderStatusTypeRejected:
+			broken = fmt.Sprintf("the %s leg ended %s with %s of %s filled", leg.Symbol, leg.Status, formatFloat(leg.FilledQty), formatFloat(leg.Quantity))
+		}
+	}
+	if sp.Near.Status == string(futures.OrderStatusTypeFilled) && sp.Far.Status == string(futures.OrderStatusTypeFilled) {
+		sp.Status = models.SpreadOpen
+		sp.EntryBasis = sp.Far.AvgPrice - sp.Near.AvgPrice
+		slog.Info("calendar spread filled", "spread_id", sp.ID.Hex(), "entry_basis", sp.EntryBasis)
+		return
+	}
+	if broken == "" && now.Before(sp.FillDeadline) {
+		return
+	}
+	if broken == "" {
+		broken = "the legs did not both fill by the deadline"
+	}
+
+	for _, leg := range []*models.SpreadLeg{&sp.Near, &sp.Far} {
+		switch futures.OrderStatusType(leg.Status) {
+		case futures.OrderStatusTypeNew, futures.OrderStatusTypePartiallyFilled:
+			if err := s.CancelBatchOrders(ctx, leg.Symbol, []int64{leg.OrderID}, nil); err != nil {
+				slog.Warn("failed to cancel calendar spread leg", "spread_id", sp.ID.Hex(), "symbol", leg.Symbol, "error", err)
+			}
+			// Book what filled before the cancel took effect
+			s.refreshSpreadLeg(ctx, sp, leg)
+		}
+	}
+	sp.Status = models.SpreadBroken
+	sp.LastError = broken
+	slog.Warn("calendar spread broken", "spread_id", sp.ID.Hex(), "reason", broken,
+		"near_filled", sp.Near.FilledQty, "far_filled", sp.Far.FilledQty)
+	if sp.AutoUnwind {
+		if err := s.unwindSpread(ctx, sp); err != nil {
+			sp.LastError = broken + "; unwind failed: " + err.Error()
+		}
+	}
+}
+
+// refreshSpreadLeg updates leg, and its stored order, from Binance
+func (s *TradingService) refreshSpreadLeg(ctx context.Context, sp *models.CalendarSpread, leg *models.SpreadLeg) {
+	live, err := s.api(ctx).GetFuturesOrder(ctx, leg.Symbol, leg.OrderID)
+	if err != nil {
+		slog.Warn("failed to get calendar spread leg", "spread_id", sp.ID.Hex(), "symbol", leg.Symbol, "binance_order_id", leg.OrderID, "error", err)
+		return
+	}
+	fill := futuresOrderFill(live)
+	s.recordOrderFill(ctx, leg.Symbol, leg.OrderID, "", fill)
+	leg.Status = fill.Status
+	leg.FilledQty = fill.ExecutedQty
+	leg.AvgPrice = fill.AvgPrice
+}
+
+// unwindSpread closes the filled quantity of each leg not unwound yet, in one batch of market
+// orders. The spread is UNWOUND once no leg holds anything. s.spreadMu must be held.
+func (s *TradingService) unwindSpread(ctx context.Context, sp *models.CalendarSpread) error {
+	var orders []AdvancedOrderRequest
+	var legs []*models.SpreadLeg
+	for _, leg := range []*models.SpreadLeg{&sp.Near, &sp.Far} {
+		if leg.FilledQty <= 0 || leg.UnwindOrderID != 0 {
+			continue
+		}
+		side := models.OrderSideSell
+		if leg.Side == string(models.OrderSideSell) {
+			side = models.OrderSideBuy
+		}
+		sp.OrdersPlaced++
+		orders = append(orders, AdvancedOrderRequest{
+			Symbol:        leg.Symbol,
+			Side:          string(side),
+			OrderType:     string(models.OrderTypeMarket),
+			Quantity:      decimal.NewFromFloat(leg.FilledQty),
+			PositionSide:  leg.PositionSide,
+			ReduceOnly:    leg.PositionSide == "", // hedge mode closes by position side instead
+			ClientOrderID: s.spreadClientOrderID(sp),
+			Source:        models.OrderSourceSpread,
+		})
+		legs = append(legs, leg)
+	}
+	if len(orders) > 0 {
+		resp, err := s.CreateBatchOrders(ctx, &BatchOrderRequest{Orders: orders})
+		if err != nil {
+			return err
+		}
+		for i, leg := range legs {
+			for _, o := range resp.Orders {
+				if o.ClientOrderID == orders[i].ClientOrderID {
+					leg.UnwindOrderID = o.BinanceOrderID
+				}
+			}
+		}
+		if len(resp.Errors) > 0 {
+			return errors.New(strings.Join(resp.Errors, "; "))
+		}
+	}
+	for _, leg := range []*models.SpreadLeg{&sp.Near, &sp.Far} {
+		if leg.FilledQty > 0 && leg.UnwindOrderID == 0 {
+			return fmt.Errorf("the %s leg was not unwound", leg.Symbol)
+		}
+	}
+	sp.Status = models.SpreadUnwound
+	slog.Info("calendar spread unwound", "spread_id", sp.ID.Hex())
+	return nil
+}
+
+// watchSpread settles a pending spread at its fill deadline, unless the service shuts down first;
+// a spread left pending then is settled when it is next fetched
+func (s *TradingService) watchSpread(ctx context.Context, id primitive.ObjectID, deadline time.Time) {
+	var stop <-chan struct{}
+	if s.bgCtx != nil {
+		stop = s.bgCtx.Done()
+	}
+	s.runBackground(func() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+		}
+		s.spreadMu.Lock()
+		defer s.spreadMu.Unlock()
+		sp, err := s.repos.Spreads.FindByID(ctx, id)
+		if err != nil {
+			slog.Error("failed to load calendar spread", "spread_id", id.Hex(), "error", err)
+			return
+		}
+		if sp.Status == models.SpreadPending {
+			s.settleSpread(ctx, sp, time.Now())
+			s.saveCalendarSpread(ctx, sp)
+		}
+	})
+}
+
+// handleSpreadOrderUpdate settles a pending spread as soon as one of its legs fills or ends
+func (s *TradingService) handleSpreadOrderUpdate(ctx context.Context, u *futures.WsOrderTradeUpdate) {
+	switch u.Status {
+	case futures.OrderStatusTypeNew, futures.OrderStatusTypePartiallyFilled:
+		return
+	}
+	source, hexID := s.parseClientOrderID(u.ClientOrderID)
+	if source != models.OrderSourceSpread {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return
+	}
+	// Canceling and unwinding must not hold up the stream
+	s.runBackground(func() {
+		ctx := context.WithoutCancel(ctx)
+		s.spreadMu.Lock()
+		defer s.spreadMu.Unlock()
+		sp, err := s.repos.Spreads.FindByID(ctx, id)
+		if err != nil {
+			slog.Error("failed to load calendar spread", "spread_id", hexID, "error", err)
+			return
+		}
+		if sp.Status != models.SpreadPending {
+			return
+		}
+		ctx, err = s.spreadContext(ctx, sp)
+		if err != nil {
+			slog.Error("failed to select calendar spread account", "spread_id", hexID, "error", err)
+			return
+		}
+		s.settleSpread(ctx, sp, time.Now())
+		s.saveCalendarSpread(ctx, sp)
+	})
+}
+
+// GetCalendarSpread returns a spread with its entry basis and, from the current mark prices, the
+// basis and the PnL of its filled legs. A pending spread past its deadline is settled first.
+func (s *TradingService) GetCalendarSpread(ctx context.Context, id string) (*models.CalendarSpread, error) {
+	sp, err := s.findCalendarSpread(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ctx, err = s.spreadContext(ctx, sp); err != nil {
+		return nil, err
+	}
+	if sp.Status == models.SpreadPending && !time.Now().Before(sp.FillDeadline) {
+		s.spreadMu.Lock()
+		if sp, err = s.findCalendarSpread(ctx, id); err == nil && sp.Status == models.SpreadPending {
+			s.settleSpread(ctx, sp, time.Now())
+			s.saveCalendarSpread(ctx, sp)
+		}
+		s.spreadMu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	nearMark, nearErr := s.currentMarkPrice(ctx, sp.Near.Symbol)
+	farMark, farErr := s.currentMarkPrice(ctx, sp.Far.Symbol)
+	if err := errors.Join(nearErr, farErr); err != nil {
+		slog.Warn("failed to value calendar spread", "spread_id", id, "error", err)
+		return sp, nil
+	}
+	sp.Near.MarkPrice, sp.Far.MarkPrice = nearMark, farMark
+	sp.CurrentBasis = farMark - nearMark
+	if sp.Status != models.SpreadUnwound {
+		for _, leg := range []*models.SpreadLeg{&sp.Near, &sp.Far} {
+			leg.PnL = (leg.MarkPrice - leg.AvgPrice) * leg.FilledQty
+			if leg.Side == string(models.OrderSideSell) {
+				leg.PnL = -leg.PnL
+			}
+			sp.BasisPnL += leg.PnL
+		}
+	}
+	return sp, nil
+}
+
+// UnwindCalendarSpread closes the filled legs of a broken or open spread with market orders
+func (s *TradingService) UnwindCalendarSpread(ctx context.Context, id string) (*models.CalendarSpread, error) {
+	s.spreadMu.Lock()
+	defer s.spreadMu.Unlock()
+	sp, err := s.findCalendarSpread(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sp.Status != models.SpreadBroken && sp.Status != models.SpreadOpen {
+		return nil, ErrSpreadState
+	}
+	if ctx, err = s.spreadContext(ctx, sp); err != nil {
+		return nil, err
+	}
+	err = s.unwindSpread(ctx, sp)
+	if err != nil {
+		sp.LastError = "unwind failed: " + err.Error()
+	}
+	s.saveCalendarSpread(ctx, sp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwind calendar spread: %w", err)
+	}
+	return sp, nil
+}
+
+func (s *TradingService) findCalendarSpread(ctx context.Context, id string) (*models.CalendarSpread, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	sp, err := s.repos.Spreads.FindByID(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSpreadNotFound
+	}
+	return sp, err
+}
+
+// spreadContext signs the requests made for sp with the account its legs were placed on
+func (s *TradingService) spreadContext(ctx context.Context, sp *models.CalendarSpread) (context.Context, error) {
+	ctx = WithPrincipal(ctx, sp.CreatedBy)
+	if sp.AccountID == "" || sp.AccountID == s.accountID(ctx) {
+		return ctx, nil
+	}
+	return s.WithAccount(ctx, sp.AccountID)
+}
+
+func (s *TradingService) saveCalendarSpread(ctx context.Context, sp *models.CalendarSpread) {
+	sp.UpdatedAt = time.Now()
+	if err := s.repos.Spreads.Update(ctx, sp); err != nil {
+		slog.Error("failed to save calendar spread", "spread_id", sp.ID.Hex(), "error", err)
+	}
+}
+
+// spreadClientOrderID numbers sp's orders; call after incrementing sp.OrdersPlaced
+func (s *TradingService) spreadClientOrderID(sp *models.CalendarSpread) string {
+	return s.strategyClientOrderID(models.OrderSourceSpread, sp.ID, sp.OrdersPlaced)
+}
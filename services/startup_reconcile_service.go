@@ -0,0 +1,341 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"github.com/adshao/go-binance/v2/delivery"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Reconciliation triggers recorded on the report
+const (
+	ReconcileTriggerStartup       = "startup"
+	ReconcileTriggerManual        = "manual"
+	ReconcileTriggerStreamRestart = "stream_restart"
+)
+
+// ReconcileTimeout bounds a reconciliation run on request; handlers extend their write deadline
+// to match, since a large book takes longer than the server's WriteTimeout
+const ReconcileTimeout = 2 * time.Minute
+
+// defaultReconcileReportLimit is the number of reports listed when no limit is given
+const defaultReconcileReportLimit = 20
+
+// ErrReconcileRunning is returned when a reconciliation is requested while one is in progress
+var ErrReconcileRunning = errors.New("a reconciliation with Binance is already running")
+
+// exchangeOrder is an open order as Binance reports it, on either futures market
+type exchangeOrder struct {
+	market models.Market
+	order  *models.FuturesOrder
+	fill   *orderFill
+}
+
+// ReconcileWithBinance brings the local book in line with Binance: open orders missing locally
+// are imported, local open orders Binance no longer knows are closed as CANCELED (EXPIRED once
+// their good-till date has passed) with a note, drifted statuses are corrected and the stored
+// positions are refreshed. COIN-M is included when it is available. The outcome is stored as a
+// reconciliation report and returned.
+func (s *TradingService) ReconcileWithBinance(ctx context.Context, trigger string) (*models.ReconciliationReport, error) {
+	if !s.reconcileMu.TryLock() {
+		return nil, ErrReconcileRunning
+	}
+	defer s.reconcileMu.Unlock()
+
+	report := &models.ReconciliationReport{Trigger: trigger, StartedAt: time.Now()}
+	markets := []models.Market{models.MarketUSDM}
+	if s.checkCoinM() == nil {
+		markets = append(markets, models.MarketCoinM)
+	}
+
+	for _, market := range markets {
+		open, err := s.exchangeOpenOrders(ctx, market)
+		if err != nil {
+			// Without Binance's open orders nothing local can be judged stale
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: failed to list open orders: %v", market, err))
+			continue
+		}
+		s.reconcileMarketOrders(ctx, market, open, report)
+	}
+
+	for _, market := range markets {
+		summary, err := s.SyncPositionsFromBinance(ctx, market)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: failed to refresh positions: %v", market, err))
+			continue
+		}
+		report.Positions = append(report.Positions, models.ReconciledPositions{
+			Market:   market,
+			Upserted: summary.Upserted,
+			Cleared:  summary.Cleared,
+			Skipped:  summary.Skipped,
+		})
+		for _, e := range summary.Errors {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %s", market, e))
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	if err := s.repos.Reconcile.Insert(ctx, report); err != nil {
+		return report, fmt.Errorf("failed to save reconciliation report: %w", err)
+	}
+	return report, nil
+}
+
+// reconcileMarketOrders compares the local open orders of a market with Binance's open orders,
+// keyed by Binance order ID, and records every change on the report
+func (s *TradingService) reconcileMarketOrders(ctx context.Context, market models.Market, open map[int64]*exchangeOrder, report *models.ReconciliationReport) {
+	var local []*models.FuturesOrder
+	err := s.repos.FuturesOrders.EachOpen(ctx, func(order *models.FuturesOrder) error {
+		if marketOf(order.Market) == market {
+			local = append(local, order)
+		}
+		return nil
+	})
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: failed to load local open orders: %v", market, err))
+		return
+	}
+
+	known := make(map[int64]bool, len(local))
+	for _, order := range local {
+		known[order.BinanceOrderID] = true
+		report.OrdersChecked++
+		now := time.Now()
+
+		var fill *orderFill
+		if live, ok := open[order.BinanceOrderID]; ok {
+			fill = live.fill
+		} else {
+			live, err := s.liveOrderFill(ctx, market, order.Symbol, order.BinanceOrderID)
+			if binance.IsOrderNotFound(err) {
+				s.closeUnknownOrder(ctx, order, report)
+				continue
+			}
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s %d: failed to fetch order: %v", order.Symbol, order.BinanceOrderID, err))
+				continue
+			}
+			fill = live
+		}
+
+		// A fill reporting less than is stored is older than the user data stream's; keep the stored one
+		changed := fill.changed(order) && !fill.stale(order)
+		if changed {
+			update := fill.update(now)
+			update.ReconciledAt = now
+			updated, err := s.repos.FuturesOrders.UpdateFill(ctx, order.BinanceOrderID, "", update)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s %d: failed to update order: %v", order.Symbol, order.BinanceOrderID, err))
+				continue
+			}
+			changed = updated
+		}
+		if !changed {
+			if _, err := s.repos.FuturesOrders.UpdateByRef(ctx, order.BinanceOrderID, "", &repository.FuturesOrderUpdate{ReconciledAt: now}); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s %d: failed to update order: %v", order.Symbol, order.BinanceOrderID, err))
+				continue
+			}
+		}
+		if changed && fill.Status != order.Status {
+			report.Updated = append(report.Updated, reconciledOrder(order, market, fill.Status, ""))
+		}
+	}
+
+	for id, live := range open {
+		if !known[id] {
+			s.importExchangeOrder(ctx, live, report)
+		}
+	}
+}
+
+// closeUnknownOrder closes a local open order that Binance has no record of
+func (s *TradingService) closeUnknownOrder(ctx context.Context, order *models.FuturesOrder, report *models.ReconciliationReport) {
+	now := time.Now()
+	status := string(futures.OrderStatusTypeCanceled)
+	if order.GoodTillDate != nil && order.GoodTillDate.Before(now) {
+		status = string(futures.OrderStatusTypeExpired)
+	}
+	note := fmt.Sprintf("unknown to Binance at %s reconciliation", report.Trigger)
+
+	missing := true
+	update := &repository.FuturesOrderUpdate{
+		Status:            status,
+		MissingOnExchange: &missing,
+		ReconcileNote:     note,
+		ReconciledAt:      now,
+		UpdatedAt:         now,
+	}
+	if _, err := s.repos.FuturesOrders.UpdateByRef(ctx, order.BinanceOrderID, "", update); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s %d: failed to close order: %v", order.Symbol, order.BinanceOrderID, err))
+		return
+	}
+	report.Closed = append(report.Closed, reconciledOrder(order, marketOf(order.Market), status, note))
+}
+
+// importExchangeOrder stores a Binance open order the local book does not list as open. An order
+// stored earlier (e.g. flagged missing or closed locally) is reopened with Binance's status instead.
+func (s *TradingService) importExchangeOrder(ctx context.Context, live *exchangeOrder, report *models.ReconciliationReport) {
+	order := live.order
+	now := time.Now()
+
+	existing, err := s.repos.FuturesOrders.FindByBinanceID(ctx, order.BinanceOrderID)
+	switch {
+	case err == nil:
+		note := fmt.Sprintf("open on Binance at %s reconciliation", report.Trigger)
+		missing := false
+		update := &repository.FuturesOrderUpdate{
+			Status:            order.Status,
+			MissingOnExchange: &missing,
+			ReconcileNote:     note,
+			ReconciledAt:      now,
+			UpdatedAt:         now,
+		}
+		if _, err := s.repos.FuturesOrders.UpdateByRef(ctx, order.BinanceOrderID, "", update); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s %d: failed to reopen order: %v", order.Symbol, order.BinanceOrderID, err))
+			return
+		}
+		report.Updated = append(report.Updated, reconciledOrder(existing, live.market, order.Status, note))
+		return
+	case !errors.Is(err, repository.ErrNotFound):
+		report.Errors = append(report.Errors, fmt.Sprintf("%s %d: failed to look up order: %v", order.Symbol, order.BinanceOrderID, err))
+		return
+	}
+
+	order.ReconcileNote = fmt.Sprintf("imported from Binance at %s reconciliation", report.Trigger)
+	order.ReconciledAt = &now
+	if _, err := s.saveFuturesOrder(ctx, order); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s %d: failed to import order: %v", order.Symbol, order.BinanceOrderID, err))
+		return
+	}
+	imported := reconciledOrder(order, live.market, order.Status, order.ReconcileNote)
+	imported.From = ""
+	report.Imported = append(report.Imported, imported)
+}
+
+func reconciledOrder(order *models.FuturesOrder, market models.Market, to, note string) models.ReconciledOrder {
+	return models.ReconciledOrder{
+		BinanceOrderID: order.BinanceOrderID,
+		ClientOrderID:  order.ClientOrderID,
+		Symbol:         order.Symbol,
+		Market:         market,
+		From:           order.Status,
+		To:             to,
+		Note:           note,
+	}
+}
+
+// exchangeOpenOrders lists every open order of a market on Binance, keyed by order ID
+func (s *TradingService) exchangeOpenOrders(ctx context.Context, market models.Market) (map[int64]*exchangeOrder, error) {
+	open := make(map[int64]*exchangeOrder)
+	if market == models.MarketCoinM {
+		orders, err := s.api(ctx).ListOpenDeliveryOrders(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range orders {
+			open[o.OrderID] = &exchangeOrder{market: market, order: deliveryOrderModel(o), fill: deliveryOrderFill(o)}
+		}
+		return open, nil
+	}
+
+	orders, err := s.listOpenFuturesOrders(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range orders {
+		open[o.OrderID] = &exchangeOrder{market: market, order: futuresOrderModel(o), fill: futuresOrderFill(o)}
+	}
+	return open, nil
+}
+
+// futuresOrderModel converts a USDⓈ-M order reported by Binance into a stored order; fields
+// Binance does not report, like leverage, are left 0
+func futuresOrderModel(o *futures.Order) *models.FuturesOrder {
+	order := &models.FuturesOrder{
+		Symbol:         o.Symbol,
+		Market:         models.MarketUSDM,
+		Side:           models.OrderSide(o.Side),
+		OrderType:      models.OrderType(o.Type),
+		PositionSide:   models.PositionSide(o.PositionSide),
+		TimeInForce:    models.TimeInForce(o.TimeInForce),
+		WorkingType:    models.WorkingType(o.WorkingType),
+		ReduceOnly:     o.ReduceOnly,
+		ClosePosition:  o.ClosePosition,
+		BinanceOrderID: o.OrderID,
+		ClientOrderID:  o.ClientOrderID,
+		Status:         string(o.Status),
+		CreatedAt:      time.UnixMilli(o.Time),
+		UpdatedAt:      time.UnixMilli(o.UpdateTime),
+	}
+	order.Quantity, _ = strconv.ParseFloat(o.OrigQuantity, 64)
+	order.Price, _ = strconv.ParseFloat(o.Price, 64)
+	order.StopPrice, _ = strconv.ParseFloat(o.StopPrice, 64)
+	order.ActivationPrice, _ = strconv.ParseFloat(o.ActivatePrice, 64)
+	order.CallbackRate, _ = strconv.ParseFloat(o.PriceRate, 64)
+	futuresOrderFill(o).apply(order)
+	return order
+}
+
+// deliveryOrderModel converts a COIN-M order reported by Binance into a stored order
+func deliveryOrderModel(o *delivery.Order) *models.FuturesOrder {
+	order := &models.FuturesOrder{
+		Symbol:         o.Symbol,
+		Market:         models.MarketCoinM,
+		Side:           models.OrderSide(o.Side),
+		OrderType:      models.OrderType(o.Type),
+		PositionSide:   models.PositionSide(o.PositionSide),
+		TimeInForce:    models.TimeInForce(o.TimeInForce),
+		WorkingType:    models.WorkingType(o.WorkingType),
+		ReduceOnly:     o.ReduceOnly,
+		ClosePosition:  o.ClosePosition,
+		BinanceOrderID: o.OrderID,
+		ClientOrderID:  o.ClientOrderID,
+		Status:         string(o.Status),
+		CreatedAt:      time.UnixMilli(o.Time),
+		UpdatedAt:      time.UnixMilli(o.UpdateTime),
+	}
+	order.Quantity, _ = strconv.ParseFloat(o.OrigQuantity, 64)
+	order.Price, _ = strconv.ParseFloat(o.Price, 64)
+	order.StopPrice, _ = strconv.ParseFloat(o.StopPrice, 64)
+	order.ActivationPrice, _ = strconv.ParseFloat(o.ActivatePrice, 64)
+	order.CallbackRate, _ = strconv.ParseFloat(o.PriceRate, 64)
+	deliveryOrderFill(o).apply(order)
+	return order
+}
+
+// ListReconciliationReports returns the latest reconciliation reports, newest first
+func (s *TradingService) ListReconciliationReports(ctx context.Context, limit int64) ([]*models.ReconciliationReport, error) {
+	if limit <= 0 || limit > repository.MaxOrderPageLimit {
+		limit = defaultReconcileReportLimit
+	}
+	reports, err := s.repos.Reconcile.List(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reconciliation reports: %w", err)
+	}
+	return reports, nil
+}
+
+// ReconcileOnStartup runs ReconcileWithBinance before the background strategies resume, so they
+// start from Binance's view of orders and positions. Failures are logged; startup continues.
+func (s *TradingService) ReconcileOnStartup(ctx context.Context) {
+	report, err := s.ReconcileWithBinance(ctx, ReconcileTriggerStartup)
+	if err != nil {
+		slog.Error("startup reconciliation failed", "error", err)
+		if report == nil {
+			return
+		}
+	}
+	slog.Info("startup reconciliation finished",
+		"checked", report.OrdersChecked, "imported", len(report.Imported), "updated", len(report.Updated),
+		"closed", len(report.Closed), "errors", len(report.Errors))
+}
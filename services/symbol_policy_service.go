@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"futures-options/models"
+	"futures-options/repository"
+)
+
+// ErrPolicySymbolNotFound is returned when removing a symbol the policy does not list
+var ErrPolicySymbolNotFound = errors.New("symbol is not in the trading policy")
+
+// SymbolPolicyError reports an order rejected by the symbol whitelist or blacklist
+type SymbolPolicyError struct {
+	Symbol string                  `json:"symbol"`
+	Policy models.SymbolPolicyMode `json:"policy"`
+}
+
+func (e *SymbolPolicyError) Error() string {
+	if e.Policy == models.SymbolPolicyWhitelist {
+		return fmt.Sprintf("trading %s is not allowed: it is not on the symbol whitelist", e.Symbol)
+	}
+	return fmt.Sprintf("trading %s is not allowed: it is on the symbol blacklist", e.Symbol)
+}
+
+// TradingPolicyRequest replaces the symbol policy
+type TradingPolicyRequest struct {
+	Mode    string   `json:"mode"` // off, whitelist or blacklist
+	Symbols []string `json:"symbols"`
+}
+
+// Validate checks the mode; a whitelist must allow at least one symbol
+func (r *TradingPolicyRequest) Validate() error {
+	v := &validator{}
+	v.oneOf("mode", r.Mode, string(models.SymbolPolicyOff), string(models.SymbolPolicyWhitelist), string(models.SymbolPolicyBlacklist))
+	if models.SymbolPolicyMode(r.Mode) == models.SymbolPolicyWhitelist && len(normalizeSymbols(r.Symbols)) == 0 {
+		v.add("symbols", RuleRequired, "must list at least one symbol in whitelist mode")
+	}
+	return v.err()
+}
+
+// PolicySymbolsRequest adds symbols to the policy's list
+type PolicySymbolsRequest struct {
+	Symbols []string `json:"symbols"`
+}
+
+// Validate checks that at least one symbol is given
+func (r *PolicySymbolsRequest) Validate() error {
+	v := &validator{}
+	if len(normalizeSymbols(r.Symbols)) == 0 {
+		v.add("symbols", RuleRequired, "must list at least one symbol")
+	}
+	return v.err()
+}
+
+// normalizeSymbols upper cases and trims symbols, dropping blanks and duplicates
+func normalizeSymbols(symbols []string) []string {
+	out := make([]string, 0, len(symbols))
+	seen := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		symbol = normalizeRiskSymbol(symbol)
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		out = append(out, symbol)
+	}
+	return out
+}
+
+// GetTradingPolicy returns the symbol policy; it is off when none was saved
+func (s *TradingService) GetTradingPolicy(ctx context.Context) (*models.TradingPolicy, error) {
+	policy, err := s.repos.Policy.Get(ctx)
+	if errors.Is(err, repository.ErrNotFound) {
+		return &models.TradingPolicy{ID: models.TradingPolicyID, Mode: models.SymbolPolicyOff, Symbols: []string{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trading policy: %w", err)
+	}
+	return policy, nil
+}
+
+// SetTradingPolicy replaces the symbol policy. It applies to the next order.
+func (s *TradingService) SetTradingPolicy(ctx context.Context, req *TradingPolicyRequest) (*models.TradingPolicy, error) {
+	start := time.Now()
+	policy := &models.TradingPolicy{
+		Mode:      models.SymbolPolicyMode(req.Mode),
+		Symbols:   normalizeSymbols(req.Symbols),
+		UpdatedBy: PrincipalFromContext(ctx),
+		UpdatedAt: start,
+	}
+	err := s.repos.Policy.Save(ctx, policy)
+	if err != nil {
+		err = fmt.Errorf("failed to save trading policy: %w", err)
+	}
+	s.recordAudit(ctx, models.AuditTradingPolicy, "", req, policy, err, start)
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// AddPolicySymbols adds symbols to the policy's list, keeping its mode
+func (s *TradingService) AddPolicySymbols(ctx context.Context, req *PolicySymbolsRequest) (*models.TradingPolicy, error) {
+	start := time.Now()
+	policy, err := s.repos.Policy.AddSymbols(ctx, normalizeSymbols(req.Symbols), PrincipalFromContext(ctx), start)
+	if err != nil {
+		err = fmt.Errorf("failed to update trading policy: %w", err)
+	}
+	s.recordAudit(ctx, models.AuditTradingPolicy, "", req, policy, err, start)
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// RemovePolicySymbol removes a symbol from the policy's list
+func (s *TradingService) RemovePolicySymbol(ctx context.Context, symbol string) error {
+	start := time.Now()
+	symbol = normalizeRiskSymbol(symbol)
+	removed, err := s.repos.Policy.RemoveSymbol(ctx, symbol, PrincipalFromContext(ctx), start)
+	if err != nil {
+		err = fmt.Errorf("failed to update trading policy: %w", err)
+	} else if !removed {
+		err = ErrPolicySymbolNotFound
+	}
+	s.recordAudit(ctx, models.AuditTradingPolicy, symbol, map[string]string{"remove": symbol}, nil, err, start)
+	return err
+}
+
+// checkSymbolPolicy rejects orders on symbols the trading policy does not allow. It is read on
+// every order, so changes apply immediately. Callers leave out reduce-only and close-position
+// orders, so a position on a symbol that was blacklisted since can still be closed.
+func (s *TradingService) checkSymbolPolicy(ctx context.Context, symbols ...string) error {
+	if len(symbols) == 0 {
+		return nil
+	}
+	policy, err := s.GetTradingPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	if policy.Mode != models.SymbolPolicyWhitelist && policy.Mode != models.SymbolPolicyBlacklist {
+		return nil
+	}
+	listed := make(map[string]bool, len(policy.Symbols))
+	for _, symbol := range policy.Symbols {
+		listed[symbol] = true
+	}
+	for _, symbol := range symbols {
+		symbol = normalizeRiskSymbol(symbol)
+		if listed[symbol] != (policy.Mode == models.SymbolPolicyWhitelist) {
+			return &SymbolPolicyError{Symbol: symbol, Policy: policy.Mode}
+		}
+	}
+	return nil
+}
+
+// openingSymbols returns the symbols of the orders that may add exposure
+func openingSymbols(orders ...*AdvancedOrderRequest) []string {
+	var symbols []string
+	for _, o := range orders {
+		if !o.ReduceOnly && !o.ClosePosition {
+			symbols = append(symbols, o.Symbol)
+		}
+	}
+	return symbols
+}
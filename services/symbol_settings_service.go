@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/logging"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// ErrSymbolSettingsNotFound is returned when a symbol has no stored settings
+var ErrSymbolSettingsNotFound = errors.New("symbol settings not found")
+
+// settingsTimeInForces are the time in force values a symbol may default to; GTD needs a date
+// per order
+var settingsTimeInForces = []string{
+	string(models.TimeInForceGTC), string(models.TimeInForceIOC), string(models.TimeInForceFOK),
+	string(models.TimeInForceGTX),
+}
+
+// SymbolSettingsRequest sets a symbol's order defaults; a field left empty has no default
+type SymbolSettingsRequest struct {
+	// Leverage and MarginType are applied on Binance when the settings are saved. The margin type
+	// is a setting of the symbol on Binance rather than of each order, so it is not merged into
+	// orders; orders placed on the symbol use it from then on.
+	Leverage     int    `json:"leverage,omitempty"`
+	MarginType   string `json:"margin_type,omitempty"`   // ISOLATED or CROSSED
+	WorkingType  string `json:"working_type,omitempty"`  // MARK_PRICE or CONTRACT_PRICE
+	PositionSide string `json:"position_side,omitempty"` // LONG or SHORT; hedge mode only
+	TimeInForce  string `json:"time_in_force,omitempty"` // GTC, IOC, FOK or GTX
+}
+
+// Validate checks the request fields
+func (r *SymbolSettingsRequest) Validate() error {
+	v := &validator{}
+	r.MarginType = strings.ToUpper(r.MarginType)
+	r.WorkingType = strings.ToUpper(r.WorkingType)
+	r.PositionSide = strings.ToUpper(r.PositionSide)
+	r.TimeInForce = strings.ToUpper(r.TimeInForce)
+	v.leverage("leverage", r.Leverage)
+	v.oneOf("margin_type", r.MarginType, marginTypes...)
+	v.oneOf("working_type", r.WorkingType, workingTypes...)
+	v.oneOf("position_side", r.PositionSide, positionSides...)
+	v.oneOf("time_in_force", r.TimeInForce, settingsTimeInForces...)
+	if r.Leverage == 0 && r.MarginType == "" && r.WorkingType == "" && r.PositionSide == "" && r.TimeInForce == "" {
+		v.add("leverage", RuleRequired, "or margin_type, working_type, position_side or time_in_force must be set")
+	}
+	return v.err()
+}
+
+// MarginTypeRequest sets the margin type of a USDⓈ-M symbol
+type MarginTypeRequest struct {
+	Symbol     string `json:"symbol"`
+	MarginType string `json:"margin_type"` // ISOLATED or CROSSED
+}
+
+// Validate checks the symbol and margin type
+func (r *MarginTypeRequest) Validate() error {
+	v := &validator{}
+	r.MarginType = strings.ToUpper(r.MarginType)
+	v.required("symbol", r.Symbol)
+	v.required("margin_type", r.MarginType)
+	v.oneOf("margin_type", r.MarginType, marginTypes...)
+	return v.err()
+}
+
+// ChangeMarginType sets a USDⓈ-M symbol's margin type on Binance and, when the symbol has stored
+// settings, records it there
+func (s *TradingService) ChangeMarginType(ctx context.Context, req *MarginTypeRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	if s.Paper() && req.MarginType != string(futures.MarginTypeCrossed) {
+		return fmt.Errorf("isolated margin is %w", ErrPaperUnsupported)
+	}
+	start := time.Now()
+	err := s.api(ctx).ChangeMarginType(ctx, req.Symbol, req.MarginType)
+	s.recordAudit(ctx, models.AuditMarginTypeChange, req.Symbol, req, nil, err, start)
+	if err != nil {
+		return err
+	}
+	s.syncSymbolSettings(ctx, req.Symbol, &repository.SymbolSettingsUpdate{MarginType: req.MarginType})
+	return nil
+}
+
+// syncSymbolSettings records a leverage or margin type just applied on Binance in symbol's
+// settings. A symbol without settings is left without; a failed write is only logged, since
+// the change itself went through.
+func (s *TradingService) syncSymbolSettings(ctx context.Context, symbol string, update *repository.SymbolSettingsUpdate) {
+	update.UpdatedAt = time.Now()
+	_, err := s.repos.Settings.Update(ctx, normalizeSettingsSymbol(symbol), update)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		logging.FromContext(ctx).Warn("failed to sync symbol settings", "symbol", symbol, "error", err)
+	}
+}
+
+// ListSymbolSettings returns the settings of every symbol that has them
+func (s *TradingService) ListSymbolSettings(ctx context.Context) ([]*models.SymbolSettings, error) {
+	settings, err := s.repos.Settings.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list symbol settings: %w", err)
+	}
+	return settings, nil
+}
+
+// GetSymbolSettings returns the settings stored for symbol
+func (s *TradingService) GetSymbolSettings(ctx context.Context, symbol string) (*models.SymbolSettings, error) {
+	settings, err := s.repos.Settings.FindBySymbol(ctx, normalizeSettingsSymbol(symbol))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSymbolSettingsNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get symbol settings: %w", err)
+	}
+	return settings, nil
+}
+
+// SetSymbolSettings creates or replaces symbol's settings. The leverage is checked against the
+// symbol's leverage brackets before anything is applied. The margin type and then the leverage
+// are applied on Binance, for the request's account, and the settings are not saved if Binance
+// refuses either; the margin type cannot change while the symbol has a position or open orders.
+// A leverage Binance refuses after the margin type changed leaves the new margin type in place.
+func (s *TradingService) SetSymbolSettings(ctx context.Context, symbol string, req *SymbolSettingsRequest) (*models.SymbolSettings, error) {
+	symbol = normalizeSettingsSymbol(symbol)
+	if _, err := s.api(ctx).GetSymbolFilters(ctx, symbol); errors.Is(err, binance.ErrUnknownSymbol) {
+		v := &validator{}
+		v.add("symbol", RuleEnum, "is not a listed futures symbol")
+		return nil, v.err()
+	} else if err != nil {
+		return nil, err
+	}
+	if req.Leverage > 0 && !s.Paper() {
+		if err := s.checkBracketLeverage(ctx, symbol, req.Leverage); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.MarginType != "" {
+		if err := s.ChangeMarginType(ctx, &MarginTypeRequest{Symbol: symbol, MarginType: req.MarginType}); err != nil {
+			return nil, err
+		}
+	}
+	if req.Leverage > 0 {
+		if err := s.ChangeLeverage(ctx, &LeverageRequest{Symbol: symbol, Leverage: req.Leverage}); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	settings := &models.SymbolSettings{
+		Symbol: symbol,
+		SymbolDefaults: models.SymbolDefaults{
+			Leverage:     req.Leverage,
+			WorkingType:  models.WorkingType(req.WorkingType),
+			PositionSide: models.PositionSide(req.PositionSide),
+			TimeInForce:  models.TimeInForce(req.TimeInForce),
+		},
+		MarginType: req.MarginType,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.repos.Settings.Upsert(ctx, settings); err != nil {
+		return nil, fmt.Errorf("failed to save symbol settings: %w", err)
+	}
+	return settings, nil
+}
+
+// checkBracketLeverage rejects a leverage above the highest one symbol's leverage brackets allow
+func (s *TradingService) checkBracketLeverage(ctx context.Context, symbol string, leverage int) error {
+	brackets, err := s.api(ctx).GetLeverageBrackets(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get leverage brackets: %w", err)
+	}
+	maxLeverage := 0
+	for _, b := range brackets {
+		maxLeverage = max(maxLeverage, b.InitialLeverage)
+	}
+	if leverage > maxLeverage {
+		v := &validator{}
+		v.add("leverage", RuleRange, fmt.Sprintf("must be at most %d for %s", maxLeverage, symbol))
+		return v.err()
+	}
+	return nil
+}
+
+// DeleteSymbolSettings removes symbol's settings; its leverage and margin type on Binance stay
+// as they are
+func (s *TradingService) DeleteSymbolSettings(ctx context.Context, symbol string) error {
+	deleted, err := s.repos.Settings.Delete(ctx, normalizeSettingsSymbol(symbol))
+	if err != nil {
+		return fmt.Errorf("failed to delete symbol settings: %w", err)
+	}
+	if !deleted {
+		return ErrSymbolSettingsNotFound
+	}
+	return nil
+}
+
+func normalizeSettingsSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+// symbolSettings returns symbol's settings, or nil when it has none
+func (s *TradingService) symbolSettings(ctx context.Context, symbol string) (*models.SymbolSettings, error) {
+	settings, err := s.repos.Settings.FindBySymbol(ctx, normalizeSettingsSymbol(symbol))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load symbol settings: %w", err)
+	}
+	return settings, nil
+}
+
+// applySymbolDefaults fills the fields req leaves empty from its symbol's settings and notes
+// them in req.AppliedDefaults. The working type only applies to trigger orders and the time in
+// force to LIMIT orders. Grid, DCA and spread orders are configured by their strategy and get
+// no defaults.
+func (s *TradingService) applySymbolDefaults(ctx context.Context, req *AdvancedOrderRequest) error {
+	switch req.Source {
+	case models.OrderSourceGrid, models.OrderSourceDCA, models.OrderSourceSpread:
+		return nil
+	}
+	settings, err := s.symbolSettings(ctx, req.Symbol)
+	if err != nil || settings == nil {
+		return err
+	}
+
+	applied := models.SymbolDefaults{}
+	if req.Leverage == 0 && settings.Leverage > 0 {
+		req.Leverage = settings.Leverage
+		applied.Leverage = settings.Leverage
+	}
+	if req.WorkingType == "" && settings.WorkingType != "" && triggerOrderType(req.OrderType) {
+		req.WorkingType = string(settings.WorkingType)
+		applied.WorkingType = settings.WorkingType
+	}
+	if req.PositionSide == "" && settings.PositionSide != "" {
+		req.PositionSide = string(settings.PositionSide)
+		applied.PositionSide = settings.PositionSide
+	}
+	if req.TimeInForce == "" && settings.TimeInForce != "" && req.OrderType == string(models.OrderTypeLimit) {
+		req.TimeInForce = string(settings.TimeInForce)
+		applied.TimeInForce = settings.TimeInForce
+	}
+	if applied != (models.SymbolDefaults{}) {
+		req.AppliedDefaults = &applied
+	}
+	return nil
+}
+
+// triggerOrderType reports whether orderType triggers on a price, which the working type selects
+func triggerOrderType(orderType string) bool {
+	switch models.OrderType(orderType) {
+	case models.OrderTypeStop, models.OrderTypeStopMarket, models.OrderTypeStopLimit, models.OrderTypeTakeProfit,
+		models.OrderTypeTakeProfitMarket, models.OrderTypeTrailingStopMarket:
+		return true
+	}
+	return false
+}
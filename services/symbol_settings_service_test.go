@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func TestSetSymbolSettingsChecksLeverageBeforeApplying(t *testing.T) {
+	s, mock, repos := newTestService(t)
+	ctx := context.Background()
+	mock.GetLeverageBracketsFunc = func(ctx context.Context, symbol string) ([]futures.Bracket, error) {
+		return []futures.Bracket{{Bracket: 1, InitialLeverage: 20, NotionalCap: 50000}, {Bracket: 2, InitialLeverage: 10, NotionalFloor: 50000, NotionalCap: 250000}}, nil
+	}
+
+	_, err := s.SetSymbolSettings(ctx, "ethusdt", &SymbolSettingsRequest{Leverage: 50, MarginType: "ISOLATED"})
+	if rules := fieldRules(t, err); rules["leverage"] != RuleRange {
+		t.Fatalf("rules = %v, want a leverage range error", rules)
+	}
+	for _, call := range []string{"ChangeMarginType", "ChangeLeverage"} {
+		if n := len(mock.CallsTo(call)); n != 0 {
+			t.Errorf("%s called %d times for a leverage above the brackets", call, n)
+		}
+	}
+	if _, err := repos.Settings.FindBySymbol(ctx, "ETHUSDT"); err == nil {
+		t.Error("settings saved for a refused leverage")
+	}
+
+	settings, err := s.SetSymbolSettings(ctx, "ethusdt", &SymbolSettingsRequest{Leverage: 20, MarginType: "ISOLATED"})
+	if err != nil {
+		t.Fatalf("SetSymbolSettings: %v", err)
+	}
+	if settings.Leverage != 20 || settings.MarginType != "ISOLATED" {
+		t.Errorf("saved leverage %d margin type %q", settings.Leverage, settings.MarginType)
+	}
+
+	// A later leverage change is synced into the stored settings
+	if err := s.ChangeLeverage(ctx, &LeverageRequest{Symbol: "ETHUSDT", Leverage: 5}); err != nil {
+		t.Fatalf("ChangeLeverage: %v", err)
+	}
+	stored, err := repos.Settings.FindBySymbol(ctx, "ETHUSDT")
+	if err != nil || stored.Leverage != 5 || stored.MarginType != "ISOLATED" {
+		t.Errorf("stored settings = %+v, %v; want leverage 5 and the margin type kept", stored, err)
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/notifications"
+)
+
+// symbolTrading is the exchange info status of a symbol open for trading
+const symbolTrading = "TRADING"
+
+// SymbolStatusError reports an order on a symbol the exchange info lists as not open for trading,
+// e.g. a contract that is SETTLING or a spot pair on BREAK
+type SymbolStatusError struct {
+	Symbol string `json:"symbol"`
+	Status string `json:"status"`
+}
+
+func (e *SymbolStatusError) Error() string {
+	return fmt.Sprintf("%s is not open for trading: its status is %s", e.Symbol, e.Status)
+}
+
+// symbolHalts holds the strategy symbols last seen outside TRADING, with their status
+type symbolHalts struct {
+	mu     sync.Mutex
+	status map[string]string
+}
+
+// symbolOpen reports whether the exchange info lists f's symbol as open for trading. Entries
+// without a status (e.g. from the paper trading engine) count as open.
+func symbolOpen(f *binance.SymbolFilters) bool {
+	return f.Status == "" || f.Status == symbolTrading
+}
+
+// checkSymbolStatus rejects orders on USDⓈ-M symbols the cached exchange info lists as not open for
+// trading. Unknown symbols and exchange info that cannot be loaded are left to the order's own checks.
+func (s *TradingService) checkSymbolStatus(ctx context.Context, symbols ...string) error {
+	for _, symbol := range symbols {
+		filters, err := s.api(ctx).GetSymbolFilters(ctx, strings.ToUpper(symbol))
+		if err != nil {
+			continue
+		}
+		if !symbolOpen(filters) {
+			return &SymbolStatusError{Symbol: filters.Symbol, Status: filters.Status}
+		}
+	}
+	return nil
+}
+
+// symbolHalted reports whether the last strategy symbol check found symbol outside TRADING
+func (s *TradingService) symbolHalted(symbol string) bool {
+	s.halts.mu.Lock()
+	defer s.halts.mu.Unlock()
+	_, halted := s.halts.status[symbol]
+	return halted
+}
+
+// strategyPaused reports whether strategy runners must hold off placing orders on symbol: while
+// trading is paused or the symbol is not open for trading
+func (s *TradingService) strategyPaused(ctx context.Context, symbol string) bool {
+	return s.tradingPaused(ctx) || s.symbolHalted(symbol)
+}
+
+// checkStrategySymbols looks up the status of the symbols of running grids and open DCA plans in
+// the cached exchange info. Their runners pause while a symbol is outside TRADING and resume when
+// it returns; each change is recorded as a risk event and notified.
+func (s *TradingService) checkStrategySymbols(ctx context.Context) {
+	symbols := make(map[string]bool)
+	grids, err := s.repos.Grids.List(ctx, models.GridRunning)
+	if err != nil {
+		slog.Error("failed to load grid strategies", "error", err)
+		return
+	}
+	for _, st := range grids {
+		symbols[st.Symbol] = true
+	}
+	for _, status := range []models.DCAStatus{models.DCAActive, models.DCAPaused, models.DCACapped} {
+		plans, err := s.repos.DCAPlans.List(ctx, status)
+		if err != nil {
+			slog.Error("failed to load DCA plans", "error", err)
+			return
+		}
+		for _, p := range plans {
+			symbols[p.Symbol] = true
+		}
+	}
+
+	s.halts.mu.Lock()
+	if s.halts.status == nil {
+		s.halts.status = make(map[string]string)
+	}
+	// Symbols no strategy trades any more are forgotten
+	for symbol := range s.halts.status {
+		if !symbols[symbol] {
+			delete(s.halts.status, symbol)
+		}
+	}
+	s.halts.mu.Unlock()
+
+	for symbol := range symbols {
+		filters, err := s.binanceClient.GetSymbolFilters(ctx, symbol)
+		if err != nil {
+			continue
+		}
+		status := filters.Status
+		if status == "" {
+			status = symbolTrading
+		}
+
+		s.halts.mu.Lock()
+		previous, halted := s.halts.status[symbol]
+		if !halted {
+			previous = symbolTrading
+		}
+		if status == symbolTrading {
+			delete(s.halts.status, symbol)
+		} else {
+			s.halts.status[symbol] = status
+		}
+		s.halts.mu.Unlock()
+
+		if status != previous {
+			s.recordSymbolStatus(ctx, symbol, previous, status)
+		}
+	}
+}
+
+// recordSymbolStatus saves a strategy symbol's status change as a risk event and notifies it
+func (s *TradingService) recordSymbolStatus(ctx context.Context, symbol, previous, status string) {
+	eventType := models.RiskEventSymbolHalted
+	title := "Strategies paused on " + symbol
+	message := fmt.Sprintf("%s changed from %s to %s; grid and DCA orders on it are paused until it is TRADING again", symbol, previous, status)
+	if status == symbolTrading {
+		eventType = models.RiskEventSymbolResumed
+		title = "Strategies resumed on " + symbol
+		message = fmt.Sprintf("%s is TRADING again after %s; grid and DCA orders on it resume", symbol, previous)
+	}
+	slog.Warn("strategy symbol status changed", "symbol", symbol, "previous_status", previous, "status", status)
+
+	now := time.Now()
+	event := &models.RiskEvent{
+		Type:           eventType,
+		Symbol:         symbol,
+		Status:         status,
+		PreviousStatus: previous,
+		EventTime:      now,
+		CreatedAt:      now,
+	}
+	if err := s.repos.RiskEvents.Insert(ctx, event); err != nil {
+		slog.Error("failed to save risk event", "symbol", symbol, "error", err)
+	}
+
+	s.notify(ctx, &notifications.Notification{
+		Title:     title,
+		Message:   message,
+		Priority:  notifications.PriorityHigh,
+		EventType: notifications.EventSymbolStatus,
+		Fields: map[string]string{
+			"symbol":          symbol,
+			"status":          status,
+			"previous_status": previous,
+		},
+	})
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"futures-options/logging"
+	"futures-options/models"
+	"futures-options/repository"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrTemplateNotFound is returned when no order template has the name
+	ErrTemplateNotFound = errors.New("order template not found")
+	// ErrTemplateExists is returned when creating a template under a name already in use
+	ErrTemplateExists = errors.New("order template already exists")
+)
+
+// templateNamePattern limits template names to what reads well in a URL path
+var templateNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// OrderTemplateRequest creates or updates a named order template. The order's quantity (or quote
+// quantity), price, stop price and callback rate may be left 0 as placeholders to be supplied at
+// execution.
+// Templates are USDⓈ-M only and cannot fix a client order ID, since each execution needs a new one.
+type OrderTemplateRequest struct {
+	Name  string               `json:"name,omitempty"` // required on create; taken from the path on update
+	Order AdvancedOrderRequest `json:"order"`
+}
+
+// Validate checks the template's order as it would be executed, with its placeholders filled in
+func (r *OrderTemplateRequest) Validate() error {
+	v := &validator{}
+	if r.Name != "" && !templateNamePattern.MatchString(r.Name) {
+		v.add("name", RuleType, "must be 1 to 64 letters, digits, '.', '_' or '-'")
+	}
+	order := r.Order
+	if order.Quantity.IsZero() && order.QuoteQuantity == 0 {
+		order.Quantity = decimal.NewFromInt(1)
+	}
+	if order.Price.IsZero() {
+		order.Price = decimal.NewFromInt(1)
+	}
+	if order.StopPrice.IsZero() {
+		order.StopPrice = decimal.NewFromInt(1)
+	}
+	if order.CallbackRate == 0 {
+		order.CallbackRate = 1
+	}
+	order.validate(v, "order.")
+	v.oneOf("order.market", r.Order.Market, string(models.MarketUSDM))
+	if r.Order.ClientOrderID != "" {
+		v.add("order.client_order_id", RuleRange, "cannot be fixed in a template; pass it when executing")
+	}
+	return v.err()
+}
+
+// ExecuteTemplateRequest supplies the values a template leaves open. Each non-zero field replaces
+// the template's.
+type ExecuteTemplateRequest struct {
+	Quantity        decimal.Decimal `json:"quantity"`
+	QuoteQuantity   float64         `json:"quote_quantity,omitempty"` // replaces the template's quantity or quote quantity
+	Price           decimal.Decimal `json:"price"`
+	StopPrice       decimal.Decimal `json:"stop_price"`
+	ActivationPrice decimal.Decimal `json:"activation_price"`
+	CallbackRate    float64         `json:"callback_rate,omitempty"`
+	Leverage        int             `json:"leverage,omitempty"`
+	ClientOrderID   string          `json:"client_order_id,omitempty"`
+	// OverrideRiskLimits skips the position limits; only allowed for RISK_OVERRIDE_PRINCIPALS
+	OverrideRiskLimits bool `json:"override_risk_limits,omitempty"`
+}
+
+// UnmarshalJSON takes the quote quantity and callback rate as JSON numbers or decimal strings; the
+// quantity and prices are decimals
+func (r *ExecuteTemplateRequest) UnmarshalJSON(data []byte) error {
+	type plain ExecuteTemplateRequest
+	return decodeDecimalFields(data, (*plain)(r))
+}
+
+// Validate checks the overrides; the merged order is validated again before it is placed
+func (r *ExecuteTemplateRequest) Validate() error {
+	v := &validator{}
+	v.nonNegativeDecimal("quantity", r.Quantity)
+	v.nonNegative("quote_quantity", r.QuoteQuantity)
+	if r.Quantity.IsPositive() && r.QuoteQuantity > 0 {
+		v.add("quantity", RuleRange, "cannot be combined with quote_quantity")
+	}
+	v.nonNegativeDecimal("price", r.Price)
+	v.nonNegativeDecimal("stop_price", r.StopPrice)
+	v.nonNegativeDecimal("activation_price", r.ActivationPrice)
+	v.nonNegative("callback_rate", r.CallbackRate)
+	if r.Leverage != 0 {
+		v.leverage("leverage", r.Leverage)
+	}
+	return v.err()
+}
+
+// ListOrderTemplates returns every order template by name
+func (s *TradingService) ListOrderTemplates(ctx context.Context) ([]*models.NamedOrderTemplate, error) {
+	templates, err := s.repos.Templates.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list order templates: %w", err)
+	}
+	return templates, nil
+}
+
+// GetOrderTemplate returns the named order template
+func (s *TradingService) GetOrderTemplate(ctx context.Context, name string) (*models.NamedOrderTemplate, error) {
+	template, err := s.repos.Templates.FindByName(ctx, name)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order template: %w", err)
+	}
+	return template, nil
+}
+
+// CreateOrderTemplate saves a new named order template
+func (s *TradingService) CreateOrderTemplate(ctx context.Context, req *OrderTemplateRequest) (*models.NamedOrderTemplate, error) {
+	if req.Name == "" {
+		v := &validator{}
+		v.add("name", RuleRequired, "is required")
+		return nil, v.err()
+	}
+	if req.Order.OverrideRiskLimits && !s.canOverrideRiskLimits(PrincipalFromContext(ctx)) {
+		return nil, ErrRiskOverrideForbidden
+	}
+
+	now := time.Now()
+	template := &models.NamedOrderTemplate{
+		Name:      req.Name,
+		Order:     orderTemplate(&req.Order),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err := s.repos.Templates.Insert(ctx, template)
+	if errors.Is(err, repository.ErrDuplicate) {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateExists, req.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save order template: %w", err)
+	}
+	return template, nil
+}
+
+// UpdateOrderTemplate replaces the order of the named template
+func (s *TradingService) UpdateOrderTemplate(ctx context.Context, name string, req *OrderTemplateRequest) (*models.NamedOrderTemplate, error) {
+	if req.Name != "" && req.Name != name {
+		v := &validator{}
+		v.add("name", RuleRange, "cannot be changed; create a new template instead")
+		return nil, v.err()
+	}
+	if req.Order.OverrideRiskLimits && !s.canOverrideRiskLimits(PrincipalFromContext(ctx)) {
+		return nil, ErrRiskOverrideForbidden
+	}
+
+	template := &models.NamedOrderTemplate{Name: name, Order: orderTemplate(&req.Order), UpdatedAt: time.Now()}
+	err := s.repos.Templates.Update(ctx, template)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update order template: %w", err)
+	}
+	return template, nil
+}
+
+// DeleteOrderTemplate removes the named template; orders already placed from it keep its name
+func (s *TradingService) DeleteOrderTemplate(ctx context.Context, name string) error {
+	deleted, err := s.repos.Templates.Delete(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete order template: %w", err)
+	}
+	if !deleted {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+// ExecuteOrderTemplate merges the overrides into the named template, validates the result and
+// places it as an advanced futures order tagged with the template name
+func (s *TradingService) ExecuteOrderTemplate(ctx context.Context, name string, overrides *ExecuteTemplateRequest) (*models.FuturesOrder, error) {
+	template, err := s.GetOrderTemplate(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	req := advancedOrderRequest(template.Order)
+	req.Template = template.Name
+	if overrides.Quantity.IsPositive() {
+		req.Quantity, req.QuoteQuantity = overrides.Quantity, 0
+	}
+	if overrides.QuoteQuantity > 0 {
+		req.Quantity, req.QuoteQuantity = decimal.Zero, overrides.QuoteQuantity
+	}
+	if overrides.Price.IsPositive() {
+		req.Price = overrides.Price
+	}
+	if overrides.StopPrice.IsPositive() {
+		req.StopPrice = overrides.StopPrice
+	}
+	if overrides.ActivationPrice.IsPositive() {
+		req.ActivationPrice = overrides.ActivationPrice
+	}
+	if overrides.CallbackRate > 0 {
+		req.CallbackRate = overrides.CallbackRate
+	}
+	if overrides.Leverage > 0 {
+		req.Leverage = overrides.Leverage
+	}
+	req.ClientOrderID = overrides.ClientOrderID
+	req.Source = models.OrderSourceTemplate
+	req.OverrideRiskLimits = req.OverrideRiskLimits || overrides.OverrideRiskLimits
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("executing order template", "template", template.Name, "symbol", req.Symbol)
+	return s.CreateAdvancedFuturesOrder(ctx, req)
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"futures-options/logging"
+	"futures-options/models"
+	"futures-options/notifications"
+	"futures-options/repository"
+)
+
+// ErrTradingPaused is returned for orders that may add exposure while trading is paused
+var ErrTradingPaused = errors.New("trading is paused")
+
+// TradingPauseRequest pauses trading until resumed, or until Until
+type TradingPauseRequest struct {
+	Until  *time.Time `json:"until,omitempty"`
+	Reason string     `json:"reason,omitempty"`
+}
+
+// Validate checks that Until, when given, is in the future
+func (r *TradingPauseRequest) Validate() error {
+	v := &validator{}
+	if r.Until != nil && !r.Until.After(time.Now()) {
+		v.add("until", RuleRange, "must be in the future")
+	}
+	return v.err()
+}
+
+// LoadTradingPause restores the trading pause saved before a restart, so trading stays paused
+func (s *TradingService) LoadTradingPause(ctx context.Context) error {
+	pause, err := s.repos.Policy.GetPause(ctx)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load trading pause: %w", err)
+	}
+
+	s.pauseMu.Lock()
+	s.pause = *pause
+	s.pauseMu.Unlock()
+	if current := s.currentPause(ctx); current.Paused {
+		logging.FromContext(ctx).Warn("trading is paused", "reason", current.Reason, "until", current.Until)
+	}
+	return nil
+}
+
+// GetTradingPause returns whether trading is paused, and why and until when
+func (s *TradingService) GetTradingPause(ctx context.Context) *models.TradingPause {
+	pause := s.currentPause(ctx)
+	return &pause
+}
+
+// PauseTrading blocks orders that may add exposure and pauses the DCA and grid runners until
+// ResumeTrading or req.Until. Cancels and reduce-only or close-position orders still go through.
+// Pausing again replaces the reason and end time.
+func (s *TradingService) PauseTrading(ctx context.Context, req *TradingPauseRequest) (*models.TradingPause, error) {
+	start := time.Now()
+	s.pauseMu.Lock()
+	pause := models.TradingPause{
+		Paused:   true,
+		Reason:   req.Reason,
+		Until:    req.Until,
+		PausedBy: PrincipalFromContext(ctx),
+		PausedAt: &start,
+	}
+	err := s.repos.Policy.SavePause(ctx, &pause)
+	if err == nil {
+		s.pause = pause
+	}
+	s.pauseMu.Unlock()
+
+	if err != nil {
+		err = fmt.Errorf("failed to save trading pause: %w", err)
+	}
+	s.recordAudit(ctx, models.AuditTradingPause, "", req, pause, err, start)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyTradingPause(ctx, pause)
+	return &pause, nil
+}
+
+// ResumeTrading ends the trading pause; it does nothing when trading is not paused
+func (s *TradingService) ResumeTrading(ctx context.Context) (*models.TradingPause, error) {
+	start := time.Now()
+	s.pauseMu.Lock()
+	if !s.pause.Paused {
+		pause := s.pause
+		s.pauseMu.Unlock()
+		return &pause, nil
+	}
+	pause := s.pause
+	pause.Paused = false
+	pause.ResumedBy = PrincipalFromContext(ctx)
+	pause.ResumedAt = &start
+	err := s.repos.Policy.SavePause(ctx, &pause)
+	if err == nil {
+		s.pause = pause
+	}
+	s.pauseMu.Unlock()
+
+	if err != nil {
+		err = fmt.Errorf("failed to save trading pause: %w", err)
+	}
+	s.recordAudit(ctx, models.AuditTradingResume, "", nil, pause, err, start)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyTradingPause(ctx, pause)
+	return &pause, nil
+}
+
+// currentPause returns the trading pause, first ending it if its end time has passed
+func (s *TradingService) currentPause(ctx context.Context) models.TradingPause {
+	s.pauseMu.Lock()
+	if !s.pause.Paused || s.pause.Until == nil || time.Now().Before(*s.pause.Until) {
+		pause := s.pause
+		s.pauseMu.Unlock()
+		return pause
+	}
+
+	start := time.Now()
+	s.pause.Paused = false
+	s.pause.ResumedBy = ""
+	s.pause.ResumedAt = &start
+	// The in-memory pause ends either way; a pause that could not be saved ends again after a restart
+	err := s.repos.Policy.SavePause(ctx, &s.pause)
+	pause := s.pause
+	s.pauseMu.Unlock()
+
+	if err != nil {
+		err = fmt.Errorf("failed to save trading pause: %w", err)
+		logging.FromContext(ctx).Error("failed to record the end of the trading pause", "error", err)
+	}
+	s.recordAudit(ctx, models.AuditTradingResume, "", nil, pause, err, start)
+	s.notifyTradingPause(ctx, pause)
+	return pause
+}
+
+// tradingPaused reports whether trading is paused
+func (s *TradingService) tradingPaused(ctx context.Context) bool {
+	return s.currentPause(ctx).Paused
+}
+
+// checkTradingPause rejects orders while trading is paused. Callers pass the symbols of the
+// orders that may add exposure, so closing orders, which have none, always pass.
+func (s *TradingService) checkTradingPause(ctx context.Context, symbols ...string) error {
+	if len(symbols) == 0 {
+		return nil
+	}
+	pause := s.currentPause(ctx)
+	if !pause.Paused {
+		return nil
+	}
+	err := ErrTradingPaused
+	if pause.Until != nil {
+		err = fmt.Errorf("%w until %s", err, pause.Until.UTC().Format(time.RFC3339))
+	}
+	if pause.Reason != "" {
+		err = fmt.Errorf("%w: %s", err, pause.Reason)
+	}
+	return err
+}
+
+// checkOrderAllowed applies the trading pause and the symbol policy to the symbols of the orders
+// that may add exposure
+func (s *TradingService) checkOrderAllowed(ctx context.Context, symbols ...string) error {
+	if err := s.checkTradingPause(ctx, symbols...); err != nil {
+		return err
+	}
+	return s.checkSymbolPolicy(ctx, symbols...)
+}
+
+func (s *TradingService) notifyTradingPause(ctx context.Context, pause models.TradingPause) {
+	n := &notifications.Notification{
+		Title:     "Trading resumed",
+		Message:   "New orders are accepted again",
+		Priority:  notifications.PriorityHigh,
+		EventType: notifications.EventTradingPause,
+		Fields:    map[string]string{},
+	}
+	switch {
+	case pause.Paused:
+		n.Title = "Trading paused"
+		n.Message = "New orders are rejected until trading is resumed"
+		if pause.Until != nil {
+			n.Message = "New orders are rejected until " + pause.Until.UTC().Format(time.RFC3339)
+		}
+		n.Fields["paused_by"] = pause.PausedBy
+	case pause.ResumedBy == "":
+		n.Message = "The trading pause ended; new orders are accepted again"
+	default:
+		n.Fields["resumed_by"] = pause.ResumedBy
+	}
+	if pause.Reason != "" {
+		n.Fields["reason"] = pause.Reason
+	}
+	logging.FromContext(ctx).Warn(n.Title, "reason", pause.Reason, "until", pause.Until)
+	s.notify(ctx, n)
+}
@@ -2,46 +2,138 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"strconv"
+	"sync"
 	"time"
 
 	"futures-options/binance"
-	"futures-options/database"
+	"futures-options/logging"
 	"futures-options/models"
+	"futures-options/notifications"
+	"futures-options/repository"
+	"futures-options/secrets"
 
 	"github.com/adshao/go-binance/v2/futures"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/shopspring/decimal"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type TradingService struct {
-	binanceClient *binance.Client
-	wsClient      *binance.WebSocketClient
+	binanceClient BinanceAPI
+	repos         *repository.Repositories
+	notifiers     []notifications.Notifier
+	cipher        *secrets.Cipher
+	audit         *AuditLogger
+	webhooks      *WebhookDispatcher
+
+	rawResponseMaxBytes    int
+	riskOverridePrincipals []string
+	marginRatioWarning     float64
+	fundingAlertThreshold  float64
+	clientOrderIDPrefix    string
+	exportTimeout          time.Duration
+	retention              RetentionPolicy
+
+	dailyLossMu sync.Mutex
+	dailyLoss   dailyLossState
+
+	throttle orderThrottle
+
+	incomeSyncMu sync.Mutex
+	reconcileMu  sync.Mutex // one reconciliation with Binance at a time
+
+	equityMu sync.Mutex
+	equity   equityState
+
+	conditional conditionalEngine
+	scheduler   orderScheduler
+	watchlist   watchlistStream
+	jobs        jobScheduler
+
+	dcaMu    sync.Mutex // serializes changes to DCA plans
+	gridMu   sync.Mutex // serializes changes to grid strategies
+	spreadMu sync.Mutex // serializes changes to calendar spreads
+
+	pauseMu sync.Mutex // guards pause
+	pause   models.TradingPause
+	halts   symbolHalts
+
+	openOrders openOrderBook // open orders per account, for the order-cap guard
+
+	credMu   sync.Mutex
+	accounts *binance.Accounts // clients of accounts selected per request; nil in paper trading mode
+	bgCtx    context.Context
+	bgWG     sync.WaitGroup // background goroutines awaited by Shutdown
+
+	streamRestartMu  sync.Mutex   // held while the user data stream is restarted on request
+	stateMu          sync.RWMutex // guards wsClient, credentialSource and activeAccount
+	wsClient         *binance.WebSocketClient
+	credentialSource string
+	activeAccount    string
+
+	wsAPIMu sync.Mutex // guards wsAPI
+	wsAPI   *binance.WSAPIClient
 }
 
-func NewTradingService(binanceClient *binance.Client) *TradingService {
+func NewTradingService(binanceClient BinanceAPI, repos *repository.Repositories) *TradingService {
 	return &TradingService{
 		binanceClient: binanceClient,
+		repos:         repos,
+		scheduler:     orderScheduler{wake: make(chan struct{}, 1)},
+		watchlist:     watchlistStream{changed: make(chan struct{}, 1)},
 	}
 }
 
-// GetAccountStatusWS retrieves account.status via WebSocket API
+// AddNotifier registers a notifier that receives trading and risk alerts
+func (s *TradingService) AddNotifier(n notifications.Notifier) {
+	s.notifiers = append(s.notifiers, n)
+}
+
+// notify pushes a notification through every registered notifier
+func (s *TradingService) notify(ctx context.Context, n *notifications.Notification) {
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	for _, notifier := range s.notifiers {
+		if err := notifier.Notify(ctx, n); err != nil {
+			logging.FromContext(ctx).Warn("notifier failed", "notifier", notifier.Name(), "error", err)
+		}
+	}
+}
+
+// GetAccountStatusWS retrieves account.status via WebSocket API, or the simulated account in paper trading mode
 func (s *TradingService) GetAccountStatusWS(ctx context.Context) (interface{}, error) {
-    ws, err := binance.NewWSAPIClient(s.binanceClient.Config)
-    if err != nil { return nil, fmt.Errorf("failed to connect WS API: %w", err) }
-    defer ws.Close()
+	// The WS API signs with the active credential's keys
+	if err := s.requireActiveAccount(ctx); err != nil {
+		return nil, err
+	}
+	if paper, ok := s.binanceClient.(paperAccount); ok {
+		return paper.AccountStatus(ctx)
+	}
+
+	var result interface{}
+	err := s.binanceClient.Breaker().Do(ctx, func() (err error) {
+		result, err = s.getAccountStatusWS(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (s *TradingService) getAccountStatusWS(ctx context.Context) (interface{}, error) {
+    ws, err := s.wsAPIClient(ctx)
+    if err != nil { return nil, err }
 
     var result interface{}
     params := map[string]interface{}{}
-    apiKey := s.binanceClient.Config.BinanceAPIKey
+    apiKey := s.binanceClient.EffectiveConfig().BinanceAPIKey
     if apiKey == "" {
         // Fallback to DB-stored active credentials
-        var cred struct{ APIKey string `bson:"api_key"` }
-        _ = database.APICredentialsCollection.FindOne(ctx, bson.M{"is_active": true}).Decode(&cred)
-        apiKey = cred.APIKey
+        if cred, err := s.repos.Credentials.FindActive(ctx); err == nil {
+            apiKey = cred.APIKey
+        }
     }
     if apiKey == "" {
         return nil, fmt.Errorf("missing apiKey: set BINANCE_API_KEY or save active credentials via /api/credentials")
@@ -54,19 +146,35 @@ func (s *TradingService) GetAccountStatusWS(ctx context.Context) (interface{}, e
     return result, nil
 }
 
-// GetAccountBalanceWS retrieves account.balance via WebSocket API
+// GetAccountBalanceWS retrieves account.balance via WebSocket API, or the simulated balance in paper trading mode
 func (s *TradingService) GetAccountBalanceWS(ctx context.Context) (interface{}, error) {
-    ws, err := binance.NewWSAPIClient(s.binanceClient.Config)
-    if err != nil { return nil, fmt.Errorf("failed to connect WS API: %w", err) }
-    defer ws.Close()
+	// The WS API signs with the active credential's keys
+	if err := s.requireActiveAccount(ctx); err != nil {
+		return nil, err
+	}
+	if paper, ok := s.binanceClient.(paperAccount); ok {
+		return paper.AccountBalance(ctx)
+	}
+
+	var result interface{}
+	err := s.binanceClient.Breaker().Do(ctx, func() (err error) {
+		result, err = s.getAccountBalanceWS(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (s *TradingService) getAccountBalanceWS(ctx context.Context) (interface{}, error) {
+    ws, err := s.wsAPIClient(ctx)
+    if err != nil { return nil, err }
 
     var result interface{}
     params := map[string]interface{}{}
-    apiKey := s.binanceClient.Config.BinanceAPIKey
+    apiKey := s.binanceClient.EffectiveConfig().BinanceAPIKey
     if apiKey == "" {
-        var cred struct{ APIKey string `bson:"api_key"` }
-        _ = database.APICredentialsCollection.FindOne(ctx, bson.M{"is_active": true}).Decode(&cred)
-        apiKey = cred.APIKey
+        if cred, err := s.repos.Credentials.FindActive(ctx); err == nil {
+            apiKey = cred.APIKey
+        }
     }
     if apiKey == "" {
         return nil, fmt.Errorf("missing apiKey: set BINANCE_API_KEY or save active credentials via /api/credentials")
@@ -81,23 +189,71 @@ func (s *TradingService) GetAccountBalanceWS(ctx context.Context) (interface{},
 
 // CreateFuturesOrder creates a futures order and saves it to MongoDB
 func (s *TradingService) CreateFuturesOrder(ctx context.Context, req *CreateFuturesOrderRequest) (*models.FuturesOrder, error) {
+	// The handler validates too, but an unknown type must never fall through to a resting limit
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkOrderAllowed(ctx, req.Symbol); err != nil {
+		return nil, err
+	}
+	if models.Market(req.Market) == models.MarketCoinM {
+		return s.createCoinMOrder(ctx, &AdvancedOrderRequest{
+			Symbol:             req.Symbol,
+			Side:               req.Side,
+			OrderType:          req.OrderType,
+			Quantity:           req.Quantity,
+			Price:              req.Price,
+			Leverage:           req.Leverage,
+			PositionSide:       req.PositionSide,
+			Market:             req.Market,
+			OverrideRiskLimits: req.OverrideRiskLimits,
+			Source:             models.OrderSourceManual,
+			ClientOrderID:      s.newClientOrderID(models.OrderSourceManual),
+		})
+	}
+	if err := s.checkSymbolStatus(ctx, req.Symbol); err != nil {
+		return nil, err
+	}
+	// Basic orders carry no working type or time in force, and position side is only recorded
+	var applied *models.SymbolDefaults
+	if req.Leverage == 0 {
+		settings, err := s.symbolSettings(ctx, req.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		if settings != nil && settings.Leverage > 0 {
+			req.Leverage = settings.Leverage
+			applied = &models.SymbolDefaults{Leverage: settings.Leverage}
+		}
+	}
+
+	if req.QuoteQuantity > 0 {
+		quantity, err := s.quoteQuantity(ctx, "", req.Symbol, req.OrderType, req.Price, futures.WorkingTypeMarkPrice, req.QuoteQuantity)
+		if err != nil {
+			return nil, err
+		}
+		req.Quantity = quantity
+	}
+
 	// Convert to Binance types
-	var side futures.SideType
+	side := futures.SideTypeSell
 	if req.Side == string(models.OrderSideBuy) {
 		side = futures.SideTypeBuy
-	} else {
-		side = futures.SideTypeSell
 	}
-
-	var orderType futures.OrderType
+	orderType := futures.OrderTypeLimit
 	if req.OrderType == string(models.OrderTypeMarket) {
 		orderType = futures.OrderTypeMarket
-	} else {
-		orderType = futures.OrderTypeLimit
+	}
+
+	order := riskOrder{Symbol: req.Symbol, Side: req.Side, PositionSide: req.PositionSide, Quantity: req.Quantity.InexactFloat64(), Price: req.Price.InexactFloat64()}
+	if err := s.checkRiskLimits(ctx, []riskOrder{order}, req.OverrideRiskLimits); err != nil {
+		return nil, err
 	}
 
 	// Create order on Binance
-	binanceOrder, err := s.binanceClient.CreateFuturesOrder(
+	clientOrderID := s.newClientOrderID(models.OrderSourceManual)
+	start := time.Now()
+	binanceOrder, err := s.api(ctx).CreateFuturesOrder(
 		ctx,
 		req.Symbol,
 		side,
@@ -105,43 +261,54 @@ func (s *TradingService) CreateFuturesOrder(ctx context.Context, req *CreateFutu
 		req.Quantity,
 		req.Price,
 		req.Leverage,
+		clientOrderID,
 	)
+	responded := time.Now()
+	s.recordAudit(ctx, models.AuditOrderCreate, req.Symbol, req, binanceOrder, err, start)
 	if err != nil {
+		s.notifyOrderRejected(ctx, req.Symbol, err)
 		return nil, fmt.Errorf("failed to create order on Binance: %w", err)
 	}
+	logging.FromContext(ctx).Info("futures order placed", "symbol", req.Symbol, "binance_order_id", binanceOrder.OrderID, "status", binanceOrder.Status)
 
 	// Save to MongoDB
 	futuresOrder := &models.FuturesOrder{
 		ID:            primitive.NewObjectID(),
 		Symbol:        req.Symbol,
+		Market:        models.MarketUSDM,
 		Side:          models.OrderSide(req.Side),
 		OrderType:     models.OrderType(req.OrderType),
-		Quantity:      req.Quantity,
-		Price:         req.Price,
+		Quantity:      req.Quantity.InexactFloat64(),
+		QuoteQuantity: req.QuoteQuantity,
+		Price:         req.Price.InexactFloat64(),
 		Leverage:      req.Leverage,
 		PositionSide:  models.PositionSide(req.PositionSide),
+		ClientOrderID: clientOrderID,
+		Source:        models.OrderSourceManual,
+		AppliedDefaults: applied,
 		BinanceOrderID: binanceOrder.OrderID,
 		Status:        string(binanceOrder.Status),
+		RawResponse:   s.rawResponse(binanceOrder),
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
+	createOrderFill(binanceOrder).apply(futuresOrder)
+	stampOrderLatency(ctx, futuresOrder, models.OrderTransportREST, start, responded, binanceOrder.UpdateTime)
 
-	_, err = database.FuturesCollection.InsertOne(ctx, futuresOrder)
-	if err != nil {
-		return nil, fmt.Errorf("failed to save order to database: %w", err)
-	}
-
-	return futuresOrder, nil
+	return s.saveFuturesOrder(ctx, futuresOrder)
 }
 
 // CreateOptionsOrder creates an options order and saves it to MongoDB
 func (s *TradingService) CreateOptionsOrder(ctx context.Context, req *CreateOptionsOrderRequest) (*models.OptionsOrder, error) {
-	// Use Options client - create a config from binance client
-	// For now, create a basic config (this would ideally come from binance.Client)
-	// Note: We'll need to pass config through or store it in Client
-	// Temporary workaround: create options client directly
-	optionsClient := binance.NewOptionsClient(nil) // Will need proper config
-	
+	if s.Paper() {
+		return nil, fmt.Errorf("options trading is %w", ErrPaperUnsupported)
+	}
+	if err := s.checkOrderAllowed(ctx, req.Symbol); err != nil {
+		return nil, err
+	}
+	optionsClient := s.api(ctx).Options()
+
+
 	binanceReq := &binance.OptionsOrderRequest{
 		Symbol:      req.Symbol,
 		Side:        req.Side,
@@ -151,7 +318,9 @@ func (s *TradingService) CreateOptionsOrder(ctx context.Context, req *CreateOpti
 		TimeInForce: "GTC",
 	}
 
+	start := time.Now()
 	binanceOrder, err := optionsClient.CreateOptionsOrder(ctx, binanceReq)
+	s.recordAudit(ctx, models.AuditOrderCreate, req.Symbol, req, binanceOrder, err, start)
 	if err != nil {
 		// If API call fails, save as pending
 		binanceOrder = nil
@@ -162,8 +331,8 @@ func (s *TradingService) CreateOptionsOrder(ctx context.Context, req *CreateOpti
 		Symbol:        req.Symbol,
 		Side:          models.OrderSide(req.Side),
 		OrderType:     models.OrderType(req.OrderType),
-		Quantity:      req.Quantity,
-		Price:         req.Price,
+		Quantity:      req.Quantity.InexactFloat64(),
+		Price:         req.Price.InexactFloat64(),
 		StrikePrice:   req.StrikePrice,
 		ExpiryDate:    req.ExpiryDate,
 		OptionType:    req.OptionType,
@@ -175,19 +344,19 @@ func (s *TradingService) CreateOptionsOrder(ctx context.Context, req *CreateOpti
 	if binanceOrder != nil {
 		optionsOrder.BinanceOrderID = binanceOrder.OrderID
 		optionsOrder.Status = binanceOrder.Status
+		optionsOrder.RawResponse = s.rawResponse(binanceOrder)
+		s.recordOptionsFill(ctx, binanceOrder)
 	}
 
-	_, err = database.OptionsCollection.InsertOne(ctx, optionsOrder)
-	if err != nil {
-		return nil, fmt.Errorf("failed to save order to database: %w", err)
-	}
-
-	return optionsOrder, nil
+	return s.saveOptionsOrder(ctx, optionsOrder)
 }
 
 // GetOptionsPositions gets options positions
 func (s *TradingService) GetOptionsPositions(ctx context.Context) ([]*models.Position, error) {
-	optionsClient := binance.NewOptionsClient(nil) // Will need proper config
+	if s.Paper() {
+		return nil, fmt.Errorf("options trading is %w", ErrPaperUnsupported)
+	}
+	optionsClient := s.api(ctx).Options()
 	binancePositions, err := optionsClient.GetOptionsPositions(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get options positions: %w", err)
@@ -210,196 +379,364 @@ func (s *TradingService) GetOptionsPositions(ctx context.Context) ([]*models.Pos
 	return positions, nil
 }
 
-// GetFuturesOrders retrieves futures orders from MongoDB
-func (s *TradingService) GetFuturesOrders(ctx context.Context, symbol string) ([]*models.FuturesOrder, error) {
-	filter := bson.M{}
-	if symbol != "" {
-		filter["symbol"] = symbol
-	}
-
-	cursor, err := database.FuturesCollection.Find(ctx, filter)
+// GetFuturesOrders retrieves a page of futures orders from MongoDB
+func (s *TradingService) GetFuturesOrders(ctx context.Context, query *OrderQuery) (*FuturesOrderPage, error) {
+	query.AccountID = AccountFromContext(ctx)
+	orders, total, err := s.repos.FuturesOrders.List(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query futures orders: %w", err)
 	}
-	defer cursor.Close(ctx)
 
-	var orders []*models.FuturesOrder
-	if err = cursor.All(ctx, &orders); err != nil {
-		return nil, fmt.Errorf("failed to decode futures orders: %w", err)
+	page := &FuturesOrderPage{Items: orders, Total: total}
+	if int64(len(orders)) > query.PageLimit() {
+		page.Items = orders[:query.PageLimit()]
+		page.NextCursor = page.Items[len(page.Items)-1].ID.Hex()
+	}
+	if query.IncludeJournal {
+		ids := make([]primitive.ObjectID, len(page.Items))
+		for i, o := range page.Items {
+			ids[i] = o.ID
+		}
+		latest, err := s.latestJournalEntries(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range page.Items {
+			o.Journal = latest[o.ID]
+		}
 	}
 
-	return orders, nil
+	return page, nil
 }
 
-// GetOptionsOrders retrieves options orders from MongoDB
-func (s *TradingService) GetOptionsOrders(ctx context.Context, symbol string) ([]*models.OptionsOrder, error) {
-	filter := bson.M{}
-	if symbol != "" {
-		filter["symbol"] = symbol
-	}
-
-	cursor, err := database.OptionsCollection.Find(ctx, filter)
+// GetOptionsOrders retrieves a page of options orders from MongoDB
+func (s *TradingService) GetOptionsOrders(ctx context.Context, query *OrderQuery) (*OptionsOrderPage, error) {
+	query.AccountID = AccountFromContext(ctx)
+	orders, total, err := s.repos.OptionsOrders.List(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query options orders: %w", err)
 	}
-	defer cursor.Close(ctx)
 
-	var orders []*models.OptionsOrder
-	if err = cursor.All(ctx, &orders); err != nil {
-		return nil, fmt.Errorf("failed to decode options orders: %w", err)
+	page := &OptionsOrderPage{Items: orders, Total: total}
+	if int64(len(orders)) > query.PageLimit() {
+		page.Items = orders[:query.PageLimit()]
+		page.NextCursor = page.Items[len(page.Items)-1].ID.Hex()
+	}
+	if query.IncludeJournal {
+		ids := make([]primitive.ObjectID, len(page.Items))
+		for i, o := range page.Items {
+			ids[i] = o.ID
+		}
+		latest, err := s.latestJournalEntries(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range page.Items {
+			o.Journal = latest[o.ID]
+		}
 	}
 
-	return orders, nil
+	return page, nil
 }
 
 // GetPositions retrieves positions from MongoDB
 func (s *TradingService) GetPositions(ctx context.Context, positionType string) ([]*models.Position, error) {
-	filter := bson.M{}
-	if positionType != "" {
-		filter["type"] = positionType
-	}
-
-	cursor, err := database.PositionsCollection.Find(ctx, filter)
+	positions, err := s.repos.Positions.List(ctx, positionType, AccountFromContext(ctx))
 	if err != nil {
-		return nil, fmt.Errorf("failed to query positions: %w", err)
+		return nil, err
 	}
-	defer cursor.Close(ctx)
+	s.attachMarginRatios(ctx, positions)
+	return positions, nil
+}
 
-	var positions []*models.Position
-	if err = cursor.All(ctx, &positions); err != nil {
-		return nil, fmt.Errorf("failed to decode positions: %w", err)
-	}
+// PositionSyncSummary reports what SyncPositionsFromBinance changed
+type PositionSyncSummary struct {
+	Market   models.Market `json:"market"`
+	Upserted int64         `json:"upserted"`
+	Cleared  int64         `json:"cleared"` // stored positions that are closed on Binance
+	Skipped  int           `json:"skipped"` // Binance positions that could not be parsed; their stored copy is kept
+	Errors   []string      `json:"errors,omitempty"`
+}
 
-	return positions, nil
+// StartPositionSync registers the position sync job, refreshing the stored positions of USDⓈ-M,
+// and COIN-M when available, every interval
+func (s *TradingService) StartPositionSync(ctx context.Context, interval time.Duration) {
+	s.RegisterJob(ctx, Job{Name: JobPositionSync, Interval: interval, Run: func(ctx context.Context) error {
+		markets := []models.Market{models.MarketUSDM}
+		if s.checkCoinM() == nil {
+			markets = append(markets, models.MarketCoinM)
+		}
+		var errs []error
+		for _, market := range markets {
+			if _, err := s.SyncPositionsFromBinance(ctx, market); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}})
 }
 
-// SyncPositionsFromBinance syncs positions from Binance to MongoDB
-func (s *TradingService) SyncPositionsFromBinance(ctx context.Context) error {
+// SyncPositionsFromBinance replaces the stored positions of a futures market with the open
+// positions on Binance; positions closed since the last sync are removed
+func (s *TradingService) SyncPositionsFromBinance(ctx context.Context, market models.Market) (*PositionSyncSummary, error) {
+	if marketOf(market) == models.MarketCoinM {
+		return s.syncCoinMPositions(ctx)
+	}
+
 	// Get positions from Binance
-	binancePositions, err := s.binanceClient.GetFuturesPositions(ctx)
+	binancePositions, err := s.api(ctx).GetFuturesPositions(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get positions from Binance: %w", err)
+		return nil, fmt.Errorf("failed to get positions from Binance: %w", err)
 	}
 
-	// Update positions in MongoDB
+	summary := &PositionSyncSummary{Market: models.MarketUSDM}
+	accountID := s.accountID(ctx)
+	var positions []*models.Position
+	var keep []string
 	for _, bp := range binancePositions {
-		positionSize, _ := strconv.ParseFloat(bp.PositionAmt, 64)
+		var p decimalParser
+		positionSize := p.float("positionAmt", bp.PositionAmt)
+		entryPrice := p.float("entryPrice", bp.EntryPrice)
+		markPrice := p.float("markPrice", bp.MarkPrice)
+		unrealizedPnl := p.float("unRealizedProfit", bp.UnRealizedProfit)
+		notional := p.float("notional", bp.Notional)
+		leverage := p.int("leverage", bp.Leverage)
+		if p.err != nil {
+			summary.skip(ctx, bp.Symbol, p.err)
+			keep = append(keep, bp.Symbol)
+			continue
+		}
 		if positionSize == 0 {
-			continue // Skip zero positions
+			continue
 		}
 
-		entryPrice, _ := strconv.ParseFloat(bp.EntryPrice, 64)
-		unrealizedPnl, _ := strconv.ParseFloat(bp.UnRealizedProfit, 64)
-		leverage, _ := strconv.Atoi(bp.Leverage)
-
-		position := &models.Position{
+		positions = append(positions, &models.Position{
 			Symbol:       bp.Symbol,
 			Type:         "FUTURES",
-			Side:         models.PositionSide(bp.PositionSide),
+			Market:       models.MarketUSDM,
+			Side:         positionSideOf(bp.PositionSide),
 			Quantity:     positionSize,
 			EntryPrice:   entryPrice,
+			CurrentPrice: markPrice,
 			UnrealizedPnl: unrealizedPnl,
+			Notional:     math.Abs(notional),
 			Leverage:     leverage,
+			Paper:        s.Paper(),
+			AccountID:    accountID,
 			UpdatedAt:    time.Now(),
-		}
+		})
+	}
 
-		// Check if position exists
-		filter := bson.M{"symbol": bp.Symbol, "type": "FUTURES"}
-		update := bson.M{"$set": position}
+	// Upserts and removals commit together, so a failed sync leaves the previous positions
+	err = s.repos.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+		var txErr error
+		summary.Upserted, summary.Cleared, txErr = s.repos.Positions.SyncMarket(ctx, models.MarketUSDM, accountID, positions, keep)
+		return txErr
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update positions: %w", err)
+	}
+	return summary, nil
+}
 
-		opts := options.Update().SetUpsert(true)
-		_, err = database.PositionsCollection.UpdateOne(ctx, filter, update, opts)
-		if err != nil {
-			return fmt.Errorf("failed to update position: %w", err)
-		}
+// positionSideOf returns the stored side of a Binance position: LONG or SHORT for the legs of a
+// hedge-mode position, BOTH in one-way mode
+func positionSideOf(side string) models.PositionSide {
+	if side == "" {
+		return models.PositionSideBoth
+	}
+	return models.PositionSide(side)
+}
+
+// skip records a Binance position that was left out of the sync
+func (s *PositionSyncSummary) skip(ctx context.Context, symbol string, err error) {
+	s.Skipped++
+	s.Errors = append(s.Errors, fmt.Sprintf("%s: %v", symbol, err))
+	logging.FromContext(ctx).Warn("position sync: skipping position", "symbol", symbol, "market", s.Market, "error", err)
+}
+
+// decimalParser parses the decimal strings of a Binance response, keeping the first failure
+type decimalParser struct {
+	err error
+}
+
+func (p *decimalParser) float(field, value string) float64 {
+	if p.err != nil {
+		return 0
 	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		p.err = fmt.Errorf("invalid %s %q", field, value)
+	}
+	return f
+}
 
-	return nil
+// int parses an integer field; an empty value is 0
+func (p *decimalParser) int(field, value string) int {
+	if p.err != nil || value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		p.err = fmt.Errorf("invalid %s %q", field, value)
+	}
+	return n
 }
 
 // Request types
 type CreateFuturesOrderRequest struct {
-	Symbol       string  `json:"symbol"`
-	Side         string  `json:"side"` // BUY or SELL
-	OrderType    string  `json:"order_type"` // MARKET or LIMIT
-	Quantity     float64 `json:"quantity"`
-	Price        float64 `json:"price,omitempty"`
-	Leverage     int     `json:"leverage"`
-	PositionSide string  `json:"position_side"` // LONG or SHORT
+	Symbol    string          `json:"symbol"`
+	Side      string          `json:"side"`       // BUY or SELL
+	OrderType string          `json:"order_type"` // MARKET or LIMIT
+	Quantity  decimal.Decimal `json:"quantity"`
+	// QuoteQuantity sizes the order by notional instead of quantity, e.g. 500 for $500 of the
+	// base asset; usdm only
+	QuoteQuantity float64         `json:"quote_quantity,omitempty"`
+	Price         decimal.Decimal `json:"price"`
+	Leverage      int             `json:"leverage"`
+	PositionSide  string          `json:"position_side"` // LONG or SHORT
+	// Market is usdm (default) or coinm; coinm quantities are whole contracts
+	Market string `json:"market,omitempty"`
+	// OverrideRiskLimits skips the position limits; only allowed for RISK_OVERRIDE_PRINCIPALS
+	OverrideRiskLimits bool `json:"override_risk_limits,omitempty"`
+}
+
+// UnmarshalJSON takes the quote_quantity as a JSON number or decimal string; the quantity and price
+// are decimals, which decode from either form themselves
+func (r *CreateFuturesOrderRequest) UnmarshalJSON(data []byte) error {
+	type plain CreateFuturesOrderRequest
+	return decodeDecimalFields(data, (*plain)(r))
 }
 
 type CreateOptionsOrderRequest struct {
-	Symbol     string    `json:"symbol"`
-	Side       string    `json:"side"` // BUY or SELL
-	OrderType  string    `json:"order_type"` // MARKET or LIMIT
-	Quantity   float64   `json:"quantity"`
-	Price      float64   `json:"price,omitempty"`
-	StrikePrice float64  `json:"strike_price"`
-	ExpiryDate time.Time `json:"expiry_date"`
-	OptionType string    `json:"option_type"` // CALL or PUT
-}
-
-// SaveAPICredentials saves API credentials to MongoDB
-func (s *TradingService) SaveAPICredentials(ctx context.Context, req *SaveAPICredentialsRequest) (*models.APICredentials, error) {
-	// Check if API key already exists
-	filter := bson.M{"api_key": req.APIKey}
-	existing := &models.APICredentials{}
-	err := database.APICredentialsCollection.FindOne(ctx, filter).Decode(existing)
-	
-	if err == nil || err == mongo.ErrNoDocuments {
-		if err == mongo.ErrNoDocuments {
-			// Create new credentials
-			credentials := &models.APICredentials{
-				ID:        primitive.NewObjectID(),
-				APIKey:    req.APIKey,
-				SecretKey: req.SecretKey,
-				IsActive:  req.IsActive,
-				IsTestnet: req.IsTestnet,
-				CreatedAt: time.Now(),
-				UpdatedAt: time.Now(),
-			}
+	Symbol      string          `json:"symbol"`
+	Side        string          `json:"side"`       // BUY or SELL
+	OrderType   string          `json:"order_type"` // MARKET or LIMIT
+	Quantity    decimal.Decimal `json:"quantity"`
+	Price       decimal.Decimal `json:"price"`
+	StrikePrice float64         `json:"strike_price"`
+	ExpiryDate  time.Time       `json:"expiry_date"`
+	OptionType  string          `json:"option_type"` // CALL or PUT
+}
+
+// UnmarshalJSON takes the strike_price as a JSON number or decimal string; the quantity and price
+// are decimals, which decode from either form themselves
+func (r *CreateOptionsOrderRequest) UnmarshalJSON(data []byte) error {
+	type plain CreateOptionsOrderRequest
+	return decodeDecimalFields(data, (*plain)(r))
+}
+
+// SavedCredentials is the outcome of SaveAPICredentials
+type SavedCredentials struct {
+	Credentials *models.APICredentials
+	Created     bool // false when the API key was already stored and its credentials were updated
+	AppliedLive bool
+}
+
+// SaveAPICredentials saves API credentials to MongoDB, creating them or updating the stored
+// credentials with the same API key
+func (s *TradingService) SaveAPICredentials(ctx context.Context, req *SaveAPICredentialsRequest) (*SavedCredentials, error) {
+	start := time.Now()
+	saved, err := s.saveAPICredentials(ctx, req)
+	var credentials *models.APICredentials
+	if saved != nil {
+		credentials = saved.Credentials
+	}
+	s.recordAudit(ctx, models.AuditCredentialSave, "", req, credentials, err, start)
+	return saved, err
+}
+
+func (s *TradingService) saveAPICredentials(ctx context.Context, req *SaveAPICredentialsRequest) (*SavedCredentials, error) {
+	if err := s.requireMasterKey(req.IsTestnet); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repos.Credentials.FindByAPIKey(ctx, req.APIKey)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to check for existing credentials: %w", err)
+	}
+
+	// Check the keys against Binance before storing them; skipped keys carry no validation time
+	validationStatus := models.CredentialSkipped
+	var validatedAt *time.Time
+	if !req.SkipValidation {
+		if valErr := s.binanceClient.ValidateAPIKeys(ctx, req.APIKey, req.SecretKey, req.IsTestnet); valErr != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCredentialValidation, valErr)
+		}
+		validationStatus = models.CredentialValid
+		now := time.Now()
+		validatedAt = &now
+	}
 
-			_, err = database.APICredentialsCollection.InsertOne(ctx, credentials)
+	encryptedSecret, err := s.encryptSecret(req.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	saved := &SavedCredentials{}
+	if existing == nil {
+		now := time.Now()
+		credentials := &models.APICredentials{
+			ID:               primitive.NewObjectID(),
+			APIKey:           req.APIKey,
+			SecretKey:        encryptedSecret,
+			IsActive:         req.IsActive,
+			IsTestnet:        req.IsTestnet,
+			ValidationStatus: validationStatus,
+			ValidatedAt:      validatedAt,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}
+		err := s.repos.Credentials.Insert(ctx, credentials)
+		switch {
+		case err == nil:
+			saved.Credentials = credentials
+			saved.Created = true
+		case errors.Is(err, repository.ErrDuplicate):
+			// A concurrent save stored the same API key first; update its credentials instead
+			existing, err = s.repos.Credentials.FindByAPIKey(ctx, req.APIKey)
 			if err != nil {
-				return nil, fmt.Errorf("failed to save API credentials: %w", err)
+				return nil, fmt.Errorf("failed to load concurrently saved credentials: %w", err)
 			}
-
-			return credentials, nil
+		default:
+			return nil, fmt.Errorf("failed to save API credentials: %w", err)
 		}
-		// Update existing credentials
-		existing.SecretKey = req.SecretKey
+	}
+
+	if !saved.Created {
+		existing.SecretKey = encryptedSecret
 		existing.IsActive = req.IsActive
 		existing.IsTestnet = req.IsTestnet
+		existing.ValidationStatus = validationStatus
+		existing.ValidatedAt = validatedAt
 		existing.UpdatedAt = time.Now()
-
-		update := bson.M{"$set": existing}
-		_, err = database.APICredentialsCollection.UpdateOne(ctx, filter, update)
-		if err != nil {
+		if err := s.repos.Credentials.Update(ctx, existing); err != nil {
 			return nil, fmt.Errorf("failed to update API credentials: %w", err)
 		}
-		return existing, nil
+		saved.Credentials = existing
+	}
+
+	if saved.Credentials.IsActive {
+		if err := s.activateCredential(ctx, saved.Credentials.ID); err != nil {
+			return nil, err
+		}
 	}
-	
-	// If we got here, there was an unexpected error
-	return nil, fmt.Errorf("unexpected error checking for existing credentials: %w", err)
+	saved.Credentials.SecretKey = req.SecretKey
+	saved.AppliedLive = s.applyIfActive(saved.Credentials)
+	return saved, nil
 }
 
 // GetAPICredentials retrieves API credentials from MongoDB
 func (s *TradingService) GetAPICredentials(ctx context.Context, activeOnly bool) ([]*models.APICredentials, error) {
-	filter := bson.M{}
-	if activeOnly {
-		filter["is_active"] = true
-	}
-
-	cursor, err := database.APICredentialsCollection.Find(ctx, filter)
+	credentials, err := s.repos.Credentials.List(ctx, activeOnly)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query API credentials: %w", err)
+		return nil, err
 	}
-	defer cursor.Close(ctx)
 
-	var credentials []*models.APICredentials
-	if err = cursor.All(ctx, &credentials); err != nil {
-		return nil, fmt.Errorf("failed to decode API credentials: %w", err)
+	for _, c := range credentials {
+		if err := s.decryptCredentials(c); err != nil {
+			return nil, err
+		}
 	}
 
 	return credentials, nil
@@ -407,19 +744,21 @@ func (s *TradingService) GetAPICredentials(ctx context.Context, activeOnly bool)
 
 // GetActiveAPICredentials gets the first active API credentials
 func (s *TradingService) GetActiveAPICredentials(ctx context.Context) (*models.APICredentials, error) {
-	filter := bson.M{"is_active": true}
-	credentials := &models.APICredentials{}
-	err := database.APICredentialsCollection.FindOne(ctx, filter).Decode(credentials)
+	credentials, err := s.repos.Credentials.FindActive(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("no active API credentials found: %w", err)
 	}
+	if err := s.decryptCredentials(credentials); err != nil {
+		return nil, err
+	}
 	return credentials, nil
 }
 
 type SaveAPICredentialsRequest struct {
-	APIKey    string `json:"api_key"`
-	SecretKey string `json:"secret_key"`
-	IsActive  bool   `json:"is_active"`
-	IsTestnet bool   `json:"is_testnet"`
+	APIKey         string `json:"api_key"`
+	SecretKey      string `json:"secret_key"`
+	IsActive       bool   `json:"is_active"`
+	IsTestnet      bool   `json:"is_testnet"`
+	SkipValidation bool   `json:"skip_validation,omitempty"` // skip the signed test call to Binance
 }
 
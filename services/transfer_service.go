@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/logging"
+	"futures-options/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// transferTypes maps each transfer direction to its Binance universal transfer type
+var transferTypes = map[models.TransferDirection]string{
+	models.TransferSpotToFutures: binance.TransferSpotToUSDM,
+	models.TransferFuturesToSpot: binance.TransferUSDMToSpot,
+	models.TransferSpotToCoinM:   binance.TransferSpotToCoinM,
+	models.TransferCoinMToSpot:   binance.TransferCoinMToSpot,
+}
+
+var transferDirections = []string{
+	string(models.TransferSpotToFutures),
+	string(models.TransferFuturesToSpot),
+	string(models.TransferSpotToCoinM),
+	string(models.TransferCoinMToSpot),
+}
+
+// TransferRequest moves an asset between the spot and futures wallets
+type TransferRequest struct {
+	Asset     string  `json:"asset"`
+	Amount    float64 `json:"amount"`
+	Direction string  `json:"direction"` // SPOT_TO_FUTURES, FUTURES_TO_SPOT, SPOT_TO_COINM or COINM_TO_SPOT
+}
+
+// Validate checks the asset, amount and direction
+func (r *TransferRequest) Validate() error {
+	v := &validator{}
+	v.required("asset", r.Asset)
+	v.positive("amount", r.Amount)
+	r.Direction = strings.ToUpper(r.Direction)
+	v.required("direction", r.Direction)
+	v.oneOf("direction", r.Direction, transferDirections...)
+	return v.err()
+}
+
+// TransferHistoryEntry is a transfer from the Binance history
+type TransferHistoryEntry struct {
+	TranID    int64                    `json:"tran_id"`
+	Asset     string                   `json:"asset"`
+	Amount    float64                  `json:"amount"`
+	Direction models.TransferDirection `json:"direction"`
+	Status    string                   `json:"status"`
+	Time      time.Time                `json:"time"`
+}
+
+// TransferFunds checks the source wallet's available balance, submits the transfer and stores it
+// with Binance's transfer ID
+func (s *TradingService) TransferFunds(ctx context.Context, req *TransferRequest) (*models.Transfer, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if s.Paper() {
+		return nil, fmt.Errorf("wallet transfers are %w", ErrPaperUnsupported)
+	}
+	req.Asset = strings.ToUpper(req.Asset)
+	direction := models.TransferDirection(req.Direction)
+
+	available, err := s.transferableBalance(ctx, direction, req.Asset)
+	if err != nil {
+		return nil, err
+	}
+	if req.Amount > available {
+		v := &validator{}
+		v.add("amount", RuleRange, fmt.Sprintf("exceeds the available %s balance %s", req.Asset, formatFloat(available)))
+		return nil, v.err()
+	}
+
+	start := time.Now()
+	tranID, err := s.api(ctx).UniversalTransfer(ctx, transferTypes[direction], req.Asset, req.Amount)
+	if err != nil {
+		s.recordAudit(ctx, models.AuditWalletTransfer, "", req, nil, err, start)
+		return nil, err
+	}
+	s.recordAudit(ctx, models.AuditWalletTransfer, "", req, map[string]int64{"tran_id": tranID}, nil, start)
+	logging.FromContext(ctx).Info("wallet transfer submitted", "asset", req.Asset, "amount", req.Amount, "direction", direction, "tran_id", tranID)
+
+	now := time.Now()
+	transfer := &models.Transfer{
+		ID:        primitive.NewObjectID(),
+		TranID:    tranID,
+		Asset:     req.Asset,
+		Amount:    req.Amount,
+		Direction: direction,
+		Status:    "PENDING",
+		CreatedBy: PrincipalFromContext(ctx),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repos.Transfers.Insert(ctx, transfer); err != nil {
+		// The funds have moved; report the transfer even though it could not be recorded
+		logging.FromContext(ctx).Error("failed to save transfer", "tran_id", tranID, "error", err)
+	}
+	return transfer, nil
+}
+
+// transferableBalance returns how much of asset the direction's source wallet can send
+func (s *TradingService) transferableBalance(ctx context.Context, direction models.TransferDirection, asset string) (float64, error) {
+	switch direction {
+	case models.TransferFuturesToSpot:
+		account, err := s.api(ctx).GetFuturesAccount(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get futures account: %w", err)
+		}
+		for _, a := range account.Assets {
+			if a.Asset == asset {
+				return strconv.ParseFloat(a.MaxWithdrawAmount, 64)
+			}
+		}
+	case models.TransferCoinMToSpot:
+		account, err := s.api(ctx).GetDeliveryAccount(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get coin-m account: %w", err)
+		}
+		for _, a := range account.Assets {
+			if a.Asset == asset {
+				return strconv.ParseFloat(a.MaxWithdrawAmount, 64)
+			}
+		}
+	default:
+		balances, err := s.api(ctx).GetSpotBalances(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, b := range balances {
+			if b.Asset == asset {
+				return strconv.ParseFloat(b.Free, 64)
+			}
+		}
+	}
+	return 0, nil
+}
+
+// ListTransfers returns the transfer history from Binance, newest first, for one direction or
+// all of them, and updates the status of the stored transfers it contains
+func (s *TradingService) ListTransfers(ctx context.Context, direction string, start, end *time.Time) ([]*TransferHistoryEntry, error) {
+	if s.Paper() {
+		return nil, fmt.Errorf("wallet transfers are %w", ErrPaperUnsupported)
+	}
+	directions := transferDirections
+	if direction != "" {
+		v := &validator{}
+		direction = strings.ToUpper(direction)
+		v.oneOf("direction", direction, transferDirections...)
+		if err := v.err(); err != nil {
+			return nil, err
+		}
+		directions = []string{direction}
+	}
+
+	var from, to time.Time
+	if start != nil {
+		from = *start
+	}
+	if end != nil {
+		to = *end
+	}
+
+	entries := []*TransferHistoryEntry{}
+	for _, d := range directions {
+		records, err := s.api(ctx).ListUniversalTransfers(ctx, transferTypes[models.TransferDirection(d)], from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range records {
+			amount, _ := strconv.ParseFloat(rec.Amount, 64)
+			entries = append(entries, &TransferHistoryEntry{
+				TranID:    rec.TranID,
+				Asset:     rec.Asset,
+				Amount:    amount,
+				Direction: models.TransferDirection(d),
+				Status:    rec.Status,
+				Time:      time.UnixMilli(rec.Timestamp),
+			})
+			if err := s.repos.Transfers.SetStatus(ctx, rec.TranID, rec.Status); err != nil {
+				logging.FromContext(ctx).Warn("failed to update transfer status", "tran_id", rec.TranID, "error", err)
+			}
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Time.After(entries[j].Time) })
+	return entries, nil
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+	"futures-options/notifications"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// StartUserDataStream connects to the futures user data stream and dispatches events until ctx is done
+func (s *TradingService) StartUserDataStream(ctx context.Context) error {
+	if s.Paper() {
+		// Simulated orders never reach Binance, so there is nothing to stream
+		return nil
+	}
+	ws, err := binance.NewWebSocketClient(s.binanceClient.Futures(), s.binanceClient.EffectiveConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create user data stream: %w", err)
+	}
+	if err := ws.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect user data stream: %w", err)
+	}
+	s.stateMu.Lock()
+	s.wsClient = ws
+	s.stateMu.Unlock()
+
+	s.runBackground(func() {
+		defer ws.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				// Handle events that already arrived before giving up the stream
+				s.drainUserDataEvents(context.WithoutCancel(ctx), ws)
+				return
+			case event, ok := <-ws.GetMessageChannel():
+				if !ok {
+					s.notifyStreamDown(ctx, ws)
+					return
+				}
+				s.handleUserDataEvent(ctx, event)
+			}
+		}
+	})
+
+	return nil
+}
+
+// StartListenKeyKeepalive registers the listen key keepalive job, extending the user data
+// stream's listen key every interval; it does nothing while no stream is open
+func (s *TradingService) StartListenKeyKeepalive(ctx context.Context, interval time.Duration) {
+	s.RegisterJob(ctx, Job{Name: JobListenKeyKeepalive, Interval: interval, Run: func(ctx context.Context) error {
+		s.stateMu.RLock()
+		ws := s.wsClient
+		s.stateMu.RUnlock()
+		if ws == nil {
+			return nil
+		}
+		return ws.KeepAlive(ctx)
+	}})
+}
+
+// userStreamRetryDelay is how long to wait before reconnecting a user data stream that failed to
+// restart; the delay doubles after each failed attempt, up to userStreamMaxRetryDelay
+const (
+	userStreamRetryDelay    = 5 * time.Second
+	userStreamMaxRetryDelay = 5 * time.Minute
+)
+
+// ErrUserStreamRestarting is returned when a restart of the user data stream is requested while
+// one is in progress
+var ErrUserStreamRestarting = errors.New("the user data stream is already being restarted")
+
+// UserStreamRestart reports what restarting the user data stream did
+type UserStreamRestart struct {
+	Actions   []string `json:"actions"`
+	Connected bool     `json:"connected"`
+	// ConnectError is set when the new stream could not connect; it is then retried in the
+	// background and no reconciliation runs
+	ConnectError string `json:"connect_error,omitempty"`
+	// Reconciliation is the order and position reconciliation run after reconnecting to catch up
+	// on events the old stream missed; ReconcileError is set instead when it could not run
+	Reconciliation *models.ReconciliationReport `json:"reconciliation,omitempty"`
+	ReconcileError string                       `json:"reconcile_error,omitempty"`
+	StartedAt      time.Time                    `json:"started_at"`
+	Duration       string                       `json:"duration"`
+}
+
+// RestartUserDataStream replaces the user data stream: the current stream is closed and its
+// listen key deleted, a new listen key is acquired and connected, and the local book is then
+// reconciled with Binance. Events the old stream had already received are still handled by its
+// consumer, which ends quietly because the stream was replaced. When the new stream cannot
+// connect, the restart reports Connected false with ConnectError, sends STREAM_DOWN and keeps
+// reconnecting in the background.
+func (s *TradingService) RestartUserDataStream(ctx context.Context) (*UserStreamRestart, error) {
+	if s.Paper() {
+		return nil, fmt.Errorf("the user data stream is %w", ErrPaperUnsupported)
+	}
+	// Held only while the stream is swapped; the reconciliation after it has its own lock
+	if !s.streamRestartMu.TryLock() {
+		return nil, ErrUserStreamRestarting
+	}
+
+	result := &UserStreamRestart{Actions: []string{}, StartedAt: time.Now()}
+	defer func() { result.Duration = time.Since(result.StartedAt).String() }()
+
+	// A credential change also replaces the stream; the two must not interleave
+	s.credMu.Lock()
+	// Detach the stream before closing it, so its consumer does not report it down
+	s.stateMu.Lock()
+	old := s.wsClient
+	s.wsClient = nil
+	s.stateMu.Unlock()
+	if old != nil {
+		old.Close()
+		result.Actions = append(result.Actions, "closed the user data stream and deleted its listen key")
+	}
+
+	// Binance hands back the existing listen key while it is valid, so the old one is deleted first
+	bgCtx := s.bgCtx
+	if bgCtx == nil {
+		bgCtx = context.WithoutCancel(ctx)
+	}
+	err := s.StartUserDataStream(bgCtx)
+	s.credMu.Unlock()
+	s.streamRestartMu.Unlock()
+	s.recordAudit(ctx, models.AuditUserStreamRestart, "", map[string]interface{}{"replaced": old != nil}, nil, err, result.StartedAt)
+	if err != nil {
+		// The old stream is already gone; keep trying rather than leave the account unwatched
+		slog.Error("failed to restart user data stream, retrying in the background", "error", err)
+		s.notifyStreamDown(ctx, nil)
+		s.retryUserDataStream(bgCtx)
+		result.ConnectError = err.Error()
+		result.Actions = append(result.Actions, "failed to connect a new user data stream; retrying in the background")
+		return result, nil
+	}
+	result.Actions = append(result.Actions, "acquired a new listen key and connected")
+	result.Connected = s.UserDataStreamConnected()
+
+	// The reconciliation finishes even if the caller goes away, but within ReconcileTimeout
+	reconcileCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ReconcileTimeout)
+	defer cancel()
+	report, err := s.ReconcileWithBinance(reconcileCtx, ReconcileTriggerStreamRestart)
+	result.Reconciliation = report
+	if err != nil {
+		result.ReconcileError = err.Error()
+	} else {
+		result.Actions = append(result.Actions, "reconciled orders and positions with Binance")
+	}
+	slog.Info("user data stream restarted", "replaced", old != nil, "reconcile_error", result.ReconcileError)
+	return result, nil
+}
+
+// retryUserDataStream reconnects the user data stream in the background after a failed restart,
+// then reconciles to catch up on the events missed meanwhile. It stops once a stream is
+// connected, by it or by a later restart or credential change, or when ctx is done.
+func (s *TradingService) retryUserDataStream(ctx context.Context) {
+	s.runBackground(func() {
+		delay := userStreamRetryDelay
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+
+			s.credMu.Lock()
+			s.stateMu.RLock()
+			connected := s.wsClient != nil
+			s.stateMu.RUnlock()
+			var err error
+			if !connected {
+				err = s.StartUserDataStream(ctx)
+			}
+			s.credMu.Unlock()
+			if connected {
+				return
+			}
+			if err == nil {
+				slog.Info("user data stream reconnected after a failed restart")
+				if _, err := s.ReconcileWithBinance(ctx, ReconcileTriggerStreamRestart); err != nil {
+					slog.Warn("reconciliation after reconnecting the user data stream failed", "error", err)
+				}
+				return
+			}
+			slog.Warn("failed to reconnect user data stream", "error", err, "retry_in", delay)
+			delay = min(delay*2, userStreamMaxRetryDelay)
+		}
+	})
+}
+
+// drainUserDataEvents handles the events still buffered on ws without waiting for new ones
+func (s *TradingService) drainUserDataEvents(ctx context.Context, ws *binance.WebSocketClient) {
+	for {
+		select {
+		case event, ok := <-ws.GetMessageChannel():
+			if !ok {
+				return
+			}
+			s.handleUserDataEvent(ctx, event)
+		default:
+			return
+		}
+	}
+}
+
+// handleUserDataEvent routes a user data stream event to its handler
+func (s *TradingService) handleUserDataEvent(ctx context.Context, event *futures.WsUserDataEvent) {
+	switch event.Event {
+	case futures.UserDataEventTypeMarginCall:
+		if err := s.HandleMarginCall(ctx, event); err != nil {
+			slog.Error("failed to handle margin call", "error", err)
+		}
+	case futures.UserDataEventTypeAccountUpdate:
+		s.resetClosedFunding(ctx, event)
+	case futures.UserDataEventTypeOrderTradeUpdate:
+		u := &event.OrderTradeUpdate
+		s.openOrders.apply(s.ActiveAccount(), u)
+		s.recordOrderFill(ctx, u.Symbol, u.ID, u.ClientOrderID, streamOrderFill(u))
+		s.recordStreamFill(ctx, u)
+		s.snapshotAfterFill(ctx, u)
+		s.notifyOrderUpdate(ctx, u)
+		s.handleGridOrderUpdate(ctx, u)
+		s.handleSpreadOrderUpdate(ctx, u)
+	}
+}
+
+// notifyOrderUpdate sends a notification for final order states: filled, canceled or rejected,
+// and POSITION_LIQUIDATED instead of ORDER_FILLED for Binance's liquidation and ADL orders
+func (s *TradingService) notifyOrderUpdate(ctx context.Context, u *futures.WsOrderTradeUpdate) {
+	var eventType, title string
+	priority := notifications.PriorityNormal
+	switch u.Status {
+	case futures.OrderStatusTypeFilled:
+		eventType, title = notifications.EventOrderFilled, "Order filled"
+		if isLiquidationOrder(u.ClientOrderID) {
+			eventType, title, priority = notifications.EventPositionLiquidated, "Position liquidated", notifications.PriorityHigh
+		}
+	case futures.OrderStatusTypeCanceled:
+		eventType, title = notifications.EventOrderCanceled, "Order canceled"
+	case futures.OrderStatusTypeRejected:
+		eventType, title, priority = notifications.EventOrderRejected, "Order rejected", notifications.PriorityHigh
+	default:
+		return
+	}
+	s.notify(ctx, &notifications.Notification{
+		Title:     title,
+		Message:   fmt.Sprintf("%s %s %s %s @ %s", u.Symbol, u.Side, u.Type, u.AccumulatedFilledQty, u.AveragePrice),
+		Priority:  priority,
+		EventType: eventType,
+		Fields: map[string]string{
+			"symbol":          u.Symbol,
+			"side":            string(u.Side),
+			"position_side":   string(u.PositionSide),
+			"type":            string(u.Type),
+			"order_id":        strconv.FormatInt(u.ID, 10),
+			"client_order_id": u.ClientOrderID,
+			"quantity":        u.OriginalQty,
+			"filled_quantity": u.AccumulatedFilledQty,
+			"average_price":   u.AveragePrice,
+			"realized_pnl":    u.RealizedPnL,
+		},
+	})
+}
+
+// notifyStreamDown sends STREAM_DOWN when ws ends on its own, or with a nil ws when no stream
+// could be connected; a stream closed because it was replaced after a credential change is not
+// reported
+func (s *TradingService) notifyStreamDown(ctx context.Context, ws *binance.WebSocketClient) {
+	s.stateMu.RLock()
+	replaced := s.wsClient != ws
+	s.stateMu.RUnlock()
+	if replaced {
+		return
+	}
+	slog.Error("user data stream disconnected")
+	s.notify(ctx, &notifications.Notification{
+		Title:     "User data stream down",
+		Message:   "The Binance user data stream disconnected; fills, margin calls and equity snapshots after fills are not tracked until it is reconnected",
+		Priority:  notifications.PriorityHigh,
+		EventType: notifications.EventStreamDown,
+	})
+}
+
+// isLiquidationOrder reports whether a client order ID belongs to an order Binance placed to
+// liquidate ("autoclose-") or auto-deleverage ("adl_autoclose") a position
+func isLiquidationOrder(clientOrderID string) bool {
+	return strings.HasPrefix(clientOrderID, "autoclose-") || strings.HasPrefix(clientOrderID, "adl_autoclose")
+}
+
+// notifyOrderRejected sends ORDER_REJECTED when Binance refuses a new order
+func (s *TradingService) notifyOrderRejected(ctx context.Context, symbol string, err error) {
+	fields := map[string]string{"error": err.Error()}
+	if symbol != "" {
+		fields["symbol"] = symbol
+	}
+	if code := binance.APIErrorCode(err); code != 0 {
+		fields["code"] = strconv.FormatInt(code, 10)
+	}
+	s.notify(ctx, &notifications.Notification{
+		Title:     "Order rejected",
+		Message:   fmt.Sprintf("Binance rejected the %s order", strings.TrimSpace(symbol+" futures")),
+		Priority:  notifications.PriorityHigh,
+		EventType: notifications.EventOrderRejected,
+		Fields:    fields,
+	})
+}
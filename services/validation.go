@@ -0,0 +1,375 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"futures-options/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxLeverage is the highest leverage Binance USDⓈ-M futures accept
+const MaxLeverage = 125
+
+// Validation rules reported in FieldError.Rule
+const (
+	RuleRequired = "required"
+	RuleEnum     = "enum"
+	RulePositive = "positive"
+	RuleRange    = "range"
+	RuleUnknown  = "unknown_field"
+	RuleType     = "type"
+)
+
+// FieldError describes a problem with a single request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// ValidationError collects every field problem found in a request
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + " " + f.Message
+	}
+	return "invalid request: " + strings.Join(msgs, "; ")
+}
+
+// validator accumulates field errors while checking a request
+type validator struct {
+	fields []FieldError
+}
+
+func (v *validator) add(field, rule, message string) {
+	v.fields = append(v.fields, FieldError{Field: field, Rule: rule, Message: message})
+}
+
+func (v *validator) required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, RuleRequired, "is required")
+	}
+}
+
+// oneOf checks an optional enum value; use required() as well for mandatory ones
+func (v *validator) oneOf(field, value string, allowed ...string) {
+	if value == "" {
+		return
+	}
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add(field, RuleEnum, "must be one of "+strings.Join(allowed, ", "))
+}
+
+func (v *validator) positive(field string, value float64) {
+	if value <= 0 {
+		v.add(field, RulePositive, "must be greater than 0")
+	}
+}
+
+// nonNegative checks optional numeric fields, where 0 means "not set"
+func (v *validator) nonNegative(field string, value float64) {
+	if value < 0 {
+		v.add(field, RulePositive, "must not be negative")
+	}
+}
+
+// positiveDecimal is positive for a decimal quantity or price
+func (v *validator) positiveDecimal(field string, value decimal.Decimal) {
+	if !value.IsPositive() {
+		v.add(field, RulePositive, "must be greater than 0")
+	}
+}
+
+// nonNegativeDecimal is nonNegative for an optional decimal quantity or price
+func (v *validator) nonNegativeDecimal(field string, value decimal.Decimal) {
+	if value.IsNegative() {
+		v.add(field, RulePositive, "must not be negative")
+	}
+}
+
+func (v *validator) leverage(field string, value int) {
+	if value < 0 || value > MaxLeverage {
+		v.add(field, RuleRange, fmt.Sprintf("must be between 1 and %d, or 0 to keep the current leverage", MaxLeverage))
+	}
+}
+
+// basicOrderType checks the order type of a basic order, pointing callers sending a stop, take
+// profit or trailing order to the advanced endpoint instead of listing it as unknown
+func (v *validator) basicOrderType(field, value string) {
+	switch models.OrderType(value) {
+	case "", models.OrderTypeMarket, models.OrderTypeLimit:
+		return
+	}
+	for _, t := range advancedOrderType {
+		if value == t {
+			v.add(field, RuleEnum, "must be MARKET or LIMIT; "+value+" orders go to POST /api/futures/advanced/order")
+			return
+		}
+	}
+	v.oneOf(field, value, basicOrderTypes...)
+}
+
+// contracts checks a COIN-M quantity, which is a whole number of contracts
+func (v *validator) contracts(field string, value decimal.Decimal) {
+	if !value.IsInteger() {
+		v.add(field, RuleType, "must be a whole number of contracts for coinm")
+	}
+}
+
+// clientOrderID checks an optional client order ID against Binance's length and character limits
+func (v *validator) clientOrderID(field, value string) {
+	switch {
+	case value == "":
+	case len(value) > maxClientOrderIDLength:
+		v.add(field, RuleRange, fmt.Sprintf("must be at most %d characters", maxClientOrderIDLength))
+	case !clientOrderIDPattern.MatchString(value):
+		v.add(field, RuleType, "may only contain letters, digits and .:/_-")
+	}
+}
+
+// quantityOrQuote checks that exactly one of quantity and quote_quantity is set, under prefix.
+// Quote quantities are sized against USDⓈ-M prices, so coinm orders cannot use them.
+func (v *validator) quantityOrQuote(prefix string, quantity decimal.Decimal, quote float64, market string) {
+	if quote == 0 {
+		v.positiveDecimal(prefix+"quantity", quantity)
+		return
+	}
+	v.positive(prefix+"quote_quantity", quote)
+	if !quantity.IsZero() {
+		v.add(prefix+"quantity", RuleRange, "cannot be combined with quote_quantity")
+	}
+	if market == string(models.MarketCoinM) {
+		v.add(prefix+"quote_quantity", RuleEnum, "is only supported for usdm orders")
+	}
+}
+
+// nested adds the field errors of a nested request's validation under prefix
+func (v *validator) nested(prefix string, err error) {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		for _, f := range validationErr.Fields {
+			v.add(prefix+f.Field, f.Rule, f.Message)
+		}
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+var (
+	orderSides    = []string{string(models.OrderSideBuy), string(models.OrderSideSell)}
+	positionSides = []string{string(models.PositionSideLong), string(models.PositionSideShort)}
+	timeInForces  = []string{
+		string(models.TimeInForceGTC), string(models.TimeInForceIOC), string(models.TimeInForceFOK),
+		string(models.TimeInForceGTX), string(models.TimeInForceGTD),
+	}
+	basicOrderTypes   = []string{string(models.OrderTypeMarket), string(models.OrderTypeLimit)}
+	workingTypes      = []string{string(models.WorkingTypeMarkPrice), string(models.WorkingTypeContractPrice)}
+	advancedOrderType = []string{
+		string(models.OrderTypeMarket), string(models.OrderTypeLimit), string(models.OrderTypeStop),
+		string(models.OrderTypeStopMarket), string(models.OrderTypeStopLimit), string(models.OrderTypeTakeProfit),
+		string(models.OrderTypeTakeProfitMarket), string(models.OrderTypeTrailingStopMarket),
+	}
+	stpModes = []string{
+		string(models.STPNone), string(models.STPExpireTaker), string(models.STPExpireBoth), string(models.STPExpireMaker),
+	}
+	priceMatchModes = []string{
+		string(models.PriceMatchNone), string(models.PriceMatchOpponent), string(models.PriceMatchOpponent5),
+		string(models.PriceMatchQueue), string(models.PriceMatchQueue5), string(models.PriceMatchQueue10), string(models.PriceMatchQueue20),
+	}
+	optionTypes = []string{"CALL", "PUT"}
+	markets     = []string{string(models.MarketUSDM), string(models.MarketCoinM)}
+)
+
+// Validate checks a basic futures order request
+func (r *CreateFuturesOrderRequest) Validate() error {
+	v := &validator{}
+	v.required("symbol", r.Symbol)
+	v.required("side", r.Side)
+	v.oneOf("side", r.Side, orderSides...)
+	v.required("order_type", r.OrderType)
+	v.basicOrderType("order_type", r.OrderType)
+	v.quantityOrQuote("", r.Quantity, r.QuoteQuantity, r.Market)
+	if r.OrderType == string(models.OrderTypeLimit) {
+		v.positiveDecimal("price", r.Price)
+	} else {
+		v.nonNegativeDecimal("price", r.Price)
+	}
+	v.leverage("leverage", r.Leverage)
+	v.oneOf("position_side", r.PositionSide, positionSides...)
+	v.oneOf("market", r.Market, markets...)
+	if r.Market == string(models.MarketCoinM) {
+		v.contracts("quantity", r.Quantity)
+	}
+	return v.err()
+}
+
+// Validate checks an advanced futures order request
+func (r *AdvancedOrderRequest) Validate() error {
+	v := &validator{}
+	r.validate(v, "")
+	return v.err()
+}
+
+// closePositionType reports whether Binance accepts close_position on orderType: only the
+// STOP_MARKET and TAKE_PROFIT_MARKET trigger types
+func closePositionType(orderType string) bool {
+	switch models.OrderType(orderType) {
+	case models.OrderTypeStopMarket, models.OrderTypeTakeProfitMarket:
+		return true
+	}
+	return false
+}
+
+// normalizeClosePosition drops the fields Binance rejects next to close_position (-1106) but which
+// close_position makes redundant: the quantity, since the whole position is closed, and
+// reduce_only, which it implies. Each dropped field is noted in Corrections. Orders of other
+// types are left alone, since validate rejects close_position on them.
+func (r *AdvancedOrderRequest) normalizeClosePosition() {
+	if !r.ClosePosition || !closePositionType(r.OrderType) {
+		return
+	}
+	if !r.Quantity.IsZero() || r.QuoteQuantity != 0 {
+		r.Quantity, r.QuoteQuantity = decimal.Zero, 0
+		r.Corrections = append(r.Corrections, "dropped quantity: close_position closes the whole position")
+	}
+	if r.ReduceOnly {
+		r.ReduceOnly = false
+		r.Corrections = append(r.Corrections, "dropped reduce_only: close_position implies it")
+	}
+}
+
+// validate checks the request, prefixing field names (used for batch entries)
+func (r *AdvancedOrderRequest) validate(v *validator, prefix string) {
+	r.normalizeClosePosition()
+	v.required(prefix+"symbol", r.Symbol)
+	v.required(prefix+"side", r.Side)
+	v.oneOf(prefix+"side", r.Side, orderSides...)
+	v.required(prefix+"order_type", r.OrderType)
+	v.oneOf(prefix+"order_type", r.OrderType, advancedOrderType...)
+	if !r.ClosePosition {
+		v.quantityOrQuote(prefix, r.Quantity, r.QuoteQuantity, r.Market)
+	} else if !closePositionType(r.OrderType) {
+		v.add(prefix+"close_position", RuleEnum, "is only allowed on STOP_MARKET and TAKE_PROFIT_MARKET orders")
+	}
+	switch models.OrderType(r.OrderType) {
+	case models.OrderTypeLimit, models.OrderTypeStop, models.OrderTypeStopLimit, models.OrderTypeTakeProfit:
+		if r.PriceMatch == "" || r.PriceMatch == string(models.PriceMatchNone) {
+			v.positiveDecimal(prefix+"price", r.Price)
+		}
+	default:
+		v.nonNegativeDecimal(prefix+"price", r.Price)
+	}
+	switch models.OrderType(r.OrderType) {
+	case models.OrderTypeStop, models.OrderTypeStopMarket, models.OrderTypeStopLimit,
+		models.OrderTypeTakeProfit, models.OrderTypeTakeProfitMarket:
+		v.positiveDecimal(prefix+"stop_price", r.StopPrice)
+	default:
+		v.nonNegativeDecimal(prefix+"stop_price", r.StopPrice)
+	}
+	if models.OrderType(r.OrderType) == models.OrderTypeTrailingStopMarket {
+		if r.CallbackRate < 0.1 || r.CallbackRate > 10 {
+			v.add(prefix+"callback_rate", RuleRange, "must be between 0.1 and 10")
+		}
+	}
+	v.nonNegativeDecimal(prefix+"activation_price", r.ActivationPrice)
+	v.leverage(prefix+"leverage", r.Leverage)
+	v.oneOf(prefix+"position_side", r.PositionSide, positionSides...)
+	v.oneOf(prefix+"time_in_force", r.TimeInForce, timeInForces...)
+	v.oneOf(prefix+"working_type", r.WorkingType, workingTypes...)
+	v.oneOf(prefix+"self_trade_prevention_mode", r.SelfTradePreventionMode, stpModes...)
+	v.oneOf(prefix+"price_match", r.PriceMatch, priceMatchModes...)
+	v.oneOf(prefix+"new_order_resp_type", r.NewOrderRespType, "ACK", "RESULT")
+	v.clientOrderID(prefix+"client_order_id", r.ClientOrderID)
+	if r.TimeInForce == string(models.TimeInForceGTD) && r.GoodTillDate == nil {
+		v.add(prefix+"good_till_date", RuleRequired, "is required when time_in_force is GTD")
+	}
+	v.oneOf(prefix+"market", r.Market, markets...)
+	if r.Market == string(models.MarketCoinM) {
+		v.contracts(prefix+"quantity", r.Quantity)
+		if r.SelfTradePreventionMode != "" || r.PriceMatch != "" || r.TimeInForce == string(models.TimeInForceGTD) {
+			v.add(prefix+"market", RuleEnum, "coinm orders do not support self_trade_prevention_mode, price_match or GTD")
+		}
+	}
+}
+
+// Validate checks every order of a batch request
+func (r *BatchOrderRequest) Validate() error {
+	v := &validator{}
+	if len(r.Orders) == 0 {
+		v.add("orders", RuleRequired, "must contain at least one order")
+	}
+	for i := range r.Orders {
+		r.Orders[i].validate(v, fmt.Sprintf("orders[%d].", i))
+		if r.Orders[i].Market == string(models.MarketCoinM) {
+			v.add(fmt.Sprintf("orders[%d].market", i), RuleEnum, "batch orders support usdm only")
+		}
+	}
+	return v.err()
+}
+
+// Validate checks an order modification request
+func (r *ModifyOrderRequest) Validate() error {
+	v := &validator{}
+	v.required("symbol", r.Symbol)
+	if r.OrderID <= 0 && r.ClientOrderID == "" {
+		v.add("order_id", RuleRequired, "order_id or client_order_id is required")
+	}
+	v.nonNegativeDecimal("quantity", r.Quantity)
+	v.nonNegativeDecimal("price", r.Price)
+	v.nonNegativeDecimal("stop_price", r.StopPrice)
+	v.nonNegativeDecimal("activation_price", r.ActivationPrice)
+	v.nonNegative("callback_rate", r.CallbackRate)
+	v.oneOf("price_match", r.PriceMatch, priceMatchModes...)
+	return v.err()
+}
+
+// Validate checks an options order request
+func (r *CreateOptionsOrderRequest) Validate() error {
+	v := &validator{}
+	v.required("symbol", r.Symbol)
+	v.required("side", r.Side)
+	v.oneOf("side", r.Side, orderSides...)
+	v.required("order_type", r.OrderType)
+	v.oneOf("order_type", r.OrderType, string(models.OrderTypeMarket), string(models.OrderTypeLimit))
+	v.positiveDecimal("quantity", r.Quantity)
+	if r.OrderType == string(models.OrderTypeLimit) {
+		v.positiveDecimal("price", r.Price)
+	} else {
+		v.nonNegativeDecimal("price", r.Price)
+	}
+	v.positive("strike_price", r.StrikePrice)
+	if r.ExpiryDate.IsZero() {
+		v.add("expiry_date", RuleRequired, "is required")
+	}
+	v.required("option_type", r.OptionType)
+	v.oneOf("option_type", r.OptionType, optionTypes...)
+	return v.err()
+}
+
+// Validate checks a credential save request
+func (r *SaveAPICredentialsRequest) Validate() error {
+	v := &validator{}
+	v.required("api_key", r.APIKey)
+	v.required("secret_key", r.SecretKey)
+	return v.err()
+}
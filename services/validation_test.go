@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"futures-options/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// fieldRules returns the rule reported for each field of a validation error
+func fieldRules(t *testing.T, err error) map[string]string {
+	t.Helper()
+	rules := map[string]string{}
+	if err == nil {
+		return rules
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("err = %v, want a ValidationError", err)
+	}
+	for _, f := range validationErr.Fields {
+		rules[f.Field] = f.Rule
+	}
+	return rules
+}
+
+func TestAdvancedOrderClosePosition(t *testing.T) {
+	allowed := map[string]bool{
+		string(models.OrderTypeStopMarket):       true,
+		string(models.OrderTypeTakeProfitMarket): true,
+	}
+	for _, orderType := range advancedOrderType {
+		t.Run(orderType, func(t *testing.T) {
+			// Otherwise valid for its type, with the fields close_position makes redundant
+			req := &AdvancedOrderRequest{
+				Symbol:        "BTCUSDT",
+				Side:          "SELL",
+				OrderType:     orderType,
+				Quantity:      decimal.RequireFromString("0.5"),
+				Price:         decimal.NewFromInt(45000),
+				StopPrice:     decimal.NewFromInt(44000),
+				CallbackRate:  1,
+				ReduceOnly:    true,
+				ClosePosition: true,
+			}
+			rules := fieldRules(t, req.Validate())
+
+			if !allowed[orderType] {
+				if rules["close_position"] != RuleEnum {
+					t.Errorf("close_position not rejected on %s: %v", orderType, rules)
+				}
+				if !req.Quantity.Equal(decimal.RequireFromString("0.5")) || !req.ReduceOnly || len(req.Corrections) != 0 {
+					t.Errorf("blocked request was normalized: quantity %s reduce_only %v corrections %q", req.Quantity, req.ReduceOnly, req.Corrections)
+				}
+				return
+			}
+			if len(rules) != 0 {
+				t.Errorf("close_position rejected on %s: %v", orderType, rules)
+			}
+			if !req.Quantity.IsZero() || req.ReduceOnly || len(req.Corrections) != 2 {
+				t.Errorf("request not normalized: quantity %s reduce_only %v corrections %q", req.Quantity, req.ReduceOnly, req.Corrections)
+			}
+		})
+	}
+}
+
+func TestNormalizeClosePosition(t *testing.T) {
+	tests := []struct {
+		name            string
+		req             AdvancedOrderRequest
+		wantCorrections int
+	}{
+		{
+			name:            "quantity and reduce only dropped",
+			req:             AdvancedOrderRequest{OrderType: "STOP_MARKET", ClosePosition: true, Quantity: decimal.NewFromInt(1), ReduceOnly: true},
+			wantCorrections: 2,
+		},
+		{
+			name:            "quote quantity dropped",
+			req:             AdvancedOrderRequest{OrderType: "TAKE_PROFIT_MARKET", ClosePosition: true, QuoteQuantity: 500},
+			wantCorrections: 1,
+		},
+		{
+			name: "nothing to drop",
+			req:  AdvancedOrderRequest{OrderType: "STOP_MARKET", ClosePosition: true},
+		},
+		{
+			name: "without close position",
+			req:  AdvancedOrderRequest{OrderType: "STOP_MARKET", Quantity: decimal.NewFromInt(1), ReduceOnly: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			req.normalizeClosePosition()
+			if len(req.Corrections) != tt.wantCorrections {
+				t.Fatalf("corrections = %q, want %d", req.Corrections, tt.wantCorrections)
+			}
+			if !req.ClosePosition {
+				if !req.Quantity.Equal(tt.req.Quantity) || req.ReduceOnly != tt.req.ReduceOnly {
+					t.Errorf("order without close_position changed")
+				}
+				return
+			}
+			if !req.Quantity.IsZero() || req.QuoteQuantity != 0 || req.ReduceOnly {
+				t.Errorf("quantity %s quote %v reduce_only %v left next to close_position", req.Quantity, req.QuoteQuantity, req.ReduceOnly)
+			}
+		})
+	}
+}
+
+func TestCreateFuturesOrderRejectsInvalidRequests(t *testing.T) {
+	valid := func() CreateFuturesOrderRequest {
+		return CreateFuturesOrderRequest{Symbol: "BTCUSDT", Side: "BUY", OrderType: "LIMIT", Quantity: decimal.RequireFromString("0.01"), Price: decimal.NewFromInt(40000)}
+	}
+	tests := []struct {
+		name   string
+		modify func(r *CreateFuturesOrderRequest)
+		// want maps each rejected field to its rule
+		want map[string]string
+	}{
+		{"misspelled order type", func(r *CreateFuturesOrderRequest) { r.OrderType = "LIMTI" }, map[string]string{"order_type": RuleEnum}},
+		{"lower case order type", func(r *CreateFuturesOrderRequest) { r.OrderType = "limit" }, map[string]string{"order_type": RuleEnum}},
+		{"advanced order type", func(r *CreateFuturesOrderRequest) { r.OrderType = "STOP_MARKET" }, map[string]string{"order_type": RuleEnum}},
+		{"trailing stop", func(r *CreateFuturesOrderRequest) { r.OrderType = "TRAILING_STOP_MARKET" }, map[string]string{"order_type": RuleEnum}},
+		{"missing order type", func(r *CreateFuturesOrderRequest) { r.OrderType = "" }, map[string]string{"order_type": RuleRequired}},
+		{"unknown side", func(r *CreateFuturesOrderRequest) { r.Side = "LONG" }, map[string]string{"side": RuleEnum}},
+		{"missing side", func(r *CreateFuturesOrderRequest) { r.Side = "" }, map[string]string{"side": RuleRequired}},
+		{"limit without price", func(r *CreateFuturesOrderRequest) { r.Price = decimal.Zero }, map[string]string{"price": RulePositive}},
+		{"market with negative price", func(r *CreateFuturesOrderRequest) { r.OrderType, r.Price = "MARKET", decimal.NewFromInt(-1) }, map[string]string{"price": RulePositive}},
+		{"no quantity", func(r *CreateFuturesOrderRequest) { r.Quantity = decimal.Zero }, map[string]string{"quantity": RulePositive}},
+		{"quantity and quote quantity", func(r *CreateFuturesOrderRequest) { r.QuoteQuantity = 500 }, map[string]string{"quantity": RuleRange}},
+		{"coinm quote quantity", func(r *CreateFuturesOrderRequest) { r.Market, r.Quantity, r.QuoteQuantity = "coinm", decimal.Zero, 500 }, map[string]string{"quote_quantity": RuleEnum}},
+		{"fractional coinm contracts", func(r *CreateFuturesOrderRequest) { r.Market, r.Quantity = "coinm", decimal.RequireFromString("1.5") }, map[string]string{"quantity": RuleType}},
+		{"unknown market", func(r *CreateFuturesOrderRequest) { r.Market = "spot" }, map[string]string{"market": RuleEnum}},
+		{"leverage above the maximum", func(r *CreateFuturesOrderRequest) { r.Leverage = MaxLeverage + 1 }, map[string]string{"leverage": RuleRange}},
+		{"unknown position side", func(r *CreateFuturesOrderRequest) { r.PositionSide = "BOTH_WAYS" }, map[string]string{"position_side": RuleEnum}},
+		{"unknown side and type", func(r *CreateFuturesOrderRequest) { r.Side, r.OrderType = "buy", "STOP" }, map[string]string{"side": RuleEnum, "order_type": RuleEnum}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.modify(&req)
+			rules := fieldRules(t, req.Validate())
+			if len(rules) != len(tt.want) {
+				t.Errorf("rejected fields %v, want %v", rules, tt.want)
+			}
+			for field, rule := range tt.want {
+				if rules[field] != rule {
+					t.Errorf("%s rule = %q, want %q", field, rules[field], rule)
+				}
+			}
+
+			// The service checks the request itself, so nothing reaches Binance
+			s, mock, _ := newTestService(t)
+			if _, err := s.CreateFuturesOrder(context.Background(), &req); err == nil {
+				t.Fatal("CreateFuturesOrder accepted the request")
+			}
+			if calls := len(mock.Calls()); calls != 0 {
+				t.Errorf("Binance was called %d times", calls)
+			}
+		})
+	}
+
+	req := valid()
+	if err := req.Validate(); err != nil {
+		t.Errorf("valid request rejected: %v", err)
+	}
+}
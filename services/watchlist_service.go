@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// maxWatchlistSymbols keeps the watchlist stream within Binance's 200 streams per connection,
+// at two streams per symbol
+const maxWatchlistSymbols = 100
+
+var (
+	// ErrWatchlistSymbolNotFound is returned when removing a symbol that is not watched
+	ErrWatchlistSymbolNotFound = errors.New("symbol is not on the watchlist")
+	// ErrWatchlistFull is returned when adding a symbol beyond maxWatchlistSymbols
+	ErrWatchlistFull = fmt.Errorf("watchlist is limited to %d symbols", maxWatchlistSymbols)
+)
+
+// watchlistStream holds the latest market stream updates of the watched symbols
+type watchlistStream struct {
+	mu      sync.Mutex
+	mark    map[string]*binance.SymbolUpdate
+	ticker  map[string]*binance.SymbolUpdate
+	changed chan struct{} // signals the stream to resubscribe after the watchlist changed
+}
+
+// WatchlistRequest adds a symbol to the watchlist
+type WatchlistRequest struct {
+	Symbol string `json:"symbol"`
+}
+
+// Validate checks the request fields
+func (r *WatchlistRequest) Validate() error {
+	v := &validator{}
+	v.required("symbol", r.Symbol)
+	return v.err()
+}
+
+// WatchlistItem is a watched USDⓈ-M symbol with its latest market data and local activity.
+// Prices and funding come from the watchlist stream; until it delivers, the prices come from the
+// price cache and the 24h change and funding are left out.
+type WatchlistItem struct {
+	Symbol             string     `json:"symbol"`
+	AddedAt            time.Time  `json:"added_at"`
+	LastPrice          float64    `json:"last_price,omitempty"`
+	PriceChangePercent *float64   `json:"price_change_percent_24h,omitempty"`
+	MarkPrice          float64    `json:"mark_price,omitempty"`
+	FundingRate        *float64   `json:"funding_rate,omitempty"`
+	NextFundingTime    *time.Time `json:"next_funding_time,omitempty"`
+	Streamed           bool       `json:"streamed"`             // market data is from the live stream
+	UpdatedAt          *time.Time `json:"updated_at,omitempty"` // latest stream update
+	HasPosition        bool       `json:"has_position"`         // a stored open position, either side
+	OpenOrders         int        `json:"open_orders"`          // stored NEW or PARTIALLY_FILLED orders
+	Error              string     `json:"error,omitempty"`      // why a price is missing
+}
+
+// AddToWatchlist adds a USDⓈ-M symbol to the watchlist and resubscribes the watchlist stream.
+// It reports whether the symbol was added; a symbol already watched is returned unchanged.
+func (s *TradingService) AddToWatchlist(ctx context.Context, req *WatchlistRequest) (*models.WatchlistEntry, bool, error) {
+	symbol := strings.ToUpper(strings.TrimSpace(req.Symbol))
+	if _, err := s.api(ctx).GetSymbolFilters(ctx, symbol); err != nil {
+		if errors.Is(err, binance.ErrUnknownSymbol) {
+			v := &validator{}
+			v.add("symbol", RuleEnum, "is not a listed USDⓈ-M symbol")
+			return nil, false, v.err()
+		}
+		return nil, false, fmt.Errorf("failed to check symbol: %w", err)
+	}
+
+	entries, err := s.repos.Watchlist.List(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load watchlist: %w", err)
+	}
+	for _, e := range entries {
+		if e.Symbol == symbol {
+			return e, false, nil
+		}
+	}
+	if len(entries) >= maxWatchlistSymbols {
+		return nil, false, ErrWatchlistFull
+	}
+
+	entry := &models.WatchlistEntry{Symbol: symbol, AddedAt: time.Now()}
+	added, err := s.repos.Watchlist.Add(ctx, entry)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to add to watchlist: %w", err)
+	}
+	if added {
+		s.watchlistChanged()
+	}
+	return entry, added, nil
+}
+
+// RemoveFromWatchlist removes a symbol from the watchlist and resubscribes the watchlist stream
+func (s *TradingService) RemoveFromWatchlist(ctx context.Context, symbol string) error {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	removed, err := s.repos.Watchlist.Remove(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to remove from watchlist: %w", err)
+	}
+	if !removed {
+		return ErrWatchlistSymbolNotFound
+	}
+
+	s.watchlist.mu.Lock()
+	delete(s.watchlist.mark, symbol)
+	delete(s.watchlist.ticker, symbol)
+	s.watchlist.mu.Unlock()
+	s.watchlistChanged()
+	return nil
+}
+
+// GetWatchlist returns the watched symbols with their latest stream data, and whether each has
+// a stored position or open orders. Positions and orders are read in one pass each.
+func (s *TradingService) GetWatchlist(ctx context.Context) ([]*WatchlistItem, error) {
+	entries, err := s.repos.Watchlist.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load watchlist: %w", err)
+	}
+
+	positions := make(map[string]bool)
+	err = s.repos.Positions.Each(ctx, "FUTURES", "", func(p *models.Position) error {
+		if marketOf(p.Market) == models.MarketUSDM && p.Quantity != 0 {
+			positions[p.Symbol] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load positions: %w", err)
+	}
+	openOrders := make(map[string]int)
+	err = s.repos.FuturesOrders.EachOpen(ctx, func(o *models.FuturesOrder) error {
+		if marketOf(o.Market) == models.MarketUSDM {
+			openOrders[o.Symbol]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load open orders: %w", err)
+	}
+
+	items := make([]*WatchlistItem, len(entries))
+	for i, e := range entries {
+		item := &WatchlistItem{
+			Symbol:      e.Symbol,
+			AddedAt:     e.AddedAt,
+			HasPosition: positions[e.Symbol],
+			OpenOrders:  openOrders[e.Symbol],
+		}
+		s.fillWatchlistItem(ctx, item)
+		items[i] = item
+	}
+	return items, nil
+}
+
+// fillWatchlistItem sets the market data of item from the stream, falling back to the price
+// cache for prices the stream has not delivered yet
+func (s *TradingService) fillWatchlistItem(ctx context.Context, item *WatchlistItem) {
+	s.watchlist.mu.Lock()
+	mark := s.watchlist.mark[item.Symbol]
+	ticker := s.watchlist.ticker[item.Symbol]
+	s.watchlist.mu.Unlock()
+
+	updated := func(t time.Time) {
+		if item.UpdatedAt == nil || t.After(*item.UpdatedAt) {
+			item.UpdatedAt = &t
+		}
+	}
+	if mark != nil {
+		item.MarkPrice = mark.MarkPrice
+		item.FundingRate = &mark.FundingRate
+		if !mark.NextFundingTime.IsZero() {
+			item.NextFundingTime = &mark.NextFundingTime
+		}
+		updated(mark.Time)
+	}
+	if ticker != nil {
+		item.LastPrice = ticker.LastPrice
+		item.PriceChangePercent = &ticker.PriceChangePercent
+		updated(ticker.Time)
+	}
+	item.Streamed = mark != nil && ticker != nil
+
+	if mark == nil {
+		if price, err := s.api(ctx).GetPrice(ctx, item.Symbol, futures.WorkingTypeMarkPrice, 0); err == nil {
+			item.MarkPrice = price.Value
+		} else {
+			item.Error = err.Error()
+		}
+	}
+	if ticker == nil {
+		if price, err := s.api(ctx).GetPrice(ctx, item.Symbol, futures.WorkingTypeContractPrice, 0); err == nil {
+			item.LastPrice = price.Value
+		} else {
+			item.Error = err.Error()
+		}
+	}
+}
+
+// StartWatchlist keeps the mark price and 24h ticker streams of the watched symbols subscribed
+// until ctx is done, resubscribing whenever the watchlist changes. The streams also keep the
+// price cache warm for those symbols.
+func (s *TradingService) StartWatchlist(ctx context.Context) {
+	s.runBackground(func() {
+		for ctx.Err() == nil {
+			symbols, err := s.watchedSymbols(ctx)
+			if err != nil {
+				slog.Warn("failed to load watchlist", "error", err)
+			}
+			if len(symbols) == 0 {
+				// Nothing to stream until a symbol is added (or the watchlist can be read again)
+				s.waitWatchlist(ctx, err != nil)
+				continue
+			}
+
+			streamCtx, cancel := context.WithCancel(ctx)
+			done := make(chan error, 1)
+			go func() {
+				done <- s.binanceClient.SubscribeSymbols(streamCtx, symbols, s.observeWatchlist)
+			}()
+			select {
+			case <-s.watchlist.changed:
+				cancel()
+				<-done
+			case err := <-done:
+				cancel()
+				if err != nil {
+					slog.Warn("watchlist stream failed, reconnecting", "error", err)
+				}
+				s.waitWatchlist(ctx, true)
+			case <-ctx.Done():
+				cancel()
+				<-done
+			}
+		}
+	})
+}
+
+// waitWatchlist blocks until the watchlist changes or ctx is done, or with retry until
+// priceStreamRetryDelay has passed
+func (s *TradingService) waitWatchlist(ctx context.Context, retry bool) {
+	var timeout <-chan time.Time
+	if retry {
+		timeout = time.After(priceStreamRetryDelay)
+	}
+	select {
+	case <-s.watchlist.changed:
+	case <-timeout:
+	case <-ctx.Done():
+	}
+}
+
+func (s *TradingService) watchedSymbols(ctx context.Context) ([]string, error) {
+	entries, err := s.repos.Watchlist.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	symbols := make([]string, len(entries))
+	for i, e := range entries {
+		symbols[i] = e.Symbol
+	}
+	return symbols, nil
+}
+
+func (s *TradingService) observeWatchlist(update *binance.SymbolUpdate) {
+	s.watchlist.mu.Lock()
+	defer s.watchlist.mu.Unlock()
+	if update.IsMark() {
+		if s.watchlist.mark == nil {
+			s.watchlist.mark = make(map[string]*binance.SymbolUpdate)
+		}
+		s.watchlist.mark[update.Symbol] = update
+		return
+	}
+	if s.watchlist.ticker == nil {
+		s.watchlist.ticker = make(map[string]*binance.SymbolUpdate)
+	}
+	s.watchlist.ticker[update.Symbol] = update
+}
+
+// watchlistChanged signals the watchlist stream to resubscribe
+func (s *TradingService) watchlistChanged() {
+	select {
+	case s.watchlist.changed <- struct{}{}:
+	default:
+	}
+}
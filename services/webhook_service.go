@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"futures-options/models"
+	"futures-options/notifications"
+	"futures-options/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// webhookQueueSize bounds the events waiting for delivery; further events are dropped
+	webhookQueueSize = 1000
+	// webhookMaxBackoff caps the delay between delivery attempts
+	webhookMaxBackoff = 5 * time.Minute
+	// defaultDeadLetterLimit is the number of dead letters listed
+	defaultDeadLetterLimit = 100
+)
+
+// ErrWebhookNotFound is returned when no webhook matches the given ID
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// WebhookOptions configures webhook delivery
+type WebhookOptions struct {
+	MaxAttempts int           // attempts before an event is dead-lettered
+	BaseDelay   time.Duration // first retry delay, doubled per attempt
+	Timeout     time.Duration // per-attempt HTTP timeout
+}
+
+// CreateWebhookRequest registers a callback URL
+type CreateWebhookRequest struct {
+	URL string `json:"url"`
+	// Secret is the HMAC-SHA256 key for the signature header; a random one is generated when omitted
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types,omitempty"` // empty subscribes to every event
+}
+
+// Validate checks the request fields
+func (r *CreateWebhookRequest) Validate() error {
+	v := &validator{}
+	v.required("url", r.URL)
+	if r.URL != "" {
+		if u, err := url.Parse(r.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.add("url", RuleType, "must be an absolute http or https URL")
+		}
+	}
+	for _, t := range r.EventTypes {
+		v.oneOf("event_types", t, notifications.EventTypes...)
+	}
+	return v.err()
+}
+
+// CreatedWebhook is returned once on creation and includes the signing secret
+type CreatedWebhook struct {
+	*models.Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookTestResult reports the outcome of a single test delivery
+type WebhookTestResult struct {
+	EventID    string `json:"event_id"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// WebhookDispatcher is a notifier that posts signed events to the registered webhooks in the
+// background, retrying failed deliveries with exponential backoff
+type WebhookDispatcher struct {
+	repo    repository.WebhookRepo
+	decrypt func(string) (string, error)
+	client  *http.Client
+	opts    WebhookOptions
+
+	events   chan *notifications.WebhookEvent
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+	inflight sync.WaitGroup
+}
+
+// EnableWebhooks starts webhook delivery and registers it as a notifier. Call it after SetCipher;
+// Close the returned dispatcher on shutdown.
+func (s *TradingService) EnableWebhooks(opts WebhookOptions) *WebhookDispatcher {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+	d := &WebhookDispatcher{
+		repo:    s.repos.Webhooks,
+		decrypt: s.decryptSecret,
+		client:  &http.Client{Timeout: opts.Timeout},
+		opts:    opts,
+		events:  make(chan *notifications.WebhookEvent, webhookQueueSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go d.run()
+	s.webhooks = d
+	s.AddNotifier(d)
+	return d
+}
+
+// Name returns the notifier name
+func (d *WebhookDispatcher) Name() string {
+	return "webhook"
+}
+
+// Notify queues n for delivery without blocking; events are dropped (and logged) when the queue is full
+func (d *WebhookDispatcher) Notify(ctx context.Context, n *notifications.Notification) error {
+	if n.EventType == "" {
+		return nil
+	}
+	event := newWebhookEvent(n)
+	select {
+	case d.events <- event:
+		return nil
+	default:
+		return fmt.Errorf("webhook queue full, dropping %s event", n.EventType)
+	}
+}
+
+// Close stops accepting events and waits for queued and in-flight deliveries; deliveries still
+// waiting to retry are dead-lettered instead
+func (d *WebhookDispatcher) Close(ctx context.Context) error {
+	d.once.Do(func() {
+		close(d.stop)
+		close(d.events)
+	})
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("webhook deliveries not finished: %w", ctx.Err())
+	}
+}
+
+func (d *WebhookDispatcher) run() {
+	defer close(d.done)
+	for event := range d.events {
+		d.dispatch(event)
+	}
+	d.inflight.Wait()
+}
+
+// dispatch starts a delivery of event to every webhook subscribed to its type
+func (d *WebhookDispatcher) dispatch(event *notifications.WebhookEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	webhooks, err := d.repo.List(ctx)
+	cancel()
+	if err != nil {
+		slog.Error("failed to load webhooks", "event_type", event.EventType, "error", err)
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("failed to encode webhook event", "event_type", event.EventType, "error", err)
+		return
+	}
+	for _, w := range webhooks {
+		if !subscribed(w, event.EventType) {
+			continue
+		}
+		w := w
+		d.inflight.Add(1)
+		go func() {
+			defer d.inflight.Done()
+			d.deliver(w, event, body)
+		}()
+	}
+}
+
+// deliver posts body to w until it succeeds or the attempts run out, then records a dead letter
+func (d *WebhookDispatcher) deliver(w *models.Webhook, event *notifications.WebhookEvent, body []byte) {
+	secret, err := d.decrypt(w.Secret)
+	if err != nil {
+		d.deadLetter(w, event, body, 0, 0, err)
+		return
+	}
+
+	delay := d.opts.BaseDelay
+	var status int
+	for attempt := 1; ; attempt++ {
+		status, err = notifications.PostWebhook(context.Background(), d.client, w.URL, secret, event, body)
+		if err == nil {
+			return
+		}
+		if attempt >= d.opts.MaxAttempts {
+			d.deadLetter(w, event, body, attempt, status, err)
+			return
+		}
+		slog.Warn("webhook delivery failed, retrying", "webhook_id", w.ID.Hex(), "event_type", event.EventType,
+			"attempt", attempt, "retry_in", delay, "error", err)
+
+		select {
+		case <-d.stop:
+			d.deadLetter(w, event, body, attempt, status, fmt.Errorf("%w (shutting down before retry)", err))
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > webhookMaxBackoff {
+			delay = webhookMaxBackoff
+		}
+	}
+}
+
+func (d *WebhookDispatcher) deadLetter(w *models.Webhook, event *notifications.WebhookEvent, body []byte, attempts, status int, err error) {
+	slog.Error("webhook delivery abandoned", "webhook_id", w.ID.Hex(), "event_type", event.EventType, "attempts", attempts, "error", err)
+	letter := &models.WebhookDeadLetter{
+		WebhookID:  w.ID,
+		URL:        w.URL,
+		EventID:    event.ID,
+		EventType:  event.EventType,
+		Payload:    string(body),
+		Attempts:   attempts,
+		LastStatus: status,
+		LastError:  err.Error(),
+		CreatedAt:  time.Now(),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.repo.InsertDeadLetter(ctx, letter); err != nil {
+		slog.Error("failed to record webhook dead letter", "webhook_id", w.ID.Hex(), "error", err)
+	}
+}
+
+func subscribed(w *models.Webhook, eventType string) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range w.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func newWebhookEvent(n *notifications.Notification) *notifications.WebhookEvent {
+	createdAt := n.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	return &notifications.WebhookEvent{
+		ID:        primitive.NewObjectID().Hex(),
+		EventType: n.EventType,
+		Title:     n.Title,
+		Message:   n.Message,
+		Priority:  n.Priority,
+		Fields:    n.Fields,
+		CreatedAt: createdAt,
+	}
+}
+
+// CreateWebhook registers a webhook; the secret is only returned in this response
+func (s *TradingService) CreateWebhook(ctx context.Context, req *CreateWebhookRequest) (*CreatedWebhook, error) {
+	req.URL = strings.TrimSpace(req.URL)
+	for i, t := range req.EventTypes {
+		req.EventTypes[i] = strings.ToUpper(strings.TrimSpace(t))
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		secret = hex.EncodeToString(raw)
+	}
+	stored, err := s.encryptSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &models.Webhook{
+		ID:         primitive.NewObjectID(),
+		URL:        req.URL,
+		Secret:     stored,
+		EventTypes: req.EventTypes,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.repos.Webhooks.Insert(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to save webhook: %w", err)
+	}
+	return &CreatedWebhook{Webhook: webhook, Secret: secret}, nil
+}
+
+// ListWebhooks returns the registered webhooks without their secrets
+func (s *TradingService) ListWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	return s.repos.Webhooks.List(ctx)
+}
+
+// DeleteWebhook removes a webhook; events already queued for it are still delivered
+func (s *TradingService) DeleteWebhook(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	deleted, err := s.repos.Webhooks.Delete(ctx, objectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if !deleted {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// TestWebhook sends a sample TEST event to one webhook once, without retries, and reports the response
+func (s *TradingService) TestWebhook(ctx context.Context, id string) (*WebhookTestResult, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	webhook, err := s.repos.Webhooks.FindByID(ctx, objectID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	secret, err := s.decryptSecret(webhook.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	event := newWebhookEvent(&notifications.Notification{
+		Title:     "Test event",
+		Message:   "Sample event sent from POST /api/notifications/webhooks/{id}/test",
+		Priority:  notifications.PriorityLow,
+		EventType: notifications.EventTest,
+		Fields:    map[string]string{"webhook_id": id},
+	})
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	client := http.DefaultClient
+	if s.webhooks != nil {
+		client = s.webhooks.client
+	}
+	start := time.Now()
+	status, err := notifications.PostWebhook(ctx, client, webhook.URL, secret, event, body)
+	result := &WebhookTestResult{EventID: event.ID, StatusCode: status, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, nil
+}
+
+// ListWebhookDeadLetters returns the most recent events that could not be delivered
+func (s *TradingService) ListWebhookDeadLetters(ctx context.Context, limit int64) ([]*models.WebhookDeadLetter, error) {
+	if limit <= 0 || limit > repository.MaxOrderPageLimit {
+		limit = defaultDeadLetterLimit
+	}
+	return s.repos.Webhooks.ListDeadLetters(ctx, limit)
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+
+	"futures-options/binance"
+)
+
+// WebSocketStatus describes the service's connections to Binance's WebSocket endpoints
+type WebSocketStatus struct {
+	UserDataStreamConnected bool                `json:"user_data_stream_connected"`
+	WSAPI                   binance.WSAPIStatus `json:"ws_api"`
+}
+
+// WebSocketStatus reports whether the user data stream is connected, and the uptime and reconnect
+// count of the pooled WS-API connection. The WS-API connects on its first request.
+func (s *TradingService) WebSocketStatus() *WebSocketStatus {
+	status := &WebSocketStatus{UserDataStreamConnected: s.UserDataStreamConnected()}
+	s.wsAPIMu.Lock()
+	ws := s.wsAPI
+	s.wsAPIMu.Unlock()
+	if ws != nil {
+		status.WSAPI = ws.Status()
+	}
+	return status
+}
+
+// wsAPIClient returns the pooled WS-API client, connecting it on first use. It keeps its
+// connection alive and replaces it when it dies, so requests share one connection.
+func (s *TradingService) wsAPIClient(ctx context.Context) (*binance.WSAPIClient, error) {
+	s.wsAPIMu.Lock()
+	defer s.wsAPIMu.Unlock()
+	if s.wsAPI == nil {
+		ws, err := s.newWSAPIClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.wsAPI = ws
+	}
+	return s.wsAPI, nil
+}
+
+// resetWSAPIClient closes the pooled WS-API client, so the next request connects with the
+// current credential, network and Ed25519 key
+func (s *TradingService) resetWSAPIClient() {
+	s.wsAPIMu.Lock()
+	ws := s.wsAPI
+	s.wsAPI = nil
+	s.wsAPIMu.Unlock()
+	if ws != nil {
+		ws.Close()
+	}
+}
@@ -0,0 +1,303 @@
+package strategy
+
+import (
+	"math"
+	"time"
+
+	"futures-options/binance"
+	"futures-options/models"
+)
+
+// The backtester replays a strategy over klines with a deliberately simple fill model:
+//
+//   - A candle is walked open → low → high → close when it closes up (or flat), and
+//     open → high → low → close when it closes down.
+//   - A limit order fills at its own price as soon as that walk touches it (touch = fill); there
+//     is no queue position, partial fill or slippage.
+//   - Market orders (a LONG or SHORT grid's initial position, DCA orders) fill at the candle's
+//     open, or, for price step DCA orders, at the step price when the walk reaches it.
+//   - Every fill pays CommissionRate on its notional. Funding is ignored.
+//   - Trades are stamped with the open time of the candle they fill in.
+
+// Reasons of backtest trades
+const (
+	BacktestInitialPosition = "initial_position" // market order opening a LONG or SHORT grid
+	BacktestGridFill        = "grid"             // grid limit order
+	BacktestDCAOrder        = "dca"              // DCA market order
+	BacktestTakeProfit      = "take_profit"      // DCA take profit limit order
+)
+
+// BacktestConfig is the market a backtest trades in
+type BacktestConfig struct {
+	Filters        *binance.SymbolFilters // tick and lot sizes orders are rounded to
+	CommissionRate float64                // fraction of each fill's notional paid as commission
+}
+
+// BacktestTrade is one simulated fill
+type BacktestTrade struct {
+	Time       time.Time `json:"time"`
+	Side       string    `json:"side"`
+	Price      float64   `json:"price"`
+	Quantity   float64   `json:"quantity"`
+	Commission float64   `json:"commission"`
+	// RealizedPnL is the PnL of the quantity the fill closed against the average entry, before
+	// commission
+	RealizedPnL float64 `json:"realized_pnl,omitempty"`
+	Reason      string  `json:"reason"`
+	Grid        *int    `json:"grid,omitempty"` // index of the grid whose order filled
+}
+
+// BacktestResult is the outcome of replaying a strategy. Amounts are in the quote asset.
+type BacktestResult struct {
+	Candles    int              `json:"candles"`
+	From       time.Time        `json:"from"` // open time of the first candle
+	To         time.Time        `json:"to"`   // close time of the last candle
+	StartPrice float64          `json:"start_price"`
+	EndPrice   float64          `json:"end_price"`
+	Trades     []*BacktestTrade `json:"trades"`
+
+	GrossRealizedPnL float64 `json:"gross_realized_pnl"` // before commission
+	Commission       float64 `json:"commission"`
+	RealizedProfit   float64 `json:"realized_profit"` // gross realized PnL less commission
+	UnrealizedPnL    float64 `json:"unrealized_pnl"`  // of the final position at the end price
+	NetProfit        float64 `json:"net_profit"`      // realized profit plus unrealized PnL
+	// MaxDrawdown is the largest fall of net profit from its running peak, measured at every
+	// point of the candle walk
+	MaxDrawdown   float64    `json:"max_drawdown"`
+	MaxDrawdownAt *time.Time `json:"max_drawdown_at,omitempty"`
+	FinalPosition float64    `json:"final_position"` // negative when short
+	AvgEntryPrice float64    `json:"avg_entry_price,omitempty"`
+
+	// The strategy's state at the end of the replay
+	Grid *models.GridStrategy `json:"grid,omitempty"`
+	DCA  *models.DCAPlan      `json:"dca,omitempty"`
+}
+
+// BacktestGrid replays st over klines, which must be in time order and not empty. The grids are
+// laid out around the first open, as CreateGridStrategy lays them out around the mark price.
+func BacktestGrid(st *models.GridStrategy, klines []*models.Kline, cfg BacktestConfig) *BacktestResult {
+	l := newLedger(klines, cfg)
+	first := klines[0]
+	st.StartPrice = first.Open
+	if held := LayoutGrids(st, cfg.Filters.TickSize, first.Open); held > 0 {
+		side := models.OrderSideBuy
+		st.Position = held
+		if st.Direction == models.GridShort {
+			side = models.OrderSideSell
+			st.Position = -held
+		}
+		l.fill(first.OpenTime, string(side), first.Open, held, BacktestInitialPosition, nil)
+	}
+
+	fillGrid := func(t time.Time, g *models.Grid) {
+		side, price, index := g.Side, GridOrderPrice(g), g.Index
+		ApplyGridFill(st, g)
+		l.fill(t, side, price, st.Quantity, BacktestGridFill, &index)
+	}
+	for _, k := range klines {
+		path := candlePath(k)
+		for i := 1; i < len(path); i++ {
+			from, to := path[i-1], path[i]
+			switch {
+			case to < from:
+				// Falling: buys fill from the highest down
+				for j := len(st.Grids) - 1; j >= 0; j-- {
+					if g := &st.Grids[j]; g.Side == string(models.OrderSideBuy) && touches(g.LowerPrice, from, to) {
+						fillGrid(k.OpenTime, g)
+					}
+				}
+			case to > from:
+				// Rising: sells fill from the lowest up
+				for j := range st.Grids {
+					if g := &st.Grids[j]; g.Side == string(models.OrderSideSell) && touches(g.UpperPrice, from, to) {
+						fillGrid(k.OpenTime, g)
+					}
+				}
+			}
+			l.mark(k.OpenTime, to)
+		}
+	}
+
+	result := l.finish()
+	result.Grid = st
+	return result
+}
+
+// BacktestDCA replays p over klines, which must be in time order and not empty. Interval plans
+// order at the open of the first candle and then of the first candle at least an interval later;
+// price step plans order at the first open and then each time the walk moves a step against the
+// last fill. The take profit, when set, fills when the walk reaches it and completes the plan.
+func BacktestDCA(p *models.DCAPlan, klines []*models.Kline, cfg BacktestConfig) *BacktestResult {
+	l := newLedger(klines, cfg)
+	interval := time.Duration(p.IntervalSeconds) * time.Second
+	exit := models.OrderSideSell
+	if p.Side == models.OrderSideSell {
+		exit = models.OrderSideBuy
+	}
+
+	// order fills a DCA order at price and reports whether it did; a budget too small for the
+	// symbol's minimum order caps the plan instead
+	order := func(t time.Time, price float64) bool {
+		quantity := DCAOrderQuantity(p, price, cfg.Filters)
+		if quantity <= 0 {
+			p.Status = models.DCACapped
+			return false
+		}
+		l.fill(t, string(p.Side), price, quantity, BacktestDCAOrder, nil)
+		p.Exposure += quantity * price
+		p.FilledQuantity += quantity
+		p.AvgEntryPrice = p.Exposure / p.FilledQuantity
+		p.LastFillPrice = price
+		p.RemainingBudget = math.Max(0, p.MaxExposure-p.Exposure)
+		return true
+	}
+
+	var next time.Time
+	for _, k := range klines {
+		if p.Status == models.DCACompleted {
+			break
+		}
+		if p.Status == models.DCAActive {
+			if interval > 0 && !k.OpenTime.Before(next) {
+				order(k.OpenTime, k.Open)
+				next = k.OpenTime.Add(interval)
+			} else if interval <= 0 && p.LastFillPrice == 0 {
+				order(k.OpenTime, k.Open)
+			}
+		}
+
+		path := candlePath(k)
+		for i := 1; i < len(path) && p.Status != models.DCACompleted; i++ {
+			from, to := path[i-1], path[i]
+			against := (to < from) == (p.Side == models.OrderSideBuy)
+			if to != from && against {
+				for p.Status == models.DCAActive {
+					trigger, ok := DCAStepPrice(p)
+					if !ok || !touches(trigger, from, to) || !order(k.OpenTime, trigger) {
+						break
+					}
+				}
+			} else if open := p.FilledQuantity - p.ClosedQuantity; to != from && p.TakeProfitPercent > 0 && open > 0 {
+				p.TakeProfitPrice = DCATakeProfitPrice(p, cfg.Filters.TickSize)
+				if touches(p.TakeProfitPrice, from, to) {
+					trade := l.fill(k.OpenTime, string(exit), p.TakeProfitPrice, open, BacktestTakeProfit, nil)
+					p.ClosedQuantity += open
+					p.RealizedPnL += trade.RealizedPnL
+					p.Status = models.DCACompleted
+				}
+			}
+			l.mark(k.OpenTime, to)
+		}
+	}
+
+	result := l.finish()
+	if open := p.FilledQuantity - p.ClosedQuantity; open > 0 {
+		p.MarkPrice = result.EndPrice
+		p.UnrealizedPnL = (result.EndPrice - p.AvgEntryPrice) * open * DCADirection(p)
+	}
+	result.DCA = p
+	return result
+}
+
+// ledger tracks the simulated position with average cost accounting, and the drawdown of its
+// net profit
+type ledger struct {
+	rate     float64
+	result   *BacktestResult
+	position float64 // negative when short
+	avgEntry float64
+	peak     float64
+}
+
+func newLedger(klines []*models.Kline, cfg BacktestConfig) *ledger {
+	return &ledger{
+		rate: cfg.CommissionRate,
+		result: &BacktestResult{
+			Candles:    len(klines),
+			From:       klines[0].OpenTime,
+			To:         klines[len(klines)-1].CloseTime,
+			StartPrice: klines[0].Open,
+			EndPrice:   klines[len(klines)-1].Close,
+			Trades:     []*BacktestTrade{},
+		},
+	}
+}
+
+// fill books a trade of quantity at price, closing against the position before opening
+func (l *ledger) fill(t time.Time, side string, price, quantity float64, reason string, grid *int) *BacktestTrade {
+	trade := &BacktestTrade{
+		Time:       t,
+		Side:       side,
+		Price:      price,
+		Quantity:   quantity,
+		Commission: price * quantity * l.rate,
+		Reason:     reason,
+		Grid:       grid,
+	}
+	signed := quantity
+	if side == string(models.OrderSideSell) {
+		signed = -quantity
+	}
+
+	if l.position != 0 && (l.position > 0) != (signed > 0) {
+		closed := math.Min(math.Abs(l.position), quantity)
+		direction := 1.0
+		if l.position < 0 {
+			direction = -1
+		}
+		trade.RealizedPnL = (price - l.avgEntry) * closed * direction
+		l.position -= closed * direction
+		if math.Abs(l.position) < 1e-12 {
+			l.position, l.avgEntry = 0, 0
+		}
+		if opened := quantity - closed; opened > 1e-12 {
+			l.position, l.avgEntry = math.Copysign(opened, signed), price
+		}
+	} else {
+		size := math.Abs(l.position)
+		l.avgEntry = (l.avgEntry*size + price*quantity) / (size + quantity)
+		l.position += signed
+	}
+
+	l.result.GrossRealizedPnL += trade.RealizedPnL
+	l.result.Commission += trade.Commission
+	l.result.Trades = append(l.result.Trades, trade)
+	l.mark(t, price)
+	return trade
+}
+
+// mark values the position at price and updates the maximum drawdown
+func (l *ledger) mark(t time.Time, price float64) {
+	equity := l.result.GrossRealizedPnL - l.result.Commission + (price-l.avgEntry)*l.position
+	if equity > l.peak {
+		l.peak = equity
+	}
+	if drawdown := l.peak - equity; drawdown > l.result.MaxDrawdown {
+		l.result.MaxDrawdown = drawdown
+		l.result.MaxDrawdownAt = &t
+	}
+}
+
+// finish values the final position at the last close
+func (l *ledger) finish() *BacktestResult {
+	r := l.result
+	r.RealizedProfit = r.GrossRealizedPnL - r.Commission
+	r.UnrealizedPnL = (r.EndPrice - l.avgEntry) * l.position
+	r.NetProfit = r.RealizedProfit + r.UnrealizedPnL
+	r.FinalPosition = l.position
+	r.AvgEntryPrice = l.avgEntry
+	return r
+}
+
+// candlePath is the order in which the fill model assumes a candle visited its prices
+func candlePath(k *models.Kline) []float64 {
+	if k.Close >= k.Open {
+		return []float64{k.Open, k.Low, k.High, k.Close}
+	}
+	return []float64{k.Open, k.High, k.Low, k.Close}
+}
+
+// touches reports whether price lies on the move from from to to
+func touches(price, from, to float64) bool {
+	return price >= math.Min(from, to) && price <= math.Max(from, to)
+}
@@ -0,0 +1,72 @@
+package strategy
+
+import (
+	"math"
+
+	"futures-options/binance"
+	"futures-options/models"
+)
+
+// DCADirection is 1 for plans building a long and -1 for shorts
+func DCADirection(p *models.DCAPlan) float64 {
+	if p.Side == models.OrderSideSell {
+		return -1
+	}
+	return 1
+}
+
+// DCAStepPrice returns the price at which a price step plan places its next order: a step
+// below its last fill for buys, above it for sells. ok is false for interval plans and before
+// the first fill.
+func DCAStepPrice(p *models.DCAPlan) (price float64, ok bool) {
+	if p.PriceStepPercent <= 0 || p.LastFillPrice <= 0 {
+		return 0, false
+	}
+	step := p.LastFillPrice * p.PriceStepPercent / 100
+	if p.Side == models.OrderSideSell {
+		return p.LastFillPrice + step, true
+	}
+	return p.LastFillPrice - step, true
+}
+
+// DCAStepReached reports whether mark has moved a step against p's last fill
+func DCAStepReached(p *models.DCAPlan, mark float64) bool {
+	trigger, ok := DCAStepPrice(p)
+	if !ok {
+		return false
+	}
+	if p.Side == models.OrderSideSell {
+		return mark >= trigger
+	}
+	return mark <= trigger
+}
+
+// DCAOrderQuantity sizes p's next market order at mark, shrunk to the budget left under its max
+// exposure and rounded to the symbol's market lot size. It returns 0 when the remaining budget
+// is too small for the symbol's minimum order, which caps the plan.
+func DCAOrderQuantity(p *models.DCAPlan, mark float64, filters *binance.SymbolFilters) float64 {
+	step, minQty, maxQty := filters.StepSize, filters.MinQty, filters.MaxQty
+	if filters.MarketStepSize > 0 {
+		step, minQty, maxQty = filters.MarketStepSize, filters.MarketMinQty, filters.MarketMaxQty
+	}
+	budget := p.MaxExposure - p.Exposure
+	quantity := p.OrderQuantity
+	if p.OrderNotional > 0 {
+		quantity = math.Min(p.OrderNotional, budget) / mark
+	} else if quantity*mark > budget {
+		quantity = budget / mark
+	}
+	quantity = RoundFloatToStep(quantity, step, RoundDown)
+	if maxQty > 0 && quantity > maxQty {
+		quantity = maxQty
+	}
+	if quantity <= 0 || quantity < minQty || quantity*mark < filters.MinNotional {
+		return 0
+	}
+	return quantity
+}
+
+// DCATakeProfitPrice is the take profit percent away from p's blended entry, rounded to tickSize
+func DCATakeProfitPrice(p *models.DCAPlan, tickSize float64) float64 {
+	return RoundFloatToStep(p.AvgEntryPrice*(1+DCADirection(p)*p.TakeProfitPercent/100), tickSize, RoundNearest)
+}
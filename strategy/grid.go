@@ -0,0 +1,102 @@
+// Package strategy holds the order logic of the grid and DCA strategies that does not depend on
+// an exchange: laying out grid levels, booking grid fills and sizing DCA orders. The live runners
+// in services and the backtester share it, so a backtest places the same orders a live run would.
+package strategy
+
+import (
+	"futures-options/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// LayoutGrids splits st's range into grids. Grids below price wait to buy and those above to sell;
+// LONG strategies start out holding the grids above and SHORT ones those below, which is the
+// quantity their initial position needs, returned as held.
+func LayoutGrids(st *models.GridStrategy, tickSize, price float64) (held float64) {
+	spacing := (st.UpperPrice - st.LowerPrice) / float64(st.GridCount)
+	st.Grids = make([]models.Grid, st.GridCount)
+	for i := range st.Grids {
+		g := &st.Grids[i]
+		g.Index = i
+		g.LowerPrice = RoundFloatToStep(st.LowerPrice+float64(i)*spacing, tickSize, RoundNearest)
+		g.UpperPrice = RoundFloatToStep(st.LowerPrice+float64(i+1)*spacing, tickSize, RoundNearest)
+
+		switch {
+		case g.UpperPrice <= price:
+			g.Side = string(models.OrderSideBuy)
+			g.Holding = st.Direction == models.GridShort
+		case g.LowerPrice >= price:
+			g.Side = string(models.OrderSideSell)
+			g.Holding = st.Direction == models.GridLong
+		case st.Direction == models.GridShort:
+			// The grid around the price opens in the strategy's direction
+			g.Side = string(models.OrderSideSell)
+		default:
+			g.Side = string(models.OrderSideBuy)
+		}
+		if g.Holding {
+			held += st.Quantity
+		}
+	}
+	return held
+}
+
+// GridOrderPrice is the limit price of g's order: its lower price for a buy, its upper for a sell
+func GridOrderPrice(g *models.Grid) float64 {
+	if g.Side == string(models.OrderSideSell) {
+		return g.UpperPrice
+	}
+	return g.LowerPrice
+}
+
+// ApplyGridFill books the fill of g's order on st and turns g to the opposite side. A fill that
+// closes what the grid's previous fill opened earns the grid's spread, which is returned.
+func ApplyGridFill(st *models.GridStrategy, g *models.Grid) (profit float64) {
+	st.FillCount++
+	g.Fills++
+	if g.Side == string(models.OrderSideBuy) {
+		st.Position += st.Quantity
+	} else {
+		st.Position -= st.Quantity
+	}
+	if g.Holding {
+		profit = (g.UpperPrice - g.LowerPrice) * st.Quantity
+		g.Profit += profit
+		g.RoundTrips++
+		st.RealizedProfit += profit
+	}
+	g.Holding = !g.Holding
+
+	if g.Side == string(models.OrderSideBuy) {
+		g.Side = string(models.OrderSideSell)
+	} else {
+		g.Side = string(models.OrderSideBuy)
+	}
+	return profit
+}
+
+// RoundToStep rounds value to a multiple of step with round (RoundDown, RoundNearest); a step
+// that is not positive leaves value unchanged. An exact multiple stays as it is and the result has
+// no more decimals than step.
+func RoundToStep(value, step decimal.Decimal, round func(decimal.Decimal) decimal.Decimal) decimal.Decimal {
+	if !step.IsPositive() {
+		return value
+	}
+	return round(value.Div(step)).Mul(step)
+}
+
+// RoundFloatToStep is RoundToStep for the float64 amounts of stored plans and grids; only the
+// rounded result is converted back to a float
+func RoundFloatToStep(value, step float64, round func(decimal.Decimal) decimal.Decimal) float64 {
+	return RoundToStep(decimal.NewFromFloat(value), decimal.NewFromFloat(step), round).InexactFloat64()
+}
+
+// RoundDown rounds d down to a whole number of steps
+func RoundDown(d decimal.Decimal) decimal.Decimal {
+	return d.Floor()
+}
+
+// RoundNearest rounds d to the nearest whole number of steps, halves away from zero
+func RoundNearest(d decimal.Decimal) decimal.Decimal {
+	return d.Round(0)
+}
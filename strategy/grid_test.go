@@ -0,0 +1,38 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRoundToStep(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		step  string
+		round func(decimal.Decimal) decimal.Decimal
+		want  string
+	}{
+		{"exact multiple stays", "0.3", "0.1", RoundDown, "0.3"},
+		{"down drops the remainder", "1.23456", "0.001", RoundDown, "1.234"},
+		{"nearest rounds half up", "100.05", "0.1", RoundNearest, "100.1"},
+		{"tiny step", "0.000000019", "0.00000001", RoundDown, "0.00000001"},
+		{"zero step leaves value", "1.23456", "0", RoundDown, "1.23456"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RoundToStep(decimal.RequireFromString(tt.value), decimal.RequireFromString(tt.step), tt.round)
+			if !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("RoundToStep(%s, %s) = %s, want %s", tt.value, tt.step, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundFloatToStep(t *testing.T) {
+	// 0.3/0.1 is 2.9999999999999996 in float64, which math.Floor would take a step down
+	if got := RoundFloatToStep(0.3, 0.1, RoundDown); got != 0.3 {
+		t.Errorf("RoundFloatToStep(0.3, 0.1) = %v, want 0.3", got)
+	}
+}